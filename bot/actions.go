@@ -0,0 +1,233 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	applicationpkg "github.com/argoproj/argo-cd/pkg/apiclient/application"
+	"google.golang.org/grpc"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/approvals"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/silences"
+)
+
+const (
+	ActionSync    = "sync"
+	ActionRefresh = "refresh"
+	ActionSilence = "silence"
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+	ActionAck     = "ack"
+
+	refreshAnnotation = "argocd.argoproj.io/refresh"
+	refreshNormal     = "normal"
+)
+
+// Action is the application action requested using the interactive notification message, e.g. the Sync button
+type Action struct {
+	// Name is one of: sync|refresh|silence|approve|reject|ack
+	Name string
+	// App is the application name
+	App string
+	// Revision is the approved or synced revision; optional
+	Revision string
+	// Sync requests the application sync after the approval
+	Sync bool
+	// Trigger and Recipient narrow down the silenced or acknowledged notifications; optional
+	Trigger   string
+	Recipient string
+	// Duration is the silence duration
+	Duration time.Duration
+	// User identifies the user who requested the action, e.g. slack:U0123
+	User string
+	// Response is the adapter specific reference of the response destination; optional
+	Response interface{}
+}
+
+// ActionAdapter parses the interactive message actions of the notification service
+type ActionAdapter interface {
+	// Parses action request and returns an error if the user is not allowed to run the action
+	ParseAction(r *http.Request) (Action, error)
+	// Sends formatted action result
+	SendActionResponse(action Action, content string, w http.ResponseWriter)
+}
+
+// AppActions runs the application operations
+type AppActions interface {
+	// Sync starts the application sync on behalf of the user; the target revision is synced if the revision is empty
+	Sync(app string, revision string, user string) error
+	// Refresh requests the application refresh
+	Refresh(app string) error
+}
+
+// NewKubernetesAppActions returns actions which update application resource the same way as the Argo CD API server
+func NewKubernetesAppActions(appClient dynamic.ResourceInterface) AppActions {
+	return &kubernetesAppActions{appClient: appClient}
+}
+
+type kubernetesAppActions struct {
+	appClient dynamic.ResourceInterface
+}
+
+func (a *kubernetesAppActions) patch(app string, patch map[string]interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = a.appClient.Patch(app, types.MergePatchType, data, v1.PatchOptions{})
+	return err
+}
+
+func (a *kubernetesAppActions) Sync(app string, revision string, user string) error {
+	obj, err := a.appClient.Get(app, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if _, ok := obj.Object["operation"]; ok {
+		return errors.New("another operation is already in progress")
+	}
+	if revision == "" {
+		revision, _, _ = unstructured.NestedString(obj.Object, "spec", "source", "targetRevision")
+	}
+	return a.patch(app, map[string]interface{}{
+		"operation": map[string]interface{}{
+			"initiatedBy": map[string]interface{}{"username": user},
+			"sync":        map[string]interface{}{"revision": revision},
+		},
+	})
+}
+
+func (a *kubernetesAppActions) Refresh(app string) error {
+	return a.patch(app, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{refreshAnnotation: refreshNormal},
+		},
+	})
+}
+
+// NewArgoCDAppActions returns actions which use the Argo CD API server; the operations are authorized using the
+// token of the connection
+func NewArgoCDAppActions(conn *grpc.ClientConn) AppActions {
+	return &argocdAppActions{client: applicationpkg.NewApplicationServiceClient(conn)}
+}
+
+type argocdAppActions struct {
+	client applicationpkg.ApplicationServiceClient
+}
+
+func (a *argocdAppActions) Sync(app string, revision string, _ string) error {
+	_, err := a.client.Sync(context.Background(), &applicationpkg.ApplicationSyncRequest{Name: &app, Revision: revision})
+	return err
+}
+
+func (a *argocdAppActions) Refresh(app string) error {
+	refresh := refreshNormal
+	_, err := a.client.Get(context.Background(), &applicationpkg.ApplicationQuery{Name: &app, Refresh: &refresh})
+	return err
+}
+
+func (s *server) executeAction(action Action) (string, error) {
+	switch action.Name {
+	case ActionSync:
+		if err := s.appActions.Sync(action.App, action.Revision, action.User); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Sync of application %s is started.", action.App), nil
+	case ActionRefresh:
+		if err := s.appActions.Refresh(action.App); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Refresh of application %s is requested.", action.App), nil
+	case ActionSilence:
+		if action.Duration <= 0 {
+			return "", errors.New("silence duration must be positive")
+		}
+		silence, err := s.silences.Create(silences.Silence{
+			App:       action.App,
+			Trigger:   action.Trigger,
+			Recipient: action.Recipient,
+			ExpiresAt: time.Now().Add(action.Duration),
+			CreatedBy: action.User,
+		})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Notifications of application %s are silenced until %s.", action.App, silence.ExpiresAt.Format(time.RFC3339)), nil
+	case ActionApprove, ActionReject:
+		return s.decide(action)
+	case ActionAck:
+		if _, err := history.Acknowledge(s.history, "", action.App, action.Trigger, action.User); err != nil {
+			return "", err
+		}
+		if action.Trigger != "" {
+			return fmt.Sprintf("Notification %s of application %s is acknowledged.", action.Trigger, action.App), nil
+		}
+		return fmt.Sprintf("Notifications of application %s are acknowledged.", action.App), nil
+	default:
+		return "", fmt.Errorf("unknown action %s", action.Name)
+	}
+}
+
+// decide records the approval decision and starts the sync of the approved application if requested
+func (s *server) decide(action Action) (string, error) {
+	status := approvals.StatusApproved
+	if action.Name == ActionReject {
+		status = approvals.StatusRejected
+	}
+	_, err := s.approvals.Record(approvals.Approval{
+		App:       action.App,
+		Revision:  action.Revision,
+		Status:    status,
+		DecidedBy: action.User,
+		DecidedAt: time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+	subject := fmt.Sprintf("Application %s", action.App)
+	if action.Revision != "" {
+		subject = fmt.Sprintf("%s revision %s", subject, action.Revision)
+	}
+	response := fmt.Sprintf("%s is %s.", subject, strings.ToLower(status))
+	if status == approvals.StatusApproved && action.Sync {
+		if err := s.appActions.Sync(action.App, action.Revision, action.User); err != nil {
+			return "", fmt.Errorf("%s is approved but sync cannot be started: %v", subject, err)
+		}
+		response = fmt.Sprintf("%s Sync is started.", response)
+	}
+	return response, nil
+}
+
+func (s *server) ActionHandler(adapter ActionAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		action, err := adapter.ParseAction(r)
+		if err != nil {
+			adapter.SendActionResponse(action, err.Error(), w)
+			return
+		}
+		if res, err := s.executeAction(action); err != nil {
+			adapter.SendActionResponse(action, fmt.Sprintf("cannot execute action: %v", err), w)
+		} else {
+			adapter.SendActionResponse(action, res, w)
+		}
+	}
+}
+
+func (s *server) AddActionAdapter(pattern string, adapter ActionAdapter) {
+	s.mux.HandleFunc(pattern, s.ActionHandler(adapter))
+}
+
+// SetAppActions overrides the actions used to sync and refresh applications
+func (s *server) SetAppActions(actions AppActions) {
+	s.appActions = actions
+}
@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/approvals"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestExecuteAction_Sync(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", func(app *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(app.Object, "master", "spec", "source", "targetRevision")
+	}))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+	s := NewServer(client, TestNamespace)
+
+	response, err := s.executeAction(Action{Name: ActionSync, App: "foo", User: "slack:U123"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Sync of application foo is started.", response)
+	assert.Equal(t, []map[string]interface{}{{"operation": map[string]interface{}{
+		"initiatedBy": map[string]interface{}{"username": "slack:U123"},
+		"sync":        map[string]interface{}{"revision": "master"},
+	}}}, patches)
+}
+
+func TestExecuteAction_SyncInProgress(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", func(app *unstructured.Unstructured) {
+		app.Object["operation"] = map[string]interface{}{"sync": map[string]interface{}{}}
+	}))
+	s := NewServer(client, TestNamespace)
+
+	_, err := s.executeAction(Action{Name: ActionSync, App: "foo", User: "slack:U123"})
+
+	assert.EqualError(t, err, "another operation is already in progress")
+}
+
+func TestExecuteAction_Refresh(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo"))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+	s := NewServer(client, TestNamespace)
+
+	_, err := s.executeAction(Action{Name: ActionRefresh, App: "foo"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"metadata": map[string]interface{}{
+		"annotations": map[string]interface{}{"argocd.argoproj.io/refresh": "normal"},
+	}}}, patches)
+}
+
+func TestExecuteAction_Silence(t *testing.T) {
+	s := NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+
+	response, err := s.executeAction(Action{
+		Name: ActionSilence, App: "foo", Trigger: "on-sync-failed", Recipient: "slack:alerts", Duration: time.Hour, User: "slack:U123",
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, response, "Notifications of application foo are silenced until")
+	items, err := s.silences.List()
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "foo", items[0].App)
+		assert.Equal(t, "on-sync-failed", items[0].Trigger)
+		assert.Equal(t, "slack:alerts", items[0].Recipient)
+		assert.Equal(t, "slack:U123", items[0].CreatedBy)
+		assert.True(t, items[0].IsActive(time.Now()))
+	}
+
+	_, err = s.executeAction(Action{Name: ActionSilence, App: "foo"})
+	assert.EqualError(t, err, "silence duration must be positive")
+}
+
+func TestExecuteAction_ApproveAndSync(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo"))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+	s := NewServer(client, TestNamespace)
+
+	response, err := s.executeAction(Action{Name: ActionApprove, App: "foo", Revision: "abc", Sync: true, User: "slack:U123"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Application foo revision abc is approved. Sync is started.", response)
+	approval, err := s.approvals.Get("foo", "abc")
+	assert.NoError(t, err)
+	if assert.NotNil(t, approval) {
+		assert.Equal(t, approvals.StatusApproved, approval.Status)
+		assert.Equal(t, "slack:U123", approval.DecidedBy)
+	}
+	assert.Equal(t, []map[string]interface{}{{"operation": map[string]interface{}{
+		"initiatedBy": map[string]interface{}{"username": "slack:U123"},
+		"sync":        map[string]interface{}{"revision": "abc"},
+	}}}, patches)
+}
+
+func TestExecuteAction_Reject(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo"))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+	s := NewServer(client, TestNamespace)
+
+	response, err := s.executeAction(Action{Name: ActionReject, App: "foo", Sync: true, User: "slack:U123"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Application foo is rejected.", response)
+	assert.Empty(t, patches)
+
+	_, err = s.executeAction(Action{Name: ActionApprove, App: "foo", User: "slack:U456"})
+	assert.EqualError(t, err, "application foo is already Rejected by slack:U123")
+}
+
+func TestExecuteAction_Ack(t *testing.T) {
+	s := NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+
+	response, err := s.executeAction(Action{Name: ActionAck, App: "foo", Trigger: "on-sync-failed", User: "slack:U123"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Notification on-sync-failed of application foo is acknowledged.", response)
+	ack, err := history.GetAcknowledgement(s.history, "argocd", "foo", "on-sync-failed", time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	if assert.NotNil(t, ack) {
+		assert.Equal(t, "slack:U123", ack.User)
+	}
+}
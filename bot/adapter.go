@@ -1,6 +1,9 @@
 package bot
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type ListSubscriptions struct {
 }
@@ -8,14 +11,45 @@ type ListSubscriptions struct {
 type UpdateSubscription struct {
 	App     string
 	Project string
-	Trigger string
+	// Selector is the label selector of the applications; the subscription is updated in every matching application
+	Selector string
+	Trigger  string
+}
+
+// QueryApp requests the application state rendered using the notification template
+type QueryApp struct {
+	App string
+}
+
+// MuteNotifications requests the temporary silence of the application or trigger notifications sent to the recipient
+type MuteNotifications struct {
+	App      string
+	Trigger  string
+	Duration time.Duration
 }
 
 type Command struct {
-	Recipient         string
+	Recipient string
+	// User identifies the user who sent the command, e.g. slack:U0123ABCD
+	User string
+	// Conversation is the adapter specific reference of the conversation that receives the response; optional
+	Conversation      interface{}
 	ListSubscriptions *ListSubscriptions
 	Subscribe         *UpdateSubscription
 	Unsubscribe       *UpdateSubscription
+	Status            *QueryApp
+	Diff              *QueryApp
+	Mute              *MuteNotifications
+}
+
+// Acknowledgement is returned by the adapter Parse method if the request does not have a command and should be
+// acknowledged using the provided response, e.g. the Slack Events API URL verification request
+type Acknowledgement struct {
+	Response string
+}
+
+func (a Acknowledgement) Error() string {
+	return a.Response
 }
 
 // Adapter encapsulates integration with the notification service
@@ -25,3 +59,11 @@ type Adapter interface {
 	// Sends formatted response
 	SendResponse(content string, w http.ResponseWriter)
 }
+
+// ConversationAdapter is implemented by adapters that send the response into the conversation using the notification
+// service API instead of the HTTP response, e.g. Bot Framework bots
+type ConversationAdapter interface {
+	Adapter
+	// Reply sends formatted response into the command conversation
+	Reply(conversation interface{}, content string) error
+}
@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const (
+	// APITokenKey is the argocd-notifications-secret key that holds the subscriptions API bearer token
+	APITokenKey = "bot-api-token"
+)
+
+// TokenVerifier returns an error if provided bearer token is not valid
+type TokenVerifier func(token string) error
+
+// NewTokenVerifier returns verifier that compares token with the value stored in the argocd-notifications-secret
+func NewTokenVerifier(secretInformer cache.SharedIndexInformer) TokenVerifier {
+	return NewSecretTokenVerifier(secretInformer, APITokenKey)
+}
+
+// NewSecretTokenVerifier returns verifier that compares token with the value of the specified argocd-notifications-secret key
+func NewSecretTokenVerifier(secretInformer cache.SharedIndexInformer, key string) TokenVerifier {
+	return func(token string) error {
+		secrets := secretInformer.GetStore().List()
+		if len(secrets) == 0 {
+			return fmt.Errorf("cannot find secret %s", settings.SecretName)
+		}
+		secret, ok := secrets[0].(*v1.Secret)
+		if !ok {
+			return errors.New("unexpected object in the secret informer storage")
+		}
+		expected := secret.Data[key]
+		if len(expected) == 0 {
+			return fmt.Errorf("token is not configured in the %s key of the %s secret", key, settings.SecretName)
+		}
+		if subtle.ConstantTimeCompare(expected, []byte(token)) != 1 {
+			return errors.New("invalid token")
+		}
+		return nil
+	}
+}
+
+// SubscriptionRequest describes subscription that should be added or removed
+type SubscriptionRequest struct {
+	Recipient string `json:"recipient"`
+	App       string `json:"app,omitempty"`
+	Project   string `json:"project,omitempty"`
+	Trigger   string `json:"trigger,omitempty"`
+}
+
+// AnnotationSubscription holds recipients subscribed to the application or project
+type AnnotationSubscription struct {
+	Trigger    string   `json:"trigger,omitempty"`
+	Recipients []string `json:"recipients"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// apiError is the error caused by the invalid request rather than the subscriptions update failure
+type apiError struct {
+	status int
+	error
+}
+
+func apiErrorStatus(err error) int {
+	if err, ok := err.(apiError); ok {
+		return err.status
+	}
+	if apierrors.IsNotFound(err) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func (s *server) getObjectSubscriptions(client dynamic.ResourceInterface, name string) ([]AnnotationSubscription, error) {
+	obj, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]AnnotationSubscription, 0)
+	for trigger, items := range recipients.GetAnnotationSubscriptions(obj.GetAnnotations()) {
+		res = append(res, AnnotationSubscription{Trigger: trigger, Recipients: items})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Trigger < res[j].Trigger
+	})
+	return res, nil
+}
+
+func (s *server) apiHandler(verifier TokenVerifier) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if err := verifier(token); err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			var res interface{}
+			var err error
+			switch {
+			case query.Get("recipient") != "":
+				res, err = s.getSubscriptions(query.Get("recipient"))
+			case query.Get("app") != "":
+				res, err = s.getObjectSubscriptions(s.appClient, query.Get("app"))
+			case query.Get("project") != "":
+				res, err = s.getObjectSubscriptions(s.appProjClient, query.Get("project"))
+			default:
+				err = errors.New("one of recipient, app or project query parameters must be specified")
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, res)
+		case http.MethodPost, http.MethodDelete:
+			var req SubscriptionRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err))
+				return
+			}
+			if req.Recipient == "" {
+				writeError(w, http.StatusBadRequest, errors.New("recipient must be specified"))
+				return
+			}
+			if _, err := s.updateSubscription(APIUser, req.Recipient, r.Method == http.MethodPost, UpdateSubscription{
+				App: req.App, Project: req.Project, Trigger: req.Trigger,
+			}); err != nil {
+				writeError(w, apiErrorStatus(err), err)
+				return
+			}
+			writeJSON(w, http.StatusOK, req)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not supported", r.Method))
+		}
+	}
+}
+
+// AddAPI registers subscriptions management REST API handler
+func (s *server) AddAPI(pattern string, verifier TokenVerifier) {
+	s.mux.HandleFunc(pattern, s.apiHandler(verifier))
+}
@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func staticTokenVerifier(token string) error {
+	if token != "my-token" {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+func TestAPI_Unauthorized(t *testing.T) {
+	s := NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions?recipient=slack:general", nil)
+	req.Header.Set("Authorization", "Bearer bad")
+	w := httptest.NewRecorder()
+
+	s.apiHandler(staticTokenVerifier)(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAPI_ListRecipientSubscriptions(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("foo"),
+		NewApp("bar", WithAnnotations(map[string]string{recipients.RecipientsAnnotation: "slack:general"})))
+	s := NewServer(client, TestNamespace)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions?recipient=slack:general", nil)
+	req.Header.Set("Authorization", "Bearer my-token")
+	w := httptest.NewRecorder()
+
+	s.apiHandler(staticTokenVerifier)(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res Subscriptions
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, Subscriptions{Applications: []string{"default/bar"}, Projects: []string{}}, res)
+}
+
+func TestAPI_ListAppSubscriptions(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation:                                   "slack:general",
+		fmt.Sprintf("on-sync-failed.%s", recipients.RecipientsAnnotation): "slack:alerts,email:sre@example.com",
+	})))
+	s := NewServer(client, TestNamespace)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions?app=foo", nil)
+	req.Header.Set("Authorization", "Bearer my-token")
+	w := httptest.NewRecorder()
+
+	s.apiHandler(staticTokenVerifier)(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res []AnnotationSubscription
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, []AnnotationSubscription{
+		{Recipients: []string{"slack:general"}},
+		{Trigger: "on-sync-failed", Recipients: []string{"slack:alerts", "email:sre@example.com"}},
+	}, res)
+}
+
+func TestAPI_ListAppSubscriptions_SubscribeAnnotations(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation:                               "slack:general",
+		recipients.SubscribeAnnotationPrefix + "slack":                "alerts",
+		recipients.SubscribeAnnotationPrefix + "on-sync-failed.email": "sre@example.com",
+	})))
+	s := NewServer(client, TestNamespace)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions?app=foo", nil)
+	req.Header.Set("Authorization", "Bearer my-token")
+	w := httptest.NewRecorder()
+
+	s.apiHandler(staticTokenVerifier)(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res []AnnotationSubscription
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, []AnnotationSubscription{
+		{Recipients: []string{"slack:alerts", "slack:general"}},
+		{Trigger: "on-sync-failed", Recipients: []string{"email:sre@example.com"}},
+	}, res)
+}
+
+func TestAPI_Subscribe(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo"))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+	s := NewServer(client, TestNamespace)
+
+	body, err := json.Marshal(SubscriptionRequest{Recipient: "slack:general", App: "foo", Trigger: "on-sync-failed"})
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer my-token")
+	w := httptest.NewRecorder()
+
+	s.apiHandler(staticTokenVerifier)(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, patches, 1)
+	val, _, _ := unstructured.NestedString(patches[0], "metadata", "annotations", recipients.SubscribeAnnotationPrefix+"on-sync-failed.slack")
+	assert.Equal(t, "general", val)
+}
+
+func TestAPI_SubscribeErrors(t *testing.T) {
+	s := NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo")), TestNamespace)
+	for name, tc := range map[string]struct {
+		request SubscriptionRequest
+		status  int
+	}{
+		"NoTarget":    {SubscriptionRequest{Recipient: "slack:general"}, http.StatusBadRequest},
+		"AppNotFound": {SubscriptionRequest{Recipient: "slack:general", App: "bar"}, http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			body, err := json.Marshal(tc.request)
+			assert.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions", bytes.NewReader(body))
+			req.Header.Set("Authorization", "Bearer my-token")
+			w := httptest.NewRecorder()
+
+			s.apiHandler(staticTokenVerifier)(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
+
+func TestAPI_SubscribeServerError(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo"))
+	client.PrependReactor("patch", "*", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	s := NewServer(client, TestNamespace)
+
+	body, err := json.Marshal(SubscriptionRequest{Recipient: "slack:general", App: "foo"})
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer my-token")
+	w := httptest.NewRecorder()
+
+	s.apiHandler(staticTokenVerifier)(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
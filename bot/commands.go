@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// UsageError is returned by ParseArgs if the command arguments are invalid. The adapters respond with the usage
+// instructions of the command formatted for the notification service.
+type UsageError struct {
+	// Command is the name of the command which usage should be shown; empty if the command is unknown
+	Command string
+	// Err is the problem of the arguments; optional
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	if e.Command != "" {
+		return fmt.Sprintf("invalid %s command", e.Command)
+	}
+	return "unknown command"
+}
+
+// ParseArgs parses the bot command arguments, e.g. [subscribe guestbook on-sync-failed]. The returned command has no
+// recipient; the adapter sets the recipient and user of the command.
+func ParseArgs(parts []string) (Command, error) {
+	cmd := Command{}
+	if len(parts) < 1 {
+		return cmd, &UsageError{}
+	}
+	command := parts[0]
+
+	switch command {
+	case "list-subscriptions":
+		cmd.ListSubscriptions = &ListSubscriptions{}
+	case "subscribe", "unsubscribe":
+		if len(parts) < 2 {
+			return cmd, &UsageError{Command: command, Err: errors.New("at least one argument expected")}
+		}
+		update := &UpdateSubscription{}
+		nameParts := strings.SplitN(parts[1], ":", 2)
+		if len(nameParts) == 1 {
+			nameParts = append([]string{"app"}, nameParts...)
+		}
+		switch nameParts[0] {
+		case "app":
+			update.App = nameParts[1]
+		case "proj":
+			update.Project = nameParts[1]
+		case "selector":
+			update.Selector = nameParts[1]
+		default:
+			return cmd, &UsageError{Command: command, Err: fmt.Errorf("incorrect name argument: %s", parts[1])}
+		}
+		if len(parts) > 2 {
+			update.Trigger = parts[2]
+		}
+		if command == "subscribe" {
+			cmd.Subscribe = update
+		} else {
+			cmd.Unsubscribe = update
+		}
+	case "status", "diff":
+		if len(parts) < 2 {
+			return cmd, &UsageError{Command: command, Err: errors.New("application name expected")}
+		}
+		if command == "status" {
+			cmd.Status = &QueryApp{App: parts[1]}
+		} else {
+			cmd.Diff = &QueryApp{App: parts[1]}
+		}
+	case "mute", "snooze":
+		mute, err := ParseMuteArgs(parts[1:])
+		if err != nil {
+			return cmd, &UsageError{Command: "mute", Err: err}
+		}
+		cmd.Mute = mute
+	default:
+		return cmd, &UsageError{}
+	}
+	return cmd, nil
+}
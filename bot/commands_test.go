@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseArgs(t *testing.T) {
+	cmd, err := ParseArgs([]string{"unsubscribe", "selector:team=a", "on-deployed"})
+	assert.NoError(t, err)
+	assert.Equal(t, &UpdateSubscription{Selector: "team=a", Trigger: "on-deployed"}, cmd.Unsubscribe)
+
+	cmd, err = ParseArgs([]string{"mute", "guestbook", "for", "1h"})
+	assert.NoError(t, err)
+	assert.Equal(t, &MuteNotifications{App: "guestbook", Duration: time.Hour}, cmd.Mute)
+}
+
+func TestParseArgs_UsageError(t *testing.T) {
+	_, err := ParseArgs(nil)
+	assert.Equal(t, &UsageError{}, err)
+	assert.EqualError(t, err, "unknown command")
+
+	_, err = ParseArgs([]string{"subscribe", "cluster:foo"})
+	if assert.IsType(t, &UsageError{}, err) {
+		assert.Equal(t, "subscribe", err.(*UsageError).Command)
+	}
+	assert.EqualError(t, err, "incorrect name argument: cluster:foo")
+
+	_, err = ParseArgs([]string{"status"})
+	assert.IsType(t, &UsageError{}, err)
+}
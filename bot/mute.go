@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/silences"
+)
+
+// NotifierSource returns the configured notification services by the service name
+type NotifierSource func() (map[string]notifiers.Notifier, error)
+
+// NewNotifierSource returns source of the notification services configured in the argocd-notifications-secret
+func NewNotifierSource(secretInformer cache.SharedIndexInformer) NotifierSource {
+	return func() (map[string]notifiers.Notifier, error) {
+		secrets := secretInformer.GetStore().List()
+		if len(secrets) == 0 {
+			return nil, fmt.Errorf("cannot find secret %s", settings.SecretName)
+		}
+		secret, ok := secrets[0].(*v1.Secret)
+		if !ok {
+			return nil, errors.New("unexpected object in the secret informer storage")
+		}
+		cfg, err := settings.ParseSecret(secret)
+		if err != nil {
+			return nil, err
+		}
+		return notifiers.GetAll(cfg), nil
+	}
+}
+
+// parseDuration parses the Go duration and the number of days, e.g. 2d
+func parseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	return time.ParseDuration(value)
+}
+
+// ParseMuteArgs parses the mute command arguments in format <app>|app:<app>|trigger:<trigger> [for] <duration>
+func ParseMuteArgs(args []string) (*MuteNotifications, error) {
+	if len(args) == 3 && args[1] == "for" {
+		args = []string{args[0], args[2]}
+	}
+	if len(args) != 2 {
+		return nil, errors.New("application or trigger and duration expected")
+	}
+	opts := &MuteNotifications{}
+	nameParts := strings.SplitN(args[0], ":", 2)
+	switch {
+	case len(nameParts) == 1:
+		opts.App = nameParts[0]
+	case nameParts[0] == "app":
+		opts.App = nameParts[1]
+	case nameParts[0] == "trigger":
+		opts.Trigger = nameParts[1]
+	default:
+		return nil, fmt.Errorf("incorrect name argument: %s", args[0])
+	}
+	duration, err := parseDuration(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration '%s'", args[1])
+	}
+	opts.Duration = duration
+	return opts, nil
+}
+
+func muteSubject(opts MuteNotifications) string {
+	switch {
+	case opts.App != "" && opts.Trigger != "":
+		return fmt.Sprintf("Notifications of application %s trigger %s", opts.App, opts.Trigger)
+	case opts.App != "":
+		return fmt.Sprintf("Notifications of application %s", opts.App)
+	default:
+		return fmt.Sprintf("Notifications of trigger %s", opts.Trigger)
+	}
+}
+
+// mute creates the silence of the notifications sent to the recipient which is announced when it expires
+func (s *server) mute(user string, recipient string, opts MuteNotifications) (string, error) {
+	if opts.App == "" && opts.Trigger == "" {
+		return "", errors.New("either application or trigger must be specified")
+	}
+	if opts.Duration <= 0 {
+		return "", errors.New("mute duration must be positive")
+	}
+	silence, err := s.silences.Create(silences.Silence{
+		App:            opts.App,
+		Trigger:        opts.Trigger,
+		Recipient:      recipient,
+		ExpiresAt:      time.Now().Add(opts.Duration),
+		CreatedBy:      user,
+		NotifyOnExpiry: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s are muted until %s.", muteSubject(opts), silence.ExpiresAt.Format(time.RFC3339)), nil
+}
+
+// notifyExpiredSilences posts the message into the conversation of every expired silence created by the mute command
+// and removes the silence, so the message is sent only once
+func (s *server) notifyExpiredSilences(notifierSource NotifierSource, now time.Time) error {
+	items, err := s.silences.List()
+	if err != nil {
+		return err
+	}
+	var services map[string]notifiers.Notifier
+	for _, silence := range items {
+		if !silence.NotifyOnExpiry || silence.IsActive(now) || silence.Recipient == "" {
+			continue
+		}
+		if services == nil {
+			if services, err = notifierSource(); err != nil {
+				return err
+			}
+		}
+		parsed, err := recipients.Parse(silence.Recipient)
+		notifier, ok := services[parsed.Service]
+		if !ok || err != nil {
+			log.Warnf("Cannot notify %s about expired silence %s: notification service is not configured", silence.Recipient, silence.Name)
+		} else {
+			subject := muteSubject(MuteNotifications{App: silence.App, Trigger: silence.Trigger})
+			if err := notifier.Send(context.TODO(), notifiers.Notification{Body: fmt.Sprintf("%s are unmuted.", subject)}, parsed.Target); err != nil {
+				log.Errorf("Failed to notify %s about expired silence %s: %v", silence.Recipient, silence.Name, err)
+				continue
+			}
+		}
+		if err := s.silences.Delete(silence.Name); err != nil {
+			log.Errorf("Failed to delete expired silence %s: %v", silence.Name, err)
+		}
+	}
+	return nil
+}
+
+// RunSilenceExpiry periodically announces the expiration of the silences created by the mute command until the stop
+// channel is closed
+func (s *server) RunSilenceExpiry(notifierSource NotifierSource, interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			if err := s.notifyExpiredSilences(notifierSource, time.Now()); err != nil {
+				log.Errorf("Failed to process expired silences: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/notifiers/mocks"
+	"github.com/argoproj-labs/argocd-notifications/shared/silences"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestParseMuteArgs(t *testing.T) {
+	opts, err := ParseMuteArgs([]string{"guestbook", "for", "2h"})
+	assert.NoError(t, err)
+	assert.Equal(t, &MuteNotifications{App: "guestbook", Duration: 2 * time.Hour}, opts)
+
+	opts, err = ParseMuteArgs([]string{"trigger:on-sync-failed", "1d"})
+	assert.NoError(t, err)
+	assert.Equal(t, &MuteNotifications{Trigger: "on-sync-failed", Duration: 24 * time.Hour}, opts)
+
+	_, err = ParseMuteArgs([]string{"guestbook", "for", "never"})
+	assert.EqualError(t, err, "invalid duration 'never'")
+
+	_, err = ParseMuteArgs([]string{"guestbook"})
+	assert.EqualError(t, err, "application or trigger and duration expected")
+}
+
+func TestExecute_Mute(t *testing.T) {
+	s := NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+
+	response, err := s.execute(Command{
+		Recipient: "slack:alerts", User: "slack:U123", Mute: &MuteNotifications{App: "guestbook", Duration: time.Hour},
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, response, "Notifications of application guestbook are muted until")
+	items, err := s.silences.List()
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "guestbook", items[0].App)
+		assert.Equal(t, "slack:alerts", items[0].Recipient)
+		assert.Equal(t, "slack:U123", items[0].CreatedBy)
+		assert.True(t, items[0].NotifyOnExpiry)
+	}
+}
+
+func TestNotifyExpiredSilences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := mocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Body: "Notifications of trigger on-sync-failed are unmuted."}, "alerts").Return(nil)
+
+	s := NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+	now := time.Now()
+	_, err := s.silences.Create(silences.Silence{Trigger: "on-sync-failed", Recipient: "slack:alerts", ExpiresAt: now.Add(-time.Minute), NotifyOnExpiry: true})
+	assert.NoError(t, err)
+	active, err := s.silences.Create(silences.Silence{App: "guestbook", Recipient: "slack:alerts", ExpiresAt: now.Add(time.Hour), NotifyOnExpiry: true})
+	assert.NoError(t, err)
+	manual, err := s.silences.Create(silences.Silence{App: "guestbook", ExpiresAt: now.Add(-time.Minute)})
+	assert.NoError(t, err)
+
+	err = s.notifyExpiredSilences(func() (map[string]notifiers.Notifier, error) {
+		return map[string]notifiers.Notifier{"slack": notifier}, nil
+	}, now)
+
+	assert.NoError(t, err)
+	items, err := s.silences.List()
+	assert.NoError(t, err)
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	assert.ElementsMatch(t, []string{active.Name, manual.Name}, names)
+}
@@ -0,0 +1,169 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/gobwas/glob"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+)
+
+const (
+	// APIUser identifies the subscriptions API requests which are authorized by the API token and not restricted by
+	// the subscription policy
+	APIUser = "api"
+)
+
+var (
+	// SubscriptionAuditAnnotation holds the users who created the bot subscriptions of the application or project
+	SubscriptionAuditAnnotation = recipients.AnnotationPostfix + "/subscription-audit"
+)
+
+// SubscriptionPolicy restricts the applications and projects the chat users may subscribe to
+type SubscriptionPolicy struct {
+	// Groups maps the group name to the group members, e.g. slack:U0123ABCD
+	Groups map[string][]string `json:"groups,omitempty"`
+	Rules  []SubscriptionRule  `json:"rules"`
+}
+
+// SubscriptionRule allows the users and group members to subscribe to the matching applications and projects
+type SubscriptionRule struct {
+	// Users are the user patterns, e.g. slack:U0123ABCD or teams:*
+	Users []string `json:"users,omitempty"`
+	// Groups are the names of the policy groups
+	Groups []string `json:"groups,omitempty"`
+	// Apps are the application name patterns, e.g. team-a-*
+	Apps []string `json:"apps,omitempty"`
+	// Projects are the project name patterns; the rule allows subscribing to the project and its applications
+	Projects []string `json:"projects,omitempty"`
+}
+
+// LoadSubscriptionPolicy reads the subscription policy from the YAML file
+func LoadSubscriptionPolicy(path string) (*SubscriptionPolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &SubscriptionPolicy{}
+	if err = yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse subscription policy %s: %v", path, err)
+	}
+	for _, r := range policy.Rules {
+		for _, pattern := range append(append(append([]string{}, r.Users...), r.Apps...), r.Projects...) {
+			if _, err := glob.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern '%s' in subscription policy %s: %v", pattern, path, err)
+			}
+		}
+	}
+	return policy, nil
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if compiled, err := glob.Compile(pattern); err == nil && compiled.Match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *SubscriptionPolicy) appliesTo(r SubscriptionRule, user string) bool {
+	if matchesAny(r.Users, user) {
+		return true
+	}
+	for _, group := range r.Groups {
+		if findStringIndex(p.Groups[group], user) > -1 {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsApp returns true if the user may subscribe to the application of the specified project
+func (p *SubscriptionPolicy) AllowsApp(user string, app string, project string) bool {
+	for _, r := range p.Rules {
+		if p.appliesTo(r, user) && (matchesAny(r.Apps, app) || matchesAny(r.Projects, project)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsProject returns true if the user may subscribe to the project
+func (p *SubscriptionPolicy) AllowsProject(user string, project string) bool {
+	for _, r := range p.Rules {
+		if p.appliesTo(r, user) && matchesAny(r.Projects, project) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSubscriptionPolicy restricts the applications and projects the chat users may subscribe to
+func (s *server) SetSubscriptionPolicy(policy *SubscriptionPolicy) {
+	s.subscriptionPolicy = policy
+}
+
+// authorizeSubscription returns an error if the subscription policy does not allow the user to update the
+// subscription of the application or project
+func (s *server) authorizeSubscription(user string, obj *unstructured.Unstructured, isProject bool) error {
+	if s.subscriptionPolicy == nil || user == APIUser {
+		return nil
+	}
+	if isProject {
+		if !s.subscriptionPolicy.AllowsProject(user, obj.GetName()) {
+			return apiError{http.StatusForbidden, fmt.Errorf("user %s is not allowed to subscribe to project %s", user, obj.GetName())}
+		}
+		return nil
+	}
+	project, _, _ := unstructured.NestedString(obj.Object, "spec", "project")
+	if !s.subscriptionPolicy.AllowsApp(user, obj.GetName(), project) {
+		return apiError{http.StatusForbidden, fmt.Errorf("user %s is not allowed to subscribe to application %s", user, obj.GetName())}
+	}
+	return nil
+}
+
+// SubscriptionRecord records who created the subscription
+type SubscriptionRecord struct {
+	Recipient string    `json:"recipient"`
+	Trigger   string    `json:"trigger,omitempty"`
+	User      string    `json:"user"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// GetSubscriptionRecords returns the subscription records stored in the audit annotation
+func GetSubscriptionRecords(annotations map[string]string) []SubscriptionRecord {
+	var records []SubscriptionRecord
+	if data, ok := annotations[SubscriptionAuditAnnotation]; ok {
+		_ = json.Unmarshal([]byte(data), &records)
+	}
+	return records
+}
+
+// auditSubscription updates the audit annotation: the record of the new subscription replaces the existing record of
+// the recipient and trigger, and the record of the removed subscription is deleted
+func auditSubscription(annotations map[string]string, record SubscriptionRecord, subscribe bool) map[string]string {
+	var records []SubscriptionRecord
+	for _, r := range GetSubscriptionRecords(annotations) {
+		if r.Recipient != record.Recipient || r.Trigger != record.Trigger {
+			records = append(records, r)
+		}
+	}
+	if subscribe {
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		delete(annotations, SubscriptionAuditAnnotation)
+		return annotations
+	}
+	if data, err := json.Marshal(records); err == nil {
+		annotations[SubscriptionAuditAnnotation] = string(data)
+	}
+	return annotations
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestLoadSubscriptionPolicy(t *testing.T) {
+	f, err := ioutil.TempFile("", "policy")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	_, err = f.WriteString(`
+groups:
+  payments: [slack:U123]
+rules:
+- groups: [payments]
+  apps: [payments-*]
+  projects: [payments]
+- users: [teams:*]
+  apps: [shared]
+`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	policy, err := LoadSubscriptionPolicy(f.Name())
+
+	assert.NoError(t, err)
+	assert.True(t, policy.AllowsApp("slack:U123", "payments-api", "default"))
+	assert.True(t, policy.AllowsApp("slack:U123", "checkout", "payments"))
+	assert.False(t, policy.AllowsApp("slack:U123", "shared", "default"))
+	assert.True(t, policy.AllowsApp("teams:29:abc", "shared", "default"))
+	assert.True(t, policy.AllowsProject("slack:U123", "payments"))
+	assert.False(t, policy.AllowsProject("slack:U456", "payments"))
+}
+
+func TestUpdateSubscription_NotAllowedByPolicy(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("payments-api", WithLabels(map[string]string{"env": "prod"})),
+		NewApp("billing", WithLabels(map[string]string{"env": "prod"})))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+	s := NewServer(client, TestNamespace)
+	s.SetSubscriptionPolicy(&SubscriptionPolicy{Rules: []SubscriptionRule{{Users: []string{"slack:U123"}, Apps: []string{"payments-*"}}}})
+
+	_, err := s.updateSubscription("slack:U123", "slack:alerts", true, UpdateSubscription{App: "payments-api"})
+	assert.NoError(t, err)
+
+	_, err = s.updateSubscription("slack:U123", "slack:alerts", true, UpdateSubscription{Selector: "env=prod"})
+	assert.EqualError(t, err, "user slack:U123 is not allowed to subscribe to application billing")
+
+	_, err = s.updateSubscription("slack:U456", "slack:alerts", true, UpdateSubscription{App: "payments-api"})
+	assert.EqualError(t, err, "user slack:U456 is not allowed to subscribe to application payments-api")
+
+	_, err = s.updateSubscription(APIUser, "slack:alerts", true, UpdateSubscription{App: "billing"})
+	assert.NoError(t, err)
+	assert.Len(t, patches, 2)
+}
+
+func TestUpdateSubscription_RecordsAudit(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo"))
+	s := NewServer(client, TestNamespace)
+
+	_, err := s.updateSubscription("slack:U123", "slack:alerts", true, UpdateSubscription{App: "foo", Trigger: "on-sync-failed"})
+	assert.NoError(t, err)
+
+	app, err := s.appClient.Get("foo", metav1.GetOptions{})
+	assert.NoError(t, err)
+	records := GetSubscriptionRecords(app.GetAnnotations())
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "slack:alerts", records[0].Recipient)
+		assert.Equal(t, "on-sync-failed", records[0].Trigger)
+		assert.Equal(t, "slack:U123", records[0].User)
+		assert.False(t, records[0].CreatedAt.IsZero())
+	}
+
+	_, err = s.updateSubscription("slack:U123", "slack:alerts", false, UpdateSubscription{App: "foo", Trigger: "on-sync-failed"})
+	assert.NoError(t, err)
+	app, err = s.appClient.Get("foo", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, ok, _ := unstructured.NestedString(app.Object, "metadata", "annotations", SubscriptionAuditAnnotation)
+	assert.False(t, ok)
+}
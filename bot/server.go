@@ -6,11 +6,19 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	log "github.com/sirupsen/logrus"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/approvals"
 	"github.com/argoproj-labs/argocd-notifications/shared/clients"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
 	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/silences"
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 )
@@ -18,34 +26,64 @@ import (
 type Server interface {
 	Serve(port int) error
 	AddAdapter(path string, adapter Adapter)
+	AddActionAdapter(path string, adapter ActionAdapter)
+	// Handler returns the handler of the adapter commands which is not registered in the server; used to handle the
+	// requests received without the server endpoints, e.g. using the Slack Socket Mode connection
+	Handler(adapter Adapter) http.HandlerFunc
+	// ActionHandler returns the handler of the adapter actions which is not registered in the server
+	ActionHandler(adapter ActionAdapter) http.HandlerFunc
+	AddAPI(path string, verifier TokenVerifier)
 }
 
 func NewServer(dynamicClient dynamic.Interface, namespace string) *server {
+	appClient := clients.NewAppClient(dynamicClient, namespace)
 	return &server{
 		mux:           http.NewServeMux(),
-		appClient:     clients.NewAppClient(dynamicClient, namespace),
+		appClient:     appClient,
 		appProjClient: clients.NewAppProjClient(dynamicClient, namespace),
+		appActions:    NewKubernetesAppActions(appClient),
+		silences:      silences.NewCRDStore(dynamicClient, namespace),
+		approvals:     approvals.NewCRDStore(dynamicClient, namespace),
+		history:       history.NewCRDStore(dynamicClient, namespace),
 	}
 }
 
 type server struct {
 	appClient     dynamic.ResourceInterface
 	appProjClient dynamic.ResourceInterface
-	mux           *http.ServeMux
+	appActions    AppActions
+	silences      silences.Store
+	approvals     approvals.Store
+	history       history.Store
+	config        ConfigSource
+	// subscriptionPolicy restricts the bot subscriptions; optional
+	subscriptionPolicy *SubscriptionPolicy
+	mux                *http.ServeMux
 }
 
-func (s *server) handler(adapter Adapter) func(http.ResponseWriter, *http.Request) {
+func (s *server) Handler(adapter Adapter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		cmd, err := adapter.Parse(r)
-		if err != nil {
-			adapter.SendResponse(err.Error(), w)
+		if ack, ok := err.(Acknowledgement); ok {
+			adapter.SendResponse(ack.Response, w)
 			return
 		}
-		if res, err := s.execute(cmd); err != nil {
-			adapter.SendResponse(fmt.Sprintf("cannot execute command: %v", err), w)
+		var response string
+		if err != nil {
+			response = err.Error()
+		} else if res, err := s.execute(cmd); err != nil {
+			response = fmt.Sprintf("cannot execute command: %v", err)
 		} else {
-			adapter.SendResponse(res, w)
+			response = res
+		}
+		if conversationAdapter, ok := adapter.(ConversationAdapter); ok && cmd.Conversation != nil {
+			if err := conversationAdapter.Reply(cmd.Conversation, response); err != nil {
+				log.Errorf("Failed to send response: %v", err)
+			}
+			w.WriteHeader(http.StatusOK)
+			return
 		}
+		adapter.SendResponse(response, w)
 	}
 }
 
@@ -54,9 +92,15 @@ func (s *server) execute(cmd Command) (string, error) {
 	case cmd.ListSubscriptions != nil:
 		return s.listSubscriptions(cmd.Recipient)
 	case cmd.Subscribe != nil:
-		return s.updateSubscription(cmd.Recipient, true, *cmd.Subscribe)
+		return s.updateSubscription(cmd.User, cmd.Recipient, true, *cmd.Subscribe)
 	case cmd.Unsubscribe != nil:
-		return s.updateSubscription(cmd.Recipient, false, *cmd.Unsubscribe)
+		return s.updateSubscription(cmd.User, cmd.Recipient, false, *cmd.Unsubscribe)
+	case cmd.Status != nil:
+		return s.renderApp(StatusTemplate, cmd.Recipient, *cmd.Status)
+	case cmd.Diff != nil:
+		return s.renderApp(DiffTemplate, cmd.Recipient, *cmd.Diff)
+	case cmd.Mute != nil:
+		return s.mute(cmd.User, cmd.Recipient, *cmd.Mute)
 	default:
 		return "", errors.New("unknown command")
 	}
@@ -71,36 +115,69 @@ func findStringIndex(items []string, item string) int {
 	return -1
 }
 
+// subscribeAnnotationKey returns the subscribe annotation key of the trigger and service notifications
+func subscribeAnnotationKey(trigger string, service string) string {
+	if trigger == "" {
+		return recipients.SubscribeAnnotationPrefix + service
+	}
+	return recipients.SubscribeAnnotationPrefix + trigger + "." + service
+}
+
+// addSubscription adds the recipient to the subscribe annotation. The recipient without service name is added to the
+// legacy recipients annotation. The recipient already subscribed using the legacy annotation is not added again.
 func addSubscription(recipient string, trigger string, annotations map[string]string) map[string]string {
 	annotations = recipients.CopyStringMap(annotations)
-	annotationKey := recipients.RecipientsAnnotation
+	legacyKey := recipients.RecipientsAnnotation
 	if trigger != "" {
-		annotationKey = fmt.Sprintf("%s.%s", trigger, annotationKey)
+		legacyKey = fmt.Sprintf("%s.%s", trigger, legacyKey)
+	}
+	legacyRecipients := recipients.ParseRecipients(annotations[legacyKey])
+	if findStringIndex(legacyRecipients, recipient) > -1 {
+		return annotations
 	}
-	existingRecipients := recipients.ParseRecipients(annotations[annotationKey])
-	if index := findStringIndex(existingRecipients, recipient); index < 0 {
-		annotations[annotationKey] = strings.Join(append(existingRecipients, recipient), ",")
+	service, target, ok := recipients.Split(recipient)
+	if !ok || target == "" {
+		annotations[legacyKey] = strings.Join(append(legacyRecipients, recipient), ",")
+		return annotations
+	}
+	annotationKey := subscribeAnnotationKey(trigger, service)
+	existingTargets := recipients.ParseRecipients(annotations[annotationKey])
+	if findStringIndex(existingTargets, target) < 0 {
+		annotations[annotationKey] = strings.Join(append(existingTargets, target), ",")
 	}
 	return annotations
 }
 
+// removeSubscription removes the recipient from both the legacy recipients and the subscribe annotations
 func removeSubscription(recipient string, trigger string, annotations map[string]string) map[string]string {
 	annotations = recipients.CopyStringMap(annotations)
 	for _, k := range recipients.GetAnnotationKeys(annotations, trigger) {
-		existingRecipients := recipients.ParseRecipients(annotations[k])
-		if index := findStringIndex(existingRecipients, recipient); index > -1 {
-			newRecipients := append(existingRecipients[:index], existingRecipients[index+1:]...)
-			if len(newRecipients) > 0 {
-				annotations[k] = strings.Join(newRecipients, ",")
-			} else {
-				delete(annotations, k)
-			}
+		removeAnnotationValue(annotations, k, recipient)
+	}
+	if service, target, ok := recipients.Split(recipient); ok && target != "" {
+		removeAnnotationValue(annotations, subscribeAnnotationKey("", service), target)
+		if trigger != "" {
+			removeAnnotationValue(annotations, subscribeAnnotationKey(trigger, service), target)
 		}
 	}
 	return annotations
 }
 
-func (s *server) updateSubscription(recipient string, subscribe bool, opts UpdateSubscription) (string, error) {
+// removeAnnotationValue removes the value from the comma separated list of the annotation values and removes the
+// annotation once the list is empty
+func removeAnnotationValue(annotations map[string]string, key string, value string) {
+	values := recipients.ParseRecipients(annotations[key])
+	if index := findStringIndex(values, value); index > -1 {
+		values = append(values[:index], values[index+1:]...)
+		if len(values) > 0 {
+			annotations[key] = strings.Join(values, ",")
+		} else {
+			delete(annotations, key)
+		}
+	}
+}
+
+func (s *server) updateSubscription(user string, recipient string, subscribe bool, opts UpdateSubscription) (string, error) {
 	var name string
 	var client dynamic.ResourceInterface
 	switch {
@@ -110,61 +187,132 @@ func (s *server) updateSubscription(recipient string, subscribe bool, opts Updat
 	case opts.Project != "":
 		name = opts.Project
 		client = s.appProjClient
+	case opts.Selector != "":
+		return s.updateSelectorSubscription(user, recipient, subscribe, opts)
 	default:
-		return "", errors.New("either application or project name must be specified")
+		return "", apiError{http.StatusBadRequest, errors.New("either application, project name or selector must be specified")}
 	}
 	obj, err := client.Get(name, v1.GetOptions{})
 	if err != nil {
 		return "", err
 	}
+	if err = s.authorizeSubscription(user, obj, opts.Project != ""); err != nil {
+		return "", err
+	}
+	if err = updateObjectSubscription(client, obj, user, recipient, subscribe, opts.Trigger); err != nil {
+		return "", err
+	}
+	return "subscription updated", nil
+}
+
+// updateSelectorSubscription updates the subscription of every application that matches the label selector
+func (s *server) updateSelectorSubscription(user string, recipient string, subscribe bool, opts UpdateSubscription) (string, error) {
+	if _, err := labels.Parse(opts.Selector); err != nil {
+		return "", apiError{http.StatusBadRequest, fmt.Errorf("invalid selector '%s': %v", opts.Selector, err)}
+	}
+	appList, err := s.appClient.List(v1.ListOptions{LabelSelector: opts.Selector})
+	if err != nil {
+		return "", err
+	}
+	if len(appList.Items) == 0 {
+		return "", apiError{http.StatusNotFound, fmt.Errorf("no applications match selector '%s'", opts.Selector)}
+	}
+	// the subscription is not updated if the user is not allowed to subscribe to any of the matching applications
+	for i := range appList.Items {
+		if err = s.authorizeSubscription(user, &appList.Items[i], false); err != nil {
+			return "", err
+		}
+	}
+	for i := range appList.Items {
+		if err = updateObjectSubscription(s.appClient, &appList.Items[i], user, recipient, subscribe, opts.Trigger); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("subscription updated in %d applications", len(appList.Items)), nil
+}
+
+func updateObjectSubscription(client dynamic.ResourceInterface, obj *unstructured.Unstructured, user string, recipient string, subscribe bool, trigger string) error {
 	oldAnnotations := recipients.CopyStringMap(obj.GetAnnotations())
 	var newAnnotations map[string]string
 	if subscribe {
-		newAnnotations = addSubscription(recipient, opts.Trigger, obj.GetAnnotations())
+		newAnnotations = addSubscription(recipient, trigger, obj.GetAnnotations())
 	} else {
-		newAnnotations = removeSubscription(recipient, opts.Trigger, obj.GetAnnotations())
+		newAnnotations = removeSubscription(recipient, trigger, obj.GetAnnotations())
+	}
+	if len(recipients.AnnotationsPatch(oldAnnotations, newAnnotations)) == 0 {
+		return nil
 	}
+	newAnnotations = auditSubscription(newAnnotations, SubscriptionRecord{
+		Recipient: recipient, Trigger: trigger, User: user, CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}, subscribe)
+	log.WithFields(log.Fields{
+		"user": user, "recipient": recipient, "trigger": trigger, "subscribe": subscribe,
+	}).Infof("Updated subscription of %s", obj.GetName())
 	annotationsPatch := recipients.AnnotationsPatch(oldAnnotations, newAnnotations)
-	if len(annotationsPatch) > 0 {
-		patch := map[string]map[string]interface{}{
-			"metadata": {
-				"annotations": annotationsPatch,
-			},
-		}
-		patchData, err := json.Marshal(patch)
-		if err != nil {
-			return "", err
-		}
-		_, err = client.Patch(name, types.MergePatchType, patchData, v1.PatchOptions{})
-		if err != nil {
-			return "", err
+	if len(annotationsPatch) == 0 {
+		return nil
+	}
+	patch := map[string]map[string]interface{}{
+		"metadata": {
+			"annotations": annotationsPatch,
+		},
+	}
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = client.Patch(obj.GetName(), types.MergePatchType, patchData, v1.PatchOptions{})
+	return err
+}
+
+// renderRecipients renders templated recipients using the application fields and skips recipients that cannot be rendered
+func renderRecipients(items []string, app *unstructured.Unstructured) []string {
+	var res []string
+	for _, item := range items {
+		if rendered, err := recipients.RenderRecipient(item, app); err == nil {
+			res = append(res, rendered)
 		}
 	}
+	return res
+}
 
-	return "subscription updated", nil
+// Subscriptions holds names of applications and projects the recipient is subscribed to
+type Subscriptions struct {
+	Applications []string `json:"applications"`
+	Projects     []string `json:"projects"`
 }
 
-func (s *server) listSubscriptions(recipient string) (string, error) {
+func (s *server) getSubscriptions(recipient string) (*Subscriptions, error) {
 	appList, err := s.appClient.List(v1.ListOptions{})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	var apps []string
-	for _, app := range appList.Items {
-		if findStringIndex(recipients.GetRecipientsFromAnnotations(app.GetAnnotations(), ""), recipient) > -1 {
-			apps = append(apps, fmt.Sprintf("%s/%s", app.GetNamespace(), app.GetName()))
+	res := &Subscriptions{Applications: []string{}, Projects: []string{}}
+	for i := range appList.Items {
+		app := appList.Items[i]
+		if findStringIndex(renderRecipients(recipients.GetRecipientsFromAnnotations(app.GetAnnotations(), ""), &app), recipient) > -1 {
+			res.Applications = append(res.Applications, fmt.Sprintf("%s/%s", app.GetNamespace(), app.GetName()))
 		}
 	}
 	appProjList, err := s.appProjClient.List(v1.ListOptions{})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	var appProjs []string
 	for _, appProj := range appProjList.Items {
 		if findStringIndex(recipients.GetRecipientsFromAnnotations(appProj.GetAnnotations(), ""), recipient) > -1 {
-			appProjs = append(appProjs, fmt.Sprintf("%s/%s", appProj.GetNamespace(), appProj.GetName()))
+			res.Projects = append(res.Projects, fmt.Sprintf("%s/%s", appProj.GetNamespace(), appProj.GetName()))
 		}
 	}
+	return res, nil
+}
+
+func (s *server) listSubscriptions(recipient string) (string, error) {
+	subscriptions, err := s.getSubscriptions(recipient)
+	if err != nil {
+		return "", err
+	}
+	apps := subscriptions.Applications
+	appProjs := subscriptions.Projects
 	response := fmt.Sprintf("The %s has no subscriptions.", recipient)
 	if len(apps) > 0 || len(appProjs) > 0 {
 		response = fmt.Sprintf("The %s is subscribed to %d applications and %d projects.",
@@ -180,7 +328,7 @@ func (s *server) listSubscriptions(recipient string) (string, error) {
 }
 
 func (s *server) AddAdapter(pattern string, adapter Adapter) {
-	s.mux.HandleFunc(pattern, s.handler(adapter))
+	s.mux.HandleFunc(pattern, s.Handler(adapter))
 }
 
 func (s *server) Serve(port int) error {
@@ -60,13 +60,13 @@ func TestUpdateSubscription_SubscribeToApp(t *testing.T) {
 
 	s := NewServer(client, TestNamespace)
 
-	resp, err := s.updateSubscription("slack:channel2", true, UpdateSubscription{App: "foo"})
+	resp, err := s.updateSubscription("slack:U1", "slack:channel2", true, UpdateSubscription{App: "foo"})
 	assert.NoError(t, err)
 	assert.Equal(t, "subscription updated", resp)
 	assert.Len(t, patches, 1)
 
-	val, _, _ := unstructured.NestedString(patches[0], "metadata", "annotations", recipients.RecipientsAnnotation)
-	assert.Equal(t, val, "slack:channel1,slack:channel2")
+	val, _, _ := unstructured.NestedString(patches[0], "metadata", "annotations", recipients.SubscribeAnnotationPrefix+"slack")
+	assert.Equal(t, val, "channel2")
 }
 
 func TestUpdateSubscription_SubscribeToAppTrigger(t *testing.T) {
@@ -79,14 +79,14 @@ func TestUpdateSubscription_SubscribeToAppTrigger(t *testing.T) {
 
 	s := NewServer(client, TestNamespace)
 
-	resp, err := s.updateSubscription("slack:channel2", true, UpdateSubscription{App: "foo", Trigger: "on-sync-failed"})
+	resp, err := s.updateSubscription("slack:U1", "slack:channel2", true, UpdateSubscription{App: "foo", Trigger: "on-sync-failed"})
 	assert.NoError(t, err)
 	assert.Equal(t, "subscription updated", resp)
 	assert.Len(t, patches, 1)
 
 	patch := patches[0]
-	val, _, _ := unstructured.NestedString(patch, "metadata", "annotations", fmt.Sprintf("on-sync-failed.%s", recipients.RecipientsAnnotation))
-	assert.Equal(t, val, "slack:channel2")
+	val, _, _ := unstructured.NestedString(patch, "metadata", "annotations", recipients.SubscribeAnnotationPrefix+"on-sync-failed.slack")
+	assert.Equal(t, val, "channel2")
 }
 
 func TestUpdateSubscription_UnsubscribeAppTrigger(t *testing.T) {
@@ -100,7 +100,7 @@ func TestUpdateSubscription_UnsubscribeAppTrigger(t *testing.T) {
 
 	s := NewServer(client, TestNamespace)
 
-	resp, err := s.updateSubscription("slack:channel2", false, UpdateSubscription{App: "foo", Trigger: "on-sync-failed"})
+	resp, err := s.updateSubscription("slack:U1", "slack:channel2", false, UpdateSubscription{App: "foo", Trigger: "on-sync-failed"})
 	assert.NoError(t, err)
 	assert.Equal(t, "subscription updated", resp)
 	assert.Len(t, patches, 1)
@@ -111,3 +111,64 @@ func TestUpdateSubscription_UnsubscribeAppTrigger(t *testing.T) {
 	val, _, _ = unstructured.NestedString(patch, "metadata", "annotations", recipients.RecipientsAnnotation)
 	assert.Equal(t, val, "slack:channel1")
 }
+
+func TestUpdateSubscription_UnsubscribeSubscribeAnnotation(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithAnnotations(map[string]string{
+		recipients.SubscribeAnnotationPrefix + "slack":                "channel1,channel2",
+		recipients.SubscribeAnnotationPrefix + "on-sync-failed.slack": "channel2",
+		recipients.SubscribeAnnotationPrefix + "on-deployed.slack":    "channel2",
+	})))
+
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+
+	s := NewServer(client, TestNamespace)
+
+	_, err := s.updateSubscription("slack:U1", "slack:channel2", false, UpdateSubscription{App: "foo", Trigger: "on-sync-failed"})
+	assert.NoError(t, err)
+	assert.Len(t, patches, 1)
+
+	annotations, _, _ := unstructured.NestedMap(patches[0], "metadata", "annotations")
+	assert.Equal(t, "channel1", annotations[recipients.SubscribeAnnotationPrefix+"slack"])
+	assert.Contains(t, annotations, recipients.SubscribeAnnotationPrefix+"on-sync-failed.slack")
+	assert.Nil(t, annotations[recipients.SubscribeAnnotationPrefix+"on-sync-failed.slack"])
+	assert.NotContains(t, annotations, recipients.SubscribeAnnotationPrefix+"on-deployed.slack")
+}
+
+func TestUpdateSubscription_SubscribeToSelector(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("foo", WithLabels(map[string]string{"team": "payments"})),
+		NewApp("bar", WithLabels(map[string]string{"team": "payments"}), WithAnnotations(map[string]string{
+			recipients.RecipientsAnnotation: "slack:@U123",
+		})),
+		NewApp("baz", WithLabels(map[string]string{"team": "other"})))
+
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+
+	s := NewServer(client, TestNamespace)
+
+	resp, err := s.updateSubscription("slack:U1", "slack:@U123", true, UpdateSubscription{Selector: "team=payments"})
+	assert.NoError(t, err)
+	assert.Equal(t, "subscription updated in 2 applications", resp)
+	assert.Len(t, patches, 1)
+
+	val, _, _ := unstructured.NestedString(patches[0], "metadata", "annotations", recipients.SubscribeAnnotationPrefix+"slack")
+	assert.Equal(t, val, "@U123")
+
+	_, err = s.updateSubscription("slack:U1", "slack:@U123", true, UpdateSubscription{Selector: "team=unknown"})
+	assert.EqualError(t, err, "no applications match selector 'team=unknown'")
+}
+
+func TestListSubscriptions_RendersTemplatedSubscription(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("foo", WithLabels(map[string]string{"team": "a"}), WithAnnotations(map[string]string{recipients.RecipientsAnnotation: "slack:team-{{.app.metadata.labels.team}}"})),
+		NewApp("bar", WithLabels(map[string]string{"team": "b"}), WithAnnotations(map[string]string{recipients.RecipientsAnnotation: "slack:team-{{.app.metadata.labels.team}}"})))
+	s := NewServer(client, TestNamespace)
+
+	response, err := s.listSubscriptions("slack:team-a")
+
+	assert.NoError(t, err)
+
+	assert.Contains(t, response, "Applications: default/foo.")
+}
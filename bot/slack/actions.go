@@ -0,0 +1,154 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gobwas/glob"
+	log "github.com/sirupsen/logrus"
+	slackclient "github.com/slack-go/slack"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+const (
+	defaultSilenceDuration = time.Hour
+)
+
+// NewSlackActionsAdapter returns adapter of the Slack interactive message actions. The button value holds the action
+// parameters in the URL query format, e.g. action=silence&app=guestbook&trigger=on-sync-failed&duration=1h or
+// action=approve&app=guestbook&revision=<sha>&sync=true; the action name defaults to the button action_id.
+func NewSlackActionsAdapter(verifier RequestVerifier, policies PolicySource) *slackActions {
+	return &slackActions{verifier: verifier, policies: policies, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+type slackActions struct {
+	verifier RequestVerifier
+	policies PolicySource
+	client   *http.Client
+}
+
+func matchesAny(patterns []string, values ...string) bool {
+	for _, pattern := range patterns {
+		compiled, err := glob.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		for _, value := range values {
+			if value != "" && compiled.Match(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isAllowed returns true if one of the policies allows the user to run the action on the application. Users are
+// matched by the immutable user ID since Slack users might change their names.
+func isAllowed(policies []notifiers.SlackActionPolicy, user slackclient.User, action string, app string) bool {
+	for _, p := range policies {
+		if matchesAny(p.Users, user.ID) && matchesAny(p.Actions, action) && matchesAny(p.Apps, app) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *slackActions) ParseAction(r *http.Request) (bot.Action, error) {
+	action := bot.Action{}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return action, err
+	}
+	if err = s.verifier(data, r.Header); err != nil {
+		return action, fmt.Errorf("failed to verify request signature: %v", err)
+	}
+	query, err := url.ParseQuery(string(data))
+	if err != nil {
+		return action, err
+	}
+	var callback slackclient.InteractionCallback
+	if err = json.Unmarshal([]byte(query.Get("payload")), &callback); err != nil {
+		return action, fmt.Errorf("failed to parse interaction payload: %v", err)
+	}
+	action.Response = callback.ResponseURL
+	if callback.Type != slackclient.InteractionTypeBlockActions || len(callback.ActionCallback.BlockActions) == 0 {
+		return action, fmt.Errorf("interaction %s is not supported", callback.Type)
+	}
+	blockAction := callback.ActionCallback.BlockActions[0]
+	params, err := url.ParseQuery(blockAction.Value)
+	if err != nil || !strings.Contains(blockAction.Value, "=") {
+		params = url.Values{"app": []string{blockAction.Value}}
+	}
+	action.Name = params.Get("action")
+	if action.Name == "" {
+		action.Name = blockAction.ActionID
+	}
+	action.App = params.Get("app")
+	action.Trigger = params.Get("trigger")
+	action.Revision = params.Get("revision")
+	action.Sync = params.Get("sync") == "true"
+	action.User = "slack:" + callback.User.ID
+	if action.App == "" {
+		return action, errors.New("action does not reference the application")
+	}
+	if action.Name == bot.ActionSilence {
+		action.Duration = defaultSilenceDuration
+		if duration := params.Get("duration"); duration != "" {
+			if action.Duration, err = time.ParseDuration(duration); err != nil {
+				return action, fmt.Errorf("invalid silence duration '%s'", duration)
+			}
+		}
+		if callback.Channel.Name != "" {
+			action.Recipient = "slack:" + callback.Channel.Name
+		}
+	}
+	policies, err := s.policies()
+	if err != nil {
+		return action, err
+	}
+	if !isAllowed(policies, callback.User, action.Name, action.App) {
+		return action, fmt.Errorf("you are not allowed to %s application %s", action.Name, action.App)
+	}
+	// the approval that starts the sync requires the sync permission as well
+	if action.Sync && !isAllowed(policies, callback.User, bot.ActionSync, action.App) {
+		return action, fmt.Errorf("you are not allowed to %s application %s", bot.ActionSync, action.App)
+	}
+	return action, nil
+}
+
+// SendActionResponse posts the action result into the channel using the interaction response URL
+func (s *slackActions) SendActionResponse(action bot.Action, content string, w http.ResponseWriter) {
+	if strings.HasPrefix(action.User, "slack:") {
+		content = fmt.Sprintf("<@%s> %s", strings.TrimPrefix(action.User, "slack:"), content)
+	}
+	responseURL, _ := action.Response.(string)
+	if responseURL == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(content))
+		return
+	}
+	data, err := json.Marshal(map[string]interface{}{"response_type": "in_channel", "replace_original": false, "text": content})
+	if err == nil {
+		var resp *http.Response
+		resp, err = s.client.Post(responseURL, "application/json", bytes.NewReader(data))
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("response URL returned status code %d", resp.StatusCode)
+			}
+		}
+	}
+	if err != nil {
+		log.Errorf("Failed to send action response: %v", err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,126 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+func staticPolicies(policies ...notifiers.SlackActionPolicy) PolicySource {
+	return func() ([]notifiers.SlackActionPolicy, error) {
+		return policies, nil
+	}
+}
+
+func newActionRequest(t *testing.T, actionID string, value string) *http.Request {
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":         "block_actions",
+		"user":         map[string]string{"id": "U123", "name": "alice"},
+		"channel":      map[string]string{"id": "C123", "name": "alerts"},
+		"response_url": "https://hooks.slack.com/actions/1",
+		"actions":      []map[string]string{{"action_id": actionID, "block_id": "actions", "value": value, "type": "button"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewRequest("POST", "http://localhost/slack/actions",
+		bytes.NewBufferString(url.Values{"payload": []string{string(payload)}}.Encode()))
+}
+
+func TestParseAction_Sync(t *testing.T) {
+	s := NewSlackActionsAdapter(noopVerifier, staticPolicies(notifiers.SlackActionPolicy{
+		Users: []string{"U123"}, Actions: []string{"sync"}, Apps: []string{"guest*"},
+	}))
+
+	action, err := s.ParseAction(newActionRequest(t, "sync", "guestbook"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, bot.ActionSync, action.Name)
+	assert.Equal(t, "guestbook", action.App)
+	assert.Equal(t, "slack:U123", action.User)
+	assert.Equal(t, "https://hooks.slack.com/actions/1", action.Response)
+}
+
+func TestParseAction_Silence(t *testing.T) {
+	s := NewSlackActionsAdapter(noopVerifier, staticPolicies(notifiers.SlackActionPolicy{
+		Users: []string{"U123"}, Actions: []string{"*"}, Apps: []string{"*"},
+	}))
+
+	action, err := s.ParseAction(newActionRequest(t, "silence-1d", "action=silence&app=guestbook&trigger=on-sync-failed&duration=24h"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, bot.ActionSilence, action.Name)
+	assert.Equal(t, "guestbook", action.App)
+	assert.Equal(t, "on-sync-failed", action.Trigger)
+	assert.Equal(t, "slack:alerts", action.Recipient)
+	assert.Equal(t, 24*time.Hour, action.Duration)
+}
+
+func TestParseAction_NotAllowed(t *testing.T) {
+	s := NewSlackActionsAdapter(noopVerifier, staticPolicies(notifiers.SlackActionPolicy{
+		Users: []string{"*"}, Actions: []string{"refresh"}, Apps: []string{"*"},
+	}))
+
+	_, err := s.ParseAction(newActionRequest(t, "sync", "guestbook"))
+
+	assert.EqualError(t, err, "you are not allowed to sync application guestbook")
+}
+
+func TestParseAction_UserNameNotMatched(t *testing.T) {
+	s := NewSlackActionsAdapter(noopVerifier, staticPolicies(notifiers.SlackActionPolicy{
+		Users: []string{"alice"}, Actions: []string{"*"}, Apps: []string{"*"},
+	}))
+
+	_, err := s.ParseAction(newActionRequest(t, "sync", "guestbook"))
+
+	assert.EqualError(t, err, "you are not allowed to sync application guestbook")
+}
+
+func TestParseAction_Approve(t *testing.T) {
+	s := NewSlackActionsAdapter(noopVerifier, staticPolicies(notifiers.SlackActionPolicy{
+		Users: []string{"U123"}, Actions: []string{"approve", "sync"}, Apps: []string{"*"},
+	}))
+
+	action, err := s.ParseAction(newActionRequest(t, "approve", "action=approve&app=guestbook&revision=abc&sync=true"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, bot.ActionApprove, action.Name)
+	assert.Equal(t, "abc", action.Revision)
+	assert.True(t, action.Sync)
+}
+
+func TestParseAction_ApproveWithSyncNotAllowed(t *testing.T) {
+	s := NewSlackActionsAdapter(noopVerifier, staticPolicies(notifiers.SlackActionPolicy{
+		Users: []string{"U123"}, Actions: []string{"approve"}, Apps: []string{"*"},
+	}))
+
+	_, err := s.ParseAction(newActionRequest(t, "approve", "action=approve&app=guestbook&sync=true"))
+
+	assert.EqualError(t, err, "you are not allowed to sync application guestbook")
+}
+
+func TestSendActionResponse(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+	s := NewSlackActionsAdapter(noopVerifier, staticPolicies())
+	w := httptest.NewRecorder()
+
+	s.SendActionResponse(bot.Action{User: "slack:U123", Response: server.URL}, "Sync of application guestbook is started.", w)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]interface{}{
+		"response_type": "in_channel", "replace_original": false, "text": "<@U123> Sync of application guestbook is started.",
+	}, received)
+}
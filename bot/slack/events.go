@@ -0,0 +1,149 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	slackclient "github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+var mentionPattern = regexp.MustCompile(`<@[A-Z0-9]+>`)
+
+// NewSlackEventsAdapter returns adapter of the Events API app_mention and direct message events. The commands are
+// sent as the messages addressed to the bot, e.g. @argocd subscribe guestbook, and the responses are posted into the
+// conversation using the bot token.
+func NewSlackEventsAdapter(verifier RequestVerifier, options OptionsSource) *slackEvents {
+	return &slackEvents{verifier: verifier, options: options}
+}
+
+type slackEvents struct {
+	verifier RequestVerifier
+	options  OptionsSource
+	// apiURL overrides the Slack API URL; used in tests
+	apiURL string
+}
+
+// eventConversation references the message that has the command
+type eventConversation struct {
+	Channel  string
+	ThreadTS string
+}
+
+func (s *slackEvents) client() (*slackclient.Client, error) {
+	opts, err := s.options()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Token == "" {
+		return nil, errors.New("slack token is not configured")
+	}
+	var clientOpts []slackclient.Option
+	if s.apiURL != "" {
+		clientOpts = append(clientOpts, slackclient.OptionAPIURL(s.apiURL))
+	}
+	return slackclient.New(opts.Token, clientOpts...), nil
+}
+
+func (s *slackEvents) Parse(r *http.Request) (bot.Command, error) {
+	cmd := bot.Command{}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return cmd, err
+	}
+	if err = s.verifier(data, r.Header); err != nil {
+		return cmd, fmt.Errorf("failed to verify request signature: %v", err)
+	}
+	event, err := slackevents.ParseEvent(json.RawMessage(data), slackevents.OptionNoVerifyToken())
+	if err != nil {
+		// events the bot is not subscribed to are acknowledged to prevent retries
+		return cmd, bot.Acknowledgement{}
+	}
+	switch event.Type {
+	case slackevents.URLVerification:
+		verification, _ := event.Data.(*slackevents.EventsAPIURLVerificationEvent)
+		if verification == nil {
+			return cmd, errors.New("unexpected url verification event")
+		}
+		return cmd, bot.Acknowledgement{Response: verification.Challenge}
+	case slackevents.CallbackEvent:
+	default:
+		return cmd, bot.Acknowledgement{}
+	}
+
+	var user, text, channel, threadTS string
+	directMessage := false
+	switch e := event.InnerEvent.Data.(type) {
+	case *slackevents.AppMentionEvent:
+		if e.BotID != "" {
+			return cmd, bot.Acknowledgement{}
+		}
+		user, text, channel, threadTS = e.User, e.Text, e.Channel, e.ThreadTimeStamp
+	case *slackevents.MessageEvent:
+		// channel messages are handled as app_mention events
+		if e.ChannelType != "im" || e.BotID != "" || e.SubType != "" {
+			return cmd, bot.Acknowledgement{}
+		}
+		user, text, channel, threadTS = e.User, e.Text, e.Channel, e.ThreadTimeStamp
+		directMessage = true
+	default:
+		return cmd, bot.Acknowledgement{}
+	}
+
+	conversation := &eventConversation{Channel: channel, ThreadTS: threadTS}
+	cmd.Conversation = conversation
+	recipient := fmt.Sprintf("slack:@%s", user)
+	if !directMessage {
+		// subscriptions reference channels by name, the same way as the slash commands
+		client, err := s.client()
+		if err != nil {
+			return cmd, err
+		}
+		info, err := client.GetConversationInfoContext(context.TODO(), channel, false)
+		if err != nil {
+			return cmd, fmt.Errorf("failed to get channel %s: %v", channel, err)
+		}
+		recipient = fmt.Sprintf("slack:%s", info.Name)
+	}
+	// the usage instructions reference the bot using the mention of the command message
+	botCommand := "@argocd"
+	if mention := mentionPattern.FindString(text); mention != "" {
+		botCommand = mention
+	}
+	cmd, err = parseCommand(botCommand, recipient, strings.Fields(mentionPattern.ReplaceAllString(text, "")))
+	cmd.Conversation = conversation
+	cmd.User = fmt.Sprintf("slack:%s", user)
+	return cmd, err
+}
+
+// SendResponse acknowledges the event; the command response is sent using the Reply method
+func (s *slackEvents) SendResponse(content string, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(content))
+}
+
+// Reply posts the response into the conversation of the command message
+func (s *slackEvents) Reply(conversation interface{}, content string) error {
+	c, ok := conversation.(*eventConversation)
+	if !ok {
+		return errors.New("unexpected conversation reference")
+	}
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+	msgOptions := []slackclient.MsgOption{slackclient.MsgOptionText(content, false)}
+	if c.ThreadTS != "" {
+		msgOptions = append(msgOptions, slackclient.MsgOptionTS(c.ThreadTS))
+	}
+	_, _, err = client.PostMessageContext(context.TODO(), c.Channel, msgOptions...)
+	return err
+}
@@ -0,0 +1,95 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+func newTestEventsAdapter(handler http.HandlerFunc) (*slackEvents, func()) {
+	server := httptest.NewServer(handler)
+	adapter := NewSlackEventsAdapter(noopVerifier, func() (*notifiers.SlackOptions, error) {
+		return &notifiers.SlackOptions{Token: "xoxb-token"}, nil
+	})
+	adapter.apiURL = server.URL + "/"
+	return adapter, server.Close
+}
+
+func TestEvents_URLVerification(t *testing.T) {
+	adapter, closeServer := newTestEventsAdapter(nil)
+	defer closeServer()
+
+	_, err := adapter.Parse(httptest.NewRequest("POST", "http://localhost/slack/events",
+		bytes.NewBufferString(`{"type": "url_verification", "challenge": "abc"}`)))
+
+	assert.Equal(t, bot.Acknowledgement{Response: "abc"}, err)
+}
+
+func TestEvents_AppMention(t *testing.T) {
+	adapter, closeServer := newTestEventsAdapter(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/conversations.info", r.URL.Path)
+		_, _ = w.Write([]byte(`{"ok": true, "channel": {"id": "C123", "name": "alerts"}}`))
+	})
+	defer closeServer()
+
+	cmd, err := adapter.Parse(httptest.NewRequest("POST", "http://localhost/slack/events", bytes.NewBufferString(`{
+  "type": "event_callback",
+  "event": {"type": "app_mention", "user": "U123", "text": "<@UBOT> subscribe foo on-sync-failed", "channel": "C123"}
+}`)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "slack:alerts", cmd.Recipient)
+	assert.Equal(t, &bot.UpdateSubscription{App: "foo", Trigger: "on-sync-failed"}, cmd.Subscribe)
+	assert.Equal(t, &eventConversation{Channel: "C123"}, cmd.Conversation)
+}
+
+func TestEvents_DirectMessage(t *testing.T) {
+	adapter, closeServer := newTestEventsAdapter(nil)
+	defer closeServer()
+
+	cmd, err := adapter.Parse(httptest.NewRequest("POST", "http://localhost/slack/events", bytes.NewBufferString(`{
+  "type": "event_callback",
+  "event": {"type": "message", "channel_type": "im", "user": "U123", "text": "list-subscriptions", "channel": "D123"}
+}`)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "slack:@U123", cmd.Recipient)
+	assert.NotNil(t, cmd.ListSubscriptions)
+}
+
+func TestEvents_IgnoresBotMessages(t *testing.T) {
+	adapter, closeServer := newTestEventsAdapter(nil)
+	defer closeServer()
+
+	_, err := adapter.Parse(httptest.NewRequest("POST", "http://localhost/slack/events", bytes.NewBufferString(`{
+  "type": "event_callback",
+  "event": {"type": "message", "channel_type": "im", "bot_id": "B123", "text": "The slack:@U123 has no subscriptions.", "channel": "D123"}
+}`)))
+
+	assert.Equal(t, bot.Acknowledgement{}, err)
+}
+
+func TestEvents_Reply(t *testing.T) {
+	var form map[string][]string
+	adapter, closeServer := newTestEventsAdapter(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat.postMessage", r.URL.Path)
+		_ = r.ParseForm()
+		form = r.PostForm
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
+	})
+	defer closeServer()
+
+	err := adapter.Reply(&eventConversation{Channel: "C123", ThreadTS: "1.2"}, "subscription updated")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"C123"}, form["channel"])
+	assert.Equal(t, []string{"subscription updated"}, form["text"])
+	assert.Equal(t, []string{"1.2"}, form["thread_ts"])
+}
@@ -16,6 +16,11 @@ import (
 	slackclient "github.com/slack-go/slack"
 )
 
+const (
+	// directMessageChannel is the channel name of the slash commands sent in the direct messages
+	directMessageChannel = "directmessage"
+)
+
 func NewSlackAdapter(verifier RequestVerifier) *slack {
 	return &slack{verifier: verifier}
 }
@@ -30,20 +35,22 @@ func mustTemplate(text string) *texttemplate.Template {
 
 var commandsHelp = map[string]*texttemplate.Template{
 	"list-subscriptions": mustTemplate("*List your subscriptions*:\n" + "```{{.cmd}} list-subscriptions```"),
-	"subscribe": mustTemplate("*Subscribe current channel*:\n" +
+	"subscribe": mustTemplate("*Subscribe current channel or yourself in the direct message*:\n" +
 		"```{{.cmd}} subscribe <my-app> <optional-trigger>\n" +
-		"{{.cmd}} subscribe proj:<my-proj> <optional-trigger>```"),
-	"unsubscribe": mustTemplate("*Unsubscribe current channel*:\n" +
+		"{{.cmd}} subscribe proj:<my-proj> <optional-trigger>\n" +
+		"{{.cmd}} subscribe selector:<label-selector> <optional-trigger>```"),
+	"unsubscribe": mustTemplate("*Unsubscribe current channel or yourself in the direct message*:\n" +
 		"```{{.cmd}} unsubscribe <my-app> <optional-trigger>\n" +
-		"{{.cmd}} unsubscribe proj:<my-proj> <optional-trigger>```"),
+		"{{.cmd}} unsubscribe proj:<my-proj> <optional-trigger>\n" +
+		"{{.cmd}} unsubscribe selector:<label-selector> <optional-trigger>```"),
+	"status": mustTemplate("*Show application status*:\n" + "```{{.cmd}} status <my-app>```"),
+	"diff":   mustTemplate("*Show out of sync application resources*:\n" + "```{{.cmd}} diff <my-app>```"),
+	"mute": mustTemplate("*Mute notifications sent to the current channel*:\n" +
+		"```{{.cmd}} mute <my-app> for <duration>\n" +
+		"{{.cmd}} mute trigger:<my-trigger> for <duration>```"),
 }
 
-func usageInstructions(query url.Values, command string, err error) string {
-	botCommand := "/argocd"
-	if cmd := query.Get("command"); cmd != "" {
-		botCommand = cmd
-	}
-
+func usageInstructions(botCommand string, command string, err error) string {
 	var usage bytes.Buffer
 	if err != nil {
 		usage.WriteString(err.Error() + "\n")
@@ -78,55 +85,43 @@ func (s *slack) parseQuery(r *http.Request) (url.Values, error) {
 }
 
 func (s *slack) Parse(r *http.Request) (bot.Command, error) {
-	cmd := bot.Command{}
 	query, err := s.parseQuery(r)
 	if err != nil {
-		return cmd, err
+		return bot.Command{}, err
 	}
 	channel := query.Get("channel_name")
 	if channel == "" {
-		return cmd, errors.New("request does not have channel")
+		return bot.Command{}, errors.New("request does not have channel")
+	}
+	botCommand := "/argocd"
+	if cmd := query.Get("command"); cmd != "" {
+		botCommand = cmd
 	}
-	parts := strings.Fields(query.Get("text"))
-	if len(parts) < 1 {
-		return cmd, errors.New(usageInstructions(query, "", nil))
+	recipient := fmt.Sprintf("slack:%s", channel)
+	if channel == directMessageChannel {
+		// the direct message commands manage subscriptions of the user; the notifier resolves the user direct
+		// message channel at send time
+		userID := query.Get("user_id")
+		if userID == "" {
+			return bot.Command{}, errors.New("request does not have user")
+		}
+		recipient = fmt.Sprintf("slack:@%s", userID)
 	}
-	command := parts[0]
-
-	cmd.Recipient = fmt.Sprintf("slack:%s", channel)
+	cmd, err := parseCommand(botCommand, recipient, strings.Fields(query.Get("text")))
+	if userID := query.Get("user_id"); userID != "" {
+		cmd.User = fmt.Sprintf("slack:%s", userID)
+	}
+	return cmd, err
+}
 
-	switch command {
-	case "list-subscriptions":
-		cmd.ListSubscriptions = &bot.ListSubscriptions{}
-	case "subscribe", "unsubscribe":
-		if len(parts) < 2 {
-			return cmd, errors.New(usageInstructions(query, command, errors.New("at least one argument expected")))
-		}
-		update := &bot.UpdateSubscription{}
-		nameParts := strings.Split(parts[1], ":")
-		if len(nameParts) == 1 {
-			nameParts = append([]string{"app"}, nameParts...)
-		}
-		switch nameParts[0] {
-		case "app":
-			update.App = nameParts[1]
-		case "proj":
-			update.Project = nameParts[1]
-		default:
-			return cmd, errors.New(usageInstructions(query, command, fmt.Errorf("incorrect name argument: %s", parts[1])))
-		}
-		if len(parts) > 2 {
-			update.Trigger = parts[2]
-		}
-		if command == "subscribe" {
-			cmd.Subscribe = update
-		} else {
-			cmd.Unsubscribe = update
-		}
-	default:
-		return cmd, errors.New(usageInstructions(query, "", nil))
+// parseCommand parses the command arguments sent using the slash command or the message addressed to the bot
+func parseCommand(botCommand string, recipient string, parts []string) (bot.Command, error) {
+	cmd, err := bot.ParseArgs(parts)
+	cmd.Recipient = recipient
+	if usageErr, ok := err.(*bot.UsageError); ok {
+		return cmd, errors.New(usageInstructions(botCommand, usageErr.Command, usageErr.Err))
 	}
-	return cmd, nil
+	return cmd, err
 }
 
 func (s *slack) SendResponse(content string, w http.ResponseWriter) {
@@ -6,8 +6,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
 )
 
 var noopVerifier = func(data []byte, header http.Header) error {
@@ -53,6 +56,20 @@ func TestParse_SubscribeProject(t *testing.T) {
 	assert.Equal(t, cmd.Recipient, "slack:test")
 }
 
+func TestParse_SubscribeSelectorInDirectMessage(t *testing.T) {
+	s := NewSlackAdapter(noopVerifier)
+
+	cmd, err := s.Parse(httptest.NewRequest("GET", "http://localhost/slack",
+		bytes.NewBufferString("text=subscribe%20selector%3Ateam%3Dpayments%20on-sync-failed&channel_name=directmessage&user_id=U123")))
+	assert.NoError(t, err)
+
+	assert.NotNil(t, cmd.Subscribe)
+	assert.Equal(t, cmd.Subscribe.Selector, "team=payments")
+	assert.Equal(t, cmd.Subscribe.Trigger, "on-sync-failed")
+	assert.Equal(t, cmd.Recipient, "slack:@U123")
+	assert.Equal(t, cmd.User, "slack:U123")
+}
+
 func TestParse_UnsubscribeApp(t *testing.T) {
 	s := NewSlackAdapter(noopVerifier)
 
@@ -107,3 +124,37 @@ func TestSendResponse(t *testing.T) {
 
 	assert.Equal(t, `{"blocks":[{"type":"section","text":{"type":"mrkdwn","text":"test"}}]}`, string(body))
 }
+
+func TestParse_StatusCommand(t *testing.T) {
+	s := NewSlackAdapter(noopVerifier)
+
+	cmd, err := s.Parse(httptest.NewRequest("GET", "http://localhost/slack",
+		bytes.NewBufferString("text=status%20foo&channel_name=test")))
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, cmd.Status) {
+		assert.Equal(t, "foo", cmd.Status.App)
+	}
+	assert.Nil(t, cmd.Diff)
+}
+
+func TestParse_DiffCommandWithoutApp(t *testing.T) {
+	s := NewSlackAdapter(noopVerifier)
+
+	_, err := s.Parse(httptest.NewRequest("GET", "http://localhost/slack",
+		bytes.NewBufferString("text=diff&channel_name=test")))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "application name expected")
+}
+
+func TestParse_MuteCommand(t *testing.T) {
+	s := NewSlackAdapter(noopVerifier)
+
+	cmd, err := s.Parse(httptest.NewRequest("GET", "http://localhost/slack",
+		bytes.NewBufferString("text=mute%20trigger%3Aon-sync-failed%20for%2030m&channel_name=test&user_id=U123")))
+	assert.NoError(t, err)
+
+	assert.Equal(t, &bot.MuteNotifications{Trigger: "on-sync-failed", Duration: 30 * time.Minute}, cmd.Mute)
+	assert.Equal(t, cmd.Recipient, "slack:test")
+}
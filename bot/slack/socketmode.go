@@ -0,0 +1,229 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+const (
+	defaultSlackAPIURL = "https://slack.com/api/"
+	// socketModeRetryInterval is the interval between the connection attempts and the Socket Mode settings checks
+	socketModeRetryInterval = 30 * time.Second
+)
+
+// errReconnect is returned when Slack asks to reconnect, e.g. before the connection refresh
+var errReconnect = errors.New("reconnect requested")
+
+type socketModeEnvelope struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type socketModeAck struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// NewSocketModeClient returns client that receives the slash commands, interactive message actions and Events API
+// events using the Socket Mode connection, so the bot does not need the public endpoints. The client connects only if
+// the socketMode is enabled in the slack section of the notifiers.yaml.
+func NewSocketModeClient(server bot.Server, options OptionsSource) *socketModeClient {
+	// the requests are received using the connection authenticated by the app token and don't have signatures
+	trusted := func(data []byte, header http.Header) error {
+		return nil
+	}
+	policies := func() ([]notifiers.SlackActionPolicy, error) {
+		opts, err := options()
+		if err != nil {
+			return nil, err
+		}
+		return opts.ActionPolicies, nil
+	}
+	return &socketModeClient{
+		options:       options,
+		commands:      server.Handler(NewSlackAdapter(trusted)),
+		actions:       server.ActionHandler(NewSlackActionsAdapter(trusted, policies)),
+		events:        server.Handler(NewSlackEventsAdapter(trusted, options)),
+		apiURL:        defaultSlackAPIURL,
+		retryInterval: socketModeRetryInterval,
+		client:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type socketModeClient struct {
+	options       OptionsSource
+	commands      http.Handler
+	actions       http.Handler
+	events        http.Handler
+	apiURL        string
+	retryInterval time.Duration
+	client        *http.Client
+}
+
+// Run maintains the Socket Mode connection until the stop channel is closed
+func (c *socketModeClient) Run(stopCh <-chan struct{}) {
+	for {
+		err := c.connect(stopCh)
+		if err == errReconnect {
+			continue
+		}
+		if err != nil {
+			log.Errorf("Slack Socket Mode connection failed: %v", err)
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(c.retryInterval):
+		}
+	}
+}
+
+// openConnection returns the WebSocket URL of the new Socket Mode connection
+func (c *socketModeClient) openConnection(appToken string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, c.apiURL+"apps.connections.open", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+appToken)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var res struct {
+		OK    bool   `json:"ok"`
+		URL   string `json:"url"`
+		Error string `json:"error"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", err
+	}
+	if !res.OK {
+		return "", fmt.Errorf("failed to open connection: %s", res.Error)
+	}
+	return res.URL, nil
+}
+
+func (c *socketModeClient) connect(stopCh <-chan struct{}) error {
+	opts, err := c.options()
+	if err != nil {
+		return err
+	}
+	if !opts.SocketMode {
+		return nil
+	}
+	if opts.AppToken == "" {
+		return errors.New("slack app token is not configured")
+	}
+	wsURL, err := c.openConnection(opts.AppToken)
+	if err != nil {
+		return err
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stopCh:
+		case <-done:
+		}
+		_ = conn.Close()
+	}()
+	log.Info("Slack Socket Mode connection is established")
+	for {
+		var envelope socketModeEnvelope
+		if err := conn.ReadJSON(&envelope); err != nil {
+			select {
+			case <-stopCh:
+				return nil
+			default:
+				return err
+			}
+		}
+		if err := c.handle(conn, envelope); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *socketModeClient) handle(conn *websocket.Conn, envelope socketModeEnvelope) error {
+	switch envelope.Type {
+	case "hello":
+		return nil
+	case "disconnect":
+		return errReconnect
+	case "slash_commands":
+		var fields map[string]interface{}
+		if err := json.Unmarshal(envelope.Payload, &fields); err != nil {
+			return conn.WriteJSON(socketModeAck{EnvelopeID: envelope.EnvelopeID})
+		}
+		query := url.Values{}
+		for k, v := range fields {
+			query.Set(k, fmt.Sprint(v))
+		}
+		// the slash command response is sent in the acknowledgement payload
+		response := dispatch(c.commands, "/slack", query.Encode())
+		return conn.WriteJSON(socketModeAck{EnvelopeID: envelope.EnvelopeID, Payload: response.Bytes()})
+	case "interactive":
+		if err := conn.WriteJSON(socketModeAck{EnvelopeID: envelope.EnvelopeID}); err != nil {
+			return err
+		}
+		dispatch(c.actions, "/slack/actions", url.Values{"payload": []string{string(envelope.Payload)}}.Encode())
+		return nil
+	case "events_api":
+		if err := conn.WriteJSON(socketModeAck{EnvelopeID: envelope.EnvelopeID}); err != nil {
+			return err
+		}
+		dispatch(c.events, "/slack/events", string(envelope.Payload))
+		return nil
+	default:
+		log.Debugf("Ignoring Slack Socket Mode message %s", envelope.Type)
+		if envelope.EnvelopeID != "" {
+			return conn.WriteJSON(socketModeAck{EnvelopeID: envelope.EnvelopeID})
+		}
+		return nil
+	}
+}
+
+// bufferResponseWriter collects the handler response
+type bufferResponseWriter struct {
+	bytes.Buffer
+	header http.Header
+}
+
+func (w *bufferResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferResponseWriter) WriteHeader(_ int) {
+}
+
+// dispatch runs the handler of the bot endpoint and returns the response body
+func dispatch(handler http.Handler, path string, body string) *bufferResponseWriter {
+	w := &bufferResponseWriter{header: http.Header{}}
+	r, err := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if err != nil {
+		log.Errorf("Failed to create request: %v", err)
+		return w
+	}
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,78 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestSocketModeClient_SlashCommand(t *testing.T) {
+	acks := make(chan socketModeAck, 1)
+	upgrader := websocket.Upgrader{}
+	var wsURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apps.connections.open":
+			assert.Equal(t, "Bearer xapp-token", r.Header.Get("Authorization"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "url": wsURL})
+		case "/ws":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+			_ = conn.WriteJSON(map[string]interface{}{"type": "hello"})
+			_ = conn.WriteJSON(map[string]interface{}{
+				"envelope_id": "1",
+				"type":        "slash_commands",
+				"payload":     map[string]interface{}{"command": "/argocd", "text": "list-subscriptions", "channel_name": "alerts"},
+			})
+			var ack socketModeAck
+			if assert.NoError(t, conn.ReadJSON(&ack)) {
+				acks <- ack
+			}
+		}
+	}))
+	defer server.Close()
+	wsURL = "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	client := NewSocketModeClient(bot.NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace), func() (*notifiers.SlackOptions, error) {
+		return &notifiers.SlackOptions{SocketMode: true, AppToken: "xapp-token"}, nil
+	})
+	client.apiURL = server.URL + "/"
+	client.retryInterval = time.Hour
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go client.Run(stopCh)
+
+	select {
+	case ack := <-acks:
+		assert.Equal(t, "1", ack.EnvelopeID)
+		assert.Contains(t, string(ack.Payload), "The slack:alerts has no subscriptions.")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for acknowledgement")
+	}
+}
+
+func TestSocketModeClient_Disabled(t *testing.T) {
+	client := NewSocketModeClient(bot.NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace), func() (*notifiers.SlackOptions, error) {
+		return &notifiers.SlackOptions{}, nil
+	})
+	client.apiURL = "http://invalid/"
+
+	assert.NoError(t, client.connect(make(chan struct{})))
+}
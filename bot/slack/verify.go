@@ -9,32 +9,47 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/cache"
 
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
 	"github.com/argoproj-labs/argocd-notifications/shared/settings"
 )
 
 type RequestVerifier func(data []byte, header http.Header) error
 
+// OptionsSource returns the slack settings configured in the notifiers.yaml
+type OptionsSource func() (*notifiers.SlackOptions, error)
+
+// PolicySource returns the policies of the interactive message actions
+type PolicySource func() ([]notifiers.SlackActionPolicy, error)
+
+func getSlackOptions(secretInformer cache.SharedIndexInformer) (*notifiers.SlackOptions, error) {
+	secrets := secretInformer.GetStore().List()
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("cannot find secret %s the slack app secret", settings.SecretName)
+	}
+	secret, ok := secrets[0].(*v1.Secret)
+	if !ok {
+		return nil, errors.New("unexpected object in the secret informer storage")
+	}
+	config, err := settings.ParseSecret(secret)
+	if err != nil {
+		return nil, errors.New("unable to parse slack configuration")
+	}
+	if config.Slack == nil {
+		return nil, errors.New("slack is not configured")
+	}
+	return config.Slack, nil
+}
+
 func NewVerifier(secretInformer cache.SharedIndexInformer) RequestVerifier {
 	return func(data []byte, header http.Header) error {
-		secrets := secretInformer.GetStore().List()
-		if len(secrets) == 0 {
-			return fmt.Errorf("cannot find secret %s the slack app secret", settings.SecretName)
-		}
-		secret, ok := secrets[0].(*v1.Secret)
-		if !ok {
-			return errors.New("unexpected object in the secret informer storage")
-		}
-		config, err := settings.ParseSecret(secret)
+		opts, err := getSlackOptions(secretInformer)
 		if err != nil {
-			return errors.New("unable to parse slack configuration")
-		}
-		if config.Slack == nil {
-			return errors.New("slack is not configured")
+			return err
 		}
-		if config.Slack.SigningSecret == "" {
+		if opts.SigningSecret == "" {
 			return errors.New("slack signing secret is not configured")
 		}
-		verifier, err := slackclient.NewSecretsVerifier(header, config.Slack.SigningSecret)
+		verifier, err := slackclient.NewSecretsVerifier(header, opts.SigningSecret)
 		if err != nil {
 			return err
 		}
@@ -45,3 +60,21 @@ func NewVerifier(secretInformer cache.SharedIndexInformer) RequestVerifier {
 		return verifier.Ensure()
 	}
 }
+
+// NewPolicySource returns source of the action policies configured in the slack section of the notifiers.yaml
+func NewPolicySource(secretInformer cache.SharedIndexInformer) PolicySource {
+	return func() ([]notifiers.SlackActionPolicy, error) {
+		opts, err := getSlackOptions(secretInformer)
+		if err != nil {
+			return nil, err
+		}
+		return opts.ActionPolicies, nil
+	}
+}
+
+// NewOptionsSource returns source of the slack section of the notifiers.yaml
+func NewOptionsSource(secretInformer cache.SharedIndexInformer) OptionsSource {
+	return func() (*notifiers.SlackOptions, error) {
+		return getSlackOptions(secretInformer)
+	}
+}
@@ -0,0 +1,336 @@
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const (
+	defaultTokenURL   = "https://login.microsoftonline.com/botframework.com/oauth2/v2.0/token"
+	botFrameworkScope = "https://api.botframework.com/.default"
+	generalChannel    = "General"
+	maxActivitySize   = 1 << 20
+	requestTimeout    = 30 * time.Second
+)
+
+var (
+	mentionRegexp = regexp.MustCompile(`<at>[^<]*</at>`)
+	tagRegexp     = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Credentials are the Bot Framework application credentials
+type Credentials struct {
+	AppID       string
+	AppPassword string
+}
+
+// CredentialsSource returns the current Bot Framework application credentials
+type CredentialsSource func() (Credentials, error)
+
+// NewCredentialsSource returns credentials source that reads the teams configuration of the argocd-notifications-secret
+func NewCredentialsSource(secretInformer cache.SharedIndexInformer) CredentialsSource {
+	return func() (Credentials, error) {
+		secrets := secretInformer.GetStore().List()
+		if len(secrets) == 0 {
+			return Credentials{}, fmt.Errorf("cannot find secret %s with the teams app credentials", settings.SecretName)
+		}
+		secret, ok := secrets[0].(*v1.Secret)
+		if !ok {
+			return Credentials{}, errors.New("unexpected object in the secret informer storage")
+		}
+		config, err := settings.ParseSecret(secret)
+		if err != nil {
+			return Credentials{}, errors.New("unable to parse teams configuration")
+		}
+		if config.Teams == nil || config.Teams.AppID == "" || config.Teams.AppPassword == "" {
+			return Credentials{}, errors.New("teams appId and appPassword are not configured")
+		}
+		return Credentials{AppID: config.Teams.AppID, AppPassword: config.Teams.AppPassword}, nil
+	}
+}
+
+type channelAccount struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type conversationAccount struct {
+	ID               string `json:"id"`
+	ConversationType string `json:"conversationType,omitempty"`
+}
+
+type channelInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// activity is the subset of the Bot Framework activity fields used by the bot
+type activity struct {
+	Type         string              `json:"type"`
+	ID           string              `json:"id,omitempty"`
+	Text         string              `json:"text,omitempty"`
+	TextFormat   string              `json:"textFormat,omitempty"`
+	ServiceURL   string              `json:"serviceUrl,omitempty"`
+	From         channelAccount      `json:"from"`
+	Recipient    channelAccount      `json:"recipient"`
+	Conversation conversationAccount `json:"conversation"`
+	ReplyToID    string              `json:"replyToId,omitempty"`
+	ChannelData  *struct {
+		Channel *channelInfo `json:"channel,omitempty"`
+		Team    *channelInfo `json:"team,omitempty"`
+	} `json:"channelData,omitempty"`
+}
+
+type accessToken struct {
+	value     string
+	appID     string
+	expiresAt time.Time
+}
+
+func NewTeamsAdapter(credentials CredentialsSource) *teams {
+	client := &http.Client{Timeout: requestTimeout}
+	return &teams{
+		credentials: credentials,
+		keys:        &keySet{openIDConfigURL: defaultOpenIDConfigURL, client: client},
+		tokenURL:    defaultTokenURL,
+		client:      client,
+	}
+}
+
+type teams struct {
+	credentials CredentialsSource
+	keys        *keySet
+	tokenURL    string
+	client      *http.Client
+
+	lock  sync.Mutex
+	token accessToken
+}
+
+var commandsHelp = []struct {
+	name  string
+	usage string
+}{
+	{"list-subscriptions", "**List channel subscriptions**:\n\n`{{.cmd}} list-subscriptions`"},
+	{"subscribe", "**Subscribe current channel**:\n\n`{{.cmd}} subscribe <my-app> <optional-trigger>`\n\n" +
+		"`{{.cmd}} subscribe proj:<my-proj> <optional-trigger>`\n\n`{{.cmd}} subscribe selector:<label-selector> <optional-trigger>`"},
+	{"unsubscribe", "**Unsubscribe current channel**:\n\n`{{.cmd}} unsubscribe <my-app> <optional-trigger>`\n\n" +
+		"`{{.cmd}} unsubscribe proj:<my-proj> <optional-trigger>`\n\n`{{.cmd}} unsubscribe selector:<label-selector> <optional-trigger>`"},
+	{"status", "**Show application status**:\n\n`{{.cmd}} status <my-app>`"},
+	{"diff", "**Show out of sync application resources**:\n\n`{{.cmd}} diff <my-app>`"},
+	{"mute", "**Mute notifications sent to the current channel**:\n\n`{{.cmd}} mute <my-app> for <duration>`\n\n" +
+		"`{{.cmd}} mute trigger:<my-trigger> for <duration>`"},
+}
+
+func usageInstructions(botName string, command string, err error) string {
+	botCommand := "@" + botName
+	var usage strings.Builder
+	if err != nil {
+		usage.WriteString(err.Error() + "\n\n")
+	}
+	found := false
+	for _, help := range commandsHelp {
+		if help.name == command {
+			usage.WriteString(strings.ReplaceAll(help.usage, "{{.cmd}}", botCommand))
+			found = true
+		}
+	}
+	if !found {
+		usage.WriteString(fmt.Sprintf("Need some help with `%s`?\n\n", botCommand))
+		for _, help := range commandsHelp {
+			usage.WriteString(strings.ReplaceAll(help.usage, "{{.cmd}}", botCommand) + "\n\n")
+		}
+	}
+	return usage.String()
+}
+
+// commandText returns the message text without the bot mention and HTML formatting
+func commandText(text string) string {
+	text = mentionRegexp.ReplaceAllString(text, " ")
+	text = tagRegexp.ReplaceAllString(text, " ")
+	return strings.ReplaceAll(html.UnescapeString(text), "\u00a0", " ")
+}
+
+func (t *teams) Parse(r *http.Request) (bot.Command, error) {
+	cmd := bot.Command{}
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxActivitySize))
+	if err != nil {
+		return cmd, err
+	}
+	var a activity
+	if err = json.Unmarshal(data, &a); err != nil {
+		return cmd, fmt.Errorf("failed to parse activity: %v", err)
+	}
+	credentials, err := t.credentials()
+	if err != nil {
+		return cmd, err
+	}
+	if err = t.keys.verify(r.Header.Get("Authorization"), credentials.AppID, a.ServiceURL); err != nil {
+		return cmd, fmt.Errorf("failed to verify request token: %v", err)
+	}
+	if a.Type != "message" {
+		return cmd, fmt.Errorf("activity type %s is not supported", a.Type)
+	}
+	// the response is sent into the conversation only if the request is verified
+	cmd.Conversation = &a
+	if a.ChannelData == nil || a.ChannelData.Channel == nil || a.ChannelData.Team == nil {
+		return cmd, errors.New("bot commands are only supported in team channels")
+	}
+	channel, err := t.channelName(credentials, &a)
+	if err != nil {
+		return cmd, err
+	}
+	parsed, err := bot.ParseArgs(strings.Fields(commandText(a.Text)))
+	if usageErr, ok := err.(*bot.UsageError); ok {
+		return cmd, errors.New(usageInstructions(a.Recipient.Name, usageErr.Command, usageErr.Err))
+	}
+	parsed.Conversation = cmd.Conversation
+	parsed.Recipient = fmt.Sprintf("teams:%s", channel)
+	parsed.User = fmt.Sprintf("teams:%s", a.From.ID)
+	return parsed, err
+}
+
+// channelName returns the name of the activity channel. Message activities include only the channel id, so the name
+// is loaded using the team channels API; the General channel has no name.
+func (t *teams) channelName(credentials Credentials, a *activity) (string, error) {
+	if name := a.ChannelData.Channel.Name; name != "" {
+		return name, nil
+	}
+	var res struct {
+		Conversations []channelInfo `json:"conversations"`
+	}
+	err := t.do(credentials, http.MethodGet,
+		fmt.Sprintf("%s/v3/teams/%s/conversations", strings.TrimRight(a.ServiceURL, "/"), url.PathEscape(a.ChannelData.Team.ID)), nil, &res)
+	if err != nil {
+		return "", fmt.Errorf("failed to get team channels: %v", err)
+	}
+	for _, c := range res.Conversations {
+		if c.ID == a.ChannelData.Channel.ID {
+			if c.Name == "" {
+				return generalChannel, nil
+			}
+			return c.Name, nil
+		}
+	}
+	return "", fmt.Errorf("channel %s is not found", a.ChannelData.Channel.ID)
+}
+
+// SendResponse acknowledges the activity; responses are sent using Reply since Bot Framework ignores response body
+func (t *teams) SendResponse(_ string, w http.ResponseWriter) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// Reply sends the response into the conversation of the activity
+func (t *teams) Reply(conversation interface{}, content string) error {
+	a, ok := conversation.(*activity)
+	if !ok {
+		return errors.New("unexpected conversation reference")
+	}
+	credentials, err := t.credentials()
+	if err != nil {
+		return err
+	}
+	reply := activity{
+		Type:         "message",
+		Text:         strings.ReplaceAll(content, "\n", "\n\n"),
+		TextFormat:   "markdown",
+		From:         a.Recipient,
+		Recipient:    a.From,
+		Conversation: a.Conversation,
+		ReplyToID:    a.ID,
+	}
+	return t.do(credentials, http.MethodPost, fmt.Sprintf("%s/v3/conversations/%s/activities/%s",
+		strings.TrimRight(a.ServiceURL, "/"), url.PathEscape(a.Conversation.ID), url.PathEscape(a.ID)), reply, nil)
+}
+
+// do sends the Bot Framework API request authenticated with the application access token
+func (t *teams) do(credentials Credentials, method string, url string, body interface{}, res interface{}) error {
+	token, err := t.getToken(credentials)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if body != nil {
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("request to %s has failed with error code %d : %s", url, resp.StatusCode, string(data))
+	}
+	if res == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+// getToken returns the cached access token or requests a new one using the client credentials grant
+func (t *teams) getToken(credentials Credentials) (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.token.appID == credentials.AppID && time.Now().Before(t.token.expiresAt) {
+		return t.token.value, nil
+	}
+	resp, err := t.client.PostForm(t.tokenURL, url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {credentials.AppID},
+		"client_secret": {credentials.AppPassword},
+		"scope":         {botFrameworkScope},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get Bot Framework access token: error code %d", resp.StatusCode)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	// the token is refreshed a minute before the expiration
+	t.token = accessToken{
+		value:     token.AccessToken,
+		appID:     credentials.AppID,
+		expiresAt: time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute),
+	}
+	return t.token.value, nil
+}
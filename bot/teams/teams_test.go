@@ -0,0 +1,160 @@
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBotFramework struct {
+	server  *httptest.Server
+	adapter *teams
+	replies []activity
+	auth    []string
+	sign    func(claims map[string]interface{}) string
+}
+
+func newTestBotFramework(t *testing.T) *testBotFramework {
+	key := newTestKey(t)
+	bf := &testBotFramework{sign: func(claims map[string]interface{}) string {
+		return signToken(t, key, claims)
+	}}
+	mux := http.NewServeMux()
+	addKeysHandlers(mux, key)
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, testAppID, r.PostForm.Get("client_id"))
+		assert.Equal(t, "password", r.PostForm.Get("client_secret"))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access-token", "expires_in": 3600})
+	})
+	mux.HandleFunc("/v3/teams/team-1/conversations", func(w http.ResponseWriter, r *http.Request) {
+		bf.auth = append(bf.auth, r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"conversations": []channelInfo{
+			{ID: "19:general@thread.skype"}, {ID: "19:deployments@thread.skype", Name: "deployments"},
+		}})
+	})
+	mux.HandleFunc("/v3/conversations/", func(w http.ResponseWriter, r *http.Request) {
+		bf.auth = append(bf.auth, r.Header.Get("Authorization"))
+		assert.Equal(t, "/v3/conversations/19:deployments@thread.skype;messageid=1/activities/1", r.URL.Path)
+		var a activity
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&a))
+		bf.replies = append(bf.replies, a)
+	})
+	bf.server = httptest.NewServer(mux)
+	bf.adapter = NewTeamsAdapter(func() (Credentials, error) {
+		return Credentials{AppID: testAppID, AppPassword: "password"}, nil
+	})
+	bf.adapter.keys.openIDConfigURL = bf.server.URL + "/openidconfiguration"
+	bf.adapter.tokenURL = bf.server.URL + "/token"
+	return bf
+}
+
+func (bf *testBotFramework) newRequest(t *testing.T, text string, channelID string) *http.Request {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":         "message",
+		"id":           "1",
+		"text":         text,
+		"serviceUrl":   bf.server.URL,
+		"from":         map[string]string{"id": "user-1", "name": "User"},
+		"recipient":    map[string]string{"id": "bot-1", "name": "argocd"},
+		"conversation": map[string]string{"id": "19:deployments@thread.skype;messageid=1", "conversationType": "channel"},
+		"channelData": map[string]interface{}{
+			"channel": map[string]string{"id": channelID},
+			"team":    map[string]string{"id": "team-1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/teams", bytes.NewReader(data))
+	r.Header.Set("Authorization", bf.sign(validClaims(bf.server.URL)))
+	return r
+}
+
+func TestParse_SubscribeAppTrigger(t *testing.T) {
+	bf := newTestBotFramework(t)
+	defer bf.server.Close()
+
+	cmd, err := bf.adapter.Parse(bf.newRequest(t, "<at>argocd</at> subscribe foo on-sync-failed", "19:deployments@thread.skype"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "teams:deployments", cmd.Recipient)
+	if assert.NotNil(t, cmd.Subscribe) {
+		assert.Equal(t, "foo", cmd.Subscribe.App)
+		assert.Equal(t, "on-sync-failed", cmd.Subscribe.Trigger)
+	}
+	assert.Equal(t, []string{"Bearer access-token"}, bf.auth)
+}
+
+func TestParse_GeneralChannel(t *testing.T) {
+	bf := newTestBotFramework(t)
+	defer bf.server.Close()
+
+	cmd, err := bf.adapter.Parse(bf.newRequest(t, "<at>argocd</at>&nbsp;unsubscribe proj:foo", "19:general@thread.skype"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "teams:General", cmd.Recipient)
+	if assert.NotNil(t, cmd.Unsubscribe) {
+		assert.Equal(t, "foo", cmd.Unsubscribe.Project)
+	}
+}
+
+func TestParse_UnknownCommand(t *testing.T) {
+	bf := newTestBotFramework(t)
+	defer bf.server.Close()
+
+	cmd, err := bf.adapter.Parse(bf.newRequest(t, "<at>argocd</at> help", "19:deployments@thread.skype"))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "`@argocd list-subscriptions`")
+	assert.NotNil(t, cmd.Conversation)
+}
+
+func TestParse_InvalidToken(t *testing.T) {
+	bf := newTestBotFramework(t)
+	defer bf.server.Close()
+	r := bf.newRequest(t, "<at>argocd</at> list-subscriptions", "19:deployments@thread.skype")
+	claims := validClaims(bf.server.URL)
+	claims["aud"] = "other-app"
+	r.Header.Set("Authorization", bf.sign(claims))
+
+	cmd, err := bf.adapter.Parse(r)
+
+	assert.Error(t, err)
+	assert.Nil(t, cmd.Conversation)
+}
+
+func TestReply(t *testing.T) {
+	bf := newTestBotFramework(t)
+	defer bf.server.Close()
+	cmd, err := bf.adapter.Parse(bf.newRequest(t, "<at>argocd</at> list-subscriptions", "19:deployments@thread.skype"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, bf.adapter.Reply(cmd.Conversation, "The teams:deployments has no subscriptions."))
+
+	if assert.Len(t, bf.replies, 1) {
+		reply := bf.replies[0]
+		assert.Equal(t, "message", reply.Type)
+		assert.Equal(t, "The teams:deployments has no subscriptions.", reply.Text)
+		assert.Equal(t, "1", reply.ReplyToID)
+		assert.Equal(t, "user-1", reply.Recipient.ID)
+		assert.Equal(t, "bot-1", reply.From.ID)
+	}
+	w := httptest.NewRecorder()
+	bf.adapter.SendResponse("ignored", w)
+	data, _ := ioutil.ReadAll(w.Body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, data)
+}
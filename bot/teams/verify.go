@@ -0,0 +1,177 @@
+package teams
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultOpenIDConfigURL = "https://login.botframework.com/v1/.well-known/openidconfiguration"
+	botFrameworkIssuer     = "https://api.botframework.com"
+	keysCacheDuration      = 24 * time.Hour
+	// keysRefreshInterval limits key set reloads caused by unknown key ids
+	keysRefreshInterval = time.Minute
+	allowedClockSkew    = 5 * time.Minute
+)
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// keySet caches the Bot Framework token signing keys
+type keySet struct {
+	openIDConfigURL string
+	client          *http.Client
+
+	lock      sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func (s *keySet) getJSON(url string, res interface{}) error {
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s has failed with error code %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+func (s *keySet) fetch() (map[string]*rsa.PublicKey, error) {
+	var config struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := s.getJSON(s.openIDConfigURL, &config); err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := s.getJSON(config.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			continue
+		}
+		keys[key.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+// get returns the key with the specified id; the key set is reloaded if the key is unknown or the cache is expired
+func (s *keySet) get(kid string) (*rsa.PublicKey, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key, ok := s.keys[kid]
+	sinceFetch := time.Since(s.fetchedAt)
+	if sinceFetch > keysCacheDuration || (!ok && sinceFetch > keysRefreshInterval) {
+		keys, err := s.fetch()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Bot Framework signing keys: %v", err)
+		}
+		s.keys, s.fetchedAt = keys, time.Now()
+		key, ok = s.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is unknown", kid)
+	}
+	return key, nil
+}
+
+type tokenClaims struct {
+	Issuer     string  `json:"iss"`
+	Audience   string  `json:"aud"`
+	ExpiresAt  float64 `json:"exp"`
+	NotBefore  float64 `json:"nbf"`
+	ServiceURL string  `json:"serviceurl"`
+}
+
+// verify returns an error if the Bot Framework token is not issued for the bot application or the service URL
+func (s *keySet) verify(authorization string, appID string, serviceURL string) error {
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return errors.New("request does not have bearer token")
+	}
+	parts := strings.Split(strings.TrimPrefix(authorization, "Bearer "), ".")
+	if len(parts) != 3 {
+		return errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "RS256" {
+		return fmt.Errorf("token signing algorithm %s is not supported", header.Alg)
+	}
+	key, err := s.get(header.Kid)
+	if err != nil {
+		return err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errors.New("malformed token signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return errors.New("invalid token signature")
+	}
+	var claims tokenClaims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return err
+	}
+	now := time.Now()
+	switch {
+	case claims.Issuer != botFrameworkIssuer:
+		return fmt.Errorf("token issuer %s is not trusted", claims.Issuer)
+	case appID == "" || claims.Audience != appID:
+		return errors.New("token is issued for another application")
+	case now.After(time.Unix(int64(claims.ExpiresAt), 0).Add(allowedClockSkew)):
+		return errors.New("token is expired")
+	case claims.NotBefore > 0 && now.Add(allowedClockSkew).Before(time.Unix(int64(claims.NotBefore), 0)):
+		return errors.New("token is not valid yet")
+	case claims.ServiceURL != serviceURL:
+		return errors.New("token is issued for another service URL")
+	}
+	return nil
+}
+
+func decodeSegment(segment string, res interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("malformed token")
+	}
+	if err = json.Unmarshal(data, res); err != nil {
+		return errors.New("malformed token")
+	}
+	return nil
+}
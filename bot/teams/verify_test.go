@@ -0,0 +1,99 @@
+package teams
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testAppID = "app-id"
+	testKeyID = "key-1"
+)
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	encode := func(obj interface{}) string {
+		data, err := json.Marshal(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := encode(map[string]string{"alg": "RS256", "kid": testKeyID, "typ": "JWT"}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims(serviceURL string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":        botFrameworkIssuer,
+		"aud":        testAppID,
+		"exp":        time.Now().Add(time.Hour).Unix(),
+		"nbf":        time.Now().Add(-time.Minute).Unix(),
+		"serviceurl": serviceURL,
+	}
+}
+
+// addKeysHandlers registers the OpenID configuration and the signing keys handlers
+func addKeysHandlers(mux *http.ServeMux, key *rsa.PrivateKey) {
+	mux.HandleFunc("/openidconfiguration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": "http://" + r.Host + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []jsonWebKey{{
+			Kid: testKeyID,
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+}
+
+func TestKeySet_Verify(t *testing.T) {
+	key := newTestKey(t)
+	mux := http.NewServeMux()
+	addKeysHandlers(mux, key)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	keys := &keySet{openIDConfigURL: server.URL + "/openidconfiguration", client: http.DefaultClient}
+	serviceURL := "https://smba.trafficmanager.net/emea/"
+
+	assert.NoError(t, keys.verify(signToken(t, key, validClaims(serviceURL)), testAppID, serviceURL))
+
+	for name, update := range map[string]func(claims map[string]interface{}){
+		"issuer":      func(claims map[string]interface{}) { claims["iss"] = "https://example.com" },
+		"audience":    func(claims map[string]interface{}) { claims["aud"] = "other-app" },
+		"expired":     func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"not before":  func(claims map[string]interface{}) { claims["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"service url": func(claims map[string]interface{}) { claims["serviceurl"] = "https://example.com" },
+	} {
+		claims := validClaims(serviceURL)
+		update(claims)
+		assert.Error(t, keys.verify(signToken(t, key, claims), testAppID, serviceURL), name)
+	}
+
+	assert.EqualError(t, keys.verify(signToken(t, newTestKey(t), validClaims(serviceURL)), testAppID, serviceURL), "invalid token signature")
+	assert.EqualError(t, keys.verify("", testAppID, serviceURL), "request does not have bearer token")
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+const (
+	// StatusTemplate is the name of the template that renders the status command response
+	StatusTemplate = "bot-app-status"
+	// DiffTemplate is the name of the template that renders the diff command response
+	DiffTemplate = "bot-app-diff"
+)
+
+// defaultTemplates are used if the templates are not configured in the argocd-notifications-cm ConfigMap
+var defaultTemplates = map[string]notifiers.Notification{
+	StatusTemplate: {
+		Title: "Application {{.app.metadata.name}}",
+		Body: `Sync Status: {{.app.status.sync.status}}
+Health Status: {{.app.status.health.status}}
+Revision: {{.app.status.sync.revision}}
+{{with .app.status.operationState}}Last Operation: {{.phase}} {{.message}}
+{{end}}{{if .context.argocdUrl}}Details: {{.context.argocdUrl}}/applications/{{.app.metadata.name}}{{end}}`,
+	},
+	DiffTemplate: {
+		Title: "Application {{.app.metadata.name}} is {{.app.status.sync.status}}",
+		Body: `{{if eq .app.status.sync.status "Synced"}}All resources are synced.{{else}}{{range .app.status.resources}}{{if ne .status "Synced"}}* {{.kind}} {{if .namespace}}{{.namespace}}/{{end}}{{.name}}: {{.status}}
+{{end}}{{end}}{{end}}`,
+	},
+}
+
+// ConfigSource returns the notifications configuration which templates and context are used to render responses
+type ConfigSource func() (*settings.Config, error)
+
+// NewConfigSource returns source of the configuration stored in the argocd-notifications-cm ConfigMap
+func NewConfigSource(configMapInformer cache.SharedIndexInformer) ConfigSource {
+	return func() (*settings.Config, error) {
+		configMaps := configMapInformer.GetStore().List()
+		if len(configMaps) == 0 {
+			return &settings.Config{}, nil
+		}
+		configMap, ok := configMaps[0].(*v1.ConfigMap)
+		if !ok {
+			return nil, errors.New("unexpected object in the config map informer storage")
+		}
+		return settings.ParseConfigMap(configMap)
+	}
+}
+
+// SetConfigSource sets the source of templates used to render the application state
+func (s *server) SetConfigSource(config ConfigSource) {
+	s.config = config
+}
+
+// renderApp renders the application state using the template configured in the ConfigMap or the default template
+func (s *server) renderApp(templateName string, recipient string, query QueryApp) (string, error) {
+	app, err := s.appClient.Get(query.App, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	cfg := &settings.Config{}
+	if s.config != nil {
+		if cfg, err = s.config(); err != nil {
+			return "", err
+		}
+	}
+	template := triggers.NotificationTemplate{Name: templateName, Notification: defaultTemplates[templateName]}
+	for _, t := range cfg.Templates {
+		if t.Name == templateName {
+			template = t
+		}
+	}
+	t, err := triggers.GetTriggers([]triggers.NotificationTemplate{template}, []triggers.NotificationTrigger{{
+		Name: templateName, Template: templateName, Condition: "true",
+	}}, nil)
+	if err != nil {
+		return "", err
+	}
+	ctx := recipients.CopyStringMap(cfg.Context)
+	ctx["notificationType"], _, _ = recipients.Split(recipient)
+	notification, err := t[templateName].FormatNotification(app, ctx)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(notification.Title + "\n" + notification.Body), nil
+}
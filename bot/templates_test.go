@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func withResources(resources ...map[string]interface{}) func(app *unstructured.Unstructured) {
+	return func(app *unstructured.Unstructured) {
+		var items []interface{}
+		for i := range resources {
+			items = append(items, resources[i])
+		}
+		_ = unstructured.SetNestedSlice(app.Object, items, "status", "resources")
+	}
+}
+
+func TestExecute_Status(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithSyncStatus("Synced"), WithHealthStatus("Healthy")))
+	s := NewServer(client, TestNamespace)
+	s.SetConfigSource(func() (*settings.Config, error) {
+		return &settings.Config{Context: map[string]string{"argocdUrl": "https://argocd.example.com"}}, nil
+	})
+
+	response, err := s.execute(Command{Recipient: "slack:test", Status: &QueryApp{App: "foo"}})
+
+	assert.NoError(t, err)
+	assert.Contains(t, response, "Application foo\n")
+	assert.Contains(t, response, "Sync Status: Synced\nHealth Status: Healthy\n")
+	assert.Contains(t, response, "Details: https://argocd.example.com/applications/foo")
+}
+
+func TestExecute_Diff(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithSyncStatus("OutOfSync"), withResources(
+		map[string]interface{}{"kind": "Deployment", "namespace": "default", "name": "guestbook-ui", "status": "OutOfSync"},
+		map[string]interface{}{"kind": "Service", "namespace": "default", "name": "guestbook-ui", "status": "Synced"},
+	)))
+	s := NewServer(client, TestNamespace)
+
+	response, err := s.execute(Command{Recipient: "slack:test", Diff: &QueryApp{App: "foo"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Application foo is OutOfSync\n* Deployment default/guestbook-ui: OutOfSync", response)
+}
+
+func TestExecute_StatusConfiguredTemplate(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithHealthStatus("Degraded")))
+	s := NewServer(client, TestNamespace)
+	s.SetConfigSource(func() (*settings.Config, error) {
+		return &settings.Config{Templates: []triggers.NotificationTemplate{{
+			Name:         StatusTemplate,
+			Notification: notifiers.Notification{Body: "{{.context.notificationType}}: {{.app.metadata.name}} is {{.app.status.health.status}}"},
+		}}}, nil
+	})
+
+	response, err := s.execute(Command{Recipient: "slack:test", Status: &QueryApp{App: "foo"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "slack: foo is Degraded", response)
+}
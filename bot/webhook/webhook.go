@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+const (
+	// TokenKey is the argocd-notifications-secret key that holds the token of the webhook commands
+	TokenKey = "bot-webhook-token"
+	// defaultService is the notification service of the recipients if the service query parameter is not specified
+	defaultService = "webhook"
+)
+
+// Message is the command message received from the chat platform
+type Message struct {
+	// Service is the notification service that sends notifications to the channel, e.g. webhook
+	Service string
+	// Channel is the channel name that is used as the recipient of the subscriptions
+	Channel string
+	// User identifies the user who sent the message
+	User string
+	// Text is the command text, e.g. subscribe guestbook
+	Text string
+}
+
+// MessageParser extracts the command message from the chat platform request
+type MessageParser func(r *http.Request) (Message, error)
+
+var supportedCommands = []string{
+	"list-subscriptions",
+	"subscribe <my-app>|proj:<my-proj>|selector:<label-selector> <optional-trigger>",
+	"unsubscribe <my-app>|proj:<my-proj>|selector:<label-selector> <optional-trigger>",
+	"status <my-app>",
+	"diff <my-app>",
+	"mute <my-app>|trigger:<my-trigger> for <duration>",
+}
+
+func usageInstructions(err error) string {
+	var usage strings.Builder
+	if err != nil {
+		usage.WriteString(err.Error() + "\n")
+	}
+	usage.WriteString("Supported commands:\n")
+	for _, command := range supportedCommands {
+		usage.WriteString(fmt.Sprintf("* %s\n", command))
+	}
+	return usage.String()
+}
+
+// NewAdapter returns adapter of the chat platform that sends the command in the HTTP request and receives the
+// response message in the HTTP response. The parser extracts the message from the request, so the platform support
+// does not require the command parsing and subscriptions logic.
+func NewAdapter(parser MessageParser) *webhook {
+	return &webhook{parser: parser}
+}
+
+type webhook struct {
+	parser MessageParser
+}
+
+func (w *webhook) Parse(r *http.Request) (bot.Command, error) {
+	message, err := w.parser(r)
+	if err != nil {
+		return bot.Command{}, err
+	}
+	if message.Channel == "" {
+		return bot.Command{}, errors.New("request does not have channel")
+	}
+	service := message.Service
+	if service == "" {
+		service = defaultService
+	}
+	cmd, err := bot.ParseArgs(strings.Fields(message.Text))
+	cmd.Recipient = fmt.Sprintf("%s:%s", service, message.Channel)
+	if message.User != "" {
+		cmd.User = fmt.Sprintf("%s:%s", service, message.User)
+	}
+	if usageErr, ok := err.(*bot.UsageError); ok {
+		return cmd, errors.New(usageInstructions(usageErr))
+	}
+	return cmd, err
+}
+
+// SendResponse writes the response message in the {"text": "<content>"} format supported by the most chat platforms
+func (w *webhook) SendResponse(content string, rw http.ResponseWriter) {
+	rw.Header().Set("Content-Type", "application/json")
+	data, err := json.Marshal(map[string]string{"text": content})
+	if err != nil {
+		_, _ = rw.Write([]byte(err.Error()))
+	} else {
+		_, _ = rw.Write(data)
+	}
+}
+
+// first returns the first non empty value
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// NewParser returns parser of the JSON or form encoded requests with the channel_name, user_name, text and optional
+// trigger_word fields, e.g. the Mattermost outgoing webhooks and slash commands. The token is provided in the token
+// field or the Authorization header. The recipient service is specified by the service query parameter.
+func NewParser(verifier bot.TokenVerifier) MessageParser {
+	return func(r *http.Request) (Message, error) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return Message{}, err
+		}
+		fields := map[string]string{}
+		contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if contentType == "application/json" {
+			var body map[string]interface{}
+			if err = json.Unmarshal(data, &body); err != nil {
+				return Message{}, fmt.Errorf("failed to parse request: %v", err)
+			}
+			for k, v := range body {
+				if s, ok := v.(string); ok {
+					fields[k] = s
+				}
+			}
+		} else {
+			query, err := url.ParseQuery(string(data))
+			if err != nil {
+				return Message{}, fmt.Errorf("failed to parse request: %v", err)
+			}
+			for k := range query {
+				fields[k] = query.Get(k)
+			}
+		}
+		token := first(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), fields["token"])
+		if err = verifier(token); err != nil {
+			return Message{}, fmt.Errorf("failed to verify request token: %v", err)
+		}
+		text := fields["text"]
+		if trigger := fields["trigger_word"]; trigger != "" {
+			text = strings.TrimPrefix(strings.TrimSpace(text), trigger)
+		}
+		return Message{
+			Service: r.URL.Query().Get("service"),
+			Channel: first(fields["channel_name"], fields["channel"]),
+			User:    first(fields["user_name"], fields["user"]),
+			Text:    text,
+		}, nil
+	}
+}
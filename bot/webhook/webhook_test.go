@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+func verifyToken(token string) error {
+	if token != "my-token" {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+func TestParse_MattermostOutgoingWebhook(t *testing.T) {
+	adapter := NewAdapter(NewParser(verifyToken))
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/webhook?service=mattermost",
+		strings.NewReader("token=my-token&channel_name=deployments&user_name=alice&trigger_word=%40argocd&text=%40argocd+subscribe+guestbook+on-sync-failed"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	cmd, err := adapter.Parse(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, bot.Command{
+		Recipient: "mattermost:deployments",
+		User:      "mattermost:alice",
+		Subscribe: &bot.UpdateSubscription{App: "guestbook", Trigger: "on-sync-failed"},
+	}, cmd)
+}
+
+func TestParse_JSONBearerToken(t *testing.T) {
+	adapter := NewAdapter(NewParser(verifyToken))
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/webhook",
+		strings.NewReader(`{"channel": "deployments", "user": "alice", "text": "list-subscriptions"}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Header.Set("Authorization", "Bearer my-token")
+
+	cmd, err := adapter.Parse(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "webhook:deployments", cmd.Recipient)
+	assert.Equal(t, "webhook:alice", cmd.User)
+	assert.NotNil(t, cmd.ListSubscriptions)
+}
+
+func TestParse_InvalidToken(t *testing.T) {
+	adapter := NewAdapter(NewParser(verifyToken))
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/webhook", strings.NewReader("token=wrong&channel=deployments&text=list-subscriptions"))
+
+	_, err := adapter.Parse(r)
+
+	assert.EqualError(t, err, "failed to verify request token: invalid token")
+}
+
+func TestParse_UnknownCommand(t *testing.T) {
+	adapter := NewAdapter(func(r *http.Request) (Message, error) {
+		return Message{Channel: "deployments", Text: "hello"}, nil
+	})
+
+	_, err := adapter.Parse(httptest.NewRequest(http.MethodPost, "http://localhost/webhook", nil))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown command\nSupported commands:\n* list-subscriptions\n")
+}
+
+func TestSendResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	NewAdapter(nil).SendResponse("subscription updated", w)
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"text": "subscription updated"}`, w.Body.String())
+}
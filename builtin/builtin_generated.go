@@ -0,0 +1,19 @@
+// Code generated by hack/gen/cmd/builtin-cm. DO NOT EDIT.
+
+package builtin
+
+// ConfigMapData holds the builtin triggers and templates in the argocd-notifications-cm ConfigMap format
+var ConfigMapData = map[string]string{
+	"template.app-health-degraded":     "body: |\n  {{if eq .context.notificationType \"slack\"}}:exclamation:{{end}} Application {{.app.metadata.name}} has degraded.\n  Application details: {{.context.argocdUrl}}/applications/{{.app.metadata.name}}.\nname: app-health-degraded\nslack:\n  attachments: |-\n    [{\n      \"title\": \"{{ .app.metadata.name}}\",\n      \"title_link\": \"{{.context.argocdUrl}}/applications/{{.app.metadata.name}}\",\n      \"color\": \"#f4c030\",\n      \"fields\": [\n      {\n        \"title\": \"Sync Status\",\n        \"value\": \"{{.app.status.sync.status}}\",\n        \"short\": true\n      },\n      {\n        \"title\": \"Repository\",\n        \"value\": \"{{.app.spec.source.repoURL}}\",\n        \"short\": true\n      }\n      {{range $index, $c := .app.status.conditions}}\n      {{if not $index}},{{end}}\n      {\n        \"title\": \"{{$c.type}}\",\n        \"value\": \"{{$c.message}}\",\n        \"short\": true\n      }\n      {{end}}\n      ]\n    }]\ntitle: Application {{.app.metadata.name}} has degraded.\n",
+	"template.app-rollback":            "body: |\n  {{if eq .context.notificationType \"slack\"}}:rewind:{{end}} Application {{.app.metadata.name}} has been rolled back from revision {{(call .deploys.GetPrevious).Revision}} to revision {{(call .deploys.GetLast).Revision}} at {{.app.status.operationState.finishedAt}}.\n  Sync operation details are available at: {{.context.argocdUrl}}/applications/{{.app.metadata.name}}?operation=true .\nname: app-rollback\nslack:\n  attachments: |\n    [{\n      \"title\": \"{{ .app.metadata.name}}\",\n      \"title_link\":\"{{.context.argocdUrl}}/applications/{{.app.metadata.name}}\",\n      \"color\": \"#f4c030\",\n      \"fields\": [\n      {\n        \"title\": \"Revision\",\n        \"value\": \"{{(call .deploys.GetLast).Revision}}\",\n        \"short\": true\n      },\n      {\n        \"title\": \"Previous Revision\",\n        \"value\": \"{{(call .deploys.GetPrevious).Revision}}\",\n        \"short\": true\n      },\n      {\n        \"title\": \"Repository\",\n        \"value\": \"{{.app.spec.source.repoURL}}\",\n        \"short\": true\n      }\n      ]\n    }]\ntitle: Application {{.app.metadata.name}} has been rolled back.\n",
+	"template.app-sync-failed":         "body: |\n  {{if eq .context.notificationType \"slack\"}}:exclamation:{{end}}  The sync operation of application {{.app.metadata.name}} has failed at {{.app.status.operationState.finishedAt}} with the following error: {{.app.status.operationState.message}}\n  Sync operation details are available at: {{.context.argocdUrl}}/applications/{{.app.metadata.name}}?operation=true .\nname: app-sync-failed\nslack:\n  attachments: \"[{\\n  \\\"title\\\": \\\"{{ .app.metadata.name}}\\\",\\n  \\\"title_link\\\":\\\"{{.context.argocdUrl}}/applications/{{.app.metadata.name}}\\\",\\n\n    \\ \\\"color\\\": \\\"#E96D76\\\",\\n  \\\"fields\\\": [\\n  {\\n    \\\"title\\\": \\\"Sync Status\\\",\\n\n    \\   \\\"value\\\": \\\"{{.app.status.sync.status}}\\\",\\n    \\\"short\\\": true\\n  },\\n  {\\n\n    \\   \\\"title\\\": \\\"Repository\\\",\\n    \\\"value\\\": \\\"{{.app.spec.source.repoURL}}\\\",\\n\n    \\   \\\"short\\\": true\\n  }\\n  {{range $index, $c := .app.status.conditions}}\\n  {{if\n    not $index}},{{end}}\\n  {\\n    \\\"title\\\": \\\"{{$c.type}}\\\",\\n    \\\"value\\\": \\\"{{$c.message}}\\\",\\n\n    \\   \\\"short\\\": true\\n  }\\n  {{end}}\\n  ]\\n}]    \"\ntitle: Failed to sync application {{.app.metadata.name}}.\n",
+	"template.app-sync-running":        "body: |\n  The sync operation of application {{.app.metadata.name}} has started at {{.app.status.operationState.startedAt}}.\n  Sync operation details are available at: {{.context.argocdUrl}}/applications/{{.app.metadata.name}}?operation=true .\nname: app-sync-running\nslack:\n  attachments: |-\n    [{\n      \"title\": \"{{ .app.metadata.name}}\",\n      \"title_link\":\"{{.context.argocdUrl}}/applications/{{.app.metadata.name}}\",\n      \"color\": \"#0DADEA\",\n      \"fields\": [\n      {\n        \"title\": \"Sync Status\",\n        \"value\": \"{{.app.status.sync.status}}\",\n        \"short\": true\n      },\n      {\n        \"title\": \"Repository\",\n        \"value\": \"{{.app.spec.source.repoURL}}\",\n        \"short\": true\n      }\n      {{range $index, $c := .app.status.conditions}}\n      {{if not $index}},{{end}}\n      {\n        \"title\": \"{{$c.type}}\",\n        \"value\": \"{{$c.message}}\",\n        \"short\": true\n      }\n      {{end}}\n      ]\n    }]\ntitle: Start syncing application {{.app.metadata.name}}.\n",
+	"template.app-sync-status-unknown": "body: |\n  {{if eq .context.notificationType \"slack\"}}:exclamation:{{end}} Application {{.app.metadata.name}} sync is 'Unknown'.\n  Application details: {{.context.argocdUrl}}/applications/{{.app.metadata.name}}.\n  {{if ne .context.notificationType \"slack\"}}\n  {{range $c := .app.status.conditions}}\n      * {{$c.message}}\n  {{end}}\n  {{end}}\nname: app-sync-status-unknown\nslack:\n  attachments: |-\n    [{\n      \"title\": \"{{ .app.metadata.name}}\",\n      \"title_link\":\"{{.context.argocdUrl}}/applications/{{.app.metadata.name}}\",\n      \"color\": \"#E96D76\",\n      \"fields\": [\n      {\n        \"title\": \"Sync Status\",\n        \"value\": \"{{.app.status.sync.status}}\",\n        \"short\": true\n      },\n      {\n        \"title\": \"Repository\",\n        \"value\": \"{{.app.spec.source.repoURL}}\",\n        \"short\": true\n      }\n      {{range $index, $c := .app.status.conditions}}\n      {{if not $index}},{{end}}\n      {\n        \"title\": \"{{$c.type}}\",\n        \"value\": \"{{$c.message}}\",\n        \"short\": true\n      }\n      {{end}}\n      ]\n    }]\ntitle: Application {{.app.metadata.name}} sync status is 'Unknown'\n",
+	"template.app-sync-succeeded":      "body: |\n  {{if eq .context.notificationType \"slack\"}}:white_check_mark:{{end}} Application {{.app.metadata.name}} has been successfully synced at {{.app.status.operationState.finishedAt}}.\n  Sync operation details are available at: {{.context.argocdUrl}}/applications/{{.app.metadata.name}}?operation=true .\nname: app-sync-succeeded\nslack:\n  attachments: \"[{\\n  \\\"title\\\": \\\"{{ .app.metadata.name}}\\\",\\n  \\\"title_link\\\":\\\"{{.context.argocdUrl}}/applications/{{.app.metadata.name}}\\\",\\n\n    \\ \\\"color\\\": \\\"#18be52\\\",\\n  \\\"fields\\\": [\\n  {\\n    \\\"title\\\": \\\"Sync Status\\\",\\n\n    \\   \\\"value\\\": \\\"{{.app.status.sync.status}}\\\",\\n    \\\"short\\\": true\\n  },\\n  {\\n\n    \\   \\\"title\\\": \\\"Repository\\\",\\n    \\\"value\\\": \\\"{{.app.spec.source.repoURL}}\\\",\\n\n    \\   \\\"short\\\": true\\n  }\\n  {{range $index, $c := .app.status.conditions}}\\n  {{if\n    not $index}},{{end}}\\n  {\\n    \\\"title\\\": \\\"{{$c.type}}\\\",\\n    \\\"value\\\": \\\"{{$c.message}}\\\",\\n\n    \\   \\\"short\\\": true\\n  }\\n  {{end}}\\n  ]\\n}]    \"\ntitle: Application {{.app.metadata.name}} has been successfully synced.\n",
+	"trigger.on-health-degraded":       "condition: app.status.health.status == 'Degraded'\ndescription: Application has degraded\nenabled: false\nname: on-health-degraded\ntemplate: app-health-degraded\n",
+	"trigger.on-rollback":              "condition: app.isRollback and app.status.operationState.phase in ['Succeeded']\ndescription: Application has been rolled back to a previously deployed revision\nenabled: false\nname: on-rollback\ntemplate: app-rollback\n",
+	"trigger.on-sync-failed":           "condition: app.status.operationState.phase in ['Error', 'Failed']\ndescription: Application syncing has failed\nenabled: false\nname: on-sync-failed\ntemplate: app-sync-failed\n",
+	"trigger.on-sync-running":          "condition: app.status.operationState.phase in ['Running']\ndescription: Application is being synced\nenabled: false\nname: on-sync-running\ntemplate: app-sync-running\n",
+	"trigger.on-sync-status-unknown":   "condition: app.status.sync.status == 'Unknown'\ndescription: Application status is 'Unknown'\nenabled: false\nname: on-sync-status-unknown\ntemplate: app-sync-status-unknown\n",
+	"trigger.on-sync-succeeded":        "condition: app.status.operationState.phase in ['Succeeded']\ndescription: Application syncing has succeeded\nenabled: false\nname: on-sync-succeeded\ntemplate: app-sync-succeeded\n",
+}
@@ -0,0 +1,3 @@
+// Package builtin provides the builtin triggers and templates defined in the builtin/triggers and builtin/templates
+// directories. Use ./hack/builtin-cm.sh to regenerate the package after changing the definitions.
+package builtin
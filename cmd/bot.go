@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"log"
+	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/dynamic"
@@ -12,6 +14,9 @@ import (
 
 	"github.com/argoproj-labs/argocd-notifications/bot"
 	"github.com/argoproj-labs/argocd-notifications/bot/slack"
+	"github.com/argoproj-labs/argocd-notifications/bot/teams"
+	"github.com/argoproj-labs/argocd-notifications/bot/webhook"
+	"github.com/argoproj-labs/argocd-notifications/shared/clients"
 	"github.com/argoproj-labs/argocd-notifications/shared/cmd"
 	"github.com/argoproj-labs/argocd-notifications/shared/settings"
 )
@@ -19,12 +24,20 @@ import (
 func newBotCommand() *cobra.Command {
 	var (
 		clientConfig clientcmd.ClientConfig
+		logOpts      *cmd.LogOpts
 		namespace    string
 		port         int
+		argocdServer clients.ArgoCDServerOptions
+		policyPath   string
+		variables    bool
 	)
 	var command = cobra.Command{
 		Use: "bot",
 		RunE: func(c *cobra.Command, args []string) error {
+			if err := logOpts.Apply(); err != nil {
+				return err
+			}
+			settings.SetVariablesEnabled(variables)
 			restConfig, err := clientConfig.ClientConfig()
 			if err != nil {
 				return err
@@ -45,16 +58,50 @@ func newBotCommand() *cobra.Command {
 			}
 			secretInformer := settings.NewSecretInformer(clientset, namespace)
 			go secretInformer.Run(context.Background().Done())
-			if !cache.WaitForCacheSync(context.Background().Done(), secretInformer.HasSynced) {
+			configMapInformer := settings.NewConfigMapInformer(clientset, namespace)
+			go configMapInformer.Run(context.Background().Done())
+			if !cache.WaitForCacheSync(context.Background().Done(), secretInformer.HasSynced, configMapInformer.HasSynced) {
 				log.Fatal("Timed out waiting for caches to sync")
 			}
 			server := bot.NewServer(dynamicClient, namespace)
+			server.SetConfigSource(bot.NewConfigSource(configMapInformer))
+			if policyPath != "" {
+				policy, err := bot.LoadSubscriptionPolicy(policyPath)
+				if err != nil {
+					return err
+				}
+				server.SetSubscriptionPolicy(policy)
+			}
+			if argocdServer.Address != "" {
+				argocdServer.AuthToken = os.Getenv(argocdAuthTokenEnv)
+				conn, err := clients.DialArgoCDServer(argocdServer)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					_ = conn.Close()
+				}()
+				server.SetAppActions(bot.NewArgoCDAppActions(conn))
+			}
+			go server.RunSilenceExpiry(bot.NewNotifierSource(secretInformer), time.Minute, context.Background().Done())
 			server.AddAdapter("/slack", slack.NewSlackAdapter(slack.NewVerifier(secretInformer)))
+			server.AddActionAdapter("/slack/actions", slack.NewSlackActionsAdapter(slack.NewVerifier(secretInformer), slack.NewPolicySource(secretInformer)))
+			server.AddAdapter("/slack/events", slack.NewSlackEventsAdapter(slack.NewVerifier(secretInformer), slack.NewOptionsSource(secretInformer)))
+			go slack.NewSocketModeClient(server, slack.NewOptionsSource(secretInformer)).Run(context.Background().Done())
+			server.AddAdapter("/teams", teams.NewTeamsAdapter(teams.NewCredentialsSource(secretInformer)))
+			server.AddAdapter("/webhook", webhook.NewAdapter(webhook.NewParser(bot.NewSecretTokenVerifier(secretInformer, webhook.TokenKey))))
+			server.AddAPI("/api/v1/subscriptions", bot.NewTokenVerifier(secretInformer))
 			return server.Serve(port)
 		},
 	}
 	clientConfig = cmd.AddK8SFlagsToCmd(&command)
+	logOpts = cmd.AddLogFlagsToCmd(&command)
 	command.Flags().IntVar(&port, "port", 8080, "Port number.")
 	command.Flags().StringVar(&namespace, "namespace", "", "Namespace which bot handles. Current namespace if empty.")
+	command.Flags().StringVar(&argocdServer.Address, "argocd-server", "", "Argo CD API server address used to sync and refresh applications. Applications are updated using the Kubernetes API if empty. The token is read from the "+argocdAuthTokenEnv+" environment variable")
+	command.Flags().BoolVar(&argocdServer.PlainText, "argocd-server-plaintext", false, "Disable TLS of the Argo CD API server connection")
+	command.Flags().StringVar(&policyPath, "subscription-policy", "", "Path to the YAML file with the policy that restricts applications and projects the chat users may subscribe to. Users may subscribe to any application if empty")
+	command.Flags().BoolVar(&argocdServer.Insecure, "argocd-server-insecure", false, "Skip the Argo CD API server certificate verification")
+	command.Flags().BoolVar(&variables, "config-variables", false, configVariablesUsage)
 	return &command
 }
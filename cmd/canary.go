@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+const (
+	// canaryMinDeliveries is the number of canary deliveries required to compare the canary with other applications,
+	// so a few notifications of the canary applications don't fail the canary
+	canaryMinDeliveries = 10
+	// canaryFailureTolerance is the failure ratio of the canary deliveries which is tolerated regardless of the failure
+	// ratio of other applications
+	canaryFailureTolerance = 0.05
+)
+
+// configGroup is the configuration applied to the applications matching the filter
+type configGroup struct {
+	triggers  map[string]triggers.Trigger
+	notifiers map[string]notifiers.Notifier
+	cfg       *settings.Config
+	// filter restricts the applications of the group; the group includes all applications if nil
+	filter func(app *unstructured.Unstructured) bool
+}
+
+// canaryOpts configures the canary rollout of the configuration changes
+type canaryOpts struct {
+	// selector matches the canary applications which get the new configuration version first
+	selector labels.Selector
+	// duration is the time the new configuration version is applied only to the canary applications
+	duration time.Duration
+	// maxRatio is the max ratio of the canary applications delivery and failure rates to the rates of other
+	// applications
+	maxRatio float64
+	// apply restarts the controllers with the stable configuration for other applications and the new configuration
+	// for the canary applications
+	apply func(stable configGroup, canary configGroup) error
+}
+
+// canaryGroupStats counts the deliveries of the applications of the canary or the stable group
+type canaryGroupStats struct {
+	apps       int
+	deliveries int
+	failures   int
+}
+
+func (s canaryGroupStats) deliveryRate() float64 {
+	if s.apps == 0 {
+		return 0
+	}
+	return float64(s.deliveries) / float64(s.apps)
+}
+
+func (s canaryGroupStats) failureRatio() float64 {
+	if s.deliveries == 0 {
+		return 0
+	}
+	return float64(s.failures) / float64(s.deliveries)
+}
+
+// canaryTracker remembers the group of every application filtered by the controllers and counts the deliveries of
+// both groups
+type canaryTracker struct {
+	lock     sync.Mutex
+	selector labels.Selector
+	// canaryApps holds true for the canary applications and false for other applications by application key
+	canaryApps map[string]bool
+	canary     canaryGroupStats
+	stable     canaryGroupStats
+}
+
+func newCanaryTracker(selector labels.Selector) *canaryTracker {
+	return &canaryTracker{selector: selector, canaryApps: map[string]bool{}}
+}
+
+// filter returns the filter of the canary applications or other applications
+func (t *canaryTracker) filter(canary bool) func(app *unstructured.Unstructured) bool {
+	return func(app *unstructured.Unstructured) bool {
+		matches := t.selector.Matches(labels.Set(app.GetLabels()))
+		t.lock.Lock()
+		t.canaryApps[app.GetNamespace()+"/"+app.GetName()] = matches
+		t.lock.Unlock()
+		return matches == canary
+	}
+}
+
+// reset clears the counters before the next canary
+func (t *canaryTracker) reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.canaryApps = map[string]bool{}
+	t.canary = canaryGroupStats{}
+	t.stable = canaryGroupStats{}
+}
+
+// ObserveDelivery counts the delivery attempt of the application which group is known
+func (t *canaryTracker) ObserveDelivery(appNamespace string, appName string, _ string, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	canary, ok := t.canaryApps[appNamespace+"/"+appName]
+	if !ok {
+		return
+	}
+	stats := &t.stable
+	if canary {
+		stats = &t.canary
+	}
+	stats.deliveries++
+	if err != nil {
+		stats.failures++
+	}
+}
+
+// stats returns the counters of the canary and the stable groups
+func (t *canaryTracker) stats() (canaryGroupStats, canaryGroupStats) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	canary, stable := t.canary, t.stable
+	for _, isCanary := range t.canaryApps {
+		if isCanary {
+			canary.apps++
+		} else {
+			stable.apps++
+		}
+	}
+	return canary, stable
+}
+
+// evaluateCanary returns an error if the canary applications receive noticeably more notifications per application or
+// the canary deliveries fail noticeably more often than the deliveries of other applications
+func evaluateCanary(canary canaryGroupStats, stable canaryGroupStats, maxRatio float64) error {
+	if canary.deliveries < canaryMinDeliveries {
+		return nil
+	}
+	if canary.deliveryRate() > stable.deliveryRate()*maxRatio {
+		return fmt.Errorf("canary applications got %.1f notifications per application, other applications got %.1f",
+			canary.deliveryRate(), stable.deliveryRate())
+	}
+	if canary.failureRatio() > canaryFailureTolerance && canary.failureRatio() > stable.failureRatio()*maxRatio {
+		return fmt.Errorf("%.0f%% of canary deliveries failed, %.0f%% of other applications deliveries failed",
+			canary.failureRatio()*100, stable.failureRatio()*100)
+	}
+	return nil
+}
+
+// configCanary applies the new configuration versions to the canary applications and decides if the version should
+// be promoted once the canary duration elapses
+type configCanary struct {
+	opts    canaryOpts
+	tracker *canaryTracker
+	// version is the configuration version of the running canary; nil if no canary is running
+	version *configVersion
+	timer   *time.Timer
+}
+
+func newConfigCanary(opts canaryOpts, tracker *canaryTracker) *configCanary {
+	return &configCanary{opts: opts, tracker: tracker}
+}
+
+// running returns true if the canary of any version is running. Not thread safe, the caller holds the configuration
+// lock.
+func (c *configCanary) running() bool {
+	return c.version != nil
+}
+
+// isRunning returns true if the canary of the specified version is running. Not thread safe, the caller holds the
+// configuration lock.
+func (c *configCanary) isRunning(version configVersion) bool {
+	return c.version != nil && c.version.equal(version)
+}
+
+// start applies the canary configuration to the canary applications and calls onDone with the canary evaluation
+// error once the canary duration elapses. The running canary is replaced, so onDone should ignore the result if the
+// canary of the version is no longer running. Not thread safe, the caller holds the configuration lock.
+func (c *configCanary) start(stable configGroup, canary configGroup, version configVersion, onDone func(err error)) error {
+	c.stop()
+	c.tracker.reset()
+	stable.filter = c.tracker.filter(false)
+	canary.filter = c.tracker.filter(true)
+	if err := c.opts.apply(stable, canary); err != nil {
+		return err
+	}
+	c.version = &version
+	c.timer = time.AfterFunc(c.opts.duration, func() {
+		canaryStats, stableStats := c.tracker.stats()
+		onDone(evaluateCanary(canaryStats, stableStats, c.opts.maxRatio))
+	})
+	return nil
+}
+
+// stop cancels the running canary. Not thread safe, the caller holds the configuration lock.
+func (c *configCanary) stop() {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.timer = nil
+	c.version = nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd/mocks"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func newCanaryApp(name string, appLabels map[string]string) *unstructured.Unstructured {
+	app := &unstructured.Unstructured{}
+	app.SetNamespace("argocd")
+	app.SetName(name)
+	app.SetLabels(appLabels)
+	return app
+}
+
+func TestEvaluateCanary(t *testing.T) {
+	assert.NoError(t, evaluateCanary(canaryGroupStats{apps: 1, deliveries: 5}, canaryGroupStats{apps: 100}, 3))
+	assert.NoError(t, evaluateCanary(canaryGroupStats{apps: 10, deliveries: 20}, canaryGroupStats{apps: 100, deliveries: 100}, 3))
+	assert.Error(t, evaluateCanary(canaryGroupStats{apps: 10, deliveries: 100}, canaryGroupStats{apps: 100, deliveries: 100}, 3))
+	assert.Error(t, evaluateCanary(canaryGroupStats{apps: 10, deliveries: 20, failures: 10}, canaryGroupStats{apps: 100, deliveries: 100, failures: 1}, 3))
+}
+
+func TestCanaryTracker(t *testing.T) {
+	tracker := newCanaryTracker(labels.SelectorFromSet(labels.Set{"canary": "true"}))
+	canaryApp := newCanaryApp("canary", map[string]string{"canary": "true"})
+	otherApp := newCanaryApp("other", nil)
+
+	assert.True(t, tracker.filter(true)(canaryApp))
+	assert.False(t, tracker.filter(true)(otherApp))
+	assert.True(t, tracker.filter(false)(otherApp))
+	tracker.ObserveDelivery("argocd", "canary", "slack", nil)
+	tracker.ObserveDelivery("argocd", "canary", "slack", errors.New("fail"))
+	tracker.ObserveDelivery("argocd", "other", "slack", nil)
+	tracker.ObserveDelivery("argocd", "unknown", "slack", nil)
+
+	canary, stable := tracker.stats()
+	assert.Equal(t, canaryGroupStats{apps: 1, deliveries: 2, failures: 1}, canary)
+	assert.Equal(t, canaryGroupStats{apps: 1, deliveries: 1}, stable)
+
+	tracker.reset()
+	canary, stable = tracker.stats()
+	assert.Equal(t, canaryGroupStats{}, canary)
+	assert.Equal(t, canaryGroupStats{}, stable)
+}
+
+func runCanary(t *testing.T, canaryDeliveries int) (*configStatus, *record.FakeRecorder, *int32) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName, Namespace: "default", ResourceVersion: "1"},
+		Data: map[string]string{
+			"trigger.on-sync-status-unknown": `{condition: "true", template: app-sync-status}`,
+			"template.app-sync-status":       `{title: hello}`,
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.SecretName, Namespace: "default", ResourceVersion: "1"},
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{"canary": "true"})
+	tracker := newCanaryTracker(selector)
+	applied := make(chan bool, 1)
+	canary := newConfigCanary(canaryOpts{selector: selector, duration: 100 * time.Millisecond, maxRatio: 3, apply: func(stable configGroup, canary configGroup) error {
+		canary.filter(newCanaryApp("canary", map[string]string{"canary": "true"}))
+		stable.filter(newCanaryApp("other", nil))
+		for i := 0; i < canaryDeliveries; i++ {
+			tracker.ObserveDelivery("argocd", "canary", "slack", nil)
+		}
+		applied <- true
+		return nil
+	}}, tracker)
+
+	var callbacksCount int32
+	clientset := fake.NewSimpleClientset(configMap, secret)
+	recorder := record.NewFakeRecorder(10)
+	status := &configStatus{}
+	watchConfig(ctx, mocks.NewMockService(ctrl), clientset, "default", configWatchOpts{status: status, recorder: recorder, canary: canary}, func(t map[string]triggers.Trigger, n map[string]notifiers.Notifier, cfg *settings.Config) error {
+		atomic.AddInt32(&callbacksCount, 1)
+		return nil
+	})
+	assert.Equal(t, int32(1), atomic.LoadInt32(&callbacksCount))
+
+	updated := configMap.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Data["trigger.on-sync-status-unknown"] = `{condition: "false", template: app-sync-status}`
+	_, err := clientset.CoreV1().ConfigMaps("default").Update(updated)
+	assert.NoError(t, err)
+
+	select {
+	case <-applied:
+	case <-time.After(5 * time.Second):
+		t.Fatal("canary has not been started")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&callbacksCount))
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&callbacksCount) == 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return status, recorder, &callbacksCount
+}
+
+func TestWatchConfig_CanaryPromoted(t *testing.T) {
+	status, _, callbacksCount := runCanary(t, 0)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(callbacksCount))
+	status.lock.RLock()
+	defer status.lock.RUnlock()
+	assert.Equal(t, "2", status.Active.ConfigMap)
+	assert.Nil(t, status.Canary)
+}
+
+func TestWatchConfig_CanaryRolledBack(t *testing.T) {
+	status, recorder, callbacksCount := runCanary(t, 100)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(callbacksCount))
+	select {
+	case event := <-recorder.Events:
+		assert.Contains(t, event, canaryFailedReason)
+	case <-time.After(5 * time.Second):
+		t.Fatal("canary failure event has not been recorded")
+	}
+	status.lock.RLock()
+	defer status.lock.RUnlock()
+	assert.Equal(t, "1", status.Active.ConfigMap)
+	assert.Equal(t, "2", status.Failed.ConfigMap)
+	assert.Nil(t, status.Canary)
+}
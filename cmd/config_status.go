@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// configVersion identifies the revision of every configuration source
+type configVersion struct {
+	// ConfigMap is the resource version of the argocd-notifications-cm config map
+	ConfigMap string `json:"configMap"`
+	// Secret is the resource version of the argocd-notifications-secret secret
+	Secret string `json:"secret"`
+	// Extra holds the resource versions of the additional config maps and content checksums of the config sources
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+func (v configVersion) equal(other configVersion) bool {
+	return reflect.DeepEqual(v, other)
+}
+
+// String returns the human readable configuration version, e.g. configMap 123, secret 456
+func (v configVersion) String() string {
+	return fmt.Sprintf("configMap %s, secret %s", v.ConfigMap, v.Secret)
+}
+
+func contentChecksum(obj interface{}) string {
+	data, _ := json.Marshal(obj)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// configStatus holds the active configuration version and the last reload error
+type configStatus struct {
+	lock sync.RWMutex
+	// Active is the version of the configuration used by the controller
+	Active *configVersion `json:"active,omitempty"`
+	// LoadedAt is the time when active configuration has been loaded
+	LoadedAt *time.Time `json:"loadedAt,omitempty"`
+	// Failed is the version of the configuration which failed to load; empty if the latest version is active
+	Failed *configVersion `json:"failed,omitempty"`
+	// Error is the error of the failed configuration
+	Error string `json:"error,omitempty"`
+	// Canary is the version of the configuration applied only to the canary applications; empty if no canary is running
+	Canary *configVersion `json:"canary,omitempty"`
+	// Config is the active merged configuration with redacted secret values
+	Config interface{} `json:"config,omitempty"`
+	// Notifiers is the active notification services configuration with redacted secret values
+	Notifiers interface{} `json:"notifiers,omitempty"`
+}
+
+// setActive updates status after successful reload
+func (s *configStatus) setActive(version configVersion) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	s.Active = &version
+	s.LoadedAt = &now
+	s.Failed = nil
+	s.Error = ""
+}
+
+// isActive returns true if the specified configuration version is active
+func (s *configStatus) isActive(version configVersion) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.Active != nil && s.Active.equal(version)
+}
+
+// setConfig updates the active configuration; the secret values are redacted
+func (s *configStatus) setConfig(cfg *settings.Config, notifiersConfig notifiers.Config) error {
+	redactedCfg, err := settings.Redact(cfg)
+	if err != nil {
+		return err
+	}
+	redactedNotifiers, err := settings.Redact(notifiersConfig)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Config = redactedCfg
+	s.Notifiers = redactedNotifiers
+	return nil
+}
+
+// isFailed returns true if the specified configuration version failed to load
+func (s *configStatus) isFailed(version configVersion) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.Failed != nil && s.Failed.equal(version)
+}
+
+// setCanary updates the version of the running configuration canary; nil if no canary is running
+func (s *configStatus) setCanary(version *configVersion) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Canary = version
+}
+
+// setFailed updates status after failed reload and returns false if the same version has already failed
+func (s *configStatus) setFailed(version configVersion, err error) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.Failed != nil && s.Failed.equal(version) {
+		return false
+	}
+	s.Failed = &version
+	s.Error = err.Error()
+	return true
+}
+
+func (s *configStatus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s)
+}
+
+func getConfigVersion(configMap *v1.ConfigMap, secret *v1.Secret, extraConfigMaps []*v1.ConfigMap, sources map[string]loadedSource) configVersion {
+	version := configVersion{ConfigMap: configMap.ResourceVersion, Secret: secret.ResourceVersion}
+	if len(extraConfigMaps) > 0 || len(sources) > 0 {
+		version.Extra = map[string]string{}
+	}
+	for _, cm := range extraConfigMaps {
+		version.Extra[cm.Name] = cm.ResourceVersion
+	}
+	for name, source := range sources {
+		version.Extra[name] = contentChecksum(source)
+	}
+	return version
+}
@@ -4,47 +4,144 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/argoproj-labs/argocd-notifications/controller"
 	"github.com/argoproj-labs/argocd-notifications/notifiers"
 	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/clients"
 	"github.com/argoproj-labs/argocd-notifications/shared/cmd"
+	"github.com/argoproj-labs/argocd-notifications/shared/flood"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/queue"
+	"github.com/argoproj-labs/argocd-notifications/shared/ratelimit"
+	imageregistry "github.com/argoproj-labs/argocd-notifications/shared/registry"
 	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/silences"
+	"github.com/argoproj-labs/argocd-notifications/shared/state"
 	"github.com/argoproj-labs/argocd-notifications/triggers"
+	exprHelpers "github.com/argoproj-labs/argocd-notifications/triggers/expr"
+	"github.com/argoproj-labs/argocd-notifications/ui"
 
+	"github.com/go-redis/redis/v7"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
 	argocdURLContextVariable = "argocdUrl"
 	defaultMetricsPort       = 9001
+	invalidConfigReason      = "InvalidConfiguration"
+	canaryFailedReason       = "ConfigCanaryFailed"
+	historyBackendNone       = "none"
+	historyBackendCRD        = "crd"
+	historyBackendMemory     = "memory"
+	historyMemoryLimit       = 1000
+	historyPruneInterval     = time.Hour
+	stateBackendAnnotations  = "annotations"
+	stateBackendConfigMap    = "configmap"
+	stateBackendRedis        = "redis"
+	rateLimitBackendMemory   = "memory"
+	rateLimitBackendRedis    = "redis"
+	floodBackendMemory       = "memory"
+	floodBackendRedis        = "redis"
+	redisPasswordEnv         = "REDIS_PASSWORD"
+	replayAPITokenEnv        = "REPLAY_API_TOKEN"
+	eventsAPITokenEnv        = "EVENTS_API_TOKEN"
+	adminAPITokenEnv         = "ADMIN_API_TOKEN"
+	uiTokenEnv               = "UI_TOKEN"
+	ackLinksKeyEnv           = "ACK_LINKS_KEY"
+	eventBusTokenEnv         = "EVENTBUS_TOKEN"
+	eventBusUsernameEnv      = "EVENTBUS_USERNAME"
+	eventBusPasswordEnv      = "EVENTBUS_PASSWORD"
+	argocdAuthTokenEnv       = "ARGOCD_AUTH_TOKEN"
+	deliveryQueueNone        = "none"
+	deliveryQueueCRD         = "crd"
+	configVariablesUsage     = "Replace the ${NAME} references in the configuration string values with the " + settings.VariableEnvPrefix + "<NAME> environment variables and the ${context.<key>} references with the config.yaml context values"
 )
 
 func newControllerCommand() *cobra.Command {
 	var (
-		clientConfig     clientcmd.ClientConfig
-		processorsCount  int
-		namespace        string
-		appLabelSelector string
-		logLevel         string
-		metricsPort      int
-		argocdRepoServer string
+		clientConfig            clientcmd.ClientConfig
+		logOpts                 *cmd.LogOpts
+		processorsCount         int
+		namespaces              []string
+		appLabelSelector        string
+		metricsPort             int
+		argocdRepoServer        string
+		configMapSelector       string
+		configResources         bool
+		configVariables         bool
+		canarySelector          string
+		canaryDuration          time.Duration
+		canaryMaxRatio          float64
+		configDirs              []string
+		configURLs              []string
+		configRefreshInterval   time.Duration
+		historyBackend          string
+		historyRetention        time.Duration
+		deliveryOpts            controller.DeliveryOptions
+		deliveryQueue           string
+		notifierTimeouts        map[string]string
+		orderedNotifiers        []string
+		metricsAppLabelsLimit   int
+		readinessCheckNotifiers bool
+		selfTestRecipients      []string
+		leaderElection          leaderElectionOpts
+		sharding                controller.Sharding
+		instancesPath           string
+		tracing                 tracingOpts
+		appEvents               bool
+		stateBackend            string
+		rateLimitBackend        string
+		floodBackend            string
+		informerOpts            controller.InformerOptions
+		redisOpts               redis.UniversalOptions
+		replayAPI               bool
+		adminAPI                bool
+		eventsPort              int
+		eventsTLSCertFile       string
+		eventsTLSKeyFile        string
+		eventBus                notifiers.EventBusOptions
+		eventBusSubject         string
+		debugPprof              bool
+		argocdServer            clients.ArgoCDServerOptions
+		silencesEnabled         bool
+		uiPort                  int
+		uiAddress               string
+		ackLinks                controller.AckLinks
+		imageProvenance         bool
+		registryConfigPath      string
+		registryOpts            imageregistry.Options
 	)
 	var command = cobra.Command{
 		Use: "controller",
 		RunE: func(c *cobra.Command, args []string) error {
+			settings.SetVariablesEnabled(configVariables)
 			restConfig, err := clientConfig.ClientConfig()
 			if err != nil {
 				return err
@@ -57,17 +154,90 @@ func newControllerCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if namespace == "" {
-				namespace, _, err = clientConfig.Namespace()
+			var namespace string
+			if len(namespaces) > 0 {
+				namespace = namespaces[0]
+			} else if namespace, _, err = clientConfig.Namespace(); err != nil {
+				return err
+			}
+			if _, err = labels.Parse(appLabelSelector); err != nil {
+				return fmt.Errorf("invalid app label selector '%s': %v", appLabelSelector, err)
+			}
+			if err = logOpts.Apply(); err != nil {
+				return err
+			}
+			shutdownTracing, err := initTracing(context.Background(), tracing)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := shutdownTracing(context.Background()); err != nil {
+					log.Warnf("Failed to flush traces: %v", err)
+				}
+			}()
+
+			sources := []appSource{{client: dynamicClient, namespace: namespace, labelSelector: appLabelSelector}}
+			for i := 1; i < len(namespaces); i++ {
+				sources = append(sources, appSource{client: dynamicClient, namespace: namespaces[i], labelSelector: appLabelSelector})
+			}
+			if argocdServer.Address != "" {
+				if instancesPath != "" || len(namespaces) > 1 {
+					return errors.New("--argocd-server cannot be used together with --instances or multiple --namespace values")
+				}
+				argocdServer.AuthToken = os.Getenv(argocdAuthTokenEnv)
+				conn, err := clients.DialArgoCDServer(argocdServer)
 				if err != nil {
 					return err
 				}
+				defer func() {
+					_ = conn.Close()
+				}()
+				log.Infof("Watching applications using Argo CD API server %s", argocdServer.Address)
+				// application events cannot be created without access to the application namespace
+				sources[0].client = clients.NewArgoCDAPIClient(conn)
+				sources[0].remote = true
 			}
-			level, err := log.ParseLevel(logLevel)
-			if err != nil {
+			if instancesPath != "" {
+				if len(namespaces) > 1 {
+					return errors.New("--instances and multiple --namespace values cannot be used together")
+				}
+				instances, err := loadInstances(instancesPath)
+				if err != nil {
+					return err
+				}
+				sources = nil
+				for _, inst := range instances {
+					source, err := inst.newAppSource(dynamicClient, namespace, appLabelSelector)
+					if err != nil {
+						return err
+					}
+					log.Infof("Watching applications of instance %s in namespace %s", source.name, source.namespace)
+					sources = append(sources, source)
+				}
+			}
+
+			if sharding.Replicas > 1 && sharding.Shard < 0 {
+				hostname, err := os.Hostname()
+				if err != nil {
+					return err
+				}
+				if sharding.Shard, err = controller.ShardFromHostname(hostname); err != nil {
+					return err
+				}
+			}
+			if err = sharding.Validate(); err != nil {
 				return err
 			}
-			log.SetLevel(level)
+			if deliveryOpts.DryRun {
+				log.Warn("Running in dry-run mode: notifications are logged instead of being sent")
+				// the dry-run controller runs next to the live controller, so it must not take over the live lease
+				leaderElection.leaseName = leaderElection.leaseName + "-dry-run"
+			}
+			if sharding.Replicas > 1 {
+				log.Infof("Processing applications of shard %d of %d", sharding.Shard, sharding.Replicas)
+				// replicas of every shard elect their own leader
+				leaderElection.leaseName = fmt.Sprintf("%s-%d", leaderElection.leaseName, sharding.Shard)
+			}
 
 			argocdService, err := argocd.NewArgoCDService(k8sClient, namespace, argocdRepoServer)
 			if err != nil {
@@ -76,57 +246,702 @@ func newControllerCommand() *cobra.Command {
 			defer argocdService.Close()
 
 			registry := controller.NewMetricsRegistry()
-			http.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{}))
+			registry.EnableAppLabels(metricsAppLabelsLimit)
+			mux := http.NewServeMux()
+			if debugPprof {
+				addPprofHandlers(mux)
+			}
+			// OpenMetrics format is required to expose exemplars
+			mux.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{EnableOpenMetrics: true}))
 
 			go func() {
-				log.Fatal(http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", metricsPort), http.DefaultServeMux))
+				log.Fatal(http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", metricsPort), mux))
 			}()
 			log.Infof("serving metrics on port %d", metricsPort)
 			log.Infof("loading configuration %d", metricsPort)
 
-			var cancelPrev context.CancelFunc
-			watchConfig(context.Background(), argocdService, k8sClient, namespace, func(triggers map[string]triggers.Trigger, notifiers map[string]notifiers.Notifier, cfg *settings.Config) error {
-				if cancelPrev != nil {
-					log.Info("Settings had been updated. Restarting controller...")
-					cancelPrev()
-					cancelPrev = nil
+			historyStore, err := newHistoryStore(historyBackend, dynamicClient, namespace)
+			if err != nil {
+				return err
+			}
+			running := &runningControllers{}
+			if replayAPI {
+				token := os.Getenv(replayAPITokenEnv)
+				if historyStore == nil || token == "" {
+					return fmt.Errorf("replay API requires notification history and the %s environment variable", replayAPITokenEnv)
+				}
+				mux.Handle("/api/replay", controller.NewReplayHandler(historyStore, running.get, token))
+			}
+			// acknowledgements recorded in the history are exposed to triggers and templates
+			exprHelpers.SetAckStore(historyStore)
+			if ackLinks.BaseURL != "" {
+				ackLinks.Key = []byte(os.Getenv(ackLinksKeyEnv))
+				if historyStore == nil || len(ackLinks.Key) == 0 {
+					return fmt.Errorf("acknowledgement links require notification history and the %s environment variable", ackLinksKeyEnv)
+				}
+				deliveryOpts.AckLinks = &ackLinks
+				mux.Handle(controller.AckPath, controller.NewAckHandler(historyStore, &ackLinks))
+			}
+			if imageProvenance {
+				if registryConfigPath != "" {
+					if registryOpts.Credentials, err = imageregistry.LoadDockerConfig(registryConfigPath); err != nil {
+						return err
+					}
+				}
+				exprHelpers.SetImageRegistry(imageregistry.NewClient(registryOpts))
+			}
+			active := &activeConfig{}
+			if eventBusSubject != "" {
+				if eventBus.URL == "" {
+					return errors.New("--eventbus-subject requires --eventbus-url")
 				}
-				ctrl, err := controller.NewController(dynamicClient, namespace, triggers, notifiers, cfg.Context, cfg.Subscriptions, appLabelSelector, registry)
+				eventBus.Token = os.Getenv(eventBusTokenEnv)
+				eventBus.Username = os.Getenv(eventBusUsernameEnv)
+				eventBus.Password = os.Getenv(eventBusPasswordEnv)
+			}
+			if eventsPort > 0 {
+				token := os.Getenv(eventsAPITokenEnv)
+				if token == "" {
+					return fmt.Errorf("events API requires the %s environment variable", eventsAPITokenEnv)
+				}
+				eventsMux := http.NewServeMux()
+				eventsMux.Handle("/api/events", controller.NewEventsHandler(running.get, token))
+				go func() {
+					addr := fmt.Sprintf("0.0.0.0:%d", eventsPort)
+					if eventsTLSCertFile == "" || eventsTLSKeyFile == "" {
+						log.Warn("Events API TLS certificate is not configured, serving plain HTTP")
+						log.Fatal(http.ListenAndServe(addr, eventsMux))
+					}
+					log.Fatal(http.ListenAndServeTLS(addr, eventsTLSCertFile, eventsTLSKeyFile, eventsMux))
+				}()
+				log.Infof("serving events API on port %d", eventsPort)
+			}
+
+			redisClient := newRedisClientFactory(redisOpts)
+			stateStore, err := newStateStore(stateBackend, k8sClient, namespace, redisClient)
+			if err != nil {
+				return err
+			}
+			deliveryOpts.RateLimitBuckets, err = newRateLimitBuckets(rateLimitBackend, redisClient)
+			if err != nil {
+				return err
+			}
+			deliveryOpts.FloodStore, err = newFloodStore(floodBackend, redisClient)
+			if err != nil {
+				return err
+			}
+			if deliveryOpts.DryRun {
+				// the shared state, rate limits, flood protection and delivery queue belong to the live controller
+				stateStore = state.NewMemoryStore()
+				deliveryOpts.RateLimitBuckets = nil
+				deliveryOpts.FloodStore = nil
+				deliveryQueue = deliveryQueueNone
+			}
+
+			switch deliveryQueue {
+			case deliveryQueueNone, "":
+			case deliveryQueueCRD:
+				deliveryOpts.Queue = queue.NewCRDQueue(dynamicClient, namespace)
+			default:
+				return fmt.Errorf("delivery queue '%s' is not supported", deliveryQueue)
+			}
+			if silencesEnabled {
+				silenceInformer := silences.NewInformer(dynamicClient, namespace, 0)
+				go silenceInformer.Run(context.Background().Done())
+				if !cache.WaitForCacheSync(context.Background().Done(), silenceInformer.HasSynced) {
+					return errors.New("timed out waiting for silences cache to sync")
+				}
+				deliveryOpts.Silences = silences.NewInformerMatcher(silenceInformer)
+			}
+			deliveryOpts.OrderedNotifiers = map[string]bool{}
+			for _, notifier := range orderedNotifiers {
+				deliveryOpts.OrderedNotifiers[notifier] = true
+			}
+			deliveryOpts.NotifierTimeouts, err = parseNotifierTimeouts(notifierTimeouts)
+			if err != nil {
+				return err
+			}
+			for service, maxConcurrent := range deliveryOpts.NotifierMaxConcurrent {
+				if maxConcurrent <= 0 {
+					return fmt.Errorf("max concurrency of notifier %s should be a positive number, got %d", service, maxConcurrent)
+				}
+			}
+			var canary *configCanary
+			if canarySelector != "" {
+				selector, err := labels.Parse(canarySelector)
 				if err != nil {
-					return err
+					return fmt.Errorf("invalid config canary selector: %v", err)
 				}
-				ctx, cancel := context.WithCancel(context.Background())
-				cancelPrev = cancel
+				tracker := newCanaryTracker(selector)
+				deliveryOpts.Observer = tracker
+				canary = newConfigCanary(canaryOpts{selector: selector, duration: canaryDuration, maxRatio: canaryMaxRatio}, tracker)
+			}
+			deliveryPipeline := controller.NewDeliveryPipeline(deliveryOpts, registry, historyStore)
+			deliveryPipeline.SetControllers(running.get)
+			aggregates := controller.NewAggregateEvaluator(deliveryPipeline, running.get, argocdService)
+			if adminAPI {
+				token := os.Getenv(adminAPITokenEnv)
+				if token == "" {
+					return fmt.Errorf("admin API requires the %s environment variable", adminAPITokenEnv)
+				}
+				adminOpts := controller.AdminOptions{
+					Config: func() *settings.Config {
+						cfg, _ := active.get()
+						return cfg
+					},
+					Controllers: running.get,
+					Silences:    silences.NewCRDStore(dynamicClient, namespace),
+					History:     historyStore,
+					Token:       token,
+				}
+				if deliveryOpts.FloodThreshold > 0 {
+					adminOpts.Flood = deliveryPipeline
+				}
+				mux.Handle("/api/admin/", controller.NewAdminHandler(adminOpts))
+			}
+
+			if uiPort > 0 {
+				uiToken := os.Getenv(uiTokenEnv)
+				if uiToken == "" && !isLoopbackAddress(uiAddress) {
+					return fmt.Errorf("web UI on the non-loopback address %s requires the %s environment variable", uiAddress, uiTokenEnv)
+				}
+				appProjClient := clients.NewAppProjClient(sources[0].client, namespace)
+				uiHandler := ui.NewHandler(ui.Options{
+					Config:  active.get,
+					History: historyStore,
+					GetApp: func(appNamespace string, name string) (*unstructured.Unstructured, error) {
+						source, err := findAppSource(sources, appNamespace, namespace)
+						if err != nil {
+							return nil, err
+						}
+						return clients.NewAppClient(source.client, source.namespace).Get(name, metav1.GetOptions{})
+					},
+					GetProject: func(name string) (*unstructured.Unstructured, error) {
+						return appProjClient.Get(name, metav1.GetOptions{})
+					},
+					ArgoCDService: argocdService,
+					Token:         uiToken,
+				})
+				go func() {
+					log.Fatal(http.ListenAndServe(net.JoinHostPort(uiAddress, strconv.Itoa(uiPort)), uiHandler))
+				}()
+				log.Infof("serving web UI on %s port %d", uiAddress, uiPort)
+			}
 
-				err = ctrl.Init(ctx)
+			status := &configStatus{}
+			mux.Handle("/debug/config", status)
+			health := newHealthChecker(status, readinessCheckNotifiers)
+			mux.HandleFunc("/healthz", health.serveLiveness)
+			mux.HandleFunc("/readyz", health.serveReadiness)
+			broadcaster := record.NewBroadcaster()
+			// events are created in the namespace of the involved object
+			broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8sClient.CoreV1().Events("")})
+			recorder := broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "argocd-notifications-controller"})
+			opts := configWatchOpts{
+				configMapSelector: configMapSelector,
+				refreshInterval:   configRefreshInterval,
+				status:            status,
+				metrics:           registry,
+				recorder:          recorder,
+			}
+			if configResources {
+				opts.resourcesClient = dynamicClient
+			}
+			opts.canary = canary
+			if len(selfTestRecipients) > 0 {
+				hostname, _ := os.Hostname()
+				test := &selfTest{recipients: selfTestRecipients, host: hostname}
+				opts.onActivated = func(notifiers map[string]notifiers.Notifier, version configVersion) {
+					go test.run(notifiers, version)
+				}
+			}
+			for _, dir := range configDirs {
+				opts.sources = append(opts.sources, settings.NewDirSource(dir))
+			}
+			for _, u := range configURLs {
+				source, err := settings.NewURLSource(u)
 				if err != nil {
 					return err
 				}
+				opts.sources = append(opts.sources, source)
+			}
 
-				go ctrl.Run(ctx, processorsCount)
+			run := func(ctx context.Context) {
+				if historyStore != nil {
+					go wait.Until(func() {
+						if err := historyStore.Prune(time.Now().Add(-historyRetention)); err != nil {
+							log.Warnf("Failed to prune notification history: %v", err)
+						}
+					}, historyPruneInterval, ctx.Done())
+				}
+				go deliveryPipeline.Run(ctx)
+				// every shard watches all applications, so the aggregate triggers are evaluated by the first shard only
+				if sharding.Replicas <= 1 || sharding.Shard == 0 {
+					go aggregates.Run(ctx)
+				}
+				if eventBusSubject != "" {
+					go controller.ConsumeEventBus(ctx, eventBus, eventBusSubject, running.get)
+				}
+				var cancelPrev context.CancelFunc
+				// applyConfig restarts the controllers of every configuration group. The delivery settings, e.g. rate
+				// limits, are read from the first group.
+				applyConfig := func(groups ...configGroup) error {
+					if cancelPrev != nil {
+						log.Info("Settings had been updated. Restarting controller...")
+						cancelPrev()
+						cancelPrev = nil
+					}
+					primary := groups[0]
+					deliveryPipeline.SetNotifiers(primary.notifiers)
+					health.setNotifiers(primary.notifiers)
+					active.set(primary.cfg, primary.notifiers)
+					deliveryPipeline.SetRateLimits(primary.cfg.RateLimits)
+					deliveryPipeline.SetBatching(primary.cfg.Batching)
+					deliveryPipeline.SetEmailDigests(primary.cfg.EmailDigests, primary.cfg.Templates, primary.cfg.Context)
+					deliveryPipeline.SetEscalations(primary.cfg.Escalations)
+					aggregates.SetTriggers(primary.cfg.AggregateTriggers, primary.cfg.Templates, primary.cfg.Context)
+					if len(primary.cfg.Escalations) > 0 && historyStore == nil {
+						log.Warn("Escalations require notification history, notifications are not escalated")
+					}
+					ctrlCtx, cancel := context.WithCancel(ctx)
+					cancelPrev = cancel
+					var controllers []controller.NotificationController
+					defer func() {
+						running.set(controllers)
+					}()
+					for _, group := range groups {
+						groupSharding := sharding
+						groupSharding.Filter = group.filter
+						cfg := group.cfg
+						for _, source := range sources {
+							var appRecorder record.EventRecorder
+							// the events of remote cluster applications cannot be created using the local cluster client
+							if appEvents && !source.remote {
+								appRecorder = recorder
+							}
+							ctrl, err := controller.NewController(source.client, source.namespace, group.triggers, group.notifiers, source.mergeContext(cfg.Context), cfg.Subscriptions, cfg.Policies, source.labelSelector, groupSharding, informerOpts, registry, deliveryPipeline, appRecorder, stateStore, argocdService)
+							if err != nil {
+								return err
+							}
+							if err = ctrl.Init(ctrlCtx); err != nil {
+								return err
+							}
+							go ctrl.Run(ctrlCtx, processorsCount)
+							controllers = append(controllers, ctrl)
+						}
+					}
+					return nil
+				}
+				if canary != nil {
+					canary.opts.apply = func(stable configGroup, canaryGroup configGroup) error {
+						return applyConfig(stable, canaryGroup)
+					}
+				}
+				watchConfig(ctx, argocdService, k8sClient, namespace, opts, func(triggers map[string]triggers.Trigger, notifiers map[string]notifiers.Notifier, cfg *settings.Config) error {
+					return applyConfig(configGroup{triggers: triggers, notifiers: notifiers, cfg: cfg})
+				})
+				<-ctx.Done()
+			}
+			ctx := shutdownContext()
+			if !leaderElection.enabled {
+				run(ctx)
 				return nil
+			}
+			health.setStandby(true)
+			return runWithLeaderElection(ctx, k8sClient, namespace, leaderElection, func(ctx context.Context) {
+				health.setStandby(false)
+				run(ctx)
+			}, func() {
+				// the leadership is released on shutdown
+				if ctx.Err() == nil {
+					log.Fatal("Leader election lost")
+				}
 			})
-			<-context.Background().Done()
-			return nil
 		},
 	}
 	clientConfig = cmd.AddK8SFlagsToCmd(&command)
+	command.Flags().IntVar(&processorsCount, "processors", 1, "Number of workers processing applications in parallel. Changes of the same application are always processed in order")
 	command.Flags().IntVar(&processorsCount, "processors-count", 1, "Processors count.")
-	command.Flags().StringVar(&appLabelSelector, "app-label-selector", "", "App label selector.")
-	command.Flags().StringVar(&namespace, "namespace", "", "Namespace which controller handles. Current namespace if empty.")
-	command.Flags().StringVar(&logLevel, "loglevel", "info", "Set the logging level. One of: debug|info|warn|error")
+	_ = command.Flags().MarkDeprecated("processors-count", "use --processors instead")
+	command.Flags().StringVar(&appLabelSelector, "app-label-selector", "", "Label selector of applications processed by the controller, e.g. team=payments")
+	command.Flags().StringSliceVar(&namespaces, "namespace", nil, "Namespaces which applications controller handles. The first namespace holds the controller configuration. Current namespace if empty.")
+	logOpts = cmd.AddLogFlagsToCmd(&command)
+	command.Flags().StringVar(&logOpts.Level, "loglevel", "info", "Set the logging level. One of: debug|info|warn|error")
+	_ = command.Flags().MarkDeprecated("loglevel", "use --log-level instead")
 	command.Flags().IntVar(&metricsPort, "metrics-port", defaultMetricsPort, "Metrics port")
+	command.Flags().BoolVar(&debugPprof, "debug-pprof", false, "Serve the /debug/pprof profiling endpoints on the metrics port")
+	command.Flags().BoolVar(&deliveryOpts.DryRun, "dry-run", false, "Evaluate triggers and render templates but log notifications instead of sending them")
+	command.Flags().StringVar(&argocdServer.Address, "argocd-server", "", "Argo CD API server address. If specified applications are watched using the Argo CD API instead of the Kubernetes API. The token is read from the "+argocdAuthTokenEnv+" environment variable")
+	command.Flags().BoolVar(&argocdServer.PlainText, "argocd-server-plaintext", false, "Disable TLS of the Argo CD API server connection")
+	command.Flags().BoolVar(&argocdServer.Insecure, "argocd-server-insecure", false, "Skip the Argo CD API server certificate verification")
+	command.Flags().BoolVar(&adminAPI, "admin-api", false, "Serve the /api/admin/ endpoints that list triggers and application subscriptions, re-evaluate applications, create silences and resume the delivery paused by the flood protection. The bearer token is read from the "+adminAPITokenEnv+" environment variable")
+	command.Flags().StringVar(&ackLinks.BaseURL, "ack-url", "", "External URL of the metrics port which serves the "+controller.AckPath+" endpoint. If specified the signed acknowledgement link is available in templates as {{.context.ackURL}}. The signing key is read from the "+ackLinksKeyEnv+" environment variable")
+	command.Flags().DurationVar(&ackLinks.TTL, "ack-link-ttl", 7*24*time.Hour, "Duration the acknowledgement links stay valid")
+	command.Flags().BoolVar(&imageProvenance, "image-provenance", false, "Expose build metadata of the application images read from the container registries to triggers and templates using the images helpers")
+	command.Flags().StringVar(&registryConfigPath, "registry-config", "", "Path to the Docker config.json file with the container registries credentials used by --image-provenance. Anonymous access is used if empty")
+	command.Flags().StringSliceVar(&registryOpts.BuildURLKeys, "image-build-url-key", []string{imageregistry.DefaultBuildURLKey}, "Image labels or annotations which hold the URL of the CI run that built the image. The first present key is used")
+	command.Flags().BoolVar(&replayAPI, "replay-api", false, "Serve the /api/replay endpoint that re-sends notifications from the history. The bearer token is read from the "+replayAPITokenEnv+" environment variable")
+	command.Flags().IntVar(&eventsPort, "events-port", 0, "Port of the /api/events endpoint that accepts external application events. The bearer token is read from the "+eventsAPITokenEnv+" environment variable. Disabled if zero")
+	command.Flags().StringVar(&eventsTLSCertFile, "events-tls-cert-file", "", "Path to the TLS certificate file of the events endpoint")
+	command.Flags().StringVar(&eventsTLSKeyFile, "events-tls-private-key-file", "", "Path to the TLS private key file of the events endpoint")
+	command.Flags().StringVar(&eventBus.URL, "eventbus-url", "", "NATS server URL of the Argo Events EventBus which events are consumed by the controller. The credentials are read from the "+eventBusTokenEnv+" or "+eventBusUsernameEnv+" and "+eventBusPasswordEnv+" environment variables")
+	command.Flags().StringVar(&eventBusSubject, "eventbus-subject", "", "EventBus subject consumed by the controller, e.g. default.ci.*. Consuming is disabled if empty")
+	command.Flags().BoolVar(&appEvents, "app-events", true, "Emit NotificationSent and NotificationFailed Kubernetes events on applications")
+	command.Flags().BoolVar(&deliveryOpts.AppStatus, "app-status-annotation", false, "Write the last fired trigger, the last delivery result and the number of failures into the "+controller.AppStatusAnnotation+" application annotation")
+	command.Flags().StringVar(&tracing.endpoint, "otlp-endpoint", "", "OTLP gRPC collector address which receives notification pipeline traces, e.g. otel-collector:4317. Tracing is disabled if empty")
+	command.Flags().BoolVar(&tracing.insecure, "otlp-insecure", false, "Disable TLS of the OTLP collector connection")
+	command.Flags().StringToStringVar(&tracing.headers, "otlp-header", nil, "Header sent to the OTLP collector, e.g. --otlp-header api-key=<key>")
+	command.Flags().Float64Var(&tracing.sampleRatio, "trace-sample-ratio", 1, "Fraction of reconciliations which are traced")
+	command.Flags().IntVar(&metricsAppLabelsLimit, "metrics-app-labels-limit", 0, "Max number of applications in the per application metrics. Per application metrics are disabled if zero")
+	command.Flags().StringArrayVar(&selfTestRecipients, "self-test-recipient", nil, "Recipient in <service>:<target> format of the notification sent after the controller starts and loads the configuration, e.g. slack:ops")
+	command.Flags().BoolVar(&readinessCheckNotifiers, "readiness-check-notifiers", false, "Verify that notification services are reachable during the readiness check")
 	command.Flags().StringVar(&argocdRepoServer, "argocd-repo-server", "argocd-repo-server:8081", "Argo CD repo server address")
+	command.Flags().StringVar(&configMapSelector, "config-map-selector", "", "Label selector of additional config maps merged into the main configuration")
+	command.Flags().BoolVar(&configVariables, "config-variables", false, configVariablesUsage)
+	command.Flags().BoolVar(&configResources, "config-resources", false, "Merge the NotificationTrigger and NotificationTemplate resources into the main configuration")
+	command.Flags().StringVar(&canarySelector, "config-canary-selector", "", "Label selector of the canary applications which get the configuration changes before other applications. Disabled if empty")
+	command.Flags().DurationVar(&canaryDuration, "config-canary-duration", 10*time.Minute, "Duration of applying the configuration changes only to the canary applications")
+	command.Flags().Float64Var(&canaryMaxRatio, "config-canary-max-ratio", 3, "Max ratio of the canary applications notifications and delivery failures rates to the rates of other applications. The configuration change is rolled back if the canary exceeds it")
+	command.Flags().StringArrayVar(&configDirs, "config-dir", nil, "Directory with additional configuration files merged into the main configuration")
+	command.Flags().StringArrayVar(&configURLs, "config-url", nil, "URL of additional config.yaml merged into the main configuration. Use #sha256=<checksum> fragment to verify the content")
+	command.Flags().DurationVar(&configRefreshInterval, "config-refresh-interval", time.Minute, "Interval of reloading configuration from config directories and URLs")
+	command.Flags().IntVar(&deliveryOpts.MaxAttempts, "delivery-max-attempts", 1, "Number of notification delivery attempts. Failed notification is retried during the next reconciliation if 1")
+	command.Flags().DurationVar(&deliveryOpts.InitialBackoff, "delivery-initial-backoff", 5*time.Second, "Delay before the first delivery retry. The delay is doubled after every attempt")
+	command.Flags().DurationVar(&deliveryOpts.MaxBackoff, "delivery-max-backoff", 5*time.Minute, "Max delay between delivery retries")
+	command.Flags().StringVar(&deliveryOpts.DeadLetterRecipient, "dead-letter-recipient", "", "Recipient in <type>:<name> format that receives notifications which were not delivered after max attempts")
+	command.Flags().StringVar(&deliveryQueue, "delivery-queue", deliveryQueueNone, "Persistent storage of notifications waiting for retry. One of: none|crd")
+	command.Flags().IntVar(&uiPort, "ui-port", 0, "Port of the read-only web UI that shows configured triggers and templates, recent deliveries and the template preview playground. Disabled if zero")
+	command.Flags().StringVar(&uiAddress, "ui-address", "127.0.0.1", "Address the web UI listens on. The bearer token read from the "+uiTokenEnv+" environment variable is required if the address is not a loopback one")
+	command.Flags().BoolVar(&silencesEnabled, "silences", false, "Suppress notifications matching the NotificationSilence resources, e.g. created by the bot")
+	command.Flags().DurationVar(&deliveryOpts.SendTimeout, "delivery-timeout", 0, "Max duration of a notification service call. The timed out call is canceled. Disabled if zero")
+	command.Flags().StringToStringVar(&notifierTimeouts, "notifier-timeout", nil, "Overrides delivery timeout of the notification service, e.g. --notifier-timeout email=30s")
+	command.Flags().StringToIntVar(&deliveryOpts.NotifierMaxConcurrent, "notifier-max-concurrent", nil, "Max number of the concurrent calls of the notification service, e.g. --notifier-max-concurrent email=5,slack=50")
+	command.Flags().StringSliceVar(&orderedNotifiers, "ordered-delivery", nil, "Notification services which receive notifications of the same application and recipient in the triggered order, e.g. --ordered-delivery slack,teams. Use * for all services")
+	command.Flags().IntVar(&deliveryOpts.CircuitBreakerThreshold, "circuit-breaker-threshold", 0, "Number of consecutive failures which stop notifications to the notification service. Disabled if zero")
+	command.Flags().DurationVar(&deliveryOpts.CircuitBreakerCooldown, "circuit-breaker-cooldown", time.Minute, "Delay before sending a trial notification to the notification service with open circuit breaker")
+	command.Flags().IntVar(&deliveryOpts.FloodThreshold, "flood-threshold", 0, "Max number of notifications within the --flood-window. The delivery is paused until it is resumed using the admin API once the threshold is exceeded. Disabled if zero")
+	command.Flags().DurationVar(&deliveryOpts.FloodWindow, "flood-window", 10*time.Minute, "Duration of the window which limits the number of notifications using the --flood-threshold")
+	command.Flags().StringVar(&deliveryOpts.FloodAlertRecipient, "flood-alert-recipient", "", "Recipient in <type>:<name> format that receives the alert once the delivery is paused by the flood protection")
+	command.Flags().StringVar(&floodBackend, "flood-backend", floodBackendMemory, "Storage of the flood protection state. The redis backend counts the notifications of all controller replicas and pauses the delivery of every replica. One of: memory|redis")
+	command.Flags().BoolVar(&leaderElection.enabled, "leader-elect", false, "Enable leader election, so only one of the controller replicas sends notifications")
+	command.Flags().StringVar(&leaderElection.leaseName, "leader-elect-lease-name", "argocd-notifications-controller", "Name of the Lease resource used for leader election")
+	command.Flags().DurationVar(&leaderElection.leaseDuration, "leader-elect-lease-duration", 15*time.Second, "Duration that standby replicas wait before taking over the leadership")
+	command.Flags().DurationVar(&leaderElection.renewDeadline, "leader-elect-renew-deadline", 10*time.Second, "Duration that the leader retries refreshing the leadership before giving up")
+	command.Flags().DurationVar(&leaderElection.retryPeriod, "leader-elect-retry-period", 2*time.Second, "Duration between leader election attempts")
+	command.Flags().IntVar(&sharding.Replicas, "replicas", 1, "Number of shards which split applications processing between controller replicas")
+	command.Flags().IntVar(&sharding.Shard, "shard", -1, "Zero based index of the shard processed by the controller replica. Inferred from the StatefulSet pod name ordinal if negative")
+	command.Flags().StringVar(&sharding.Key, "sharding-key", controller.ShardingKeyName, "Application attribute used to assign shards. One of: name|project")
+	command.Flags().StringVar(&instancesPath, "instances", "", "Path to the file with the list of Argo CD instances which applications are watched by the controller")
+	command.Flags().DurationVar(&informerOpts.ResyncPeriod, "app-resync-period", controller.DefaultResyncPeriod, "Interval of re-evaluating triggers of all applications. Periodic resync is disabled if zero")
+	command.Flags().DurationVar(&informerOpts.WatchTimeout, "watch-timeout", 0, "Timeout of the applications watch requests. The API server default is used if zero")
+	command.Flags().BoolVar(&informerOpts.SkipUnchanged, "skip-unchanged-apps", false, "Skip re-evaluation of applications which were not modified, e.g. during the resync and relist after the watch expiration")
+	command.Flags().StringVar(&stateBackend, "state-backend", stateBackendAnnotations, "Storage of the already sent notifications state. One of: annotations|configmap|redis")
+	command.Flags().StringVar(&rateLimitBackend, "rate-limit-backend", rateLimitBackendMemory, "Storage of the rate limit counters. The redis backend shares the rate limits by all controller replicas. One of: memory|redis")
+	command.Flags().StringSliceVar(&redisOpts.Addrs, "redis-address", nil, "Redis server address used by the redis state, rate limit and flood protection backends. The password is read from the "+redisPasswordEnv+" environment variable")
+	command.Flags().IntVar(&redisOpts.DB, "redis-db", 0, "Redis database number")
+	command.Flags().StringVar(&historyBackend, "history-backend", historyBackendNone, "Notification history backend. One of: none|crd|memory")
+	command.Flags().DurationVar(&historyRetention, "history-retention", 7*24*time.Hour, "Notification history retention period")
 	return &command
 }
 
-func watchConfig(ctx context.Context, argocdService argocd.Service, clientset kubernetes.Interface, namespace string, callback func(map[string]triggers.Trigger, map[string]notifiers.Notifier, *settings.Config) error) {
+// shutdownContext returns the context which is canceled when the process receives SIGTERM or SIGINT, so the
+// in-flight notifier calls are aborted instead of blocking the shutdown
+func shutdownContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		sig := <-signals
+		log.Infof("Received %v signal. Shutting down...", sig)
+		cancel()
+	}()
+	return ctx
+}
+
+func parseNotifierTimeouts(timeouts map[string]string) (map[string]time.Duration, error) {
+	res := map[string]time.Duration{}
+	for service, val := range timeouts {
+		timeout, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout of notifier %s: %v", service, err)
+		}
+		res[service] = timeout
+	}
+	return res, nil
+}
+
+// runningControllers holds controllers created after the latest configuration change
+type runningControllers struct {
+	lock        sync.Mutex
+	controllers []controller.NotificationController
+}
+
+func (r *runningControllers) get() []controller.NotificationController {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.controllers
+}
+
+func (r *runningControllers) set(controllers []controller.NotificationController) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.controllers = controllers
+}
+
+// activeConfig holds the configuration and notification services used by the running controllers
+type activeConfig struct {
+	lock      sync.RWMutex
+	cfg       *settings.Config
+	notifiers map[string]notifiers.Notifier
+}
+
+func (a *activeConfig) get() (*settings.Config, map[string]notifiers.Notifier) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.cfg, a.notifiers
+}
+
+func (a *activeConfig) set(cfg *settings.Config, services map[string]notifiers.Notifier) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.cfg = cfg
+	a.notifiers = services
+}
+
+// newRedisClientFactory returns the function that creates the Redis client on the first call, so the redis backends
+// share the client
+func newRedisClientFactory(redisOpts redis.UniversalOptions) func(backend string) (redis.UniversalClient, error) {
+	var client redis.UniversalClient
+	return func(backend string) (redis.UniversalClient, error) {
+		if len(redisOpts.Addrs) == 0 {
+			return nil, fmt.Errorf("--redis-address is required by the redis %s backend", backend)
+		}
+		if client == nil {
+			redisOpts.Password = os.Getenv(redisPasswordEnv)
+			client = redis.NewUniversalClient(&redisOpts)
+		}
+		return client, nil
+	}
+}
+
+func newStateStore(backend string, clientset kubernetes.Interface, namespace string, redisClient func(backend string) (redis.UniversalClient, error)) (state.Store, error) {
+	switch backend {
+	case stateBackendAnnotations, "":
+		// annotation store is created for each controller since it uses the application client
+		return nil, nil
+	case stateBackendConfigMap:
+		return state.NewConfigMapStore(clientset, namespace), nil
+	case stateBackendRedis:
+		client, err := redisClient("state")
+		if err != nil {
+			return nil, err
+		}
+		return state.NewRedisStore(client), nil
+	default:
+		return nil, fmt.Errorf("state backend '%s' is not supported", backend)
+	}
+}
+
+func newRateLimitBuckets(backend string, redisClient func(backend string) (redis.UniversalClient, error)) (ratelimit.Buckets, error) {
+	switch backend {
+	case rateLimitBackendMemory, "":
+		return nil, nil
+	case rateLimitBackendRedis:
+		client, err := redisClient("rate limit")
+		if err != nil {
+			return nil, err
+		}
+		return ratelimit.NewRedisBuckets(client), nil
+	default:
+		return nil, fmt.Errorf("rate limit backend '%s' is not supported", backend)
+	}
+}
+
+func newFloodStore(backend string, redisClient func(backend string) (redis.UniversalClient, error)) (flood.Store, error) {
+	switch backend {
+	case floodBackendMemory, "":
+		return nil, nil
+	case floodBackendRedis:
+		client, err := redisClient("flood protection")
+		if err != nil {
+			return nil, err
+		}
+		return flood.NewRedisStore(client), nil
+	default:
+		return nil, fmt.Errorf("flood protection backend '%s' is not supported", backend)
+	}
+}
+
+func newHistoryStore(backend string, dynamicClient dynamic.Interface, namespace string) (history.Store, error) {
+	switch backend {
+	case historyBackendNone, "":
+		return nil, nil
+	case historyBackendCRD:
+		return history.NewCRDStore(dynamicClient, namespace), nil
+	case historyBackendMemory:
+		return history.NewMemoryStore(historyMemoryLimit), nil
+	default:
+		return nil, fmt.Errorf("history backend '%s' is not supported", backend)
+	}
+}
+
+type configWatchOpts struct {
+	// configMapSelector is a label selector of additional config maps
+	configMapSelector string
+	// sources are loaded periodically and merged into the main configuration
+	sources []settings.Source
+	// refreshInterval is the interval of reloading sources
+	refreshInterval time.Duration
+	// status is updated after every configuration reload
+	status *configStatus
+	// metrics counts configuration reloads
+	metrics configReloadsMetrics
+	// recorder is used to report invalid configuration using Kubernetes events; optional
+	recorder record.EventRecorder
+	// onActivated is called after the new configuration version becomes active; optional
+	onActivated func(notifiers map[string]notifiers.Notifier, version configVersion)
+	// resourcesClient is used to watch the NotificationTrigger and NotificationTemplate resources; optional
+	resourcesClient dynamic.Interface
+	// canary applies the new configuration versions to the canary applications before other applications; optional
+	canary *configCanary
+}
+
+type configReloadsMetrics interface {
+	IncConfigReloadsCounter(succeeded bool)
+}
+
+type noopConfigReloadsMetrics struct{}
+
+func (m noopConfigReloadsMetrics) IncConfigReloadsCounter(_ bool) {}
+
+// unstructuredList returns the unstructured objects cached by the informer
+func unstructuredList(informer cache.SharedIndexInformer) []*unstructured.Unstructured {
+	var res []*unstructured.Unstructured
+	for _, obj := range informer.GetStore().List() {
+		if un, ok := obj.(*unstructured.Unstructured); ok {
+			res = append(res, un)
+		}
+	}
+	return res
+}
+
+type loadedSource struct {
+	configMap *v1.ConfigMap
+	secret    *v1.Secret
+}
+
+func watchConfig(ctx context.Context, argocdService argocd.Service, clientset kubernetes.Interface, namespace string, opts configWatchOpts, callback func(map[string]triggers.Trigger, map[string]notifiers.Notifier, *settings.Config) error) {
+	if opts.status == nil {
+		opts.status = &configStatus{}
+	}
+	if opts.metrics == nil {
+		opts.metrics = noopConfigReloadsMetrics{}
+	}
 	var secret *v1.Secret
 	var configMap *v1.ConfigMap
 	defaultConfig := settings.Config{
 		Context: map[string]string{argocdURLContextVariable: "https://localhost:4000"},
 	}
+	var extraCmInformer cache.SharedIndexInformer
+	if opts.configMapSelector != "" {
+		extraCmInformer = settings.NewConfigMapsInformer(clientset, namespace, opts.configMapSelector)
+	}
+	var triggerInformer, templateInformer cache.SharedIndexInformer
+	if opts.resourcesClient != nil {
+		triggerInformer = settings.NewResourceInformer(opts.resourcesClient, settings.NotificationTriggerResource, namespace)
+		templateInformer = settings.NewResourceInformer(opts.resourcesClient, settings.NotificationTemplateResource, namespace)
+	}
+	loadedSources := map[string]loadedSource{}
+	getExtraConfigMaps := func() []*v1.ConfigMap {
+		var res []*v1.ConfigMap
+		if extraCmInformer != nil {
+			for _, obj := range extraCmInformer.GetStore().List() {
+				if cm, ok := obj.(*v1.ConfigMap); ok && cm.Name != settings.ConfigMapName {
+					res = append(res, cm)
+				}
+			}
+		}
+		if triggerInformer != nil {
+			cm, err := settings.ResourcesConfigMap(unstructuredList(triggerInformer), unstructuredList(templateInformer))
+			if err != nil {
+				log.Errorf("Failed to read notification triggers and templates resources: %v", err)
+			} else {
+				res = append(res, cm)
+			}
+		}
+		return res
+	}
+	getSourcesConfig := func() ([]*v1.ConfigMap, []*v1.Secret) {
+		var configMaps []*v1.ConfigMap
+		var secrets []*v1.Secret
+		for _, source := range opts.sources {
+			loaded, ok := loadedSources[source.Name()]
+			if !ok {
+				continue
+			}
+			if loaded.configMap != nil {
+				configMaps = append(configMaps, loaded.configMap)
+			}
+			if loaded.secret != nil {
+				secrets = append(secrets, loaded.secret)
+			}
+		}
+		return configMaps, secrets
+	}
 	lock := &sync.Mutex{}
+	// stable is the configuration applied to all applications; nil until the first configuration version is active
+	var stable *configGroup
+	// activate applies the configuration to all applications
+	activate := func(group configGroup, version configVersion, sourceSecrets []*v1.Secret) {
+		if err := callback(group.triggers, group.notifiers, group.cfg); err != nil {
+			log.Fatalf("Failed to start controller: %v", err)
+		}
+		stable = &group
+		opts.metrics.IncConfigReloadsCounter(true)
+		opts.status.setActive(version)
+		if opts.onActivated != nil {
+			opts.onActivated(group.notifiers, version)
+		}
+		notifiersConfig, err := settings.ParseNotifiersConfig(secret, group.cfg.Context, sourceSecrets...)
+		if err == nil {
+			err = opts.status.setConfig(group.cfg, notifiersConfig)
+		}
+		if err != nil {
+			log.Warnf("Failed to update debug configuration: %v", err)
+		}
+	}
+	// startCanary applies the configuration to the canary applications and activates it for all applications once
+	// the canary succeeds
+	startCanary := func(group configGroup, version configVersion, sourceSecrets []*v1.Secret) {
+		err := opts.canary.start(*stable, group, version, func(canaryErr error) {
+			lock.Lock()
+			defer lock.Unlock()
+			if ctx.Err() != nil || !opts.canary.isRunning(version) {
+				return
+			}
+			opts.canary.stop()
+			opts.status.setCanary(nil)
+			if canaryErr == nil {
+				log.Infof("Configuration canary of %s succeeded, applying configuration to all applications", version)
+				activate(group, version, sourceSecrets)
+				return
+			}
+			opts.metrics.IncConfigReloadsCounter(false)
+			opts.status.setFailed(version, fmt.Errorf("configuration canary failed: %v", canaryErr))
+			log.Errorf("Configuration canary of %s failed, continue using previous settings: %v", version, canaryErr)
+			if opts.recorder != nil && configMap.Name != "" {
+				opts.recorder.Eventf(configMap, v1.EventTypeWarning, canaryFailedReason, "Configuration canary failed: %v", canaryErr)
+			}
+			if err := callback(stable.triggers, stable.notifiers, stable.cfg); err != nil {
+				log.Fatalf("Failed to start controller: %v", err)
+			}
+		})
+		if err != nil {
+			log.Fatalf("Failed to start controller: %v", err)
+		}
+		opts.status.setCanary(&version)
+		log.Infof("Configuration %s is applied to canary applications for %v", version, opts.canary.opts.duration)
+	}
 	onNewConfigMapAndSecret := func(newSecret *v1.Secret, newConfigMap *v1.ConfigMap) {
 		lock.Lock()
 		defer lock.Unlock()
@@ -138,18 +953,50 @@ func watchConfig(ctx context.Context, argocdService argocd.Service, clientset ku
 		}
 
 		if secret != nil && configMap != nil {
-			if t, n, c, err := settings.ParseConfig(configMap, secret, defaultConfig, argocdService); err == nil {
-				if err = callback(t, n, c); err != nil {
-					log.Fatalf("Failed to start controller: %v", err)
+			sourceConfigMaps, sourceSecrets := getSourcesConfig()
+			extraConfigMaps := getExtraConfigMaps()
+			version := getConfigVersion(configMap, secret, extraConfigMaps, loadedSources)
+			// informers resync and sources refresh deliver not modified configuration
+			if opts.canary != nil && opts.canary.isRunning(version) {
+				return
+			}
+			if opts.status.isActive(version) {
+				// the configuration is reverted to the active version while the canary is running
+				if opts.canary != nil && opts.canary.running() {
+					opts.canary.stop()
+					opts.status.setCanary(nil)
+					if err := callback(stable.triggers, stable.notifiers, stable.cfg); err != nil {
+						log.Fatalf("Failed to start controller: %v", err)
+					}
 				}
-			} else {
-				log.Fatalf("Failed to parse new settings: %v", err)
+				return
+			}
+			t, n, c, err := settings.ParseConfig(configMap, secret, defaultConfig, argocdService, append(extraConfigMaps, sourceConfigMaps...), sourceSecrets)
+			if err != nil {
+				opts.metrics.IncConfigReloadsCounter(false)
+				// keep using the last known good configuration and report the error only once per version
+				if opts.status.setFailed(version, err) {
+					log.Errorf("Failed to parse new settings, continue using previous settings: %v", err)
+					if opts.recorder != nil && configMap.Name != "" {
+						opts.recorder.Eventf(configMap, v1.EventTypeWarning, invalidConfigReason, "Failed to parse notifications configuration: %v", err)
+					}
+				}
+				return
+			}
+			group := configGroup{triggers: t, notifiers: n, cfg: c}
+			if opts.canary != nil && stable != nil {
+				// the failed canary version is not retried until the configuration changes
+				if !opts.status.isFailed(version) {
+					startCanary(group, version, sourceSecrets)
+				}
+				return
 			}
+			activate(group, version, sourceSecrets)
 		}
 	}
 
 	onConfigMapChanged := func(newObj interface{}) {
-		if cm, ok := newObj.(*v1.ConfigMap); ok {
+		if cm, ok := newObj.(*v1.ConfigMap); ok && cm.Name == settings.ConfigMapName {
 			onNewConfigMapAndSecret(nil, cm)
 		}
 	}
@@ -181,10 +1028,77 @@ func watchConfig(ctx context.Context, argocdService argocd.Service, clientset ku
 			onSecretChanged(obj)
 		},
 	})
+	// loadSources reloads configuration sources and returns true if any of them has changed
+	loadSources := func() bool {
+		changed := false
+		for _, source := range opts.sources {
+			cm, s, err := source.Load()
+			if err != nil {
+				log.Errorf("Failed to load configuration from %s: %v", source.Name(), err)
+				continue
+			}
+			next := loadedSource{configMap: cm, secret: s}
+			lock.Lock()
+			if prev, ok := loadedSources[source.Name()]; !ok || !reflect.DeepEqual(prev, next) {
+				loadedSources[source.Name()] = next
+				changed = true
+			}
+			lock.Unlock()
+		}
+		return changed
+	}
+	if len(opts.sources) > 0 {
+		loadSources()
+	}
+
+	synced := []cache.InformerSynced{cmInformer.HasSynced, secretInformer.HasSynced}
+	if extraCmInformer != nil {
+		onExtraConfigMapChanged := func(obj interface{}) {
+			if cm, ok := obj.(*v1.ConfigMap); ok && cm.Name == settings.ConfigMapName {
+				return
+			}
+			if extraCmInformer.HasSynced() {
+				onNewConfigMapAndSecret(nil, nil)
+			}
+		}
+		extraCmInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: onExtraConfigMapChanged,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				onExtraConfigMapChanged(newObj)
+			},
+			DeleteFunc: onExtraConfigMapChanged,
+		})
+		go extraCmInformer.Run(ctx.Done())
+		// additional config maps should be loaded before the main config is parsed
+		if !cache.WaitForCacheSync(ctx.Done(), extraCmInformer.HasSynced) {
+			log.Fatal(errors.New("timed out waiting for caches to sync"))
+		}
+	}
+	if triggerInformer != nil {
+		onResourceChanged := func(_ interface{}) {
+			if triggerInformer.HasSynced() && templateInformer.HasSynced() {
+				onNewConfigMapAndSecret(nil, nil)
+			}
+		}
+		for _, informer := range []cache.SharedIndexInformer{triggerInformer, templateInformer} {
+			informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+				AddFunc: onResourceChanged,
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					onResourceChanged(newObj)
+				},
+				DeleteFunc: onResourceChanged,
+			})
+			go informer.Run(ctx.Done())
+		}
+		// triggers and templates should be loaded before the main config is parsed
+		if !cache.WaitForCacheSync(ctx.Done(), triggerInformer.HasSynced, templateInformer.HasSynced) {
+			log.Fatal(errors.New("timed out waiting for caches to sync"))
+		}
+	}
 	go secretInformer.Run(ctx.Done())
 	go cmInformer.Run(ctx.Done())
 
-	if !cache.WaitForCacheSync(ctx.Done(), cmInformer.HasSynced, secretInformer.HasSynced) {
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
 		log.Fatal(errors.New("timed out waiting for caches to sync"))
 	}
 	var missingWarn []string
@@ -194,7 +1108,43 @@ func watchConfig(ctx context.Context, argocdService argocd.Service, clientset ku
 	if len(secretInformer.GetStore().List()) == 0 {
 		missingWarn = append(missingWarn, fmt.Sprintf("secret %s", settings.SecretName))
 	}
+	if len(opts.sources) > 0 {
+		// configuration sources are sufficient so don't wait for the config map and secret
+		lock.Lock()
+		if configMap == nil {
+			configMap = &v1.ConfigMap{}
+		}
+		if secret == nil {
+			secret = &v1.Secret{}
+		}
+		lock.Unlock()
+		onNewConfigMapAndSecret(nil, nil)
+		reload := func() {
+			if loadSources() {
+				log.Info("Configuration sources had been updated")
+				onNewConfigMapAndSecret(nil, nil)
+			}
+		}
+		for _, source := range opts.sources {
+			if watchable, ok := source.(settings.WatchableSource); ok {
+				if err := watchable.Watch(ctx.Done(), reload); err != nil {
+					log.Warnf("Failed to watch %s, changes are loaded every %v: %v", source.Name(), opts.refreshInterval, err)
+				}
+			}
+		}
+		go wait.Until(reload, opts.refreshInterval, ctx.Done())
+		return
+	}
 	if len(missingWarn) > 0 {
 		log.Warnf("Cannot find %s. Waiting when both config map and secret are created.", strings.Join(missingWarn, " and "))
 	}
 }
+
+// isLoopbackAddress returns true if the listen address only accepts connections from the same host
+func isLoopbackAddress(address string) bool {
+	if address == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(address)
+	return ip != nil && ip.IsLoopback()
+}
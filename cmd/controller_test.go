@@ -2,13 +2,22 @@ package main
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/argoproj-labs/argocd-notifications/notifiers"
 	"github.com/argoproj-labs/argocd-notifications/shared/argocd/mocks"
@@ -54,7 +63,7 @@ templates:
 	notifiersMap := make(map[string]notifiers.Notifier)
 	argocdService := mocks.NewMockService(ctrl)
 	clientset := fake.NewSimpleClientset(configMap, secret)
-	watchConfig(ctx, argocdService, clientset, "default", func(t map[string]triggers.Trigger, n map[string]notifiers.Notifier, cfg *settings.Config) error {
+	watchConfig(ctx, argocdService, clientset, "default", configWatchOpts{}, func(t map[string]triggers.Trigger, n map[string]notifiers.Notifier, cfg *settings.Config) error {
 		triggersMap = t
 		notifiersMap = n
 		return nil
@@ -68,3 +77,214 @@ templates:
 	_, ok = notifiersMap["slack"]
 	assert.True(t, ok)
 }
+
+func TestWatchConfig_MergesSelectedConfigMaps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName, Namespace: "default"},
+		Data: map[string]string{
+			"trigger.on-sync-status-unknown": `{condition: "true", template: app-sync-status}`,
+			"template.app-sync-status":       `{title: hello}`,
+		},
+	}
+	teamConfigMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "argocd-notifications-cm-team-a",
+			Namespace: "default",
+			Labels:    map[string]string{"argocd-notifications.argoproj.io/config": "true"},
+		},
+		Data: map[string]string{
+			"trigger.on-team-a-event": `{condition: "true", template: app-sync-status}`,
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.SecretName, Namespace: "default"},
+	}
+
+	triggersMap := make(map[string]triggers.Trigger)
+	clientset := fake.NewSimpleClientset(configMap, teamConfigMap, secret)
+	watchConfig(ctx, mocks.NewMockService(ctrl), clientset, "default", configWatchOpts{configMapSelector: "argocd-notifications.argoproj.io/config=true"}, func(t map[string]triggers.Trigger, n map[string]notifiers.Notifier, cfg *settings.Config) error {
+		triggersMap = t
+		return nil
+	})
+
+	assert.Len(t, triggersMap, 2)
+	_, ok := triggersMap["on-team-a-event"]
+	assert.True(t, ok)
+}
+
+func TestWatchConfig_MergesResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName, Namespace: "default"},
+		Data: map[string]string{
+			"trigger.on-sync-status-unknown": `{condition: "true", template: app-sync-status}`,
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.SecretName, Namespace: "default"},
+	}
+	trigger := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argocd-notifications.argoproj.io/v1alpha1",
+		"kind":       "NotificationTrigger",
+		"metadata":   map[string]interface{}{"name": "on-team-a-event", "namespace": "default"},
+		"spec":       map[string]interface{}{"condition": "true", "template": "app-sync-status"},
+	}}
+	template := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argocd-notifications.argoproj.io/v1alpha1",
+		"kind":       "NotificationTemplate",
+		"metadata":   map[string]interface{}{"name": "app-sync-status", "namespace": "default"},
+		"spec":       map[string]interface{}{"title": "hello"},
+	}}
+
+	triggersMap := make(map[string]triggers.Trigger)
+	opts := configWatchOpts{resourcesClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), trigger, template)}
+	watchConfig(ctx, mocks.NewMockService(ctrl), fake.NewSimpleClientset(configMap, secret), "default", opts, func(t map[string]triggers.Trigger, n map[string]notifiers.Notifier, cfg *settings.Config) error {
+		triggersMap = t
+		return nil
+	})
+
+	assert.Len(t, triggersMap, 2)
+	_, ok := triggersMap["on-team-a-event"]
+	assert.True(t, ok)
+}
+
+func TestWatchConfig_LoadsDirSource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir, err := ioutil.TempDir("", "config")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "trigger.on-sync-status-unknown"), []byte(`{condition: "true", template: app-sync-status}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "template.app-sync-status"), []byte(`{title: hello}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notifiers.yaml"), []byte(`slack: {token: my-token}`), 0644))
+
+	triggersMap := make(map[string]triggers.Trigger)
+	notifiersMap := make(map[string]notifiers.Notifier)
+	// neither config map nor secret exist in the cluster
+	clientset := fake.NewSimpleClientset()
+	watchConfig(ctx, mocks.NewMockService(ctrl), clientset, "default", configWatchOpts{
+		sources:         []settings.Source{settings.NewDirSource(dir)},
+		refreshInterval: time.Hour,
+	}, func(t map[string]triggers.Trigger, n map[string]notifiers.Notifier, cfg *settings.Config) error {
+		triggersMap = t
+		notifiersMap = n
+		return nil
+	})
+
+	_, ok := triggersMap["on-sync-status-unknown"]
+	assert.True(t, ok)
+	_, ok = notifiersMap["slack"]
+	assert.True(t, ok)
+}
+
+func TestWatchConfig_KeepsLastKnownGoodConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName, Namespace: "default", ResourceVersion: "1"},
+		Data: map[string]string{
+			"trigger.on-sync-status-unknown": `{condition: "true", template: app-sync-status}`,
+			"template.app-sync-status":       `{title: hello}`,
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.SecretName, Namespace: "default", ResourceVersion: "1"},
+	}
+
+	var callbacksCount int32
+	clientset := fake.NewSimpleClientset(configMap, secret)
+	recorder := record.NewFakeRecorder(10)
+	status := &configStatus{}
+	watchConfig(ctx, mocks.NewMockService(ctrl), clientset, "default", configWatchOpts{status: status, recorder: recorder}, func(t map[string]triggers.Trigger, n map[string]notifiers.Notifier, cfg *settings.Config) error {
+		atomic.AddInt32(&callbacksCount, 1)
+		return nil
+	})
+	assert.Equal(t, int32(1), atomic.LoadInt32(&callbacksCount))
+
+	invalid := configMap.DeepCopy()
+	invalid.ResourceVersion = "2"
+	invalid.Data["trigger.on-sync-status-unknown"] = `{condition: "==", template: app-sync-status}`
+	_, err := clientset.CoreV1().ConfigMaps("default").Update(invalid)
+	assert.NoError(t, err)
+
+	select {
+	case event := <-recorder.Events:
+		assert.Contains(t, event, invalidConfigReason)
+	case <-time.After(5 * time.Second):
+		t.Fatal("invalid configuration event has not been recorded")
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&callbacksCount))
+	status.lock.RLock()
+	defer status.lock.RUnlock()
+	assert.Equal(t, "1", status.Active.ConfigMap)
+	assert.Equal(t, "2", status.Failed.ConfigMap)
+	assert.NotEmpty(t, status.Error)
+}
+
+func TestWatchConfig_SkipsNotModifiedConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName, Namespace: "default", ResourceVersion: "1"},
+		Data: map[string]string{
+			"trigger.on-sync-status-unknown": `{condition: "true", template: app-sync-status}`,
+			"template.app-sync-status":       `{title: hello}`,
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.SecretName, Namespace: "default", ResourceVersion: "1"},
+	}
+
+	var callbacksCount int32
+	clientset := fake.NewSimpleClientset(configMap, secret)
+	watchConfig(ctx, mocks.NewMockService(ctrl), clientset, "default", configWatchOpts{}, func(t map[string]triggers.Trigger, n map[string]notifiers.Notifier, cfg *settings.Config) error {
+		atomic.AddInt32(&callbacksCount, 1)
+		return nil
+	})
+	assert.Equal(t, int32(1), atomic.LoadInt32(&callbacksCount))
+
+	// the same resource version is delivered by the informer resync
+	_, err := clientset.CoreV1().ConfigMaps("default").Update(configMap.DeepCopy())
+	assert.NoError(t, err)
+	updated := configMap.DeepCopy()
+	updated.ResourceVersion = "2"
+	_, err = clientset.CoreV1().ConfigMaps("default").Update(updated)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&callbacksCount) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&callbacksCount))
+}
+
+func TestIsLoopbackAddress(t *testing.T) {
+	assert.True(t, isLoopbackAddress("127.0.0.1"))
+	assert.True(t, isLoopbackAddress("::1"))
+	assert.True(t, isLoopbackAddress("localhost"))
+	assert.False(t, isLoopbackAddress("0.0.0.0"))
+	assert.False(t, isLoopbackAddress(""))
+}
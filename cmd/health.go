@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+const (
+	healthCheckOK        = "ok"
+	endpointCheckTimeout = 2 * time.Second
+)
+
+// readinessStatus is the response of the readiness endpoint
+type readinessStatus struct {
+	Ready bool `json:"ready"`
+	// Checks holds the result of every check: "ok" or the error message. The endpoint checks are named
+	// <notifier>=<host>:<port>.
+	Checks map[string]string `json:"checks"`
+}
+
+// healthChecker serves liveness and readiness endpoints. The controller is ready once the configuration is
+// successfully loaded; optionally the readiness check verifies that notification service endpoints are reachable.
+type healthChecker struct {
+	status         *configStatus
+	checkEndpoints bool
+	dial           func(network, address string, timeout time.Duration) (net.Conn, error)
+
+	lock      sync.RWMutex
+	endpoints map[string][]string
+	// standby is true while the controller waits for the leadership
+	standby bool
+}
+
+func newHealthChecker(status *configStatus, checkEndpoints bool) *healthChecker {
+	return &healthChecker{status: status, checkEndpoints: checkEndpoints, dial: net.DialTimeout}
+}
+
+// setNotifiers updates the list of notification service endpoints
+func (h *healthChecker) setNotifiers(notifiersByName map[string]notifiers.Notifier) {
+	endpoints := map[string][]string{}
+	for name, notifier := range notifiersByName {
+		if endpointer, ok := notifier.(notifiers.Endpointer); ok {
+			endpoints[name] = endpointer.Endpoints()
+		}
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.endpoints = endpoints
+}
+
+// setStandby marks the controller replica that waits for the leadership. The standby replica does not load
+// configuration and is reported as ready.
+func (h *healthChecker) setStandby(standby bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.standby = standby
+}
+
+func (h *healthChecker) checkReadiness() readinessStatus {
+	h.lock.RLock()
+	standby := h.standby
+	h.lock.RUnlock()
+	if standby {
+		return readinessStatus{Ready: true, Checks: map[string]string{"leaderElection": "standby"}}
+	}
+	res := readinessStatus{Ready: true, Checks: map[string]string{"config": healthCheckOK}}
+	h.status.lock.RLock()
+	loaded := h.status.Active != nil
+	h.status.lock.RUnlock()
+	if !loaded {
+		res.Ready = false
+		res.Checks["config"] = "configuration is not loaded"
+	}
+	if !h.checkEndpoints {
+		return res
+	}
+	h.lock.RLock()
+	var endpoints []string
+	for name, notifierEndpoints := range h.endpoints {
+		for _, endpoint := range notifierEndpoints {
+			endpoints = append(endpoints, name+"="+endpoint)
+		}
+	}
+	h.lock.RUnlock()
+
+	var wg sync.WaitGroup
+	results := make([]string, len(endpoints))
+	for i := range endpoints {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			address := endpoints[i][strings.Index(endpoints[i], "=")+1:]
+			// dial resolves the host name, so both DNS and TCP connectivity are verified
+			conn, err := h.dial("tcp", address, endpointCheckTimeout)
+			if err != nil {
+				results[i] = err.Error()
+				return
+			}
+			_ = conn.Close()
+			results[i] = healthCheckOK
+		}(i)
+	}
+	wg.Wait()
+	for i := range endpoints {
+		res.Checks[endpoints[i]] = results[i]
+		if results[i] != healthCheckOK {
+			res.Ready = false
+		}
+	}
+	return res
+}
+
+func (h *healthChecker) serveLiveness(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte(healthCheckOK))
+}
+
+func (h *healthChecker) serveReadiness(w http.ResponseWriter, _ *http.Request) {
+	status := h.checkReadiness()
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+func TestHealthChecker_NotReadyUntilConfigLoaded(t *testing.T) {
+	status := &configStatus{}
+	health := newHealthChecker(status, false)
+
+	rr := httptest.NewRecorder()
+	health.serveReadiness(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), "configuration is not loaded")
+
+	status.setActive(configVersion{ConfigMap: "1", Secret: "1"})
+	rr = httptest.NewRecorder()
+	health.serveReadiness(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	health.serveLiveness(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestHealthChecker_ChecksEndpoints(t *testing.T) {
+	status := &configStatus{}
+	status.setActive(configVersion{ConfigMap: "1", Secret: "1"})
+	health := newHealthChecker(status, true)
+	health.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		if address == "smtp.example.com:587" {
+			return nil, errors.New("no such host")
+		}
+		client, server := net.Pipe()
+		_ = server.Close()
+		return client, nil
+	}
+	health.setNotifiers(map[string]notifiers.Notifier{
+		"slack": notifiers.NewSlackNotifier(notifiers.SlackOptions{}),
+	})
+
+	res := health.checkReadiness()
+	assert.True(t, res.Ready)
+	assert.Equal(t, map[string]string{"config": "ok", "slack=slack.com:443": "ok"}, res.Checks)
+
+	health.setNotifiers(map[string]notifiers.Notifier{
+		"email": notifiers.NewEmailNotifier(notifiers.EmailOptions{Host: "smtp.example.com", Port: 587}),
+	})
+	res = health.checkReadiness()
+	assert.False(t, res.Ready)
+	assert.Equal(t, map[string]string{"config": "ok", "email=smtp.example.com:587": "no such host"}, res.Checks)
+}
+
+func TestHealthChecker_StandbyIsReady(t *testing.T) {
+	health := newHealthChecker(&configStatus{}, false)
+	health.setStandby(true)
+
+	res := health.checkReadiness()
+
+	assert.True(t, res.Ready)
+	assert.Equal(t, map[string]string{"leaderElection": "standby"}, res.Checks)
+}
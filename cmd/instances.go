@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+)
+
+// instance is the Argo CD instance which applications are watched by the controller
+type instance struct {
+	// Name is the unique instance name
+	Name string `json:"name"`
+	// Kubeconfig is the path to the kube config of the instance cluster; the controller kube config is used if empty
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// KubeContext is the name of the kube config context; the current context is used if empty
+	KubeContext string `json:"kubeContext,omitempty"`
+	// Namespace is the Argo CD namespace; the kube config context namespace is used if empty
+	Namespace string `json:"namespace,omitempty"`
+	// Context overrides the template context values, e.g. argocdUrl
+	Context map[string]string `json:"context,omitempty"`
+	// AppLabelSelector overrides the --app-label-selector flag value
+	AppLabelSelector string `json:"appLabelSelector,omitempty"`
+}
+
+// appSource is the set of applications processed by a single controller
+type appSource struct {
+	name          string
+	client        dynamic.Interface
+	namespace     string
+	labelSelector string
+	context       map[string]string
+	// remote is true if applications are located in a cluster other than the controller cluster
+	remote bool
+}
+
+// mergeContext returns template context with the source specific overrides
+func (s appSource) mergeContext(context map[string]string) map[string]string {
+	res := recipients.CopyStringMap(context)
+	for k, v := range s.context {
+		res[k] = v
+	}
+	return res
+}
+
+// findAppSource returns the source that watches applications of the namespace; the controller namespace is used if
+// the namespace is empty. Returns an error if the namespace is watched by several sources, e.g. instances of different
+// clusters, since the application cannot be resolved unambiguously.
+func findAppSource(sources []appSource, namespace string, controllerNamespace string) (appSource, error) {
+	if namespace == "" {
+		namespace = controllerNamespace
+	}
+	var res []appSource
+	for _, source := range sources {
+		if source.namespace == namespace {
+			res = append(res, source)
+		}
+	}
+	switch len(res) {
+	case 0:
+		return appSource{}, fmt.Errorf("applications of namespace %s are not watched", namespace)
+	case 1:
+		return res[0], nil
+	default:
+		return appSource{}, fmt.Errorf("applications of namespace %s are watched by several instances", namespace)
+	}
+}
+
+func loadInstances(path string) ([]instance, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var instances []instance
+	if err = yaml.Unmarshal(data, &instances); err != nil {
+		return nil, fmt.Errorf("failed to parse instances file %s: %v", path, err)
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("instances file %s has no instances", path)
+	}
+	names := map[string]bool{}
+	for _, inst := range instances {
+		if inst.Name == "" {
+			return nil, errors.New("instance name must not be empty")
+		}
+		if names[inst.Name] {
+			return nil, fmt.Errorf("instance %s is defined more than once", inst.Name)
+		}
+		if _, err := labels.Parse(inst.AppLabelSelector); err != nil {
+			return nil, fmt.Errorf("instance %s has invalid app label selector '%s': %v", inst.Name, inst.AppLabelSelector, err)
+		}
+		names[inst.Name] = true
+	}
+	return instances, nil
+}
+
+// newAppSource returns source that watches applications of the instance. The default client and namespace are used
+// when instance has no kube config settings.
+func (i instance) newAppSource(defaultClient dynamic.Interface, defaultNamespace string, defaultLabelSelector string) (appSource, error) {
+	source := appSource{name: i.Name, client: defaultClient, namespace: defaultNamespace, labelSelector: defaultLabelSelector, context: i.Context}
+	if i.Kubeconfig != "" || i.KubeContext != "" {
+		source.remote = true
+		clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: i.Kubeconfig, Precedence: clientcmd.NewDefaultClientConfigLoadingRules().Precedence},
+			&clientcmd.ConfigOverrides{CurrentContext: i.KubeContext})
+		restConfig, err := clientConfig.ClientConfig()
+		if err != nil {
+			return source, fmt.Errorf("failed to load kube config of instance %s: %v", i.Name, err)
+		}
+		if source.client, err = dynamic.NewForConfig(restConfig); err != nil {
+			return source, err
+		}
+		if source.namespace, _, err = clientConfig.Namespace(); err != nil {
+			return source, err
+		}
+	}
+	if i.Namespace != "" {
+		source.namespace = i.Namespace
+	}
+	if i.AppLabelSelector != "" {
+		source.labelSelector = i.AppLabelSelector
+	}
+	return source, nil
+}
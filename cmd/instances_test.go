@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func writeInstances(t *testing.T, dir string, data string) string {
+	path := filepath.Join(dir, "instances.yaml")
+	if !assert.NoError(t, ioutil.WriteFile(path, []byte(data), 0644)) {
+		t.FailNow()
+	}
+	return path
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "instances")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return dir
+}
+
+func TestLoadInstances(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := writeInstances(t, dir, `
+- name: prod
+  kubeContext: prod
+  namespace: argocd
+  context:
+    argocdUrl: https://prod.example.com
+- name: staging
+`)
+	instances, err := loadInstances(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []instance{{
+		Name:        "prod",
+		KubeContext: "prod",
+		Namespace:   "argocd",
+		Context:     map[string]string{"argocdUrl": "https://prod.example.com"},
+	}, {
+		Name: "staging",
+	}}, instances)
+}
+
+func TestLoadInstances_Invalid(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	_, err := loadInstances(writeInstances(t, dir, `[]`))
+	assert.Error(t, err)
+
+	_, err = loadInstances(writeInstances(t, dir, `[{namespace: argocd}]`))
+	assert.EqualError(t, err, "instance name must not be empty")
+
+	_, err = loadInstances(writeInstances(t, dir, `[{name: prod}, {name: prod}]`))
+	assert.EqualError(t, err, "instance prod is defined more than once")
+
+	_, err = loadInstances(writeInstances(t, dir, `[{name: prod, appLabelSelector: "a in b"}]`))
+	assert.Error(t, err)
+}
+
+func TestInstanceNewAppSource_Default(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	source, err := instance{Name: "local", Namespace: "argocd-2"}.newAppSource(client, "argocd", "team=a")
+	assert.NoError(t, err)
+	assert.Equal(t, client, source.client)
+	assert.Equal(t, "argocd-2", source.namespace)
+	assert.Equal(t, "team=a", source.labelSelector)
+
+	source, err = instance{Name: "local", AppLabelSelector: "team=b"}.newAppSource(client, "argocd", "team=a")
+	assert.NoError(t, err)
+	assert.Equal(t, "argocd", source.namespace)
+	assert.Equal(t, "team=b", source.labelSelector)
+}
+
+func TestAppSourceMergeContext(t *testing.T) {
+	source := appSource{context: map[string]string{"argocdUrl": "https://prod.example.com"}}
+	context := map[string]string{"argocdUrl": "https://localhost:4000", "env": "prod"}
+
+	assert.Equal(t, map[string]string{
+		"argocdUrl": "https://prod.example.com",
+		"env":       "prod",
+	}, source.mergeContext(context))
+	assert.Equal(t, "https://localhost:4000", context["argocdUrl"])
+}
+
+func TestFindAppSource(t *testing.T) {
+	sources := []appSource{
+		{name: "remote", namespace: "team-b", remote: true},
+		{name: "default", namespace: "argocd"},
+		{name: "team-a", namespace: "team-a"},
+		{name: "team-a-remote", namespace: "team-a", remote: true},
+	}
+
+	source, err := findAppSource(sources, "", "argocd")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", source.name)
+
+	source, err = findAppSource(sources, "team-b", "argocd")
+	assert.NoError(t, err)
+	assert.Equal(t, "remote", source.name)
+
+	_, err = findAppSource(sources, "team-a", "argocd")
+	assert.EqualError(t, err, "applications of namespace team-a are watched by several instances")
+
+	_, err = findAppSource(sources, "team-c", "argocd")
+	assert.EqualError(t, err, "applications of namespace team-c are not watched")
+
+	_, err = findAppSource(sources, "", "team-c")
+	assert.EqualError(t, err, "applications of namespace team-c are not watched")
+}
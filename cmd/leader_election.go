@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+type leaderElectionOpts struct {
+	enabled       bool
+	leaseName     string
+	leaseDuration time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+	// identity of the candidate; the host name with random suffix is used if empty
+	identity string
+}
+
+// runWithLeaderElection blocks until context is canceled and invokes run function when the candidate acquires the
+// leadership. The run context is canceled and onStopped is invoked when the leadership is lost.
+func runWithLeaderElection(ctx context.Context, clientset kubernetes.Interface, namespace string, opts leaderElectionOpts, run func(ctx context.Context), onStopped func()) error {
+	if opts.leaseName == "" {
+		return errors.New("leader election lease name must not be empty")
+	}
+	identity := opts.identity
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to get host name: %v", err)
+		}
+		identity = hostname + "_" + rand.String(5)
+	}
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: opts.leaseName, Namespace: namespace},
+		Client:     clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   opts.leaseDuration,
+		RenewDeadline:   opts.renewDeadline,
+		RetryPeriod:     opts.retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            opts.leaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Infof("Acquired leadership as %s", identity)
+				run(ctx)
+			},
+			OnStoppedLeading: func() {
+				log.Warnf("Leadership of %s is lost", identity)
+				onStopped()
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					log.Infof("Controller %s is the leader, waiting in standby", leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	elector.Run(ctx)
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRunWithLeaderElection_SingleLeader(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	opts := leaderElectionOpts{
+		enabled:       true,
+		leaseName:     "argocd-notifications-controller",
+		leaseDuration: time.Second,
+		renewDeadline: 500 * time.Millisecond,
+		retryPeriod:   100 * time.Millisecond,
+	}
+	var leaders int32
+	var stopped int32
+	start := func(identity string) context.CancelFunc {
+		ctx, cancel := context.WithCancel(context.Background())
+		candidateOpts := opts
+		candidateOpts.identity = identity
+		go func() {
+			_ = runWithLeaderElection(ctx, clientset, "default", candidateOpts, func(ctx context.Context) {
+				atomic.AddInt32(&leaders, 1)
+				<-ctx.Done()
+			}, func() {
+				atomic.AddInt32(&stopped, 1)
+			})
+		}()
+		return cancel
+	}
+
+	cancelFirst := start("first")
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&leaders) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancelSecond := start("second")
+	defer cancelSecond()
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&leaders))
+
+	cancelFirst()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&leaders) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&stopped))
+}
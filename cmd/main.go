@@ -10,6 +10,13 @@ import (
 )
 
 func main() {
+	if isKubectlPlugin(os.Args[0]) {
+		if err := newKubectlPluginCommand().Execute(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
 	var command = &cobra.Command{
 		Use:   "argocd-notifications",
 		Short: "argocd controls a Argo CD server",
@@ -19,6 +26,7 @@ func main() {
 	}
 	command.AddCommand(newControllerCommand())
 	command.AddCommand(newBotCommand())
+	command.AddCommand(newWebhookCommand())
 	command.AddCommand(tools.NewToolsCommand())
 	if err := command.Execute(); err != nil {
 		fmt.Println(err)
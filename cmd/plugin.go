@@ -0,0 +1,31 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argocd-notifications/cmd/tools"
+)
+
+const (
+	// kubectlPluginBinary is the binary name kubectl resolves for the "kubectl argocd-notifications" command; the
+	// dashes of the plugin name are replaced with underscores since dashes separate plugin sub-commands
+	kubectlPluginBinary = "kubectl-argocd_notifications"
+)
+
+// isKubectlPlugin returns true if the binary is invoked as kubectl plugin
+func isKubectlPlugin(executable string) bool {
+	name := strings.TrimSuffix(filepath.Base(executable), ".exe")
+	return name == kubectlPluginBinary
+}
+
+// newKubectlPluginCommand returns the tools command as the root command of the plugin, so the tools are available as
+// "kubectl argocd-notifications <command>" and use the current kubeconfig context unless --context is specified
+func newKubectlPluginCommand() *cobra.Command {
+	command := tools.NewToolsCommand()
+	command.Use = kubectlPluginBinary
+	command.Short = "Argo CD Notifications CLI that helps to configure and troubleshoot notifications"
+	return command
+}
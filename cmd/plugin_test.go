@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsKubectlPlugin(t *testing.T) {
+	assert.True(t, isKubectlPlugin("/usr/local/bin/kubectl-argocd_notifications"))
+	assert.True(t, isKubectlPlugin("kubectl-argocd_notifications.exe"))
+	assert.False(t, isKubectlPlugin("/app/argocd-notifications"))
+}
+
+func TestKubectlPluginCommand(t *testing.T) {
+	command := newKubectlPluginCommand()
+	assert.Equal(t, kubectlPluginBinary, command.Name())
+	for _, flag := range []string{"kubeconfig", "context", "namespace", "config-map", "secret"} {
+		assert.NotNil(t, command.PersistentFlags().Lookup(flag), flag)
+	}
+	sub, _, err := command.Find([]string{"trigger", "run"})
+	assert.NoError(t, err)
+	assert.Equal(t, "run", sub.Name())
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
+)
+
+// selfTest sends the startup notification to the ops recipients once the first configuration is loaded, so the
+// notification services credentials are verified after every deploy
+type selfTest struct {
+	once sync.Once
+	// recipients are in <service>:<target> format
+	recipients []string
+	host       string
+}
+
+func (s *selfTest) run(services map[string]notifiers.Notifier, version configVersion) {
+	if len(s.recipients) == 0 {
+		return
+	}
+	s.once.Do(func() {
+		for recipient, err := range sendSelfTest(services, s.recipients, s.host, version) {
+			if err != nil {
+				log.Errorf("Self-test notification to %s failed: %v", recipient, err)
+			} else {
+				log.Infof("Self-test notification sent to %s", recipient)
+			}
+		}
+	})
+}
+
+// sendSelfTest sends the self-test notification to every recipient and returns the delivery errors by recipient
+func sendSelfTest(services map[string]notifiers.Notifier, recipients []string, host string, version configVersion) map[string]error {
+	notification := notifiers.Notification{
+		Title: "Notifications controller started",
+		Body:  fmt.Sprintf("Notifications controller %s started, configuration version %s loaded", host, version),
+	}
+	res := map[string]error{}
+	for _, recipient := range recipients {
+		parsed, err := sharedrecipients.Parse(recipient)
+		if err != nil {
+			res[recipient] = err
+			continue
+		}
+		notifier, ok := services[parsed.Service]
+		if !ok {
+			res[recipient] = fmt.Errorf("notification service %s is not configured", parsed.Service)
+			continue
+		}
+		res[recipient] = notifier.Send(notifiers.WithRecipientOptions(context.TODO(), parsed.Options()), notification, parsed.Target)
+	}
+	return res
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/notifiers/mocks"
+)
+
+func TestSendSelfTest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	slack := mocks.NewMockNotifier(ctrl)
+	email := mocks.NewMockNotifier(ctrl)
+	expected := notifiers.Notification{
+		Title: "Notifications controller started",
+		Body:  "Notifications controller controller-0 started, configuration version configMap 1, secret 2 loaded",
+	}
+	slack.EXPECT().Send(gomock.Any(), expected, "ops").Return(nil)
+	email.EXPECT().Send(gomock.Any(), expected, "ops@example.com").Return(errors.New("authentication failed"))
+
+	res := sendSelfTest(map[string]notifiers.Notifier{"slack": slack, "email": email},
+		[]string{"slack:ops", "email:ops@example.com", "teams:ops", "invalid"}, "controller-0", configVersion{ConfigMap: "1", Secret: "2"})
+
+	assert.NoError(t, res["slack:ops"])
+	assert.EqualError(t, res["email:ops@example.com"], "authentication failed")
+	assert.EqualError(t, res["teams:ops"], "notification service teams is not configured")
+	assert.Error(t, res["invalid"])
+}
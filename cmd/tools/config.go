@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/clients"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+func newConfigCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "config",
+		Short: "Configuration related commands",
+		RunE: func(c *cobra.Command, args []string) error {
+			return errors.New("select child command")
+		},
+	}
+	command.AddCommand(newConfigLintCommand(cmdContext))
+	command.AddCommand(newConfigValidateCommand(cmdContext))
+	command.AddCommand(newConfigDiffCommand(cmdContext))
+	command.AddCommand(newConfigDocsCommand(cmdContext))
+	command.AddCommand(newConfigMigrateCommand(cmdContext))
+	return &command
+}
+
+func newConfigLintCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		output          string
+		extraConfigMaps []string
+	)
+	var command = cobra.Command{
+		Use: "lint",
+		Example: `
+# Lint in-cluster config map and secret
+argocd-notifications tools config lint
+
+# Lint local config map together with additional config map, skip notification services checks
+argocd-notifications tools config lint --config-map ./argocd-notifications-cm.yaml --secret :empty \
+  --extra-config-map ./team-a-cm.yaml
+`,
+		Short: "Reports problems in the triggers, templates and subscriptions configuration",
+		RunE: func(c *cobra.Command, args []string) error {
+			_, _, issues, err := lintConfig(cmdContext, extraConfigMaps)
+			if err != nil {
+				return err
+			}
+			return printIssues(cmdContext, issues, output)
+		},
+	}
+	command.Flags().StringVarP(&output, "output", "o", "wide", "Output format. One of:json|yaml|wide|junit|sarif")
+	command.Flags().StringArrayVar(&extraConfigMaps, "extra-config-map", nil, "Additional config map file path which triggers and templates are merged with the main config map")
+	return &command
+}
+
+// lintConfig loads the config map, the additional config maps and the secret and returns the lint issues
+func lintConfig(cmdContext *commandContext, extraConfigMaps []string) (*v1.ConfigMap, []*v1.ConfigMap, []settings.LintIssue, error) {
+	configMap, err := cmdContext.loadConfigMap()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load config map: %v", err)
+	}
+	var extra []*v1.ConfigMap
+	for _, path := range extraConfigMaps {
+		var cm v1.ConfigMap
+		if err := readYAMLFile(path, &cm); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load config map %s: %v", path, err)
+		}
+		extra = append(extra, &cm)
+	}
+	var issues []settings.LintIssue
+	if cmdContext.secretPath == ":empty" {
+		issues = settings.LintConfigMaps(configMap, extra, nil)
+	} else {
+		secret, err := cmdContext.loadSecret()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load secret: %v", err)
+		}
+		notifiersCfg, err := settings.ParseSecret(secret)
+		if err != nil {
+			issues = append(issues, settings.LintIssue{Severity: settings.SeverityError, Rule: settings.RuleInvalidConfig, Message: err.Error()})
+			issues = append(issues, settings.LintConfigMaps(configMap, extra, nil)...)
+		} else {
+			issues = settings.LintConfigMaps(configMap, extra, &notifiersCfg)
+		}
+	}
+	return configMap, extra, issues, nil
+}
+
+// printIssues prints the issues and returns an error if at least one issue has error severity
+func printIssues(cmdContext *commandContext, issues []settings.LintIssue, output string) error {
+	if issues == nil {
+		issues = []settings.LintIssue{}
+	}
+	switch output {
+	case "", "wide":
+		if len(issues) == 0 {
+			_, _ = fmt.Fprintln(cmdContext.stdout, "No issues found")
+			break
+		}
+		w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "SEVERITY\tMESSAGE\n")
+		for _, issue := range issues {
+			_, _ = fmt.Fprintf(w, "%s\t%s\n", issue.Severity, issue.Message)
+		}
+		_ = w.Flush()
+	case "junit":
+		if err := writeJUnitReport(cmdContext.stdout, issues); err != nil {
+			return err
+		}
+	case "sarif":
+		if err := writeSARIFReport(cmdContext.stdout, issues, cmdContext.configMapLocation()); err != nil {
+			return err
+		}
+	default:
+		if err := printFormatted(issues, output, cmdContext.stdout); err != nil {
+			return err
+		}
+	}
+	if settings.HasErrors(issues) {
+		return errors.New("configuration has errors")
+	}
+	return nil
+}
+
+func newConfigMigrateCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		layout      string
+		annotations bool
+		dryRun      bool
+	)
+	var command = cobra.Command{
+		Use: "migrate",
+		Example: `
+# Convert local config map into the layout with a separate key per trigger and template
+argocd-notifications tools config migrate --config-map ./argocd-notifications-cm.yaml --layout keys
+
+# Convert in-cluster config map into the layout with all triggers and templates in config.yaml key
+argocd-notifications tools config migrate --layout flat
+
+# Print how recipients annotations of applications and projects are going to be converted
+argocd-notifications tools config migrate --annotations --dry-run
+`,
+		Short: "Converts the config map layout or the legacy recipients annotations of applications and projects",
+		RunE: func(c *cobra.Command, args []string) error {
+			if annotations {
+				return migrateAnnotations(cmdContext, dryRun)
+			}
+			configMap, err := cmdContext.loadConfigMap()
+			if err != nil {
+				return fmt.Errorf("failed to load config map: %v", err)
+			}
+			res, err := settings.MigrateConfigMap(configMap, settings.Layout(layout))
+			if err != nil {
+				return err
+			}
+			// server generated fields are not needed in the output manifest
+			res.ObjectMeta = metav1.ObjectMeta{Name: res.Name, Namespace: res.Namespace, Labels: res.Labels, Annotations: res.Annotations}
+			res.TypeMeta = metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"}
+			return printFormatted(res, "yaml", cmdContext.stdout)
+		},
+	}
+	command.Flags().StringVar(&layout, "layout", string(settings.LayoutKeys), "Config map layout. One of:keys|flat")
+	command.Flags().BoolVar(&annotations, "annotations", false, "Convert recipients annotations of applications and projects instead of the config map")
+	command.Flags().BoolVar(&dryRun, "dry-run", false, "Print annotations changes without updating applications and projects")
+	return &command
+}
+
+func migrateAnnotations(cmdContext *commandContext, dryRun bool) error {
+	_, dynamicClient, ns, err := cmdContext.getK8SClients()
+	if err != nil {
+		return err
+	}
+	resources := []struct {
+		kind   string
+		client func(dynamic.Interface, string) dynamic.ResourceInterface
+	}{
+		{kind: "application", client: clients.NewAppClient},
+		{kind: "project", client: clients.NewAppProjClient},
+	}
+	for _, res := range resources {
+		client := res.client(dynamicClient, ns)
+		list, err := client.List(metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list %ss: %v", res.kind, err)
+		}
+		for _, obj := range list.Items {
+			migrated, changed := recipients.MigrateAnnotations(obj.GetAnnotations())
+			if !changed {
+				continue
+			}
+			patch := recipients.AnnotationsPatch(obj.GetAnnotations(), migrated)
+			patchData, err := json.Marshal(map[string]map[string]interface{}{
+				"metadata": {"annotations": patch},
+			})
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				_, _ = fmt.Fprintf(cmdContext.stdout, "%s %s (dry run): %s\n", res.kind, obj.GetName(), string(patchData))
+				continue
+			}
+			if _, err = client.Patch(obj.GetName(), types.MergePatchType, patchData, metav1.PatchOptions{}); err != nil {
+				return fmt.Errorf("failed to patch %s %s: %v", res.kind, obj.GetName(), err)
+			}
+			_, _ = fmt.Fprintf(cmdContext.stdout, "%s %s migrated\n", res.kind, obj.GetName())
+		}
+	}
+	return nil
+}
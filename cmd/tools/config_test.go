@@ -0,0 +1,248 @@
+package tools
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/clients"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	testingutil "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func TestConfigLint(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{
+		Triggers: []triggers.NotificationTrigger{{
+			Name:      "my-trigger",
+			Condition: "app.metadata.name == 'guestbook'",
+			Template:  "missing-template",
+		}},
+		Templates: []triggers.NotificationTemplate{{
+			Name: "my-template",
+		}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newConfigLintCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.Error(t, err)
+	assert.Contains(t, stdout.String(), "trigger my-trigger references unknown template missing-template")
+	assert.Contains(t, stdout.String(), "template my-template is not used by any trigger")
+}
+
+func TestConfigLint_NoIssues(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{
+		Triggers: []triggers.NotificationTrigger{{
+			Name:      "my-trigger",
+			Condition: "app.metadata.name == 'guestbook'",
+			Template:  "my-template",
+		}},
+		Templates: []triggers.NotificationTemplate{{
+			Name: "my-template",
+		}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newConfigLintCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "No issues found")
+}
+
+func TestConfigValidate(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{
+		Triggers: []triggers.NotificationTrigger{{
+			Name:      "my-trigger",
+			Condition: "app.metadata.name > 5",
+			Template:  "my-template",
+		}},
+		Templates: []triggers.NotificationTemplate{{
+			Name: "my-template",
+			Notification: notifiers.Notification{
+				Body:  "{{index .app.status.resources 5}}",
+				Slack: &notifiers.SlackNotification{Attachments: "[{{.app.metadata.name}}]"},
+			},
+		}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newConfigValidateCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.EqualError(t, err, "configuration has errors")
+	assert.Contains(t, stdout.String(), "trigger my-trigger fails for sample application sample-synced")
+	assert.Contains(t, stdout.String(), "template my-template cannot be rendered: sample application sample-synced")
+}
+
+func TestConfigValidate_BuiltinConfig(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cfg, err := BuildConfigFromFS("../../builtin/templates", "../../builtin/triggers")
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx, closer, err := newTestContext(&stdout, &stderr, *cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newConfigValidateCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "No issues found\n", stdout.String())
+}
+
+func TestConfigMigrate(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{
+		Triggers: []triggers.NotificationTrigger{{
+			Name:      "my-trigger",
+			Condition: "app.metadata.name == 'guestbook'",
+			Template:  "my-template",
+		}},
+		Templates: []triggers.NotificationTemplate{{
+			Name: "my-template",
+		}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newConfigMigrateCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "trigger.my-trigger: |")
+	assert.Contains(t, stdout.String(), "template.my-template: |")
+	assert.NotContains(t, stdout.String(), "config.yaml")
+}
+
+func TestConfigMigrate_Annotations(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		testingutil.NewApp("guestbook", testingutil.WithAnnotations(map[string]string{
+			recipients.RecipientsAnnotation: "slack:my-channel",
+		})))
+	ctx.getK8SClients = func() (kubernetes.Interface, dynamic.Interface, string, error) {
+		return fake.NewSimpleClientset(), dynamicClient, testingutil.TestNamespace, nil
+	}
+
+	command := newConfigMigrateCommand(ctx)
+	assert.NoError(t, command.Flags().Set("annotations", "true"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "application guestbook migrated")
+
+	app, err := clients.NewAppClient(dynamicClient, testingutil.TestNamespace).Get("guestbook", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{recipients.SubscribeAnnotationPrefix + "slack": "my-channel"}, app.GetAnnotations())
+}
+
+func TestConfigDiff(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{
+		Triggers: []triggers.NotificationTrigger{{
+			Name: "on-sync-failed", Condition: "app.status.operationState.phase in ['Error', 'Failed']", Template: "app-sync-failed",
+		}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+	clientset := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName, Namespace: testingutil.TestNamespace},
+		Data: map[string]string{
+			"trigger.on-sync-failed": `{condition: "app.status.operationState.phase in ['Error']", template: app-sync-failed}`,
+			"trigger.on-deployed":    `{condition: "true", template: app-deployed}`,
+		},
+	})
+	ctx.getK8SClients = func() (kubernetes.Interface, dynamic.Interface, string, error) {
+		return clientset, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), testingutil.TestNamespace, nil
+	}
+
+	command := newConfigDiffCommand(ctx)
+	assert.NoError(t, command.Flags().Set("exit-code", "true"))
+	err = command.RunE(command, nil)
+	assert.EqualError(t, err, "found 2 differences")
+	assert.Equal(t, `- triggers.on-deployed
+  - {"condition":"true","template":"app-deployed"}
+~ triggers.on-sync-failed.condition
+  - app.status.operationState.phase in ['Error']
+  + app.status.operationState.phase in ['Error', 'Failed']
+`, stdout.String())
+}
+
+func TestConfigDocs(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	disabled := false
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{
+		Triggers: []triggers.NotificationTrigger{{
+			Name: "on-sync-failed", Condition: "app.status.operationState.phase in ['Error', 'Failed']", Template: "app-sync-failed",
+			Description: "Application syncing has failed",
+		}, {
+			Name: "on-deployed", Condition: "true", Template: "app-deployed", Enabled: &disabled,
+		}},
+		Templates: []triggers.NotificationTemplate{{
+			Name:         "app-sync-failed",
+			Notification: notifiers.Notification{Title: "Failed", Body: "{{.app.metadata.name}} sync failed", Slack: &notifiers.SlackNotification{}},
+		}},
+		Subscriptions: settings.DefaultSubscriptions{{Recipients: []string{"slack:alerts"}, Triggers: []string{"on-sync-failed"}}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newConfigDocsCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	docs := stdout.String()
+	assert.Contains(t, docs, "# Notifications Catalog")
+	assert.Contains(t, docs, "| [on-deployed](#on-deployed)       |                                | [app-deployed](#app-deployed)       | no      |")
+	assert.Contains(t, docs, "**condition**:\n```\napp.status.operationState.phase in ['Error', 'Failed']\n```")
+	assert.Contains(t, docs, "**default recipients**: slack:alerts")
+	assert.Contains(t, docs, "**customized for**: slack")
+	assert.Contains(t, docs, "| slack:alerts | on-sync-failed | all applications |")
+
+	stdout.Reset()
+	assert.NoError(t, command.Flags().Set("format", "html"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "<title>Notifications Catalog</title>")
+	assert.Contains(t, stdout.String(), `<h3 id="on-sync-failed">on-sync-failed</h3>`)
+}
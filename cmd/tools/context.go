@@ -30,6 +30,7 @@ type (
 type commandContext struct {
 	configMapPath string
 	secretPath    string
+	stdin         io.Reader
 	stdout        io.Writer
 	stderr        io.Writer
 	getK8SClients clientsSource
@@ -87,53 +88,65 @@ func getK8SClients(clientConfig clientcmd.ClientConfig) (kubernetes.Interface, d
 	return k8sClient, dynamicClient, ns, nil
 }
 
-func (c *commandContext) getConfig() (map[string]triggers.Trigger, map[string]notifiers.Notifier, *settings.Config, error) {
-	var builtin *settings.Config
-	var configMap v1.ConfigMap
+func readYAMLFile(path string, obj interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, obj)
+}
+
+func (c *commandContext) loadConfigMap() (*v1.ConfigMap, error) {
 	if c.configMapPath == "" {
 		k8sClient, _, ns, err := c.getK8SClients()
 		if err != nil {
-			return nil, nil, nil, err
-		}
-		cm, err := k8sClient.CoreV1().ConfigMaps(ns).Get(settings.ConfigMapName, metav1.GetOptions{})
-		if err != nil {
-			return nil, nil, nil, err
-		}
-		configMap = *cm
-	} else {
-		builtin = &settings.Config{}
-		data, err := ioutil.ReadFile(c.configMapPath)
-		if err != nil {
-			return nil, nil, nil, err
-		}
-		if err = yaml.Unmarshal(data, &configMap); err != nil {
-			return nil, nil, nil, err
+			return nil, err
 		}
+		return k8sClient.CoreV1().ConfigMaps(ns).Get(settings.ConfigMapName, metav1.GetOptions{})
+	}
+	var configMap v1.ConfigMap
+	if err := readYAMLFile(c.configMapPath, &configMap); err != nil {
+		return nil, err
 	}
+	return &configMap, nil
+}
 
-	var secret v1.Secret
-	if c.secretPath == ":empty" {
-		secret = v1.Secret{}
-	} else if c.secretPath == "" {
+// configMapLocation returns the config map file path or the in-cluster config map name
+func (c *commandContext) configMapLocation() string {
+	if c.configMapPath == "" {
+		return settings.ConfigMapName
+	}
+	return c.configMapPath
+}
+
+func (c *commandContext) loadSecret() (*v1.Secret, error) {
+	switch c.secretPath {
+	case ":empty":
+		return &v1.Secret{}, nil
+	case "":
 		k8sClient, _, ns, err := c.getK8SClients()
 		if err != nil {
-			return nil, nil, nil, err
-		}
-		s, err := k8sClient.CoreV1().Secrets(ns).Get(settings.SecretName, metav1.GetOptions{})
-		if err != nil {
-			return nil, nil, nil, err
-		}
-		secret = *s
-	} else {
-		data, err := ioutil.ReadFile(c.secretPath)
-		if err != nil {
-			return nil, nil, nil, err
-		}
-		if err = yaml.Unmarshal(data, &secret); err != nil {
-			return nil, nil, nil, err
+			return nil, err
 		}
+		return k8sClient.CoreV1().Secrets(ns).Get(settings.SecretName, metav1.GetOptions{})
+	}
+	var secret v1.Secret
+	if err := readYAMLFile(c.secretPath, &secret); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func (c *commandContext) getConfig() (map[string]triggers.Trigger, map[string]notifiers.Notifier, *settings.Config, error) {
+	configMap, err := c.loadConfigMap()
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	return settings.ParseConfig(&configMap, &secret, *builtin, &lazyArgocdServiceInitializer{})
+	secret, err := c.loadSecret()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return settings.ParseConfig(configMap, secret, settings.Config{}, c.argocdService, nil, nil)
 }
 
 func (c *commandContext) loadApplication(application string) (*unstructured.Unstructured, error) {
@@ -156,3 +169,19 @@ func (c *commandContext) loadApplication(application string) (*unstructured.Unst
 	}
 	return app, nil
 }
+
+func (c *commandContext) listApplications(selector string) ([]*unstructured.Unstructured, error) {
+	_, client, ns, err := c.getK8SClients()
+	if err != nil {
+		return nil, err
+	}
+	list, err := clients.NewAppClient(client, ns).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	var apps []*unstructured.Unstructured
+	for i := range list.Items {
+		apps = append(apps, &list.Items[i])
+	}
+	return apps, nil
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+var changeSigns = map[settings.ChangeType]string{
+	settings.ChangeAdded:   "+",
+	settings.ChangeRemoved: "-",
+	settings.ChangeChanged: "~",
+}
+
+func newConfigDiffCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		output   string
+		exitCode bool
+	)
+	var command = cobra.Command{
+		Use: "diff",
+		Example: `
+# Compare local config map with the in-cluster config map
+argocd-notifications tools config diff --config-map ./argocd-notifications-cm.yaml
+
+# Compare local config map and secret with the in-cluster ones and fail if there are any differences
+argocd-notifications tools config diff --config-map ./argocd-notifications-cm.yaml \
+  --secret ./argocd-notifications-secret.yaml --exit-code
+`,
+		Short: "Prints differences between the proposed and in-cluster configuration",
+		Long: `Prints differences between the proposed configuration specified by the --config-map and --secret flags and
+the in-cluster configuration. The triggers and templates are compared by name and the changes are reported per field,
+e.g. the trigger condition. The secret values are compared but not printed.`,
+		RunE: func(c *cobra.Command, args []string) error {
+			compareSecrets := cmdContext.secretPath != "" && cmdContext.secretPath != ":empty"
+			if cmdContext.configMapPath == "" && !compareSecrets {
+				return errors.New("the proposed configuration should be specified using --config-map or --secret flag")
+			}
+			active := *cmdContext
+			active.configMapPath = ""
+			active.secretPath = ""
+
+			changes := make([]settings.ConfigChange, 0)
+			if cmdContext.configMapPath != "" {
+				proposedConfigMap, err := cmdContext.loadConfigMap()
+				if err != nil {
+					return fmt.Errorf("failed to load config map: %v", err)
+				}
+				activeConfigMap, err := active.loadConfigMap()
+				if err != nil {
+					return fmt.Errorf("failed to load in-cluster config map: %v", err)
+				}
+				configMapChanges, err := settings.DiffConfigMaps(activeConfigMap, proposedConfigMap)
+				if err != nil {
+					return err
+				}
+				changes = append(changes, configMapChanges...)
+			}
+			if compareSecrets {
+				proposedSecret, err := cmdContext.loadSecret()
+				if err != nil {
+					return fmt.Errorf("failed to load secret: %v", err)
+				}
+				activeSecret, err := active.loadSecret()
+				if err != nil {
+					return fmt.Errorf("failed to load in-cluster secret: %v", err)
+				}
+				secretChanges, err := settings.DiffSecrets(activeSecret, proposedSecret)
+				if err != nil {
+					return err
+				}
+				changes = append(changes, secretChanges...)
+			}
+
+			switch output {
+			case "", "wide":
+				if len(changes) == 0 {
+					_, _ = fmt.Fprintln(cmdContext.stdout, "No differences found")
+					break
+				}
+				for _, change := range changes {
+					_, _ = fmt.Fprintf(cmdContext.stdout, "%s %s\n", changeSigns[change.Type], change.Path)
+					printChangeValue(cmdContext, "-", change.Old)
+					printChangeValue(cmdContext, "+", change.New)
+				}
+			default:
+				if err := printFormatted(changes, output, cmdContext.stdout); err != nil {
+					return err
+				}
+			}
+			if exitCode && len(changes) > 0 {
+				return fmt.Errorf("found %d differences", len(changes))
+			}
+			return nil
+		},
+	}
+	command.Flags().StringVarP(&output, "output", "o", "wide", "Output format. One of:json|yaml|wide")
+	command.Flags().BoolVar(&exitCode, "exit-code", false, "Exit with non-zero code if there are differences")
+	return &command
+}
+
+func printChangeValue(cmdContext *commandContext, sign string, value string) {
+	if value == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(value, "\n"), "\n") {
+		_, _ = fmt.Fprintf(cmdContext.stdout, "  %s %s\n", sign, line)
+	}
+}
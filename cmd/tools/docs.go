@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/russross/blackfriday"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func newConfigDocsCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		format          string
+		title           string
+		extraConfigMaps []string
+	)
+	var command = cobra.Command{
+		Use: "docs",
+		Example: `
+# Generate Markdown catalog of the in-cluster triggers, templates and subscriptions
+argocd-notifications tools config docs > notifications.md
+
+# Generate HTML catalog of the local config map merged with the team config map
+argocd-notifications tools config docs --config-map ./argocd-notifications-cm.yaml \
+  --extra-config-map ./team-a-cm.yaml --format html > notifications.html
+`,
+		Short: "Generates documentation of the configured triggers, templates and subscriptions",
+		Long: `Generates documentation of the configured triggers, templates and subscriptions, so the platform team can
+publish the catalog of available notifications for the application developers. The additional config maps are merged
+with the main config map the same way as the controller does.`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if format != "markdown" && format != "html" {
+				return fmt.Errorf("format '%s' is not supported", format)
+			}
+			configMap, err := cmdContext.loadConfigMap()
+			if err != nil {
+				return fmt.Errorf("failed to load config map: %v", err)
+			}
+			cfg, err := settings.ParseConfigMap(configMap)
+			if err != nil {
+				return fmt.Errorf("failed to parse config map: %v", err)
+			}
+			var extra []*v1.ConfigMap
+			for _, path := range extraConfigMaps {
+				var cm v1.ConfigMap
+				if err := readYAMLFile(path, &cm); err != nil {
+					return fmt.Errorf("failed to load config map %s: %v", path, err)
+				}
+				extra = append(extra, &cm)
+			}
+			if cfg, err = settings.MergeConfigMaps(cfg, extra...); err != nil {
+				return err
+			}
+			var markdown bytes.Buffer
+			generateConfigDocs(&markdown, title, cfg)
+			if format == "markdown" {
+				_, err = cmdContext.stdout.Write(markdown.Bytes())
+				return err
+			}
+			renderer := blackfriday.HtmlRenderer(blackfriday.HTML_COMPLETE_PAGE|blackfriday.HTML_SKIP_HTML|blackfriday.HTML_USE_XHTML, title, "")
+			extensions := blackfriday.EXTENSION_TABLES | blackfriday.EXTENSION_FENCED_CODE | blackfriday.EXTENSION_AUTOLINK |
+				blackfriday.EXTENSION_AUTO_HEADER_IDS | blackfriday.EXTENSION_NO_INTRA_EMPHASIS
+			_, err = cmdContext.stdout.Write(blackfriday.Markdown(markdown.Bytes(), renderer, extensions))
+			return err
+		},
+	}
+	command.Flags().StringVar(&format, "format", "markdown", "Documentation format. One of:markdown|html")
+	command.Flags().StringVar(&title, "title", "Notifications Catalog", "Documentation title")
+	command.Flags().StringArrayVar(&extraConfigMaps, "extra-config-map", nil, "Additional config map file path which triggers and templates are merged with the main config map")
+	return &command
+}
+
+func newMarkdownTable(out io.Writer, header ...string) *tablewriter.Table {
+	table := tablewriter.NewWriter(out)
+	table.SetHeader(header)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(false)
+	return table
+}
+
+// escapeCell escapes characters that break the Markdown table cell
+func escapeCell(val string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(val)
+}
+
+func describeSubscriptionScope(s settings.Subscription) string {
+	var parts []string
+	if s.Selector != nil && !s.Selector.Empty() {
+		parts = append(parts, "selector: `"+s.Selector.String()+"`")
+	}
+	if s.Exclude != nil && !s.Exclude.Empty() {
+		parts = append(parts, "exclude: `"+s.Exclude.String()+"`")
+	}
+	for name, values := range map[string][]string{"projects": s.Projects, "namespaces": s.Namespaces, "clusters": s.Clusters} {
+		if len(values) > 0 {
+			parts = append(parts, name+": "+strings.Join(values, ", "))
+		}
+	}
+	if len(parts) == 0 {
+		return "all applications"
+	}
+	sort.Strings(parts)
+	return escapeCell(strings.Join(parts, "; "))
+}
+
+// templateServices returns names of the services which have the service specific template fields
+func templateServices(t triggers.NotificationTemplate) []string {
+	var res []string
+	if t.Slack != nil {
+		res = append(res, "slack")
+	}
+	if t.CloudEvents != nil {
+		res = append(res, "cloudevents")
+	}
+	if t.Deployment != nil {
+		res = append(res, "github", "gitlab")
+	}
+	if t.Jenkins != nil {
+		res = append(res, "jenkins")
+	}
+	if t.GitHubDispatch != nil {
+		res = append(res, "github-dispatch")
+	}
+	for name := range t.Webhook {
+		res = append(res, "webhook:"+name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func generateConfigDocs(out io.Writer, title string, cfg *settings.Config) {
+	_, _ = fmt.Fprintf(out, "# %s\n\n", title)
+	_, _ = fmt.Fprintf(out, "Subscribe the application to the trigger notifications using the `%s<trigger>.<service>: <recipients>` annotation, e.g.:\n\n",
+		recipients.SubscribeAnnotationPrefix)
+	_, _ = fmt.Fprintf(out, "```yaml\nmetadata:\n  annotations:\n    %son-sync-failed.slack: my-channel\n```\n\n", recipients.SubscribeAnnotationPrefix)
+
+	sortedTriggers := append([]triggers.NotificationTrigger{}, cfg.Triggers...)
+	sort.Slice(sortedTriggers, func(i, j int) bool {
+		return sortedTriggers[i].Name < sortedTriggers[j].Name
+	})
+	_, _ = fmt.Fprintln(out, "## Triggers")
+	_, _ = fmt.Fprintln(out, "")
+	table := newMarkdownTable(out, "NAME", "DESCRIPTION", "TEMPLATE", "ENABLED")
+	for _, t := range sortedTriggers {
+		enabled := "yes"
+		if t.Enabled != nil && !*t.Enabled {
+			enabled = "no"
+		}
+		table.Append([]string{fmt.Sprintf("[%s](#%s)", t.Name, t.Name), escapeCell(t.Description), fmt.Sprintf("[%s](#%s)", t.Template, t.Template), enabled})
+	}
+	table.Render()
+	for _, t := range sortedTriggers {
+		_, _ = fmt.Fprintf(out, "\n### %s\n\n", t.Name)
+		if t.Description != "" {
+			_, _ = fmt.Fprintf(out, "%s\n\n", t.Description)
+		}
+		if t.Event != "" {
+			_, _ = fmt.Fprintf(out, "**event**: `%s`\n\n", t.Event)
+		}
+		_, _ = fmt.Fprintf(out, "**condition**:\n```\n%s\n```\n\n", strings.TrimSpace(t.Condition))
+		if t.OncePer != "" {
+			_, _ = fmt.Fprintf(out, "**oncePer**: `%s`\n\n", t.OncePer)
+		}
+		_, _ = fmt.Fprintf(out, "**template**: [%s](#%s)\n", t.Template, t.Template)
+		var defaultRecipients []string
+		for _, s := range cfg.Subscriptions {
+			if s.MatchesTrigger(t.Name) {
+				defaultRecipients = append(defaultRecipients, s.Recipients...)
+			}
+		}
+		if len(defaultRecipients) > 0 {
+			_, _ = fmt.Fprintf(out, "\n**default recipients**: %s\n", strings.Join(defaultRecipients, ", "))
+		}
+	}
+
+	sortedTemplates := append([]triggers.NotificationTemplate{}, cfg.Templates...)
+	sort.Slice(sortedTemplates, func(i, j int) bool {
+		return sortedTemplates[i].Name < sortedTemplates[j].Name
+	})
+	_, _ = fmt.Fprintln(out, "\n## Templates")
+	for _, t := range sortedTemplates {
+		_, _ = fmt.Fprintf(out, "\n### %s\n\n", t.Name)
+		if t.Title != "" {
+			_, _ = fmt.Fprintf(out, "**title**: `%s`\n\n", t.Title)
+		}
+		if t.Body != "" {
+			_, _ = fmt.Fprintf(out, "**body**:\n```\n%s\n```\n\n", strings.TrimSpace(t.Body))
+		}
+		if services := templateServices(t); len(services) > 0 {
+			_, _ = fmt.Fprintf(out, "**customized for**: %s\n", strings.Join(services, ", "))
+		}
+	}
+
+	if len(cfg.Subscriptions) > 0 {
+		_, _ = fmt.Fprintln(out, "\n## Default Subscriptions")
+		_, _ = fmt.Fprintln(out, "")
+		table = newMarkdownTable(out, "RECIPIENTS", "TRIGGERS", "APPLICATIONS")
+		for _, s := range cfg.Subscriptions {
+			subscriptionTriggers := "all triggers"
+			if len(s.Triggers) > 0 {
+				subscriptionTriggers = strings.Join(s.Triggers, ", ")
+			}
+			table.Append([]string{escapeCell(strings.Join(s.Recipients, ", ")), escapeCell(subscriptionTriggers), describeSubscriptionScope(s)})
+		}
+		table.Render()
+	}
+}
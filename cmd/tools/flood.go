@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argocd-notifications/controller"
+)
+
+const adminAPITokenEnv = "ADMIN_API_TOKEN"
+
+type adminAPIClient struct {
+	url   string
+	token string
+}
+
+// call sends the admin API request and decodes the response into the res
+func (c *adminAPIClient) call(method string, path string, res interface{}) error {
+	if c.token == "" {
+		return fmt.Errorf("the %s environment variable is required", adminAPITokenEnv)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.url, "/")+"/api/admin/"+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Error == "" {
+			return fmt.Errorf("admin API returned %s", resp.Status)
+		}
+		return errors.New(apiErr.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+func printFloodStatus(cmdContext *commandContext, status controller.FloodStatus) {
+	if !status.Paused {
+		_, _ = fmt.Fprintf(cmdContext.stdout, "Delivery is active, the limit is %d notifications within %s\n", status.Threshold, status.Window)
+		return
+	}
+	pausedAt := ""
+	if status.PausedAt != nil {
+		pausedAt = " at " + status.PausedAt.Format(time.RFC3339)
+	}
+	_, _ = fmt.Fprintf(cmdContext.stdout, "Delivery is paused%s, %d notifications were dropped\n", pausedAt, status.Suppressed)
+}
+
+func newFloodCommand(cmdContext *commandContext) *cobra.Command {
+	var adminAPIURL string
+	var command = cobra.Command{
+		Use:   "flood",
+		Short: "Prints the flood protection state and resumes the paused delivery",
+		Long: `Prints the flood protection state and resumes the delivery paused by the flood protection using the
+controller admin API. The admin API bearer token is read from the ` + adminAPITokenEnv + ` environment variable.`,
+		Run: func(c *cobra.Command, args []string) {
+			c.HelpFunc()(c, args)
+		},
+	}
+	command.PersistentFlags().StringVar(&adminAPIURL, "admin-api-url", "http://argocd-notifications-controller-metrics:9001", "URL of the controller admin API")
+
+	command.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Prints whether the delivery is paused by the flood protection",
+		Example: `
+# Print the flood protection state using port-forwarded admin API
+argocd-notifications tools flood status --admin-api-url http://localhost:9001
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			client := adminAPIClient{url: adminAPIURL, token: os.Getenv(adminAPITokenEnv)}
+			var status controller.FloodStatus
+			if err := client.call(http.MethodGet, "flood", &status); err != nil {
+				return fmt.Errorf("failed to get flood protection state: %v", err)
+			}
+			printFloodStatus(cmdContext, status)
+			return nil
+		},
+	})
+	command.AddCommand(&cobra.Command{
+		Use:   "resume",
+		Short: "Resumes the delivery paused by the flood protection",
+		Example: `
+# Resume the delivery once the cause of the notifications flood is fixed
+argocd-notifications tools flood resume --admin-api-url http://localhost:9001
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			client := adminAPIClient{url: adminAPIURL, token: os.Getenv(adminAPITokenEnv)}
+			var status controller.FloodStatus
+			if err := client.call(http.MethodPost, "flood/resume", &status); err != nil {
+				return fmt.Errorf("failed to resume delivery: %v", err)
+			}
+			printFloodStatus(cmdContext, status)
+			return nil
+		},
+	})
+	return &command
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runFloodCommand(t *testing.T, handler http.HandlerFunc, args ...string) (string, error) {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	var stdout bytes.Buffer
+	command := newFloodCommand(&commandContext{stdout: &stdout})
+	assert.NoError(t, command.PersistentFlags().Set("admin-api-url", server.URL))
+	subCommand, _, err := command.Find(args)
+	if !assert.NoError(t, err) {
+		return "", err
+	}
+	err = subCommand.RunE(subCommand, nil)
+	return stdout.String(), err
+}
+
+func TestFlood(t *testing.T) {
+	_ = os.Setenv(adminAPITokenEnv, "secret")
+	defer func() {
+		_ = os.Unsetenv(adminAPITokenEnv)
+	}()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/admin/flood":
+			_, _ = w.Write([]byte(`{"paused":true,"pausedAt":"2020-06-01T10:00:00Z","suppressed":5}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/admin/flood/resume":
+			_, _ = w.Write([]byte(`{"paused":false,"threshold":100,"window":"10m0s"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	out, err := runFloodCommand(t, handler, "status")
+	assert.NoError(t, err)
+	assert.Equal(t, "Delivery is paused at 2020-06-01T10:00:00Z, 5 notifications were dropped\n", out)
+
+	out, err = runFloodCommand(t, handler, "resume")
+	assert.NoError(t, err)
+	assert.Equal(t, "Delivery is active, the limit is 100 notifications within 10m0s\n", out)
+
+	_, err = runFloodCommand(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"error":"delivery is not paused"}`))
+	}, "resume")
+	assert.EqualError(t, err, "failed to resume delivery: delivery is not paused")
+}
+
+func TestFlood_TokenRequired(t *testing.T) {
+	_, err := runFloodCommand(t, func(w http.ResponseWriter, r *http.Request) {}, "status")
+	assert.EqualError(t, err, "failed to get flood protection state: the ADMIN_API_TOKEN environment variable is required")
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+)
+
+func newHistoryCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		filter   history.Filter
+		status   string
+		since    string
+		follow   bool
+		interval time.Duration
+		output   string
+	)
+	var command = cobra.Command{
+		Use:   "history",
+		Short: "Prints notification history and optionally streams newly recorded notifications",
+		Example: `
+# Print notifications sent during the last hour
+argocd-notifications tools history --since 1h
+
+# Watch the guestbook application notifications of the on-sync-failed trigger
+argocd-notifications tools history --app guestbook --trigger on-sync-failed --follow
+
+# Stream failed notifications as JSON lines
+argocd-notifications tools history --status Failed --follow -o json
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if output != "wide" && output != "json" {
+				return fmt.Errorf("output '%s' is not supported", output)
+			}
+			var err error
+			if filter.Since, err = parseReplayTime(since); err != nil {
+				return err
+			}
+			filter.Status = history.Status(status)
+			_, client, ns, err := cmdContext.getK8SClients()
+			if err != nil {
+				return err
+			}
+			stopCh := make(chan struct{})
+			if !follow {
+				close(stopCh)
+			}
+			w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+			if output == "wide" {
+				_, _ = fmt.Fprintf(w, "TIMESTAMP\tAPP\tTRIGGER\tRECIPIENT\tSTATUS\tERROR\n")
+			}
+			err = history.Tail(history.NewCRDStore(client, ns), filter, interval, stopCh, func(e history.Event) {
+				if output == "json" {
+					data, _ := json.Marshal(e)
+					_, _ = fmt.Fprintln(cmdContext.stdout, string(data))
+					return
+				}
+				app := e.App
+				if e.AppNamespace != "" {
+					app = e.AppNamespace + "/" + app
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Timestamp.Format(time.RFC3339), app, e.Trigger, e.Recipient, e.Status, e.Error)
+				// flush every line so the streamed events are printed immediately
+				_ = w.Flush()
+			})
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load notification history: %v\n", err)
+				return nil
+			}
+			return w.Flush()
+		},
+	}
+	command.Flags().StringVar(&filter.App, "app", "", "Print notifications of the specified application")
+	command.Flags().StringVar(&filter.Trigger, "trigger", "", "Print notifications of the specified trigger")
+	command.Flags().StringVar(&filter.Recipient, "recipient", "", "Print notifications of the specified recipient in <type>:<name> format")
+	command.Flags().StringVar(&status, "status", "", "Print notifications with the specified status. One of: Sent|Failed|DryRun")
+	command.Flags().StringVar(&since, "since", "", "Print notifications recorded after the specified time or duration ago, e.g. 2h")
+	command.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing notifications as they are recorded")
+	command.Flags().DurationVar(&interval, "interval", 5*time.Second, "Notification history polling interval used with --follow")
+	command.Flags().StringVarP(&output, "output", "o", "wide", "Output format. One of:wide|json; json prints one event per line")
+	return &command
+}
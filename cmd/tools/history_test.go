@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+func TestHistory(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	now := time.Now().UTC().Truncate(time.Second)
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{},
+		newNotificationEvent("guestbook-1", map[string]interface{}{
+			"app": "guestbook", "appNamespace": "default", "trigger": "on-sync-failed", "recipient": "slack:test",
+			"timestamp": now.Format(time.RFC3339), "status": "Failed", "error": "timeout",
+		}),
+		newNotificationEvent("guestbook-2", map[string]interface{}{
+			"app": "guestbook", "appNamespace": "default", "trigger": "on-deployed", "recipient": "slack:test",
+			"timestamp": now.Add(-2 * time.Hour).Format(time.RFC3339), "status": "Sent",
+		}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newHistoryCommand(ctx)
+	assert.NoError(t, command.Flags().Set("since", "1h"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "default/guestbook  on-sync-failed  slack:test  Failed  timeout")
+	assert.NotContains(t, stdout.String(), "on-deployed")
+
+	stdout.Reset()
+	command = newHistoryCommand(ctx)
+	assert.NoError(t, command.Flags().Set("output", "json"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, `{"app":"guestbook","appNamespace":"default","trigger":"on-deployed","recipient":"slack:test","notifier":"","timestamp":"`+
+			now.Add(-2*time.Hour).Format(time.RFC3339)+`","status":"Sent"}`, lines[0])
+	}
+}
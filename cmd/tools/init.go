@@ -0,0 +1,348 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/slack-go/slack"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/builtin"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const (
+	verifyTimeout = 10 * time.Second
+)
+
+var (
+	// supportedInitServices are the notification services configured by the init command
+	supportedInitServices = []string{"slack", "email", "webhook", "teams"}
+	// slackAPIURL is the Slack API used to verify the token; overridden in tests
+	slackAPIURL = slack.APIURL
+)
+
+// initOptions holds the notification services settings of the starter configuration
+type initOptions struct {
+	services      []string
+	triggers      []string
+	argocdURL     string
+	slackToken    string
+	slackChannel  string
+	emailHost     string
+	emailPort     int
+	emailUsername string
+	emailPassword string
+	emailFrom     string
+	emailTo       string
+	webhookName   string
+	webhookURL    string
+	teamsChannel  string
+	teamsURL      string
+}
+
+// prompter asks the user for the settings which are not specified using flags
+type prompter struct {
+	cmdContext *commandContext
+	scanner    *bufio.Scanner
+}
+
+func (p *prompter) ask(value *string, question string) {
+	if *value != "" || p == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(p.cmdContext.stderr, "%s: ", question)
+	if p.scanner.Scan() {
+		*value = strings.TrimSpace(p.scanner.Text())
+	}
+}
+
+func (o *initOptions) prompt(p *prompter) {
+	if len(o.services) == 0 && p != nil {
+		var services string
+		p.ask(&services, fmt.Sprintf("Notification services (%s)", strings.Join(supportedInitServices, ",")))
+		for _, s := range strings.Split(services, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				o.services = append(o.services, s)
+			}
+		}
+	}
+	p.ask(&o.argocdURL, "Argo CD URL, e.g. https://argocd.example.com")
+	for _, service := range o.services {
+		switch service {
+		case "slack":
+			p.ask(&o.slackToken, "Slack bot token")
+			p.ask(&o.slackChannel, "Slack channel")
+		case "email":
+			p.ask(&o.emailHost, "SMTP host")
+			p.ask(&o.emailUsername, "SMTP username")
+			p.ask(&o.emailPassword, "SMTP password")
+			p.ask(&o.emailFrom, "Email sender address")
+			p.ask(&o.emailTo, "Email recipient address")
+		case "webhook":
+			p.ask(&o.webhookName, "Webhook name")
+			p.ask(&o.webhookURL, "Webhook URL")
+		case "teams":
+			p.ask(&o.teamsChannel, "Microsoft Teams channel")
+			p.ask(&o.teamsURL, "Microsoft Teams channel incoming webhook URL")
+		}
+	}
+}
+
+// validate returns an error if the settings of the selected services are missing
+func (o *initOptions) validate() error {
+	if len(o.services) == 0 {
+		return fmt.Errorf("at least one notification service is required, supported services: %s", strings.Join(supportedInitServices, ", "))
+	}
+	required := map[string][][2]string{
+		"slack":   {{"--slack-token", o.slackToken}, {"--slack-channel", o.slackChannel}},
+		"email":   {{"--email-host", o.emailHost}, {"--email-from", o.emailFrom}, {"--email-to", o.emailTo}},
+		"webhook": {{"--webhook-name", o.webhookName}, {"--webhook-url", o.webhookURL}},
+		"teams":   {{"--teams-channel", o.teamsChannel}, {"--teams-url", o.teamsURL}},
+	}
+	for _, service := range o.services {
+		flags, ok := required[service]
+		if !ok {
+			return fmt.Errorf("notification service %s is not supported, supported services: %s", service, strings.Join(supportedInitServices, ", "))
+		}
+		for _, flag := range flags {
+			if flag[1] == "" {
+				return fmt.Errorf("%s is required for %s notification service", flag[0], service)
+			}
+		}
+	}
+	return nil
+}
+
+// recipients returns the recipients of the starter subscription
+func (o *initOptions) recipients() []string {
+	var res []string
+	for _, service := range o.services {
+		switch service {
+		case "slack":
+			res = append(res, "slack:"+o.slackChannel)
+		case "email":
+			res = append(res, "email:"+o.emailTo)
+		case "webhook":
+			res = append(res, "webhook:"+o.webhookName)
+		case "teams":
+			res = append(res, "teams:"+o.teamsChannel)
+		}
+	}
+	return res
+}
+
+// notifiers returns the notifiers.yaml secret key content
+func (o *initOptions) notifiers() map[string]interface{} {
+	res := map[string]interface{}{}
+	for _, service := range o.services {
+		switch service {
+		case "slack":
+			res["slack"] = map[string]interface{}{"token": o.slackToken}
+		case "email":
+			email := map[string]interface{}{"host": o.emailHost, "port": o.emailPort, "from": o.emailFrom}
+			if o.emailUsername != "" {
+				email["username"] = o.emailUsername
+				email["password"] = o.emailPassword
+			}
+			res["email"] = email
+		case "webhook":
+			res["webhook"] = []map[string]interface{}{{"name": o.webhookName, "url": o.webhookURL}}
+		case "teams":
+			res["teams"] = map[string]interface{}{"recipientUrls": map[string]string{o.teamsChannel: o.teamsURL}}
+		}
+	}
+	return res
+}
+
+// verify checks that the notification services are reachable using the provided settings
+func (o *initOptions) verify() map[string]error {
+	res := map[string]error{}
+	for _, service := range o.services {
+		switch service {
+		case "slack":
+			_, err := slack.New(o.slackToken, slack.OptionAPIURL(slackAPIURL)).AuthTest()
+			res[service] = err
+		case "email":
+			res[service] = verifyAddress(net.JoinHostPort(o.emailHost, strconv.Itoa(o.emailPort)))
+		case "webhook":
+			res[service] = verifyURL(o.webhookURL)
+		case "teams":
+			res[service] = verifyURL(o.teamsURL)
+		}
+	}
+	return res
+}
+
+func verifyAddress(address string) error {
+	conn, err := net.DialTimeout("tcp", address, verifyTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func verifyURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return verifyAddress(net.JoinHostPort(u.Hostname(), port))
+}
+
+// starterConfigMap returns the config map with builtin triggers and templates and the subscription of the configured
+// recipients to the selected triggers
+func (o *initOptions) starterConfigMap() (*v1.ConfigMap, error) {
+	cm := &v1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName},
+		Data:       map[string]string{},
+	}
+	for k, v := range builtin.ConfigMapData {
+		cm.Data[k] = v
+	}
+	for _, name := range o.triggers {
+		data, ok := cm.Data["trigger."+name]
+		if !ok {
+			var names []string
+			for k := range builtin.ConfigMapData {
+				if strings.HasPrefix(k, "trigger.") {
+					names = append(names, strings.TrimPrefix(k, "trigger."))
+				}
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("trigger %s is not builtin (found %s)", name, strings.Join(names, ", "))
+		}
+		trigger := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(data), &trigger); err != nil {
+			return nil, err
+		}
+		trigger["enabled"] = true
+		enabled, err := yaml.Marshal(trigger)
+		if err != nil {
+			return nil, err
+		}
+		cm.Data["trigger."+name] = string(enabled)
+	}
+	config := map[string]interface{}{
+		"subscriptions": []map[string]interface{}{{"recipients": o.recipients(), "triggers": o.triggers}},
+	}
+	if o.argocdURL != "" {
+		config["context"] = map[string]string{"argocdUrl": o.argocdURL}
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	cm.Data["config.yaml"] = string(data)
+	return cm, nil
+}
+
+func (o *initOptions) starterSecret() (*v1.Secret, error) {
+	data, err := yaml.Marshal(o.notifiers())
+	if err != nil {
+		return nil, err
+	}
+	return &v1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: settings.SecretName},
+		StringData: map[string]string{"notifiers.yaml": string(data)},
+	}, nil
+}
+
+func newInitCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		opts        initOptions
+		interactive bool
+		verify      bool
+	)
+	var command = cobra.Command{
+		Use:   "init",
+		Short: "Generates starter argocd-notifications-cm ConfigMap and argocd-notifications-secret Secret",
+		Long: `Generates starter argocd-notifications-cm ConfigMap and argocd-notifications-secret Secret for the selected
+notification services. The ConfigMap includes the builtin triggers and templates and subscribes the configured
+recipients to the selected triggers. The command verifies that the notification services are reachable and prints the
+manifests ready to be applied using kubectl.`,
+		Example: `
+# Ask the notification services settings interactively
+argocd-notifications tools init --interactive > argocd-notifications.yaml
+
+# Generate Slack and email configuration using flags
+argocd-notifications tools init --service slack --slack-token xoxb-my-token --slack-channel deployments \
+  --service email --email-host smtp.gmail.com --email-from argocd@example.com --email-to team@example.com \
+  --argocd-url https://argocd.example.com | kubectl apply -n argocd -f -
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			var p *prompter
+			if interactive {
+				p = &prompter{cmdContext: cmdContext, scanner: bufio.NewScanner(cmdContext.stdin)}
+			}
+			opts.prompt(p)
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			if verify {
+				results := opts.verify()
+				failed := false
+				for _, service := range opts.services {
+					if err := results[service]; err != nil {
+						failed = true
+						_, _ = fmt.Fprintf(cmdContext.stderr, "%s: failed to verify connectivity: %v\n", service, err)
+					} else {
+						_, _ = fmt.Fprintf(cmdContext.stderr, "%s: connectivity verified\n", service)
+					}
+				}
+				if failed {
+					return fmt.Errorf("notification services verification has failed, use --verify=false to skip verification")
+				}
+			}
+			cm, err := opts.starterConfigMap()
+			if err != nil {
+				return err
+			}
+			secret, err := opts.starterSecret()
+			if err != nil {
+				return err
+			}
+			if err = printFormatted(cm, "yaml", cmdContext.stdout); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintln(cmdContext.stdout, "---")
+			return printFormatted(secret, "yaml", cmdContext.stdout)
+		},
+	}
+	command.Flags().BoolVar(&interactive, "interactive", false, "Ask the settings which are not specified using flags")
+	command.Flags().BoolVar(&verify, "verify", true, "Verify that the notification services are reachable")
+	command.Flags().StringArrayVar(&opts.services, "service", nil, fmt.Sprintf("Notification service. One of:%s", strings.Join(supportedInitServices, "|")))
+	command.Flags().StringArrayVar(&opts.triggers, "trigger", []string{"on-sync-failed", "on-health-degraded"}, "Builtin trigger enabled and subscribed by the recipients")
+	command.Flags().StringVar(&opts.argocdURL, "argocd-url", "", "Argo CD URL used in the notification templates")
+	command.Flags().StringVar(&opts.slackToken, "slack-token", "", "Slack bot token")
+	command.Flags().StringVar(&opts.slackChannel, "slack-channel", "", "Slack channel")
+	command.Flags().StringVar(&opts.emailHost, "email-host", "", "SMTP host")
+	command.Flags().IntVar(&opts.emailPort, "email-port", 587, "SMTP port")
+	command.Flags().StringVar(&opts.emailUsername, "email-username", "", "SMTP username")
+	command.Flags().StringVar(&opts.emailPassword, "email-password", "", "SMTP password")
+	command.Flags().StringVar(&opts.emailFrom, "email-from", "", "Email sender address")
+	command.Flags().StringVar(&opts.emailTo, "email-to", "", "Email recipient address")
+	command.Flags().StringVar(&opts.webhookName, "webhook-name", "", "Webhook name")
+	command.Flags().StringVar(&opts.webhookURL, "webhook-url", "", "Webhook URL")
+	command.Flags().StringVar(&opts.teamsChannel, "teams-channel", "", "Microsoft Teams channel")
+	command.Flags().StringVar(&opts.teamsURL, "teams-url", "", "Microsoft Teams channel incoming webhook URL")
+	return &command
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+func TestInit_Interactive(t *testing.T) {
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/auth.test", r.URL.Path)
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer slackServer.Close()
+	defer func(url string) {
+		slackAPIURL = url
+	}(slackAPIURL)
+	slackAPIURL = slackServer.URL + "/"
+	smtpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = smtpListener.Close()
+	}()
+	host, port, _ := net.SplitHostPort(smtpListener.Addr().String())
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx := &commandContext{
+		stdin:  strings.NewReader("slack,email\nhttps://argocd.example.com\nxoxb-token\ndeployments\n" + host + "\n\n\nargocd@example.com\nteam@example.com\n"),
+		stdout: &stdout,
+		stderr: &stderr,
+	}
+	command := newInitCommand(ctx)
+	assert.NoError(t, command.Flags().Set("interactive", "true"))
+	assert.NoError(t, command.Flags().Set("email-port", port))
+	err = command.RunE(command, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, stderr.String(), "slack: connectivity verified\n")
+	assert.Contains(t, stderr.String(), "email: connectivity verified\n")
+
+	manifests := strings.Split(stdout.String(), "\n---\n")
+	if !assert.Len(t, manifests, 2) {
+		return
+	}
+	var cm v1.ConfigMap
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[0]), &cm))
+	cfg, err := settings.ParseConfigMap(&cm)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "https://argocd.example.com", cfg.Context["argocdUrl"])
+	if assert.Len(t, cfg.Subscriptions, 1) {
+		assert.Equal(t, []string{"slack:deployments", "email:team@example.com"}, cfg.Subscriptions[0].Recipients)
+		assert.Equal(t, []string{"on-sync-failed", "on-health-degraded"}, cfg.Subscriptions[0].Triggers)
+	}
+	for _, trigger := range cfg.Triggers {
+		if trigger.Name == "on-sync-failed" {
+			assert.True(t, *trigger.Enabled)
+		}
+	}
+
+	var secret v1.Secret
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[1]), &secret))
+	notifiersCfg, err := settings.ParseSecret(&v1.Secret{Data: map[string][]byte{"notifiers.yaml": []byte(secret.StringData["notifiers.yaml"])}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "xoxb-token", notifiersCfg.Slack.Token)
+	assert.Equal(t, host, notifiersCfg.Email.Host)
+	assert.Equal(t, port, strconv.Itoa(notifiersCfg.Email.Port))
+}
+
+func TestInit_MissingSettings(t *testing.T) {
+	ctx := &commandContext{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+	command := newInitCommand(ctx)
+	assert.NoError(t, command.Flags().Set("service", "slack"))
+	assert.NoError(t, command.Flags().Set("slack-token", "xoxb-token"))
+
+	err := command.RunE(command, nil)
+
+	assert.EqualError(t, err, "--slack-channel is required for slack notification service")
+}
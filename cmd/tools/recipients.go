@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/clients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// triggerRecipients holds the recipients of the trigger notifications
+type triggerRecipients struct {
+	Trigger    string                       `json:"trigger"`
+	Recipients []settings.ResolvedRecipient `json:"recipients"`
+}
+
+// loadProject returns the project loaded from the file or the live project of the application; the live project is
+// optional since the application might be loaded from the file
+func (c *commandContext) loadProject(app *unstructured.Unstructured, project string) (*unstructured.Unstructured, error) {
+	if project != "" {
+		var proj unstructured.Unstructured
+		if err := readYAMLFile(project, &proj.Object); err != nil {
+			return nil, err
+		}
+		return &proj, nil
+	}
+	name, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+	if name == "" {
+		return nil, nil
+	}
+	_, client, ns, err := c.getK8SClients()
+	if err != nil {
+		return nil, err
+	}
+	return clients.NewAppProjClient(client, ns).Get(name, metav1.GetOptions{})
+}
+
+func newRecipientsCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		output   string
+		trigger  string
+		project  string
+		excluded bool
+	)
+	var command = cobra.Command{
+		Use:   "recipients APPLICATION",
+		Short: "Prints recipients of the application notifications per trigger",
+		Long: `Prints recipients of the application notifications per trigger. The recipients are resolved the same way
+as the controller does: the default subscriptions, application and project annotations are combined and the
+unsubscribed recipients and recipients forbidden by the recipient policies are excluded. The application is loaded from
+the YAML file if the argument has the file extension, otherwise the live application and project are fetched from the
+cluster.`,
+		Example: `
+# Print recipients of the live application notifications
+argocd-notifications tools recipients guestbook
+
+# Print recipients of the on-sync-failed trigger including the excluded recipients and the exclusion reasons
+argocd-notifications tools recipients guestbook --trigger on-sync-failed --excluded
+
+# Print recipients of the application and project defined in the files
+argocd-notifications tools recipients ./sample-app.yaml --project ./sample-project.yaml
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one argument, got %d", len(args))
+			}
+			_, _, cfg, err := cmdContext.getConfig()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
+				return nil
+			}
+			app, err := cmdContext.loadApplication(args[0])
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load application: %v\n", err)
+				return nil
+			}
+			proj, err := cmdContext.loadProject(app, project)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load project, project annotations are ignored: %v\n", err)
+			}
+			var items []triggerRecipients
+			for _, t := range cfg.Triggers {
+				if trigger != "" && t.Name != trigger {
+					continue
+				}
+				item := triggerRecipients{Trigger: t.Name, Recipients: []settings.ResolvedRecipient{}}
+				for _, r := range settings.ExplainRecipients(app, proj, t.Name, cfg.Subscriptions, cfg.Policies) {
+					if excluded || r.Excluded == "" && r.Error == "" {
+						item.Recipients = append(item.Recipients, r)
+					}
+				}
+				items = append(items, item)
+			}
+			if trigger != "" && len(items) == 0 {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "trigger with name '%s' does not exist\n", trigger)
+				return nil
+			}
+			switch output {
+			case "", "wide":
+				w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+				_, _ = fmt.Fprintf(w, "TRIGGER\tRECIPIENT\tSOURCES\tSTATUS\n")
+				for _, item := range items {
+					for _, r := range item.Recipients {
+						status := "notified"
+						if r.Error != "" {
+							status = "error: " + r.Error
+						} else if r.Excluded != "" {
+							status = "excluded: " + r.Excluded
+						}
+						_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Trigger, r.Recipient, strings.Join(r.Sources, ","), status)
+					}
+				}
+				_ = w.Flush()
+			case "name":
+				for _, item := range items {
+					for _, r := range item.Recipients {
+						_, _ = fmt.Fprintf(cmdContext.stdout, "%s %s\n", item.Trigger, r.Recipient)
+					}
+				}
+			default:
+				return printFormatted(items, output, cmdContext.stdout)
+			}
+			return nil
+		},
+	}
+	addOutputFlags(&command, &output)
+	command.Flags().StringVar(&trigger, "trigger", "", "Print recipients of the specified trigger only")
+	command.Flags().StringVar(&project, "project", "", "Project file path; the live project of the application is used by default")
+	command.Flags().BoolVar(&excluded, "excluded", false, "Print excluded recipients and the exclusion reasons")
+	return &command
+}
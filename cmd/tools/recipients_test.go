@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	testingutil "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func TestRecipients(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{
+		Triggers: []triggers.NotificationTrigger{{
+			Name: "on-sync-failed", Condition: "true", Template: "my-template",
+		}, {
+			Name: "on-deployed", Condition: "true", Template: "my-template",
+		}},
+		Templates: []triggers.NotificationTemplate{{Name: "my-template"}},
+	}, testingutil.NewApp("guestbook", testingutil.WithProject("default"), testingutil.WithAnnotations(map[string]string{
+		"argocd-notifications.argoproj.io/subscribe.on-sync-failed.slack":   "alerts",
+		"argocd-notifications.argoproj.io/unsubscribe.on-sync-failed.slack": "general",
+	})), testingutil.NewProject("default", testingutil.WithAnnotations(map[string]string{
+		"argocd-notifications.argoproj.io/subscribe.slack": "general",
+	})))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newRecipientsCommand(ctx)
+	assert.NoError(t, command.Flags().Set("excluded", "true"))
+	err = command.RunE(command, []string{"guestbook"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, `TRIGGER         RECIPIENT      SOURCES      STATUS
+on-sync-failed  slack:alerts   application  notified
+on-sync-failed  slack:general  project      excluded: unsubscribed
+on-deployed     slack:general  project      notified
+`, stdout.String())
+}
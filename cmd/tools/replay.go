@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/clients"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+// parseReplayTime parses time in RFC3339 format or duration relative to the current time, e.g. 2h
+func parseReplayTime(val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(val); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' should be either duration or time in RFC3339 format", val)
+	}
+	return t, nil
+}
+
+func newReplayCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		filter history.Filter
+		status string
+		since  string
+		until  string
+		dryRun bool
+	)
+	var command = cobra.Command{
+		Use:   "replay",
+		Short: "Sends once again notifications which were not delivered according to the notification history",
+		Example: `
+# Print notifications failed during the last two hours without sending them
+argocd-notifications tools replay --since 2h --dry-run
+
+# Replay failed notifications of the guestbook application to Slack
+argocd-notifications tools replay --app guestbook --recipient slack:my-channel --since 2021-01-01T10:00:00Z --until 2021-01-01T12:00:00Z
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			var err error
+			if filter.Since, err = parseReplayTime(since); err != nil {
+				return err
+			}
+			if filter.Until, err = parseReplayTime(until); err != nil {
+				return err
+			}
+			filter.Status = history.Status(status)
+			_, client, ns, err := cmdContext.getK8SClients()
+			if err != nil {
+				return err
+			}
+			events, err := history.ListReplayable(history.NewCRDStore(client, ns), filter)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load notification history: %v\n", err)
+				return nil
+			}
+			triggersByName, notifiersByName, config, err := cmdContext.getConfig()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintf(w, "TIMESTAMP\tAPP\tTRIGGER\tRECIPIENT\tRESULT\n")
+			for _, e := range events {
+				result := "replayed"
+				if dryRun {
+					result = "skipped (dry run)"
+				} else if err := replayEvent(cmdContext, e, triggersByName, notifiersByName, config.Context); err != nil {
+					result = err.Error()
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s/%s\t%s\t%s\t%s\n", e.Timestamp.Format(time.RFC3339), e.AppNamespace, e.App, e.Trigger, e.Recipient, result)
+			}
+			return w.Flush()
+		},
+	}
+	command.Flags().StringVar(&filter.App, "app", "", "Replay notifications of the specified application")
+	command.Flags().StringVar(&filter.Trigger, "trigger", "", "Replay notifications of the specified trigger")
+	command.Flags().StringVar(&filter.Recipient, "recipient", "", "Replay notifications of the specified recipient in <type>:<name> format")
+	command.Flags().StringVar(&status, "status", string(history.StatusFailed), "Status of the last delivery attempt. One of: Failed|DryRun")
+	command.Flags().StringVar(&since, "since", "", "Replay notifications recorded after the specified time or duration ago, e.g. 2h")
+	command.Flags().StringVar(&until, "until", "", "Replay notifications recorded before the specified time or duration ago")
+	command.Flags().BoolVar(&dryRun, "dry-run", false, "Print notifications without sending them")
+	return &command
+}
+
+func replayEvent(cmdContext *commandContext, e history.Event, triggersByName map[string]triggers.Trigger, notifiersByName map[string]notifiers.Notifier, cfgContext map[string]string) error {
+	trigger, ok := triggersByName[e.Trigger]
+	if !ok {
+		return fmt.Errorf("trigger %s is not configured", e.Trigger)
+	}
+	parsed, err := sharedrecipients.Parse(e.Recipient)
+	if err != nil {
+		return err
+	}
+	service, target := parsed.Service, parsed.Target
+	notifier, ok := notifiersByName[service]
+	if !ok {
+		return fmt.Errorf("%s is not valid recipient type", service)
+	}
+	_, client, _, err := cmdContext.getK8SClients()
+	if err != nil {
+		return err
+	}
+	app, err := clients.NewAppClient(client, e.AppNamespace).Get(e.App, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	ctx := sharedrecipients.CopyStringMap(cfgContext)
+	ctx["notificationType"] = service
+	notification, err := trigger.FormatNotification(app, ctx)
+	if err != nil {
+		return err
+	}
+	return notifier.Send(notifiers.WithRecipientOptions(context.TODO(), parsed.Options()), *notification, target)
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	testingutil "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newNotificationEvent(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion(history.NotificationEventResource.GroupVersion().String())
+	obj.SetKind("NotificationEvent")
+	obj.SetName(name)
+	obj.SetNamespace("default")
+	return obj
+}
+
+func TestReplay(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	now := time.Now().UTC().Format(time.RFC3339)
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{}, testingutil.NewApp("guestbook"),
+		newNotificationEvent("guestbook-1", map[string]interface{}{
+			"app": "guestbook", "appNamespace": "default", "trigger": "on-sync", "recipient": "slack:test",
+			"timestamp": now, "status": "Failed",
+		}),
+		newNotificationEvent("guestbook-2", map[string]interface{}{
+			"app": "guestbook", "appNamespace": "default", "trigger": "on-deployed", "recipient": "slack:test",
+			"timestamp": now, "status": "Sent",
+		}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newReplayCommand(ctx)
+	assert.NoError(t, command.Flags().Set("since", "1h"))
+	assert.NoError(t, command.Flags().Set("dry-run", "true"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "default/guestbook  on-sync  slack:test  skipped (dry run)")
+	assert.NotContains(t, stdout.String(), "on-deployed")
+
+	stdout.Reset()
+	assert.NoError(t, command.Flags().Set("dry-run", "false"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "trigger on-sync is not configured")
+}
+
+func TestParseReplayTime(t *testing.T) {
+	parsed, err := parseReplayTime("2021-01-01T10:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC), parsed)
+
+	parsed, err = parseReplayTime("1h")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), parsed, time.Minute)
+
+	_, err = parseReplayTime("yesterday")
+	assert.Error(t, err)
+}
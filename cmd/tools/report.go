@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const (
+	reportToolName = "argocd-notifications"
+	reportToolURI  = "https://github.com/argoproj-labs/argocd-notifications"
+	sarifSchema    = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion   = "2.1.0"
+)
+
+// ruleDescriptions are the short descriptions of the lint rules shown in the SARIF report
+var ruleDescriptions = map[string]string{
+	settings.RuleInvalidConfig:     "Configuration cannot be parsed",
+	settings.RuleInvalidTemplate:   "Template cannot be parsed",
+	settings.RuleInvalidExpression: "Trigger condition or oncePer expression cannot be parsed",
+	settings.RuleInvalidRecipient:  "Subscription recipient has wrong format",
+	settings.RuleDuplicate:         "Trigger or template is defined more than once",
+	settings.RuleUnknownReference:  "Configuration references undefined trigger, template or notification service",
+	settings.RuleNeverFires:        "Trigger condition is always false",
+	settings.RuleUnused:            "Template is not used by any trigger",
+	settings.RuleIgnored:           "Definition of the additional config map is ignored",
+	settings.RuleEvaluationError:   "Trigger fails for the sample application",
+	settings.RuleRenderingError:    "Template cannot be rendered for the sample application",
+}
+
+func issueRule(issue settings.LintIssue) string {
+	if issue.Rule == "" {
+		return settings.RuleInvalidConfig
+	}
+	return issue.Rule
+}
+
+type junitTestSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes the issues as JUnit test cases. Errors are reported as failures and warnings as passed test
+// cases with the message in the output, so warnings do not fail the CI pipeline. The single passed test case is
+// reported if there are no issues.
+func writeJUnitReport(out io.Writer, issues []settings.LintIssue) error {
+	suite := junitTestSuite{Name: reportToolName}
+	for _, issue := range issues {
+		testCase := junitTestCase{Name: issue.Message, ClassName: issueRule(issue)}
+		if issue.Severity == settings.SeverityError {
+			testCase.Failure = &junitFailure{Message: issue.Message, Type: string(issue.Severity), Text: issue.Message}
+			suite.Failures++
+		} else {
+			testCase.SystemOut = string(issue.Severity) + ": " + issue.Message
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	if len(suite.Cases) == 0 {
+		suite.Cases = append(suite.Cases, junitTestCase{Name: "configuration", ClassName: reportToolName})
+	}
+	suite.Tests = len(suite.Cases)
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// writeSARIFReport writes the issues in the SARIF format. The issues are attributed to the first line of the config map
+// file since the configuration is parsed from the config map values rather than the YAML document.
+func writeSARIFReport(out io.Writer, issues []settings.LintIssue, location string) error {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: reportToolName, InformationURI: reportToolURI, Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+	for id, description := range ruleDescriptions {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: description}})
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+	for _, issue := range issues {
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  issueRule(issue),
+			Level:   string(issue.Severity),
+			Message: sarifMessage{Text: issue.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(location)},
+				Region:           sarifRegion{StartLine: 1},
+			}}},
+		})
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+var reportIssues = []settings.LintIssue{
+	{Severity: settings.SeverityError, Rule: settings.RuleUnknownReference, Message: "trigger my-trigger references unknown template missing"},
+	{Severity: settings.SeverityWarning, Rule: settings.RuleUnused, Message: "template my-template is not used by any trigger"},
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, writeJUnitReport(&out, reportIssues))
+
+	report := out.String()
+	assert.Contains(t, report, `<testsuite name="argocd-notifications" tests="2" failures="1">`)
+	assert.Contains(t, report, `<testcase name="trigger my-trigger references unknown template missing" classname="unknown-reference">`)
+	assert.Contains(t, report, `<failure message="trigger my-trigger references unknown template missing" type="error">`)
+	assert.Contains(t, report, `<system-out>warning: template my-template is not used by any trigger</system-out>`)
+
+	out.Reset()
+	assert.NoError(t, writeJUnitReport(&out, nil))
+	assert.Contains(t, out.String(), `<testsuite name="argocd-notifications" tests="1" failures="0">`)
+}
+
+func TestWriteSARIFReport(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, writeSARIFReport(&out, reportIssues, "config/argocd-notifications-cm.yaml"))
+
+	var report sarifLog
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, "2.1.0", report.Version)
+	if assert.Len(t, report.Runs, 1) {
+		run := report.Runs[0]
+		assert.Len(t, run.Tool.Driver.Rules, len(ruleDescriptions))
+		if assert.Len(t, run.Results, 2) {
+			assert.Equal(t, "unknown-reference", run.Results[0].RuleID)
+			assert.Equal(t, "error", run.Results[0].Level)
+			assert.Equal(t, "warning", run.Results[1].Level)
+			assert.Equal(t, "config/argocd-notifications-cm.yaml", run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+const adHocTemplate = "__send__"
+
+func newSendCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		service    string
+		recipients []string
+		template   string
+		app        string
+		title      string
+		body       string
+		dryRun     bool
+	)
+	var command = cobra.Command{
+		Use:   "send",
+		Short: "Sends ad-hoc notification to the specified recipients",
+		Long: `Sends ad-hoc notification to the specified recipients of the notification service. The notification is
+rendered using the configured template or the title and body flags; the title and body might reference the application
+fields the same way as templates do. The command exits with non-zero code if the notification cannot be delivered, so
+it might be used to smoke test the notification services after the credentials rotation.`,
+		Example: `
+# Send notification about the live application using the configured template
+argocd-notifications tools send --service slack --recipient my-channel --template app-deployed --app guestbook
+
+# Send plain message to verify email settings
+argocd-notifications tools send --service email --recipient admin@example.com --title "Test" --body "Hello"
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if service == "" || len(recipients) == 0 {
+				return errors.New("--service and at least one --recipient are required")
+			}
+			if template == "" && title == "" && body == "" {
+				return errors.New("either --template or --title and --body are required")
+			}
+			_, notifiersByName, config, err := cmdContext.getConfig()
+			if err != nil {
+				return fmt.Errorf("failed to parse config: %v", err)
+			}
+			notifiersByName["console"] = &consoleNotifier{stdout: cmdContext.stdout}
+			notifier, ok := notifiersByName[service]
+			if !ok {
+				return fmt.Errorf("notification service %s is not configured", service)
+			}
+			templates := config.Templates
+			if template == "" {
+				template = adHocTemplate
+				templates = []triggers.NotificationTemplate{{
+					Name:         adHocTemplate,
+					Notification: notifiers.Notification{Title: title, Body: body},
+				}}
+			}
+			triggersByName, err := triggers.GetTriggers(templates, []triggers.NotificationTrigger{{
+				Name:      adHocTemplate,
+				Template:  template,
+				Condition: "true",
+			}}, cmdContext.argocdService)
+			if err != nil {
+				return fmt.Errorf("failed to parse template: %v", err)
+			}
+			application := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if app != "" {
+				if application, err = cmdContext.loadApplication(app); err != nil {
+					return fmt.Errorf("failed to load application: %v", err)
+				}
+			}
+			ctx := sharedrecipients.CopyStringMap(config.Context)
+			ctx["notificationType"] = service
+			notification, err := triggersByName[adHocTemplate].FormatNotification(application, ctx)
+			if err != nil {
+				return fmt.Errorf("failed to format notification: %v", err)
+			}
+			for _, recipient := range recipients {
+				if dryRun {
+					if err = printPreview(cmdContext.stdout, notifier, *notification, service+":"+recipient, recipient); err != nil {
+						return fmt.Errorf("failed to render '%s:%s' notification: %v", service, recipient, err)
+					}
+					continue
+				}
+				result, err := notifiers.SendWithResult(context.TODO(), notifier, *notification, recipient)
+				if err != nil {
+					return fmt.Errorf("failed to notify '%s:%s': %v", service, recipient, err)
+				}
+				if result.MessageID != "" {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "notification sent to %s:%s, message id %s\n", service, recipient, result.MessageID)
+				} else {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "notification sent to %s:%s\n", service, recipient)
+				}
+			}
+			return nil
+		},
+	}
+	command.Flags().StringVar(&service, "service", "", "Notification service, e.g. slack")
+	command.Flags().StringArrayVar(&recipients, "recipient", nil, "Recipient of the notification service, e.g. Slack channel")
+	command.Flags().StringVar(&template, "template", "", "Name of the configured template")
+	command.Flags().StringVar(&app, "app", "", "Application name or file path used to render the notification")
+	command.Flags().StringVar(&title, "title", "", "Notification title; used if the template is not specified")
+	command.Flags().StringVar(&body, "body", "", "Notification body; used if the template is not specified")
+	command.Flags().BoolVar(&dryRun, "dry-run", false, "Print notification in the notification service format instead of sending it")
+	return &command
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	testingutil "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func TestSend_Template(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{
+		Templates: []triggers.NotificationTemplate{{
+			Name:         "app-deployed",
+			Notification: notifiers.Notification{Title: "{{.app.metadata.name}} is deployed"},
+		}},
+	}, testingutil.NewApp("guestbook"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newSendCommand(ctx)
+	assert.NoError(t, command.Flags().Set("service", "console"))
+	assert.NoError(t, command.Flags().Set("recipient", "stdout"))
+	assert.NoError(t, command.Flags().Set("template", "app-deployed"))
+	assert.NoError(t, command.Flags().Set("app", "guestbook"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "title: guestbook is deployed")
+	assert.Equal(t, "notification sent to console:stdout\n", stderr.String())
+}
+
+func TestSend_Message(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newSendCommand(ctx)
+	assert.NoError(t, command.Flags().Set("service", "console"))
+	assert.NoError(t, command.Flags().Set("recipient", "stdout"))
+	assert.NoError(t, command.Flags().Set("body", "hello"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "body: hello")
+
+	command = newSendCommand(ctx)
+	assert.NoError(t, command.Flags().Set("service", "slack"))
+	assert.NoError(t, command.Flags().Set("recipient", "my-channel"))
+	assert.NoError(t, command.Flags().Set("body", "hello"))
+	err = command.RunE(command, nil)
+	assert.EqualError(t, err, "notification service slack is not configured")
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -18,10 +19,26 @@ type consoleNotifier struct {
 	stdout io.Writer
 }
 
-func (c *consoleNotifier) Send(notification notifiers.Notification, _ string) error {
+func (c *consoleNotifier) Send(_ context.Context, notification notifiers.Notification, _ string) error {
 	return printFormatted(notification, "yaml", c.stdout)
 }
 
+// printPreview prints the notification rendered by the notifier or the notification fields if the notifier does not
+// support previews
+func printPreview(out io.Writer, notifier notifiers.Notifier, notification notifiers.Notification, recipient string, destination string) error {
+	_, _ = fmt.Fprintf(out, "# %s\n", recipient)
+	previewer, ok := notifier.(notifiers.Previewer)
+	if !ok {
+		return printFormatted(notification, "yaml", out)
+	}
+	preview, err := previewer.Preview(notification, destination)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(out, strings.TrimRight(preview, "\n"))
+	return nil
+}
+
 func newTemplateCommand(cmdContext *commandContext) *cobra.Command {
 	var command = cobra.Command{
 		Use:   "template",
@@ -38,6 +55,7 @@ func newTemplateCommand(cmdContext *commandContext) *cobra.Command {
 func newTemplateNotifyCommand(cmdContext *commandContext) *cobra.Command {
 	var (
 		recipients []string
+		dryRun     bool
 	)
 	var command = cobra.Command{
 		Use: "notify NAME APPLICATION",
@@ -47,6 +65,10 @@ argocd-notifications tools template notify app-sync-succeeded guestbook --recipi
 
 # Render notification render generated notification in console
 argocd-notifications tools template notify app-sync-succeeded guestbook
+
+# Print Slack message and email MIME message instead of sending them
+argocd-notifications tools template notify app-sync-succeeded guestbook --recipient slack:my-channel \
+    --recipient email:admin@example.com --dry-run
 `,
 		Short: "Generates notification using the specified template and send it to specified recipients",
 		RunE: func(c *cobra.Command, args []string) error {
@@ -82,12 +104,12 @@ argocd-notifications tools template notify app-sync-succeeded guestbook
 			}
 
 			for _, recipient := range recipients {
-				parts := strings.Split(recipient, ":")
-				if len(parts) < 2 {
-					_, _ = fmt.Fprintf(cmdContext.stderr, "%s is not valid recipient. Expected recipient format is <type>:<name>\n", recipient)
+				parsed, err := sharedrecipients.Parse(recipient)
+				if err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "%v\n", err)
 					return nil
 				}
-				notifierType := parts[0]
+				notifierType := parsed.Service
 				notifier, ok := notifiersByName[notifierType]
 				if !ok {
 					_, _ = fmt.Fprintf(cmdContext.stderr, "%s is not valid recipient type.\n", notifierType)
@@ -101,7 +123,14 @@ argocd-notifications tools template notify app-sync-succeeded guestbook
 					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to format notification: %v\n", err)
 					return nil
 				}
-				if err = notifier.Send(*notification, parts[1]); err != nil {
+				if dryRun {
+					if err = printPreview(cmdContext.stdout, notifier, *notification, recipient, parsed.Target); err != nil {
+						_, _ = fmt.Fprintf(cmdContext.stderr, "failed to render '%s' notification: %v\n", recipient, err)
+						return nil
+					}
+					continue
+				}
+				if err = notifier.Send(notifiers.WithRecipientOptions(context.TODO(), parsed.Options()), *notification, parsed.Target); err != nil {
 					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to notify '%s': %v\n", recipient, err)
 					return nil
 				}
@@ -111,6 +140,7 @@ argocd-notifications tools template notify app-sync-succeeded guestbook
 		},
 	}
 	command.Flags().StringArrayVar(&recipients, "recipient", []string{"console:stdout"}, "List of recipients")
+	command.Flags().BoolVar(&dryRun, "dry-run", false, "Print notification in the notification service format instead of sending it")
 
 	return &command
 }
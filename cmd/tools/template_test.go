@@ -2,9 +2,13 @@ package tools
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/ghodss/yaml"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 
 	"github.com/argoproj-labs/argocd-notifications/notifiers"
 	"github.com/argoproj-labs/argocd-notifications/shared/settings"
@@ -40,6 +44,54 @@ func TestTemplateNotifyConsole(t *testing.T) {
 	assert.Contains(t, stdout.String(), "hello guestbook")
 }
 
+func TestTemplateNotifyDryRun(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{
+		Templates: []triggers.NotificationTemplate{{
+			Name: "my-template",
+			Notification: notifiers.Notification{
+				Title: "hello {{.app.metadata.name}}",
+				Body:  "{{.app.metadata.name}} is synced",
+			},
+		}},
+	}, testingutil.NewApp("guestbook"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+	secretData, err := yaml.Marshal(v1.Secret{Data: map[string][]byte{
+		"notifiers.yaml": []byte("email:\n  host: smtp.example.com\n  from: argocd@example.com\n"),
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	secretFile, err := ioutil.TempFile("", "*-secret.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(secretFile.Name())
+	}()
+	_, err = secretFile.Write(secretData)
+	assert.NoError(t, secretFile.Close())
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx.secretPath = secretFile.Name()
+
+	command := newTemplateNotifyCommand(ctx)
+	assert.NoError(t, command.Flags().Set("recipient", "email:admin@example.com"))
+	assert.NoError(t, command.Flags().Set("dry-run", "true"))
+	err = command.RunE(command, []string{"my-template", "guestbook"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "# email:admin@example.com\n")
+	assert.Contains(t, stdout.String(), "From: argocd@example.com\r\n")
+	assert.Contains(t, stdout.String(), "Subject: hello guestbook\r\n")
+	assert.Contains(t, stdout.String(), "guestbook is synced")
+}
+
 func TestTemplateGet(t *testing.T) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -13,6 +13,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/argoproj-labs/argocd-notifications/shared/cmd"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
 )
 
 func withDebugLogs() func() {
@@ -51,7 +52,9 @@ func printFormatted(input interface{}, output string, out io.Writer) error {
 func NewToolsCommand() *cobra.Command {
 	var (
 		argocdRepoServer string
+		variables        bool
 		cmdContext       = commandContext{
+			stdin:         os.Stdin,
 			stdout:        os.Stdout,
 			stderr:        os.Stderr,
 			argocdService: &lazyArgocdServiceInitializer{argocdRepoServer: &argocdRepoServer},
@@ -63,10 +66,20 @@ func NewToolsCommand() *cobra.Command {
 		Run: func(c *cobra.Command, args []string) {
 			c.HelpFunc()(c, args)
 		},
+		PersistentPreRun: func(c *cobra.Command, args []string) {
+			settings.SetVariablesEnabled(variables)
+		},
 	}
 
 	command.AddCommand(newTriggerCommand(&cmdContext))
 	command.AddCommand(newTemplateCommand(&cmdContext))
+	command.AddCommand(newConfigCommand(&cmdContext))
+	command.AddCommand(newReplayCommand(&cmdContext))
+	command.AddCommand(newInitCommand(&cmdContext))
+	command.AddCommand(newRecipientsCommand(&cmdContext))
+	command.AddCommand(newSendCommand(&cmdContext))
+	command.AddCommand(newHistoryCommand(&cmdContext))
+	command.AddCommand(newFloodCommand(&cmdContext))
 
 	command.PersistentFlags().StringVar(&cmdContext.configMapPath,
 		"config-map", "", "argocd-notifications-cm.yaml file path")
@@ -74,6 +87,8 @@ func NewToolsCommand() *cobra.Command {
 		"secret", "", "argocd-notifications-secret.yaml file path. Use empty secret if provided value is ':empty'")
 	command.PersistentFlags().StringVar(&argocdRepoServer,
 		"argocd-repo-server", "argocd-repo-server:8081", "Argo CD repo server address")
+	command.PersistentFlags().BoolVar(&variables,
+		"config-variables", false, "Replace the ${NAME} references in the configuration string values with the "+settings.VariableEnvPrefix+"<NAME> environment variables and the ${context.<key>} references with the config.yaml context values")
 	clientConfig := cmd.AddK8SFlagsToCmd(&command)
 	cmdContext.getK8SClients = func() (kubernetes.Interface, dynamic.Interface, string, error) {
 		return getK8SClients(clientConfig)
@@ -7,6 +7,7 @@ import (
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/argoproj-labs/argocd-notifications/triggers"
 )
@@ -26,23 +27,34 @@ func newTriggerCommand(cmdContext *commandContext) *cobra.Command {
 }
 
 func newTriggerRunCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		selector string
+	)
 	var command = cobra.Command{
-		Use:   "run NAME APPLICATION",
+		Use:   "run NAME [APPLICATION...]",
 		Short: "Evaluates specified trigger condition and prints the result",
+		Long: `Evaluates specified trigger condition and prints the result. The application is loaded from the YAML file
+if the argument has the file extension, otherwise the live application is fetched from the cluster. The value of each
+condition clause joined by the logical operators is printed to explain the result.`,
 		Example: `
 # Execute trigger configured in 'argocd-notification-cm' ConfigMap
 argocd-notifications tools trigger run on-sync-status-unknown ./sample-app.yaml
 
 # Execute trigger using argocd-notifications-cm.yaml instead of 'argocd-notification-cm' ConfigMap
 argocd-notifications tools trigger run on-sync-status-unknown ./sample-app.yaml \
-    --config-map ./argocd-notifications-cm.yaml`,
+    --config-map ./argocd-notifications-cm.yaml
+
+# Execute trigger against the live 'guestbook' application
+argocd-notifications tools trigger run on-sync-failed guestbook
+
+# Execute trigger against the live applications matching the label selector
+argocd-notifications tools trigger run on-sync-failed --selector team=my-team`,
 		RunE: func(c *cobra.Command, args []string) error {
-			if len(args) < 2 {
-				return fmt.Errorf("expected two arguments, got %d", len(args))
+			if len(args) < 1 || len(args) < 2 && selector == "" {
+				return fmt.Errorf("expected trigger name and at least one application or selector, got %d arguments", len(args))
 			}
 			name := args[0]
-			application := args[1]
-			triggersByName, _, _, err := cmdContext.getConfig()
+			triggersByName, _, cfg, err := cmdContext.getConfig()
 			if err != nil {
 				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
 				return nil
@@ -57,24 +69,66 @@ argocd-notifications tools trigger run on-sync-status-unknown ./sample-app.yaml
 					"trigger with name '%s' does not exist (found %s)\n", name, strings.Join(names, ", "))
 				return nil
 			}
-			app, err := cmdContext.loadApplication(application)
-			if err != nil {
-				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load application: %v\n", err)
-				return nil
+			condition := ""
+			for _, t := range cfg.Triggers {
+				if t.Name == name {
+					condition = t.Condition
+				}
 			}
-			ok, err = trigger.Triggered(app)
-			if err != nil {
-				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to execute trigger %s: %v\n", name, err)
-				return nil
+			var apps []*unstructured.Unstructured
+			for _, application := range args[1:] {
+				app, err := cmdContext.loadApplication(application)
+				if err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load application: %v\n", err)
+					return nil
+				}
+				apps = append(apps, app)
+			}
+			if selector != "" {
+				selected, err := cmdContext.listApplications(selector)
+				if err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to list applications: %v\n", err)
+					return nil
+				}
+				apps = append(apps, selected...)
+			}
+			for _, app := range apps {
+				ok, err := trigger.Triggered(app)
+				if err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to execute trigger %s for application %s: %v\n", name, app.GetName(), err)
+					continue
+				}
+				if len(apps) == 1 {
+					_, _ = fmt.Fprintf(cmdContext.stdout, "%v\n", ok)
+				} else {
+					_, _ = fmt.Fprintf(cmdContext.stdout, "%s: %v\n", app.GetName(), ok)
+				}
+				printConditionClauses(cmdContext, condition, app)
 			}
-			_, _ = fmt.Fprintf(cmdContext.stdout, "%v\n", ok)
 			return nil
 		},
 	}
-
+	command.Flags().StringVarP(&selector, "selector", "l", "", "Label selector of the live applications")
 	return &command
 }
 
+// printConditionClauses prints the value of each condition clause if the condition has more than one clause
+func printConditionClauses(cmdContext *commandContext, condition string, app *unstructured.Unstructured) {
+	results := triggers.ExplainCondition(condition, app, cmdContext.argocdService)
+	if len(results) < 2 {
+		return
+	}
+	w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+	for _, res := range results {
+		value := fmt.Sprintf("%v", res.Value)
+		if res.Error != nil {
+			value = fmt.Sprintf("error: %v", res.Error)
+		}
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", res.Clause, value)
+	}
+	_ = w.Flush()
+}
+
 func newTriggerGetCommand(cmdContext *commandContext) *cobra.Command {
 	var (
 		output string
@@ -91,6 +91,34 @@ func TestTriggerRun(t *testing.T) {
 	assert.Contains(t, stdout.String(), "true")
 }
 
+func TestTriggerRun_ExplainsLiveApplications(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, settings.Config{
+		Triggers: []triggers.NotificationTrigger{{
+			Name:      "my-trigger",
+			Condition: "app.metadata.name == 'guestbook' && app.metadata.namespace == 'other'",
+			Template:  "my-template",
+		}},
+		Templates: []triggers.NotificationTemplate{{
+			Name: "my-template",
+		}},
+	}, testingutil.NewApp("guestbook", testingutil.WithLabels(map[string]string{"team": "a"})),
+		testingutil.NewApp("other", testingutil.WithLabels(map[string]string{"team": "a"})))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newTriggerRunCommand(ctx)
+	assert.NoError(t, command.Flags().Set("selector", "team=a"))
+	err = command.RunE(command, []string{"my-trigger"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "guestbook: false\n  app.metadata.name == 'guestbook'   true\n  app.metadata.namespace == 'other'  false\n")
+	assert.Contains(t, stdout.String(), "other: false\n  app.metadata.name == 'guestbook'   false\n")
+}
+
 func TestTriggerGet(t *testing.T) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -120,3 +148,31 @@ func TestTriggerGet(t *testing.T) {
 	assert.Contains(t, stdout.String(), "my-trigger1")
 	assert.Contains(t, stdout.String(), "my-trigger2")
 }
+
+func TestTriggerGet_ConfigMapFromCluster(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	clientset := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName, Namespace: testingutil.TestNamespace},
+		Data: map[string]string{
+			"trigger.my-trigger":   `{condition: "true", template: my-template}`,
+			"template.my-template": `{body: hello}`,
+		},
+	}, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.SecretName, Namespace: testingutil.TestNamespace},
+	})
+	ctx := &commandContext{
+		stdout:        &stdout,
+		stderr:        &stderr,
+		argocdService: &lazyArgocdServiceInitializer{},
+		getK8SClients: func() (kubernetes.Interface, dynamic.Interface, string, error) {
+			return clientset, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), testingutil.TestNamespace, nil
+		},
+	}
+
+	command := newTriggerGetCommand(ctx)
+	err := command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "my-trigger")
+}
@@ -0,0 +1,322 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
+)
+
+// sampleApplications are the applications in the typical states used to verify triggers and templates
+var sampleApplications = []string{`
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: sample-synced
+  namespace: argocd
+  labels:
+    app.kubernetes.io/name: sample
+  annotations:
+    notifications.argoproj.io/subscribe.on-sync-succeeded.slack: my-channel
+spec:
+  project: default
+  source:
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+    path: guestbook
+    targetRevision: HEAD
+  destination:
+    server: https://kubernetes.default.svc
+    namespace: default
+status:
+  sync:
+    status: Synced
+    revision: 0bd8b0a829b1c2247a1a55513a65a9d7fe38ac53
+  health:
+    status: Healthy
+  summary:
+    images:
+    - gcr.io/heptio-images/ks-guestbook-demo:0.2
+  resources:
+  - kind: Deployment
+    name: guestbook-ui
+    namespace: default
+    status: Synced
+    health:
+      status: Healthy
+  reconciledAt: "2021-01-01T00:00:00Z"
+  operationState:
+    phase: Succeeded
+    message: successfully synced (all tasks run)
+    startedAt: "2021-01-01T00:00:00Z"
+    finishedAt: "2021-01-01T00:01:00Z"
+    operation:
+      initiatedBy:
+        username: admin
+      sync:
+        revision: 0bd8b0a829b1c2247a1a55513a65a9d7fe38ac53
+    syncResult:
+      revision: 0bd8b0a829b1c2247a1a55513a65a9d7fe38ac53
+`, `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: sample-sync-failed
+  namespace: argocd
+spec:
+  project: default
+  source:
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+    path: guestbook
+    targetRevision: HEAD
+  destination:
+    server: https://kubernetes.default.svc
+    namespace: default
+status:
+  sync:
+    status: OutOfSync
+    revision: 0bd8b0a829b1c2247a1a55513a65a9d7fe38ac53
+  health:
+    status: Degraded
+    message: Deployment has timed out progressing
+  conditions:
+  - type: SyncError
+    message: one or more objects failed to apply
+  resources:
+  - kind: Deployment
+    name: guestbook-ui
+    namespace: default
+    status: OutOfSync
+    health:
+      status: Degraded
+  operationState:
+    phase: Failed
+    message: one or more objects failed to apply
+    startedAt: "2021-01-01T00:00:00Z"
+    finishedAt: "2021-01-01T00:01:00Z"
+    operation:
+      initiatedBy:
+        automated: true
+      sync:
+        revision: 0bd8b0a829b1c2247a1a55513a65a9d7fe38ac53
+    syncResult:
+      revision: 0bd8b0a829b1c2247a1a55513a65a9d7fe38ac53
+`, `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: sample-sync-running
+  namespace: argocd
+spec:
+  project: default
+  source:
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+    path: guestbook
+    targetRevision: HEAD
+  destination:
+    server: https://kubernetes.default.svc
+    namespace: default
+operation:
+  sync:
+    revision: 0bd8b0a829b1c2247a1a55513a65a9d7fe38ac53
+status:
+  sync:
+    status: Unknown
+  health:
+    status: Progressing
+  operationState:
+    phase: Running
+    message: waiting for completion of hook apps/Deployment/guestbook-ui
+    startedAt: "2021-01-01T00:00:00Z"
+    operation:
+      initiatedBy:
+        username: admin
+      sync:
+        revision: 0bd8b0a829b1c2247a1a55513a65a9d7fe38ac53
+`}
+
+// loadSampleApplications returns the bundled sample applications and the applications loaded from the files
+func loadSampleApplications(paths []string) ([]*unstructured.Unstructured, error) {
+	var apps []*unstructured.Unstructured
+	for _, data := range sampleApplications {
+		var app unstructured.Unstructured
+		if err := yaml.Unmarshal([]byte(data), &app); err != nil {
+			return nil, err
+		}
+		apps = append(apps, &app)
+	}
+	for _, path := range paths {
+		var app unstructured.Unstructured
+		if err := readYAMLFile(path, &app.Object); err != nil {
+			return nil, fmt.Errorf("failed to load application %s: %v", path, err)
+		}
+		apps = append(apps, &app)
+	}
+	return apps, nil
+}
+
+// sampleArgoCDService returns the commit metadata without connecting to the Argo CD repo server
+type sampleArgoCDService struct{}
+
+func (sampleArgoCDService) GetCommitMetadata(_ context.Context, _ string, commitSHA string) (*shared.CommitMetadata, error) {
+	return &shared.CommitMetadata{
+		Message: "Sample commit message",
+		Author:  "Sample Author <author@example.com>",
+		Date:    time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		Tags:    []string{"v1.0.0"},
+	}, nil
+}
+
+// validateConfig evaluates every trigger and renders every template using the sample applications
+func validateConfig(cfg *settings.Config, services []string, apps []*unstructured.Unstructured) []settings.LintIssue {
+	var issues []settings.LintIssue
+	argocdService := sampleArgoCDService{}
+	for _, t := range cfg.Triggers {
+		// disabled triggers are validated as well since they might be enabled in the future
+		t.Enabled = nil
+		t.Template = "__validate__"
+		compiled, err := triggers.GetTriggers([]triggers.NotificationTemplate{{Name: "__validate__"}}, []triggers.NotificationTrigger{t}, argocdService)
+		if err != nil {
+			// the compilation errors are reported by the linter
+			continue
+		}
+		trigger := compiled[t.Name]
+		for _, app := range apps {
+			if eventTrigger, ok := trigger.(triggers.EventTrigger); ok && eventTrigger.GetEvent() != "" {
+				_, err = eventTrigger.TriggeredByEvent(app, map[string]interface{}{"type": eventTrigger.GetEvent()})
+			} else {
+				_, err = trigger.Triggered(app)
+			}
+			if err == nil {
+				if oncePer, ok := trigger.(triggers.OncePerTrigger); ok {
+					_, err = oncePer.GetOncePer(app)
+				}
+			}
+			if err != nil {
+				issues = append(issues, settings.LintIssue{
+					Severity: settings.SeverityError,
+					Rule:     settings.RuleEvaluationError,
+					Message:  fmt.Sprintf("trigger %s fails for sample application %s: %v", t.Name, app.GetName(), err),
+				})
+				break
+			}
+		}
+	}
+
+	if len(services) == 0 {
+		services = []string{""}
+	}
+	for _, t := range cfg.Templates {
+		compiled, err := triggers.GetTriggers([]triggers.NotificationTemplate{t}, []triggers.NotificationTrigger{{
+			Name: "__validate__", Template: t.Name, Condition: "true",
+		}}, argocdService)
+		if err != nil {
+			continue
+		}
+		trigger := compiled["__validate__"]
+		if err = renderTemplate(trigger, cfg.Context, services, apps); err != nil {
+			issues = append(issues, settings.LintIssue{
+				Severity: settings.SeverityError,
+				Rule:     settings.RuleRenderingError,
+				Message:  fmt.Sprintf("template %s cannot be rendered: %v", t.Name, err),
+			})
+		}
+	}
+	return issues
+}
+
+// renderTemplate renders notification for every sample application and notification service
+func renderTemplate(trigger triggers.Trigger, context map[string]string, services []string, apps []*unstructured.Unstructured) error {
+	for _, app := range apps {
+		for _, service := range services {
+			ctx := sharedrecipients.CopyStringMap(context)
+			ctx["notificationType"] = service
+			notification, err := trigger.FormatNotification(app, ctx)
+			if err != nil {
+				return fmt.Errorf("sample application %s: %v", app.GetName(), err)
+			}
+			if err = validateSlackNotification(notification); err != nil {
+				return fmt.Errorf("sample application %s: %v", app.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateSlackNotification(notification *notifiers.Notification) error {
+	if notification.Slack == nil {
+		return nil
+	}
+	if attachments := notification.Slack.Attachments; attachments != "" && !json.Valid([]byte(attachments)) {
+		return fmt.Errorf("slack attachments are not valid JSON: %s", attachments)
+	}
+	if blocks := notification.Slack.Blocks; blocks != "" && !json.Valid([]byte(blocks)) {
+		return fmt.Errorf("slack blocks are not valid JSON: %s", blocks)
+	}
+	return nil
+}
+
+func newConfigValidateCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		output          string
+		extraConfigMaps []string
+		applications    []string
+	)
+	var command = cobra.Command{
+		Use: "validate",
+		Example: `
+# Validate local config map and secret in the CI pipeline
+argocd-notifications tools config validate --config-map ./argocd-notifications-cm.yaml \
+  --secret ./argocd-notifications-secret.yaml
+
+# Validate local config map using additional sample application and skip notification services checks
+argocd-notifications tools config validate --config-map ./argocd-notifications-cm.yaml --secret :empty \
+  --app ./my-app.yaml
+`,
+		Short: "Parses configuration, evaluates triggers and renders templates using sample applications",
+		Long: `Parses configuration, evaluates triggers and renders templates using sample applications.
+In addition to the lint checks the command evaluates every trigger condition and renders every template for each
+configured notification service using the bundled sample applications, so runtime errors are detected before the
+configuration is applied. The command exits with non-zero code if configuration has errors.`,
+		RunE: func(c *cobra.Command, args []string) error {
+			configMap, extra, issues, err := lintConfig(cmdContext, extraConfigMaps)
+			if err != nil {
+				return err
+			}
+			apps, err := loadSampleApplications(applications)
+			if err != nil {
+				return err
+			}
+			secret, err := cmdContext.loadSecret()
+			if err != nil {
+				return fmt.Errorf("failed to load secret: %v", err)
+			}
+			_, notifiersByName, cfg, err := settings.ParseConfig(configMap, secret, settings.Config{}, sampleArgoCDService{}, extra, nil)
+			if err != nil {
+				issues = append(issues, settings.LintIssue{Severity: settings.SeverityError, Rule: settings.RuleInvalidConfig, Message: err.Error()})
+				return printIssues(cmdContext, issues, output)
+			}
+			var services []string
+			for name := range notifiersByName {
+				services = append(services, name)
+			}
+			sort.Strings(services)
+			issues = append(issues, validateConfig(cfg, services, apps)...)
+			return printIssues(cmdContext, issues, output)
+		},
+	}
+	command.Flags().StringVarP(&output, "output", "o", "wide", "Output format. One of:json|yaml|wide|junit|sarif")
+	command.Flags().StringArrayVar(&extraConfigMaps, "extra-config-map", nil, "Additional config map file path which triggers and templates are merged with the main config map")
+	command.Flags().StringArrayVar(&applications, "app", nil, "Additional sample application file path")
+	return &command
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+const tracingServiceName = "argocd-notifications-controller"
+
+type tracingOpts struct {
+	// endpoint is the OTLP gRPC collector address; tracing is disabled if empty
+	endpoint    string
+	insecure    bool
+	headers     map[string]string
+	sampleRatio float64
+}
+
+// initTracing registers the global tracer provider which exports spans to the OTLP collector and returns function
+// that flushes pending spans
+func initTracing(ctx context.Context, opts tracingOpts) (func(context.Context) error, error) {
+	if opts.endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if opts.sampleRatio < 0 || opts.sampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio should be between 0 and 1, got %v", opts.sampleRatio)
+	}
+	driverOpts := []otlpgrpc.Option{otlpgrpc.WithEndpoint(opts.endpoint), otlpgrpc.WithHeaders(opts.headers)}
+	if opts.insecure {
+		driverOpts = append(driverOpts, otlpgrpc.WithInsecure())
+	}
+	exporter, err := otlp.NewExporter(ctx, otlpgrpc.NewDriver(driverOpts...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.ServiceNameKey.String(tracingServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
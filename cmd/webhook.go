@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argocd-notifications/webhook"
+)
+
+func newWebhookCommand() *cobra.Command {
+	var (
+		port        int
+		tlsCertFile string
+		tlsKeyFile  string
+	)
+	var command = cobra.Command{
+		Use:   "webhook",
+		Short: "Starts validating admission webhook that rejects invalid notifications configuration",
+		RunE: func(c *cobra.Command, args []string) error {
+			mux := http.NewServeMux()
+			mux.Handle("/validate", webhook.NewHandler())
+			addr := fmt.Sprintf("0.0.0.0:%d", port)
+			log.Infof("serving admission webhook on port %d", port)
+			if tlsCertFile == "" || tlsKeyFile == "" {
+				log.Warn("TLS certificate is not configured, serving plain HTTP")
+				return http.ListenAndServe(addr, mux)
+			}
+			return http.ListenAndServeTLS(addr, tlsCertFile, tlsKeyFile, mux)
+		},
+	}
+	command.Flags().IntVar(&port, "port", 8443, "Port number.")
+	command.Flags().StringVar(&tlsCertFile, "tls-cert-file", "/app/tls/tls.crt", "Path to the TLS certificate file.")
+	command.Flags().StringVar(&tlsKeyFile, "tls-private-key-file", "/app/tls/tls.key", "Path to the TLS private key file.")
+	return &command
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
+)
+
+const (
+	// AckPath is the path of the acknowledgement endpoint which the ackURL template context variable points to
+	AckPath = "/api/ack"
+	ackURL  = "ackURL"
+	// ackLinkUser is recorded as the user of the link acknowledgements since the link holder is not authenticated
+	ackLinkUser = "ack-link"
+)
+
+// AckLinks signs the acknowledgement links embedded into the rendered notifications
+type AckLinks struct {
+	// BaseURL is the external URL of the controller which serves the AckPath endpoint
+	BaseURL string
+	// Key signs the link tokens
+	Key []byte
+	// TTL is the duration the link stays valid
+	TTL time.Duration
+}
+
+// ackClaims identifies the acknowledged notification
+type ackClaims struct {
+	AppNamespace string `json:"appNamespace,omitempty"`
+	App          string `json:"app"`
+	Trigger      string `json:"trigger"`
+	Recipient    string `json:"recipient,omitempty"`
+	Expires      int64  `json:"expires"`
+}
+
+func (l *AckLinks) sign(payload string) string {
+	mac := hmac.New(sha256.New, l.Key)
+	_, _ = mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (l *AckLinks) token(claims ackClaims) (string, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + l.sign(payload), nil
+}
+
+// verify returns the claims of the token or an error if the token signature is invalid or the token is expired
+func (l *AckLinks) verify(token string, now time.Time) (ackClaims, error) {
+	claims := ackClaims{}
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(l.sign(parts[0]))) {
+		return claims, errors.New("invalid token")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return claims, errors.New("invalid token")
+	}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return claims, errors.New("invalid token")
+	}
+	if now.Unix() > claims.Expires {
+		return claims, errors.New("token is expired")
+	}
+	return claims, nil
+}
+
+// Link returns the signed link which acknowledges the application trigger notification sent to the recipient or an
+// empty string if acknowledgement links are disabled
+func (l *AckLinks) Link(appNamespace string, app string, trigger string, recipient string) string {
+	if l == nil || l.BaseURL == "" {
+		return ""
+	}
+	token, err := l.token(ackClaims{
+		AppNamespace: appNamespace,
+		App:          app,
+		Trigger:      trigger,
+		Recipient:    recipient,
+		Expires:      time.Now().Add(l.TTL).Unix(),
+	})
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(l.BaseURL, "/") + AckPath + "?token=" + url.QueryEscape(token)
+}
+
+var ackFormTemplate = template.Must(template.New("ack").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Acknowledge notification</title></head>
+<body>
+<p>Acknowledge notification {{.Trigger}} of application {{.App}}?</p>
+<form method="post">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Acknowledge</button>
+</form>
+</body>
+</html>
+`))
+
+// NewAckHandler returns the handler of the acknowledgement links. The GET request renders the confirmation form, so
+// the links opened by the message previews and security scanners do not acknowledge notifications; the form POST
+// request records the acknowledgement of the recipient in the history.
+func NewAckHandler(store history.Store, links *AckLinks) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("method %s is not supported", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		token := r.Form.Get("token")
+		claims, err := links.verify(token, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_ = ackFormTemplate.Execute(w, map[string]string{"App": claims.App, "Trigger": claims.Trigger, "Token": token})
+			return
+		}
+		if _, err := history.AcknowledgeRecipient(store, claims.AppNamespace, claims.App, claims.Trigger, claims.Recipient, ackLinkUser); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprintf(w, "Notification %s of application %s is acknowledged.\n", claims.Trigger, claims.App)
+	})
+}
+
+// templateContext returns the template context of the notification sent to the recipient: the configuration context,
+// the notification service type and the acknowledgement link if acknowledgement links are enabled
+func (c *notificationController) templateContext(appNamespace string, appName string, trigger string, service string, recipient string) map[string]string {
+	templateCtx := sharedrecipients.CopyStringMap(c.context)
+	templateCtx[notificationType] = service
+	if link := c.delivery.opts.AckLinks.Link(appNamespace, appName, trigger, recipient); link != "" {
+		templateCtx[ackURL] = link
+	}
+	return templateCtx
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+)
+
+func ackToken(t *testing.T, link string) string {
+	parsed, err := url.Parse(link)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	return parsed.Query().Get("token")
+}
+
+func TestAckLinks(t *testing.T) {
+	links := &AckLinks{BaseURL: "https://notifications.example.com/", Key: []byte("secret"), TTL: time.Hour}
+
+	link := links.Link("argocd", "guestbook", "on-sync-failed", "slack:ops")
+	assert.True(t, strings.HasPrefix(link, "https://notifications.example.com/api/ack?token="))
+
+	claims, err := links.verify(ackToken(t, link), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, ackClaims{AppNamespace: "argocd", App: "guestbook", Trigger: "on-sync-failed", Recipient: "slack:ops", Expires: claims.Expires}, claims)
+
+	_, err = links.verify(ackToken(t, link), time.Now().Add(2*time.Hour))
+	assert.EqualError(t, err, "token is expired")
+
+	other := &AckLinks{BaseURL: links.BaseURL, Key: []byte("other"), TTL: time.Hour}
+	_, err = other.verify(ackToken(t, link), time.Now())
+	assert.EqualError(t, err, "invalid token")
+
+	var disabled *AckLinks
+	assert.Empty(t, disabled.Link("argocd", "guestbook", "on-sync-failed", "slack:ops"))
+}
+
+func TestAckHandler(t *testing.T) {
+	links := &AckLinks{BaseURL: "https://notifications.example.com", Key: []byte("secret"), TTL: time.Hour}
+	store := history.NewMemoryStore(10)
+	handler := NewAckHandler(store, links)
+	link := links.Link("argocd", "guestbook", "on-sync-failed", "slack:ops")
+
+	// opening the link only renders the confirmation form
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, link, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<form method="post">`)
+	events, err := store.List(history.Filter{App: "guestbook"})
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	form := url.Values{"token": {ackToken(t, link)}}
+	r := httptest.NewRequest(http.MethodPost, AckPath, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	ack, err := history.GetLastAcknowledgement(store, "argocd", "guestbook", "on-sync-failed")
+	assert.NoError(t, err)
+	if assert.NotNil(t, ack) {
+		assert.Equal(t, ackLinkUser, ack.User)
+		assert.Equal(t, "slack:ops", ack.Recipient)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, AckPath+"?token=invalid", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestTemplateContext_AckURL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, _, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]string{"notificationType": "mock"}, ctrl.templateContext("argocd", "guestbook", "on-sync-failed", "mock", "mock:ops"))
+
+	links := &AckLinks{BaseURL: "https://notifications.example.com", Key: []byte("secret"), TTL: time.Hour}
+	ctrl.delivery = NewDeliveryPipeline(DeliveryOptions{AckLinks: links}, NewMetricsRegistry(), history.NewMemoryStore(10))
+	templateCtx := ctrl.templateContext("argocd", "guestbook", "on-sync-failed", "mock", "mock:ops")
+	claims, err := links.verify(ackToken(t, templateCtx["ackURL"]), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "mock:ops", claims.Recipient)
+}
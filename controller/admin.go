@@ -0,0 +1,362 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antonmedv/expr"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/silences"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+const adminAPIPrefix = "/api/admin/"
+
+// AdminOptions holds the admin API dependencies
+type AdminOptions struct {
+	// Config returns the active configuration; nil until the configuration is loaded
+	Config func() *settings.Config
+	// Controllers returns the running controllers
+	Controllers func() []NotificationController
+	// Silences persists the silences created using the API; the silences endpoint is disabled if nil
+	Silences silences.Store
+	// Flood pauses and resumes the delivery; the flood endpoints are disabled if nil
+	Flood FloodController
+	// History records the acknowledgements which stop the escalations and lists the delivery attempts; the acks and
+	// history endpoints are disabled if nil
+	History history.Store
+	// Token is the bearer token of the API requests
+	Token string
+}
+
+// CompiledTrigger describes the trigger condition compiled by the controller
+type CompiledTrigger struct {
+	triggers.NotificationTrigger
+	// Clauses are the condition parts joined by the top-level logical operators
+	Clauses []string `json:"clauses"`
+	// Program is the disassembled bytecode of the condition
+	Program string `json:"program"`
+	// OncePerProgram is the disassembled bytecode of the oncePer expression
+	OncePerProgram string `json:"oncePerProgram,omitempty"`
+}
+
+// SilenceRequest describes the silence that should be created
+type SilenceRequest struct {
+	silences.Silence
+	// Duration is the silence duration, e.g. 2h; used if expiresAt is not specified
+	Duration string `json:"duration,omitempty"`
+}
+
+// AckRequest describes the acknowledged notifications
+type AckRequest struct {
+	// App is the application name in [<namespace>/]<name> format
+	App string `json:"app"`
+	// Trigger is the acknowledged trigger; notifications of all application triggers are acknowledged if empty
+	Trigger string `json:"trigger,omitempty"`
+	// User identifies the user who acknowledged the notifications; admin-api if empty
+	User string `json:"user,omitempty"`
+}
+
+// SilenceResponse is the created silence
+type SilenceResponse struct {
+	Name string `json:"name"`
+	silences.Silence
+}
+
+// Refresh re-evaluates triggers of the application with the next reconciliation
+func (c *notificationController) Refresh(appNamespace string, appName string) error {
+	app, err := c.getApp(appNamespace, appName)
+	if err != nil {
+		return err
+	}
+	c.refreshQueue.Add(app.GetNamespace() + "/" + app.GetName())
+	return nil
+}
+
+// Subscriptions returns the recipients of every trigger notifications about the application
+func (c *notificationController) Subscriptions(appNamespace string, appName string) (map[string][]settings.ResolvedRecipient, error) {
+	app, err := c.getApp(appNamespace, appName)
+	if err != nil {
+		return nil, err
+	}
+	proj := c.getAppProj(app)
+	res := map[string][]settings.ResolvedRecipient{}
+	for trigger := range c.triggers {
+		res[trigger] = settings.ExplainRecipients(app, proj, trigger, c.subscriptions, c.policies)
+	}
+	return res, nil
+}
+
+// compileTrigger compiles the trigger expressions the same way as the controller does
+func compileTrigger(t triggers.NotificationTrigger) (*CompiledTrigger, error) {
+	res := CompiledTrigger{NotificationTrigger: t, Clauses: []string{}}
+	condition := t.Condition
+	if condition == "" && t.Event != "" {
+		condition = "true"
+	}
+	program, err := expr.Compile(condition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trigger '%s' condition: %v", t.Name, err)
+	}
+	res.Program = program.Disassemble()
+	res.Clauses = append(res.Clauses, triggers.SplitCondition(condition)...)
+	if t.OncePer != "" {
+		if program, err = expr.Compile(t.OncePer); err != nil {
+			return nil, fmt.Errorf("failed to parse trigger '%s' oncePer expression: %v", t.Name, err)
+		}
+		res.OncePerProgram = program.Disassemble()
+	}
+	return &res, nil
+}
+
+// splitAppName splits the application name in [<namespace>/]<name> format
+func splitAppName(app string) (string, string) {
+	if parts := strings.SplitN(app, "/", 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return "", app
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(obj)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	writeAdminJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// NewAdminHandler returns HTTP handler of the admin API that lists triggers and application subscriptions, forces
+// re-evaluation of the application triggers, creates silences, acknowledges notifications, lists the notification
+// history and resumes the delivery paused by the flood protection. Every request should include the bearer token.
+//
+//	GET  /api/admin/triggers
+//	GET  /api/admin/triggers/<name>
+//	GET  /api/admin/subscriptions?app=[<namespace>/]<name>
+//	POST /api/admin/refresh?app=[<namespace>/]<name>
+//	POST /api/admin/silences
+//	POST /api/admin/acks
+//	GET  /api/admin/history?app=<name>&trigger=<name>&recipient=<recipient>&status=<status>&since=<time>&until=<time>
+//	GET  /api/admin/flood
+//	POST /api/admin/flood/resume
+func NewAdminHandler(opts AdminOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actual := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if opts.Token == "" || subtle.ConstantTimeCompare([]byte(opts.Token), []byte(actual)) != 1 {
+			writeAdminError(w, http.StatusUnauthorized, errors.New("invalid token"))
+			return
+		}
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, adminAPIPrefix), "/")
+		method := http.MethodGet
+		if path == "refresh" || path == "silences" || path == "acks" || path == "flood/resume" {
+			method = http.MethodPost
+		}
+		if r.Method != method {
+			writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not supported", r.Method))
+			return
+		}
+		var res interface{}
+		status := http.StatusOK
+		var err error
+		switch {
+		case path == "triggers":
+			res, err = listTriggers(opts)
+		case strings.HasPrefix(path, "triggers/"):
+			res, err = getTrigger(opts, strings.TrimPrefix(path, "triggers/"))
+		case path == "subscriptions":
+			res, err = getSubscriptions(opts, r.URL.Query().Get("app"))
+		case path == "refresh":
+			res, err = refreshApp(opts, r.URL.Query().Get("app"))
+			status = http.StatusAccepted
+		case path == "silences":
+			res, err = createSilence(opts, r)
+			status = http.StatusCreated
+		case path == "acks":
+			res, err = acknowledge(opts, r)
+			status = http.StatusCreated
+		case path == "history":
+			res, err = listHistory(opts, r)
+		case path == "flood":
+			res, err = getFloodStatus(opts)
+		case path == "flood/resume":
+			res, err = resumeDelivery(opts)
+		default:
+			writeAdminError(w, http.StatusNotFound, fmt.Errorf("endpoint %s is not found", r.URL.Path))
+			return
+		}
+		if err != nil {
+			writeAdminError(w, adminErrorStatus(err), err)
+			return
+		}
+		writeAdminJSON(w, status, res)
+	})
+}
+
+// adminError is the error caused by the invalid request rather than the controller failure
+type adminError struct {
+	status int
+	error
+}
+
+func adminErrorStatus(err error) int {
+	if err == errAppNotManaged {
+		return http.StatusNotFound
+	}
+	if err, ok := err.(adminError); ok {
+		return err.status
+	}
+	return http.StatusInternalServerError
+}
+
+func activeConfig(opts AdminOptions) (*settings.Config, error) {
+	cfg := opts.Config()
+	if cfg == nil {
+		return nil, adminError{http.StatusServiceUnavailable, errors.New("configuration is not loaded")}
+	}
+	return cfg, nil
+}
+
+func listTriggers(opts AdminOptions) ([]triggers.NotificationTrigger, error) {
+	cfg, err := activeConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	res := append([]triggers.NotificationTrigger{}, cfg.Triggers...)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+func getTrigger(opts AdminOptions, name string) (*CompiledTrigger, error) {
+	cfg, err := activeConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range cfg.Triggers {
+		if t.Name == name {
+			return compileTrigger(t)
+		}
+	}
+	return nil, adminError{http.StatusNotFound, fmt.Errorf("trigger %s is not configured", name)}
+}
+
+// forEachController calls the action with the controllers until the one that manages the application is found
+func forEachController(opts AdminOptions, app string, action func(c NotificationController, namespace string, name string) error) error {
+	if app == "" {
+		return adminError{http.StatusBadRequest, errors.New("app parameter is required")}
+	}
+	namespace, name := splitAppName(app)
+	err := errAppNotManaged
+	controllers := opts.Controllers()
+	for i := 0; i < len(controllers) && err == errAppNotManaged; i++ {
+		err = action(controllers[i], namespace, name)
+	}
+	return err
+}
+
+func getSubscriptions(opts AdminOptions, app string) (map[string][]settings.ResolvedRecipient, error) {
+	var res map[string][]settings.ResolvedRecipient
+	err := forEachController(opts, app, func(c NotificationController, namespace string, name string) error {
+		var err error
+		res, err = c.Subscriptions(namespace, name)
+		return err
+	})
+	return res, err
+}
+
+func refreshApp(opts AdminOptions, app string) (map[string]string, error) {
+	err := forEachController(opts, app, func(c NotificationController, namespace string, name string) error {
+		return c.Refresh(namespace, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"app": app}, nil
+}
+
+func createSilence(opts AdminOptions, r *http.Request) (*SilenceResponse, error) {
+	if opts.Silences == nil {
+		return nil, adminError{http.StatusNotImplemented, errors.New("silences are not enabled")}
+	}
+	var req SilenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, adminError{http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err)}
+	}
+	if req.ExpiresAt.IsZero() {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			return nil, adminError{http.StatusBadRequest, errors.New("either expiresAt or positive duration is required")}
+		}
+		req.ExpiresAt = time.Now().Add(duration)
+	}
+	if req.CreatedBy == "" {
+		req.CreatedBy = "admin-api"
+	}
+	silence, err := opts.Silences.Create(req.Silence)
+	if err != nil {
+		return nil, err
+	}
+	return &SilenceResponse{Name: silence.Name, Silence: silence}, nil
+}
+
+func acknowledge(opts AdminOptions, r *http.Request) (*history.Event, error) {
+	if opts.History == nil {
+		return nil, adminError{http.StatusNotImplemented, errors.New("notification history is not enabled")}
+	}
+	var req AckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, adminError{http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err)}
+	}
+	if req.App == "" {
+		return nil, adminError{http.StatusBadRequest, errors.New("app is required")}
+	}
+	if req.User == "" {
+		req.User = "admin-api"
+	}
+	namespace, name := splitAppName(req.App)
+	event, err := history.Acknowledge(opts.History, namespace, name, req.Trigger, req.User)
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func listHistory(opts AdminOptions, r *http.Request) ([]history.Event, error) {
+	if opts.History == nil {
+		return nil, adminError{http.StatusNotImplemented, errors.New("notification history is not enabled")}
+	}
+	filter, err := history.ParseFilter(r.URL.Query())
+	if err != nil {
+		return nil, adminError{http.StatusBadRequest, err}
+	}
+	return opts.History.List(filter)
+}
+
+func getFloodStatus(opts AdminOptions) (*FloodStatus, error) {
+	if opts.Flood == nil {
+		return nil, adminError{http.StatusNotImplemented, errors.New("flood protection is not enabled")}
+	}
+	status := opts.Flood.FloodStatus()
+	return &status, nil
+}
+
+func resumeDelivery(opts AdminOptions) (*FloodStatus, error) {
+	if opts.Flood == nil {
+		return nil, adminError{http.StatusNotImplemented, errors.New("flood protection is not enabled")}
+	}
+	if !opts.Flood.Resume() {
+		return nil, adminError{http.StatusConflict, errors.New("delivery is not paused")}
+	}
+	status := opts.Flood.FloodStatus()
+	return &status, nil
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/silences"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func adminRequest(handler http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestAdminHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("guestbook", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "mock:recipient",
+	})))
+	ctrl, _, _, err := newController(t, ctx, client)
+	if !assert.NoError(t, err) {
+		return
+	}
+	cfg := &settings.Config{Triggers: []triggers.NotificationTrigger{{
+		Name: "on-sync-failed", Condition: "app.status.sync.status == 'OutOfSync' and 1 > 0", Template: "app-sync-failed", OncePer: "app.status.sync.revision",
+	}}}
+	silenceStore := silences.NewCRDStore(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+	handler := NewAdminHandler(AdminOptions{
+		Config:      func() *settings.Config { return cfg },
+		Controllers: func() []NotificationController { return []NotificationController{ctrl} },
+		Silences:    silenceStore,
+		Token:       "secret",
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/triggers", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("ListTriggers", func(t *testing.T) {
+		w := adminRequest(handler, http.MethodGet, "/api/admin/triggers", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"on-sync-failed"`)
+	})
+
+	t.Run("GetTrigger", func(t *testing.T) {
+		w := adminRequest(handler, http.MethodGet, "/api/admin/triggers/on-sync-failed", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var res CompiledTrigger
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, []string{"app.status.sync.status == 'OutOfSync'", "1 > 0"}, res.Clauses)
+		assert.NotEmpty(t, res.Program)
+		assert.NotEmpty(t, res.OncePerProgram)
+
+		w = adminRequest(handler, http.MethodGet, "/api/admin/triggers/unknown", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Subscriptions", func(t *testing.T) {
+		w := adminRequest(handler, http.MethodGet, "/api/admin/subscriptions?app="+TestNamespace+"/guestbook", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var res map[string][]settings.ResolvedRecipient
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		if assert.Len(t, res["mock"], 1) {
+			assert.Equal(t, "mock:recipient", res["mock"][0].Recipient)
+		}
+
+		w = adminRequest(handler, http.MethodGet, "/api/admin/subscriptions?app=unknown", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = adminRequest(handler, http.MethodGet, "/api/admin/subscriptions", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		w := adminRequest(handler, http.MethodPost, "/api/admin/refresh?app=guestbook", "")
+		assert.Equal(t, http.StatusAccepted, w.Code)
+
+		w = adminRequest(handler, http.MethodGet, "/api/admin/refresh?app=guestbook", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("CreateSilence", func(t *testing.T) {
+		w := adminRequest(handler, http.MethodPost, "/api/admin/silences", `{"app":"guestbook","trigger":"on-sync-failed","duration":"1h"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		items, err := silenceStore.List()
+		assert.NoError(t, err)
+		if assert.Len(t, items, 1) {
+			assert.Equal(t, "guestbook", items[0].App)
+			assert.Equal(t, "admin-api", items[0].CreatedBy)
+		}
+
+		w = adminRequest(handler, http.MethodPost, "/api/admin/silences", `{"app":"guestbook"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAdminHandler_Flood(t *testing.T) {
+	handler := NewAdminHandler(AdminOptions{Token: "secret"})
+	w := adminRequest(handler, http.MethodGet, "/api/admin/flood", "")
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+
+	p := NewDeliveryPipeline(DeliveryOptions{FloodThreshold: 1, FloodWindow: time.Minute}, NewMetricsRegistry(), nil)
+	handler = NewAdminHandler(AdminOptions{Token: "secret", Flood: p})
+	w = adminRequest(handler, http.MethodPost, "/api/admin/flood/resume", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	p.flood.allow()
+	p.flood.allow()
+	w = adminRequest(handler, http.MethodGet, "/api/admin/flood", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"paused":true`)
+
+	w = adminRequest(handler, http.MethodPost, "/api/admin/flood/resume", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"paused":false`)
+	assert.False(t, p.FloodStatus().Paused)
+}
+
+func TestAdminHandler_Acks(t *testing.T) {
+	handler := NewAdminHandler(AdminOptions{Token: "secret"})
+	w := adminRequest(handler, http.MethodPost, "/api/admin/acks", `{"app": "argocd/guestbook"}`)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+
+	store := history.NewMemoryStore(10)
+	handler = NewAdminHandler(AdminOptions{Token: "secret", History: store})
+	w = adminRequest(handler, http.MethodPost, "/api/admin/acks", `{"trigger": "on-sync-failed"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = adminRequest(handler, http.MethodPost, "/api/admin/acks", `{"app": "argocd/guestbook", "trigger": "on-sync-failed"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"Acknowledged"`)
+	ack, err := history.GetAcknowledgement(store, "argocd", "guestbook", "on-sync-failed", time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	if assert.NotNil(t, ack) {
+		assert.Equal(t, "admin-api", ack.User)
+	}
+}
+
+func TestAdminHandler_History(t *testing.T) {
+	handler := NewAdminHandler(AdminOptions{Token: "secret"})
+	w := adminRequest(handler, http.MethodGet, "/api/admin/history", "")
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+
+	store := history.NewMemoryStore(0)
+	assert.NoError(t, store.Record(history.Event{App: "app1", Trigger: "on-sync", Timestamp: time.Now()}))
+	assert.NoError(t, store.Record(history.Event{App: "app2", Trigger: "on-sync", Timestamp: time.Now()}))
+	handler = NewAdminHandler(AdminOptions{Token: "secret", History: store})
+
+	w = adminRequest(handler, http.MethodGet, "/api/admin/history?app=app2", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var events []history.Event
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "app2", events[0].App)
+	}
+
+	w = adminRequest(handler, http.MethodGet, "/api/admin/history?since=yesterday", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/history", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+// aggregateTickInterval is how often the evaluator checks if the aggregate triggers are due
+const aggregateTickInterval = 10 * time.Second
+
+// aggregateRule is the configured aggregate trigger and its evaluation state
+type aggregateRule struct {
+	trigger   settings.AggregateTrigger
+	aggregate *triggers.Aggregate
+	// next is the time of the next evaluation
+	next time.Time
+	// triggered is the condition value of the previous evaluation; the notification is sent when the condition
+	// switches from false to true
+	triggered bool
+}
+
+// AggregateEvaluator periodically evaluates the aggregate triggers over the applications of every running controller
+// and delivers the notification of the matched applications when the trigger condition switches to true
+type AggregateEvaluator struct {
+	lock          sync.Mutex
+	rules         map[string]*aggregateRule
+	templates     map[string]triggers.NotificationTemplate
+	context       map[string]string
+	pipeline      *DeliveryPipeline
+	controllers   func() []NotificationController
+	argocdService argocd.Service
+}
+
+func NewAggregateEvaluator(pipeline *DeliveryPipeline, controllers func() []NotificationController, argocdService argocd.Service) *AggregateEvaluator {
+	return &AggregateEvaluator{
+		rules:         map[string]*aggregateRule{},
+		pipeline:      pipeline,
+		controllers:   controllers,
+		argocdService: argocdService,
+	}
+}
+
+// SetTriggers updates the aggregate triggers and the templates which render the notifications. The state of the
+// unchanged triggers is kept, so the configuration reload does not repeat the notifications.
+func (e *AggregateEvaluator) SetTriggers(aggregateTriggers []settings.AggregateTrigger, templates []triggers.NotificationTemplate, context map[string]string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	rules := map[string]*aggregateRule{}
+	for _, t := range aggregateTriggers {
+		if existing, ok := e.rules[t.Name]; ok && reflect.DeepEqual(existing.trigger, t) {
+			rules[t.Name] = existing
+			continue
+		}
+		aggregate, err := triggers.NewAggregate(t.Selector, t.Match, t.Condition, e.argocdService)
+		if err != nil {
+			log.Errorf("Failed to compile aggregate trigger %s: %v", t.Name, err)
+			continue
+		}
+		rules[t.Name] = &aggregateRule{trigger: t, aggregate: aggregate}
+	}
+	e.rules = rules
+	e.templates = map[string]triggers.NotificationTemplate{}
+	for _, t := range templates {
+		e.templates[t.Name] = t
+	}
+	e.context = context
+}
+
+func (e *AggregateEvaluator) Run(ctx context.Context) {
+	wait.Until(func() {
+		e.evaluate(ctx, time.Now())
+	}, aggregateTickInterval, ctx.Done())
+}
+
+// evaluate evaluates the aggregate triggers which are due
+func (e *AggregateEvaluator) evaluate(ctx context.Context, now time.Time) {
+	controllers := e.controllers()
+	// the controllers are not running until the configuration is loaded and the informers are synced
+	if len(controllers) == 0 {
+		return
+	}
+	e.lock.Lock()
+	var due []*aggregateRule
+	for _, rule := range e.rules {
+		if !now.Before(rule.next) {
+			rule.next = now.Add(rule.trigger.GetInterval())
+			due = append(due, rule)
+		}
+	}
+	templates, context := e.templates, e.context
+	e.lock.Unlock()
+	if len(due) == 0 {
+		return
+	}
+
+	var apps []*unstructured.Unstructured
+	for _, c := range controllers {
+		apps = append(apps, c.Apps()...)
+	}
+	for _, rule := range due {
+		logEntry := log.WithField(logFieldTrigger, rule.trigger.Name)
+		res, err := rule.aggregate.Evaluate(apps)
+		if err != nil {
+			logEntry.Errorf("Failed to execute condition of aggregate trigger %s: %v", rule.trigger.Name, err)
+			continue
+		}
+		e.pipeline.metrics.IncTriggerEvaluationsCounter(rule.trigger.Name, res.Triggered)
+		logEntry.Infof("Aggregate trigger %s result: %v, %d of %d application(s) matched", rule.trigger.Name, res.Triggered, len(res.Matched), res.Total)
+		e.lock.Lock()
+		fire := res.Triggered && !rule.triggered
+		rule.triggered = res.Triggered
+		e.lock.Unlock()
+		if !fire {
+			continue
+		}
+		t, ok := templates[rule.trigger.Template]
+		if !ok {
+			logEntry.Errorf("Template %s of aggregate trigger %s is not found", rule.trigger.Template, rule.trigger.Name)
+			continue
+		}
+		vars := res.Vars()
+		vars["trigger"] = rule.trigger.Name
+		vars["context"] = context
+		notification, err := triggers.FormatTemplate(t, vars)
+		if err != nil {
+			e.pipeline.metrics.IncTemplateRenderErrorsCounter(t.Name)
+			logEntry.Errorf("Failed to render template %s of aggregate trigger %s: %v", t.Name, rule.trigger.Name, err)
+			continue
+		}
+		correlationID := newCorrelationID()
+		for _, recipient := range rule.trigger.Recipients {
+			parsed, err := sharedrecipients.Parse(recipient)
+			if err != nil {
+				logEntry.Errorf("Failed to send aggregate trigger %s notification: %v", rule.trigger.Name, err)
+				continue
+			}
+			d := &delivery{
+				trigger:       rule.trigger.Name,
+				template:      t.Name,
+				service:       parsed.Service,
+				target:        parsed.Target,
+				options:       parsed.Options(),
+				notification:  *notification,
+				correlationID: correlationID,
+			}
+			if err := e.pipeline.deliver(ctx, d); err != nil {
+				d.logEntry().Errorf("Failed to deliver %s notification to %s: %v", d.trigger, d.recipient(), err)
+			}
+		}
+	}
+}
+
+// Apps returns the applications of the controller configuration group; the applications of every shard are returned,
+// so the aggregate triggers are evaluated over all applications
+func (c *notificationController) Apps() []*unstructured.Unstructured {
+	var apps []*unstructured.Unstructured
+	for _, obj := range c.appInformer.GetIndexer().List() {
+		app, ok := obj.(*unstructured.Unstructured)
+		if !ok || (c.sharding.Filter != nil && !c.sharding.Filter(app)) {
+			continue
+		}
+		apps = append(apps, app)
+	}
+	return apps
+}
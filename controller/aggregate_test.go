@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	notifiermocks "github.com/argoproj-labs/argocd-notifications/notifiers/mocks"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+// appsController is the running controller which watches the specified applications
+type appsController struct {
+	NotificationController
+	apps []*unstructured.Unstructured
+}
+
+func (c *appsController) Apps() []*unstructured.Unstructured {
+	return c.apps
+}
+
+func TestAggregateEvaluator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), nil)
+	p.SetNotifiers(map[string]notifiers.Notifier{"mock": notifier})
+	running := &appsController{apps: []*unstructured.Unstructured{
+		NewApp("guestbook", WithProject("default"), WithHealthStatus("Degraded")),
+		NewApp("billing", WithProject("default"), WithHealthStatus("Healthy")),
+	}}
+	e := NewAggregateEvaluator(p, func() []NotificationController { return []NotificationController{running} }, nil)
+	e.SetTriggers([]settings.AggregateTrigger{{
+		Name:       "on-apps-degraded",
+		Match:      "app.status.health.status == 'Degraded'",
+		Condition:  "ratio >= 0.5",
+		Template:   "apps-degraded",
+		Recipients: []string{"mock:platform"},
+	}}, []triggers.NotificationTemplate{{
+		Name: "apps-degraded",
+		Notification: notifiers.Notification{
+			Title: "{{.matched}} of {{.total}} apps are degraded",
+			Body:  "{{range .apps}}{{.metadata.name}} {{end}}",
+		},
+	}}, nil)
+
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "1 of 2 apps are degraded", Body: "guestbook ", Webhook: map[string]notifiers.WebhookNotification{}}, "platform").Return(nil)
+	now := time.Now()
+	e.evaluate(context.Background(), now)
+
+	// the notification is not repeated while the condition holds
+	e.evaluate(context.Background(), now.Add(time.Minute))
+
+	// the trigger fires again once the condition switches to false and back to true
+	running.apps = running.apps[:1]
+	running.apps[0] = NewApp("guestbook", WithProject("default"), WithHealthStatus("Healthy"))
+	e.evaluate(context.Background(), now.Add(2*time.Minute))
+	running.apps[0] = NewApp("guestbook", WithProject("default"), WithHealthStatus("Degraded"))
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "1 of 1 apps are degraded", Body: "guestbook ", Webhook: map[string]notifiers.WebhookNotification{}}, "platform").Return(nil)
+	e.evaluate(context.Background(), now.Add(3*time.Minute))
+}
+
+func TestAggregateEvaluator_RespectsInterval(t *testing.T) {
+	evaluated := 0
+	e := NewAggregateEvaluator(NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), nil), func() []NotificationController {
+		evaluated++
+		return []NotificationController{&appsController{}}
+	}, nil)
+	e.SetTriggers([]settings.AggregateTrigger{{
+		Name: "on-apps-degraded", Match: "false", Template: "apps-degraded", Recipients: []string{"mock:platform"}, Interval: "5m",
+	}}, nil, nil)
+	now := time.Now()
+
+	e.evaluate(context.Background(), now)
+	assert.False(t, e.rules["on-apps-degraded"].next.Before(now.Add(5*time.Minute)))
+	next := e.rules["on-apps-degraded"].next
+	e.evaluate(context.Background(), now.Add(time.Minute))
+	assert.Equal(t, next, e.rules["on-apps-degraded"].next)
+
+	// the unchanged trigger keeps the evaluation state
+	e.SetTriggers([]settings.AggregateTrigger{{
+		Name: "on-apps-degraded", Match: "false", Template: "apps-degraded", Recipients: []string{"mock:platform"}, Interval: "5m",
+	}}, nil, nil)
+	assert.Equal(t, next, e.rules["on-apps-degraded"].next)
+	assert.Equal(t, 2, evaluated)
+}
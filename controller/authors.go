@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
+)
+
+// maxCachedCommitAuthors limits the number of cached commit authors
+const maxCachedCommitAuthors = 1000
+
+// commitAuthors resolves the author:<service> recipients to the author of the application sync revision commit. The
+// commit authors are cached since the commits never change.
+type commitAuthors struct {
+	argocdService argocd.Service
+	lock          sync.Mutex
+	authors       map[string]string
+}
+
+func newCommitAuthors(argocdService argocd.Service) *commitAuthors {
+	return &commitAuthors{argocdService: argocdService, authors: map[string]string{}}
+}
+
+// appRevision returns the repository URL and the revision of the last sync operation or the current sync revision
+func appRevision(app *unstructured.Unstructured) (string, string) {
+	repoURL, _, _ := unstructured.NestedString(app.Object, "spec", "source", "repoURL")
+	revision, _, _ := unstructured.NestedString(app.Object, "status", "operationState", "syncResult", "revision")
+	if revision == "" {
+		revision, _, _ = unstructured.NestedString(app.Object, "status", "sync", "revision")
+	}
+	return repoURL, revision
+}
+
+func (a *commitAuthors) get(ctx context.Context, app *unstructured.Unstructured) (string, error) {
+	if a.argocdService == nil {
+		return "", errors.New("commit metadata is not available without Argo CD repo server")
+	}
+	repoURL, revision := appRevision(app)
+	if repoURL == "" || revision == "" {
+		return "", fmt.Errorf("application %s has no sync revision", app.GetName())
+	}
+	key := repoURL + "@" + revision
+	a.lock.Lock()
+	author, ok := a.authors[key]
+	a.lock.Unlock()
+	if ok {
+		return author, nil
+	}
+	meta, err := a.argocdService.GetCommitMetadata(ctx, repoURL, revision)
+	if err != nil {
+		return "", fmt.Errorf("failed to get metadata of commit %s: %v", revision, err)
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if len(a.authors) >= maxCachedCommitAuthors {
+		a.authors = map[string]string{}
+	}
+	a.authors[key] = meta.Author
+	return meta.Author, nil
+}
+
+// resolve replaces the author recipients of the failover chain with the recipients of the commit author; the other
+// recipients of the chain are returned as is
+func (a *commitAuthors) resolve(ctx context.Context, app *unstructured.Unstructured, recipient string) (string, error) {
+	chain := sharedrecipients.SplitFailoverChain(recipient)
+	resolved := make([]string, len(chain))
+	for i, item := range chain {
+		resolved[i] = item
+		if !sharedrecipients.IsAuthor(item) {
+			continue
+		}
+		author, err := a.get(ctx, app)
+		if err != nil {
+			return "", err
+		}
+		if resolved[i], err = sharedrecipients.ResolveAuthor(item, author); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(resolved, sharedrecipients.FailoverSeparator), nil
+}
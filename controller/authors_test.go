@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	argocdmocks "github.com/argoproj-labs/argocd-notifications/shared/argocd/mocks"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
+)
+
+func TestCommitAuthors_Resolve(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	argocdService := argocdmocks.NewMockService(ctrl)
+	argocdService.EXPECT().GetCommitMetadata(gomock.Any(), "https://github.com/argoproj/argocd-example-apps.git", "abc").
+		Return(&shared.CommitMetadata{Author: "Alice <alice@corp.com>"}, nil).Times(1)
+	authors := newCommitAuthors(argocdService)
+	app := NewApp("guestbook", WithRepoURL("https://github.com/argoproj/argocd-example-apps.git"))
+	_ = unstructured.SetNestedField(app.Object, "abc", "status", "sync", "revision")
+
+	recipient, err := authors.resolve(context.Background(), app, "author:slack|email:team@corp.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "slack-user:alice@corp.com|email:team@corp.com", recipient)
+
+	// the commit author is cached
+	recipient, err = authors.resolve(context.Background(), app, "author:email")
+	assert.NoError(t, err)
+	assert.Equal(t, "email:alice@corp.com", recipient)
+
+	recipient, err = authors.resolve(context.Background(), NewApp("other"), "slack:deploys")
+	assert.NoError(t, err)
+	assert.Equal(t, "slack:deploys", recipient)
+
+	_, err = authors.resolve(context.Background(), NewApp("other"), "author:email")
+	assert.EqualError(t, err, "application other has no sync revision")
+}
+
+func TestCommitAuthors_MetadataError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	argocdService := argocdmocks.NewMockService(ctrl)
+	argocdService.EXPECT().GetCommitMetadata(gomock.Any(), gomock.Any(), "abc").Return(nil, errors.New("not found"))
+	app := NewApp("guestbook", WithRepoURL("https://github.com/argoproj/argocd-example-apps.git"))
+	_ = unstructured.SetNestedField(app.Object, "abc", "status", "sync", "revision")
+
+	_, err := newCommitAuthors(argocdService).resolve(context.Background(), app, "author:email")
+	assert.EqualError(t, err, "failed to get metadata of commit abc: not found")
+}
+
+func TestSendsNotificationToCommitAuthor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithRepoURL("https://github.com/argoproj/argocd-example-apps.git"), WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "author:email",
+	}))
+	_ = unstructured.SetNestedField(app.Object, "abc", "status", "operationState", "syncResult", "revision")
+	ctrl, trigger, notifier, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	if !assert.NoError(t, err) {
+		return
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	argocdService := argocdmocks.NewMockService(mockCtrl)
+	argocdService.EXPECT().GetCommitMetadata(gomock.Any(), gomock.Any(), "abc").Return(&shared.CommitMetadata{Author: "Alice <alice@corp.com>"}, nil)
+	ctrl.authors = newCommitAuthors(argocdService)
+	ctrl.notifiers = map[string]notifiers.Notifier{"email": notifier}
+
+	trigger.EXPECT().GetTemplateName().Return("test")
+	trigger.EXPECT().Triggered(app).Return(true, nil)
+	trigger.EXPECT().FormatNotification(app, map[string]string{"notificationType": "email"}).Return(
+		&notifiers.Notification{Title: "title", Body: "body"}, nil)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "title", Body: "body"}, "alice@corp.com").Return(nil)
+
+	assert.NoError(t, ctrl.processApp(context.Background(), app, logEntry))
+}
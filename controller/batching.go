@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// batcher holds the configured notifier batching rules
+type batcher struct {
+	lock  sync.Mutex
+	rules []settings.Batch
+}
+
+func (b *batcher) setRules(rules []settings.Batch) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.rules = rules
+}
+
+// match returns the first batching rule that matches the recipient or nil if notifications are sent immediately
+func (b *batcher) match(service string, recipient string) *settings.Batch {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for i := range b.rules {
+		if b.rules[i].Matches(service, recipient) {
+			rule := b.rules[i]
+			return &rule
+		}
+	}
+	return nil
+}
+
+// SetBatching updates notifier batching rules
+func (p *DeliveryPipeline) SetBatching(rules []settings.Batch) {
+	p.batcher.setRules(rules)
+}
+
+// batch adds the notification to the pending batch of the recipient and returns true if notifications of the
+// recipient are batched. The batch is sent when the batch interval elapses.
+func (p *DeliveryPipeline) batch(d *delivery) bool {
+	if d.batched != nil {
+		return false
+	}
+	rule := p.batcher.match(d.service, d.recipient())
+	if rule == nil {
+		return false
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if batch, ok := p.batches[d.recipient()]; ok {
+		batch.batched = append(batch.batched, d.notification)
+		return true
+	}
+	batch := &delivery{
+		trigger:       d.trigger,
+		service:       d.service,
+		target:        d.target,
+		options:       d.options,
+		notifiers:     d.notifiers,
+		fallbacks:     d.fallbacks,
+		batched:       []notifiers.Notification{d.notification},
+		correlationID: newCorrelationID(),
+	}
+	p.batches[d.recipient()] = batch
+	d.logEntry().Infof("Notification is added to the batch %s", batch.correlationID)
+	batch.logEntry().Infof("Sending batch of notifications to %s in %v", d.recipient(), rule.GetInterval())
+	p.queue.AddAfter(batch, rule.GetInterval())
+	return true
+}
+
+// flushBatch removes the batch from the pending batches and combines batched notifications. Returns false if the
+// delivery is not a batch. The batched notifications are read under the lock since notifications are added to the
+// pending batch concurrently.
+func (p *DeliveryPipeline) flushBatch(d *delivery) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if d.batched == nil {
+		return false
+	}
+	if p.batches[d.recipient()] == d {
+		delete(p.batches, d.recipient())
+	}
+	if len(d.batched) == 1 {
+		d.notification = d.batched[0]
+	} else {
+		d.notification = combineNotifications(fmt.Sprintf("%d notification(s)", len(d.batched)), d.batched)
+	}
+	d.batched = nil
+	return true
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState struct {
+	failures int
+	open     bool
+	openedAt time.Time
+	// probing is true while the single trial delivery of the half-open breaker is in flight
+	probing bool
+}
+
+// circuitBreaker stops sending notifications using the notifier which failed threshold times in a row. After the
+// cooldown period the breaker lets a single delivery through and closes if it succeeds.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	lock   sync.Mutex
+	states map[string]*breakerState
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now, states: map[string]*breakerState{}}
+}
+
+func (b *circuitBreaker) getState(notifier string) *breakerState {
+	state, ok := b.states[notifier]
+	if !ok {
+		state = &breakerState{}
+		b.states[notifier] = state
+	}
+	return state
+}
+
+// allow returns true if the notification might be sent using the specified notifier
+func (b *circuitBreaker) allow(notifier string) bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	state := b.getState(notifier)
+	if !state.open {
+		return true
+	}
+	if state.probing || b.now().Sub(state.openedAt) < b.cooldown {
+		return false
+	}
+	state.probing = true
+	return true
+}
+
+// record updates the notifier state using the delivery result and returns true if the breaker is open
+func (b *circuitBreaker) record(notifier string, err error) bool {
+	if b.threshold <= 0 {
+		return false
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	state := b.getState(notifier)
+	if err == nil {
+		*state = breakerState{}
+		return false
+	}
+	state.failures++
+	if state.probing || state.failures >= b.threshold {
+		state.open = true
+		state.probing = false
+		state.openedAt = b.now()
+	}
+	return state.open
+}
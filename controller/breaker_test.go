@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	breaker := newCircuitBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		assert.False(t, breaker.record("slack", errors.New("fail")))
+	}
+	assert.True(t, breaker.allow("slack"))
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	breaker := newCircuitBreaker(2, time.Minute)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	assert.False(t, breaker.record("slack", errors.New("fail")))
+	assert.True(t, breaker.allow("slack"))
+	assert.True(t, breaker.record("slack", errors.New("fail")))
+	assert.False(t, breaker.allow("slack"))
+	assert.True(t, breaker.allow("email"))
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	breaker := newCircuitBreaker(1, time.Minute)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	breaker.record("slack", errors.New("fail"))
+
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.allow("slack"))
+	// only single trial notification is allowed
+	assert.False(t, breaker.allow("slack"))
+	assert.True(t, breaker.record("slack", errors.New("fail")))
+	assert.False(t, breaker.allow("slack"))
+
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.allow("slack"))
+	assert.False(t, breaker.record("slack", nil))
+	assert.True(t, breaker.allow("slack"))
+	assert.True(t, breaker.allow("slack"))
+}
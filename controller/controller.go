@@ -2,41 +2,54 @@ package controller
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"runtime/debug"
-	"strings"
 	"time"
 
 	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
 	"github.com/argoproj-labs/argocd-notifications/shared/clients"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
 	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
 	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/state"
 	"github.com/argoproj-labs/argocd-notifications/triggers"
 
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	runtimeutil "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 )
 
 const (
-	resyncPeriod     = 60 * time.Second
-	notificationType = "notificationType"
+	notificationType   = "notificationType"
+	digestTemplateName = "digest"
 )
 
 type NotificationController interface {
 	Run(ctx context.Context, processors int)
 	Init(ctx context.Context) error
+	// Replay sends once again the notification recorded in the history
+	Replay(ctx context.Context, event history.Event) error
+	// ProcessEvent sends notifications of the triggers fired by the external event
+	ProcessEvent(ctx context.Context, event ExternalEvent) error
+	// Refresh re-evaluates triggers of the application
+	Refresh(appNamespace string, appName string) error
+	// Subscriptions returns the recipients of every trigger notifications about the application
+	Subscriptions(appNamespace string, appName string) (map[string][]settings.ResolvedRecipient, error)
+	// Apps returns the applications watched by the controller
+	Apps() []*unstructured.Unstructured
+	// ReleaseState removes the notification state entry of the application, so the notification is sent again once
+	// the trigger condition holds
+	ReleaseState(appNamespace string, appName string, stateKey string) error
 }
 
 func NewController(client dynamic.Interface,
@@ -45,23 +58,39 @@ func NewController(client dynamic.Interface,
 	notifiers map[string]notifiers.Notifier,
 	context map[string]string,
 	subscriptions settings.DefaultSubscriptions,
+	policies settings.RecipientPolicies,
 	appLabelSelector string,
+	sharding Sharding,
+	informerOpts InformerOptions,
 	metricsRegistry *controllerRegistry,
+	deliveryPipeline *DeliveryPipeline,
+	recorder record.EventRecorder,
+	stateStore state.Store,
+	argocdService argocd.Service,
 ) (NotificationController, error) {
 	appClient := clients.NewAppClient(client, namespace)
-	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	queue := newShardedQueue()
 
-	appInformer := newInformer(appClient, appLabelSelector)
+	appInformer := newInformer(appClient, appLabelSelector, informerOpts)
 
 	appInformer.AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
+				if app, ok := obj.(*unstructured.Unstructured); ok && !sharding.Owns(app) {
+					return
+				}
 				key, err := cache.MetaNamespaceKeyFunc(obj)
 				if err == nil {
 					queue.Add(key)
 				}
 			},
 			UpdateFunc: func(old, new interface{}) {
+				if app, ok := new.(*unstructured.Unstructured); ok && !sharding.Owns(app) {
+					return
+				}
+				if informerOpts.SkipUnchanged && isUnchanged(old, new) {
+					return
+				}
 				key, err := cache.MetaNamespaceKeyFunc(new)
 				if err == nil {
 					queue.Add(key)
@@ -69,10 +98,17 @@ func NewController(client dynamic.Interface,
 			},
 		},
 	)
-	appProjInformer := newInformer(clients.NewAppProjClient(client, namespace), "")
+	appProjInformer := newInformer(clients.NewAppProjClient(client, namespace), "", informerOpts)
+	if stateStore == nil {
+		stateStore = state.NewAnnotationStore(appClient)
+	}
+	if deliveryPipeline == nil {
+		deliveryPipeline = NewDeliveryPipeline(DeliveryOptions{}, metricsRegistry, nil)
+	}
 
 	return &notificationController{
 		subscriptions:   subscriptions,
+		policies:        policies,
 		appClient:       appClient,
 		appInformer:     appInformer,
 		appProjInformer: appProjInformer,
@@ -81,38 +117,34 @@ func NewController(client dynamic.Interface,
 		notifiers:       notifiers,
 		context:         context,
 		metricsRegistry: metricsRegistry,
+		digest:          newDigestBuffer(),
+		delivery:        deliveryPipeline,
+		recorder:        recorder,
+		state:           stateStore,
+		sharding:        sharding,
+		authors:         newCommitAuthors(argocdService),
 	}, nil
 }
 
-func newInformer(resClient dynamic.ResourceInterface, selector string) cache.SharedIndexInformer {
-	informer := cache.NewSharedIndexInformer(
-		&cache.ListWatch{
-			ListFunc: func(options v1.ListOptions) (object runtime.Object, err error) {
-				options.LabelSelector = selector
-				return resClient.List(options)
-			},
-			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
-				options.LabelSelector = selector
-				return resClient.Watch(options)
-			},
-		},
-		&unstructured.Unstructured{},
-		resyncPeriod,
-		cache.Indexers{},
-	)
-	return informer
-}
-
 type notificationController struct {
 	appClient       dynamic.ResourceInterface
 	appInformer     cache.SharedIndexInformer
 	appProjInformer cache.SharedIndexInformer
-	refreshQueue    workqueue.RateLimitingInterface
+	refreshQueue    *shardedQueue
 	triggers        map[string]triggers.Trigger
 	notifiers       map[string]notifiers.Notifier
 	context         map[string]string
 	subscriptions   settings.DefaultSubscriptions
+	policies        settings.RecipientPolicies
 	metricsRegistry *controllerRegistry
+	digest          *digestBuffer
+	delivery        *DeliveryPipeline
+	// recorder is used to emit application events about notifications delivery; optional
+	recorder record.EventRecorder
+	state    state.Store
+	sharding Sharding
+	// authors resolves the recipients of the commit authors
+	authors *commitAuthors
 }
 
 func (c *notificationController) Init(ctx context.Context) error {
@@ -130,127 +162,388 @@ func (c *notificationController) Run(ctx context.Context, processors int) {
 	defer c.refreshQueue.ShutDown()
 
 	log.Warn("Controller is running.")
-	for i := 0; i < processors; i++ {
+	for _, shard := range c.refreshQueue.start(processors) {
+		queue := shard
 		go wait.Until(func() {
-			for c.processQueueItem() {
+			for c.processQueueItem(ctx, queue) {
 			}
 		}, time.Second, ctx.Done())
 	}
+	go wait.Until(func() {
+		c.flushDigests(ctx)
+	}, digestFlushInterval, ctx.Done())
 	<-ctx.Done()
 	log.Warn("Controller has stopped.")
 }
 
-func (c *notificationController) getRecipients(app *unstructured.Unstructured, trigger string) map[string]bool {
-	recipients := make(map[string]bool)
-	for _, r := range c.subscriptions.GetRecipients(trigger, app.GetLabels()) {
-		recipients[r] = true
+func (c *notificationController) flushDigests(ctx context.Context) {
+	for recipient, entry := range c.digest.ready(time.Now()) {
+		primary, fallbacks, err := c.parseRecipientChain(recipient)
+		if err != nil {
+			log.Errorf("Failed to send digest to %s: %v", recipient, err)
+			continue
+		}
+		items := c.recordDigested(entry.items)
+		if len(items) == 0 {
+			continue
+		}
+		err = c.delivery.deliver(ctx, &delivery{
+			trigger:      digestTemplateName,
+			template:     digestTemplateName,
+			service:      primary.Service,
+			target:       primary.Target,
+			options:      primary.Options(),
+			fallbacks:    fallbacks,
+			notifiers:    c.notifiers,
+			notification: formatDigest(items),
+		})
+		if err != nil {
+			log.Errorf("Failed to send digest to %s: %v", recipient, err)
+			c.releaseDigested(items)
+			c.digest.add(recipient, entry.schedule, items...)
+		}
 	}
-	if annotations := app.GetAnnotations(); annotations != nil {
-		for _, recipient := range sharedrecipients.GetRecipientsFromAnnotations(annotations, trigger) {
-			recipients[recipient] = true
+}
+
+// recordDigested records the state of the notifications collected into the digest before it is sent, so the
+// notifications are not collected again. Notifications already sent or claimed by another controller replica are
+// dropped.
+func (c *notificationController) recordDigested(items []digestItem) []digestItem {
+	var res []digestItem
+	for _, item := range items {
+		if item.stateKey == "" {
+			res = append(res, item)
+			continue
+		}
+		app, err := c.getApp(item.appNamespace, item.appName)
+		if err == errAppNotManaged {
+			// the application is deleted, so there is no state to record
+			res = append(res, item)
+			continue
+		} else if err != nil {
+			log.Warnf("Failed to record the state of %s notification: %v", item.stateKey, err)
+			res = append(res, item)
+			continue
+		}
+		// the notification might have been sent directly once the schedule window has opened
+		if appState, err := c.state.Load(app, true); err != nil {
+			log.Warnf("Failed to load the state of %s notification: %v", item.stateKey, err)
+		} else if entry, ok := appState[item.stateKey]; ok && state.EntryMatches(entry, item.hash) {
+			continue
+		}
+		if claimed, err := c.claim(app, item.stateKey, item.hash); err != nil {
+			log.Warnf("Failed to claim %s notification: %v", item.stateKey, err)
+		} else if !claimed {
+			continue
+		}
+		if err := c.updateState(app, item.stateKey, state.Entry(time.Now(), item.hash)); err != nil {
+			log.Warnf("Failed to record the state of %s notification: %v", item.stateKey, err)
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+// releaseDigested removes the state of the notifications of the digest which is not sent
+func (c *notificationController) releaseDigested(items []digestItem) {
+	for _, item := range items {
+		if item.stateKey == "" {
+			continue
+		}
+		if err := c.ReleaseState(item.appNamespace, item.appName, item.stateKey); err != nil && err != errAppNotManaged {
+			log.Warnf("Failed to release the state of %s notification: %v", item.stateKey, err)
 		}
 	}
+}
+
+// parseRecipientChain returns the primary recipient and the fallback recipients of the
+// recipient chain in <service>:<target>|<service>:<target> format. The fallback recipients receive the notification
+// in order if delivery to the previous recipient fails.
+func (c *notificationController) parseRecipientChain(recipient string) (sharedrecipients.Recipient, []string, error) {
+	chain := sharedrecipients.SplitFailoverChain(recipient)
+	var primary sharedrecipients.Recipient
+	for i, item := range chain {
+		parsed, err := sharedrecipients.Parse(item)
+		if err != nil {
+			return sharedrecipients.Recipient{}, nil, err
+		}
+		if _, ok := c.notifiers[parsed.Service]; !ok {
+			return sharedrecipients.Recipient{}, nil, fmt.Errorf("%s is not valid recipient type.", parsed.Service)
+		}
+		if i == 0 {
+			primary = parsed
+		}
+	}
+	return primary, chain[1:], nil
+}
+
+// splitRecipient returns normalized service and target of the recipient in <service>:<target> format
+func splitRecipient(recipient string) (string, string, bool) {
+	parsed, err := sharedrecipients.Parse(recipient)
+	if err != nil {
+		return "", "", false
+	}
+	return parsed.Service, parsed.Target, true
+}
+
+func (c *notificationController) getRecipients(app *unstructured.Unstructured, trigger string) map[string]bool {
+	return settings.ResolveRecipients(app, c.getAppProj(app), trigger, c.subscriptions, c.policies)
+}
+
+func (c *notificationController) getAppProj(app *unstructured.Unstructured) *unstructured.Unstructured {
 	projName, ok, err := unstructured.NestedString(app.Object, "spec", "project")
 	if !ok || err != nil {
-		return recipients
+		return nil
 	}
 	projObj, ok, err := c.appProjInformer.GetIndexer().GetByKey(fmt.Sprintf("%s/%s", app.GetNamespace(), projName))
 	if !ok || err != nil {
-		return recipients
+		return nil
 	}
 	proj, ok := projObj.(*unstructured.Unstructured)
 	if !ok {
-		return recipients
-	}
-	if annotations := proj.GetAnnotations(); annotations != nil {
-		for _, recipient := range sharedrecipients.GetRecipientsFromAnnotations(annotations, trigger) {
-			recipients[recipient] = true
-		}
+		return nil
 	}
-	return recipients
+	return proj
 }
 
-func (c *notificationController) processApp(app *unstructured.Unstructured, logEntry *log.Entry) error {
+func (c *notificationController) processApp(ctx context.Context, app *unstructured.Unstructured, logEntry *log.Entry) error {
 	refreshed := false
-	annotations := app.GetAnnotations()
-	if annotations == nil {
-		annotations = make(map[string]string)
+	appState, err := c.state.Load(app, false)
+	if err != nil {
+		return err
 	}
+	loadedState := appState.Copy()
+	trackOutOfSync(app, appState)
 	for triggerKey, t := range c.triggers {
+		if et, ok := t.(triggers.EventTrigger); ok && et.GetEvent() != "" {
+			continue
+		}
+		correlationID := newCorrelationID()
+		logEntry := logEntry.WithFields(log.Fields{logFieldTrigger: triggerKey, logFieldCorrelationID: correlationID})
+		triggerCtx, span := tracer().Start(ctx, spanTriggerEvaluate, trace.WithAttributes(
+			attrTrigger.String(triggerKey), attrCorrelationID.String(correlationID)))
 		triggered, err := t.Triggered(app)
 		if err != nil {
 			logEntry.Debugf("Failed to execute condition of trigger %s: %v", triggerKey, err)
 		}
+		span.SetAttributes(attrTriggered.Bool(triggered))
+		endSpan(span, err)
 		recipients := c.getRecipients(app, triggerKey)
 		logEntry.Infof("Trigger %s result: %v", triggerKey, triggered)
 		c.metricsRegistry.IncTriggerEvaluationsCounter(triggerKey, triggered)
+		ot, hasOncePer := t.(triggers.OncePerTrigger)
+		hasOncePer = hasOncePer && ot.HasOncePer()
 		if !triggered {
-			for recipient := range recipients {
-				triggerAnnotation := sharedrecipients.FormatTriggerRecipientAnnotation(triggerKey, recipient)
-				delete(annotations, triggerAnnotation)
+			// the state of once per triggers is kept, so the notification is not sent again if the condition flips
+			if !hasOncePer {
+				for recipient := range recipients {
+					stateKey := state.Key(triggerKey, recipient)
+					if _, ok := appState[stateKey]; !ok {
+						continue
+					}
+					delete(appState, stateKey)
+					if err := c.releaseClaim(app, stateKey); err != nil {
+						return err
+					}
+				}
 			}
-			app.SetAnnotations(annotations)
 			continue
 		}
+		oncePer := ""
+		if hasOncePer {
+			if oncePer, err = ot.GetOncePer(app); err != nil {
+				logEntry.Debugf("Failed to evaluate oncePer expression of trigger %s: %v", triggerKey, err)
+			}
+		}
 
 		for recipient := range recipients {
-			triggerAnnotation := sharedrecipients.FormatTriggerRecipientAnnotation(triggerKey, recipient)
-			_, alreadyNotified := annotations[triggerAnnotation]
-			// informer might have stale data, so we cannot trust it and should reload app state to avoid sending notification twice
+			stateKey := state.Key(triggerKey, recipient)
+			hash := state.Hash(triggerKey, oncePer, recipient)
+			isNotified := func() bool {
+				entry, ok := appState[stateKey]
+				return ok && state.EntryMatches(entry, hash)
+			}
+			alreadyNotified := isNotified()
+			// store might have stale data, so we cannot trust it and should reload app state to avoid sending notification twice
 			if !alreadyNotified && !refreshed {
-				refreshedApp, err := c.appClient.Get(app.GetName(), v1.GetOptions{})
+				refreshedState, err := c.state.Load(app, true)
 				if err != nil {
 					return err
 				}
-				if refreshedApp.GetAnnotations() != nil {
-					for k, v := range refreshedApp.GetAnnotations() {
-						annotations[k] = v
-					}
+				for k, v := range refreshedState {
+					appState[k] = v
 				}
-				_, alreadyNotified = annotations[triggerAnnotation]
+				alreadyNotified = isNotified()
 
 				refreshed = true
 			}
+			logEntry := logEntry.WithField(logFieldRecipient, recipient)
 			if alreadyNotified {
 				logEntry.Infof("%s notification already sent", triggerKey)
 				continue // move to the next recipient
 			}
 			successful := true
 
-			parts := strings.Split(recipient, ":")
-			if len(parts) < 2 {
-				return fmt.Errorf("%s is not valid recipient. Expected recipient format is <type>:<name>", recipient)
+			resolved, err := c.authors.resolve(ctx, app, recipient)
+			if err != nil {
+				logEntry.Warnf("Failed to resolve commit author of recipient %s: %v", recipient, err)
+				continue
 			}
-			notifierType := parts[0]
-			notifier, ok := c.notifiers[notifierType]
-			if !ok {
-				return fmt.Errorf("%s is not valid recipient type.", notifierType)
+			primary, fallbacks, err := c.parseRecipientChain(resolved)
+			if err != nil {
+				return err
 			}
 
-			logEntry.Infof("Sending %s notification", triggerKey)
-			ctx := sharedrecipients.CopyStringMap(c.context)
-			ctx[notificationType] = notifierType
-			notification, err := t.FormatNotification(app, ctx)
+			schedule := c.subscriptions.GetSchedule(triggerKey, app, recipient)
+			if schedule != nil && !schedule.IsActive(time.Now()) && !schedule.Digest {
+				logEntry.Infof("%s notification to %s is postponed until the schedule window opens", triggerKey, recipient)
+				continue
+			}
+
+			templateCtx := c.templateContext(app.GetNamespace(), app.GetName(), triggerKey, primary.Service, resolved)
+			templateName := t.GetTemplateName()
+			renderCtx, span := tracer().Start(triggerCtx, spanTemplateRender, trace.WithAttributes(
+				attrTemplate.String(templateName), attrRecipient.String(recipient)))
+			notification, err := t.FormatNotification(app, templateCtx)
+			endSpan(span, err)
 			if err != nil {
+				logEntry.Errorf("Failed to render template %s: %v", templateName, err)
+				c.metricsRegistry.IncTemplateRenderErrorsCounter(templateName)
+				return err
+			}
+			if schedule != nil && !schedule.IsActive(time.Now()) {
+				// the state is recorded once the digest is sent, so the notification is not lost if the controller
+				// restarts before the schedule window opens
+				logEntry.Infof("%s notification to %s is added to the digest", triggerKey, recipient)
+				c.digest.add(resolved, *schedule, digestItem{
+					appName: app.GetName(), appNamespace: app.GetNamespace(), stateKey: stateKey, hash: hash,
+					notification: *notification,
+				})
+				continue
+			}
+			if claimed, err := c.claim(app, stateKey, hash); err != nil {
 				return err
+			} else if !claimed {
+				logEntry.Infof("%s notification is already sent by another controller replica", triggerKey)
+				appState[stateKey] = state.Entry(time.Now(), hash)
+				continue
 			}
-			if err = notifier.Send(*notification, parts[1]); err != nil {
+			logEntry.Infof("Sending %s notification", triggerKey)
+			err = c.delivery.deliver(ctx, &delivery{
+				appName:       app.GetName(),
+				appNamespace:  app.GetNamespace(),
+				stateKey:      stateKey,
+				trigger:       triggerKey,
+				template:      templateName,
+				service:       primary.Service,
+				target:        primary.Target,
+				options:       primary.Options(),
+				fallbacks:     fallbacks,
+				notifiers:     c.notifiers,
+				notification:  *notification,
+				correlationID: correlationID,
+				spanContext:   trace.SpanContextFromContext(renderCtx),
+				appRef:        appReference(app),
+				recorder:      c.recorder,
+			})
+			if err != nil {
 				logEntry.Errorf("Failed to notify recipient %s defined in app %s/%s: %v",
 					recipient, app.GetNamespace(), app.GetName(), err)
 				successful = false
-				c.metricsRegistry.IncDeliveriesCounter(t.GetTemplateName(), notifierType, false)
-			} else {
-				c.metricsRegistry.IncDeliveriesCounter(t.GetTemplateName(), notifierType, true)
+				if err := c.releaseClaim(app, stateKey); err != nil {
+					logEntry.Warnf("Failed to release the claim of %s notification: %v", triggerKey, err)
+				}
 			}
 
 			if successful {
 				logEntry.Debugf("Notification %s was sent", recipient)
-				annotations[triggerAnnotation] = time.Now().Format(time.RFC3339)
+				appState[stateKey] = state.Entry(time.Now(), hash)
 			}
 		}
 
 	}
-	app.SetAnnotations(annotations)
+	if reflect.DeepEqual(loadedState, appState) {
+		return nil
+	}
+	return c.state.Save(app, appState)
+}
+
+// trackOutOfSync records the time the application has become OutOfSync in the state and exposes it as the
+// outOfSyncSince application field. The time is kept while the sync status is Unknown and removed once the
+// application is synced.
+func trackOutOfSync(app *unstructured.Unstructured, appState state.State) {
+	status, _, _ := unstructured.NestedString(app.Object, "status", "sync", "status")
+	since, ok := appState[state.OutOfSyncSinceKey]
+	switch {
+	case status == "OutOfSync" && !ok:
+		since = time.Now().Format(time.RFC3339)
+		appState[state.OutOfSyncSinceKey] = since
+	case status == "Synced":
+		delete(appState, state.OutOfSyncSinceKey)
+		return
+	case !ok:
+		return
+	}
+	app.Object["outOfSyncSince"] = since
+}
+
+// claim claims the notification if the state store is shared by the controller replicas and returns false if the
+// notification is claimed by another replica
+func (c *notificationController) claim(app *unstructured.Unstructured, stateKey string, hash string) (bool, error) {
+	claimer, ok := c.state.(state.Claimer)
+	if !ok {
+		return true, nil
+	}
+	return claimer.Claim(app, stateKey, hash)
+}
+
+// ReleaseState removes the notification state entry and releases the claim of the notification which was not
+// delivered after it had been recorded as sent
+func (c *notificationController) ReleaseState(appNamespace string, appName string, stateKey string) error {
+	app, err := c.getApp(appNamespace, appName)
+	if err != nil {
+		return err
+	}
+	if err = c.releaseClaim(app, stateKey); err != nil {
+		return err
+	}
+	return c.updateState(app, stateKey, "")
+}
+
+// updateState sets the application state entry, or removes it if the value is empty, and persists the state
+func (c *notificationController) updateState(app *unstructured.Unstructured, stateKey string, value string) error {
+	// informer might have stale data, so the state entry is updated in the latest application
+	latest, err := c.appClient.Get(app.GetName(), v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	appState, err := c.state.Load(latest, true)
+	if err != nil {
+		return err
+	}
+	if current, ok := appState[stateKey]; (value == "" && !ok) || (ok && current == value) {
+		return nil
+	}
+	if value == "" {
+		delete(appState, stateKey)
+	} else {
+		appState[stateKey] = value
+	}
+	latestCopy := latest.DeepCopy()
+	if err = c.state.Save(latestCopy, appState); err != nil {
+		return err
+	}
+	if changes := annotationChanges(latest.GetAnnotations(), latestCopy.GetAnnotations()); len(changes) > 0 {
+		return c.patchAnnotations(latest, changes)
+	}
+	return nil
+}
+
+// releaseClaim releases the notification claim, so the notification is sent again once the trigger condition holds
+func (c *notificationController) releaseClaim(app *unstructured.Unstructured, stateKey string) error {
+	if claimer, ok := c.state.(state.Claimer); ok {
+		return claimer.Release(app, stateKey)
+	}
 	return nil
 }
 
@@ -300,8 +593,8 @@ func (c *notificationController) isAppSyncStatusRefreshed(app *unstructured.Unst
 	return true
 }
 
-func (c *notificationController) processQueueItem() (processNext bool) {
-	key, shutdown := c.refreshQueue.Get()
+func (c *notificationController) processQueueItem(ctx context.Context, queue workqueue.RateLimitingInterface) (processNext bool) {
+	key, shutdown := queue.Get()
 	if shutdown {
 		processNext = false
 		return
@@ -311,7 +604,7 @@ func (c *notificationController) processQueueItem() (processNext bool) {
 		if r := recover(); r != nil {
 			log.Errorf("Recovered from panic: %+v\n%s", r, debug.Stack())
 		}
-		c.refreshQueue.Done(key)
+		queue.Done(key)
 	}()
 
 	obj, exists, err := c.appInformer.GetIndexer().GetByKey(key.(string))
@@ -329,37 +622,33 @@ func (c *notificationController) processQueueItem() (processNext bool) {
 		return
 	}
 	appCopy := app.DeepCopy()
+	ctx, span := tracer().Start(ctx, spanReconcile, trace.WithAttributes(attrApp.String(key.(string))))
+	defer func() {
+		endSpan(span, err)
+	}()
 	logEntry := log.WithField("app", key)
 	logEntry.Info("Start processing")
 	if refreshed := c.isAppSyncStatusRefreshed(appCopy, logEntry); !refreshed {
 		logEntry.Info("Processing skipped, sync status out of date")
 		return
 	}
-	err = c.processApp(appCopy, logEntry)
+	err = c.processApp(ctx, appCopy, logEntry)
 	if err != nil {
 		logEntry.Errorf("Failed to process: %v", err)
 		return
 	}
-	if !reflect.DeepEqual(app.GetAnnotations(), appCopy.GetAnnotations()) {
-		annotationsPatch := make(map[string]interface{})
-		for k, v := range appCopy.GetAnnotations() {
-			annotationsPatch[k] = v
-		}
-		for k := range app.GetAnnotations() {
-			if _, ok = appCopy.GetAnnotations()[k]; !ok {
-				annotationsPatch[k] = nil
-			}
-		}
-
-		patchData, err := json.Marshal(map[string]map[string]interface{}{
-			"metadata": {"annotations": annotationsPatch},
-		})
-		if err != nil {
-			logEntry.Errorf("Failed to marshal app patch: %v", err)
+	statusUpdate, hasStatus := c.delivery.statuses.take(key.(string))
+	if hasStatus {
+		if err = setAppStatus(appCopy, statusUpdate); err != nil {
+			logEntry.Errorf("Failed to update notification status: %v", err)
 			return
 		}
-		_, err = c.appClient.Patch(app.GetName(), types.MergePatchType, patchData, v1.PatchOptions{})
-		if err != nil {
+	}
+	if changes := annotationChanges(app.GetAnnotations(), appCopy.GetAnnotations()); len(changes) > 0 {
+		if err = c.patchAnnotations(app, changes); err != nil {
+			if hasStatus {
+				c.delivery.statuses.restore(key.(string), statusUpdate)
+			}
 			logEntry.Errorf("Failed to patch app: %v", err)
 			return
 		}
@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -19,8 +22,11 @@ import (
 
 	"github.com/argoproj-labs/argocd-notifications/notifiers"
 	notifiermocks "github.com/argoproj-labs/argocd-notifications/notifiers/mocks"
+	"github.com/argoproj-labs/argocd-notifications/shared/clients"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
 	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
 	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/state"
 	. "github.com/argoproj-labs/argocd-notifications/testing"
 	"github.com/argoproj-labs/argocd-notifications/triggers"
 	triggermocks "github.com/argoproj-labs/argocd-notifications/triggers/mocks"
@@ -45,8 +51,15 @@ func newController(t *testing.T, ctx context.Context, client dynamic.Interface,
 		map[string]notifiers.Notifier{"mock": notifier},
 		map[string]string{},
 		subscriptions,
+		nil,
 		"",
-		NewMetricsRegistry())
+		Sharding{},
+		InformerOptions{ResyncPeriod: DefaultResyncPeriod},
+		NewMetricsRegistry(),
+		nil,
+		nil,
+		nil,
+		nil)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -70,14 +83,92 @@ func TestSendsNotificationIfTriggered(t *testing.T) {
 	trigger.EXPECT().Triggered(app).Return(true, nil)
 	trigger.EXPECT().FormatNotification(app, map[string]string{"notificationType": "mock"}).Return(
 		&notifiers.Notification{Title: "title", Body: "body"}, nil)
-	notifier.EXPECT().Send(notifiers.Notification{Title: "title", Body: "body"}, "recipient").Return(nil)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "title", Body: "body"}, "recipient").Return(nil)
 
-	err = ctrl.processApp(app, logEntry)
+	err = ctrl.processApp(context.Background(), app, logEntry)
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, app.GetAnnotations()[fmt.Sprintf("mock.mock.recipient.%s", recipients.AnnotationPostfix)])
 }
 
+func TestReleasesStateOfFailedDeferredNotification(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "mock:recipient",
+	}))
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), app)
+	ctrl, trigger, notifier, err := newController(t, ctx, client)
+	assert.NoError(t, err)
+	ctrl.delivery = NewDeliveryPipeline(DeliveryOptions{MaxAttempts: 1}, NewMetricsRegistry(), nil)
+	ctrl.delivery.SetControllers(func() []NotificationController { return []NotificationController{ctrl} })
+	ctrl.delivery.SetRateLimits([]settings.RateLimit{{Notifier: "mock", Limit: 1, Period: "200ms"}})
+	go ctrl.delivery.Run(ctx)
+
+	notification := notifiers.Notification{Title: "title", Body: "body"}
+	trigger.EXPECT().GetTemplateName().Return("test")
+	trigger.EXPECT().Triggered(app).Return(true, nil)
+	trigger.EXPECT().FormatNotification(app, map[string]string{"notificationType": "mock"}).Return(&notification, nil)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "earlier"}, "recipient").Return(nil)
+	notifier.EXPECT().Send(gomock.Any(), notification, "recipient").Return(errors.New("fail"))
+
+	// the earlier notification exhausts the rate limit, so the application notification is delayed
+	assert.NoError(t, ctrl.delivery.deliver(ctx, &delivery{
+		service: "mock", target: "recipient", notifiers: ctrl.notifiers, notification: notifiers.Notification{Title: "earlier"},
+	}))
+	appCopy := app.DeepCopy()
+	err = ctrl.processApp(ctx, appCopy, logEntry)
+	assert.NoError(t, err)
+	stateKey := fmt.Sprintf("mock.mock.recipient.%s", recipients.AnnotationPostfix)
+	assert.NoError(t, ctrl.patchAnnotations(app, annotationChanges(app.GetAnnotations(), appCopy.GetAnnotations())))
+	appClient := clients.NewAppClient(client, TestNamespace)
+	persisted, err := appClient.Get("test", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, persisted.GetAnnotations()[stateKey])
+
+	assert.Eventually(t, func() bool {
+		updated, err := appClient.Get("test", metav1.GetOptions{})
+		if err != nil {
+			return false
+		}
+		_, ok := updated.GetAnnotations()[stateKey]
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRecordsNotificationHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "mock:recipient",
+	}))
+	ctrl, trigger, notifier, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+	store := history.NewMemoryStore(0)
+	ctrl.delivery = NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), store)
+
+	notification := notifiers.Notification{Title: "title", Body: "body"}
+	trigger.EXPECT().GetTemplateName().Return("test")
+	trigger.EXPECT().Triggered(app).Return(true, nil)
+	trigger.EXPECT().FormatNotification(app, map[string]string{"notificationType": "mock"}).Return(&notification, nil)
+	notifier.EXPECT().Send(gomock.Any(), notification, "recipient").Return(errors.New("fail"))
+
+	err = ctrl.processApp(context.Background(), app, logEntry)
+	assert.NoError(t, err)
+
+	events, err := store.List(history.Filter{App: "test"})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "mock", events[0].Trigger)
+		assert.Equal(t, "test", events[0].Template)
+		assert.Equal(t, "mock:recipient", events[0].Recipient)
+		assert.Equal(t, "mock", events[0].Notifier)
+		assert.Equal(t, history.HashNotification(notification), events[0].BodyHash)
+		assert.Equal(t, history.StatusFailed, events[0].Status)
+		assert.Equal(t, "fail", events[0].Error)
+	}
+}
+
 func TestDoesNotSendNotificationIfAnnotationPresent(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -90,9 +181,45 @@ func TestDoesNotSendNotificationIfAnnotationPresent(t *testing.T) {
 
 	trigger.EXPECT().Triggered(app).Return(true, nil)
 
-	err = ctrl.processApp(app, logEntry)
+	err = ctrl.processApp(context.Background(), app, logEntry)
+
+	assert.NoError(t, err)
+}
+
+// claimedStore is the store shared with another controller replica which has already claimed every notification
+type claimedStore struct {
+	state.Store
+}
+
+func (s claimedStore) Claim(_ *unstructured.Unstructured, _ string, _ string) (bool, error) {
+	return false, nil
+}
+
+func (s claimedStore) Release(_ *unstructured.Unstructured, _ string) error {
+	return nil
+}
+
+func TestDoesNotSendNotificationClaimedByAnotherReplica(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "mock:recipient",
+	}))
+	ctrl, trigger, _, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+	store := claimedStore{Store: state.NewMemoryStore()}
+	ctrl.state = store
+
+	trigger.EXPECT().GetTemplateName().Return("test")
+	trigger.EXPECT().Triggered(app).Return(true, nil)
+	trigger.EXPECT().FormatNotification(app, gomock.Any()).Return(&notifiers.Notification{Title: "title"}, nil)
+
+	err = ctrl.processApp(context.Background(), app, logEntry)
 
 	assert.NoError(t, err)
+	appState, err := store.Load(app, true)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, appState[state.Key("mock", "mock:recipient")])
 }
 
 func TestRemovesAnnotationIfNoTrigger(t *testing.T) {
@@ -107,12 +234,102 @@ func TestRemovesAnnotationIfNoTrigger(t *testing.T) {
 
 	trigger.EXPECT().Triggered(app).Return(false, nil)
 
-	err = ctrl.processApp(app, logEntry)
+	err = ctrl.processApp(context.Background(), app, logEntry)
 
 	assert.NoError(t, err)
 	assert.Empty(t, app.GetAnnotations()[fmt.Sprintf("mock.mock.recipient.%s", recipients.AnnotationPostfix)])
 }
 
+func TestTracksOutOfSyncSince(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	since := time.Now().Add(-24 * time.Hour).Format(time.RFC3339)
+	app := NewApp("test", WithSyncStatus("OutOfSync"))
+	ctrl, trigger, _, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+	trigger.EXPECT().Triggered(app).Return(false, nil).AnyTimes()
+
+	err = ctrl.processApp(context.Background(), app, logEntry)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, app.GetAnnotations()[state.OutOfSyncSinceKey])
+	assert.Equal(t, app.GetAnnotations()[state.OutOfSyncSinceKey], app.Object["outOfSyncSince"])
+
+	app.SetAnnotations(map[string]string{state.OutOfSyncSinceKey: since})
+	err = ctrl.processApp(context.Background(), app, logEntry)
+	assert.NoError(t, err)
+	assert.Equal(t, since, app.Object["outOfSyncSince"])
+
+	delete(app.Object, "outOfSyncSince")
+	_ = unstructured.SetNestedField(app.Object, "Synced", "status", "sync", "status")
+	err = ctrl.processApp(context.Background(), app, logEntry)
+	assert.NoError(t, err)
+	assert.NotContains(t, app.GetAnnotations(), state.OutOfSyncSinceKey)
+	assert.NotContains(t, app.Object, "outOfSyncSince")
+}
+
+func TestDoesNotResendOncePerNotification(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "mock:recipient",
+	}), WithLabels(map[string]string{"healthy": "true", "revision": "1"}))
+	ctrl, _, notifier, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctrl.triggers, err = triggers.GetTriggers([]triggers.NotificationTemplate{{
+		Name:         "deployed",
+		Notification: notifiers.Notification{Title: "{{.app.metadata.labels.revision}} is deployed"},
+	}}, []triggers.NotificationTrigger{{
+		Name:      "on-deployed",
+		Template:  "deployed",
+		Condition: "app.metadata.labels.healthy == 'true'",
+		OncePer:   "app.metadata.labels.revision",
+	}}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{
+		Title: "1 is deployed", Webhook: map[string]notifiers.WebhookNotification{},
+	}, "recipient").Return(nil).Times(1)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{
+		Title: "2 is deployed", Webhook: map[string]notifiers.WebhookNotification{},
+	}, "recipient").Return(nil).Times(1)
+
+	for _, labels := range []map[string]string{
+		{"healthy": "true", "revision": "1"},
+		{"healthy": "false", "revision": "1"},
+		{"healthy": "true", "revision": "1"},
+		{"healthy": "true", "revision": "2"},
+	} {
+		app.SetLabels(labels)
+		assert.NoError(t, ctrl.processApp(context.Background(), app, logEntry))
+	}
+}
+
+func TestParseRecipientChain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, _, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	primary, fallbacks, err := ctrl.parseRecipientChain("mock:primary?thread=true|mock:secondary|mock:sre@corp")
+	assert.NoError(t, err)
+	assert.Equal(t, "mock", primary.Service)
+	assert.Equal(t, "primary", primary.Target)
+	assert.Equal(t, map[string]string{"thread": "true"}, primary.Options())
+	assert.Equal(t, []string{"mock:secondary", "mock:sre@corp"}, fallbacks)
+
+	_, _, err = ctrl.parseRecipientChain("mock:primary|unknown:secondary")
+	assert.Error(t, err)
+	primary, fallbacks, err = ctrl.parseRecipientChain("mock:tag1|tag2")
+	assert.NoError(t, err)
+	assert.Equal(t, "tag1|tag2", primary.Target)
+	assert.Empty(t, fallbacks)
+}
+
 func TestGetRecipients(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -144,6 +361,25 @@ func TestGetRecipients_HasDefaultSubscriptions(t *testing.T) {
 	assert.Equal(t, map[string]bool{"slack:test1": true, "slack:test2": true}, recipients)
 }
 
+func TestGetRecipients_FiltersByPolicies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithProject("team-a"), WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "slack:team-a-alerts,slack:team-b-alerts",
+	}))
+	ctrl, _, _, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+	cfg, err := settings.ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+policies:
+- projects: [team-a]
+  recipients: ["slack:team-a-*"]`}})
+	assert.NoError(t, err)
+	ctrl.policies = cfg.Policies
+
+	recipients := ctrl.getRecipients(app, "on-app-health-degraded")
+	assert.Equal(t, map[string]bool{"slack:team-a-alerts": true}, recipients)
+}
+
 func TestUpdatedAnnotationsSavedAsPatch(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -242,3 +478,190 @@ var testsAppSyncStatusRefreshed = map[string]struct {
 		},
 	}, result: false},
 }
+
+func TestPostponesNotificationOutsideOfSchedule(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test")
+	ctrl, trigger, _, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app), settings.Subscription{
+		Recipients: []string{"mock:recipient"},
+		Selector:   labels.NewSelector(),
+		Schedule:   &settings.Schedule{Start: "00:00", End: "00:00"},
+	})
+	assert.NoError(t, err)
+
+	trigger.EXPECT().Triggered(app).Return(true, nil)
+
+	err = ctrl.processApp(context.Background(), app, logEntry)
+
+	assert.NoError(t, err)
+	assert.Empty(t, app.GetAnnotations()[fmt.Sprintf("mock.mock.recipient.%s", recipients.AnnotationPostfix)])
+}
+
+func TestAddsNotificationToDigestOutsideOfSchedule(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test")
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), app)
+	ctrl, trigger, notifier, err := newController(t, ctx, client, settings.Subscription{
+		Recipients: []string{"mock:recipient"},
+		Selector:   labels.NewSelector(),
+		Schedule:   &settings.Schedule{Start: "00:00", End: "00:00", Digest: true},
+	})
+	assert.NoError(t, err)
+
+	trigger.EXPECT().GetTemplateName().Return("test").Times(2)
+	trigger.EXPECT().Triggered(app).Return(true, nil).Times(2)
+	trigger.EXPECT().FormatNotification(app, map[string]string{"notificationType": "mock"}).Return(
+		&notifiers.Notification{Title: "title", Body: "body"}, nil).Times(2)
+
+	// the state is recorded once the digest is sent, so the repeated reconciliation does not duplicate the notification
+	stateKey := fmt.Sprintf("mock.mock.recipient.%s", recipients.AnnotationPostfix)
+	for i := 0; i < 2; i++ {
+		err = ctrl.processApp(context.Background(), app, logEntry)
+		assert.NoError(t, err)
+		assert.Empty(t, app.GetAnnotations()[stateKey])
+	}
+
+	ctrl.digest.entries["mock:recipient"].schedule = settings.Schedule{}
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{
+		Title: "1 notification(s) suppressed during quiet hours",
+		Body:  "title\nbody",
+	}, "recipient").Return(nil)
+
+	ctrl.flushDigests(ctx)
+
+	assert.Empty(t, ctrl.digest.entries)
+	updated, err := clients.NewAppClient(client, TestNamespace).Get("test", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, updated.GetAnnotations()[stateKey])
+}
+
+func TestSkipsDigestedNotificationSentDirectly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "mock:recipient",
+	}))
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), app)
+	ctrl, trigger, notifier, err := newController(t, ctx, client)
+	assert.NoError(t, err)
+	stateKey := fmt.Sprintf("mock.mock.recipient.%s", recipients.AnnotationPostfix)
+	// the notification collected during quiet hours is still in the digest once the schedule window opens
+	ctrl.digest.add("mock:recipient", settings.Schedule{}, digestItem{
+		appName: "test", appNamespace: TestNamespace, stateKey: stateKey, hash: state.Hash("mock", "", "mock:recipient"),
+		notification: notifiers.Notification{Title: "title", Body: "body"},
+	})
+
+	notification := notifiers.Notification{Title: "title", Body: "body"}
+	trigger.EXPECT().GetTemplateName().Return("test")
+	trigger.EXPECT().Triggered(app).Return(true, nil)
+	trigger.EXPECT().FormatNotification(app, map[string]string{"notificationType": "mock"}).Return(&notification, nil)
+	notifier.EXPECT().Send(gomock.Any(), notification, "recipient").Return(nil).Times(1)
+
+	appCopy := app.DeepCopy()
+	assert.NoError(t, ctrl.processApp(ctx, appCopy, logEntry))
+	assert.NoError(t, ctrl.patchAnnotations(app, annotationChanges(app.GetAnnotations(), appCopy.GetAnnotations())))
+
+	ctrl.flushDigests(ctx)
+
+	assert.Empty(t, ctrl.digest.entries)
+}
+
+func TestReleasesStateOfFailedDigest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test")
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), app)
+	ctrl, _, notifier, err := newController(t, ctx, client)
+	assert.NoError(t, err)
+	stateKey := fmt.Sprintf("mock.mock.recipient.%s", recipients.AnnotationPostfix)
+	ctrl.digest.add("mock:recipient", settings.Schedule{}, digestItem{
+		appName: "test", appNamespace: TestNamespace, stateKey: stateKey, hash: "abc",
+		notification: notifiers.Notification{Title: "title"},
+	})
+	notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "recipient").Return(errors.New("fail"))
+
+	ctrl.flushDigests(ctx)
+
+	if assert.Contains(t, ctrl.digest.entries, "mock:recipient") {
+		assert.Len(t, ctrl.digest.entries["mock:recipient"].items, 1)
+	}
+	updated, err := clients.NewAppClient(client, TestNamespace).Get("test", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, updated.GetAnnotations(), stateKey)
+}
+
+func TestGetRecipients_RendersRecipientTemplates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithLabels(map[string]string{"team": "payments"}), WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "slack:{{.app.metadata.labels.team}}-deploys, slack:{{.app.metadata.labels.missing}}",
+	}))
+	ctrl, _, _, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+
+	recipients := ctrl.getRecipients(app, "on-app-health-degraded")
+	assert.Equal(t, map[string]bool{"slack:payments-deploys": true}, recipients)
+}
+
+func TestGetRecipients_ExcludesUnsubscribedRecipients(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithProject("default"), WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation:                                         "slack:test1",
+		recipients.UnsubscribeAnnotationPrefix + "on-app-health-degraded.slack": "noisy-channel",
+	}))
+	appProj := NewProject("default", WithAnnotations(map[string]string{
+		recipients.UnsubscribeAnnotationPrefix + "slack": "test1",
+	}))
+	ctrl, _, _, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app, appProj), settings.Subscription{
+		Recipients: []string{"slack:noisy-channel", "slack:test2"}, Selector: labels.NewSelector()})
+	assert.NoError(t, err)
+
+	recipients := ctrl.getRecipients(app, "on-app-health-degraded")
+	assert.Equal(t, map[string]bool{"slack:test2": true}, recipients)
+}
+
+func TestWritesAppStatusAnnotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "mock:recipient",
+	}))
+
+	patchCh := make(chan []byte, 1)
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), app)
+	client.PrependReactor("patch", "*", func(action kubetesting.Action) (handled bool, ret runtime.Object, err error) {
+		patchCh <- action.(kubetesting.PatchAction).GetPatch()
+		cancel()
+		return true, nil, nil
+	})
+	ctrl, trigger, notifier, err := newController(t, ctx, client)
+	assert.NoError(t, err)
+	ctrl.delivery = NewDeliveryPipeline(DeliveryOptions{AppStatus: true}, ctrl.metricsRegistry, nil)
+
+	trigger.EXPECT().GetTemplateName().Return("test").AnyTimes()
+	trigger.EXPECT().Triggered(gomock.Any()).Return(true, nil).AnyTimes()
+	trigger.EXPECT().FormatNotification(gomock.Any(), gomock.Any()).Return(&notifiers.Notification{Title: "title"}, nil).AnyTimes()
+	notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "recipient").Return(errors.New("fail")).AnyTimes()
+
+	go ctrl.Run(ctx, 1)
+
+	select {
+	case <-time.After(time.Second * 60):
+		t.Error("application was not patched")
+	case patchData := <-patchCh:
+		patch := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(patchData, &patch))
+		val, _, err := unstructured.NestedString(patch, "metadata", "annotations", AppStatusAnnotation)
+		assert.NoError(t, err)
+		var status AppNotificationStatus
+		assert.NoError(t, json.Unmarshal([]byte(val), &status))
+		assert.Equal(t, "mock", status.LastTrigger)
+		assert.Equal(t, "mock:recipient", status.LastRecipient)
+		assert.Equal(t, history.StatusFailed, status.LastResult)
+		assert.Equal(t, "fail", status.LastError)
+		assert.Equal(t, 1, status.Failures)
+	}
+}
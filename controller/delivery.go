@@ -0,0 +1,872 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/flood"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/queue"
+	"github.com/argoproj-labs/argocd-notifications/shared/ratelimit"
+	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/silences"
+)
+
+const (
+	// OrderAllNotifiers is the DeliveryOptions.OrderedNotifiers key that enables ordering of all notifiers
+	OrderAllNotifiers = "*"
+
+	notifierErrorTimeout = "timeout"
+	notifierErrorAPI     = "api"
+)
+
+// DeliveryOptions configures retries of the failed notifications
+type DeliveryOptions struct {
+	// MaxAttempts is the number of delivery attempts. If it is 1 or less the failed notification is retried during
+	// the next application reconciliation.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry; the delay is doubled after every attempt
+	InitialBackoff time.Duration
+	// MaxBackoff limits the delay between retries
+	MaxBackoff time.Duration
+	// DeadLetterRecipient receives notifications which are not delivered after MaxAttempts attempts; optional
+	DeadLetterRecipient string
+	// Queue persists notifications waiting for retry; optional
+	Queue queue.Queue
+	// SendTimeout limits the duration of a notifier call; disabled if zero
+	SendTimeout time.Duration
+	// NotifierTimeouts overrides SendTimeout for the specific notifier types
+	NotifierTimeouts map[string]time.Duration
+	// NotifierMaxConcurrent limits the number of the concurrent calls of the specific notifier types; the calls
+	// exceeding the limit wait until the earlier calls return
+	NotifierMaxConcurrent map[string]int
+	// RateLimitBuckets are the rate limit buckets shared by the controller replicas; the rate limits are enforced
+	// by every replica separately if nil
+	RateLimitBuckets ratelimit.Buckets
+	// CircuitBreakerThreshold is the number of consecutive failures which open the notifier circuit breaker; the
+	// circuit breaker is disabled if zero
+	CircuitBreakerThreshold int
+	// CircuitBreakerCooldown is the delay before the open circuit breaker lets a trial notification through
+	CircuitBreakerCooldown time.Duration
+	// OrderedNotifiers lists notifier types which receive notifications of the same application and recipient in
+	// the order the notifications were triggered, even if earlier notifications are retried or delayed by rate
+	// limits; OrderAllNotifiers enables ordering of all notifiers.
+	OrderedNotifiers map[string]bool
+	// DryRun disables sending notifications: the pipeline logs and records notifications instead of delivering them
+	DryRun bool
+	// Silences finds silences that suppress notifications; optional
+	Silences silences.Matcher
+	// Observer is notified about the outcome of every delivery attempt; optional
+	Observer DeliveryObserver
+	// FloodThreshold is the max number of notifications within the FloodWindow. The delivery is paused until it is
+	// explicitly resumed once the threshold is exceeded; the flood protection is disabled if zero.
+	FloodThreshold int
+	// FloodWindow is the duration of the window which limits the number of notifications
+	FloodWindow time.Duration
+	// FloodAlertRecipient receives the single alert once the delivery is paused; optional
+	FloodAlertRecipient string
+	// FloodStore is the flood protection state shared by the controller replicas; every replica counts its own
+	// notifications if nil
+	FloodStore flood.Store
+	// AppStatus enables the notification summary of every application in the AppStatusAnnotation annotation
+	AppStatus bool
+	// AckLinks signs the acknowledgement links of the ackURL template context variable; the links are disabled if nil
+	AckLinks *AckLinks
+}
+
+// DeliveryObserver is notified about the outcome of the delivery attempts, e.g. to compare the deliveries of the
+// applications using different configuration versions
+type DeliveryObserver interface {
+	ObserveDelivery(appNamespace string, appName string, service string, err error)
+}
+
+type delivery struct {
+	// id is assigned when delivery is scheduled for retry
+	id           string
+	appName      string
+	appNamespace string
+	// stateKey is the application state entry recorded once the notification is handed over to the pipeline; the
+	// entry is removed if the notification is not delivered after all attempts
+	stateKey string
+	trigger  string
+	template string
+	service  string
+	target   string
+	// notifiers used to send notification; the pipeline notifiers are used if nil
+	notifiers    map[string]notifiers.Notifier
+	notification notifiers.Notification
+	attempts     int
+	lastErr      error
+	// correlationID ties together log entries of the notification
+	correlationID string
+	// spanContext is the parent of the notifier send spans
+	spanContext trace.SpanContext
+	// appRef and recorder are used to report the delivery outcome using application events; optional
+	appRef   *corev1.ObjectReference
+	recorder record.EventRecorder
+	// rateLimited is true if rate limit tokens were already reserved for the delivery
+	rateLimited bool
+	// summarized holds notifications collapsed into the delivery by the rate limit
+	summarized []notifiers.Notification
+	// sequence orders deliveries of the same application and recipient if ordering is enabled
+	sequence int64
+	// waiting is true if the delivery waits for the earlier deliveries of the same application and recipient
+	waiting bool
+	// fallbacks are the next recipients of the failover chain in <service>:<target> format
+	fallbacks []string
+	// options are the recipient parameters passed to the notifier, e.g. thread=true of slack:deploys?thread=true
+	options notifiers.RecipientOptions
+	// batched holds notifications combined into the delivery by the notifier batching
+	batched []notifiers.Notification
+	// emailDigested holds notifications collected into the delivery by the email digest
+	emailDigested []emailDigestItem
+	// escalated is true if the delivery notifies the recipient of the escalation tier
+	escalated bool
+}
+
+func (d *delivery) recipient() string {
+	return d.service + ":" + d.target
+}
+
+// sendOptions returns the recipient options of the notifier call. The group key of the application notification is
+// the application key unless the recipient specifies it.
+func (d *delivery) sendOptions() notifiers.RecipientOptions {
+	if d.appName == "" || d.options.Get(notifiers.OptionGroupKey) != "" {
+		return d.options
+	}
+	res := notifiers.RecipientOptions{notifiers.OptionGroupKey: d.appNamespace + "/" + d.appName}
+	for k, v := range d.options {
+		res[k] = v
+	}
+	return res
+}
+
+func (d *delivery) toQueueItem(nextAttempt time.Time) queue.Item {
+	item := queue.Item{
+		ID:            d.id,
+		App:           d.appName,
+		AppNamespace:  d.appNamespace,
+		StateKey:      d.stateKey,
+		Trigger:       d.trigger,
+		Template:      d.template,
+		Service:       d.service,
+		Target:        d.target,
+		Notification:  d.notification,
+		Attempts:      d.attempts,
+		NextAttempt:   nextAttempt,
+		CorrelationID: d.correlationID,
+		Sequence:      d.sequence,
+		Fallbacks:     d.fallbacks,
+		Options:       d.options,
+	}
+	if d.lastErr != nil {
+		item.LastError = d.lastErr.Error()
+	}
+	return item
+}
+
+func fromQueueItem(item queue.Item) *delivery {
+	d := &delivery{
+		id:            item.ID,
+		appName:       item.App,
+		appNamespace:  item.AppNamespace,
+		stateKey:      item.StateKey,
+		trigger:       item.Trigger,
+		template:      item.Template,
+		service:       item.Service,
+		target:        item.Target,
+		notification:  item.Notification,
+		attempts:      item.Attempts,
+		correlationID: item.CorrelationID,
+		sequence:      item.Sequence,
+		fallbacks:     item.Fallbacks,
+		options:       item.Options,
+	}
+	if item.LastError != "" {
+		d.lastErr = errors.New(item.LastError)
+	}
+	return d
+}
+
+// DeliveryPipeline sends notifications, records delivery attempts and retries failed deliveries using exponential
+// backoff with jitter. The notifications which cannot be delivered after the max attempts are routed to the dead letter
+// recipient. Notifications exceeding the rate limits are delayed or collapsed into summary, notifications of the
+// batched recipients are combined and sent once per batch interval, notifications of the email digest recipients are
+// sent as a single summary email on the digest schedule. Every notifier call is limited by the send timeout
+// and guarded by the circuit breaker, so the unresponsive service does not stall reconciliation. Notifications of the
+// ordered notifiers wait until the earlier notifications of the same application and recipient are delivered.
+// Notifications of the escalation policy triggers are escalated to the next recipient tier unless they are
+// acknowledged in time. The pipeline outlives controller restarts caused by configuration changes.
+type DeliveryPipeline struct {
+	opts          DeliveryOptions
+	metrics       *controllerRegistry
+	history       history.Store
+	queue         workqueue.DelayingInterface
+	breaker       *circuitBreaker
+	limiter       *rateLimiter
+	batcher       *batcher
+	emailDigester *emailDigester
+	escalator     *escalator
+	flood         *floodGuard
+	jitter        func() float64
+	// statuses is nil unless the application status annotation is enabled
+	statuses *appStatuses
+	// slots limit the concurrent calls of the notifiers with the max concurrency
+	slots map[string]chan struct{}
+
+	lock         sync.Mutex
+	notifiers    map[string]notifiers.Notifier
+	controllers  func() []NotificationController
+	summaries    map[string]*delivery
+	batches      map[string]*delivery
+	emailDigests map[string]*delivery
+	escalations  map[string]*escalation
+	sequences    map[string]int64
+	backlogs     map[string][]*delivery
+	restore      sync.Once
+}
+
+// NewDeliveryPipeline returns new delivery pipeline. The history store is optional.
+func NewDeliveryPipeline(opts DeliveryOptions, metrics *controllerRegistry, historyStore history.Store) *DeliveryPipeline {
+	var statuses *appStatuses
+	if opts.AppStatus && !opts.DryRun {
+		statuses = newAppStatuses()
+	}
+	slots := map[string]chan struct{}{}
+	for service, maxConcurrent := range opts.NotifierMaxConcurrent {
+		if maxConcurrent > 0 {
+			slots[service] = make(chan struct{}, maxConcurrent)
+		}
+	}
+	return &DeliveryPipeline{
+		opts:          opts,
+		metrics:       metrics,
+		history:       historyStore,
+		queue:         workqueue.NewDelayingQueue(),
+		breaker:       newCircuitBreaker(opts.CircuitBreakerThreshold, opts.CircuitBreakerCooldown),
+		limiter:       newRateLimiter(opts.RateLimitBuckets),
+		slots:         slots,
+		batcher:       &batcher{},
+		emailDigester: &emailDigester{},
+		escalator:     &escalator{},
+		flood:         newFloodGuard(opts.FloodThreshold, opts.FloodWindow, opts.FloodStore),
+		jitter:        rand.Float64,
+
+		statuses:     statuses,
+		summaries:    map[string]*delivery{},
+		batches:      map[string]*delivery{},
+		emailDigests: map[string]*delivery{},
+		escalations:  map[string]*escalation{},
+		sequences:    map[string]int64{},
+		backlogs:     map[string][]*delivery{},
+	}
+}
+
+// SetNotifiers updates notifiers which are used to retry deliveries restored from the queue. The persisted
+// deliveries and escalations are restored when notifiers are set for the first time.
+func (p *DeliveryPipeline) SetNotifiers(notifiers map[string]notifiers.Notifier) {
+	p.lock.Lock()
+	p.notifiers = notifiers
+	p.lock.Unlock()
+	p.restore.Do(p.restoreQueue)
+}
+
+// SetControllers sets the source of the running controllers which release the application state of the notifications
+// that are not delivered after all attempts
+func (p *DeliveryPipeline) SetControllers(controllers func() []NotificationController) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.controllers = controllers
+}
+
+// releaseState removes the application state entry of the notification that was reported as sent but is not delivered
+// after all attempts, e.g. the delivery delayed by the rate limit, so the notification is sent again during the next
+// reconciliation
+func (p *DeliveryPipeline) releaseState(d *delivery) {
+	p.lock.Lock()
+	getControllers := p.controllers
+	p.lock.Unlock()
+	if d.stateKey == "" || getControllers == nil {
+		return
+	}
+	err := errAppNotManaged
+	controllers := getControllers()
+	for i := 0; i < len(controllers) && err == errAppNotManaged; i++ {
+		err = controllers[i].ReleaseState(d.appNamespace, d.appName, d.stateKey)
+	}
+	if err != nil {
+		d.logEntry().Warnf("Failed to release the state of %s notification to %s: %v", d.trigger, d.recipient(), err)
+	}
+}
+
+// SetRateLimits updates rate limits of the delivered notifications
+func (p *DeliveryPipeline) SetRateLimits(limits []settings.RateLimit) {
+	p.limiter.setLimits(limits)
+}
+
+func (p *DeliveryPipeline) getNotifiers(d *delivery) map[string]notifiers.Notifier {
+	if d.notifiers != nil {
+		return d.notifiers
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.notifiers
+}
+
+func (p *DeliveryPipeline) restoreQueue() {
+	if p.opts.Queue == nil {
+		return
+	}
+	items, err := p.opts.Queue.List()
+	if err != nil {
+		log.Errorf("Failed to restore pending notifications: %v", err)
+		return
+	}
+	for _, item := range items {
+		if item.Escalation != nil {
+			p.restoreEscalation(item)
+			continue
+		}
+		d := fromQueueItem(item)
+		d.logEntry().Infof("Restored pending %s notification to %s", d.trigger, d.recipient())
+		p.enqueueOrdered(d)
+		p.queue.AddAfter(d, time.Until(item.NextAttempt))
+	}
+}
+
+// Run processes scheduled retries until context is canceled
+func (p *DeliveryPipeline) Run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		p.queue.ShutDown()
+	}()
+	wait.Until(func() {
+		for p.processNext(ctx) {
+		}
+	}, time.Second, ctx.Done())
+}
+
+// deliver sends the notification and returns an error if it failed and will not be retried by the pipeline. The
+// in-flight notifier call is aborted when the context is canceled; the retries are sent using the pipeline context.
+func (p *DeliveryPipeline) deliver(ctx context.Context, d *delivery) error {
+	p.statuses.triggered(d.appNamespace, d.appName, d.trigger)
+	if p.silenced(d) {
+		return nil
+	}
+	if p.flooded(ctx, d) {
+		return nil
+	}
+	p.startEscalation(d)
+	if p.emailDigest(d) {
+		return nil
+	}
+	if p.batch(d) {
+		return nil
+	}
+	p.enqueueOrdered(d)
+	if p.rateLimit(d) {
+		if !d.rateLimited {
+			// the notification is collapsed into the summary
+			p.releaseOrdered(d)
+		}
+		return nil
+	}
+	if !p.acquireOrdered(d) {
+		return nil
+	}
+	err := p.send(ctx, d)
+	if err == nil {
+		p.emitAppEvent(d)
+		p.releaseOrdered(d)
+		return nil
+	}
+	if p.opts.MaxAttempts <= 1 {
+		p.emitAppEvent(d)
+		p.releaseOrdered(d)
+		if next := p.failover(d); next != nil {
+			return p.deliver(ctx, next)
+		}
+		return err
+	}
+	p.retry(ctx, d)
+	return nil
+}
+
+// silenced returns true if the notification is suppressed by the silence; the suppressed notification is treated as
+// delivered, so it is not sent again when the silence expires
+func (p *DeliveryPipeline) silenced(d *delivery) bool {
+	if p.opts.Silences == nil {
+		return false
+	}
+	silence := p.opts.Silences.Find(d.appNamespace, d.appName, d.trigger, d.recipient())
+	if silence == nil {
+		return false
+	}
+	p.metrics.IncSilencedCounter(d.trigger, d.service)
+	d.logEntry().Infof("%s notification to %s is suppressed by silence %s until %s", d.trigger, d.recipient(), silence.Name, silence.ExpiresAt.Format(time.RFC3339))
+	return true
+}
+
+// flooded returns true if the delivery is paused by the flood protection. The dropped notification is treated as
+// delivered, so it is not sent again once the delivery is resumed. The notification that pauses the delivery triggers
+// the alert to the flood alert recipient.
+func (p *DeliveryPipeline) flooded(ctx context.Context, d *delivery) bool {
+	allowed, tripped := p.flood.allow()
+	// the delivery might be paused or resumed by another controller replica sharing the flood protection state
+	p.metrics.SetDeliveryPaused(!allowed)
+	if allowed {
+		return false
+	}
+	p.metrics.IncFloodSuppressedCounter(d.trigger, d.service)
+	if !tripped {
+		d.logEntry().Debugf("Delivery is paused by the flood protection, dropped %s notification to %s", d.trigger, d.recipient())
+		return true
+	}
+	d.logEntry().Errorf("More than %d notifications were sent within %v, pausing delivery until it is resumed", p.opts.FloodThreshold, p.opts.FloodWindow)
+	p.sendFloodAlert(ctx, d)
+	return true
+}
+
+func (p *DeliveryPipeline) sendFloodAlert(ctx context.Context, d *delivery) {
+	if p.opts.FloodAlertRecipient == "" || p.opts.DryRun {
+		return
+	}
+	alert, err := sharedrecipients.Parse(p.opts.FloodAlertRecipient)
+	if err != nil {
+		d.logEntry().Errorf("Flood alert recipient is not valid: %v", err)
+		return
+	}
+	notifier, ok := p.getNotifiers(d)[alert.Service]
+	if !ok {
+		d.logEntry().Errorf("Flood alert recipient type %s is not configured", alert.Service)
+		return
+	}
+	notification := formatFloodAlert(p.opts.FloodThreshold, p.opts.FloodWindow, d)
+	sendCtx := notifiers.WithRecipientOptions(ctx, alert.Options())
+	result, err := p.sendWithTimeout(sendCtx, notifier, alert.Service, notification, alert.Target)
+	_ = p.recordEvent("", "", "", "", p.opts.FloodAlertRecipient, notification, result, err)
+	if err != nil {
+		d.logEntry().Errorf("Failed to send flood alert to %s: %v", p.opts.FloodAlertRecipient, err)
+	}
+}
+
+// FloodStatus returns the state of the flood protection
+func (p *DeliveryPipeline) FloodStatus() FloodStatus {
+	return p.flood.status()
+}
+
+// Resume resumes the delivery paused by the flood protection and returns false if the delivery is not paused
+func (p *DeliveryPipeline) Resume() bool {
+	if !p.flood.resume() {
+		return false
+	}
+	p.metrics.SetDeliveryPaused(false)
+	log.Info("Delivery paused by the flood protection is resumed")
+	return true
+}
+
+// rateLimit returns true if the delivery exceeds the rate limit and is either scheduled for later or collapsed into
+// the pending summary notification
+func (p *DeliveryPipeline) rateLimit(d *delivery) bool {
+	if d.rateLimited {
+		return false
+	}
+	reservation := p.limiter.reserve(d.service, d.recipient())
+	if reservation.delay <= 0 {
+		return false
+	}
+	p.metrics.IncRateLimitedCounter(d.service, reservation.overflow)
+	if reservation.overflow != settings.RateLimitOverflowSummary {
+		d.logEntry().Infof("Rate limit of %s notifications to %s is exceeded. Delaying notification by %v", d.trigger, d.recipient(), reservation.delay)
+		d.rateLimited = true
+		p.queue.AddAfter(d, reservation.delay)
+		return true
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if summary, ok := p.summaries[d.recipient()]; ok {
+		summary.summarized = append(summary.summarized, d.notification)
+		reservation.cancel()
+		return true
+	}
+	summary := &delivery{
+		trigger:       d.trigger,
+		service:       d.service,
+		target:        d.target,
+		options:       d.options,
+		notifiers:     d.notifiers,
+		rateLimited:   true,
+		summarized:    []notifiers.Notification{d.notification},
+		correlationID: newCorrelationID(),
+	}
+	p.summaries[d.recipient()] = summary
+	d.logEntry().Infof("Notification is collapsed into the rate limit summary %s", summary.correlationID)
+	summary.logEntry().Infof("Rate limit of notifications to %s is exceeded. Sending summary in %v", d.recipient(), reservation.delay)
+	p.queue.AddAfter(summary, reservation.delay)
+	return true
+}
+
+func (p *DeliveryPipeline) send(ctx context.Context, d *delivery) error {
+	// the rate limit collapses notifications into the pending summary under the lock
+	p.lock.Lock()
+	if d.summarized != nil {
+		if p.summaries[d.recipient()] == d {
+			delete(p.summaries, d.recipient())
+		}
+		d.notification = formatRateLimitSummary(d.summarized)
+		d.summarized = nil
+	}
+	p.lock.Unlock()
+	if !p.flushBatch(d) {
+		p.flushEmailDigest(d)
+	}
+	d.attempts++
+	notifier, ok := p.getNotifiers(d)[d.service]
+	if !ok {
+		d.lastErr = fmt.Errorf("%s is not valid recipient type", d.service)
+		return d.lastErr
+	}
+	if p.opts.DryRun {
+		d.lastErr = nil
+		_ = p.recordEvent(d.appName, d.appNamespace, d.trigger, d.template, d.recipient(), d.notification, nil, nil)
+		p.metrics.IncDryRunDeliveriesCounter(d.trigger, d.service)
+		p.observe(d)
+		d.logEntry().Infof("Dry run: skipped sending %s notification to %s", d.trigger, d.recipient())
+		d.logEntry().Debugf("Dry run notification:\n%s\n%s", d.notification.Title, d.notification.Body)
+		return nil
+	}
+	if !p.breaker.allow(d.service) {
+		d.lastErr = fmt.Errorf("circuit breaker of %s notifier is open", d.service)
+		p.metrics.IncCircuitBreakerRejectionsCounter(d.service)
+		return d.lastErr
+	}
+	sendCtx, span := tracer().Start(trace.ContextWithRemoteSpanContext(ctx, d.spanContext), spanNotifierSend, trace.WithAttributes(
+		attrNotifier.String(d.service),
+		attrRecipient.String(d.recipient()),
+		attrTrigger.String(d.trigger),
+		attrAttempt.Int(d.attempts),
+		attrCorrelationID.String(d.correlationID)))
+	start := time.Now()
+	sendCtx = notifiers.WithRecipientOptions(sendCtx, d.sendOptions())
+	result, err := p.sendWithTimeout(sendCtx, notifier, d.service, d.notification, d.target)
+	d.lastErr = err
+	p.metrics.ObserveDeliveryDuration(d.service, time.Since(start))
+	endSpan(span, d.lastErr)
+	if d.lastErr != nil {
+		p.metrics.IncNotifierErrorsCounter(d.service, notifierErrorReason(d.lastErr))
+	}
+	p.metrics.SetCircuitBreakerOpen(d.service, p.breaker.record(d.service, d.lastErr))
+	eventID := p.recordEvent(d.appName, d.appNamespace, d.trigger, d.template, d.recipient(), d.notification, result, d.lastErr)
+	p.metrics.IncDeliveriesCounter(d.template, d.trigger, d.service, d.lastErr == nil, eventID)
+	p.metrics.IncAppDeliveriesCounter(d.appNamespace, d.appName, d.service, d.lastErr == nil)
+	p.statuses.delivered(d.appNamespace, d.appName, d.recipient(), d.lastErr)
+	p.observe(d)
+	if d.lastErr == nil {
+		logEntry := d.logEntry()
+		if result != nil && result.MessageID != "" {
+			logEntry = logEntry.WithField(logFieldMessageID, result.MessageID)
+		}
+		logEntry.Infof("Delivered %s notification to %s", d.trigger, d.recipient())
+	}
+	return d.lastErr
+}
+
+// observe reports the delivery attempt outcome to the observer if it is configured
+func (p *DeliveryPipeline) observe(d *delivery) {
+	if p.opts.Observer != nil {
+		p.opts.Observer.ObserveDelivery(d.appNamespace, d.appName, d.service, d.lastErr)
+	}
+}
+
+// sendWithTimeout sends the notification and returns the notification service response or an error if the notifier
+// does not respond in time. The notifier call is canceled once the timeout expires or the context is canceled. The
+// notifiers which check the context only before sending keep running in the background, so the caller is never
+// blocked longer than the timeout. The call waits for the free slot if the notifier max concurrency is reached; the
+// slot is held until the notifier returns, including the calls running in the background.
+func (p *DeliveryPipeline) sendWithTimeout(ctx context.Context, notifier notifiers.Notifier, service string, notification notifiers.Notification, target string) (*notifiers.DeliveryResult, error) {
+	release, err := p.acquireSlot(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	timeout := p.opts.SendTimeout
+	if notifierTimeout, ok := p.opts.NotifierTimeouts[service]; ok {
+		timeout = notifierTimeout
+	}
+	if timeout <= 0 {
+		defer release()
+		return notifiers.SendWithResult(ctx, notifier, notification, target)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	type sendResult struct {
+		result *notifiers.DeliveryResult
+		err    error
+	}
+	res := make(chan sendResult, 1)
+	go func() {
+		defer release()
+		result, err := notifiers.SendWithResult(ctx, notifier, notification, target)
+		res <- sendResult{result: result, err: err}
+	}()
+	select {
+	case r := <-res:
+		if r.err != nil && ctx.Err() == context.DeadlineExceeded {
+			return r.result, &sendTimeoutError{service: service, timeout: timeout}
+		}
+		return r.result, r.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, &sendTimeoutError{service: service, timeout: timeout}
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// acquireSlot waits until the call does not exceed the max concurrency of the notifier and returns the function that
+// frees the slot
+func (p *DeliveryPipeline) acquireSlot(ctx context.Context, service string) (func(), error) {
+	slots, ok := p.slots[service]
+	if !ok {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type sendTimeoutError struct {
+	service string
+	timeout time.Duration
+}
+
+func (e *sendTimeoutError) Error() string {
+	return fmt.Sprintf("%s notifier did not respond in %v", e.service, e.timeout)
+}
+
+// notifierErrorReason returns the reason label of the notifier error metric
+func notifierErrorReason(err error) string {
+	if _, ok := err.(*sendTimeoutError); ok {
+		return notifierErrorTimeout
+	}
+	return notifierErrorAPI
+}
+
+// backoff returns the exponential backoff of the specified attempt randomized by up to 50%
+func (p *DeliveryPipeline) backoff(attempts int) time.Duration {
+	backoff := p.opts.InitialBackoff
+	for i := 1; i < attempts && (p.opts.MaxBackoff <= 0 || backoff < p.opts.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.opts.MaxBackoff > 0 && backoff > p.opts.MaxBackoff {
+		backoff = p.opts.MaxBackoff
+	}
+	return backoff/2 + time.Duration(p.jitter()*float64(backoff/2))
+}
+
+func (p *DeliveryPipeline) retry(ctx context.Context, d *delivery) {
+	if d.attempts >= p.opts.MaxAttempts {
+		next := p.failover(d)
+		if next == nil {
+			p.deadLetter(ctx, d)
+			p.releaseState(d)
+		} else {
+			p.emitAppEvent(d)
+		}
+		p.forget(d)
+		p.releaseOrdered(d)
+		if next != nil {
+			if err := p.deliver(ctx, next); err != nil {
+				next.logEntry().Errorf("Failed to deliver %s notification to %s: %v", next.trigger, next.recipient(), err)
+				p.releaseState(next)
+			}
+		}
+		return
+	}
+	backoff := p.backoff(d.attempts)
+	d.logEntry().Warnf("Failed to deliver %s notification to %s (attempt %d of %d): %v. Retrying in %v",
+		d.trigger, d.recipient(), d.attempts, p.opts.MaxAttempts, d.lastErr, backoff)
+	p.metrics.IncDeliveryRetriesCounter(d.service)
+	p.save(d, time.Now().Add(backoff))
+	p.queue.AddAfter(d, backoff)
+}
+
+// save persists delivery in the queue if it is configured
+func (p *DeliveryPipeline) save(d *delivery, nextAttempt time.Time) {
+	if p.opts.Queue == nil {
+		return
+	}
+	if d.id == "" {
+		d.id = newDeliveryID(d)
+	}
+	if err := p.opts.Queue.Save(d.toQueueItem(nextAttempt)); err != nil {
+		d.logEntry().Warnf("Failed to persist pending %s notification to %s: %v", d.trigger, d.recipient(), err)
+	}
+}
+
+// forget removes delivery from the persistent queue
+func (p *DeliveryPipeline) forget(d *delivery) {
+	if p.opts.Queue == nil || d.id == "" {
+		return
+	}
+	if err := p.opts.Queue.Delete(d.id); err != nil {
+		d.logEntry().Warnf("Failed to remove pending %s notification to %s: %v", d.trigger, d.recipient(), err)
+	}
+}
+
+func newDeliveryID(d *delivery) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s/%s/%d", d.appNamespace, d.appName, d.trigger, d.recipient(), time.Now().UnixNano())))
+	return "notification-" + hex.EncodeToString(sum[:])[:16]
+}
+
+func (p *DeliveryPipeline) processNext(ctx context.Context) bool {
+	item, shutdown := p.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer p.queue.Done(item)
+	if e, ok := item.(*escalation); ok {
+		p.escalate(ctx, e)
+		return true
+	}
+	d, ok := item.(*delivery)
+	if !ok {
+		return true
+	}
+	if p.flood.suppress() {
+		p.metrics.IncFloodSuppressedCounter(d.trigger, d.service)
+		d.logEntry().Debugf("Delivery is paused by the flood protection, dropped %s notification to %s", d.trigger, d.recipient())
+		p.forget(d)
+		p.releaseOrdered(d)
+		return true
+	}
+	if !p.acquireOrdered(d) {
+		return true
+	}
+	if err := p.send(ctx, d); err != nil {
+		p.retry(ctx, d)
+	} else {
+		p.emitAppEvent(d)
+		p.forget(d)
+		p.releaseOrdered(d)
+	}
+	return true
+}
+
+// failover returns the delivery of the notification to the next recipient of the fallback chain or nil if the chain
+// is exhausted
+func (p *DeliveryPipeline) failover(d *delivery) *delivery {
+	if len(d.fallbacks) == 0 {
+		return nil
+	}
+	fallback, _ := sharedrecipients.Parse(d.fallbacks[0])
+	next := &delivery{
+		appName:       d.appName,
+		appNamespace:  d.appNamespace,
+		stateKey:      d.stateKey,
+		trigger:       d.trigger,
+		template:      d.template,
+		service:       fallback.Service,
+		target:        fallback.Target,
+		options:       fallback.Options(),
+		notifiers:     d.notifiers,
+		notification:  d.notification,
+		correlationID: d.correlationID,
+		spanContext:   d.spanContext,
+		appRef:        d.appRef,
+		recorder:      d.recorder,
+		fallbacks:     d.fallbacks[1:],
+	}
+	p.metrics.IncFailoversCounter(d.service)
+	d.logEntry().Warnf("Failed to deliver %s notification to %s after %d attempts: %v. Failing over to %s",
+		d.trigger, d.recipient(), d.attempts, d.lastErr, next.recipient())
+	return next
+}
+
+func (p *DeliveryPipeline) deadLetter(ctx context.Context, d *delivery) {
+	p.metrics.IncDeadLettersCounter(d.service)
+	p.emitAppEvent(d)
+	d.logEntry().Errorf("Failed to deliver %s notification to %s after %d attempts: %v", d.trigger, d.recipient(), d.attempts, d.lastErr)
+	if p.opts.DeadLetterRecipient == "" {
+		return
+	}
+	deadLetter, err := sharedrecipients.Parse(p.opts.DeadLetterRecipient)
+	if err != nil {
+		d.logEntry().Errorf("Dead letter recipient is not valid: %v", err)
+		return
+	}
+	notifier, ok := p.getNotifiers(d)[deadLetter.Service]
+	if !ok {
+		d.logEntry().Errorf("Dead letter recipient type %s is not configured", deadLetter.Service)
+		return
+	}
+	notification := formatDeadLetter(d)
+	sendCtx := notifiers.WithRecipientOptions(ctx, deadLetter.Options())
+	result, err := p.sendWithTimeout(sendCtx, notifier, deadLetter.Service, notification, deadLetter.Target)
+	_ = p.recordEvent(d.appName, d.appNamespace, d.trigger, d.template, p.opts.DeadLetterRecipient, notification, result, err)
+	if err != nil {
+		d.logEntry().Errorf("Failed to send %s notification to dead letter recipient %s: %v", d.trigger, p.opts.DeadLetterRecipient, err)
+	}
+}
+
+func formatDeadLetter(d *delivery) notifiers.Notification {
+	app := "digest"
+	if d.appName != "" {
+		app = fmt.Sprintf("app %s/%s", d.appNamespace, d.appName)
+	}
+	return notifiers.Notification{
+		Title: fmt.Sprintf("Failed to deliver %s notification to %s", d.trigger, d.recipient()),
+		Body: fmt.Sprintf("Notification about %s was not delivered after %d attempts: %v\n\n%s\n%s",
+			app, d.attempts, d.lastErr, d.notification.Title, d.notification.Body),
+	}
+}
+
+// recordEvent persists the delivery attempt and the notification service response in the history store if it is
+// configured and returns the event id
+func (p *DeliveryPipeline) recordEvent(appName string, appNamespace string, trigger string, template string, recipient string, notification notifiers.Notification, result *notifiers.DeliveryResult, err error) string {
+	if p.history == nil {
+		return ""
+	}
+	service, _, _ := splitRecipient(recipient)
+	event := history.Event{
+		App:          appName,
+		AppNamespace: appNamespace,
+		Trigger:      trigger,
+		Template:     template,
+		Recipient:    recipient,
+		Notifier:     service,
+		BodyHash:     history.HashNotification(notification),
+		Timestamp:    time.Now(),
+		Status:       history.StatusSent,
+		Result:       result,
+	}
+	if err != nil {
+		event.Status = history.StatusFailed
+		event.Error = err.Error()
+	} else if p.opts.DryRun {
+		event.Status = history.StatusDryRun
+	}
+	event.ID = history.NewEventID(event)
+	if err := p.history.Record(event); err != nil {
+		log.Warnf("Failed to record %s notification to %s in history: %v", trigger, recipient, err)
+		return ""
+	}
+	return event.ID
+}
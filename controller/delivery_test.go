@@ -0,0 +1,793 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	notifiermocks "github.com/argoproj-labs/argocd-notifications/notifiers/mocks"
+	"github.com/argoproj-labs/argocd-notifications/shared/flood"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/queue"
+	"github.com/argoproj-labs/argocd-notifications/shared/ratelimit"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/silences"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func TestDeliveryPipeline_Backoff(t *testing.T) {
+	p := NewDeliveryPipeline(DeliveryOptions{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}, NewMetricsRegistry(), nil)
+	p.jitter = func() float64 { return 1 }
+
+	assert.Equal(t, time.Second, p.backoff(1))
+	assert.Equal(t, 2*time.Second, p.backoff(2))
+	assert.Equal(t, 4*time.Second, p.backoff(3))
+	assert.Equal(t, 5*time.Second, p.backoff(4))
+	assert.Equal(t, 5*time.Second, p.backoff(100))
+
+	p.jitter = func() float64 { return 0 }
+	assert.Equal(t, 2*time.Second, p.backoff(3))
+}
+
+func TestDeliveryPipeline_NoRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").Return(errors.New("fail"))
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), nil)
+
+	err := p.deliver(context.TODO(), &delivery{
+		service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+		notification: notifiers.Notification{Title: "hello"},
+	})
+
+	assert.EqualError(t, err, "fail")
+}
+
+func TestDeliveryPipeline_DryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	store := history.NewMemoryStore(0)
+	p := NewDeliveryPipeline(DeliveryOptions{DryRun: true}, NewMetricsRegistry(), store)
+
+	err := p.deliver(context.TODO(), &delivery{
+		appName: "guestbook", appNamespace: TestNamespace, trigger: "on-sync", service: "mock", target: "recipient",
+		notifiers: map[string]notifiers.Notifier{"mock": notifier}, notification: notifiers.Notification{Title: "hello"},
+	})
+
+	assert.NoError(t, err)
+	events, err := store.List(history.Filter{App: "guestbook"})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, history.StatusDryRun, events[0].Status)
+	}
+}
+
+type resultNotifier struct {
+	result notifiers.DeliveryResult
+}
+
+func (n *resultNotifier) Send(ctx context.Context, notification notifiers.Notification, recipient string) error {
+	_, err := n.SendWithResult(ctx, notification, recipient)
+	return err
+}
+
+func (n *resultNotifier) SendWithResult(_ context.Context, _ notifiers.Notification, _ string) (*notifiers.DeliveryResult, error) {
+	res := n.result
+	return &res, nil
+}
+
+func TestDeliveryPipeline_RecordsResult(t *testing.T) {
+	store := history.NewMemoryStore(0)
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), store)
+	notifier := &resultNotifier{result: notifiers.DeliveryResult{MessageID: "1503435956.000247", Channel: "C123"}}
+
+	err := p.deliver(context.TODO(), &delivery{
+		appName: "guestbook", appNamespace: TestNamespace, trigger: "on-sync", service: "mock", target: "recipient",
+		notifiers: map[string]notifiers.Notifier{"mock": notifier}, notification: notifiers.Notification{Title: "hello"},
+	})
+
+	assert.NoError(t, err)
+	events, err := store.List(history.Filter{App: "guestbook"})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) && assert.NotNil(t, events[0].Result) {
+		assert.Equal(t, "1503435956.000247", events[0].Result.MessageID)
+		assert.Equal(t, "C123", events[0].Result.Channel)
+		assert.False(t, events[0].Result.Timestamp.IsZero())
+	}
+}
+
+type testSilences []silences.Silence
+
+func (s testSilences) Find(appNamespace string, appName string, trigger string, recipient string) *silences.Silence {
+	for i := range s {
+		if s[i].Matches(appNamespace, appName, trigger, recipient) {
+			return &s[i]
+		}
+	}
+	return nil
+}
+
+func TestDeliveryPipeline_Silenced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "general").Return(nil)
+	p := NewDeliveryPipeline(DeliveryOptions{Silences: testSilences{{
+		Name: "silence-1", App: "guestbook", Recipient: "mock:recipient", ExpiresAt: time.Now().Add(time.Hour),
+	}}}, NewMetricsRegistry(), nil)
+
+	for _, target := range []string{"recipient", "general"} {
+		err := p.deliver(context.TODO(), &delivery{
+			appName: "guestbook", appNamespace: TestNamespace, trigger: "on-sync", service: "mock", target: target,
+			notifiers: map[string]notifiers.Notifier{"mock": notifier}, notification: notifiers.Notification{Title: "hello"},
+		})
+		assert.NoError(t, err)
+	}
+}
+
+func TestDeliveryPipeline_RetriesFailedDelivery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	gomock.InOrder(
+		notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").Return(errors.New("fail")),
+		notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").Return(nil),
+	)
+	store := history.NewMemoryStore(0)
+	p := NewDeliveryPipeline(DeliveryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond}, NewMetricsRegistry(), store)
+	go p.Run(ctx)
+
+	err := p.deliver(context.TODO(), &delivery{
+		appName: "guestbook", appNamespace: TestNamespace, trigger: "on-sync", service: "mock", target: "recipient",
+		notifiers: map[string]notifiers.Notifier{"mock": notifier}, notification: notifiers.Notification{Title: "hello"},
+	})
+
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		events, _ := store.List(history.Filter{Status: history.StatusSent})
+		return len(events) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	events, err := store.List(history.Filter{App: "guestbook"})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestDeliveryPipeline_DeadLetter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").Return(errors.New("fail")).Times(2)
+	dlq := notifiermocks.NewMockNotifier(ctrl)
+	delivered := make(chan notifiers.Notification, 1)
+	dlq.EXPECT().Send(gomock.Any(), gomock.Any(), "oncall").DoAndReturn(func(_ context.Context, n notifiers.Notification, _ string) error {
+		delivered <- n
+		return nil
+	})
+	p := NewDeliveryPipeline(DeliveryOptions{MaxAttempts: 2, InitialBackoff: time.Millisecond, DeadLetterRecipient: "dlq:oncall"}, NewMetricsRegistry(), nil)
+	go p.Run(ctx)
+
+	err := p.deliver(context.TODO(), &delivery{
+		appName: "guestbook", appNamespace: TestNamespace, trigger: "on-sync", service: "mock", target: "recipient",
+		notifiers:    map[string]notifiers.Notifier{"mock": notifier, "dlq": dlq},
+		notification: notifiers.Notification{Title: "hello"},
+	})
+	assert.NoError(t, err)
+
+	select {
+	case n := <-delivered:
+		assert.Equal(t, "Failed to deliver on-sync notification to mock:recipient", n.Title)
+		assert.Contains(t, n.Body, "app default/guestbook was not delivered after 2 attempts: fail")
+		assert.Contains(t, n.Body, "hello")
+	case <-time.After(5 * time.Second):
+		t.Fatal("dead letter notification was not sent")
+	}
+}
+
+func TestDeliveryPipeline_FloodProtection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").Return(nil).Times(3)
+	ops := notifiermocks.NewMockNotifier(ctrl)
+	var alert notifiers.Notification
+	ops.EXPECT().Send(gomock.Any(), gomock.Any(), "oncall").DoAndReturn(func(_ context.Context, n notifiers.Notification, _ string) error {
+		alert = n
+		return nil
+	})
+	p := NewDeliveryPipeline(DeliveryOptions{FloodThreshold: 2, FloodWindow: time.Minute, FloodAlertRecipient: "ops:oncall"}, NewMetricsRegistry(), nil)
+	newDelivery := func() *delivery {
+		return &delivery{
+			appName: "guestbook", appNamespace: TestNamespace, trigger: "on-sync", service: "mock", target: "recipient",
+			notifiers:    map[string]notifiers.Notifier{"mock": notifier, "ops": ops},
+			notification: notifiers.Notification{Title: "hello"},
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, p.deliver(context.TODO(), newDelivery()))
+	}
+	assert.Equal(t, "Notification flood detected, delivery is paused", alert.Title)
+	assert.Contains(t, alert.Body, "More than 2 notifications were sent within 1m0s")
+	status := p.FloodStatus()
+	assert.True(t, status.Paused)
+	assert.Equal(t, 2, status.Suppressed)
+
+	assert.True(t, p.Resume())
+	assert.False(t, p.Resume())
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery()))
+}
+
+func TestDeliveryPipeline_Failover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	primary := notifiermocks.NewMockNotifier(ctrl)
+	primary.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "svc").Return(errors.New("fail")).Times(2)
+	fallback := notifiermocks.NewMockNotifier(ctrl)
+	fallback.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "sre").Return(errors.New("fail")).Times(2)
+	delivered := make(chan string, 1)
+	fallback.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "sre@corp").DoAndReturn(func(_ context.Context, _ notifiers.Notification, target string) error {
+		delivered <- target
+		return nil
+	})
+	dlq := notifiermocks.NewMockNotifier(ctrl)
+	p := NewDeliveryPipeline(DeliveryOptions{MaxAttempts: 2, InitialBackoff: time.Millisecond, DeadLetterRecipient: "dlq:oncall"}, NewMetricsRegistry(), nil)
+	go p.Run(ctx)
+
+	err := p.deliver(context.TODO(), &delivery{
+		appName: "guestbook", appNamespace: TestNamespace, trigger: "on-sync", service: "pagerduty", target: "svc",
+		fallbacks:    []string{"slack:sre", "slack:sre@corp"},
+		notifiers:    map[string]notifiers.Notifier{"pagerduty": primary, "slack": fallback, "dlq": dlq},
+		notification: notifiers.Notification{Title: "hello"},
+	})
+	assert.NoError(t, err)
+
+	select {
+	case target := <-delivered:
+		assert.Equal(t, "sre@corp", target)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not sent to the fallback recipient")
+	}
+}
+
+func TestDeliveryPipeline_FailoverNoRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	primary := notifiermocks.NewMockNotifier(ctrl)
+	primary.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "svc").Return(errors.New("fail"))
+	fallback := notifiermocks.NewMockNotifier(ctrl)
+	fallback.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "sre").Return(nil)
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), nil)
+
+	err := p.deliver(context.TODO(), &delivery{
+		service: "pagerduty", target: "svc", fallbacks: []string{"slack:sre"},
+		notifiers:    map[string]notifiers.Notifier{"pagerduty": primary, "slack": fallback},
+		notification: notifiers.Notification{Title: "hello"},
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestDeliveryPipeline_RestoresPersistedDeliveries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	delivered := make(chan bool, 1)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").DoAndReturn(func(_ context.Context, _ notifiers.Notification, _ string) error {
+		delivered <- true
+		return nil
+	})
+	q := queue.NewCRDQueue(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+	assert.NoError(t, q.Save(queue.Item{
+		ID: "notification-1", App: "guestbook", Trigger: "on-sync", Service: "mock", Target: "recipient",
+		Notification: notifiers.Notification{Title: "hello"}, Attempts: 1, NextAttempt: time.Now(),
+	}))
+	p := NewDeliveryPipeline(DeliveryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond, Queue: q}, NewMetricsRegistry(), nil)
+	go p.Run(ctx)
+
+	p.SetNotifiers(map[string]notifiers.Notifier{"mock": notifier})
+
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("persisted notification was not sent")
+	}
+	assert.Eventually(t, func() bool {
+		items, err := q.List()
+		return err == nil && len(items) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDeliveryPipeline_PersistsScheduledRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").Return(errors.New("fail"))
+	q := queue.NewCRDQueue(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+	p := NewDeliveryPipeline(DeliveryOptions{MaxAttempts: 3, InitialBackoff: time.Hour, Queue: q}, NewMetricsRegistry(), nil)
+
+	err := p.deliver(context.TODO(), &delivery{
+		appName: "guestbook", trigger: "on-sync", service: "mock", target: "recipient",
+		notifiers: map[string]notifiers.Notifier{"mock": notifier}, notification: notifiers.Notification{Title: "hello"},
+	})
+	assert.NoError(t, err)
+
+	items, err := q.List()
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, 1, items[0].Attempts)
+		assert.Equal(t, "fail", items[0].LastError)
+		assert.Equal(t, "guestbook", items[0].App)
+	}
+}
+
+func TestDeliveryPipeline_SendTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	unblock := make(chan struct{})
+	defer close(unblock)
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").DoAndReturn(func(_ context.Context, _ notifiers.Notification, _ string) error {
+		<-unblock
+		return nil
+	})
+	p := NewDeliveryPipeline(DeliveryOptions{
+		SendTimeout: time.Hour, NotifierTimeouts: map[string]time.Duration{"mock": 10 * time.Millisecond},
+	}, NewMetricsRegistry(), nil)
+
+	err := p.deliver(context.TODO(), &delivery{
+		service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+		notification: notifiers.Notification{Title: "hello"},
+	})
+
+	assert.EqualError(t, err, "mock notifier did not respond in 10ms")
+}
+
+func TestDeliveryPipeline_NotifierMaxConcurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").DoAndReturn(func(_ context.Context, _ notifiers.Notification, _ string) error {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return nil
+	}).Times(6)
+	p := NewDeliveryPipeline(DeliveryOptions{NotifierMaxConcurrent: map[string]int{"mock": 2}}, NewMetricsRegistry(), nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, p.deliver(context.TODO(), &delivery{
+				service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+				notification: notifiers.Notification{Title: "hello"},
+			}))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, maxRunning)
+}
+
+func TestDeliveryPipeline_SendCanceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	aborted := make(chan bool, 1)
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").DoAndReturn(func(ctx context.Context, _ notifiers.Notification, _ string) error {
+		<-ctx.Done()
+		aborted <- true
+		return ctx.Err()
+	})
+	p := NewDeliveryPipeline(DeliveryOptions{SendTimeout: time.Hour}, NewMetricsRegistry(), nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	err := p.deliver(ctx, &delivery{
+		service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+		notification: notifiers.Notification{Title: "hello"},
+	})
+
+	assert.Equal(t, context.Canceled, err)
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("notifier call was not aborted")
+	}
+}
+
+func TestDeliveryPipeline_RecipientOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	var options notifiers.RecipientOptions
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "deploys").DoAndReturn(func(ctx context.Context, _ notifiers.Notification, _ string) error {
+		options = notifiers.GetRecipientOptions(ctx)
+		return nil
+	})
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), nil)
+
+	err := p.deliver(context.TODO(), &delivery{
+		appName: "guestbook", appNamespace: "argocd",
+		service: "mock", target: "deploys", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+		notification: notifiers.Notification{Title: "hello"},
+		options:      notifiers.RecipientOptions{"thread": "true"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, notifiers.RecipientOptions{"thread": "true", notifiers.OptionGroupKey: "argocd/guestbook"}, options)
+}
+
+func TestDeliveryPipeline_CircuitBreaker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").Return(errors.New("fail")).Times(2)
+	p := NewDeliveryPipeline(DeliveryOptions{CircuitBreakerThreshold: 2, CircuitBreakerCooldown: time.Hour}, NewMetricsRegistry(), nil)
+	newDelivery := func() *delivery {
+		return &delivery{
+			service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+			notification: notifiers.Notification{Title: "hello"},
+		}
+	}
+
+	assert.EqualError(t, p.deliver(context.TODO(), newDelivery()), "fail")
+	assert.EqualError(t, p.deliver(context.TODO(), newDelivery()), "fail")
+	assert.EqualError(t, p.deliver(context.TODO(), newDelivery()), "circuit breaker of mock notifier is open")
+}
+
+func TestDeliveryPipeline_RateLimitQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	var sent int32
+	notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "recipient").DoAndReturn(func(_ context.Context, _ notifiers.Notification, _ string) error {
+		atomic.AddInt32(&sent, 1)
+		return nil
+	}).Times(2)
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), nil)
+	p.SetRateLimits([]settings.RateLimit{{Notifier: "mock", Limit: 1, Period: "200ms"}})
+	go p.Run(ctx)
+	newDelivery := func(title string) *delivery {
+		return &delivery{
+			service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+			notification: notifiers.Notification{Title: title},
+		}
+	}
+
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("first")))
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("second")))
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sent))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&sent) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDeliveryPipeline_SharedFloodProtection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	server, err := miniredis.Run()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+	store := flood.NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "first"}, "recipient").Return(nil)
+	opts := DeliveryOptions{FloodThreshold: 1, FloodWindow: time.Hour, FloodStore: store}
+	replica1 := NewDeliveryPipeline(opts, NewMetricsRegistry(), nil)
+	replica2 := NewDeliveryPipeline(opts, NewMetricsRegistry(), nil)
+	newDelivery := func(title string) *delivery {
+		return &delivery{
+			service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+			notification: notifiers.Notification{Title: title},
+		}
+	}
+
+	assert.NoError(t, replica1.deliver(context.TODO(), newDelivery("first")))
+	// the notification of another replica exceeds the shared threshold
+	assert.NoError(t, replica2.deliver(context.TODO(), newDelivery("second")))
+	assert.True(t, replica1.FloodStatus().Paused)
+	assert.Equal(t, 1, replica1.FloodStatus().Suppressed)
+
+	assert.True(t, replica2.Resume())
+	assert.False(t, replica1.FloodStatus().Paused)
+	assert.False(t, replica1.Resume())
+}
+
+func TestDeliveryPipeline_SharedRateLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	server, err := miniredis.Run()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+	buckets := ratelimit.NewRedisBuckets(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "first"}, "recipient").Return(nil)
+	newReplica := func() *DeliveryPipeline {
+		p := NewDeliveryPipeline(DeliveryOptions{RateLimitBuckets: buckets}, NewMetricsRegistry(), nil)
+		p.SetRateLimits([]settings.RateLimit{{Notifier: "mock", Limit: 1, Period: "1h"}})
+		return p
+	}
+	newDelivery := func(title string) *delivery {
+		return &delivery{
+			service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+			notification: notifiers.Notification{Title: title},
+		}
+	}
+
+	assert.NoError(t, newReplica().deliver(context.TODO(), newDelivery("first")))
+	// the notification of another replica is delayed since the limit is shared
+	assert.NoError(t, newReplica().deliver(context.TODO(), newDelivery("second")))
+}
+
+func TestDeliveryPipeline_RateLimitSummary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	summarySent := make(chan notifiers.Notification, 1)
+	gomock.InOrder(
+		notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "first"}, "recipient").Return(nil),
+		notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "recipient").DoAndReturn(func(_ context.Context, n notifiers.Notification, _ string) error {
+			summarySent <- n
+			return nil
+		}),
+	)
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), nil)
+	p.SetRateLimits([]settings.RateLimit{{PerRecipient: true, Limit: 1, Period: "200ms", Overflow: settings.RateLimitOverflowSummary}})
+	go p.Run(ctx)
+	newDelivery := func(title string) *delivery {
+		return &delivery{
+			service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+			notification: notifiers.Notification{Title: title},
+		}
+	}
+
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("first")))
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("second")))
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("third")))
+
+	select {
+	case n := <-summarySent:
+		assert.Equal(t, "2 notification(s) collapsed due to rate limit", n.Title)
+		assert.Contains(t, n.Body, "second")
+		assert.Contains(t, n.Body, "third")
+	case <-time.After(5 * time.Second):
+		t.Fatal("summary notification was not sent")
+	}
+}
+
+func TestDeliveryPipeline_Batching(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	batchSent := make(chan notifiers.Notification, 1)
+	notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "recipient").DoAndReturn(func(_ context.Context, n notifiers.Notification, _ string) error {
+		batchSent <- n
+		return nil
+	})
+	other := notifiermocks.NewMockNotifier(ctrl)
+	other.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "third", Body: "third body"}, "recipient").Return(nil)
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), nil)
+	p.SetBatching([]settings.Batch{{Notifier: "mock", Interval: "200ms"}})
+	go p.Run(ctx)
+	newDelivery := func(service string, title string) *delivery {
+		return &delivery{
+			service: service, target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier, "other": other},
+			notification: notifiers.Notification{Title: title, Body: title + " body"},
+		}
+	}
+
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("mock", "first")))
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("mock", "second")))
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("other", "third")))
+
+	select {
+	case n := <-batchSent:
+		assert.Equal(t, "2 notification(s)", n.Title)
+		assert.Equal(t, "first\nfirst body\n\nsecond\nsecond body", n.Body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch notification was not sent")
+	}
+}
+
+func TestDeliveryPipeline_EmailDigest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	digestSent := make(chan notifiers.Notification, 1)
+	notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "team@example.com").DoAndReturn(func(_ context.Context, n notifiers.Notification, _ string) error {
+		digestSent <- n
+		return nil
+	})
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), nil)
+	p.SetEmailDigests([]settings.EmailDigest{{Schedule: "@every 1s", Template: "digest"}}, []triggers.NotificationTemplate{{
+		Name: "digest",
+		Notification: notifiers.Notification{
+			Title: "{{.count}} notification(s) for {{.recipient}}",
+			Body:  "{{range .apps}}{{.name}}:{{range .notifications}} {{.title}}{{end}}\n{{end}}",
+		},
+	}}, nil)
+	go p.Run(ctx)
+	newDelivery := func(app string, title string) *delivery {
+		return &delivery{
+			appName: app, appNamespace: "argocd", trigger: "on-sync", service: "email", target: "team@example.com",
+			notifiers: map[string]notifiers.Notifier{"email": notifier}, notification: notifiers.Notification{Title: title},
+		}
+	}
+
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("guestbook", "first")))
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("bookinfo", "second")))
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery("guestbook", "third")))
+
+	select {
+	case n := <-digestSent:
+		assert.Equal(t, "3 notification(s) for email:team@example.com", n.Title)
+		assert.Equal(t, "bookinfo: second\nguestbook: first third\n", n.Body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("digest notification was not sent")
+	}
+}
+
+func TestDeliveryPipeline_Escalation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "alerts").Return(nil)
+	escalated := make(chan bool, 1)
+	notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "oncall").DoAndReturn(func(_ context.Context, _ notifiers.Notification, _ string) error {
+		escalated <- true
+		return nil
+	})
+	store := history.NewMemoryStore(10)
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), store)
+	p.SetEscalations([]settings.Escalation{{Triggers: []string{"on-sync-failed"}, Tiers: []settings.EscalationTier{
+		{After: "100ms", Recipients: []string{"mock:oncall"}},
+		{After: "200ms", Recipients: []string{"mock:manager"}},
+	}}})
+	go p.Run(ctx)
+
+	assert.NoError(t, p.deliver(context.TODO(), &delivery{
+		appName: "guestbook", appNamespace: "argocd", trigger: "on-sync-failed", service: "mock", target: "alerts",
+		notifiers: map[string]notifiers.Notifier{"mock": notifier}, notification: notifiers.Notification{Title: "failed"},
+	}))
+	select {
+	case <-escalated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not escalated")
+	}
+	_, err := history.Acknowledge(store, "argocd", "guestbook", "on-sync-failed", "slack:U123")
+	assert.NoError(t, err)
+
+	// the acknowledged notification is not escalated to the manager
+	time.Sleep(500 * time.Millisecond)
+	events, err := store.List(history.Filter{Status: history.StatusEscalated})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "mock:oncall", events[0].Recipient)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	assert.Empty(t, p.escalations)
+}
+
+func TestDeliveryPipeline_PersistsEscalation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "alerts").Return(nil)
+	q := queue.NewCRDQueue(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+	p := NewDeliveryPipeline(DeliveryOptions{Queue: q}, NewMetricsRegistry(), history.NewMemoryStore(10))
+	p.SetEscalations([]settings.Escalation{{Triggers: []string{"on-sync-failed"}, Tiers: []settings.EscalationTier{
+		{After: "1h", Recipients: []string{"mock:oncall"}},
+	}}})
+
+	assert.NoError(t, p.deliver(context.TODO(), &delivery{
+		appName: "guestbook", appNamespace: "argocd", trigger: "on-sync-failed", service: "mock", target: "alerts",
+		notifiers: map[string]notifiers.Notifier{"mock": notifier}, notification: notifiers.Notification{Title: "failed"},
+	}))
+
+	items, err := q.List()
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) && assert.NotNil(t, items[0].Escalation) {
+		assert.Equal(t, "guestbook", items[0].App)
+		assert.Equal(t, "on-sync-failed", items[0].Trigger)
+		assert.Equal(t, 0, items[0].Escalation.Tier)
+		assert.Equal(t, []settings.EscalationTier{{After: "1h", Recipients: []string{"mock:oncall"}}}, items[0].Escalation.Tiers)
+	}
+}
+
+func TestDeliveryPipeline_RestoresEscalation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	escalated := make(chan bool, 1)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "failed"}, "oncall").DoAndReturn(func(_ context.Context, _ notifiers.Notification, _ string) error {
+		escalated <- true
+		return nil
+	})
+	q := queue.NewCRDQueue(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+	assert.NoError(t, q.Save(queue.Item{
+		ID: "escalation-1", App: "guestbook", AppNamespace: "argocd", Trigger: "on-sync-failed",
+		Notification: notifiers.Notification{Title: "failed"}, NextAttempt: time.Now(),
+		Escalation: &queue.Escalation{
+			Tiers:     []settings.EscalationTier{{After: "1h", Recipients: []string{"mock:oncall"}}},
+			StartedAt: time.Now().Add(-time.Hour),
+		},
+	}))
+	p := NewDeliveryPipeline(DeliveryOptions{Queue: q}, NewMetricsRegistry(), history.NewMemoryStore(10))
+	go p.Run(ctx)
+
+	p.SetNotifiers(map[string]notifiers.Notifier{"mock": notifier})
+
+	select {
+	case <-escalated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("restored escalation was not sent")
+	}
+	assert.Eventually(t, func() bool {
+		items, err := q.List()
+		return err == nil && len(items) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestFormatEmailDigest(t *testing.T) {
+	at := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	n := formatEmailDigest([]emailDigestItem{
+		{appNamespace: "argocd", appName: "guestbook", trigger: "on-sync", notification: notifiers.Notification{Title: "synced", Body: "guestbook is synced"}, time: at},
+		{trigger: "self-test", notification: notifiers.Notification{Body: "test"}, time: at},
+	})
+
+	assert.Equal(t, "Digest of 2 notification(s)", n.Title)
+	assert.Equal(t, "Other notifications:\n\n2020-06-01T10:00:00Z self-test\ntest\n\nApplication argocd/guestbook:\n\n2020-06-01T10:00:00Z on-sync\nsynced\nguestbook is synced", n.Body)
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const (
+	digestFlushInterval = time.Minute
+)
+
+type digestEntry struct {
+	schedule settings.Schedule
+	items    []digestItem
+}
+
+// digestItem is the application notification collected into the digest. The notification state is recorded once the
+// digest is sent, so notifications lost on controller restart are collected again while the trigger condition holds.
+// External event notifications have no state key.
+type digestItem struct {
+	appName      string
+	appNamespace string
+	stateKey     string
+	hash         string
+	notification notifiers.Notification
+}
+
+func (i digestItem) key() string {
+	return i.appNamespace + "/" + i.appName + "/" + i.stateKey + "/" + i.hash
+}
+
+// digestBuffer accumulates notifications suppressed by the subscription schedule
+type digestBuffer struct {
+	lock    sync.Mutex
+	entries map[string]*digestEntry
+}
+
+func newDigestBuffer() *digestBuffer {
+	return &digestBuffer{entries: map[string]*digestEntry{}}
+}
+
+// add collects the notifications into the recipient digest; the trigger notifications already collected are skipped
+func (b *digestBuffer) add(recipient string, schedule settings.Schedule, items ...digestItem) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	entry, ok := b.entries[recipient]
+	if !ok {
+		entry = &digestEntry{schedule: schedule}
+		b.entries[recipient] = entry
+	}
+	for _, item := range items {
+		if !entry.contains(item) {
+			entry.items = append(entry.items, item)
+		}
+	}
+}
+
+func (e *digestEntry) contains(item digestItem) bool {
+	if item.stateKey == "" {
+		return false
+	}
+	for _, existing := range e.items {
+		if existing.key() == item.key() {
+			return true
+		}
+	}
+	return false
+}
+
+// ready removes and returns notifications of recipients which schedule window is open at the specified time
+func (b *digestBuffer) ready(now time.Time) map[string]*digestEntry {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	res := map[string]*digestEntry{}
+	for recipient, entry := range b.entries {
+		if entry.schedule.IsActive(now) {
+			res[recipient] = entry
+			delete(b.entries, recipient)
+		}
+	}
+	return res
+}
+
+func formatDigest(items []digestItem) notifiers.Notification {
+	var body strings.Builder
+	for i, item := range items {
+		n := item.notification
+		if i > 0 {
+			body.WriteString("\n\n")
+		}
+		if n.Title != "" {
+			body.WriteString(n.Title + "\n")
+		}
+		body.WriteString(n.Body)
+	}
+	return notifiers.Notification{
+		Title: fmt.Sprintf("%d notification(s) suppressed during quiet hours", len(items)),
+		Body:  body.String(),
+	}
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+// emailDigestItem is the notification collected into the email digest
+type emailDigestItem struct {
+	appNamespace string
+	appName      string
+	trigger      string
+	notification notifiers.Notification
+	time         time.Time
+}
+
+func (i emailDigestItem) app() string {
+	if i.appName == "" {
+		return ""
+	}
+	return i.appNamespace + "/" + i.appName
+}
+
+func (i emailDigestItem) vars() map[string]interface{} {
+	return map[string]interface{}{
+		"app":          i.app(),
+		"appName":      i.appName,
+		"appNamespace": i.appNamespace,
+		"trigger":      i.trigger,
+		"title":        i.notification.Title,
+		"body":         i.notification.Body,
+		"time":         i.time,
+	}
+}
+
+// emailDigester holds the configured email digest rules and the templates which render the digest emails
+type emailDigester struct {
+	lock      sync.Mutex
+	rules     []settings.EmailDigest
+	templates map[string]triggers.NotificationTemplate
+	context   map[string]string
+}
+
+func (g *emailDigester) setRules(rules []settings.EmailDigest, templates []triggers.NotificationTemplate, context map[string]string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.rules = rules
+	g.templates = map[string]triggers.NotificationTemplate{}
+	for _, t := range templates {
+		g.templates[t.Name] = t
+	}
+	g.context = context
+}
+
+// match returns the first digest rule that matches the recipient or nil if notifications are sent immediately
+func (g *emailDigester) match(service string, recipient string) *settings.EmailDigest {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for i := range g.rules {
+		if g.rules[i].Matches(service, recipient) {
+			rule := g.rules[i]
+			return &rule
+		}
+	}
+	return nil
+}
+
+// template returns the digest template with the specified name and the configuration context
+func (g *emailDigester) template(name string) (triggers.NotificationTemplate, map[string]string, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	t, ok := g.templates[name]
+	return t, g.context, ok
+}
+
+// SetEmailDigests updates email digest rules and the templates which render the digest emails
+func (p *DeliveryPipeline) SetEmailDigests(rules []settings.EmailDigest, templates []triggers.NotificationTemplate, context map[string]string) {
+	p.emailDigester.setRules(rules, templates, context)
+}
+
+// emailDigest adds the notification to the pending digest of the recipient and returns true if notifications of the
+// recipient are collected into the digest. The digest is sent at the next time of the digest schedule.
+func (p *DeliveryPipeline) emailDigest(d *delivery) bool {
+	if d.emailDigested != nil {
+		return false
+	}
+	rule := p.emailDigester.match(d.service, d.recipient())
+	if rule == nil {
+		return false
+	}
+	item := emailDigestItem{appNamespace: d.appNamespace, appName: d.appName, trigger: d.trigger, notification: d.notification, time: time.Now()}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if digest, ok := p.emailDigests[d.recipient()]; ok {
+		digest.emailDigested = append(digest.emailDigested, item)
+		return true
+	}
+	digest := &delivery{
+		trigger:       d.trigger,
+		template:      rule.Template,
+		service:       d.service,
+		target:        d.target,
+		options:       d.options,
+		notifiers:     d.notifiers,
+		fallbacks:     d.fallbacks,
+		emailDigested: []emailDigestItem{item},
+		correlationID: newCorrelationID(),
+	}
+	p.emailDigests[d.recipient()] = digest
+	next := rule.Next(item.time)
+	d.logEntry().Infof("Notification is added to the digest %s", digest.correlationID)
+	digest.logEntry().Infof("Sending digest of notifications to %s at %s", d.recipient(), next.Format(time.RFC3339))
+	p.queue.AddAfter(digest, time.Until(next))
+	return true
+}
+
+// flushEmailDigest removes the digest from the pending digests and renders the digest email using the collected
+// notifications. The delivery is not changed if it is not a digest. The collected notifications are read under the
+// lock since notifications are added to the pending digest concurrently.
+func (p *DeliveryPipeline) flushEmailDigest(d *delivery) {
+	p.lock.Lock()
+	if d.emailDigested == nil {
+		p.lock.Unlock()
+		return
+	}
+	if p.emailDigests[d.recipient()] == d {
+		delete(p.emailDigests, d.recipient())
+	}
+	items := d.emailDigested
+	d.emailDigested = nil
+	p.lock.Unlock()
+
+	if d.template != "" {
+		if t, context, ok := p.emailDigester.template(d.template); ok {
+			n, err := triggers.FormatTemplate(t, emailDigestVars(d.recipient(), items, context))
+			if err == nil {
+				d.notification = *n
+				return
+			}
+			p.metrics.IncTemplateRenderErrorsCounter(d.template)
+			d.logEntry().Errorf("Failed to render digest template %s: %v", d.template, err)
+		} else {
+			d.logEntry().Errorf("Digest template %s is not found", d.template)
+		}
+	}
+	d.notification = formatEmailDigest(items)
+}
+
+// emailDigestVars returns the variables of the digest template: the recipient, the collected notifications and the
+// notifications grouped by application
+func emailDigestVars(recipient string, items []emailDigestItem, context map[string]string) map[string]interface{} {
+	var notifications []map[string]interface{}
+	byApp := map[string][]map[string]interface{}{}
+	for _, item := range items {
+		vars := item.vars()
+		notifications = append(notifications, vars)
+		byApp[item.app()] = append(byApp[item.app()], vars)
+	}
+	var apps []map[string]interface{}
+	for _, app := range sortedEmailDigestApps(items) {
+		appNotifications := byApp[app]
+		apps = append(apps, map[string]interface{}{
+			"name":          appNotifications[0]["appName"],
+			"namespace":     appNotifications[0]["appNamespace"],
+			"notifications": appNotifications,
+		})
+	}
+	return map[string]interface{}{
+		"recipient":     recipient,
+		"notifications": notifications,
+		"apps":          apps,
+		"count":         len(items),
+		"context":       context,
+	}
+}
+
+func sortedEmailDigestApps(items []emailDigestItem) []string {
+	seen := map[string]bool{}
+	var apps []string
+	for _, item := range items {
+		if !seen[item.app()] {
+			seen[item.app()] = true
+			apps = append(apps, item.app())
+		}
+	}
+	sort.Strings(apps)
+	return apps
+}
+
+// formatEmailDigest combines the collected notifications grouped by application into the digest email
+func formatEmailDigest(items []emailDigestItem) notifiers.Notification {
+	var groups []string
+	for _, app := range sortedEmailDigestApps(items) {
+		group := "Application " + app + ":"
+		if app == "" {
+			group = "Other notifications:"
+		}
+		for _, item := range items {
+			if item.app() != app {
+				continue
+			}
+			group += "\n\n" + item.time.UTC().Format(time.RFC3339) + " " + item.trigger
+			if item.notification.Title != "" {
+				group += "\n" + item.notification.Title
+			}
+			group += "\n" + item.notification.Body
+		}
+		groups = append(groups, group)
+	}
+	return notifiers.Notification{
+		Title: fmt.Sprintf("Digest of %d notification(s)", len(items)),
+		Body:  strings.Join(groups, "\n\n"),
+	}
+}
@@ -0,0 +1,284 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/queue"
+	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// escalator holds the configured escalation policies
+type escalator struct {
+	lock  sync.Mutex
+	rules []settings.Escalation
+}
+
+func (e *escalator) setRules(rules []settings.Escalation) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.rules = rules
+}
+
+// match returns the first escalation policy that matches the trigger or nil if notifications are not escalated
+func (e *escalator) match(trigger string) *settings.Escalation {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for i := range e.rules {
+		if e.rules[i].Matches(trigger) {
+			rule := e.rules[i]
+			return &rule
+		}
+	}
+	return nil
+}
+
+// escalation is the pending escalation of the application trigger notification
+type escalation struct {
+	// id identifies the escalation in the persistent queue
+	id           string
+	appName      string
+	appNamespace string
+	trigger      string
+	template     string
+	notification notifiers.Notification
+	notifiers    map[string]notifiers.Notifier
+	appRef       *corev1.ObjectReference
+	recorder     record.EventRecorder
+	// tiers are the tiers of the policy at the time the escalation started
+	tiers []settings.EscalationTier
+	// tier is the index of the next tier
+	tier int
+	// startedAt is the time of the escalated notification; the acknowledgements recorded since then stop escalation
+	startedAt     time.Time
+	correlationID string
+}
+
+func (e *escalation) key() string {
+	return e.appNamespace + "/" + e.appName + "/" + e.trigger
+}
+
+func (e *escalation) logEntry() *log.Entry {
+	return log.WithFields(log.Fields{
+		logFieldApp:           e.appNamespace + "/" + e.appName,
+		logFieldTrigger:       e.trigger,
+		logFieldCorrelationID: e.correlationID,
+	})
+}
+
+// SetEscalations updates escalation policies of the delivered notifications
+func (p *DeliveryPipeline) SetEscalations(rules []settings.Escalation) {
+	p.escalator.setRules(rules)
+}
+
+// startEscalation schedules the escalation of the application notification if the trigger matches an escalation
+// policy. Notifications of the already escalating application trigger do not restart the escalation. Escalation
+// requires the history store which holds the acknowledgements.
+func (p *DeliveryPipeline) startEscalation(d *delivery) {
+	if d.escalated || d.appName == "" || p.history == nil {
+		return
+	}
+	rule := p.escalator.match(d.trigger)
+	if rule == nil {
+		return
+	}
+	e := &escalation{
+		appName:       d.appName,
+		appNamespace:  d.appNamespace,
+		trigger:       d.trigger,
+		template:      d.template,
+		notification:  d.notification,
+		notifiers:     d.notifiers,
+		appRef:        d.appRef,
+		recorder:      d.recorder,
+		tiers:         rule.Tiers,
+		startedAt:     time.Now(),
+		correlationID: d.correlationID,
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.escalations[e.key()]; ok {
+		return
+	}
+	p.escalations[e.key()] = e
+	d.logEntry().Infof("Escalating %s notification in %v unless it is acknowledged", d.trigger, e.tiers[0].GetAfter())
+	p.saveEscalation(e, e.tiers[0].GetAfter())
+	p.queue.AddAfter(e, e.tiers[0].GetAfter())
+}
+
+// escalate notifies the recipients of the next tier unless the notification is acknowledged and schedules the
+// following tier
+func (p *DeliveryPipeline) escalate(ctx context.Context, e *escalation) {
+	entry := e.logEntry()
+	ack, err := history.GetAcknowledgement(p.history, e.appNamespace, e.appName, e.trigger, e.startedAt)
+	if err != nil {
+		// the unacknowledged notification is escalated if acknowledgements cannot be verified
+		entry.Warnf("Failed to get acknowledgements of %s notification: %v", e.trigger, err)
+	}
+	if ack != nil {
+		entry.Infof("Notification %s is acknowledged by %s, escalation is stopped", e.trigger, ack.User)
+		p.stopEscalation(e)
+		return
+	}
+	tier := e.tiers[e.tier]
+	e.tier++
+	p.metrics.IncEscalationsCounter(e.trigger, e.tier)
+	for _, recipient := range tier.Recipients {
+		parsed, err := sharedrecipients.Parse(recipient)
+		if err != nil {
+			entry.Errorf("Failed to escalate %s notification: %v", e.trigger, err)
+			continue
+		}
+		next := e.delivery()
+		next.service = parsed.Service
+		next.target = parsed.Target
+		next.options = parsed.Options()
+		p.recordEscalation(e, next.recipient())
+		next.logEntry().Warnf("Notification %s is not acknowledged in %v, escalating to %s", e.trigger, tier.GetAfter(), next.recipient())
+		if err := p.deliver(ctx, next); err != nil {
+			next.logEntry().Errorf("Failed to escalate %s notification to %s: %v", e.trigger, next.recipient(), err)
+		}
+	}
+	if e.tier < len(e.tiers) {
+		p.saveEscalation(e, e.tiers[e.tier].GetAfter())
+		p.queue.AddAfter(e, e.tiers[e.tier].GetAfter())
+	} else {
+		p.stopEscalation(e)
+	}
+}
+
+func (p *DeliveryPipeline) stopEscalation(e *escalation) {
+	p.lock.Lock()
+	if p.escalations[e.key()] == e {
+		delete(p.escalations, e.key())
+	}
+	p.lock.Unlock()
+	p.forgetEscalation(e)
+}
+
+// saveEscalation persists the escalation in the queue if it is configured, so the escalation survives controller
+// restarts
+func (p *DeliveryPipeline) saveEscalation(e *escalation, delay time.Duration) {
+	if p.opts.Queue == nil {
+		return
+	}
+	if e.id == "" {
+		e.id = newEscalationID(e)
+	}
+	if err := p.opts.Queue.Save(e.toQueueItem(time.Now().Add(delay))); err != nil {
+		e.logEntry().Warnf("Failed to persist escalation of %s notification: %v", e.trigger, err)
+	}
+}
+
+// forgetEscalation removes the escalation from the persistent queue
+func (p *DeliveryPipeline) forgetEscalation(e *escalation) {
+	if p.opts.Queue == nil || e.id == "" {
+		return
+	}
+	if err := p.opts.Queue.Delete(e.id); err != nil {
+		e.logEntry().Warnf("Failed to remove escalation of %s notification: %v", e.trigger, err)
+	}
+}
+
+// restoreEscalation schedules the next tier of the escalation restored from the persistent queue
+func (p *DeliveryPipeline) restoreEscalation(item queue.Item) {
+	e := escalationFromQueueItem(item)
+	if p.history == nil {
+		e.logEntry().Warnf("Escalation of %s notification is not restored, escalation requires the history store", e.trigger)
+		return
+	}
+	if e.tier >= len(e.tiers) {
+		p.forgetEscalation(e)
+		return
+	}
+	p.lock.Lock()
+	p.escalations[e.key()] = e
+	p.lock.Unlock()
+	e.logEntry().Infof("Restored escalation of %s notification", e.trigger)
+	p.queue.AddAfter(e, time.Until(item.NextAttempt))
+}
+
+func newEscalationID(e *escalation) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", e.key(), e.startedAt.UnixNano())))
+	return "escalation-" + hex.EncodeToString(sum[:])[:16]
+}
+
+func (e *escalation) toQueueItem(nextTier time.Time) queue.Item {
+	return queue.Item{
+		ID:            e.id,
+		App:           e.appName,
+		AppNamespace:  e.appNamespace,
+		Trigger:       e.trigger,
+		Template:      e.template,
+		Notification:  e.notification,
+		NextAttempt:   nextTier,
+		CorrelationID: e.correlationID,
+		Escalation: &queue.Escalation{
+			Tiers:     e.tiers,
+			Tier:      e.tier,
+			StartedAt: e.startedAt,
+		},
+	}
+}
+
+func escalationFromQueueItem(item queue.Item) *escalation {
+	return &escalation{
+		id:            item.ID,
+		appName:       item.App,
+		appNamespace:  item.AppNamespace,
+		trigger:       item.Trigger,
+		template:      item.Template,
+		notification:  item.Notification,
+		tiers:         item.Escalation.Tiers,
+		tier:          item.Escalation.Tier,
+		startedAt:     item.Escalation.StartedAt,
+		correlationID: item.CorrelationID,
+	}
+}
+
+// recordEscalation persists the escalation to the recipient, so the escalation state is visible in the history
+func (p *DeliveryPipeline) recordEscalation(e *escalation, recipient string) {
+	service, _, _ := splitRecipient(recipient)
+	event := history.Event{
+		App:          e.appName,
+		AppNamespace: e.appNamespace,
+		Trigger:      e.trigger,
+		Template:     e.template,
+		Recipient:    recipient,
+		Notifier:     service,
+		BodyHash:     history.HashNotification(e.notification),
+		Timestamp:    time.Now(),
+		Status:       history.StatusEscalated,
+	}
+	event.ID = history.NewEventID(event)
+	if err := p.history.Record(event); err != nil {
+		log.Warnf("Failed to record escalation of %s notification to %s in history: %v", e.trigger, recipient, err)
+	}
+}
+
+// delivery returns the delivery of the escalated notification to the tier recipient
+func (e *escalation) delivery() *delivery {
+	return &delivery{
+		appName:       e.appName,
+		appNamespace:  e.appNamespace,
+		trigger:       e.trigger,
+		template:      e.template,
+		notification:  e.notification,
+		notifiers:     e.notifiers,
+		appRef:        e.appRef,
+		recorder:      e.recorder,
+		correlationID: e.correlationID,
+		escalated:     true,
+	}
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+const (
+	eventBusReconnectDelay = 5 * time.Second
+)
+
+// ParseEventBusMessage converts the Argo Events EventBus message into the external event. The event type is
+// <event-source-name>.<event-name> and the payload is the event data. The application is referenced by the app and
+// appNamespace payload fields or fields of the payload body, so the webhook event source requests can reference the
+// application in the request body.
+func ParseEventBusMessage(data []byte) (ExternalEvent, error) {
+	var ce cloudEvent
+	if err := json.Unmarshal(data, &ce); err != nil {
+		return ExternalEvent{}, err
+	}
+	event, err := ce.toExternalEvent()
+	if err != nil {
+		return ExternalEvent{}, err
+	}
+	event.Type = ce.Source + "." + ce.Subject
+	event.App, event.AppNamespace = "", ""
+	for _, fields := range []interface{}{event.Payload, event.Payload["body"]} {
+		if fields, ok := fields.(map[string]interface{}); ok && event.App == "" {
+			event.App, _ = fields["app"].(string)
+			event.AppNamespace, _ = fields["appNamespace"].(string)
+		}
+	}
+	if event.App == "" {
+		return ExternalEvent{}, errors.New("event data does not reference the application")
+	}
+	return event, nil
+}
+
+// ConsumeEventBus subscribes to the EventBus subject and processes received events until the context is canceled.
+// The connection is re-established if it is lost.
+func ConsumeEventBus(ctx context.Context, opts notifiers.EventBusOptions, subject string, controllers func() []NotificationController) {
+	for {
+		conn, err := opts.Dial()
+		if err == nil {
+			done := make(chan struct{})
+			go func() {
+				select {
+				case <-ctx.Done():
+					_ = conn.Close()
+				case <-done:
+				}
+			}()
+			err = conn.Subscribe(subject, func(subject string, data []byte) {
+				logEntry := log.WithField("subject", subject)
+				event, err := ParseEventBusMessage(data)
+				if err != nil {
+					logEntry.Warnf("Failed to parse EventBus message: %v", err)
+					return
+				}
+				if err = DispatchEvent(ctx, controllers(), event); err != nil {
+					logEntry.Warnf("Failed to process EventBus event %s of app %s: %v", event.Type, event.App, err)
+				}
+			})
+			close(done)
+			_ = conn.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warnf("EventBus subscription %s is interrupted, reconnecting in %v: %v", subject, eventBusReconnectDelay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventBusReconnectDelay):
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEventBusMessage(t *testing.T) {
+	event, err := ParseEventBusMessage([]byte(`{
+  "specversion": "1.0", "id": "1", "source": "ci", "type": "webhook", "subject": "image-pushed",
+  "data": {"header": {}, "body": {"app": "guestbook", "image": "guestbook:v2"}}
+}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ci.image-pushed", event.Type)
+		assert.Equal(t, "guestbook", event.App)
+		assert.Equal(t, "", event.AppNamespace)
+	}
+
+	event, err = ParseEventBusMessage([]byte(`{
+  "specversion": "1.0", "id": "1", "source": "ci", "type": "nats", "subject": "image-pushed",
+  "data": {"app": "guestbook", "appNamespace": "argocd"}
+}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "guestbook", event.App)
+		assert.Equal(t, "argocd", event.AppNamespace)
+	}
+
+	_, err = ParseEventBusMessage([]byte(`{"specversion": "1.0", "source": "ci", "subject": "test", "data": "test"}`))
+	assert.EqualError(t, err, "event data does not reference the application")
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// NotificationSentReason is the reason of the application event emitted after the notification is delivered
+	NotificationSentReason = "NotificationSent"
+	// NotificationFailedReason is the reason of the application event emitted if the notification is not delivered
+	NotificationFailedReason = "NotificationFailed"
+)
+
+func appReference(app *unstructured.Unstructured) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion:      app.GetAPIVersion(),
+		Kind:            app.GetKind(),
+		Namespace:       app.GetNamespace(),
+		Name:            app.GetName(),
+		UID:             app.GetUID(),
+		ResourceVersion: app.GetResourceVersion(),
+	}
+}
+
+// emitAppEvent reports the final delivery outcome using Kubernetes event of the application
+func (p *DeliveryPipeline) emitAppEvent(d *delivery) {
+	if d.recorder == nil || d.appRef == nil || p.opts.DryRun {
+		return
+	}
+	if d.lastErr == nil {
+		d.recorder.Eventf(d.appRef, corev1.EventTypeNormal, NotificationSentReason,
+			"Sent %s notification to %s", d.trigger, d.recipient())
+	} else {
+		d.recorder.Eventf(d.appRef, corev1.EventTypeWarning, NotificationFailedReason,
+			"Failed to send %s notification to %s: %v", d.trigger, d.recipient(), d.lastErr)
+	}
+}
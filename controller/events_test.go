@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	notifiermocks "github.com/argoproj-labs/argocd-notifications/notifiers/mocks"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestDeliveryPipeline_EmitsAppEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").Return(nil)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "other").Return(errors.New("fail"))
+	recorder := record.NewFakeRecorder(10)
+	p := NewDeliveryPipeline(DeliveryOptions{}, NewMetricsRegistry(), nil)
+	appRef := appReference(NewApp("guestbook"))
+
+	assert.NoError(t, p.deliver(context.TODO(), &delivery{
+		trigger: "on-sync", service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+		notification: notifiers.Notification{Title: "hello"}, appRef: appRef, recorder: recorder,
+	}))
+	assert.Error(t, p.deliver(context.TODO(), &delivery{
+		trigger: "on-sync", service: "mock", target: "other", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+		notification: notifiers.Notification{Title: "hello"}, appRef: appRef, recorder: recorder,
+	}))
+
+	assert.Equal(t, "Normal NotificationSent Sent on-sync notification to mock:recipient", <-recorder.Events)
+	assert.Equal(t, "Warning NotificationFailed Failed to send on-sync notification to mock:other: fail", <-recorder.Events)
+}
+
+func TestDeliveryPipeline_EmitsAppEventAfterRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "hello"}, "recipient").Return(errors.New("fail")).Times(2)
+	recorder := record.NewFakeRecorder(10)
+	p := NewDeliveryPipeline(DeliveryOptions{MaxAttempts: 2, InitialBackoff: time.Millisecond}, NewMetricsRegistry(), nil)
+	go p.Run(ctx)
+
+	assert.NoError(t, p.deliver(context.TODO(), &delivery{
+		trigger: "on-sync", service: "mock", target: "recipient", notifiers: map[string]notifiers.Notifier{"mock": notifier},
+		notification: notifiers.Notification{Title: "hello"}, appRef: appReference(NewApp("guestbook")), recorder: recorder,
+	}))
+
+	select {
+	case event := <-recorder.Events:
+		assert.Equal(t, "Warning NotificationFailed Failed to send on-sync notification to mock:recipient: fail", event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event is not emitted")
+	}
+	assert.Len(t, recorder.Events, 0)
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/flood"
+)
+
+// FloodStatus describes the state of the flood protection
+type FloodStatus struct {
+	// Paused is true if the delivery is paused because of the notifications flood
+	Paused bool `json:"paused"`
+	// PausedAt is the time the delivery has been paused
+	PausedAt *time.Time `json:"pausedAt,omitempty"`
+	// Suppressed is the number of notifications which were not sent since the delivery has been paused
+	Suppressed int `json:"suppressed"`
+	// Threshold is the max number of notifications within the window
+	Threshold int `json:"threshold"`
+	// Window is the duration of the window, e.g. 5m0s
+	Window string `json:"window"`
+}
+
+// FloodController pauses and resumes the notifications delivery
+type FloodController interface {
+	// FloodStatus returns the state of the flood protection
+	FloodStatus() FloodStatus
+	// Resume resumes the paused delivery and returns false if the delivery is not paused
+	Resume() bool
+}
+
+// floodGuard pauses the delivery if more than threshold notifications are sent within the window. The delivery stays
+// paused until resume is called.
+type floodGuard struct {
+	threshold int
+	window    time.Duration
+	now       func() time.Time
+	// shared is the state shared by the controller replicas; the in-memory state is used if nil or if the shared
+	// state is not available
+	shared flood.Store
+
+	lock sync.Mutex
+	// sent holds the time of the notifications sent within the window in chronological order
+	sent       []time.Time
+	pausedAt   time.Time
+	paused     bool
+	suppressed int
+}
+
+func newFloodGuard(threshold int, window time.Duration, shared flood.Store) *floodGuard {
+	return &floodGuard{threshold: threshold, window: window, now: time.Now, shared: shared}
+}
+
+// allow returns false if the delivery is paused and true as the second result if the notification has just paused
+// the delivery
+func (g *floodGuard) allow() (bool, bool) {
+	if g.threshold <= 0 {
+		return true, false
+	}
+	if g.shared != nil {
+		allowed, tripped, err := g.shared.Allow(g.threshold, g.window)
+		if err == nil {
+			return allowed, tripped
+		}
+		log.Warnf("Failed to check shared flood protection state: %v", err)
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.paused {
+		g.suppressed++
+		return false, false
+	}
+	now := g.now()
+	expired := 0
+	for expired < len(g.sent) && now.Sub(g.sent[expired]) >= g.window {
+		expired++
+	}
+	g.sent = append(g.sent[expired:], now)
+	if len(g.sent) <= g.threshold {
+		return true, false
+	}
+	g.paused = true
+	g.pausedAt = now
+	g.suppressed = 1
+	g.sent = nil
+	return false, true
+}
+
+// suppress returns true if the delivery is paused, so the retried or delayed notification should be dropped
+func (g *floodGuard) suppress() bool {
+	if g.threshold <= 0 {
+		return false
+	}
+	if g.shared != nil {
+		suppressed, err := g.shared.Suppress()
+		if err == nil {
+			return suppressed
+		}
+		log.Warnf("Failed to check shared flood protection state: %v", err)
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.paused {
+		g.suppressed++
+	}
+	return g.paused
+}
+
+// resume resumes the delivery paused using either the shared or the in-memory state
+func (g *floodGuard) resume() bool {
+	resumed := false
+	if g.shared != nil {
+		var err error
+		if resumed, err = g.shared.Resume(); err != nil {
+			log.Warnf("Failed to resume shared flood protection: %v", err)
+		}
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if !g.paused {
+		return resumed
+	}
+	g.paused = false
+	g.suppressed = 0
+	return true
+}
+
+func (g *floodGuard) status() FloodStatus {
+	res := FloodStatus{Threshold: g.threshold, Window: g.window.String()}
+	if g.shared != nil {
+		pausedAt, suppressed, err := g.shared.Status()
+		if err == nil {
+			res.Paused, res.PausedAt, res.Suppressed = pausedAt != nil, pausedAt, suppressed
+			return res
+		}
+		log.Warnf("Failed to get shared flood protection state: %v", err)
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	res.Paused, res.Suppressed = g.paused, g.suppressed
+	if g.paused {
+		pausedAt := g.pausedAt
+		res.PausedAt = &pausedAt
+	}
+	return res
+}
+
+func formatFloodAlert(threshold int, window time.Duration, d *delivery) notifiers.Notification {
+	return notifiers.Notification{
+		Title: "Notification flood detected, delivery is paused",
+		Body: fmt.Sprintf("More than %d notifications were sent within %v, the delivery of notifications is paused "+
+			"and notifications are dropped until it is resumed using the admin API or the "+
+			"'argocd-notifications tools flood resume' command.\n\nThe last notification is the %s notification to %s:\n%s\n%s",
+			threshold, window, d.trigger, d.recipient(), d.notification.Title, d.notification.Body),
+	}
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloodGuard_Disabled(t *testing.T) {
+	guard := newFloodGuard(0, time.Minute, nil)
+	for i := 0; i < 10; i++ {
+		allowed, _ := guard.allow()
+		assert.True(t, allowed)
+	}
+	assert.False(t, guard.suppress())
+}
+
+func TestFloodGuard_PausesAfterThreshold(t *testing.T) {
+	guard := newFloodGuard(2, time.Minute, nil)
+	now := time.Now()
+	guard.now = func() time.Time { return now }
+
+	allowed, tripped := guard.allow()
+	assert.True(t, allowed)
+	assert.False(t, tripped)
+	now = now.Add(time.Minute)
+	// the first notification is outside of the window
+	allowed, _ = guard.allow()
+	assert.True(t, allowed)
+	allowed, _ = guard.allow()
+	assert.True(t, allowed)
+
+	allowed, tripped = guard.allow()
+	assert.False(t, allowed)
+	assert.True(t, tripped)
+	allowed, tripped = guard.allow()
+	assert.False(t, allowed)
+	assert.False(t, tripped)
+	assert.True(t, guard.suppress())
+
+	status := guard.status()
+	assert.True(t, status.Paused)
+	assert.Equal(t, 3, status.Suppressed)
+	assert.Equal(t, now, *status.PausedAt)
+}
+
+func TestFloodGuard_Resume(t *testing.T) {
+	guard := newFloodGuard(1, time.Minute, nil)
+	assert.False(t, guard.resume())
+	guard.allow()
+	guard.allow()
+	assert.True(t, guard.status().Paused)
+
+	assert.True(t, guard.resume())
+	assert.False(t, guard.status().Paused)
+	allowed, _ := guard.allow()
+	assert.True(t, allowed)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// DefaultResyncPeriod is the default interval of the applications re-evaluation
+	DefaultResyncPeriod = 60 * time.Second
+)
+
+// InformerOptions configures how the controller watches applications and projects
+type InformerOptions struct {
+	// ResyncPeriod is the interval of re-evaluating triggers of all applications; periodic resync is disabled if zero
+	ResyncPeriod time.Duration
+	// WatchTimeout limits the duration of watch requests; the API server default is used if zero
+	WatchTimeout time.Duration
+	// SkipUnchanged disables re-evaluation of applications which were not modified since the last evaluation, such as
+	// during the periodic resync and relisting after the watch expiration
+	SkipUnchanged bool
+}
+
+func newInformer(resClient dynamic.ResourceInterface, selector string, opts InformerOptions) cache.SharedIndexInformer {
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (object runtime.Object, err error) {
+				options.LabelSelector = selector
+				return resClient.List(options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = selector
+				if opts.WatchTimeout > 0 {
+					timeoutSeconds := int64(opts.WatchTimeout.Seconds())
+					options.TimeoutSeconds = &timeoutSeconds
+				}
+				return resClient.Watch(options)
+			},
+		},
+		&unstructured.Unstructured{},
+		opts.ResyncPeriod,
+		cache.Indexers{},
+	)
+	return informer
+}
+
+// isUnchanged returns true if the update notification is caused by the resync or relist and the object is not modified
+func isUnchanged(old interface{}, new interface{}) bool {
+	oldObj, ok := old.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+	newObj, ok := new.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+	return oldObj.GetResourceVersion() != "" && oldObj.GetResourceVersion() == newObj.GetResourceVersion()
+}
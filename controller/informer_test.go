@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestIsUnchanged(t *testing.T) {
+	old := NewApp("guestbook")
+	old.SetResourceVersion("1")
+	resynced := old.DeepCopy()
+	updated := old.DeepCopy()
+	updated.SetResourceVersion("2")
+
+	assert.True(t, isUnchanged(old, resynced))
+	assert.False(t, isUnchanged(old, updated))
+	assert.False(t, isUnchanged(NewApp("guestbook"), NewApp("guestbook")))
+	assert.False(t, isUnchanged("guestbook", updated))
+}
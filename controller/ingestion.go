@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+const (
+	maxEventSize = 1 << 20
+)
+
+// ExternalEvent is the event about the application posted by an external system, e.g. CI or image updater
+type ExternalEvent struct {
+	// App is the application name
+	App string `json:"app"`
+	// AppNamespace is the application namespace; the first controller that manages the application is used if empty
+	AppNamespace string `json:"appNamespace,omitempty"`
+	// Type is the event type which is matched with the event field of the triggers
+	Type string `json:"type"`
+	// Payload is the arbitrary event data available in the trigger conditions and templates as event.payload
+	Payload map[string]interface{} `json:"payload,omitempty"`
+	// ID and Source are the optional event attributes; CloudEvents always have both
+	ID     string `json:"id,omitempty"`
+	Source string `json:"source,omitempty"`
+}
+
+func (e ExternalEvent) vars() map[string]interface{} {
+	return map[string]interface{}{"type": e.Type, "payload": e.Payload, "id": e.ID, "source": e.Source}
+}
+
+type cloudEvent struct {
+	SpecVersion string          `json:"specversion"`
+	ID          string          `json:"id"`
+	Source      string          `json:"source"`
+	Type        string          `json:"type"`
+	Subject     string          `json:"subject"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// toExternalEvent converts the CloudEvent into the external event. The subject attribute references the application
+// in the [<namespace>/]<name> format. The JSON object data is used as the payload, other data is exposed as the
+// payload data field.
+func (e cloudEvent) toExternalEvent() (ExternalEvent, error) {
+	if e.SpecVersion != notifiers.CloudEventsSpecVersion {
+		return ExternalEvent{}, fmt.Errorf("CloudEvents spec version %s is not supported", e.SpecVersion)
+	}
+	event := ExternalEvent{App: e.Subject, Type: e.Type, ID: e.ID, Source: e.Source}
+	if parts := strings.SplitN(e.Subject, "/", 2); len(parts) == 2 {
+		event.AppNamespace, event.App = parts[0], parts[1]
+	}
+	if len(e.Data) > 0 {
+		if err := json.Unmarshal(e.Data, &event.Payload); err != nil {
+			var data interface{}
+			if err := json.Unmarshal(e.Data, &data); err != nil {
+				return ExternalEvent{}, err
+			}
+			event.Payload = map[string]interface{}{"data": data}
+		}
+	}
+	return event, nil
+}
+
+// parseEvent reads the external event, the structured and binary mode CloudEvents HTTP requests
+func parseEvent(w http.ResponseWriter, r *http.Request) (ExternalEvent, error) {
+	body := http.MaxBytesReader(w, r.Body, maxEventSize)
+	contentType := strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0])
+	switch {
+	case contentType == notifiers.CloudEventsContentType:
+		var ce cloudEvent
+		if err := json.NewDecoder(body).Decode(&ce); err != nil {
+			return ExternalEvent{}, err
+		}
+		return ce.toExternalEvent()
+	case r.Header.Get("Ce-Specversion") != "":
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return ExternalEvent{}, err
+		}
+		return cloudEvent{
+			SpecVersion: r.Header.Get("Ce-Specversion"),
+			ID:          r.Header.Get("Ce-Id"),
+			Source:      r.Header.Get("Ce-Source"),
+			Type:        r.Header.Get("Ce-Type"),
+			Subject:     r.Header.Get("Ce-Subject"),
+			Data:        data,
+		}.toExternalEvent()
+	}
+	var event ExternalEvent
+	err := json.NewDecoder(body).Decode(&event)
+	return event, err
+}
+
+func (c *notificationController) getApp(namespace string, name string) (*unstructured.Unstructured, error) {
+	var obj interface{}
+	var exists bool
+	var err error
+	if namespace != "" {
+		obj, exists, err = c.appInformer.GetIndexer().GetByKey(namespace + "/" + name)
+	} else {
+		for _, item := range c.appInformer.GetIndexer().List() {
+			if app, ok := item.(*unstructured.Unstructured); ok && app.GetName() == name {
+				obj, exists = app, true
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	app, ok := obj.(*unstructured.Unstructured)
+	if !exists || !ok || !c.sharding.Owns(app) {
+		return nil, errAppNotManaged
+	}
+	return app.DeepCopy(), nil
+}
+
+// ProcessEvent evaluates triggers of the external event type and sends notifications to the trigger recipients. The
+// event notifications are sent every time the trigger condition is true, so the notification state is not updated.
+func (c *notificationController) ProcessEvent(ctx context.Context, event ExternalEvent) error {
+	app, err := c.getApp(event.AppNamespace, event.App)
+	if err != nil {
+		return err
+	}
+	vars := event.vars()
+	logEntry := log.WithFields(log.Fields{logFieldApp: app.GetNamespace() + "/" + app.GetName(), "event": event.Type})
+	for triggerKey, t := range c.triggers {
+		et, ok := t.(triggers.EventTrigger)
+		if !ok || et.GetEvent() != event.Type {
+			continue
+		}
+		correlationID := newCorrelationID()
+		logEntry := logEntry.WithFields(log.Fields{logFieldTrigger: triggerKey, logFieldCorrelationID: correlationID})
+		triggerCtx, span := tracer().Start(ctx, spanTriggerEvaluate, trace.WithAttributes(
+			attrTrigger.String(triggerKey), attrCorrelationID.String(correlationID)))
+		triggered, err := et.TriggeredByEvent(app, vars)
+		span.SetAttributes(attrTriggered.Bool(triggered))
+		endSpan(span, err)
+		if err != nil {
+			logEntry.Debugf("Failed to execute condition of trigger %s: %v", triggerKey, err)
+		}
+		c.metricsRegistry.IncTriggerEvaluationsCounter(triggerKey, triggered)
+		logEntry.Infof("Trigger %s result: %v", triggerKey, triggered)
+		if !triggered {
+			continue
+		}
+		for recipient := range c.getRecipients(app, triggerKey) {
+			logEntry := logEntry.WithField(logFieldRecipient, recipient)
+			resolved, err := c.authors.resolve(ctx, app, recipient)
+			if err != nil {
+				logEntry.Warnf("Failed to resolve commit author of recipient %s: %v", recipient, err)
+				continue
+			}
+			primary, fallbacks, err := c.parseRecipientChain(resolved)
+			if err != nil {
+				return err
+			}
+			schedule := c.subscriptions.GetSchedule(triggerKey, app, recipient)
+			if schedule != nil && !schedule.IsActive(time.Now()) && !schedule.Digest {
+				logEntry.Infof("%s notification to %s is dropped outside of the schedule window", triggerKey, recipient)
+				continue
+			}
+			templateCtx := c.templateContext(app.GetNamespace(), app.GetName(), triggerKey, primary.Service, resolved)
+			templateName := et.GetTemplateName()
+			renderCtx, span := tracer().Start(triggerCtx, spanTemplateRender, trace.WithAttributes(
+				attrTemplate.String(templateName), attrRecipient.String(recipient)))
+			notification, err := et.FormatEventNotification(app, vars, templateCtx)
+			endSpan(span, err)
+			if err != nil {
+				logEntry.Errorf("Failed to render template %s: %v", templateName, err)
+				c.metricsRegistry.IncTemplateRenderErrorsCounter(templateName)
+				return err
+			}
+			if schedule != nil && !schedule.IsActive(time.Now()) {
+				logEntry.Infof("%s notification to %s is added to the digest", triggerKey, recipient)
+				c.digest.add(resolved, *schedule, digestItem{
+					appName: app.GetName(), appNamespace: app.GetNamespace(), notification: *notification,
+				})
+				continue
+			}
+			err = c.delivery.deliver(ctx, &delivery{
+				appName:       app.GetName(),
+				appNamespace:  app.GetNamespace(),
+				trigger:       triggerKey,
+				template:      templateName,
+				service:       primary.Service,
+				target:        primary.Target,
+				options:       primary.Options(),
+				fallbacks:     fallbacks,
+				notifiers:     c.notifiers,
+				notification:  *notification,
+				correlationID: correlationID,
+				spanContext:   trace.SpanContextFromContext(renderCtx),
+				appRef:        appReference(app),
+				recorder:      c.recorder,
+			})
+			if err != nil {
+				logEntry.Errorf("Failed to notify recipient %s defined in app %s/%s: %v",
+					recipient, app.GetNamespace(), app.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// DispatchEvent processes the event using the first controller that manages the event application
+func DispatchEvent(ctx context.Context, controllers []NotificationController, event ExternalEvent) error {
+	err := errAppNotManaged
+	for i := 0; i < len(controllers) && err == errAppNotManaged; i++ {
+		err = controllers[i].ProcessEvent(ctx, event)
+	}
+	return err
+}
+
+// NewEventsHandler returns HTTP handler that accepts JSON formatted external events and CloudEvents. The request
+// should include bearer token.
+func NewEventsHandler(controllers func() []NotificationController, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("method %s is not supported", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		actual := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(actual)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		event, err := parseEvent(w, r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to parse event: %v", err), http.StatusBadRequest)
+			return
+		}
+		if event.App == "" || event.Type == "" {
+			http.Error(w, "event app and type must be specified", http.StatusBadRequest)
+			return
+		}
+		err = DispatchEvent(r.Context(), controllers(), event)
+		switch {
+		case errors.Is(err, errAppNotManaged):
+			http.Error(w, fmt.Sprintf("application %s is not found", event.App), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func TestProcessEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("guestbook", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "mock:recipient",
+	}))
+	ctrl, _, notifier, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctrl.triggers, err = triggers.GetTriggers([]triggers.NotificationTemplate{{
+		Name:         "image-updated",
+		Notification: notifiers.Notification{Title: "{{.app.metadata.name}} uses {{.event.payload.image}}"},
+	}}, []triggers.NotificationTrigger{{
+		Name: "on-image-updated", Template: "image-updated", Event: "image-updated", Condition: "event.payload.image != 'skip'",
+	}}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{
+		Title: "guestbook uses guestbook:v2", Webhook: map[string]notifiers.WebhookNotification{},
+	}, "recipient").Return(nil).Times(2)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, ctrl.ProcessEvent(context.Background(), ExternalEvent{
+			App: "guestbook", Type: "image-updated", Payload: map[string]interface{}{"image": "guestbook:v2"},
+		}))
+	}
+	assert.NoError(t, ctrl.ProcessEvent(context.Background(), ExternalEvent{
+		App: "guestbook", Type: "image-updated", Payload: map[string]interface{}{"image": "skip"},
+	}))
+	assert.NoError(t, ctrl.ProcessEvent(context.Background(), ExternalEvent{App: "guestbook", Type: "other"}))
+	assert.Equal(t, errAppNotManaged, ctrl.ProcessEvent(context.Background(), ExternalEvent{App: "unknown", Type: "image-updated"}))
+
+	// event triggers are not evaluated on application changes
+	assert.NoError(t, ctrl.processApp(context.Background(), app, logEntry))
+}
+
+func TestEventsHandler(t *testing.T) {
+	handler := NewEventsHandler(func() []NotificationController { return nil }, "secret")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/events", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	for body, code := range map[string]int{
+		`{`:                                    http.StatusBadRequest,
+		`{"app": "guestbook"}`:                 http.StatusBadRequest,
+		`{"app": "guestbook", "type": "test"}`: http.StatusNotFound,
+	} {
+		w = httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/events", bytes.NewBufferString(body))
+		r.Header.Set("Authorization", "Bearer secret")
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, code, w.Code, body)
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/events", bytes.NewBufferString(`{
+  "specversion": "1.0", "id": "1", "source": "ci", "type": "image-updated", "subject": "guestbook"
+}`))
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("Content-Type", notifiers.CloudEventsContentType)
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestParseEvent_CloudEvents(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/events", bytes.NewBufferString(`{
+  "specversion": "1.0", "id": "1", "source": "ci", "type": "image-updated", "subject": "argocd/guestbook",
+  "data": {"image": "guestbook:v2"}
+}`))
+	r.Header.Set("Content-Type", "application/cloudevents+json; charset=utf-8")
+	event, err := parseEvent(httptest.NewRecorder(), r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ExternalEvent{
+			App: "guestbook", AppNamespace: "argocd", Type: "image-updated", ID: "1", Source: "ci",
+			Payload: map[string]interface{}{"image": "guestbook:v2"},
+		}, event)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/api/events", bytes.NewBufferString(`"guestbook:v2"`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Ce-Specversion", "1.0")
+	r.Header.Set("Ce-Id", "2")
+	r.Header.Set("Ce-Source", "ci")
+	r.Header.Set("Ce-Type", "image-updated")
+	r.Header.Set("Ce-Subject", "guestbook")
+	event, err = parseEvent(httptest.NewRecorder(), r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ExternalEvent{
+			App: "guestbook", Type: "image-updated", ID: "2", Source: "ci",
+			Payload: map[string]interface{}{"data": "guestbook:v2"},
+		}, event)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/api/events", nil)
+	r.Header.Set("Ce-Specversion", "0.3")
+	_, err = parseEvent(httptest.NewRecorder(), r)
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	logFieldApp           = "app"
+	logFieldTrigger       = "trigger"
+	logFieldRecipient     = "recipient"
+	logFieldCorrelationID = "correlation_id"
+	logFieldMessageID     = "message_id"
+)
+
+// newCorrelationID returns random ID which ties together log entries of trigger evaluation, template rendering and
+// delivery of a single notification
+func newCorrelationID() string {
+	data := make([]byte, 8)
+	if _, err := rand.Read(data); err != nil {
+		log.Warnf("Failed to generate correlation ID: %v", err)
+	}
+	return hex.EncodeToString(data)
+}
+
+// logEntry returns log entry with the delivery fields
+func (d *delivery) logEntry() *log.Entry {
+	fields := log.Fields{logFieldTrigger: d.trigger, logFieldRecipient: d.recipient()}
+	if d.appName != "" {
+		fields[logFieldApp] = d.appNamespace + "/" + d.appName
+	}
+	if d.correlationID != "" {
+		fields[logFieldCorrelationID] = d.correlationID
+	}
+	return log.WithFields(fields)
+}
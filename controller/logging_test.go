@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestProcessApp_LogsCorrelationID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "mock:recipient",
+	}))
+	ctrl, trigger, notifier, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+
+	trigger.EXPECT().GetTemplateName().Return("test")
+	trigger.EXPECT().Triggered(app).Return(true, nil)
+	trigger.EXPECT().FormatNotification(app, map[string]string{"notificationType": "mock"}).Return(
+		&notifiers.Notification{Title: "title", Body: "body"}, nil)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "title", Body: "body"}, "recipient").Return(nil)
+
+	logger, hook := test.NewNullLogger()
+	globalHook := test.NewGlobal()
+	defer globalHook.Reset()
+
+	err = ctrl.processApp(context.Background(), app, logrus.NewEntry(logger))
+	assert.NoError(t, err)
+
+	entries := append(hook.AllEntries(), globalHook.AllEntries()...)
+	if !assert.NotEmpty(t, entries) {
+		return
+	}
+	correlationID := entries[0].Data[logFieldCorrelationID]
+	assert.NotEmpty(t, correlationID)
+	for _, entry := range entries {
+		assert.Equal(t, correlationID, entry.Data[logFieldCorrelationID], entry.Message)
+		assert.Equal(t, "mock", entry.Data[logFieldTrigger], entry.Message)
+	}
+	last := globalHook.LastEntry()
+	if assert.NotNil(t, last) {
+		assert.Equal(t, "mock:recipient", last.Data[logFieldRecipient])
+		assert.Equal(t, "default/test", last.Data[logFieldApp])
+	}
+}
+
+func TestDeliveryQueueItem_KeepsCorrelationID(t *testing.T) {
+	d := &delivery{trigger: "on-sync", service: "mock", target: "recipient", correlationID: "abc"}
+	assert.Equal(t, "abc", fromQueueItem(d.toQueueItem(time.Now())).correlationID)
+}
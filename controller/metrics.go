@@ -2,17 +2,49 @@ package controller
 
 import (
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+const (
+	eventIDExemplarLabel = "event_id"
+	otherAppLabel        = "other"
+)
+
 var (
 	deliveriesCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "argocd_notifications_deliveries_total",
 			Help: "Number of delivered notifications.",
 		},
-		[]string{"template", "notifier", "succeeded"},
+		[]string{"template", "trigger", "notifier", "succeeded"},
+	)
+
+	deliveryDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "argocd_notifications_delivery_duration_seconds",
+			Help:    "Duration of notification service calls.",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"notifier"},
+	)
+
+	notifierErrorsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_notifier_errors_total",
+			Help: "Number of errors returned by notification services.",
+		},
+		[]string{"notifier", "reason"},
+	)
+
+	templateRenderErrorsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_template_render_errors_total",
+			Help: "Number of notification template rendering errors.",
+		},
+		[]string{"template"},
 	)
 
 	triggerEvaluationsCounter = prometheus.NewCounterVec(
@@ -22,29 +54,315 @@ var (
 		},
 		[]string{"name", "triggered"},
 	)
+
+	configReloadsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_config_reloads_total",
+			Help: "Number of configuration reloads.",
+		},
+		[]string{"succeeded"},
+	)
+
+	deliveryRetriesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_delivery_retries_total",
+			Help: "Number of scheduled retries of the failed notifications.",
+		},
+		[]string{"notifier"},
+	)
+
+	deadLettersCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_dead_letters_total",
+			Help: "Number of notifications which were not delivered after max attempts.",
+		},
+		[]string{"notifier"},
+	)
+
+	failoversCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_failovers_total",
+			Help: "Number of failed notifications which were delivered to the next recipient of the fallback chain.",
+		},
+		[]string{"notifier"},
+	)
+
+	circuitBreakerOpenGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "argocd_notifications_circuit_breaker_open",
+			Help: "Whether the notifier circuit breaker is open.",
+		},
+		[]string{"notifier"},
+	)
+
+	circuitBreakerRejectionsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_circuit_breaker_rejections_total",
+			Help: "Number of notifications which were not sent because the notifier circuit breaker is open.",
+		},
+		[]string{"notifier"},
+	)
+
+	rateLimitedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_rate_limited_total",
+			Help: "Number of notifications which exceeded the rate limit.",
+		},
+		[]string{"notifier", "overflow"},
+	)
+
+	appDeliveriesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_app_deliveries_total",
+			Help: "Number of delivered notifications per application.",
+		},
+		[]string{"app", "notifier", "succeeded"},
+	)
+
+	appPatchConflictsCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_app_patch_conflicts_total",
+			Help: "Number of application annotation patches retried due to the conflict.",
+		},
+	)
+
+	dryRunDeliveriesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_dry_run_deliveries_total",
+			Help: "Number of notifications which were not sent because the controller runs in dry-run mode.",
+		},
+		[]string{"trigger", "notifier"},
+	)
+
+	silencedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_silenced_total",
+			Help: "Number of notifications which were not sent because of the matching silence.",
+		},
+		[]string{"trigger", "notifier"},
+	)
+
+	deliveryPausedGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "argocd_notifications_delivery_paused",
+			Help: "Whether the delivery is paused by the flood protection.",
+		},
+	)
+
+	floodSuppressedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_flood_suppressed_total",
+			Help: "Number of notifications which were not sent because the delivery is paused by the flood protection.",
+		},
+		[]string{"trigger", "notifier"},
+	)
+
+	escalationsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_escalations_total",
+			Help: "Number of unacknowledged notifications escalated to the next recipient tier.",
+		},
+		[]string{"trigger", "tier"},
+	)
 )
 
 func NewMetricsRegistry() *controllerRegistry {
 	registry := &controllerRegistry{
-		Registry:                  prometheus.NewRegistry(),
-		deliveriesCounter:         deliveriesCounter,
-		triggerEvaluationsCounter: triggerEvaluationsCounter,
+		Registry:                        prometheus.NewRegistry(),
+		deliveriesCounter:               deliveriesCounter,
+		triggerEvaluationsCounter:       triggerEvaluationsCounter,
+		deliveryDurationHistogram:       deliveryDurationHistogram,
+		notifierErrorsCounter:           notifierErrorsCounter,
+		templateRenderErrorsCounter:     templateRenderErrorsCounter,
+		configReloadsCounter:            configReloadsCounter,
+		deliveryRetriesCounter:          deliveryRetriesCounter,
+		deadLettersCounter:              deadLettersCounter,
+		failoversCounter:                failoversCounter,
+		circuitBreakerOpenGauge:         circuitBreakerOpenGauge,
+		circuitBreakerRejectionsCounter: circuitBreakerRejectionsCounter,
+		rateLimitedCounter:              rateLimitedCounter,
+		appDeliveriesCounter:            appDeliveriesCounter,
+		appPatchConflictsCounter:        appPatchConflictsCounter,
+		dryRunDeliveriesCounter:         dryRunDeliveriesCounter,
+		silencedCounter:                 silencedCounter,
+		deliveryPausedGauge:             deliveryPausedGauge,
+		floodSuppressedCounter:          floodSuppressedCounter,
+		escalationsCounter:              escalationsCounter,
 	}
 	registry.MustRegister(deliveriesCounter)
 	registry.MustRegister(triggerEvaluationsCounter)
+	registry.MustRegister(deliveryDurationHistogram)
+	registry.MustRegister(notifierErrorsCounter)
+	registry.MustRegister(templateRenderErrorsCounter)
+	registry.MustRegister(configReloadsCounter)
+	registry.MustRegister(deliveryRetriesCounter)
+	registry.MustRegister(deadLettersCounter)
+	registry.MustRegister(failoversCounter)
+	registry.MustRegister(circuitBreakerOpenGauge)
+	registry.MustRegister(circuitBreakerRejectionsCounter)
+	registry.MustRegister(rateLimitedCounter)
+	registry.MustRegister(appDeliveriesCounter)
+	registry.MustRegister(appPatchConflictsCounter)
+	registry.MustRegister(dryRunDeliveriesCounter)
+	registry.MustRegister(silencedCounter)
+	registry.MustRegister(deliveryPausedGauge)
+	registry.MustRegister(floodSuppressedCounter)
+	registry.MustRegister(escalationsCounter)
 	return registry
 }
 
 type controllerRegistry struct {
 	*prometheus.Registry
-	deliveriesCounter         *prometheus.CounterVec
-	triggerEvaluationsCounter *prometheus.CounterVec
+	deliveriesCounter               *prometheus.CounterVec
+	triggerEvaluationsCounter       *prometheus.CounterVec
+	deliveryDurationHistogram       *prometheus.HistogramVec
+	notifierErrorsCounter           *prometheus.CounterVec
+	templateRenderErrorsCounter     *prometheus.CounterVec
+	configReloadsCounter            *prometheus.CounterVec
+	deliveryRetriesCounter          *prometheus.CounterVec
+	deadLettersCounter              *prometheus.CounterVec
+	failoversCounter                *prometheus.CounterVec
+	circuitBreakerOpenGauge         *prometheus.GaugeVec
+	circuitBreakerRejectionsCounter *prometheus.CounterVec
+	rateLimitedCounter              *prometheus.CounterVec
+	appDeliveriesCounter            *prometheus.CounterVec
+	appPatchConflictsCounter        prometheus.Counter
+	dryRunDeliveriesCounter         *prometheus.CounterVec
+	silencedCounter                 *prometheus.CounterVec
+	deliveryPausedGauge             prometheus.Gauge
+	floodSuppressedCounter          *prometheus.CounterVec
+	escalationsCounter              *prometheus.CounterVec
+	appLabels                       appLabels
+}
+
+// appLabels limits the number of distinct application label values
+type appLabels struct {
+	lock  sync.Mutex
+	limit int
+	seen  map[string]bool
+}
+
+// get returns the label value of the specified application and false if application labels are disabled
+func (l *appLabels) get(app string) (string, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.limit <= 0 {
+		return "", false
+	}
+	if l.seen == nil {
+		l.seen = map[string]bool{}
+	}
+	if !l.seen[app] {
+		if len(l.seen) >= l.limit {
+			return otherAppLabel, true
+		}
+		l.seen[app] = true
+	}
+	return app, true
+}
+
+// IncDeliveriesCounter increments deliveries counter. The failed delivery counter exemplar references the history event
+// if event id is not empty.
+func (r *controllerRegistry) IncDeliveriesCounter(template string, trigger string, notifier string, succeeded bool, eventID string) {
+	counter := r.deliveriesCounter.WithLabelValues(template, trigger, notifier, strconv.FormatBool(succeeded))
+	if adder, ok := counter.(prometheus.ExemplarAdder); ok && !succeeded && eventID != "" {
+		adder.AddWithExemplar(1, prometheus.Labels{eventIDExemplarLabel: eventID})
+		return
+	}
+	counter.Inc()
+}
+
+// EnableAppLabels enables per application deliveries counter. The number of distinct applications is limited to avoid
+// metrics cardinality explosion; deliveries of applications above the limit are counted using the "other" label.
+func (r *controllerRegistry) EnableAppLabels(limit int) {
+	r.appLabels.lock.Lock()
+	defer r.appLabels.lock.Unlock()
+	r.appLabels.limit = limit
+}
+
+func (r *controllerRegistry) IncAppDeliveriesCounter(appNamespace string, appName string, notifier string, succeeded bool) {
+	if appName == "" {
+		return
+	}
+	app, ok := r.appLabels.get(appNamespace + "/" + appName)
+	if !ok {
+		return
+	}
+	r.appDeliveriesCounter.WithLabelValues(app, notifier, strconv.FormatBool(succeeded)).Inc()
+}
+
+func (r *controllerRegistry) IncAppPatchConflictsCounter() {
+	r.appPatchConflictsCounter.Inc()
+}
+
+func (r *controllerRegistry) IncDryRunDeliveriesCounter(trigger string, notifier string) {
+	r.dryRunDeliveriesCounter.WithLabelValues(trigger, notifier).Inc()
 }
 
-func (r *controllerRegistry) IncDeliveriesCounter(template string, notifier string, succeeded bool) {
-	r.deliveriesCounter.WithLabelValues(template, notifier, strconv.FormatBool(succeeded)).Inc()
+func (r *controllerRegistry) IncSilencedCounter(trigger string, notifier string) {
+	r.silencedCounter.WithLabelValues(trigger, notifier).Inc()
+}
+
+func (r *controllerRegistry) IncFloodSuppressedCounter(trigger string, notifier string) {
+	r.floodSuppressedCounter.WithLabelValues(trigger, notifier).Inc()
+}
+
+func (r *controllerRegistry) IncEscalationsCounter(trigger string, tier int) {
+	r.escalationsCounter.WithLabelValues(trigger, strconv.Itoa(tier)).Inc()
+}
+
+func (r *controllerRegistry) SetDeliveryPaused(paused bool) {
+	val := 0.0
+	if paused {
+		val = 1
+	}
+	r.deliveryPausedGauge.Set(val)
+}
+
+func (r *controllerRegistry) ObserveDeliveryDuration(notifier string, duration time.Duration) {
+	r.deliveryDurationHistogram.WithLabelValues(notifier).Observe(duration.Seconds())
+}
+
+func (r *controllerRegistry) IncNotifierErrorsCounter(notifier string, reason string) {
+	r.notifierErrorsCounter.WithLabelValues(notifier, reason).Inc()
+}
+
+func (r *controllerRegistry) IncTemplateRenderErrorsCounter(template string) {
+	r.templateRenderErrorsCounter.WithLabelValues(template).Inc()
 }
 
 func (r *controllerRegistry) IncTriggerEvaluationsCounter(name string, triggered bool) {
 	r.triggerEvaluationsCounter.WithLabelValues(name, strconv.FormatBool(triggered)).Inc()
 }
+
+func (r *controllerRegistry) IncConfigReloadsCounter(succeeded bool) {
+	r.configReloadsCounter.WithLabelValues(strconv.FormatBool(succeeded)).Inc()
+}
+
+func (r *controllerRegistry) IncDeliveryRetriesCounter(notifier string) {
+	r.deliveryRetriesCounter.WithLabelValues(notifier).Inc()
+}
+
+func (r *controllerRegistry) IncDeadLettersCounter(notifier string) {
+	r.deadLettersCounter.WithLabelValues(notifier).Inc()
+}
+
+func (r *controllerRegistry) IncFailoversCounter(notifier string) {
+	r.failoversCounter.WithLabelValues(notifier).Inc()
+}
+
+func (r *controllerRegistry) SetCircuitBreakerOpen(notifier string, open bool) {
+	val := 0.0
+	if open {
+		val = 1
+	}
+	r.circuitBreakerOpenGauge.WithLabelValues(notifier).Set(val)
+}
+
+func (r *controllerRegistry) IncCircuitBreakerRejectionsCounter(notifier string) {
+	r.circuitBreakerRejectionsCounter.WithLabelValues(notifier).Inc()
+}
+
+func (r *controllerRegistry) IncRateLimitedCounter(notifier string, overflow string) {
+	r.rateLimitedCounter.WithLabelValues(notifier, overflow).Inc()
+}
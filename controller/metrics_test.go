@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	notifiermocks "github.com/argoproj-labs/argocd-notifications/notifiers/mocks"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+)
+
+func TestDeliveryMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	gomock.InOrder(
+		notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "recipient").Return(nil),
+		notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "recipient").Return(errors.New("fail")),
+	)
+	registry := NewMetricsRegistry()
+	p := NewDeliveryPipeline(DeliveryOptions{}, registry, nil)
+	newDelivery := func() *delivery {
+		return &delivery{
+			trigger: "on-sync", template: "app-sync", service: "metrics-test", target: "recipient",
+			notifiers: map[string]notifiers.Notifier{"metrics-test": notifier},
+		}
+	}
+
+	assert.NoError(t, p.deliver(context.TODO(), newDelivery()))
+	assert.Error(t, p.deliver(context.TODO(), newDelivery()))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(registry.deliveriesCounter.WithLabelValues("app-sync", "on-sync", "metrics-test", "true")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(registry.deliveriesCounter.WithLabelValues("app-sync", "on-sync", "metrics-test", "false")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(registry.notifierErrorsCounter.WithLabelValues("metrics-test", notifierErrorAPI)))
+}
+
+func TestDeliveryMetrics_FailedDeliveryExemplar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	notifier.EXPECT().Send(gomock.Any(), gomock.Any(), "recipient").Return(errors.New("fail"))
+	registry := NewMetricsRegistry()
+	store := history.NewMemoryStore(0)
+	p := NewDeliveryPipeline(DeliveryOptions{}, registry, store)
+
+	assert.Error(t, p.deliver(context.TODO(), &delivery{
+		appName: "guestbook", trigger: "on-sync", template: "app-sync", service: "exemplar-test", target: "recipient",
+		notifiers: map[string]notifiers.Notifier{"exemplar-test": notifier},
+	}))
+
+	events, err := store.List(history.Filter{App: "guestbook"})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.NotEmpty(t, events[0].ID)
+
+	metric := &dto.Metric{}
+	err = registry.deliveriesCounter.WithLabelValues("app-sync", "on-sync", "exemplar-test", "false").(prometheus.Metric).Write(metric)
+	assert.NoError(t, err)
+	exemplar := metric.GetCounter().GetExemplar()
+	if assert.NotNil(t, exemplar) {
+		assert.Equal(t, eventIDExemplarLabel, exemplar.Label[0].GetName())
+		assert.Equal(t, events[0].ID, exemplar.Label[0].GetValue())
+	}
+}
+
+func TestAppDeliveriesCounter(t *testing.T) {
+	registry := NewMetricsRegistry()
+	registry.IncAppDeliveriesCounter("default", "app-labels-disabled", "slack", true)
+	assert.Equal(t, float64(0), testutil.ToFloat64(registry.appDeliveriesCounter.WithLabelValues("default/app-labels-disabled", "slack", "true")))
+
+	registry.EnableAppLabels(2)
+	registry.IncAppDeliveriesCounter("default", "app1", "app-labels-test", true)
+	registry.IncAppDeliveriesCounter("default", "app2", "app-labels-test", true)
+	registry.IncAppDeliveriesCounter("default", "app3", "app-labels-test", true)
+	registry.IncAppDeliveriesCounter("default", "app1", "app-labels-test", true)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(registry.appDeliveriesCounter.WithLabelValues("default/app1", "app-labels-test", "true")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(registry.appDeliveriesCounter.WithLabelValues("default/app2", "app-labels-test", "true")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(registry.appDeliveriesCounter.WithLabelValues(otherAppLabel, "app-labels-test", "true")))
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"sort"
+	"time"
+)
+
+// orderingKey returns the key of deliveries which should be sent in order or empty string if the delivery is not
+// ordered. The digest and rate limit summary notifications are not associated with the application and not ordered.
+func (p *DeliveryPipeline) orderingKey(d *delivery) string {
+	if d.appName == "" || !(p.opts.OrderedNotifiers[d.service] || p.opts.OrderedNotifiers[OrderAllNotifiers]) {
+		return ""
+	}
+	return d.appNamespace + "/" + d.appName + "/" + d.recipient()
+}
+
+// enqueueOrdered assigns the sequence number to the new ordered delivery and adds it to the end of the ordering backlog
+func (p *DeliveryPipeline) enqueueOrdered(d *delivery) {
+	key := p.orderingKey(d)
+	if key == "" {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if d.sequence == 0 {
+		p.sequences[key]++
+		d.sequence = p.sequences[key]
+	} else if d.sequence > p.sequences[key] {
+		p.sequences[key] = d.sequence
+	}
+	backlog := append(p.backlogs[key], d)
+	sort.SliceStable(backlog, func(i, j int) bool {
+		return backlog[i].sequence < backlog[j].sequence
+	})
+	p.backlogs[key] = backlog
+}
+
+// acquireOrdered returns true if all earlier notifications of the same application and recipient were delivered. The
+// delivery that has to wait is persisted and is sent after the earlier deliveries are completed.
+func (p *DeliveryPipeline) acquireOrdered(d *delivery) bool {
+	key := p.orderingKey(d)
+	if key == "" {
+		return true
+	}
+	p.lock.Lock()
+	backlog := p.backlogs[key]
+	if len(backlog) == 0 || backlog[0] == d {
+		p.lock.Unlock()
+		return true
+	}
+	d.waiting = true
+	p.lock.Unlock()
+	d.logEntry().Infof("%s notification to %s is waiting for %d earlier notification(s)", d.trigger, d.recipient(), len(backlog)-1)
+	p.save(d, time.Now())
+	return false
+}
+
+// releaseOrdered removes the completed delivery from the backlog and sends the next delivery if it is waiting
+func (p *DeliveryPipeline) releaseOrdered(d *delivery) {
+	key := p.orderingKey(d)
+	if key == "" {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	backlog := p.backlogs[key]
+	for i := range backlog {
+		if backlog[i] == d {
+			backlog = append(backlog[:i], backlog[i+1:]...)
+			break
+		}
+	}
+	if len(backlog) == 0 {
+		delete(p.backlogs, key)
+		return
+	}
+	p.backlogs[key] = backlog
+	if next := backlog[0]; next.waiting {
+		next.waiting = false
+		p.queue.Add(next)
+	}
+}
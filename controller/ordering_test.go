@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	notifiermocks "github.com/argoproj-labs/argocd-notifications/notifiers/mocks"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestDeliveryPipeline_OrderedDelivery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := notifiermocks.NewMockNotifier(ctrl)
+	delivered := make(chan string, 3)
+	record := func(_ context.Context, n notifiers.Notification, _ string) error {
+		delivered <- n.Title
+		return nil
+	}
+	gomock.InOrder(
+		notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "running"}, "recipient").Return(errors.New("fail")),
+		notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "running"}, "recipient").DoAndReturn(record),
+		notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "succeeded"}, "recipient").DoAndReturn(record),
+	)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "other"}, "other").DoAndReturn(record)
+	p := NewDeliveryPipeline(DeliveryOptions{
+		MaxAttempts: 3, InitialBackoff: 50 * time.Millisecond, OrderedNotifiers: map[string]bool{"mock": true},
+	}, NewMetricsRegistry(), nil)
+	go p.Run(ctx)
+
+	for _, n := range []struct{ title, target string }{{"running", "recipient"}, {"succeeded", "recipient"}, {"other", "other"}} {
+		assert.NoError(t, p.deliver(context.TODO(), &delivery{
+			appName: "guestbook", appNamespace: TestNamespace, trigger: "on-sync", service: "mock", target: n.target,
+			notifiers: map[string]notifiers.Notifier{"mock": notifier}, notification: notifiers.Notification{Title: n.title},
+		}))
+	}
+
+	var titles []string
+	for i := 0; i < 3; i++ {
+		select {
+		case title := <-delivered:
+			titles = append(titles, title)
+		case <-time.After(5 * time.Second):
+			t.Fatal("notification was not sent")
+		}
+	}
+	// notifications of the other recipient don't wait
+	assert.Equal(t, []string{"other", "running", "succeeded"}, titles)
+	assert.Eventually(t, func() bool {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		return len(p.backlogs) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDeliveryPipeline_OrderingKey(t *testing.T) {
+	p := NewDeliveryPipeline(DeliveryOptions{OrderedNotifiers: map[string]bool{"slack": true}}, NewMetricsRegistry(), nil)
+	assert.Equal(t, "default/guestbook/slack:test", p.orderingKey(&delivery{appName: "guestbook", appNamespace: "default", service: "slack", target: "test"}))
+	assert.Empty(t, p.orderingKey(&delivery{appName: "guestbook", appNamespace: "default", service: "email", target: "test"}))
+	assert.Empty(t, p.orderingKey(&delivery{service: "slack", target: "test"}))
+
+	p = NewDeliveryPipeline(DeliveryOptions{OrderedNotifiers: map[string]bool{OrderAllNotifiers: true}}, NewMetricsRegistry(), nil)
+	assert.NotEmpty(t, p.orderingKey(&delivery{appName: "guestbook", appNamespace: "default", service: "email", target: "test"}))
+}
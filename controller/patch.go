@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"encoding/json"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// annotationChanges returns annotations that should be set or removed (nil value) to turn original into updated
+func annotationChanges(original map[string]string, updated map[string]string) map[string]interface{} {
+	changes := map[string]interface{}{}
+	for k, v := range updated {
+		if originalValue, ok := original[k]; !ok || originalValue != v {
+			changes[k] = v
+		}
+	}
+	for k := range original {
+		if _, ok := updated[k]; !ok {
+			changes[k] = nil
+		}
+	}
+	return changes
+}
+
+// patchAnnotations applies all annotation changes made during the application processing using a single merge
+// patch. The patch is conditional on the application resource version, so changes made by other controllers are not
+// overwritten; the resource version is reloaded and the patch is retried on conflict.
+func (c *notificationController) patchAnnotations(app *unstructured.Unstructured, changes map[string]interface{}) error {
+	resourceVersion := app.GetResourceVersion()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		metadata := map[string]interface{}{"annotations": changes}
+		if resourceVersion != "" {
+			metadata["resourceVersion"] = resourceVersion
+		}
+		patchData, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+		if err != nil {
+			return err
+		}
+		_, err = c.appClient.Patch(app.GetName(), types.MergePatchType, patchData, v1.PatchOptions{})
+		if apierrors.IsConflict(err) {
+			latest, getErr := c.appClient.Get(app.GetName(), v1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			resourceVersion = latest.GetResourceVersion()
+			c.metricsRegistry.IncAppPatchConflictsCounter()
+		}
+		return err
+	})
+}
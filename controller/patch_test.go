@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestAnnotationChanges(t *testing.T) {
+	changes := annotationChanges(
+		map[string]string{"unchanged": "1", "updated": "1", "removed": "1"},
+		map[string]string{"unchanged": "1", "updated": "2", "added": "1"})
+
+	assert.Equal(t, map[string]interface{}{"updated": "2", "added": "1", "removed": nil}, changes)
+}
+
+func TestPatchAnnotations_RetriesOnConflict(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test")
+	app.SetResourceVersion("1")
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), app)
+	var patches []map[string]interface{}
+	client.PrependReactor("patch", "*", func(action kubetesting.Action) (handled bool, ret runtime.Object, err error) {
+		patch := map[string]interface{}{}
+		_ = json.Unmarshal(action.(kubetesting.PatchAction).GetPatch(), &patch)
+		patches = append(patches, patch)
+		if len(patches) == 1 {
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "applications"}, "test", nil)
+		}
+		return true, app, nil
+	})
+	client.PrependReactor("get", "*", func(action kubetesting.Action) (handled bool, ret runtime.Object, err error) {
+		latest := app.DeepCopy()
+		latest.SetResourceVersion("2")
+		return true, latest, nil
+	})
+	ctrl, _, _, err := newController(t, ctx, client)
+	assert.NoError(t, err)
+
+	err = ctrl.patchAnnotations(app, map[string]interface{}{"a": "b", "c": nil})
+	assert.NoError(t, err)
+
+	if assert.Len(t, patches, 2) {
+		assert.Equal(t, map[string]interface{}{"metadata": map[string]interface{}{
+			"resourceVersion": "1", "annotations": map[string]interface{}{"a": "b", "c": nil},
+		}}, patches[0])
+		assert.Equal(t, map[string]interface{}{"metadata": map[string]interface{}{
+			"resourceVersion": "2", "annotations": map[string]interface{}{"a": "b", "c": nil},
+		}}, patches[1])
+	}
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// shardedQueue distributes application keys between the work queues using key hash. Every shard is processed by a
+// single worker, so the events of the same application are processed in order while a slow notifier only delays
+// applications of one shard.
+type shardedQueue struct {
+	lock    sync.Mutex
+	pending []string
+	added   map[string]bool
+	shards  []workqueue.RateLimitingInterface
+}
+
+func newShardedQueue() *shardedQueue {
+	return &shardedQueue{added: map[string]bool{}}
+}
+
+func shardIndex(key string, shards int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// Add adds the key to the shard queue. The keys added before the shards are started are buffered.
+func (q *shardedQueue) Add(key string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.shards == nil {
+		if !q.added[key] {
+			q.added[key] = true
+			q.pending = append(q.pending, key)
+		}
+		return
+	}
+	q.shards[shardIndex(key, len(q.shards))].Add(key)
+}
+
+// start creates the specified number of shards and moves the buffered keys into the shards
+func (q *shardedQueue) start(count int) []workqueue.RateLimitingInterface {
+	if count < 1 {
+		count = 1
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for i := 0; i < count; i++ {
+		q.shards = append(q.shards, workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()))
+	}
+	for _, key := range q.pending {
+		q.shards[shardIndex(key, count)].Add(key)
+	}
+	q.pending = nil
+	q.added = nil
+	return q.shards
+}
+
+func (q *shardedQueue) ShutDown() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, shard := range q.shards {
+		shard.ShutDown()
+	}
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedQueue_BuffersKeysUntilStarted(t *testing.T) {
+	queue := newShardedQueue()
+	queue.Add("default/app1")
+	queue.Add("default/app2")
+	queue.Add("default/app1")
+
+	shards := queue.start(1)
+	defer queue.ShutDown()
+
+	assert.Len(t, shards, 1)
+	assert.Equal(t, 2, shards[0].Len())
+}
+
+func TestShardedQueue_SameKeySameShard(t *testing.T) {
+	queue := newShardedQueue()
+	shards := queue.start(4)
+	defer queue.ShutDown()
+
+	for i := 0; i < 20; i++ {
+		queue.Add(fmt.Sprintf("default/app%d", i))
+	}
+	for i := 0; i < 20; i++ {
+		queue.Add(fmt.Sprintf("default/app%d", i))
+	}
+
+	total := 0
+	for i, shard := range shards {
+		count := shard.Len()
+		total += count
+		for j := 0; j < count; j++ {
+			key, _ := shard.Get()
+			assert.Equal(t, i, shardIndex(key.(string), len(shards)))
+		}
+	}
+	assert.Equal(t, 20, total)
+}
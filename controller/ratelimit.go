@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/ratelimit"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// rateLimiter maintains token buckets of the configured rate limits
+type rateLimiter struct {
+	lock    sync.Mutex
+	limits  []settings.RateLimit
+	buckets map[string]*rate.Limiter
+	// shared are the buckets shared by the controller replicas; the in-memory buckets are used if nil or if the
+	// shared buckets are not available
+	shared ratelimit.Buckets
+}
+
+func newRateLimiter(shared ratelimit.Buckets) *rateLimiter {
+	return &rateLimiter{buckets: map[string]*rate.Limiter{}, shared: shared}
+}
+
+// setLimits replaces rate limits. The buckets state is preserved if limits have not changed.
+func (l *rateLimiter) setLimits(limits []settings.RateLimit) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if reflect.DeepEqual(l.limits, limits) {
+		return
+	}
+	l.limits = limits
+	l.buckets = map[string]*rate.Limiter{}
+}
+
+type rateLimitReservation struct {
+	cancels  []func()
+	delay    time.Duration
+	overflow string
+}
+
+// cancel returns reserved tokens to the buckets
+func (r *rateLimitReservation) cancel() {
+	for i := range r.cancels {
+		r.cancels[i]()
+	}
+}
+
+// reserve takes a token from the buckets of every matching limit and returns the delay after which the notification
+// might be sent along with the overflow mode of the limit that caused the longest delay
+func (l *rateLimiter) reserve(service string, recipient string) *rateLimitReservation {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := time.Now()
+	res := &rateLimitReservation{}
+	for i, limit := range l.limits {
+		if !limit.Matches(service, recipient) {
+			continue
+		}
+		key := fmt.Sprintf("%d", i)
+		if limit.PerRecipient {
+			key = key + "/" + recipient
+		}
+		var delay time.Duration
+		if shared, ok := l.reserveShared(limit, recipient); ok {
+			delay = shared
+			res.cancels = append(res.cancels, func() {
+				if err := l.shared.Cancel(sharedRateLimitKey(limit, recipient), limit.Limit, limit.GetPeriod()); err != nil {
+					log.Warnf("Failed to cancel shared rate limit reservation: %v", err)
+				}
+			})
+		} else {
+			bucket, ok := l.buckets[key]
+			if !ok {
+				bucket = rate.NewLimiter(rate.Limit(float64(limit.Limit)/limit.GetPeriod().Seconds()), limit.Limit)
+				l.buckets[key] = bucket
+			}
+			reservation := bucket.ReserveN(now, 1)
+			res.cancels = append(res.cancels, reservation.Cancel)
+			delay = reservation.DelayFrom(now)
+		}
+		if delay > res.delay {
+			res.delay = delay
+			res.overflow = limit.GetOverflow()
+		}
+	}
+	return res
+}
+
+// reserveShared takes the token from the shared bucket and returns false if the shared buckets are not configured or
+// not available, so the in-memory bucket should be used
+func (l *rateLimiter) reserveShared(limit settings.RateLimit, recipient string) (time.Duration, bool) {
+	if l.shared == nil {
+		return 0, false
+	}
+	delay, err := l.shared.Reserve(sharedRateLimitKey(limit, recipient), limit.Limit, limit.GetPeriod())
+	if err != nil {
+		log.Warnf("Shared rate limits are not available, falling back to the controller rate limits: %v", err)
+		return 0, false
+	}
+	return delay, true
+}
+
+// sharedRateLimitKey returns the shared bucket key which includes the limit settings, so the buckets are not reused
+// once the limit changes
+func sharedRateLimitKey(limit settings.RateLimit, recipient string) string {
+	key := fmt.Sprintf("%s/%s/%d/%v", limit.Notifier, limit.Recipient, limit.Limit, limit.GetPeriod())
+	if limit.PerRecipient {
+		key = key + "/" + recipient
+	}
+	return key
+}
+
+func formatRateLimitSummary(notifications []notifiers.Notification) notifiers.Notification {
+	if len(notifications) == 1 {
+		return notifications[0]
+	}
+	return combineNotifications(fmt.Sprintf("%d notification(s) collapsed due to rate limit", len(notifications)), notifications)
+}
+
+// combineNotifications returns the notification with the specified title which body includes titles and bodies of
+// the combined notifications
+func combineNotifications(title string, notifications []notifiers.Notification) notifiers.Notification {
+	var body strings.Builder
+	for i, n := range notifications {
+		if i > 0 {
+			body.WriteString("\n\n")
+		}
+		if n.Title != "" {
+			body.WriteString(n.Title + "\n")
+		}
+		body.WriteString(n.Body)
+	}
+	return notifiers.Notification{Title: title, Body: body.String()}
+}
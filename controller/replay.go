@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+)
+
+var errAppNotManaged = errors.New("application is not managed by the controller")
+
+// ReplayResult is the outcome of the replayed notification
+type ReplayResult struct {
+	EventID      string `json:"eventId,omitempty"`
+	App          string `json:"app"`
+	AppNamespace string `json:"appNamespace,omitempty"`
+	Trigger      string `json:"trigger"`
+	Recipient    string `json:"recipient"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Replay renders the event notification using the current application state and sends it once again
+func (c *notificationController) Replay(ctx context.Context, event history.Event) error {
+	app, err := c.getApp(event.AppNamespace, event.App)
+	if err != nil {
+		return err
+	}
+	t, ok := c.triggers[event.Trigger]
+	if !ok {
+		return fmt.Errorf("trigger %s is not configured", event.Trigger)
+	}
+	service, target, ok := splitRecipient(event.Recipient)
+	if !ok {
+		return fmt.Errorf("%s is not valid recipient. Expected recipient format is <type>:<name>", event.Recipient)
+	}
+	if _, ok := c.notifiers[service]; !ok {
+		return fmt.Errorf("%s is not valid recipient type", service)
+	}
+	templateCtx := c.templateContext(app.GetNamespace(), app.GetName(), event.Trigger, service, event.Recipient)
+	notification, err := t.FormatNotification(app, templateCtx)
+	if err != nil {
+		c.metricsRegistry.IncTemplateRenderErrorsCounter(t.GetTemplateName())
+		return err
+	}
+	d := &delivery{
+		appName:       app.GetName(),
+		appNamespace:  app.GetNamespace(),
+		trigger:       event.Trigger,
+		template:      t.GetTemplateName(),
+		service:       service,
+		target:        target,
+		notifiers:     c.notifiers,
+		notification:  *notification,
+		correlationID: newCorrelationID(),
+		appRef:        appReference(app),
+		recorder:      c.recorder,
+	}
+	d.logEntry().Infof("Replaying %s notification of event %s", event.Trigger, event.ID)
+	return c.delivery.deliver(ctx, d)
+}
+
+// Replay sends once again the notifications returned by history.ListReplayable. Every notification is replayed by
+// the first controller that manages the application. The notifications are rendered using the current application
+// state since the history does not store the notification content.
+func Replay(ctx context.Context, store history.Store, controllers []NotificationController, filter history.Filter, dryRun bool) ([]ReplayResult, error) {
+	events, err := history.ListReplayable(store, filter)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]ReplayResult, 0)
+	for _, e := range events {
+		result := ReplayResult{EventID: e.ID, App: e.App, AppNamespace: e.AppNamespace, Trigger: e.Trigger, Recipient: e.Recipient}
+		if !dryRun {
+			err := errAppNotManaged
+			for i := 0; i < len(controllers) && err == errAppNotManaged; i++ {
+				err = controllers[i].Replay(ctx, e)
+			}
+			if err != nil {
+				result.Error = err.Error()
+			}
+		}
+		res = append(res, result)
+	}
+	return res, nil
+}
+
+// NewReplayHandler returns HTTP handler that replays notifications selected by the POST request query parameters. The
+// request should include bearer token; the notifications are only listed if the dryRun parameter is true.
+func NewReplayHandler(store history.Store, controllers func() []NotificationController, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("method %s is not supported", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		actual := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(actual)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		filter, err := history.ParseFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res, err := Replay(r.Context(), store, controllers(), filter, r.URL.Query().Get("dryRun") == "true")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	})
+}
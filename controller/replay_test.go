@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestReplay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("guestbook")
+	ctrl, trigger, notifier, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	if !assert.NoError(t, err) {
+		return
+	}
+	store := history.NewMemoryStore(0)
+	assert.NoError(t, store.Record(history.Event{ID: "1", App: "guestbook", AppNamespace: TestNamespace, Trigger: "mock",
+		Recipient: "mock:recipient", Timestamp: time.Now(), Status: history.StatusFailed}))
+	assert.NoError(t, store.Record(history.Event{ID: "2", App: "unknown", AppNamespace: TestNamespace, Trigger: "mock",
+		Recipient: "mock:recipient", Timestamp: time.Now(), Status: history.StatusFailed}))
+
+	trigger.EXPECT().GetTemplateName().Return("test").AnyTimes()
+	trigger.EXPECT().FormatNotification(gomock.Any(), map[string]string{"notificationType": "mock"}).Return(
+		&notifiers.Notification{Title: "title"}, nil)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "title"}, "recipient").Return(nil)
+
+	res, err := Replay(ctx, store, []NotificationController{ctrl}, history.Filter{}, false)
+
+	assert.NoError(t, err)
+	if assert.Len(t, res, 2) {
+		assert.Equal(t, ReplayResult{EventID: "1", App: "guestbook", AppNamespace: TestNamespace, Trigger: "mock", Recipient: "mock:recipient"}, res[0])
+		assert.Equal(t, errAppNotManaged.Error(), res[1].Error)
+	}
+}
+
+func TestReplayHandler(t *testing.T) {
+	store := history.NewMemoryStore(0)
+	assert.NoError(t, store.Record(history.Event{ID: "1", App: "guestbook", Trigger: "on-sync", Recipient: "slack:test",
+		Timestamp: time.Now(), Status: history.StatusFailed}))
+	handler := NewReplayHandler(store, func() []NotificationController { return nil }, "secret")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/replay?dryRun=true", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/replay?dryRun=true&app=guestbook", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res []ReplayResult
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, "1", res[0].EventID)
+		assert.Empty(t, res[0].Error)
+	}
+}
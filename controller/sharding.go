@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// ShardingKeyName distributes applications between shards using application namespace and name
+	ShardingKeyName = "name"
+	// ShardingKeyProject distributes applications between shards using application project, so all applications of
+	// the same project are processed by one replica
+	ShardingKeyProject = "project"
+)
+
+// Sharding splits applications between controller replicas. Every replica processes only applications of its shard.
+type Sharding struct {
+	// Shard is the zero based index of the replica shard
+	Shard int
+	// Replicas is the total number of shards; sharding is disabled if it is 1 or less
+	Replicas int
+	// Key is the application attribute used to assign shard: name or project
+	Key string
+	// Filter further restricts the applications of the shard, e.g. to the applications of the configuration canary;
+	// optional
+	Filter func(app *unstructured.Unstructured) bool
+}
+
+// Validate returns an error if sharding settings are inconsistent
+func (s Sharding) Validate() error {
+	if s.Replicas <= 1 {
+		return nil
+	}
+	if s.Shard < 0 || s.Shard >= s.Replicas {
+		return fmt.Errorf("shard should be in range [0, %d), got %d", s.Replicas, s.Shard)
+	}
+	switch s.Key {
+	case "", ShardingKeyName, ShardingKeyProject:
+	default:
+		return fmt.Errorf("sharding key should be one of: %s, %s", ShardingKeyName, ShardingKeyProject)
+	}
+	return nil
+}
+
+// Owns returns true if the application belongs to the shard
+func (s Sharding) Owns(app *unstructured.Unstructured) bool {
+	if s.Filter != nil && !s.Filter(app) {
+		return false
+	}
+	if s.Replicas <= 1 {
+		return true
+	}
+	key := app.GetNamespace() + "/" + app.GetName()
+	if s.Key == ShardingKeyProject {
+		project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+		key = app.GetNamespace() + "/" + project
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32()%uint32(s.Replicas)) == s.Shard
+}
+
+// ShardFromHostname returns the shard index of the StatefulSet pod using the ordinal suffix of its host name,
+// e.g. argocd-notifications-controller-2
+func ShardFromHostname(hostname string) (int, error) {
+	i := strings.LastIndex(hostname, "-")
+	if i < 0 {
+		return 0, fmt.Errorf("cannot infer shard from host name %s: host name has no ordinal suffix", hostname)
+	}
+	shard, err := strconv.Atoi(hostname[i+1:])
+	if err != nil {
+		return 0, fmt.Errorf("cannot infer shard from host name %s: %v", hostname, err)
+	}
+	return shard, nil
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestSharding_Disabled(t *testing.T) {
+	assert.True(t, Sharding{}.Owns(NewApp("guestbook")))
+	assert.NoError(t, Sharding{Shard: 5}.Validate())
+}
+
+func TestSharding_EveryAppHasSingleShard(t *testing.T) {
+	shards := make([]int, 3)
+	for i := 0; i < 30; i++ {
+		app := NewApp(fmt.Sprintf("app-%d", i))
+		owners := 0
+		for shard := range shards {
+			if (Sharding{Shard: shard, Replicas: len(shards)}).Owns(app) {
+				owners++
+				shards[shard]++
+			}
+		}
+		assert.Equal(t, 1, owners)
+	}
+	for shard := range shards {
+		assert.NotZero(t, shards[shard])
+	}
+}
+
+func TestSharding_ByProject(t *testing.T) {
+	sharding := Sharding{Replicas: 4, Key: ShardingKeyProject}
+	for shard := 0; shard < sharding.Replicas; shard++ {
+		sharding.Shard = shard
+		assert.Equal(t,
+			sharding.Owns(NewApp("app-1", WithProject("team-a"))),
+			sharding.Owns(NewApp("app-2", WithProject("team-a"))))
+	}
+}
+
+func TestSharding_Validate(t *testing.T) {
+	assert.NoError(t, Sharding{Shard: 1, Replicas: 2, Key: ShardingKeyName}.Validate())
+	assert.EqualError(t, Sharding{Shard: 2, Replicas: 2}.Validate(), "shard should be in range [0, 2), got 2")
+	assert.EqualError(t, Sharding{Shard: 0, Replicas: 2, Key: "cluster"}.Validate(), "sharding key should be one of: name, project")
+}
+
+func TestShardFromHostname(t *testing.T) {
+	shard, err := ShardFromHostname("argocd-notifications-controller-2")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, shard)
+
+	_, err = ShardFromHostname("argocd-notifications-controller-7d9f8")
+	assert.Error(t, err)
+}
+
+func TestController_SkipsAppsOfOtherShards(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sharding := Sharding{Shard: 0, Replicas: 2}
+	var owned, skipped *unstructured.Unstructured
+	for i := 0; owned == nil || skipped == nil; i++ {
+		app := NewApp(fmt.Sprintf("app-%d", i))
+		if sharding.Owns(app) {
+			owned = app
+		} else {
+			skipped = app
+		}
+	}
+	c, err := NewController(fake.NewSimpleDynamicClient(runtime.NewScheme(), owned, skipped), TestNamespace,
+		nil, nil, nil, nil, nil, "", sharding, InformerOptions{}, NewMetricsRegistry(), nil, nil, nil, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Init(ctx))
+
+	// informer event handlers run asynchronously, so the owned application might be queued after the cache sync
+	queue := c.(*notificationController).refreshQueue
+	assert.Eventually(t, func() bool {
+		return reflect.DeepEqual([]string{TestNamespace + "/" + owned.GetName()}, pendingKeys(queue))
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// pendingKeys returns the keys buffered by the queue before the shards are started
+func pendingKeys(q *shardedQueue) []string {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return append([]string{}, q.pending...)
+}
+
+func TestSharding_Filter(t *testing.T) {
+	sharding := Sharding{Filter: func(app *unstructured.Unstructured) bool {
+		return app.GetName() == "guestbook"
+	}}
+	assert.True(t, sharding.Owns(NewApp("guestbook")))
+	assert.False(t, sharding.Owns(NewApp("other")))
+}
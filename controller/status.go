@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	sharedrecipients "github.com/argoproj-labs/argocd-notifications/shared/recipients"
+)
+
+// AppStatusAnnotation is the application annotation that holds the notification summary of the application
+const AppStatusAnnotation = "status." + sharedrecipients.AnnotationPostfix
+
+// AppNotificationStatus is the compact notification summary of the application, so the application consumers and
+// dashboards might surface the notification health
+type AppNotificationStatus struct {
+	// LastTrigger is the name of the last trigger which fired
+	LastTrigger string `json:"lastTrigger,omitempty"`
+	// LastTriggeredAt is the time the last trigger fired
+	LastTriggeredAt *time.Time `json:"lastTriggeredAt,omitempty"`
+	// LastRecipient is the recipient of the last delivery attempt
+	LastRecipient string `json:"lastRecipient,omitempty"`
+	// LastResult is the status of the last delivery attempt: Sent or Failed
+	LastResult history.Status `json:"lastResult,omitempty"`
+	// LastError is the error of the last delivery attempt if it failed
+	LastError string `json:"lastError,omitempty"`
+	// LastDeliveredAt is the time of the last delivery attempt
+	LastDeliveredAt *time.Time `json:"lastDeliveredAt,omitempty"`
+	// Failures is the number of failed delivery attempts since the last successful delivery
+	Failures int `json:"failures"`
+}
+
+// appStatusUpdate holds the notification outcomes of the application which are not yet written to the application
+type appStatusUpdate struct {
+	triggered   bool
+	trigger     string
+	triggeredAt time.Time
+	delivered   bool
+	recipient   string
+	err         error
+	deliveredAt time.Time
+	// failures is the number of failures since the last successful delivery of the update or since the update start
+	failures int
+	// succeeded is true if any delivery of the update succeeded, so the failures of the status are reset
+	succeeded bool
+}
+
+// merge returns the update that combines the update with the newer update
+func (u appStatusUpdate) merge(newer appStatusUpdate) appStatusUpdate {
+	res := newer
+	if !newer.triggered {
+		res.triggered, res.trigger, res.triggeredAt = u.triggered, u.trigger, u.triggeredAt
+	}
+	if !newer.delivered {
+		res.delivered, res.recipient, res.err, res.deliveredAt = u.delivered, u.recipient, u.err, u.deliveredAt
+	}
+	if !newer.succeeded {
+		res.failures = u.failures + newer.failures
+		res.succeeded = u.succeeded
+	}
+	return res
+}
+
+// apply updates the application status using the pending outcomes
+func (u appStatusUpdate) apply(status *AppNotificationStatus) {
+	if u.triggered {
+		triggeredAt := u.triggeredAt.UTC().Truncate(time.Second)
+		status.LastTrigger = u.trigger
+		status.LastTriggeredAt = &triggeredAt
+	}
+	if !u.delivered {
+		return
+	}
+	deliveredAt := u.deliveredAt.UTC().Truncate(time.Second)
+	status.LastRecipient = u.recipient
+	status.LastDeliveredAt = &deliveredAt
+	status.LastResult = history.StatusSent
+	status.LastError = ""
+	if u.err != nil {
+		status.LastResult = history.StatusFailed
+		status.LastError = u.err.Error()
+	}
+	if u.succeeded {
+		status.Failures = u.failures
+	} else {
+		status.Failures += u.failures
+	}
+}
+
+// appStatuses accumulates the notification outcomes of the applications until the controller writes them into the
+// application annotation. The nil appStatuses ignores the outcomes.
+type appStatuses struct {
+	lock    sync.Mutex
+	updates map[string]appStatusUpdate
+}
+
+func newAppStatuses() *appStatuses {
+	return &appStatuses{updates: map[string]appStatusUpdate{}}
+}
+
+func (s *appStatuses) add(appNamespace string, appName string, update appStatusUpdate) {
+	if s == nil || appName == "" {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := appNamespace + "/" + appName
+	s.updates[key] = s.updates[key].merge(update)
+}
+
+func (s *appStatuses) triggered(appNamespace string, appName string, trigger string) {
+	s.add(appNamespace, appName, appStatusUpdate{triggered: true, trigger: trigger, triggeredAt: time.Now()})
+}
+
+func (s *appStatuses) delivered(appNamespace string, appName string, recipient string, err error) {
+	update := appStatusUpdate{delivered: true, recipient: recipient, err: err, deliveredAt: time.Now(), succeeded: err == nil}
+	if err != nil {
+		update.failures = 1
+	}
+	s.add(appNamespace, appName, update)
+}
+
+// take removes and returns the pending update of the application with the specified key
+func (s *appStatuses) take(key string) (appStatusUpdate, bool) {
+	if s == nil {
+		return appStatusUpdate{}, false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	u, ok := s.updates[key]
+	delete(s.updates, key)
+	return u, ok
+}
+
+// restore returns the update which failed to be written, so it is written with the outcomes received since then
+func (s *appStatuses) restore(key string, update appStatusUpdate) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if newer, ok := s.updates[key]; ok {
+		update = update.merge(newer)
+	}
+	s.updates[key] = update
+}
+
+// setAppStatus applies the update to the status annotation of the application
+func setAppStatus(app *unstructured.Unstructured, u appStatusUpdate) error {
+	annotations := app.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	var status AppNotificationStatus
+	if data, ok := annotations[AppStatusAnnotation]; ok {
+		// the malformed annotation is overwritten
+		_ = json.Unmarshal([]byte(data), &status)
+	}
+	u.apply(&status)
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	annotations[AppStatusAnnotation] = string(data)
+	app.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestAppStatuses_CountsFailures(t *testing.T) {
+	statuses := newAppStatuses()
+	statuses.triggered("default", "guestbook", "on-sync-failed")
+	statuses.delivered("default", "guestbook", "slack:alerts", errors.New("fail"))
+	statuses.delivered("default", "guestbook", "slack:alerts", errors.New("fail"))
+
+	update, ok := statuses.take("default/guestbook")
+	assert.True(t, ok)
+	status := AppNotificationStatus{Failures: 3}
+	update.apply(&status)
+	assert.Equal(t, "on-sync-failed", status.LastTrigger)
+	assert.Equal(t, history.StatusFailed, status.LastResult)
+	assert.Equal(t, 5, status.Failures)
+
+	statuses.delivered("default", "guestbook", "slack:alerts", nil)
+	statuses.delivered("default", "guestbook", "email:sre", errors.New("timeout"))
+	update, _ = statuses.take("default/guestbook")
+	update.apply(&status)
+	assert.Equal(t, "on-sync-failed", status.LastTrigger)
+	assert.Equal(t, "email:sre", status.LastRecipient)
+	assert.Equal(t, "timeout", status.LastError)
+	assert.Equal(t, 1, status.Failures)
+
+	_, ok = statuses.take("default/guestbook")
+	assert.False(t, ok)
+}
+
+func TestAppStatuses_Restore(t *testing.T) {
+	statuses := newAppStatuses()
+	statuses.delivered("default", "guestbook", "slack:alerts", errors.New("fail"))
+	update, _ := statuses.take("default/guestbook")
+	statuses.delivered("default", "guestbook", "slack:alerts", errors.New("fail"))
+	statuses.restore("default/guestbook", update)
+
+	update, _ = statuses.take("default/guestbook")
+	status := AppNotificationStatus{}
+	update.apply(&status)
+	assert.Equal(t, 2, status.Failures)
+}
+
+func TestAppStatuses_Disabled(t *testing.T) {
+	var statuses *appStatuses
+	statuses.delivered("default", "guestbook", "slack:alerts", nil)
+	_, ok := statuses.take("default/guestbook")
+	assert.False(t, ok)
+}
+
+func TestSetAppStatus(t *testing.T) {
+	app := NewApp("guestbook", WithAnnotations(map[string]string{AppStatusAnnotation: `{"lastTrigger":"on-deployed","failures":2}`}))
+	statuses := newAppStatuses()
+	statuses.delivered("default", "guestbook", "slack:alerts", nil)
+	update, _ := statuses.take("default/guestbook")
+
+	assert.NoError(t, setAppStatus(app, update))
+
+	var status AppNotificationStatus
+	assert.NoError(t, json.Unmarshal([]byte(app.GetAnnotations()[AppStatusAnnotation]), &status))
+	assert.Equal(t, "on-deployed", status.LastTrigger)
+	assert.Equal(t, history.StatusSent, status.LastResult)
+	assert.Equal(t, 0, status.Failures)
+	assert.NotNil(t, status.LastDeliveredAt)
+}
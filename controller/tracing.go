@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/argoproj-labs/argocd-notifications/controller"
+
+	spanReconcile       = "reconcile"
+	spanTriggerEvaluate = "trigger.evaluate"
+	spanTemplateRender  = "template.render"
+	spanNotifierSend    = "notifier.send"
+
+	attrApp           = attribute.Key("argocd.app")
+	attrTrigger       = attribute.Key("notifications.trigger")
+	attrTriggered     = attribute.Key("notifications.triggered")
+	attrTemplate      = attribute.Key("notifications.template")
+	attrNotifier      = attribute.Key("notifications.notifier")
+	attrRecipient     = attribute.Key("notifications.recipient")
+	attrAttempt       = attribute.Key("notifications.attempt")
+	attrCorrelationID = attribute.Key("notifications.correlation_id")
+)
+
+// tracer returns tracer of the globally registered provider, so spans are exported once tracing is configured
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// endSpan records the error if any and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func withTestTracer() (*tracetest.InMemoryExporter, func()) {
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	return exporter, func() {
+		otel.SetTracerProvider(prev)
+	}
+}
+
+func TestProcessApp_RecordsSpans(t *testing.T) {
+	exporter, reset := withTestTracer()
+	defer reset()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "mock:recipient",
+	}))
+	ctrl, trigger, notifier, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+
+	trigger.EXPECT().GetTemplateName().Return("test")
+	trigger.EXPECT().Triggered(app).Return(true, nil)
+	trigger.EXPECT().FormatNotification(app, map[string]string{"notificationType": "mock"}).Return(
+		&notifiers.Notification{Title: "title", Body: "body"}, nil)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "title", Body: "body"}, "recipient").Return(errors.New("fail"))
+
+	rootCtx, root := otel.Tracer("test").Start(context.Background(), spanReconcile)
+	err = ctrl.processApp(rootCtx, app, logEntry)
+	root.End()
+	assert.NoError(t, err)
+
+	spans := map[string]*sdktrace.SpanSnapshot{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	if !assert.Len(t, spans, 4) {
+		return
+	}
+	rootID := trace.SpanContextFromContext(rootCtx).SpanID()
+	assert.Equal(t, rootID, spans[spanTriggerEvaluate].Parent.SpanID())
+	assert.Equal(t, spans[spanTriggerEvaluate].SpanContext.SpanID(), spans[spanTemplateRender].Parent.SpanID())
+	assert.Equal(t, spans[spanTemplateRender].SpanContext.SpanID(), spans[spanNotifierSend].Parent.SpanID())
+	assert.Equal(t, codes.Error, spans[spanNotifierSend].StatusCode)
+	assert.Equal(t, "fail", spans[spanNotifierSend].StatusMessage)
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"go/format"
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
 
 	"github.com/argoproj-labs/argocd-notifications/cmd/tools"
 	"github.com/ghodss/yaml"
@@ -51,6 +54,9 @@ func main() {
 	err = ioutil.WriteFile(target, d, 0644)
 	dieOnError(err, "Failed to write builtin configmap")
 
+	err = ioutil.WriteFile(path.Join(wd, "builtin/builtin_generated.go"), generateBuiltinSource(cm.Data), 0644)
+	dieOnError(err, "Failed to write builtin configmap source")
+
 }
 
 func dieOnError(err error, msg string) {
@@ -59,3 +65,27 @@ func dieOnError(err error, msg string) {
 		os.Exit(1)
 	}
 }
+
+// generateBuiltinSource returns the Go source of the builtin config map data, so the CLI can use the builtin
+// triggers and templates without the manifests
+func generateBuiltinSource(data map[string]string) []byte {
+	var keys []string
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var src bytes.Buffer
+	_, _ = fmt.Fprintln(&src, "// Code generated by hack/gen/cmd/builtin-cm. DO NOT EDIT.")
+	_, _ = fmt.Fprintln(&src, "")
+	_, _ = fmt.Fprintln(&src, "package builtin")
+	_, _ = fmt.Fprintln(&src, "")
+	_, _ = fmt.Fprintln(&src, "// ConfigMapData holds the builtin triggers and templates in the argocd-notifications-cm ConfigMap format")
+	_, _ = fmt.Fprintln(&src, "var ConfigMapData = map[string]string{")
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(&src, "%q: %q,\n", k, data[k])
+	}
+	_, _ = fmt.Fprintln(&src, "}")
+	res, err := format.Source(src.Bytes())
+	dieOnError(err, "Failed to format builtin configmap source")
+	return res
+}
@@ -41,7 +41,11 @@ func generateBuiltInTriggersDocs(out io.Writer, builtin *settings.Config) {
 func generateCommandsDocs(out io.Writer) error {
 	toolsCmd := tools.NewToolsCommand()
 	for _, subCommand := range toolsCmd.Commands() {
-		for _, cmd := range subCommand.Commands() {
+		commands := subCommand.Commands()
+		if len(commands) == 0 {
+			commands = append(commands, subCommand)
+		}
+		for _, cmd := range commands {
 			var cmdDesc bytes.Buffer
 			if err := doc.GenMarkdown(cmd, &cmdDesc); err != nil {
 				return err
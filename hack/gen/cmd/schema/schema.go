@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const header = `// Code generated by hack/gen/cmd/schema. DO NOT EDIT.
+
+package settings
+`
+
+func main() {
+	wd, err := os.Getwd()
+	dieOnError(err, "Failed to get current working directory")
+
+	configSchema, err := settings.GenerateConfigSchema()
+	dieOnError(err, "Failed to generate config.yaml schema")
+	notifiersSchema, err := settings.GenerateNotifiersSchema()
+	dieOnError(err, "Failed to generate notifiers.yaml schema")
+
+	err = ioutil.WriteFile(path.Join(wd, "docs/schemas/config.schema.json"), append(configSchema, '\n'), 0644)
+	dieOnError(err, "Failed to write config.yaml schema")
+	err = ioutil.WriteFile(path.Join(wd, "docs/schemas/notifiers.schema.json"), append(notifiersSchema, '\n'), 0644)
+	dieOnError(err, "Failed to write notifiers.yaml schema")
+
+	var code strings.Builder
+	code.WriteString(header)
+	code.WriteString(fmt.Sprintf("\nconst configSchemaJSON = `%s`\n", configSchema))
+	code.WriteString(fmt.Sprintf("\nconst notifiersSchemaJSON = `%s`\n", notifiersSchema))
+	err = ioutil.WriteFile(path.Join(wd, "shared/settings/schema_generated.go"), []byte(code.String()), 0644)
+	dieOnError(err, "Failed to write embedded schema")
+}
+
+func dieOnError(err error, msg string) {
+	if err != nil {
+		fmt.Printf("[ERROR] %s: %v", msg, err)
+		os.Exit(1)
+	}
+}
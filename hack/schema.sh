@@ -0,0 +1,3 @@
+#!/bin/bash
+
+go run github.com/argoproj-labs/argocd-notifications/hack/gen/cmd/schema
@@ -0,0 +1,183 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+)
+
+const (
+	// GitHubDispatchService is the service of the recipients which are the GitHub repositories receiving the
+	// repository_dispatch event, e.g. github-dispatch:argoproj/e2e-tests
+	GitHubDispatchService = "github-dispatch"
+
+	defaultDispatchEventType = "argocd-notifications"
+)
+
+type JenkinsOptions struct {
+	// URL is the Jenkins server URL, e.g. https://jenkins.example.com
+	URL      string `json:"url"`
+	Username string `json:"username,omitempty"`
+	// APIToken is the API token of the user; the builds are triggered anonymously if the username is empty
+	APIToken string `json:"apiToken,omitempty"`
+}
+
+type JenkinsNotification struct {
+	// Parameters are the build parameters of the parameterized job
+	Parameters map[string]string `json:"parameters,omitempty" patchStrategy:"replace"`
+}
+
+type GitHubDispatchNotification struct {
+	// EventType is the repository_dispatch event type matched by the workflow `on.repository_dispatch.types` filter;
+	// argocd-notifications if empty
+	EventType string `json:"eventType,omitempty"`
+	// ClientPayload is the JSON object available to the workflow as github.event.client_payload; the notification
+	// title and body are sent if empty
+	ClientPayload string `json:"clientPayload,omitempty"`
+}
+
+// NewJenkinsNotifier returns the notifier which starts the build of the recipient job, e.g. jenkins:folder/e2e-tests
+func NewJenkinsNotifier(opts JenkinsOptions) Notifier {
+	return &jenkinsNotifier{opts: opts}
+}
+
+type jenkinsNotifier struct {
+	httpConfig
+	opts JenkinsOptions
+}
+
+// jobPath returns the URL path of the job in the nested folders, e.g. /job/folder/job/e2e-tests for folder/e2e-tests
+func jobPath(job string) string {
+	var parts []string
+	for _, name := range strings.Split(strings.Trim(job, "/"), "/") {
+		parts = append(parts, "job", url.PathEscape(name))
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+func (n *jenkinsNotifier) Send(ctx context.Context, notification Notification, job string) error {
+	_, err := n.SendWithResult(ctx, notification, job)
+	return err
+}
+
+// SendWithResult queues the build of the job with the templated parameters; the URL of the queue item is returned as
+// the message id
+func (n *jenkinsNotifier) SendWithResult(ctx context.Context, notification Notification, job string) (*DeliveryResult, error) {
+	if job == "" {
+		return nil, fmt.Errorf("jenkins job is empty")
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	client := n.httpClient("jenkins", false)
+	client.Jar = jar
+	baseURL := strings.TrimSuffix(n.opts.URL, "/")
+	headers := http.Header{}
+	// the CSRF protection crumb is required unless the request is authenticated using the API token
+	crumbReq, err := n.newRequest(ctx, http.MethodGet, baseURL+"/crumbIssuer/api/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp, err := client.Do(crumbReq); err == nil {
+		var crumb struct {
+			Field string `json:"crumbRequestField"`
+			Crumb string `json:"crumb"`
+		}
+		if resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(&crumb) == nil && crumb.Field != "" {
+			headers.Set(crumb.Field, crumb.Crumb)
+		}
+		_ = resp.Body.Close()
+	}
+
+	buildURL := baseURL + jobPath(job) + "/build"
+	values := url.Values{}
+	if notification.Jenkins != nil && len(notification.Jenkins.Parameters) > 0 {
+		buildURL = baseURL + jobPath(job) + "/buildWithParameters"
+		for k, v := range notification.Jenkins.Parameters {
+			values.Set(k, v)
+		}
+	}
+	req, err := n.newRequest(ctx, http.MethodPost, buildURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for k := range headers {
+		req.Header.Set(k, headers.Get(k))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	res := httpResult(resp)
+	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return res, fmt.Errorf("request to %s has failed with error code %d : %s", buildURL, resp.StatusCode, string(data))
+	}
+	res.MessageID = resp.Header.Get("Location")
+	return res, nil
+}
+
+func (n *jenkinsNotifier) newRequest(ctx context.Context, method string, rawURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if n.opts.Username != "" {
+		req.SetBasicAuth(n.opts.Username, n.opts.APIToken)
+	}
+	return req.WithContext(ctx), nil
+}
+
+// NewGitHubDispatchNotifier returns the notifier which sends the repository_dispatch event to the recipient
+// repository, so the GitHub Actions workflows, e.g. post-deploy tests, are started
+func NewGitHubDispatchNotifier(opts GitHubOptions) Notifier {
+	return &gitHubDispatchNotifier{client: newDeploymentsClient(GitHubDispatchService, coalesce(opts.ApiUrl, defaultGitHubApiUrl), map[string]string{
+		"Authorization": "token " + opts.Token,
+		"Accept":        "application/vnd.github.v3+json",
+	})}
+}
+
+type gitHubDispatchNotifier struct {
+	client *deploymentsClient
+}
+
+func (n *gitHubDispatchNotifier) setHTTPOptions(opts HTTPOptions) {
+	n.client.setHTTPOptions(opts)
+}
+
+func (n *gitHubDispatchNotifier) Send(ctx context.Context, notification Notification, repo string) error {
+	_, err := n.SendWithResult(ctx, notification, repo)
+	return err
+}
+
+func (n *gitHubDispatchNotifier) SendWithResult(ctx context.Context, notification Notification, repo string) (*DeliveryResult, error) {
+	if strings.Count(strings.Trim(repo, "/"), "/") != 1 {
+		return nil, fmt.Errorf("repository %s is not in <owner>/<repo> format", repo)
+	}
+	eventType := defaultDispatchEventType
+	payload := map[string]interface{}{"title": notification.Title, "body": notification.Body}
+	if d := notification.GitHubDispatch; d != nil {
+		eventType = coalesce(d.EventType, defaultDispatchEventType)
+		if d.ClientPayload != "" {
+			payload = map[string]interface{}{}
+			if err := json.Unmarshal([]byte(d.ClientPayload), &payload); err != nil {
+				return nil, fmt.Errorf("client payload is not a valid JSON object: %v", err)
+			}
+		}
+	}
+	return n.client.do(ctx, http.MethodPost, "/repos/"+strings.Trim(repo, "/")+"/dispatches", map[string]interface{}{
+		"event_type":     eventType,
+		"client_payload": payload,
+	}, nil)
+}
@@ -0,0 +1,104 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobPath(t *testing.T) {
+	assert.Equal(t, "/job/e2e-tests", jobPath("e2e-tests"))
+	assert.Equal(t, "/job/folder/job/e2e%20tests", jobPath("/folder/e2e tests"))
+}
+
+func TestJenkins_SendWithParameters(t *testing.T) {
+	var form map[string][]string
+	var crumb string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, _ := r.BasicAuth()
+		assert.Equal(t, "admin", user)
+		assert.Equal(t, "secret", token)
+		switch r.URL.Path {
+		case "/crumbIssuer/api/json":
+			_, _ = w.Write([]byte(`{"crumbRequestField": "Jenkins-Crumb", "crumb": "abc"}`))
+		case "/job/folder/job/e2e-tests/buildWithParameters":
+			assert.NoError(t, r.ParseForm())
+			form = r.PostForm
+			crumb = r.Header.Get("Jenkins-Crumb")
+			w.Header().Set("Location", "http://jenkins/queue/item/7/")
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	notifier := NewJenkinsNotifier(JenkinsOptions{URL: server.URL + "/", Username: "admin", APIToken: "secret"})
+
+	res, err := SendWithResult(context.Background(), notifier, Notification{
+		Jenkins: &JenkinsNotification{Parameters: map[string]string{"APP": "guestbook", "REVISION": "abc123"}},
+	}, "folder/e2e-tests")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://jenkins/queue/item/7/", res.MessageID)
+	assert.Equal(t, map[string][]string{"APP": {"guestbook"}, "REVISION": {"abc123"}}, form)
+	assert.Equal(t, "abc", crumb)
+}
+
+func TestJenkins_SendWithoutParameters(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/crumbIssuer/api/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		path = r.URL.Path
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	err := NewJenkinsNotifier(JenkinsOptions{URL: server.URL}).Send(context.Background(), Notification{}, "e2e-tests")
+	assert.NoError(t, err)
+	assert.Equal(t, "/job/e2e-tests/build", path)
+}
+
+func TestGitHubDispatch_Send(t *testing.T) {
+	var body map[string]interface{}
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		data, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(data, &body))
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	notifier := NewGitHubDispatchNotifier(GitHubOptions{ApiUrl: server.URL, Token: "secret"})
+
+	err := notifier.Send(context.Background(), Notification{
+		Title: "Application guestbook is synced",
+		GitHubDispatch: &GitHubDispatchNotification{
+			EventType:     "post-deploy",
+			ClientPayload: `{"app": "guestbook"}`,
+		},
+	}, "argoproj/e2e-tests")
+	assert.NoError(t, err)
+	assert.Equal(t, "/repos/argoproj/e2e-tests/dispatches", path)
+	assert.Equal(t, map[string]interface{}{
+		"event_type":     "post-deploy",
+		"client_payload": map[string]interface{}{"app": "guestbook"},
+	}, body)
+
+	err = notifier.Send(context.Background(), Notification{Title: "hello", Body: "world"}, "argoproj/e2e-tests")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"event_type":     "argocd-notifications",
+		"client_payload": map[string]interface{}{"title": "hello", "body": "world"},
+	}, body)
+
+	err = notifier.Send(context.Background(), Notification{}, "e2e-tests")
+	assert.EqualError(t, err, "repository e2e-tests is not in <owner>/<repo> format")
+}
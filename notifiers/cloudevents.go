@@ -0,0 +1,218 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// CloudEventsSpecVersion is the supported CloudEvents specification version
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType is the content type of the structured mode CloudEvents HTTP requests
+	CloudEventsContentType = "application/cloudevents+json"
+
+	cloudEventsModeBinary      = "binary"
+	cloudEventsModeStructured  = "structured"
+	cloudEventsProtocolHTTP    = "http"
+	cloudEventsProtocolKafka   = "kafka"
+	cloudEventsDataContentType = "application/json"
+	defaultCloudEventsType     = "io.argoproj.notifications.notification"
+	defaultCloudEventsSource   = "argocd-notifications"
+	kafkaRestProxyContentType  = "application/vnd.kafka.json.v2+json"
+)
+
+// CloudEventsNotification holds the templated attributes and data of the emitted CloudEvent
+type CloudEventsNotification struct {
+	Type    string `json:"type,omitempty" yaml:"type,omitempty"`
+	Subject string `json:"subject,omitempty" yaml:"subject,omitempty"`
+	// Data is the event data; the value is sent as JSON if it is a valid JSON document and as a string otherwise
+	Data string `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+type CloudEventsSink struct {
+	Name string `json:"name"`
+	// URL is the HTTP sink URL, e.g. Knative broker, or the Kafka REST Proxy URL if the kafka protocol is used
+	URL string `json:"url"`
+	// Protocol is one of: http|kafka. Default value: http
+	Protocol string `json:"protocol"`
+	// Mode is the HTTP content mode. One of: binary|structured. Default value: binary
+	Mode string `json:"mode"`
+	// Topic is the Kafka topic which receives events
+	Topic   string   `json:"topic"`
+	Source  string   `json:"source"`
+	Headers []Header `json:"headers"`
+}
+
+// CloudEventsOptions holds list of configured CloudEvents sinks
+type CloudEventsOptions []CloudEventsSink
+
+// cloudEvent is the CloudEvents JSON format envelope
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+func NewCloudEventsNotifier(opts CloudEventsOptions) Notifier {
+	return &cloudEventsNotifier{opts: opts}
+}
+
+type cloudEventsNotifier struct {
+	httpConfig
+	opts CloudEventsOptions
+}
+
+func newCloudEventID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func newCloudEvent(sink CloudEventsSink, notification Notification) *cloudEvent {
+	event := &cloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              newCloudEventID(),
+		Source:          coalesce(sink.Source, defaultCloudEventsSource),
+		Type:            defaultCloudEventsType,
+		Time:            time.Now().UTC().Format(time.RFC3339),
+		DataContentType: cloudEventsDataContentType,
+	}
+	var data []byte
+	if n := notification.CloudEvents; n != nil {
+		event.Type = coalesce(n.Type, event.Type)
+		event.Subject = n.Subject
+		data = []byte(n.Data)
+	}
+	if len(data) == 0 {
+		data, _ = json.Marshal(map[string]string{"title": notification.Title, "body": notification.Body})
+	} else if !json.Valid(data) {
+		data, _ = json.Marshal(string(data))
+	}
+	event.Data = data
+	return event
+}
+
+func findCloudEventsSinkByName(sinks []CloudEventsSink, name string) (*CloudEventsSink, error) {
+	for _, item := range sinks {
+		if item.Name == name {
+			return &item, nil
+		}
+	}
+	return nil, fmt.Errorf("cloudevents sink with name '%s' is not configured", name)
+}
+
+func (n *cloudEventsNotifier) Send(ctx context.Context, notification Notification, recipient string) error {
+	_, err := n.SendWithResult(ctx, notification, recipient)
+	return err
+}
+
+// SendWithResult sends the event and returns the response status; the event id is used as the message id since the
+// event receivers use it to deduplicate events
+func (n *cloudEventsNotifier) SendWithResult(ctx context.Context, notification Notification, recipient string) (*DeliveryResult, error) {
+	sink, err := findCloudEventsSinkByName(n.opts, recipient)
+	if err != nil {
+		return nil, err
+	}
+	event := newCloudEvent(*sink, notification)
+	var req *http.Request
+	switch coalesce(sink.Protocol, cloudEventsProtocolHTTP) {
+	case cloudEventsProtocolHTTP:
+		req, err = newCloudEventsHTTPRequest(*sink, event)
+	case cloudEventsProtocolKafka:
+		req, err = newCloudEventsKafkaRequest(*sink, event)
+	default:
+		err = fmt.Errorf("cloudevents protocol '%s' is not supported", sink.Protocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range sink.Headers {
+		req.Header.Set(h.Name, h.Value)
+	}
+	client := n.httpClient(fmt.Sprintf("cloudevents:%s", sink.Name), false)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	res := &DeliveryResult{MessageID: event.ID, StatusCode: resp.StatusCode}
+	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			data = []byte(fmt.Sprintf("unable to read response data: %v", err))
+		}
+		return res, fmt.Errorf("request to %s has failed with error code %d : %s", req.URL, resp.StatusCode, string(data))
+	}
+	return res, nil
+}
+
+// newCloudEventsHTTPRequest returns request that uses the CloudEvents HTTP protocol binding
+func newCloudEventsHTTPRequest(sink CloudEventsSink, event *cloudEvent) (*http.Request, error) {
+	switch coalesce(sink.Mode, cloudEventsModeBinary) {
+	case cloudEventsModeBinary:
+		req, err := http.NewRequest(http.MethodPost, sink.URL, bytes.NewReader(event.Data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", event.DataContentType)
+		req.Header.Set("Ce-Specversion", event.SpecVersion)
+		req.Header.Set("Ce-Id", event.ID)
+		req.Header.Set("Ce-Source", event.Source)
+		req.Header.Set("Ce-Type", event.Type)
+		req.Header.Set("Ce-Time", event.Time)
+		if event.Subject != "" {
+			req.Header.Set("Ce-Subject", event.Subject)
+		}
+		return req, nil
+	case cloudEventsModeStructured:
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest(http.MethodPost, sink.URL, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", CloudEventsContentType)
+		return req, nil
+	}
+	return nil, fmt.Errorf("cloudevents mode '%s' is not supported", sink.Mode)
+}
+
+// newCloudEventsKafkaRequest returns request that publishes structured mode event into the Kafka topic using the
+// Kafka REST Proxy. The event subject is used as the record key, so events of the same subject keep the order.
+func newCloudEventsKafkaRequest(sink CloudEventsSink, event *cloudEvent) (*http.Request, error) {
+	if sink.Topic == "" {
+		return nil, fmt.Errorf("kafka topic of the cloudevents sink '%s' is not configured", sink.Name)
+	}
+	record := map[string]interface{}{"value": event}
+	if event.Subject != "" {
+		record["key"] = event.Subject
+	}
+	data, err := json.Marshal(map[string]interface{}{"records": []interface{}{record}})
+	if err != nil {
+		return nil, err
+	}
+	url := strings.TrimRight(sink.URL, "/") + "/topics/" + sink.Topic
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", kafkaRestProxyContentType)
+	return req, nil
+}
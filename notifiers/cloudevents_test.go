@@ -0,0 +1,102 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCloudEventsTestServer(t *testing.T, path *string, headers *http.Header, body *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		*path = request.URL.Path
+		*headers = request.Header
+		data, err := ioutil.ReadAll(request.Body)
+		assert.NoError(t, err)
+		*body = string(data)
+	}))
+}
+
+func TestCloudEvents_Binary(t *testing.T) {
+	var path, body string
+	var headers http.Header
+	server := newCloudEventsTestServer(t, &path, &headers, &body)
+	defer server.Close()
+
+	notifier := NewCloudEventsNotifier(CloudEventsOptions{{Name: "broker", URL: server.URL, Source: "argocd/prod"}})
+	err := notifier.Send(context.Background(), Notification{CloudEvents: &CloudEventsNotification{
+		Type: "io.example.synced", Subject: "guestbook", Data: `{"app": "guestbook"}`,
+	}}, "broker")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, `{"app": "guestbook"}`, body)
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+	assert.Equal(t, "1.0", headers.Get("Ce-Specversion"))
+	assert.Equal(t, "io.example.synced", headers.Get("Ce-Type"))
+	assert.Equal(t, "argocd/prod", headers.Get("Ce-Source"))
+	assert.Equal(t, "guestbook", headers.Get("Ce-Subject"))
+	assert.NotEmpty(t, headers.Get("Ce-Id"))
+}
+
+func TestCloudEvents_Structured(t *testing.T) {
+	var path, body string
+	var headers http.Header
+	server := newCloudEventsTestServer(t, &path, &headers, &body)
+	defer server.Close()
+
+	notifier := NewCloudEventsNotifier(CloudEventsOptions{{Name: "broker", URL: server.URL, Mode: "structured"}})
+	err := notifier.Send(context.Background(), Notification{Title: "hello", Body: "world"}, "broker")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, CloudEventsContentType, headers.Get("Content-Type"))
+	var event map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal([]byte(body), &event)) {
+		return
+	}
+	assert.Equal(t, "1.0", event["specversion"])
+	assert.Equal(t, "io.argoproj.notifications.notification", event["type"])
+	assert.Equal(t, "argocd-notifications", event["source"])
+	assert.Equal(t, map[string]interface{}{"title": "hello", "body": "world"}, event["data"])
+}
+
+func TestCloudEvents_Kafka(t *testing.T) {
+	var path, body string
+	var headers http.Header
+	server := newCloudEventsTestServer(t, &path, &headers, &body)
+	defer server.Close()
+
+	notifier := NewCloudEventsNotifier(CloudEventsOptions{{Name: "kafka", URL: server.URL, Protocol: "kafka", Topic: "deployments"}})
+	err := notifier.Send(context.Background(), Notification{CloudEvents: &CloudEventsNotification{Subject: "guestbook", Data: "not json"}}, "kafka")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "/topics/deployments", path)
+	assert.Equal(t, "application/vnd.kafka.json.v2+json", headers.Get("Content-Type"))
+	var req struct {
+		Records []struct {
+			Key   string                 `json:"key"`
+			Value map[string]interface{} `json:"value"`
+		} `json:"records"`
+	}
+	if !assert.NoError(t, json.Unmarshal([]byte(body), &req)) || !assert.Len(t, req.Records, 1) {
+		return
+	}
+	assert.Equal(t, "guestbook", req.Records[0].Key)
+	assert.Equal(t, "not json", req.Records[0].Value["data"])
+}
+
+func TestCloudEvents_FailedToSendNotConfigured(t *testing.T) {
+	notifier := NewCloudEventsNotifier(CloudEventsOptions{})
+	err := notifier.Send(context.Background(), Notification{}, "test")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not configured")
+}
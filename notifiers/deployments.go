@@ -0,0 +1,314 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultGitHubApiUrl = "https://api.github.com"
+	defaultGitLabApiUrl = "https://gitlab.com/api/v4"
+
+	// DeploymentStatePending and the other deployment states use the GitHub deployment status vocabulary; the states
+	// are mapped to the GitLab deployment statuses
+	DeploymentStatePending    = "pending"
+	DeploymentStateQueued     = "queued"
+	DeploymentStateInProgress = "in_progress"
+	DeploymentStateSuccess    = "success"
+	DeploymentStateFailure    = "failure"
+	DeploymentStateError      = "error"
+	DeploymentStateInactive   = "inactive"
+)
+
+var (
+	// scpRepoURL matches the git@host:owner/repo.git repository URLs
+	scpRepoURL               = regexp.MustCompile(`^[^@/]+@[^:/]+:(.+)$`)
+	gitLabDeploymentStatuses = map[string]string{
+		DeploymentStatePending:    "created",
+		DeploymentStateQueued:     "created",
+		DeploymentStateInProgress: "running",
+		DeploymentStateSuccess:    "success",
+		DeploymentStateFailure:    "failed",
+		DeploymentStateError:      "failed",
+		DeploymentStateInactive:   "canceled",
+	}
+)
+
+// DeploymentNotification holds the templated fields of the GitHub or GitLab deployment which represents the application
+// sync. The deployment of the repository, environment and ref is created by the first notification, the following
+// notifications update the deployment status.
+type DeploymentNotification struct {
+	// RepoURL is the repository URL, e.g. {{.app.spec.source.repoURL}}
+	RepoURL string `json:"repoURL,omitempty" yaml:"repoURL,omitempty"`
+	// Ref is the deployed commit SHA, e.g. {{.app.status.operationState.syncResult.revision}}
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty"`
+	// TargetRevision is the branch or tag of the deployed commit; GitLab deployments require it, Ref is used if empty
+	TargetRevision string `json:"targetRevision,omitempty" yaml:"targetRevision,omitempty"`
+	// State is one of: pending|queued|in_progress|success|failure|error|inactive
+	State       string `json:"state,omitempty" yaml:"state,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// EnvironmentURL is the URL of the deployed environment, e.g. the application ingress URL; GitHub only
+	EnvironmentURL string `json:"environmentURL,omitempty" yaml:"environmentURL,omitempty"`
+	// LogURL is the URL of the deployment details, e.g. the application page in Argo CD; GitHub only
+	LogURL string `json:"logURL,omitempty" yaml:"logURL,omitempty"`
+}
+
+type GitHubOptions struct {
+	// ApiUrl is the GitHub REST API endpoint, e.g. of the GitHub Enterprise server; https://api.github.com if empty
+	ApiUrl string `json:"apiUrl,omitempty"`
+	Token  string `json:"token"`
+}
+
+type GitLabOptions struct {
+	// ApiUrl is the GitLab REST API endpoint, e.g. of the self-managed instance; https://gitlab.com/api/v4 if empty
+	ApiUrl string `json:"apiUrl,omitempty"`
+	Token  string `json:"token"`
+}
+
+// repoPath returns the path of the repository without the .git suffix, e.g. owner/repo for
+// https://github.com/owner/repo.git or git@github.com:owner/repo.git
+func repoPath(repoURL string) (string, error) {
+	path := ""
+	if match := scpRepoURL.FindStringSubmatch(repoURL); match != nil {
+		path = match[1]
+	} else if u, err := url.Parse(repoURL); err == nil {
+		path = u.Path
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if strings.Count(path, "/") < 1 {
+		return "", fmt.Errorf("failed to get repository path of %s", repoURL)
+	}
+	return path, nil
+}
+
+// deploymentsClient holds the deployment ids of the already created deployments
+type deploymentsClient struct {
+	httpConfig
+	service string
+	apiURL  string
+	headers map[string]string
+	lock    sync.Mutex
+	// ids are the deployment ids by repository path, environment and ref
+	ids map[string]int64
+}
+
+func newDeploymentsClient(service string, apiURL string, headers map[string]string) *deploymentsClient {
+	return &deploymentsClient{service: service, apiURL: strings.TrimSuffix(apiURL, "/"), headers: headers, ids: map[string]int64{}}
+}
+
+func (c *deploymentsClient) do(ctx context.Context, method string, path string, body interface{}, out interface{}) (*DeliveryResult, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, c.apiURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	client := c.httpClient(c.service, false)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	res := httpResult(resp)
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return res, err
+	}
+	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		return res, fmt.Errorf("request to %s has failed with error code %d : %s", req.URL, resp.StatusCode, string(respData))
+	}
+	if out != nil {
+		if err := json.Unmarshal(respData, out); err != nil {
+			return res, fmt.Errorf("failed to unmarshal response of %s: %v", req.URL, err)
+		}
+	}
+	return res, nil
+}
+
+func (c *deploymentsClient) cachedID(key string) (int64, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	id, ok := c.ids[key]
+	return id, ok
+}
+
+func (c *deploymentsClient) cacheID(key string, id int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ids[key] = id
+}
+
+type deployment struct {
+	ID  int64  `json:"id"`
+	Ref string `json:"ref"`
+	Sha string `json:"sha"`
+}
+
+// parseDeployment returns the deployment fields and the repository path of the notification
+func parseDeployment(notification Notification) (*DeploymentNotification, string, error) {
+	d := notification.Deployment
+	if d == nil || d.Ref == "" || d.RepoURL == "" {
+		return nil, "", fmt.Errorf("deployment notification requires the repoURL and ref fields")
+	}
+	if _, ok := gitLabDeploymentStatuses[d.State]; !ok {
+		return nil, "", fmt.Errorf("deployment state '%s' is not supported", d.State)
+	}
+	path, err := repoPath(d.RepoURL)
+	return d, path, err
+}
+
+// NewGitHubNotifier returns the notifier which creates GitHub deployments of the recipient environment and updates the
+// deployment statuses
+func NewGitHubNotifier(opts GitHubOptions) Notifier {
+	return &gitHubNotifier{client: newDeploymentsClient("github", coalesce(opts.ApiUrl, defaultGitHubApiUrl), map[string]string{
+		"Authorization": "token " + opts.Token,
+		"Accept":        "application/vnd.github.v3+json",
+	})}
+}
+
+type gitHubNotifier struct {
+	client *deploymentsClient
+}
+
+func (n *gitHubNotifier) setHTTPOptions(opts HTTPOptions) {
+	n.client.setHTTPOptions(opts)
+}
+
+func (n *gitHubNotifier) Send(ctx context.Context, notification Notification, environment string) error {
+	_, err := n.SendWithResult(ctx, notification, environment)
+	return err
+}
+
+// SendWithResult creates the deployment of the ref unless it already exists and creates the deployment status; the
+// deployment id is returned as the message id
+func (n *gitHubNotifier) SendWithResult(ctx context.Context, notification Notification, environment string) (*DeliveryResult, error) {
+	d, path, err := parseDeployment(notification)
+	if err != nil {
+		return nil, err
+	}
+	key := path + "/" + environment + "@" + d.Ref
+	id, ok := n.client.cachedID(key)
+	if !ok {
+		var existing []deployment
+		query := url.Values{"ref": {d.Ref}, "environment": {environment}, "per_page": {"1"}}
+		if _, err := n.client.do(ctx, http.MethodGet, "/repos/"+path+"/deployments?"+query.Encode(), nil, &existing); err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 {
+			id = existing[0].ID
+		} else {
+			created := deployment{}
+			res, err := n.client.do(ctx, http.MethodPost, "/repos/"+path+"/deployments", map[string]interface{}{
+				"ref":               d.Ref,
+				"environment":       environment,
+				"description":       d.Description,
+				"auto_merge":        false,
+				"required_contexts": []string{},
+			}, &created)
+			if err != nil {
+				return res, err
+			}
+			id = created.ID
+		}
+		n.client.cacheID(key, id)
+	}
+	res, err := n.client.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/deployments/%d/statuses", path, id), map[string]interface{}{
+		"state":           d.State,
+		"description":     d.Description,
+		"environment_url": d.EnvironmentURL,
+		"log_url":         d.LogURL,
+	}, nil)
+	if res != nil {
+		res.MessageID = strconv.FormatInt(id, 10)
+	}
+	return res, err
+}
+
+// NewGitLabNotifier returns the notifier which creates GitLab deployments of the recipient environment and updates the
+// deployment statuses
+func NewGitLabNotifier(opts GitLabOptions) Notifier {
+	return &gitLabNotifier{client: newDeploymentsClient("gitlab", coalesce(opts.ApiUrl, defaultGitLabApiUrl), map[string]string{
+		"PRIVATE-TOKEN": opts.Token,
+	})}
+}
+
+type gitLabNotifier struct {
+	client *deploymentsClient
+}
+
+func (n *gitLabNotifier) setHTTPOptions(opts HTTPOptions) {
+	n.client.setHTTPOptions(opts)
+}
+
+func (n *gitLabNotifier) Send(ctx context.Context, notification Notification, environment string) error {
+	_, err := n.SendWithResult(ctx, notification, environment)
+	return err
+}
+
+// SendWithResult creates the deployment of the ref unless it already exists or updates the deployment status; the
+// deployment id is returned as the message id
+func (n *gitLabNotifier) SendWithResult(ctx context.Context, notification Notification, environment string) (*DeliveryResult, error) {
+	d, path, err := parseDeployment(notification)
+	if err != nil {
+		return nil, err
+	}
+	project := "/projects/" + url.PathEscape(path)
+	status := gitLabDeploymentStatuses[d.State]
+	key := path + "/" + environment + "@" + d.Ref
+	id, ok := n.client.cachedID(key)
+	if !ok {
+		var existing []deployment
+		query := url.Values{"environment": {environment}, "order_by": {"created_at"}, "sort": {"desc"}}
+		if _, err := n.client.do(ctx, http.MethodGet, project+"/deployments?"+query.Encode(), nil, &existing); err != nil {
+			return nil, err
+		}
+		for _, item := range existing {
+			if item.Sha == d.Ref || item.Ref == d.Ref {
+				id, ok = item.ID, true
+				break
+			}
+		}
+	}
+	var res *DeliveryResult
+	if ok {
+		res, err = n.client.do(ctx, http.MethodPut, fmt.Sprintf("%s/deployments/%d", project, id), map[string]interface{}{
+			"status": status,
+		}, nil)
+	} else {
+		created := deployment{}
+		res, err = n.client.do(ctx, http.MethodPost, project+"/deployments", map[string]interface{}{
+			"environment": environment,
+			"sha":         d.Ref,
+			"ref":         coalesce(d.TargetRevision, d.Ref),
+			"tag":         false,
+			"status":      status,
+		}, &created)
+		id = created.ID
+	}
+	if err != nil {
+		return res, err
+	}
+	n.client.cacheID(key, id)
+	res.MessageID = strconv.FormatInt(id, 10)
+	return res, nil
+}
@@ -0,0 +1,125 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type deploymentsRequest struct {
+	method string
+	uri    string
+	body   map[string]interface{}
+}
+
+func newFakeDeploymentsServer(requests *[]deploymentsRequest, existing string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(data, &body)
+		*requests = append(*requests, deploymentsRequest{method: r.Method, uri: r.URL.RequestURI(), body: body})
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(existing))
+		default:
+			_, _ = w.Write([]byte(`{"id": 42}`))
+		}
+	}))
+}
+
+func TestRepoPath(t *testing.T) {
+	for repoURL, expected := range map[string]string{
+		"https://github.com/argoproj/argocd-example-apps.git":   "argoproj/argocd-example-apps",
+		"https://github.com/argoproj/argocd-example-apps":       "argoproj/argocd-example-apps",
+		"git@github.com:argoproj/argocd-example-apps.git":       "argoproj/argocd-example-apps",
+		"https://gitlab.com/group/subgroup/guestbook.git":       "group/subgroup/guestbook",
+		"ssh://git@gitlab.example.com:2222/group/guestbook.git": "group/guestbook",
+	} {
+		path, err := repoPath(repoURL)
+		assert.NoError(t, err, repoURL)
+		assert.Equal(t, expected, path, repoURL)
+	}
+	_, err := repoPath("https://github.com")
+	assert.Error(t, err)
+}
+
+func TestGitHub_SendDeployment(t *testing.T) {
+	var requests []deploymentsRequest
+	server := newFakeDeploymentsServer(&requests, `[]`)
+	defer server.Close()
+	notifier := NewGitHubNotifier(GitHubOptions{ApiUrl: server.URL, Token: "secret"})
+	notification := Notification{Deployment: &DeploymentNotification{
+		RepoURL: "https://github.com/argoproj/guestbook.git",
+		Ref:     "abc",
+		State:   DeploymentStateInProgress,
+		LogURL:  "https://argocd.example.com/applications/guestbook",
+	}}
+
+	res, err := SendWithResult(context.Background(), notifier, notification, "production")
+	assert.NoError(t, err)
+	assert.Equal(t, "42", res.MessageID)
+	notification.Deployment.State = DeploymentStateSuccess
+	assert.NoError(t, notifier.Send(context.Background(), notification, "production"))
+
+	if assert.Len(t, requests, 4) {
+		assert.Equal(t, "/repos/argoproj/guestbook/deployments?environment=production&per_page=1&ref=abc", requests[0].uri)
+		assert.Equal(t, http.MethodPost, requests[1].method)
+		assert.Equal(t, "/repos/argoproj/guestbook/deployments", requests[1].uri)
+		assert.Equal(t, "production", requests[1].body["environment"])
+		assert.Equal(t, "/repos/argoproj/guestbook/deployments/42/statuses", requests[2].uri)
+		assert.Equal(t, "in_progress", requests[2].body["state"])
+		assert.Equal(t, "https://argocd.example.com/applications/guestbook", requests[2].body["log_url"])
+		// the created deployment is reused
+		assert.Equal(t, "/repos/argoproj/guestbook/deployments/42/statuses", requests[3].uri)
+		assert.Equal(t, "success", requests[3].body["state"])
+	}
+}
+
+func TestGitLab_SendDeployment(t *testing.T) {
+	var requests []deploymentsRequest
+	server := newFakeDeploymentsServer(&requests, `[{"id": 7, "sha": "abc", "ref": "main"}]`)
+	defer server.Close()
+	notifier := NewGitLabNotifier(GitLabOptions{ApiUrl: server.URL, Token: "secret"})
+
+	res, err := SendWithResult(context.Background(), notifier, Notification{Deployment: &DeploymentNotification{
+		RepoURL: "https://gitlab.com/group/guestbook.git",
+		Ref:     "abc",
+		State:   DeploymentStateFailure,
+	}}, "production")
+	assert.NoError(t, err)
+	assert.Equal(t, "7", res.MessageID)
+
+	res, err = SendWithResult(context.Background(), notifier, Notification{Deployment: &DeploymentNotification{
+		RepoURL:        "https://gitlab.com/group/guestbook.git",
+		Ref:            "def",
+		TargetRevision: "main",
+		State:          DeploymentStatePending,
+	}}, "production")
+	assert.NoError(t, err)
+	assert.Equal(t, "42", res.MessageID)
+
+	if assert.Len(t, requests, 4) {
+		assert.Equal(t, "/projects/group%2Fguestbook/deployments?environment=production&order_by=created_at&sort=desc", requests[0].uri)
+		assert.Equal(t, http.MethodPut, requests[1].method)
+		assert.Equal(t, "/projects/group%2Fguestbook/deployments/7", requests[1].uri)
+		assert.Equal(t, map[string]interface{}{"status": "failed"}, requests[1].body)
+		assert.Equal(t, http.MethodPost, requests[3].method)
+		assert.Equal(t, map[string]interface{}{"environment": "production", "sha": "def", "ref": "main", "tag": false, "status": "created"}, requests[3].body)
+	}
+}
+
+func TestDeployment_InvalidNotification(t *testing.T) {
+	notifier := NewGitHubNotifier(GitHubOptions{Token: "secret"})
+
+	err := notifier.Send(context.Background(), Notification{Body: "hello"}, "production")
+	assert.EqualError(t, err, "deployment notification requires the repoURL and ref fields")
+
+	err = notifier.Send(context.Background(), Notification{Deployment: &DeploymentNotification{RepoURL: "https://github.com/argoproj/guestbook.git", Ref: "abc", State: "done"}}, "production")
+	assert.EqualError(t, err, "deployment state 'done' is not supported")
+}
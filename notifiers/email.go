@@ -1,9 +1,37 @@
 package notifiers
 
 import (
-	"gomodules.xyz/notify/smtp"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gomail "gopkg.in/gomail.v2"
+)
+
+const (
+	emailDialTimeout = 10 * time.Second
+	// emailMaxIdleConns is the default number of the idle connections kept open for the subsequent deliveries
+	emailMaxIdleConns = 2
+	// emailIdleTimeout is the default duration the idle connection is kept open; SMTP servers usually close the
+	// connections idle for a few minutes
+	emailIdleTimeout = 30 * time.Second
 )
 
+// emailPriorities are the X-Priority header values of the priority recipient option
+var emailPriorities = map[string]string{
+	"high":   "1 (Highest)",
+	"normal": "3 (Normal)",
+	"low":    "5 (Lowest)",
+}
+
 type EmailOptions struct {
 	Host               string `json:"host"`
 	Port               int    `json:"port"`
@@ -11,23 +39,322 @@ type EmailOptions struct {
 	Username           string `json:"username"`
 	Password           string `json:"password"`
 	From               string `json:"from"`
+	// MaxIdleConns is the number of the connections kept open for the subsequent deliveries, 2 if zero; negative
+	// value closes the connection after every message
+	MaxIdleConns int `json:"maxIdleConns,omitempty"`
+	// IdleTimeout is how long the idle connection is kept open, e.g. 1m; 30s if empty
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+}
+
+func (o EmailOptions) getMaxIdleConns() int {
+	if o.MaxIdleConns == 0 {
+		return emailMaxIdleConns
+	}
+	return o.MaxIdleConns
+}
+
+func (o EmailOptions) getIdleTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(o.IdleTimeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return emailIdleTimeout
 }
 
 type emailNotifier struct {
 	opts EmailOptions
+	lock sync.Mutex
+	// idle holds the connections of the completed deliveries, the most recently used last
+	idle []*emailConn
 }
 
 func NewEmailNotifier(opts EmailOptions) Notifier {
 	return &emailNotifier{opts: opts}
 }
 
-func (n *emailNotifier) Send(notification Notification, recipient string) error {
-	return smtp.New(smtp.Options{
-		From:               n.opts.From,
-		Host:               n.opts.Host,
-		Port:               n.opts.Port,
-		InsecureSkipVerify: n.opts.InsecureSkipVerify,
-		Password:           n.opts.Password,
-		Username:           n.opts.Username,
-	}).WithSubject(notification.Title).WithBody(notification.Body).To(recipient).Send()
+// Send sends the email message. The priority recipient option sets the message priority: high, normal or low.
+func (n *emailNotifier) Send(ctx context.Context, notification Notification, recipient string) error {
+	mail := n.newMessage(notification, recipient)
+	if priority := GetRecipientOptions(ctx).Get("priority"); priority != "" {
+		header, ok := emailPriorities[priority]
+		if !ok {
+			return fmt.Errorf("email priority %s is not valid, expected high, normal or low", priority)
+		}
+		mail.SetHeader("X-Priority", header)
+		mail.SetHeader("Importance", priority)
+	}
+	var msg bytes.Buffer
+	if _, err := mail.WriteTo(&msg); err != nil {
+		return err
+	}
+	err := n.send(ctx, recipient, msg.Bytes())
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// Preview returns the MIME message without sending it
+func (n *emailNotifier) Preview(notification Notification, recipient string) (string, error) {
+	var buf bytes.Buffer
+	if _, err := n.newMessage(notification, recipient).WriteTo(&buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (n *emailNotifier) newMessage(notification Notification, recipient string) *gomail.Message {
+	mail := gomail.NewMessage()
+	mail.SetHeader("From", n.opts.From)
+	mail.SetHeader("To", recipient)
+	mail.SetHeader("Subject", notification.Title)
+	mail.SetBody("text/plain", notification.Body)
+	return mail
+}
+
+func (n *emailNotifier) tlsConfig() *tls.Config {
+	if n.opts.InsecureSkipVerify {
+		return &tls.Config{InsecureSkipVerify: true}
+	}
+	return &tls.Config{ServerName: n.opts.Host}
+}
+
+// send delivers the message using the idle connection of the previous deliveries or the new connection if there
+// is no idle one. The connection is closed as soon as the context is canceled, so the unresponsive SMTP server does
+// not block the caller, and is returned to the idle connections once the message is delivered.
+func (n *emailNotifier) send(ctx context.Context, recipient string, msg []byte) error {
+	if strings.ContainsAny(n.opts.From+recipient, "\r\n") {
+		return errors.New("email address must not contain line breaks")
+	}
+	c := n.idleConn()
+	// the server might have closed the idle connection, so the connection is checked before it is reused
+	if c != nil && c.watch(ctx, c.client.Reset) != nil {
+		c.close()
+		c = nil
+	}
+	if c == nil {
+		var err error
+		if c, err = n.dial(ctx); err != nil {
+			return err
+		}
+	}
+	if err := c.watch(ctx, func() error {
+		return c.deliver(n.opts.From, recipient, msg)
+	}); err != nil {
+		c.close()
+		return err
+	}
+	n.releaseConn(c)
+	return nil
+}
+
+// dial opens the new SMTP connection. The connection is encrypted either from the start if the credentials are
+// configured and the port is 465 or using the STARTTLS extension if the server supports it.
+func (n *emailNotifier) dial(ctx context.Context) (*emailConn, error) {
+	dialer := net.Dialer{Timeout: emailDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.opts.Host, strconv.Itoa(n.opts.Port)))
+	if err != nil {
+		return nil, err
+	}
+	c := &emailConn{conn: conn}
+	err = c.watch(ctx, func() error {
+		hasAuth := n.opts.Username != "" && n.opts.Password != ""
+		ssl := hasAuth && n.opts.Port == 465
+		if ssl {
+			conn = tls.Client(conn, n.tlsConfig())
+		}
+		if c.client, err = smtp.NewClient(conn, n.opts.Host); err != nil {
+			return err
+		}
+		if ok, _ := c.client.Extension("STARTTLS"); ok && !ssl {
+			if err = c.client.StartTLS(n.tlsConfig()); err != nil {
+				return err
+			}
+		}
+		if ok, mechanisms := c.client.Extension("AUTH"); ok && hasAuth {
+			if err = c.client.Auth(n.auth(mechanisms)); err != nil {
+				return err
+			}
+		}
+		c.pipelining, _ = c.client.Extension("PIPELINING")
+		return nil
+	})
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// idleConn returns the most recently used idle connection; the connections idle for longer than the idle timeout
+// are closed
+func (n *emailNotifier) idleConn() *emailConn {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	for len(n.idle) > 0 {
+		c := n.idle[len(n.idle)-1]
+		n.idle = n.idle[:len(n.idle)-1]
+		if time.Since(c.idleSince) < n.opts.getIdleTimeout() {
+			return c
+		}
+		go c.quit()
+	}
+	return nil
+}
+
+// releaseConn returns the connection to the idle connections or closes it if there are enough idle connections
+func (n *emailNotifier) releaseConn(c *emailConn) {
+	c.idleSince = time.Now()
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if len(n.idle) >= n.opts.getMaxIdleConns() {
+		go c.quit()
+		return
+	}
+	n.idle = append(n.idle, c)
+}
+
+// emailConn is the SMTP connection which is reused by the subsequent deliveries
+type emailConn struct {
+	conn   net.Conn
+	client *smtp.Client
+	// pipelining is true if the server supports sending the envelope commands without waiting for the responses
+	pipelining bool
+	idleSince  time.Time
+}
+
+// watch runs the SMTP commands and closes the connection if the context is canceled before the commands complete
+func (c *emailConn) watch(ctx context.Context, commands func() error) error {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			_ = c.conn.Close()
+		case <-stop:
+		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.conn.SetDeadline(deadline)
+	}
+	err := commands()
+	close(stop)
+	<-stopped
+	// the connection deadline might be exceeded before the context is done
+	if deadline, ok := ctx.Deadline(); ok && err != nil && !time.Now().Before(deadline) {
+		err = context.DeadlineExceeded
+	} else if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	_ = c.conn.SetDeadline(time.Time{})
+	return err
+}
+
+// deliver sends the message in the single mail transaction; the MAIL, RCPT and DATA commands are sent at once if
+// the server supports pipelining
+func (c *emailConn) deliver(from string, recipient string, msg []byte) error {
+	var err error
+	if c.pipelining {
+		err = c.pipelineEnvelope(from, recipient)
+	} else {
+		err = c.envelope(from, recipient)
+	}
+	if err != nil {
+		return err
+	}
+	w := c.client.Text.DotWriter()
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	_, _, err = c.client.Text.ReadResponse(250)
+	return err
+}
+
+func (c *emailConn) envelope(from string, recipient string) error {
+	if err := c.client.Mail(from); err != nil {
+		return err
+	}
+	if err := c.client.Rcpt(recipient); err != nil {
+		return err
+	}
+	if err := c.client.Text.PrintfLine("DATA"); err != nil {
+		return err
+	}
+	_, _, err := c.client.Text.ReadResponse(354)
+	return err
+}
+
+// pipelineEnvelope writes the envelope commands at once and then reads the responses; the first rejected command
+// is reported since the following commands fail as well
+func (c *emailConn) pipelineEnvelope(from string, recipient string) error {
+	mail := fmt.Sprintf("MAIL FROM:<%s>", from)
+	if ok, _ := c.client.Extension("8BITMIME"); ok {
+		mail += " BODY=8BITMIME"
+	}
+	text := c.client.Text
+	_, _ = fmt.Fprintf(text.W, "%s\r\nRCPT TO:<%s>\r\nDATA\r\n", mail, recipient)
+	if err := text.W.Flush(); err != nil {
+		return err
+	}
+	var firstErr error
+	for _, code := range []int{250, 25, 354} {
+		if _, _, err := text.ReadResponse(code); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (c *emailConn) close() {
+	if c.client != nil {
+		_ = c.client.Close()
+	} else {
+		_ = c.conn.Close()
+	}
+}
+
+// quit ends the session of the idle connection and closes it
+func (c *emailConn) quit() {
+	_ = c.conn.SetDeadline(time.Now().Add(emailDialTimeout))
+	_ = c.client.Quit()
+	c.close()
+}
+
+// auth returns the most secure authentication mechanism supported by the server
+func (n *emailNotifier) auth(mechanisms string) smtp.Auth {
+	switch {
+	case strings.Contains(mechanisms, "CRAM-MD5"):
+		return smtp.CRAMMD5Auth(n.opts.Username, n.opts.Password)
+	case strings.Contains(mechanisms, "LOGIN") && !strings.Contains(mechanisms, "PLAIN"):
+		return &loginAuth{username: n.opts.Username, password: n.opts.Password}
+	default:
+		return smtp.PlainAuth("", n.opts.Username, n.opts.Password, n.opts.Host)
+	}
+}
+
+// loginAuth implements the LOGIN authentication mechanism which is not supported by the net/smtp package
+type loginAuth struct {
+	username string
+	password string
+}
+
+func (a *loginAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch {
+	case bytes.EqualFold(fromServer, []byte("Username:")):
+		return []byte(a.username), nil
+	case bytes.EqualFold(fromServer, []byte("Password:")):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
 }
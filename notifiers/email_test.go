@@ -0,0 +1,194 @@
+package notifiers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serveSMTP accepts a single connection and replies to the SMTP commands; the received DATA is sent to the channel
+func serveSMTP(t *testing.T, listener net.Listener, data chan<- string) {
+	conn, err := listener.Accept()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	reader := bufio.NewReader(conn)
+	_, _ = fmt.Fprint(conn, "220 localhost\r\n")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(strings.SplitN(strings.TrimSpace(line), " ", 2)[0]) {
+		case "DATA":
+			_, _ = fmt.Fprint(conn, "354 go ahead\r\n")
+			var msg strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				msg.WriteString(line)
+			}
+			data <- msg.String()
+			_, _ = fmt.Fprint(conn, "250 OK\r\n")
+		case "QUIT":
+			_, _ = fmt.Fprint(conn, "221 Bye\r\n")
+			return
+		default:
+			_, _ = fmt.Fprint(conn, "250 OK\r\n")
+		}
+	}
+}
+
+func TestEmail_Send(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	data := make(chan string, 1)
+	go serveSMTP(t, listener, data)
+
+	addr := listener.Addr().(*net.TCPAddr)
+	notifier := NewEmailNotifier(EmailOptions{Host: addr.IP.String(), Port: addr.Port, From: "argocd@example.com"})
+	err = notifier.Send(context.Background(), Notification{Title: "guestbook synced", Body: "revision abc"}, "dev@example.com")
+	if !assert.NoError(t, err) {
+		return
+	}
+	msg := <-data
+	assert.Contains(t, msg, "Subject: guestbook synced")
+	assert.Contains(t, msg, "revision abc")
+}
+
+func TestEmail_SendPriority(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	data := make(chan string, 1)
+	go serveSMTP(t, listener, data)
+
+	addr := listener.Addr().(*net.TCPAddr)
+	notifier := NewEmailNotifier(EmailOptions{Host: addr.IP.String(), Port: addr.Port, From: "argocd@example.com"})
+	ctx := WithRecipientOptions(context.Background(), RecipientOptions{"priority": "high"})
+	err = notifier.Send(ctx, Notification{Title: "guestbook degraded"}, "sre@example.com")
+	if !assert.NoError(t, err) {
+		return
+	}
+	msg := <-data
+	assert.Contains(t, msg, "X-Priority: 1 (Highest)")
+	assert.Contains(t, msg, "Importance: high")
+}
+
+func TestEmail_SendInvalidPriority(t *testing.T) {
+	notifier := NewEmailNotifier(EmailOptions{Host: "127.0.0.1", Port: 1})
+	ctx := WithRecipientOptions(context.Background(), RecipientOptions{"priority": "urgent"})
+	err := notifier.Send(ctx, Notification{}, "sre@example.com")
+	assert.EqualError(t, err, "email priority urgent is not valid, expected high, normal or low")
+}
+
+func TestEmail_SendCanceled(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	// the server accepts the connection but never responds
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	addr := listener.Addr().(*net.TCPAddr)
+	notifier := NewEmailNotifier(EmailOptions{Host: addr.IP.String(), Port: addr.Port, From: "argocd@example.com"})
+	start := time.Now()
+	err = notifier.Send(ctx, Notification{Title: "guestbook synced"}, "dev@example.com")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestEmail_SendReusesConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	data := make(chan string, 2)
+	// the server accepts the single connection, so the second message is delivered only if the connection is reused
+	go serveSMTP(t, listener, data)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	addr := listener.Addr().(*net.TCPAddr)
+	notifier := NewEmailNotifier(EmailOptions{Host: addr.IP.String(), Port: addr.Port, From: "argocd@example.com"})
+	for _, title := range []string{"guestbook synced", "guestbook deployed"} {
+		if !assert.NoError(t, notifier.Send(ctx, Notification{Title: title}, "dev@example.com")) {
+			return
+		}
+		assert.Contains(t, <-data, "Subject: "+title)
+	}
+}
+
+func TestEmail_SendPipelining(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	data := make(chan string, 1)
+	// the server responds to the envelope commands only after all of them are received
+	go func() {
+		conn, err := listener.Accept()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		_, _ = fmt.Fprint(conn, "220 localhost\r\n")
+		_, _ = reader.ReadString('\n')
+		_, _ = fmt.Fprint(conn, "250-localhost\r\n250 PIPELINING\r\n")
+		var commands []string
+		for i := 0; i < 3; i++ {
+			line, _ := reader.ReadString('\n')
+			commands = append(commands, strings.TrimSpace(line))
+		}
+		assert.Equal(t, []string{"MAIL FROM:<argocd@example.com>", "RCPT TO:<dev@example.com>", "DATA"}, commands)
+		_, _ = fmt.Fprint(conn, "250 OK\r\n250 OK\r\n354 go ahead\r\n")
+		var msg strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == ".\r\n" {
+				break
+			}
+			msg.WriteString(line)
+		}
+		data <- msg.String()
+		_, _ = fmt.Fprint(conn, "250 OK\r\n")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	addr := listener.Addr().(*net.TCPAddr)
+	notifier := NewEmailNotifier(EmailOptions{Host: addr.IP.String(), Port: addr.Port, From: "argocd@example.com"})
+	err = notifier.Send(ctx, Notification{Title: "guestbook synced"}, "dev@example.com")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, <-data, "Subject: guestbook synced")
+}
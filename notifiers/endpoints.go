@@ -0,0 +1,133 @@
+package notifiers
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+)
+
+const (
+	slackEndpoint    = "slack.com:443"
+	opsgenieEndpoint = "api.opsgenie.com:443"
+)
+
+// Endpointer is implemented by notifiers which know addresses of the notification service. The addresses are in
+// host:port format and might be used for connectivity checks.
+type Endpointer interface {
+	Endpoints() []string
+}
+
+// urlEndpoint returns host:port of the URL; default port of the URL scheme is used if URL has no port
+func urlEndpoint(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return "", false
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), true
+}
+
+func (n *emailNotifier) Endpoints() []string {
+	if n.opts.Host == "" {
+		return nil
+	}
+	return []string{net.JoinHostPort(n.opts.Host, strconv.Itoa(n.opts.Port))}
+}
+
+func (n *slackNotifier) Endpoints() []string {
+	return []string{slackEndpoint}
+}
+
+func (n *slackLookupNotifier) Endpoints() []string {
+	return n.slack.Endpoints()
+}
+
+func (n *opsgenieNotifier) Endpoints() []string {
+	if n.opts.ApiUrl == "" {
+		return []string{opsgenieEndpoint}
+	}
+	// opsgenie API URL is configured as a host name, e.g. api.eu.opsgenie.com
+	if endpoint, ok := urlEndpoint(n.opts.ApiUrl); ok {
+		return []string{endpoint}
+	}
+	return []string{net.JoinHostPort(n.opts.ApiUrl, "443")}
+}
+
+func (n *grafanaNotifier) Endpoints() []string {
+	if endpoint, ok := urlEndpoint(n.opts.ApiUrl); ok {
+		return []string{endpoint}
+	}
+	return nil
+}
+
+func (n *webhookNotifier) Endpoints() []string {
+	var res []string
+	for _, webhook := range n.opts {
+		if endpoint, ok := urlEndpoint(webhook.URL); ok {
+			res = append(res, endpoint)
+		}
+	}
+	return res
+}
+
+func (n *cloudEventsNotifier) Endpoints() []string {
+	var res []string
+	for _, sink := range n.opts {
+		if endpoint, ok := urlEndpoint(sink.URL); ok {
+			res = append(res, endpoint)
+		}
+	}
+	return res
+}
+
+func (n *eventBusNotifier) Endpoints() []string {
+	u, err := url.Parse(n.opts.URL)
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+	return []string{net.JoinHostPort(u.Hostname(), coalesce(u.Port(), "4222"))}
+}
+
+func (n *teamsNotifier) Endpoints() []string {
+	var res []string
+	for _, webhookURL := range n.opts.RecipientURLs {
+		if endpoint, ok := urlEndpoint(webhookURL); ok {
+			res = append(res, endpoint)
+		}
+	}
+	return res
+}
+
+func (n *gitHubNotifier) Endpoints() []string {
+	if endpoint, ok := urlEndpoint(n.client.apiURL); ok {
+		return []string{endpoint}
+	}
+	return nil
+}
+
+func (n *gitLabNotifier) Endpoints() []string {
+	if endpoint, ok := urlEndpoint(n.client.apiURL); ok {
+		return []string{endpoint}
+	}
+	return nil
+}
+
+func (n *gitHubDispatchNotifier) Endpoints() []string {
+	if endpoint, ok := urlEndpoint(n.client.apiURL); ok {
+		return []string{endpoint}
+	}
+	return nil
+}
+
+func (n *jenkinsNotifier) Endpoints() []string {
+	if endpoint, ok := urlEndpoint(n.opts.URL); ok {
+		return []string{endpoint}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package notifiers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpoints(t *testing.T) {
+	all := GetAll(Config{
+		Email:    &EmailOptions{Host: "smtp.example.com", Port: 587},
+		Slack:    &SlackOptions{},
+		Opsgenie: &OpsgenieOptions{ApiUrl: "api.eu.opsgenie.com"},
+		Grafana:  &GrafanaOptions{ApiUrl: "http://grafana.example.com/api"},
+		Webhook: &WebhookOptions{
+			{Name: "github", URL: "https://api.github.com"},
+			{Name: "local", URL: "http://localhost:8080/hook"},
+		},
+		Teams:   &TeamsOptions{RecipientURLs: map[string]string{"deployments": "https://example.webhook.office.com/webhookb2/abc"}},
+		GitHub:  &GitHubOptions{},
+		GitLab:  &GitLabOptions{ApiUrl: "https://gitlab.example.com/api/v4"},
+		Jenkins: &JenkinsOptions{URL: "https://jenkins.example.com"},
+	})
+	endpoints := map[string][]string{}
+	for name, notifier := range all {
+		endpoints[name] = notifier.(Endpointer).Endpoints()
+	}
+
+	assert.Equal(t, map[string][]string{
+		"email":           {"smtp.example.com:587"},
+		"slack":           {"slack.com:443"},
+		"slack-user":      {"slack.com:443"},
+		"slack-group":     {"slack.com:443"},
+		"opsgenie":        {"api.eu.opsgenie.com:443"},
+		"grafana":         {"grafana.example.com:80"},
+		"webhook":         {"api.github.com:443", "localhost:8080"},
+		"teams":           {"example.webhook.office.com:443"},
+		"github":          {"api.github.com:443"},
+		"github-dispatch": {"api.github.com:443"},
+		"jenkins":         {"jenkins.example.com:443"},
+		"gitlab":          {"gitlab.example.com:443"},
+	}, endpoints)
+}
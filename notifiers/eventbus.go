@@ -0,0 +1,89 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers/internal/nats"
+)
+
+const (
+	defaultEventBusSubjectPrefix   = "default"
+	defaultEventBusEventSourceName = "argocd-notifications"
+)
+
+// EventBusOptions configures publishing into the NATS or JetStream based Argo Events EventBus
+type EventBusOptions struct {
+	URL                string `json:"url"`
+	Token              string `json:"token"`
+	Username           string `json:"username"`
+	Password           string `json:"password"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+	// EventSourceName is the event source name referenced by the sensor dependencies
+	EventSourceName string `json:"eventSourceName"`
+	// SubjectPrefix is the first token of the EventBus subjects. Default value: default
+	SubjectPrefix string `json:"subjectPrefix"`
+}
+
+func (o EventBusOptions) natsOptions() nats.Options {
+	return nats.Options{
+		URL:                o.URL,
+		Token:              o.Token,
+		Username:           o.Username,
+		Password:           o.Password,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+		Name:               "argocd-notifications",
+	}
+}
+
+func (o EventBusOptions) eventSourceName() string {
+	return coalesce(o.EventSourceName, defaultEventBusEventSourceName)
+}
+
+// Subject returns the EventBus subject of the event source event
+func (o EventBusOptions) Subject(eventName string) string {
+	return coalesce(o.SubjectPrefix, defaultEventBusSubjectPrefix) + "." + o.eventSourceName() + "." + eventName
+}
+
+// Dial connects to the EventBus NATS server
+func (o EventBusOptions) Dial() (*nats.Conn, error) {
+	return nats.Dial(o.natsOptions())
+}
+
+func NewEventBusNotifier(opts EventBusOptions) Notifier {
+	return &eventBusNotifier{opts: opts}
+}
+
+type eventBusNotifier struct {
+	opts EventBusOptions
+	lock sync.Mutex
+	conn *nats.Conn
+}
+
+// Send publishes the structured mode CloudEvent using the Argo Events event source conventions: the recipient is the
+// event name which is used as the event subject and the last token of the EventBus subject.
+func (n *eventBusNotifier) Send(ctx context.Context, notification Notification, recipient string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	event := newCloudEvent(CloudEventsSink{Source: n.opts.eventSourceName()}, notification)
+	event.Subject = recipient
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.conn == nil {
+		if n.conn, err = n.opts.Dial(); err != nil {
+			return err
+		}
+	}
+	if err = n.conn.Publish(n.opts.Subject(recipient), data); err != nil {
+		// the connection is re-established during the next attempt
+		_ = n.conn.Close()
+		n.conn = nil
+	}
+	return err
+}
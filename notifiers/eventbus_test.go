@@ -0,0 +1,19 @@
+package notifiers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBusOptions_Subject(t *testing.T) {
+	assert.Equal(t, "default.argocd-notifications.app-synced", EventBusOptions{}.Subject("app-synced"))
+	assert.Equal(t, "eventbus.prod.app-synced", EventBusOptions{SubjectPrefix: "eventbus", EventSourceName: "prod"}.Subject("app-synced"))
+}
+
+func TestEventBus_FailedToConnect(t *testing.T) {
+	notifier := NewEventBusNotifier(EventBusOptions{URL: "http://localhost:4222"})
+	err := notifier.Send(context.Background(), Notification{}, "app-synced")
+	assert.EqualError(t, err, "NATS URL http://localhost:4222 should use nats or tls scheme")
+}
@@ -0,0 +1,15 @@
+module github.com/argoproj-labs/argocd-notifications/notifiers
+
+go 1.13
+
+require (
+	github.com/golang/mock v1.3.1
+	github.com/kr/pretty v0.1.0 // indirect
+	github.com/opsgenie/opsgenie-go-sdk-v2 v1.0.5
+	github.com/sirupsen/logrus v1.4.2
+	github.com/slack-go/slack v0.6.6
+	github.com/stretchr/testify v1.7.0
+	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
+	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
+	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df
+)
@@ -2,17 +2,16 @@ package notifiers
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
-	httputil "github.com/argoproj-labs/argocd-notifications/shared/http"
-
 	log "github.com/sirupsen/logrus"
 )
 
@@ -23,6 +22,7 @@ type GrafanaOptions struct {
 }
 
 type grafanaNotifier struct {
+	httpConfig
 	opts GrafanaOptions
 }
 
@@ -37,7 +37,13 @@ type GrafanaAnnotation struct {
 	Text     string   `json:"text"`
 }
 
-func (n *grafanaNotifier) Send(notification Notification, tags string) error {
+func (n *grafanaNotifier) Send(ctx context.Context, notification Notification, tags string) error {
+	_, err := n.SendWithResult(ctx, notification, tags)
+	return err
+}
+
+// SendWithResult creates the annotation and returns the annotation id
+func (n *grafanaNotifier) SendWithResult(ctx context.Context, notification Notification, tags string) (*DeliveryResult, error) {
 	ga := GrafanaAnnotation{
 		Time:     time.Now().Unix() * 1000, // unix ts in ms
 		IsRegion: false,
@@ -45,31 +51,38 @@ func (n *grafanaNotifier) Send(notification Notification, tags string) error {
 		Text:     notification.Title,
 	}
 
-	client := &http.Client{
-		Transport: httputil.NewLoggingRoundTripper(&http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: n.opts.InsecureSkipVerify,
-			},
-		}, log.WithField("notifier", "grafana")),
-	}
+	client := n.httpClient("grafana", n.opts.InsecureSkipVerify)
 
 	jsonValue, _ := json.Marshal(ga)
 	apiUrl, err := url.Parse(n.opts.ApiUrl)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 	annotationApi := *apiUrl
 	annotationApi.Path = path.Join(apiUrl.Path, "annotations")
 	req, err := http.NewRequest("POST", annotationApi.String(), bytes.NewBuffer(jsonValue))
 	if err != nil {
 		log.Errorf("Failed to create grafana annotation request: %s", err)
-		return err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", n.opts.ApiKey))
 
-	_, err = client.Do(req)
-	return err
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	res := httpResult(resp)
+	var body struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.ID != 0 {
+		res.MessageID = strconv.FormatInt(body.ID, 10)
+	}
+	return res, nil
 }
@@ -0,0 +1,76 @@
+package notifiers
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	httputil "github.com/argoproj-labs/argocd-notifications/notifiers/internal/http"
+)
+
+const (
+	// defaultMaxIdleConnsPerHost is higher than the net/http default, so the notification bursts to the same service
+	// reuse the connections instead of opening the new ones
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// HTTPOptions tune the HTTP clients of the notifiers; the notifiers with the same options share the connections
+type HTTPOptions struct {
+	// Timeout limits the request duration including reading the response body, e.g. 30s; not limited if empty
+	Timeout string `json:"timeout,omitempty"`
+	// ResponseHeaderTimeout limits the time waiting for the response headers after the request is sent
+	ResponseHeaderTimeout string `json:"responseHeaderTimeout,omitempty"`
+	// IdleConnTimeout is how long the idle keep-alive connection is kept open, 90s if empty
+	IdleConnTimeout string `json:"idleConnTimeout,omitempty"`
+	// MaxIdleConnsPerHost is the number of the idle keep-alive connections kept open per host, 10 if zero
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost,omitempty"`
+	// MaxConnsPerHost limits the number of the connections per host; not limited if zero
+	MaxConnsPerHost int `json:"maxConnsPerHost,omitempty"`
+}
+
+// httpConfigurable is implemented by the notifiers that send the requests using the HTTP client
+type httpConfigurable interface {
+	setHTTPOptions(opts HTTPOptions)
+}
+
+// httpConfig holds the HTTP options of the notifier
+type httpConfig struct {
+	httpOpts HTTPOptions
+}
+
+func (c *httpConfig) setHTTPOptions(opts HTTPOptions) {
+	c.httpOpts = opts
+}
+
+// httpClient returns the client using the shared transport; the requests and responses are logged with the
+// notifier name
+func (c *httpConfig) httpClient(notifier string, insecureSkipVerify bool) *http.Client {
+	return &http.Client{
+		Timeout:   parseDuration(c.httpOpts.Timeout, 0),
+		Transport: httputil.NewLoggingRoundTripper(c.transport(insecureSkipVerify), log.WithField("notifier", notifier)),
+	}
+}
+
+func (c *httpConfig) transport(insecureSkipVerify bool) *http.Transport {
+	maxIdleConnsPerHost := c.httpOpts.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost == 0 {
+		maxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	return httputil.NewTransport(httputil.TransportOptions{
+		InsecureSkipVerify:    insecureSkipVerify,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		MaxConnsPerHost:       c.httpOpts.MaxConnsPerHost,
+		IdleConnTimeout:       parseDuration(c.httpOpts.IdleConnTimeout, defaultIdleConnTimeout),
+		ResponseHeaderTimeout: parseDuration(c.httpOpts.ResponseHeaderTimeout, 0),
+	})
+}
+
+// parseDuration returns the default duration if the value is empty or malformed
+func parseDuration(value string, defaultDuration time.Duration) time.Duration {
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return duration
+	}
+	return defaultDuration
+}
@@ -0,0 +1,33 @@
+package notifiers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPConfig_SharedTransport(t *testing.T) {
+	teams := &httpConfig{}
+	webhook := &httpConfig{}
+	assert.Same(t, teams.transport(false), webhook.transport(false))
+	assert.NotSame(t, teams.transport(false), teams.transport(true))
+
+	transport := teams.transport(false)
+	assert.Equal(t, defaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, defaultIdleConnTimeout, transport.IdleConnTimeout)
+	assert.True(t, transport.ForceAttemptHTTP2)
+}
+
+func TestGetAll_HTTPOptions(t *testing.T) {
+	all := GetAll(Config{
+		Teams:  &TeamsOptions{},
+		GitHub: &GitHubOptions{},
+		HTTP:   &HTTPOptions{Timeout: "30s", IdleConnTimeout: "1m", MaxConnsPerHost: 20},
+	})
+	teams := all["teams"].(*teamsNotifier)
+	assert.Equal(t, 30*time.Second, teams.httpClient("teams", false).Timeout)
+	assert.Equal(t, time.Minute, teams.transport(false).IdleConnTimeout)
+	assert.Equal(t, 20, teams.transport(false).MaxConnsPerHost)
+	assert.Same(t, teams.transport(false), all["github"].(*gitHubNotifier).client.transport(false))
+}
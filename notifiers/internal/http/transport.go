@@ -0,0 +1,54 @@
+package http
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TransportOptions tune the connections of the shared transport
+type TransportOptions struct {
+	InsecureSkipVerify    bool
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	ResponseHeaderTimeout time.Duration
+}
+
+var (
+	transportsLock sync.Mutex
+	transports     = map[TransportOptions]*http.Transport{}
+)
+
+// NewTransport returns the transport shared by the clients with the same options, so the keep-alive connections are
+// reused by the notifiers and survive the configuration reloads
+func NewTransport(opts TransportOptions) *http.Transport {
+	transportsLock.Lock()
+	defer transportsLock.Unlock()
+	if transport, ok := transports[opts]; ok {
+		return transport
+	}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		// HTTP/2 is not negotiated by default if the TLS config is customized
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	if opts.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	transports[opts] = transport
+	return transport
+}
@@ -0,0 +1,216 @@
+// Package nats implements the subset of the NATS client protocol required to publish and consume messages of the
+// NATS and JetStream based Argo Events EventBus.
+package nats
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPort    = "4222"
+	requestTimeout = 10 * time.Second
+	maxControlLine = 4096
+)
+
+// Options configures the NATS server connection
+type Options struct {
+	// URL of the NATS server in nats://<host>:<port> format; use tls:// scheme to require TLS
+	URL string
+	// Token, Username and Password are the optional credentials
+	Token    string
+	Username string
+	Password string
+	// InsecureSkipVerify skips the server certificate verification
+	InsecureSkipVerify bool
+	// Name is the client name reported to the server
+	Name string
+}
+
+type serverInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+type connectInfo struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Token    string `json:"auth_token,omitempty"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Lang     string `json:"lang"`
+	Version  string `json:"version"`
+	Protocol int    `json:"protocol"`
+}
+
+// Conn is the NATS server connection. The connection should be used either to publish or to consume messages.
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	lock   sync.Mutex
+}
+
+// Dial connects to the NATS server and authenticates the client
+func Dial(opts Options) (*Conn, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("NATS URL %s should use nats or tls scheme", opts.URL)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+	if u.User != nil && opts.Username == "" && opts.Token == "" {
+		if password, ok := u.User.Password(); ok {
+			opts.Username, opts.Password = u.User.Username(), password
+		} else {
+			opts.Token = u.User.Username()
+		}
+	}
+	conn, err := net.DialTimeout("tcp", addr, requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{conn: conn, reader: bufio.NewReaderSize(conn, maxControlLine)}
+	if err = c.handshake(opts, u.Scheme == "tls", u.Hostname()); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Conn) handshake(opts Options, requireTLS bool, host string) error {
+	_ = c.conn.SetDeadline(time.Now().Add(requestTimeout))
+	defer func() {
+		_ = c.conn.SetDeadline(time.Time{})
+	}()
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected NATS server greeting: %s", line)
+	}
+	var info serverInfo
+	if err = json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return err
+	}
+	if info.TLSRequired || requireTLS {
+		tlsConn := tls.Client(c.conn, &tls.Config{ServerName: host, InsecureSkipVerify: opts.InsecureSkipVerify})
+		if err = tlsConn.Handshake(); err != nil {
+			return err
+		}
+		c.conn = tlsConn
+		c.reader = bufio.NewReaderSize(tlsConn, maxControlLine)
+	}
+	connect, err := json.Marshal(connectInfo{
+		Token: opts.Token, User: opts.Username, Pass: opts.Password, Name: opts.Name,
+		Lang: "go", Version: "1.0.0", Protocol: 1,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(c.conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+	return c.waitPong()
+}
+
+func (c *Conn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// waitPong reads server messages until PONG and returns the server error if any
+func (c *Conn) waitPong() error {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err = io.WriteString(c.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server error: %s", strings.Trim(strings.TrimPrefix(line, "-ERR"), " '"))
+		}
+	}
+}
+
+// Publish sends message to the subject and waits until the server processes it
+func (c *Conn) Publish(subject string, data []byte) error {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return fmt.Errorf("invalid NATS subject '%s'", subject)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_ = c.conn.SetDeadline(time.Now().Add(requestTimeout))
+	defer func() {
+		_ = c.conn.SetDeadline(time.Time{})
+	}()
+	if _, err := fmt.Fprintf(c.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(data), data); err != nil {
+		return err
+	}
+	return c.waitPong()
+}
+
+// Subscribe subscribes to the subject and invokes the handler for every received message. The method blocks until
+// the connection is closed.
+func (c *Conn) Subscribe(subject string, handler func(subject string, data []byte)) error {
+	if _, err := fmt.Fprintf(c.conn, "SUB %s 1\r\n", subject); err != nil {
+		return err
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PING":
+			if _, err = io.WriteString(c.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server error: %s", strings.Trim(strings.TrimPrefix(line, "-ERR"), " '"))
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				return fmt.Errorf("malformed NATS message: %s", line)
+			}
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return fmt.Errorf("malformed NATS message: %s", line)
+			}
+			data := make([]byte, size+2)
+			if _, err = io.ReadFull(c.reader, data); err != nil {
+				return err
+			}
+			handler(fields[1], data[:size])
+		}
+	}
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,114 @@
+package nats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type message struct {
+	subject string
+	data    string
+}
+
+// newTestServer starts NATS server that accepts the specified token and sends received messages to the channel
+func newTestServer(t *testing.T, token string, published chan<- message) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() {
+					_ = conn.Close()
+				}()
+				reader := bufio.NewReader(conn)
+				_, _ = fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					fields := strings.Fields(line)
+					switch fields[0] {
+					case "CONNECT":
+						if token != "" && !strings.Contains(line, `"auth_token":"`+token+`"`) {
+							_, _ = fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+							return
+						}
+					case "PING":
+						_, _ = fmt.Fprint(conn, "PONG\r\n")
+					case "PUB":
+						size, _ := strconv.Atoi(fields[2])
+						data := make([]byte, size+2)
+						_, _ = io.ReadFull(reader, data)
+						published <- message{subject: fields[1], data: string(data[:size])}
+					case "SUB":
+						_, _ = fmt.Fprintf(conn, "PING\r\nMSG %s %s 5\r\nhello\r\n", fields[1], fields[2])
+					}
+				}
+			}()
+		}
+	}()
+	return listener
+}
+
+func TestPublish(t *testing.T) {
+	published := make(chan message, 1)
+	listener := newTestServer(t, "secret", published)
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	conn, err := Dial(Options{URL: "nats://" + listener.Addr().String(), Token: "secret"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	assert.NoError(t, conn.Publish("default.argocd.app-synced", []byte("hello")))
+	assert.Equal(t, message{subject: "default.argocd.app-synced", data: "hello"}, <-published)
+	assert.Error(t, conn.Publish("invalid subject", nil))
+}
+
+func TestDial_InvalidToken(t *testing.T) {
+	listener := newTestServer(t, "secret", nil)
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	_, err := Dial(Options{URL: "nats://" + listener.Addr().String(), Token: "invalid"})
+	assert.EqualError(t, err, "NATS server error: Authorization Violation")
+}
+
+func TestSubscribe(t *testing.T) {
+	listener := newTestServer(t, "", nil)
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	conn, err := Dial(Options{URL: "nats://" + listener.Addr().String()})
+	if !assert.NoError(t, err) {
+		return
+	}
+	var received []message
+	err = conn.Subscribe("default.ci.*", func(subject string, data []byte) {
+		received = append(received, message{subject: subject, data: string(data)})
+		_ = conn.Close()
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []message{{subject: "default.ci.*", data: "hello"}}, received)
+}
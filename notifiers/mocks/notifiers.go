@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	notifiers "github.com/argoproj-labs/argocd-notifications/notifiers"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -34,15 +35,15 @@ func (m *MockNotifier) EXPECT() *MockNotifierMockRecorder {
 }
 
 // Send mocks base method
-func (m *MockNotifier) Send(arg0 notifiers.Notification, arg1 string) error {
+func (m *MockNotifier) Send(arg0 context.Context, arg1 notifiers.Notification, arg2 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Send", arg0, arg1)
+	ret := m.ctrl.Call(m, "Send", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Send indicates an expected call of Send
-func (mr *MockNotifierMockRecorder) Send(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockNotifierMockRecorder) Send(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockNotifier)(nil).Send), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockNotifier)(nil).Send), arg0, arg1, arg2)
 }
@@ -1,11 +1,21 @@
 package notifiers
 
+import "context"
+
 type Config struct {
-	Email    *EmailOptions    `json:"email"`
-	Slack    *SlackOptions    `json:"slack"`
-	Opsgenie *OpsgenieOptions `json:"opsgenie"`
-	Grafana  *GrafanaOptions  `json:"grafana"`
-	Webhook  *WebhookOptions  `json:"webhook"`
+	Email       *EmailOptions       `json:"email"`
+	Slack       *SlackOptions       `json:"slack"`
+	Opsgenie    *OpsgenieOptions    `json:"opsgenie"`
+	Grafana     *GrafanaOptions     `json:"grafana"`
+	Webhook     *WebhookOptions     `json:"webhook"`
+	CloudEvents *CloudEventsOptions `json:"cloudevents"`
+	EventBus    *EventBusOptions    `json:"eventbus"`
+	Teams       *TeamsOptions       `json:"teams"`
+	GitHub      *GitHubOptions      `json:"github"`
+	GitLab      *GitLabOptions      `json:"gitlab"`
+	Jenkins     *JenkinsOptions     `json:"jenkins"`
+	// HTTP tunes the HTTP clients of the notifiers
+	HTTP *HTTPOptions `json:"http,omitempty"`
 }
 
 type SlackSpecific struct {
@@ -14,16 +24,29 @@ type SlackSpecific struct {
 }
 
 type Notification struct {
-	Title   string                         `json:"title,omitempty"`
-	Body    string                         `json:"body,omitempty"`
-	Slack   *SlackNotification             `json:"slack,omitempty"`
-	Webhook map[string]WebhookNotification `json:"webhook,omitempty" patchStrategy:"replace"`
+	Title          string                         `json:"title,omitempty"`
+	Body           string                         `json:"body,omitempty"`
+	Slack          *SlackNotification             `json:"slack,omitempty"`
+	Webhook        map[string]WebhookNotification `json:"webhook,omitempty" patchStrategy:"replace"`
+	CloudEvents    *CloudEventsNotification       `json:"cloudevents,omitempty"`
+	Deployment     *DeploymentNotification        `json:"deployment,omitempty"`
+	Jenkins        *JenkinsNotification           `json:"jenkins,omitempty"`
+	GitHubDispatch *GitHubDispatchNotification    `json:"githubDispatch,omitempty"`
 }
 
 //go:generate mockgen -destination=./mocks/notifiers.go -package=mocks github.com/argoproj-labs/argocd-notifications/notifiers Notifier
 
+// Notifier delivers notifications to the recipients of the notification service. The context limits the delivery
+// duration and carries the cancellation; the notifiers backed by the clients without context support check the
+// context only before sending.
 type Notifier interface {
-	Send(notification Notification, recipient string) error
+	Send(ctx context.Context, notification Notification, recipient string) error
+}
+
+// Previewer is implemented by the notifiers that can render the notification in the service specific format, e.g.
+// Slack message JSON or email MIME message, without delivering it
+type Previewer interface {
+	Preview(notification Notification, recipient string) (string, error)
 }
 
 func GetAll(config Config) map[string]Notifier {
@@ -32,7 +55,10 @@ func GetAll(config Config) map[string]Notifier {
 		res["email"] = NewEmailNotifier(*config.Email)
 	}
 	if config.Slack != nil {
-		res["slack"] = NewSlackNotifier(*config.Slack)
+		slackNotifier := newSlackNotifier(*config.Slack)
+		res["slack"] = slackNotifier
+		res[SlackUserService] = &slackLookupNotifier{slack: slackNotifier, service: SlackUserService, lookup: lookupSlackUser}
+		res[SlackGroupService] = &slackLookupNotifier{slack: slackNotifier, service: SlackGroupService, lookup: lookupSlackGroup}
 	}
 	if config.Opsgenie != nil {
 		res["opsgenie"] = NewOpsgenieNotifier(*config.Opsgenie)
@@ -45,5 +71,50 @@ func GetAll(config Config) map[string]Notifier {
 	if config.Webhook != nil {
 		res["webhook"] = NewWebhookNotifier(*config.Webhook)
 	}
+
+	if config.CloudEvents != nil {
+		res["cloudevents"] = NewCloudEventsNotifier(*config.CloudEvents)
+	}
+
+	if config.EventBus != nil {
+		res["eventbus"] = NewEventBusNotifier(*config.EventBus)
+	}
+
+	if config.Teams != nil {
+		res["teams"] = NewTeamsNotifier(*config.Teams)
+	}
+
+	if config.GitHub != nil {
+		res["github"] = NewGitHubNotifier(*config.GitHub)
+		res[GitHubDispatchService] = NewGitHubDispatchNotifier(*config.GitHub)
+	}
+
+	if config.GitLab != nil {
+		res["gitlab"] = NewGitLabNotifier(*config.GitLab)
+	}
+
+	if config.Jenkins != nil {
+		res["jenkins"] = NewJenkinsNotifier(*config.Jenkins)
+	}
+
+	if config.HTTP != nil {
+		for _, n := range res {
+			if configurable, ok := n.(httpConfigurable); ok {
+				configurable.setHTTPOptions(*config.HTTP)
+			}
+		}
+	}
+	return res
+}
+
+// coalesce returns the first non-empty string
+func coalesce(first string, other ...string) string {
+	res := first
+	for i := range other {
+		if res != "" {
+			break
+		}
+		res = other[i]
+	}
 	return res
 }
@@ -3,13 +3,9 @@ package notifiers
 import (
 	"context"
 	"fmt"
-	"net/http"
 
 	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
 	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
-	log "github.com/sirupsen/logrus"
-
-	httputil "github.com/argoproj-labs/argocd-notifications/shared/http"
 )
 
 type OpsgenieOptions struct {
@@ -18,6 +14,7 @@ type OpsgenieOptions struct {
 }
 
 type opsgenieNotifier struct {
+	httpConfig
 	opts OpsgenieOptions
 }
 
@@ -25,19 +22,24 @@ func NewOpsgenieNotifier(opts OpsgenieOptions) Notifier {
 	return &opsgenieNotifier{opts: opts}
 }
 
-func (n *opsgenieNotifier) Send(notification Notification, recipient string) error {
+func (n *opsgenieNotifier) Send(ctx context.Context, notification Notification, recipient string) error {
+	_, err := n.SendWithResult(ctx, notification, recipient)
+	return err
+}
+
+// SendWithResult creates the alert and returns the request id; Opsgenie processes the alert creation requests
+// asynchronously and the request id is used to query the request status
+func (n *opsgenieNotifier) SendWithResult(ctx context.Context, notification Notification, recipient string) (*DeliveryResult, error) {
 	apiKey, ok := n.opts.ApiKeys[recipient]
 	if !ok {
-		return fmt.Errorf("no API key configured for recipient %s", recipient)
+		return nil, fmt.Errorf("no API key configured for recipient %s", recipient)
 	}
 	alertClient, _ := alert.NewClient(&client.Config{
 		ApiKey:         apiKey,
 		OpsGenieAPIURL: client.ApiUrl(n.opts.ApiUrl),
-		HttpClient: &http.Client{
-			Transport: httputil.NewLoggingRoundTripper(http.DefaultTransport, log.WithField("notifier", "opsgenie")),
-		},
+		HttpClient:     n.httpClient("opsgenie", false),
 	})
-	_, err := alertClient.Create(context.TODO(), &alert.CreateAlertRequest{
+	res, err := alertClient.Create(ctx, &alert.CreateAlertRequest{
 		Message:     notification.Title,
 		Description: notification.Body,
 		Responders: []alert.Responder{
@@ -48,5 +50,8 @@ func (n *opsgenieNotifier) Send(notification Notification, recipient string) err
 		},
 		Source: "Argo CD",
 	})
-	return err
+	if err != nil {
+		return nil, err
+	}
+	return &DeliveryResult{MessageID: res.RequestId, Channel: recipient}, nil
 }
@@ -0,0 +1,50 @@
+package notifiers
+
+import (
+	"context"
+	"strconv"
+)
+
+const (
+	// OptionGroupKey identifies the related notifications, e.g. of the same application. The Slack notifier posts
+	// notifications with the same group key to the single thread if the thread option is enabled.
+	OptionGroupKey = "groupKey"
+)
+
+type recipientOptionsKey struct{}
+
+// RecipientOptions are the per-delivery options of the recipient, e.g. thread=true of the slack:deploys?thread=true
+// recipient. The notifiers ignore unknown options.
+type RecipientOptions map[string]string
+
+// WithRecipientOptions returns the context that carries the recipient options to the notifier
+func WithRecipientOptions(ctx context.Context, options RecipientOptions) context.Context {
+	if len(options) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, recipientOptionsKey{}, options)
+}
+
+// GetRecipientOptions returns the recipient options of the context; the result is nil if the context has no options
+func GetRecipientOptions(ctx context.Context) RecipientOptions {
+	options, _ := ctx.Value(recipientOptionsKey{}).(RecipientOptions)
+	return options
+}
+
+// Get returns the option value or an empty string if the option is not set
+func (o RecipientOptions) Get(name string) string {
+	return o[name]
+}
+
+// Bool returns the boolean option value or the default value if the option is not set or is not valid boolean
+func (o RecipientOptions) Bool(name string, defaultValue bool) bool {
+	v, ok := o[name]
+	if !ok {
+		return defaultValue
+	}
+	res, err := strconv.ParseBool(v)
+	if err != nil {
+		return defaultValue
+	}
+	return res
+}
@@ -0,0 +1,19 @@
+package notifiers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecipientOptions(t *testing.T) {
+	assert.Nil(t, GetRecipientOptions(context.Background()))
+	assert.Equal(t, context.Background(), WithRecipientOptions(context.Background(), nil))
+
+	options := GetRecipientOptions(WithRecipientOptions(context.Background(), RecipientOptions{"thread": "true", "broadcast": "yes"}))
+	assert.True(t, options.Bool("thread", false))
+	assert.True(t, options.Bool("broadcast", true))
+	assert.False(t, options.Bool("missing", false))
+	assert.Equal(t, "true", options.Get("thread"))
+}
@@ -0,0 +1,76 @@
+package notifiers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestIDHeaders are the response headers which hold the provider side request id
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "Request-Id"}
+
+// DeliveryResult is the notification service response to the delivered notification. The fields are set only if the
+// notification service returns them.
+type DeliveryResult struct {
+	// MessageID identifies the created message or the request on the notification service side, e.g. Slack message
+	// timestamp or Opsgenie request id. The message id of the Slack message might be used to update the message or
+	// start a thread.
+	MessageID string `json:"messageId,omitempty"`
+	// Channel is the channel the message was posted to, e.g. the Slack channel id
+	Channel string `json:"channel,omitempty"`
+	// Timestamp is the time the notification service accepted the notification
+	Timestamp time.Time `json:"timestamp"`
+	// StatusCode is the HTTP status of the notification service response
+	StatusCode int `json:"statusCode,omitempty"`
+}
+
+// ResultSender is implemented by the notifiers that return the notification service response. The result might be
+// returned together with the error, e.g. to provide the HTTP status of the rejected request.
+type ResultSender interface {
+	SendWithResult(ctx context.Context, notification Notification, recipient string) (*DeliveryResult, error)
+}
+
+// SendWithResult sends the notification and returns the delivery result. The result of the notifiers which do not
+// implement ResultSender holds only the delivery time.
+func SendWithResult(ctx context.Context, notifier Notifier, notification Notification, recipient string) (*DeliveryResult, error) {
+	sender, ok := notifier.(ResultSender)
+	if !ok {
+		if err := notifier.Send(ctx, notification, recipient); err != nil {
+			return nil, err
+		}
+		return &DeliveryResult{Timestamp: time.Now()}, nil
+	}
+	res, err := sender.SendWithResult(ctx, notification, recipient)
+	if res != nil && res.Timestamp.IsZero() {
+		res.Timestamp = time.Now()
+	}
+	return res, err
+}
+
+// httpResult returns the result of the notification delivered using the HTTP request
+func httpResult(resp *http.Response) *DeliveryResult {
+	res := &DeliveryResult{StatusCode: resp.StatusCode}
+	for _, header := range requestIDHeaders {
+		if id := resp.Header.Get(header); id != "" {
+			res.MessageID = id
+			break
+		}
+	}
+	return res
+}
+
+// slackTimestamp returns the time of the Slack message timestamp, e.g. 1503435956.000247
+func slackTimestamp(ts string) time.Time {
+	parts := strings.SplitN(ts, ".", 2)
+	sec, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	var usec int64
+	if len(parts) == 2 {
+		usec, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+	return time.Unix(sec, usec*int64(time.Microsecond))
+}
@@ -0,0 +1,68 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type plainNotifier struct{}
+
+func (n plainNotifier) Send(_ context.Context, _ Notification, _ string) error {
+	return nil
+}
+
+func TestSendWithResult_NotSupported(t *testing.T) {
+	res, err := SendWithResult(context.Background(), plainNotifier{}, Notification{}, "recipient")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.False(t, res.Timestamp.IsZero())
+		assert.Empty(t, res.MessageID)
+	}
+}
+
+func TestSendWithResult_Webhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	notifier := NewWebhookNotifier(WebhookOptions{{Name: "ok", URL: server.URL}, {Name: "fail", URL: server.URL + "/fail"}})
+
+	res, err := SendWithResult(context.Background(), notifier, Notification{}, "ok")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "req-1", res.MessageID)
+	}
+
+	res, err = SendWithResult(context.Background(), notifier, Notification{}, "fail")
+	assert.Error(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	}
+}
+
+func TestSendWithResult_Slack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "channel": "C123", "ts": "1503435956.000247"})
+	}))
+	defer server.Close()
+	notifier := NewSlackNotifier(SlackOptions{Token: "xoxb-test", ApiUrl: server.URL})
+
+	res, err := SendWithResult(context.Background(), notifier, Notification{Body: "hello"}, "deployments")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, "1503435956.000247", res.MessageID)
+		assert.Equal(t, "C123", res.Channel)
+		assert.Equal(t, time.Unix(1503435956, 247000), res.Timestamp)
+	}
+}
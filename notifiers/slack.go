@@ -2,14 +2,12 @@ package notifiers
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"regexp"
-
-	httputil "github.com/argoproj-labs/argocd-notifications/shared/http"
+	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/slack-go/slack"
@@ -27,28 +25,160 @@ type SlackOptions struct {
 	SigningSecret      string   `json:"signingSecret"`
 	Channels           []string `json:"channels"`
 	InsecureSkipVerify bool     `json:"insecureSkipVerify"`
+	// ApiUrl is the Slack Web API endpoint, e.g. of the fake server in tests; https://slack.com/api/ if empty
+	ApiUrl string `json:"apiUrl,omitempty"`
+	// AppToken is the app-level token used by the bot to open the Socket Mode connection
+	AppToken string `json:"appToken,omitempty"`
+	// SocketMode enables the bot Socket Mode connection that receives commands without the public bot endpoints
+	SocketMode bool `json:"socketMode,omitempty"`
+	// ActionPolicies allow users to run the bot interactive message actions; actions are denied if empty
+	ActionPolicies []SlackActionPolicy `json:"actionPolicies,omitempty"`
+	// Workspaces are the additional Slack workspaces by name, notified using the slack:<workspace>/<channel>
+	// recipients; the username and icon default to the top level settings
+	Workspaces map[string]SlackWorkspace `json:"workspaces,omitempty"`
+}
+
+// SlackWorkspace holds the settings of the additional Slack workspace
+type SlackWorkspace struct {
+	Username           string `json:"username,omitempty"`
+	Icon               string `json:"icon,omitempty"`
+	Token              string `json:"token"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+	ApiUrl             string `json:"apiUrl,omitempty"`
+}
+
+// SlackActionPolicy allows Slack users to run the interactive message actions on the matching applications
+type SlackActionPolicy struct {
+	// Users are the Slack user IDs, e.g. U0123ABCD; * matches any user. User names are not matched since users
+	// might change them.
+	Users []string `json:"users"`
+	// Actions are the allowed actions: sync, refresh, silence, approve or reject; * allows all actions
+	Actions []string `json:"actions"`
+	// Apps are the application name patterns, e.g. team-a-*
+	Apps []string `json:"apps"`
 }
 
 type slackNotifier struct {
+	httpConfig
 	opts SlackOptions
+	lock sync.Mutex
+	// threads holds the timestamps of the thread parent messages by the recipient and the notification group key
+	threads map[string]string
+	// directory caches the user ids of the slack-user and slack-group recipients
+	directory *slackDirectory
+	// workspaces are the notifiers of the named workspaces
+	workspaces map[string]*slackNotifier
 }
 
 var validIconEmoij = regexp.MustCompile(`^:.+:$`)
 
 func NewSlackNotifier(opts SlackOptions) Notifier {
-	return &slackNotifier{opts: opts}
+	return newSlackNotifier(opts)
+}
+
+func newSlackNotifier(opts SlackOptions) *slackNotifier {
+	n := &slackNotifier{opts: opts, threads: map[string]string{}, directory: newSlackDirectory(), workspaces: map[string]*slackNotifier{}}
+	for name, workspace := range opts.Workspaces {
+		n.workspaces[name] = newSlackNotifier(SlackOptions{
+			Username:           coalesce(workspace.Username, opts.Username),
+			Icon:               coalesce(workspace.Icon, opts.Icon),
+			Token:              workspace.Token,
+			InsecureSkipVerify: workspace.InsecureSkipVerify,
+			ApiUrl:             workspace.ApiUrl,
+		})
+	}
+	return n
+}
+
+func (n *slackNotifier) setHTTPOptions(opts HTTPOptions) {
+	n.httpOpts = opts
+	for _, workspace := range n.workspaces {
+		workspace.setHTTPOptions(opts)
+	}
+}
+
+// workspace returns the notifier of the <workspace>/<channel> recipient workspace and the channel; the recipients
+// without the workspace prefix are notified using the top level workspace
+func (n *slackNotifier) workspace(recipient string) (*slackNotifier, string, error) {
+	parts := strings.SplitN(recipient, "/", 2)
+	if len(parts) < 2 {
+		return n, recipient, nil
+	}
+	workspace, ok := n.workspaces[parts[0]]
+	if !ok {
+		return nil, "", fmt.Errorf("slack workspace %s is not configured", parts[0])
+	}
+	return workspace, parts[1], nil
 }
 
-func (n *slackNotifier) Send(notification Notification, recipient string) error {
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: n.opts.InsecureSkipVerify,
-		},
+func (n *slackNotifier) Send(ctx context.Context, notification Notification, recipient string) error {
+	_, err := n.SendWithResult(ctx, notification, recipient)
+	return err
+}
+
+// SendWithResult posts the message and returns the channel id and the message timestamp, which identifies the message
+// in the thread replies and message updates. The thread=true recipient option posts notifications with the same group
+// key as the replies to the first notification of the group; broadcast=true also shows the replies in the channel.
+func (n *slackNotifier) SendWithResult(ctx context.Context, notification Notification, recipient string) (*DeliveryResult, error) {
+	if workspace, channel, err := n.workspace(recipient); err != nil {
+		return nil, err
+	} else if workspace != n {
+		return workspace.SendWithResult(ctx, notification, channel)
+	}
+	s := n.client()
+	msgOptions, err := n.messageOptions(notification)
+	if err != nil {
+		return nil, err
 	}
-	client := &http.Client{
-		Transport: httputil.NewLoggingRoundTripper(transport, log.WithField("notifier", "slack")),
+	channel, err := resolveChannel(ctx, s, recipient)
+	if err != nil {
+		return nil, err
+	}
+	recipientOptions := GetRecipientOptions(ctx)
+	threadKey := ""
+	if groupKey := recipientOptions.Get(OptionGroupKey); groupKey != "" && recipientOptions.Bool("thread", false) {
+		threadKey = recipient + "/" + groupKey
+	}
+	parentTs := n.threadTs(threadKey)
+	if parentTs != "" {
+		msgOptions = append(msgOptions, slack.MsgOptionTS(parentTs))
+		if recipientOptions.Bool("broadcast", false) {
+			msgOptions = append(msgOptions, slack.MsgOptionBroadcast())
+		}
+	}
+	channel, ts, err := s.PostMessageContext(ctx, channel, msgOptions...)
+	if err != nil {
+		return nil, err
 	}
-	s := slack.New(n.opts.Token, slack.OptionHTTPClient(client))
+	if threadKey != "" && parentTs == "" {
+		n.lock.Lock()
+		n.threads[threadKey] = ts
+		n.lock.Unlock()
+	}
+	return &DeliveryResult{MessageID: ts, Channel: channel, Timestamp: slackTimestamp(ts)}, nil
+}
+
+// client returns the Slack Web API client
+func (n *slackNotifier) client() *slack.Client {
+	options := []slack.Option{slack.OptionHTTPClient(n.httpClient("slack", n.opts.InsecureSkipVerify))}
+	if n.opts.ApiUrl != "" {
+		options = append(options, slack.OptionAPIURL(strings.TrimSuffix(n.opts.ApiUrl, "/")+"/"))
+	}
+	return slack.New(n.opts.Token, options...)
+}
+
+// threadTs returns the timestamp of the thread parent message or an empty string if the thread is not started
+func (n *slackNotifier) threadTs(threadKey string) string {
+	if threadKey == "" {
+		return ""
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.threads[threadKey]
+}
+
+// messageOptions returns the chat.postMessage parameters of the notification
+func (n *slackNotifier) messageOptions(notification Notification) ([]slack.MsgOption, error) {
 	msgOptions := []slack.MsgOption{slack.MsgOptionText(notification.Body, false)}
 	if n.opts.Username != "" {
 		msgOptions = append(msgOptions, slack.MsgOptionUsername(n.opts.Username))
@@ -67,21 +197,65 @@ func (n *slackNotifier) Send(notification Notification, recipient string) error
 		attachments := make([]slack.Attachment, 0)
 		if notification.Slack.Attachments != "" {
 			if err := json.Unmarshal([]byte(notification.Slack.Attachments), &attachments); err != nil {
-				return fmt.Errorf("failed to unmarshal attachments '%s' : %v", notification.Slack.Attachments, err)
+				return nil, fmt.Errorf("failed to unmarshal attachments '%s' : %v", notification.Slack.Attachments, err)
 			}
 		}
 
 		blocks := slack.Blocks{}
 		if notification.Slack.Blocks != "" {
 			if err := json.Unmarshal([]byte(notification.Slack.Blocks), &blocks); err != nil {
-				return fmt.Errorf("failed to unmarshal blocks '%s' : %v", notification.Slack.Blocks, err)
+				return nil, fmt.Errorf("failed to unmarshal blocks '%s' : %v", notification.Slack.Blocks, err)
 			}
 		}
 		msgOptions = append(msgOptions, slack.MsgOptionAttachments(attachments...), slack.MsgOptionBlocks(blocks.BlockSet...))
 	}
+	return msgOptions, nil
+}
 
-	_, _, err := s.PostMessageContext(context.TODO(), recipient, msgOptions...)
-	return err
+// Preview returns the chat.postMessage request parameters without sending the message
+func (n *slackNotifier) Preview(notification Notification, recipient string) (string, error) {
+	if workspace, channel, err := n.workspace(recipient); err != nil {
+		return "", err
+	} else if workspace != n {
+		return workspace.Preview(notification, channel)
+	}
+	msgOptions, err := n.messageOptions(notification)
+	if err != nil {
+		return "", err
+	}
+	_, values, err := slack.UnsafeApplyMsgOptions("", recipient, "", msgOptions...)
+	if err != nil {
+		return "", err
+	}
+	params := map[string]interface{}{}
+	for k := range values {
+		if k == "token" {
+			continue
+		}
+		// attachments and blocks are JSON encoded
+		if v := values.Get(k); (strings.HasPrefix(v, "[") || strings.HasPrefix(v, "{")) && json.Valid([]byte(v)) {
+			params[k] = json.RawMessage(v)
+		} else {
+			params[k] = v
+		}
+	}
+	data, err := json.MarshalIndent(params, "", "  ")
+	return string(data), err
+}
+
+// resolveChannel returns the channel of the recipient. The @<user id> recipients are resolved to the user direct
+// message channel.
+func resolveChannel(ctx context.Context, s *slack.Client, recipient string) (string, error) {
+	if !strings.HasPrefix(recipient, "@") {
+		return recipient, nil
+	}
+	channel, _, _, err := s.OpenConversationContext(ctx, &slack.OpenConversationParameters{
+		Users: []string{strings.TrimPrefix(recipient, "@")},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open direct message channel of user %s: %v", strings.TrimPrefix(recipient, "@"), err)
+	}
+	return channel.ID, nil
 }
 
 func isValidIconURL(iconURL string) bool {
@@ -0,0 +1,136 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// SlackUserService is the service of the recipients which are the Slack users identified by the email address,
+	// e.g. slack-user:alice@corp.com
+	SlackUserService = "slack-user"
+	// SlackGroupService is the service of the recipients which are the members of the Slack user group identified by the
+	// group handle, e.g. slack-group:@oncall-payments
+	SlackGroupService = "slack-group"
+
+	slackDirectoryTTL = time.Hour
+)
+
+type slackDirectoryEntry struct {
+	userIDs []string
+	expires time.Time
+}
+
+// slackDirectory caches the user ids of the looked up users and user groups, so every notification does not call the
+// Slack users and usergroups API
+type slackDirectory struct {
+	lock    sync.Mutex
+	entries map[string]slackDirectoryEntry
+	now     func() time.Time
+}
+
+func newSlackDirectory() *slackDirectory {
+	return &slackDirectory{entries: map[string]slackDirectoryEntry{}, now: time.Now}
+}
+
+// get returns the cached user ids or looks them up and caches the result; the failed lookups are not cached
+func (d *slackDirectory) get(key string, lookup func() ([]string, error)) ([]string, error) {
+	d.lock.Lock()
+	entry, ok := d.entries[key]
+	d.lock.Unlock()
+	if ok && d.now().Before(entry.expires) {
+		return entry.userIDs, nil
+	}
+	userIDs, err := lookup()
+	if err != nil {
+		return nil, err
+	}
+	d.lock.Lock()
+	d.entries[key] = slackDirectoryEntry{userIDs: userIDs, expires: d.now().Add(slackDirectoryTTL)}
+	d.lock.Unlock()
+	return userIDs, nil
+}
+
+type slackLookupFunc func(ctx context.Context, s *slack.Client, target string) ([]string, error)
+
+// lookupSlackUser returns the id of the Slack user with the specified email address
+func lookupSlackUser(ctx context.Context, s *slack.Client, email string) ([]string, error) {
+	user, err := s.GetUserByEmailContext(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Slack user by email %s: %v", email, err)
+	}
+	return []string{user.ID}, nil
+}
+
+// lookupSlackGroup returns the ids of the members of the Slack user group with the specified handle
+func lookupSlackGroup(ctx context.Context, s *slack.Client, handle string) ([]string, error) {
+	handle = strings.TrimPrefix(handle, "@")
+	groups, err := s.GetUserGroupsContext(ctx, slack.GetUserGroupsOptionIncludeUsers(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Slack user groups: %v", err)
+	}
+	for _, group := range groups {
+		if group.Handle == handle {
+			return group.Users, nil
+		}
+	}
+	return nil, fmt.Errorf("slack user group @%s is not found", handle)
+}
+
+// slackLookupNotifier sends direct messages to the Slack users resolved from the recipient, e.g. the commit author
+// email or the on-call user group handle
+type slackLookupNotifier struct {
+	slack   *slackNotifier
+	service string
+	lookup  slackLookupFunc
+}
+
+func (n *slackLookupNotifier) Send(ctx context.Context, notification Notification, recipient string) error {
+	_, err := n.SendWithResult(ctx, notification, recipient)
+	return err
+}
+
+// SendWithResult sends the direct message to every resolved user and returns the result of the last delivered message
+func (n *slackLookupNotifier) SendWithResult(ctx context.Context, notification Notification, recipient string) (*DeliveryResult, error) {
+	workspace, target, err := n.slack.workspace(recipient)
+	if err != nil {
+		return nil, err
+	}
+	userIDs, err := workspace.directory.get(n.service+":"+target, func() ([]string, error) {
+		return n.lookup(ctx, workspace.client(), target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		return nil, fmt.Errorf("%s:%s does not match any Slack user", n.service, recipient)
+	}
+	var res *DeliveryResult
+	var failed []string
+	for _, userID := range userIDs {
+		userRes, err := workspace.SendWithResult(ctx, notification, "@"+userID)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", userID, err))
+			continue
+		}
+		res = userRes
+	}
+	if len(failed) > 0 {
+		return res, fmt.Errorf("failed to notify Slack users of %s:%s: %s", n.service, recipient, strings.Join(failed, "; "))
+	}
+	return res, nil
+}
+
+// Preview returns the chat.postMessage request parameters of the direct message without resolving the users
+func (n *slackLookupNotifier) Preview(notification Notification, recipient string) (string, error) {
+	workspace, target, err := n.slack.workspace(recipient)
+	if err != nil {
+		return "", err
+	}
+	return workspace.Preview(notification, target)
+}
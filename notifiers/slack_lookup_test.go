@@ -0,0 +1,94 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeSlackDirectoryServer(lookups map[string]int, messages *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		method := strings.TrimPrefix(r.URL.Path, "/")
+		lookups[method]++
+		w.Header().Set("Content-Type", "application/json")
+		var res map[string]interface{}
+		switch method {
+		case "users.lookupByEmail":
+			if r.Form.Get("email") != "alice@corp.com" {
+				res = map[string]interface{}{"ok": false, "error": "users_not_found"}
+			} else {
+				res = map[string]interface{}{"ok": true, "user": map[string]interface{}{"id": "U1"}}
+			}
+		case "usergroups.list":
+			res = map[string]interface{}{"ok": true, "usergroups": []map[string]interface{}{
+				{"id": "S1", "handle": "oncall-payments", "users": []string{"U1", "U2"}},
+			}}
+		case "conversations.open":
+			res = map[string]interface{}{"ok": true, "channel": map[string]interface{}{"id": "D" + r.Form.Get("users")}}
+		case "chat.postMessage":
+			*messages = append(*messages, r.Form.Get("channel"))
+			res = map[string]interface{}{"ok": true, "channel": r.Form.Get("channel"), "ts": "1503435956.000001"}
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+}
+
+func TestSlackUser_Send(t *testing.T) {
+	lookups := map[string]int{}
+	var messages []string
+	server := newFakeSlackDirectoryServer(lookups, &messages)
+	defer server.Close()
+	notifier := GetAll(Config{Slack: &SlackOptions{Token: "xoxb-test", ApiUrl: server.URL}})[SlackUserService]
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, notifier.Send(context.Background(), Notification{Body: "hello"}, "alice@corp.com"))
+	}
+	assert.Equal(t, []string{"DU1", "DU1"}, messages)
+	// the user id is cached
+	assert.Equal(t, 1, lookups["users.lookupByEmail"])
+
+	err := notifier.Send(context.Background(), Notification{Body: "hello"}, "bob@corp.com")
+	assert.EqualError(t, err, "failed to find Slack user by email bob@corp.com: users_not_found")
+}
+
+func TestSlackGroup_Send(t *testing.T) {
+	lookups := map[string]int{}
+	var messages []string
+	server := newFakeSlackDirectoryServer(lookups, &messages)
+	defer server.Close()
+	notifier := GetAll(Config{Slack: &SlackOptions{Token: "xoxb-test", ApiUrl: server.URL}})[SlackGroupService]
+
+	res, err := SendWithResult(context.Background(), notifier, Notification{Body: "hello"}, "@oncall-payments")
+	assert.NoError(t, err)
+	assert.Equal(t, "1503435956.000001", res.MessageID)
+	assert.Equal(t, []string{"DU1", "DU2"}, messages)
+
+	err = notifier.Send(context.Background(), Notification{Body: "hello"}, "@unknown")
+	assert.EqualError(t, err, "slack user group @unknown is not found")
+}
+
+func TestSlackDirectory_Expires(t *testing.T) {
+	directory := newSlackDirectory()
+	now := directory.now()
+	directory.now = func() time.Time { return now }
+	calls := 0
+	lookup := func() ([]string, error) {
+		calls++
+		return []string{"U1"}, nil
+	}
+
+	_, _ = directory.get("slack-user:alice@corp.com", lookup)
+	_, _ = directory.get("slack-user:alice@corp.com", lookup)
+	assert.Equal(t, 1, calls)
+
+	directory.now = func() time.Time { return now.Add(slackDirectoryTTL + time.Second) }
+	_, _ = directory.get("slack-user:alice@corp.com", lookup)
+	assert.Equal(t, 2, calls)
+}
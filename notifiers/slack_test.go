@@ -1,8 +1,14 @@
 package notifiers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/slack-go/slack"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,3 +25,102 @@ func TestValidIconURL(t *testing.T) {
 	assert.Equal(t, false, isValidIconURL("ftp://favicon.ico"))
 	assert.Equal(t, false, isValidIconURL("ftp://lorempixel.com/favicon.ico"))
 }
+
+func TestResolveChannel(t *testing.T) {
+	var users string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		users = r.Form.Get("users")
+		_, _ = w.Write([]byte(`{"ok": true, "channel": {"id": "D123"}}`))
+	}))
+	defer server.Close()
+	client := slack.New("token", slack.OptionAPIURL(server.URL+"/"))
+
+	channel, err := resolveChannel(context.TODO(), client, "general")
+	assert.NoError(t, err)
+	assert.Equal(t, "general", channel)
+
+	channel, err = resolveChannel(context.TODO(), client, "@U123")
+	assert.NoError(t, err)
+	assert.Equal(t, "D123", channel)
+	assert.Equal(t, "U123", users)
+}
+
+func TestSlack_Preview(t *testing.T) {
+	notifier := NewSlackNotifier(SlackOptions{Token: "secret", Username: "argocd"}).(Previewer)
+
+	preview, err := notifier.Preview(Notification{
+		Body:  "hello",
+		Slack: &SlackNotification{Blocks: `[{"type": "divider"}]`},
+	}, "my-channel")
+
+	assert.NoError(t, err)
+	assert.NotContains(t, preview, "secret")
+	assert.JSONEq(t, `{
+  "attachments": [],
+  "blocks": [{"type": "divider"}],
+  "channel": "my-channel",
+  "text": "hello",
+  "username": "argocd"
+}`, preview)
+}
+
+func TestSlack_SendThread(t *testing.T) {
+	var requests []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		requests = append(requests, map[string]string{
+			"thread_ts":       r.PostForm.Get("thread_ts"),
+			"reply_broadcast": r.PostForm.Get("reply_broadcast"),
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "channel": "C123", "ts": fmt.Sprintf("1503435956.00000%d", len(requests))})
+	}))
+	defer server.Close()
+	notifier := NewSlackNotifier(SlackOptions{Token: "xoxb-test", ApiUrl: server.URL})
+
+	ctx := WithRecipientOptions(context.Background(), RecipientOptions{"thread": "true", "broadcast": "true", OptionGroupKey: "argocd/guestbook"})
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, notifier.Send(ctx, Notification{Body: "hello"}, "deployments"))
+	}
+	otherApp := WithRecipientOptions(context.Background(), RecipientOptions{"thread": "true", OptionGroupKey: "argocd/other"})
+	assert.NoError(t, notifier.Send(otherApp, Notification{Body: "hello"}, "deployments"))
+
+	assert.Equal(t, []map[string]string{
+		{"thread_ts": "", "reply_broadcast": ""},
+		{"thread_ts": "1503435956.000001", "reply_broadcast": "true"},
+		{"thread_ts": "", "reply_broadcast": ""},
+	}, requests)
+}
+
+func TestSlack_SendWorkspace(t *testing.T) {
+	var requests []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		requests = append(requests, map[string]string{
+			"token":    r.PostForm.Get("token"),
+			"channel":  r.PostForm.Get("channel"),
+			"username": r.PostForm.Get("username"),
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "channel": "C123", "ts": "1503435956.000001"})
+	}))
+	defer server.Close()
+	notifier := NewSlackNotifier(SlackOptions{
+		Token:    "xoxb-prod",
+		Username: "argocd",
+		ApiUrl:   server.URL,
+		Workspaces: map[string]SlackWorkspace{
+			"dev": {Token: "xoxb-dev", ApiUrl: server.URL},
+		},
+	})
+
+	assert.NoError(t, notifier.Send(context.Background(), Notification{Body: "hello"}, "deployments"))
+	assert.NoError(t, notifier.Send(context.Background(), Notification{Body: "hello"}, "dev/deployments"))
+	assert.EqualError(t, notifier.Send(context.Background(), Notification{Body: "hello"}, "qa/deployments"), "slack workspace qa is not configured")
+
+	assert.Equal(t, []map[string]string{
+		{"token": "xoxb-prod", "channel": "deployments", "username": "argocd"},
+		{"token": "xoxb-dev", "channel": "deployments", "username": "argocd"},
+	}, requests)
+}
@@ -0,0 +1,92 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+type TeamsOptions struct {
+	// RecipientURLs maps channel names to the incoming webhook URLs of the channels
+	RecipientURLs map[string]string `json:"recipientUrls"`
+	// AppID and AppPassword are the Bot Framework application credentials used by the bot
+	AppID       string `json:"appId"`
+	AppPassword string `json:"appPassword"`
+}
+
+type teamsMessageCard struct {
+	Type    string `json:"@type"`
+	Context string `json:"@context"`
+	Title   string `json:"title,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Text    string `json:"text"`
+}
+
+func NewTeamsNotifier(opts TeamsOptions) Notifier {
+	return &teamsNotifier{opts: opts}
+}
+
+type teamsNotifier struct {
+	httpConfig
+	opts TeamsOptions
+}
+
+func newTeamsMessageCard(notification Notification) teamsMessageCard {
+	return teamsMessageCard{
+		Type:    "MessageCard",
+		Context: "https://schema.org/extensions",
+		Title:   notification.Title,
+		Summary: notification.Title,
+		Text:    notification.Body,
+	}
+}
+
+// Preview returns the message card JSON without posting it to the channel webhook
+func (n *teamsNotifier) Preview(notification Notification, recipient string) (string, error) {
+	if _, ok := n.opts.RecipientURLs[recipient]; !ok {
+		return "", fmt.Errorf("teams channel '%s' is not configured", recipient)
+	}
+	data, err := json.MarshalIndent(newTeamsMessageCard(notification), "", "  ")
+	return string(data), err
+}
+
+func (n *teamsNotifier) Send(ctx context.Context, notification Notification, recipient string) error {
+	_, err := n.SendWithResult(ctx, notification, recipient)
+	return err
+}
+
+// SendWithResult posts the message card and returns the response status
+func (n *teamsNotifier) SendWithResult(ctx context.Context, notification Notification, recipient string) (*DeliveryResult, error) {
+	webhookURL, ok := n.opts.RecipientURLs[recipient]
+	if !ok {
+		return nil, fmt.Errorf("teams channel '%s' is not configured", recipient)
+	}
+	data, err := json.Marshal(newTeamsMessageCard(notification))
+	if err != nil {
+		return nil, err
+	}
+	client := n.httpClient("teams", false)
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			data = []byte(fmt.Sprintf("unable to read response data: %v", err))
+		}
+		return httpResult(resp), fmt.Errorf("request to teams channel '%s' has failed with error code %d : %s", recipient, resp.StatusCode, string(data))
+	}
+	return httpResult(resp), nil
+}
@@ -0,0 +1,37 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeams_Send(t *testing.T) {
+	var card map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		data, err := ioutil.ReadAll(request.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(data, &card))
+	}))
+	defer server.Close()
+
+	notifier := NewTeamsNotifier(TeamsOptions{RecipientURLs: map[string]string{"deployments": server.URL}})
+	err := notifier.Send(context.Background(), Notification{Title: "guestbook synced", Body: "revision abc"}, "deployments")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "MessageCard", card["@type"])
+	assert.Equal(t, "guestbook synced", card["title"])
+	assert.Equal(t, "revision abc", card["text"])
+}
+
+func TestTeams_UnknownChannel(t *testing.T) {
+	notifier := NewTeamsNotifier(TeamsOptions{})
+	assert.EqualError(t, notifier.Send(context.Background(), Notification{}, "deployments"), "teams channel 'deployments' is not configured")
+}
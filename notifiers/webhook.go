@@ -2,15 +2,12 @@ package notifiers
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	nethttputil "net/http/httputil"
 	"strings"
-
-	httputil "github.com/argoproj-labs/argocd-notifications/shared/http"
-	log "github.com/sirupsen/logrus"
-
-	"github.com/argoproj-labs/argocd-notifications/shared/text"
 )
 
 type WebhookNotification struct {
@@ -44,6 +41,7 @@ func NewWebhookNotifier(opts WebhookOptions) Notifier {
 }
 
 type webhookNotifier struct {
+	httpConfig
 	opts WebhookOptions
 }
 
@@ -56,17 +54,18 @@ func findWebhookSettingsByName(settings []WebhookSettings, name string) (*Webhoo
 	return nil, fmt.Errorf("webhook with name '%s' is not configured", name)
 }
 
-func (w webhookNotifier) Send(notification Notification, recipient string) error {
+// newRequest returns the webhook request of the notification
+func (w webhookNotifier) newRequest(notification Notification, recipient string) (*http.Request, *WebhookSettings, error) {
 	webhookSettings, err := findWebhookSettingsByName(w.opts, recipient)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	body := notification.Body
 	method := http.MethodGet
 	urlPath := ""
 	if webhookNotification, ok := notification.Webhook[webhookSettings.Name]; ok {
 		body = webhookNotification.Body
-		method = text.Coalesce(webhookNotification.Method, method)
+		method = coalesce(webhookNotification.Method, method)
 		if webhookNotification.Path != "" {
 			urlPath = webhookNotification.Path
 		}
@@ -77,7 +76,7 @@ func (w webhookNotifier) Send(notification Notification, recipient string) error
 	}
 	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	for _, h := range webhookSettings.Headers {
 		req.Header.Set(h.Name, h.Value)
@@ -85,21 +84,48 @@ func (w webhookNotifier) Send(notification Notification, recipient string) error
 	if webhookSettings.BasicAuth != nil {
 		req.SetBasicAuth(webhookSettings.BasicAuth.Username, webhookSettings.BasicAuth.Password)
 	}
+	return req, webhookSettings, nil
+}
+
+// Preview returns the webhook HTTP request without sending it; the Authorization header value is redacted
+func (w webhookNotifier) Preview(notification Notification, recipient string) (string, error) {
+	req, _, err := w.newRequest(notification, recipient)
+	if err != nil {
+		return "", err
+	}
+	if req.Header.Get("Authorization") != "" {
+		req.Header.Set("Authorization", "******")
+	}
+	data, err := nethttputil.DumpRequest(req, true)
+	return string(data), err
+}
+
+func (w webhookNotifier) Send(ctx context.Context, notification Notification, recipient string) error {
+	_, err := w.SendWithResult(ctx, notification, recipient)
+	return err
+}
 
-	client := http.Client{
-		Transport: httputil.NewLoggingRoundTripper(
-			http.DefaultTransport, log.WithField("notifier", fmt.Sprintf("webhook:%s", webhookSettings.Name))),
+// SendWithResult sends the request and returns the response status and the request id response header
+func (w webhookNotifier) SendWithResult(ctx context.Context, notification Notification, recipient string) (*DeliveryResult, error) {
+	req, webhookSettings, err := w.newRequest(notification, recipient)
+	if err != nil {
+		return nil, err
 	}
-	resp, err := client.Do(req)
+
+	client := w.httpClient(fmt.Sprintf("webhook:%s", webhookSettings.Name), false)
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
 		data, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
 			data = []byte(fmt.Sprintf("unable to read response data: %v", err))
 		}
-		return fmt.Errorf("request to %s has failed with error code %d : %s", url, resp.StatusCode, string(data))
+		return httpResult(resp), fmt.Errorf("request to %s has failed with error code %d : %s", req.URL, resp.StatusCode, string(data))
 	}
-	return nil
+	return httpResult(resp), nil
 }
@@ -1,6 +1,7 @@
 package notifiers
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -27,7 +28,7 @@ func TestWebhook_SuccessfullySendsNotification(t *testing.T) {
 		URL:       server.URL,
 		Headers:   []Header{{Name: "testHeader", Value: "testHeaderValue"}},
 	}})
-	err := notifier.Send(
+	err := notifier.Send(context.Background(),
 		Notification{
 			Webhook: map[string]WebhookNotification{
 				"test": {Body: "hello world", Method: http.MethodPost},
@@ -42,7 +43,7 @@ func TestWebhook_SuccessfullySendsNotification(t *testing.T) {
 
 func TestWebhook_FailedToSendNotConfigured(t *testing.T) {
 	notifier := NewWebhookNotifier(WebhookOptions{})
-	err := notifier.Send(Notification{}, "test")
+	err := notifier.Send(context.Background(), Notification{}, "test")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not configured")
 }
@@ -59,7 +60,7 @@ func TestWebhook_SubPath(t *testing.T) {
 		URL:  fmt.Sprintf("%s/subpath1", server.URL),
 	}})
 
-	err := notifier.Send(Notification{
+	err := notifier.Send(context.Background(), Notification{
 		Webhook: map[string]WebhookNotification{
 			"test": {Body: "hello world", Method: http.MethodPost},
 		},
@@ -67,7 +68,7 @@ func TestWebhook_SubPath(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "/subpath1", receivedPath)
 
-	err = notifier.Send(Notification{
+	err = notifier.Send(context.Background(), Notification{
 		Webhook: map[string]WebhookNotification{
 			"test": {Body: "hello world", Method: http.MethodPost, Path: "/subpath2"},
 		},
@@ -75,3 +76,20 @@ func TestWebhook_SubPath(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "/subpath1/subpath2", receivedPath)
 }
+
+func TestWebhook_Preview(t *testing.T) {
+	notifier := NewWebhookNotifier(WebhookOptions{{
+		Name:      "github",
+		URL:       "https://api.github.com",
+		BasicAuth: &BasicAuth{Username: "user", Password: "secret"},
+	}}).(Previewer)
+
+	preview, err := notifier.Preview(Notification{
+		Webhook: map[string]WebhookNotification{"github": {Method: http.MethodPost, Path: "/statuses", Body: "hello"}},
+	}, "github")
+
+	assert.NoError(t, err)
+	assert.Contains(t, preview, "POST /statuses HTTP/1.1\r\nHost: api.github.com\r\n")
+	assert.Contains(t, preview, "Authorization: ******\r\n")
+	assert.Contains(t, preview, "\r\n\r\nhello")
+}
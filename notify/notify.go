@@ -0,0 +1,107 @@
+// Package notify allows embedding argocd-notifications into other controllers. It sends notifications defined in the
+// configuration built by the settings.ConfigBuilder, so no config maps are required:
+//
+//	cfg, err := settings.NewConfigBuilder().
+//		Trigger("on-sync-succeeded", "app.status.operationState.phase in ['Succeeded']", "app-sync-succeeded").
+//		Template("app-sync-succeeded", notifiers.Notification{Title: "{{.app.metadata.name}} has been synced"}).
+//		Subscription(settings.Subscription{Recipients: []string{"slack:deployments"}}).
+//		Build()
+//	...
+//	svc, err := notify.NewService(cfg, notifiers.Config{Slack: &notifiers.SlackOptions{Token: token}}, nil)
+//	...
+//	err = svc.Notify(app, "on-sync-succeeded")
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+const notificationType = "notificationType"
+
+// Service sends notifications about applications
+type Service interface {
+	// Triggered returns true if the trigger condition is true for the application
+	Triggered(app *unstructured.Unstructured, trigger string) (bool, error)
+	// Notify sends the trigger notification to the application recipients. The trigger condition is not evaluated.
+	Notify(app *unstructured.Unstructured, trigger string) error
+}
+
+// NewService returns service that uses the provided configuration. The Argo CD service is used by the template
+// functions that access the Git repository metadata and might be nil.
+func NewService(cfg *settings.Config, notifiersCfg notifiers.Config, argocdService argocd.Service) (Service, error) {
+	t, err := triggers.GetTriggers(cfg.Templates, cfg.Triggers, argocdService)
+	if err != nil {
+		return nil, err
+	}
+	return &service{cfg: cfg, triggers: t, notifiers: notifiers.GetAll(notifiersCfg)}, nil
+}
+
+type service struct {
+	cfg       *settings.Config
+	triggers  map[string]triggers.Trigger
+	notifiers map[string]notifiers.Notifier
+}
+
+func (s *service) getTrigger(name string) (triggers.Trigger, error) {
+	t, ok := s.triggers[name]
+	if !ok {
+		return nil, fmt.Errorf("trigger %s is not configured", name)
+	}
+	return t, nil
+}
+
+func (s *service) Triggered(app *unstructured.Unstructured, trigger string) (bool, error) {
+	t, err := s.getTrigger(trigger)
+	if err != nil {
+		return false, err
+	}
+	return t.Triggered(app)
+}
+
+func (s *service) Notify(app *unstructured.Unstructured, trigger string) error {
+	t, err := s.getTrigger(trigger)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for chain := range settings.ResolveRecipients(app, nil, trigger, s.cfg.Subscriptions, s.cfg.Policies) {
+		// the recipients of the failover chain are notified in order until the notification is delivered
+		var chainErrs []error
+		for _, recipient := range recipients.SplitFailoverChain(chain) {
+			parsed, err := recipients.Parse(recipient)
+			if err != nil {
+				chainErrs = append(chainErrs, err)
+				continue
+			}
+			notifier, ok := s.notifiers[parsed.Service]
+			if !ok {
+				chainErrs = append(chainErrs, fmt.Errorf("%s is not valid recipient type", parsed.Service))
+				continue
+			}
+			ctx := recipients.CopyStringMap(s.cfg.Context)
+			ctx[notificationType] = parsed.Service
+			notification, err := t.FormatNotification(app, ctx)
+			if err != nil {
+				return err
+			}
+			if err := notifier.Send(context.TODO(), *notification, parsed.Target); err != nil {
+				chainErrs = append(chainErrs, fmt.Errorf("failed to notify %s: %v", recipient, err))
+				continue
+			}
+			chainErrs = nil
+			break
+		}
+		errs = append(errs, chainErrs...)
+	}
+	return errors.NewAggregate(errs)
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/notifiers/mocks"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newService(t *testing.T) (*service, *mocks.MockNotifier, func()) {
+	ctrl := gomock.NewController(t)
+	cfg, err := settings.NewConfigBuilder().
+		Trigger("on-synced", "app.metadata.name == 'synced'", "app-synced").
+		Template("app-synced", notifiers.Notification{Title: "{{.app.metadata.name}} {{.context.notificationType}}"}).
+		Subscription(settings.Subscription{Recipients: []string{"mock:deployments"}}).
+		Build()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	svc, err := NewService(cfg, notifiers.Config{}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	notifier := mocks.NewMockNotifier(ctrl)
+	s := svc.(*service)
+	s.notifiers = map[string]notifiers.Notifier{"mock": notifier}
+	return s, notifier, ctrl.Finish
+}
+
+func TestNotify(t *testing.T) {
+	svc, notifier, finish := newService(t)
+	defer finish()
+	app := NewApp("synced", WithAnnotations(map[string]string{recipients.RecipientsAnnotation: "mock:team"}))
+
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "synced mock", Webhook: map[string]notifiers.WebhookNotification{}}, "deployments").Return(nil)
+	notifier.EXPECT().Send(gomock.Any(), notifiers.Notification{Title: "synced mock", Webhook: map[string]notifiers.WebhookNotification{}}, "team").Return(nil)
+
+	assert.NoError(t, svc.Notify(app, "on-synced"))
+}
+
+func TestNotify_UnknownTrigger(t *testing.T) {
+	svc, _, finish := newService(t)
+	defer finish()
+
+	assert.EqualError(t, svc.Notify(NewApp("synced"), "on-unknown"), "trigger on-unknown is not configured")
+}
+
+func TestTriggered(t *testing.T) {
+	svc, _, finish := newService(t)
+	defer finish()
+
+	triggered, err := svc.Triggered(NewApp("synced"), "on-synced")
+	assert.NoError(t, err)
+	assert.True(t, triggered)
+
+	triggered, err = svc.Triggered(NewApp("other"), "on-synced")
+	assert.NoError(t, err)
+	assert.False(t, triggered)
+}
@@ -0,0 +1,137 @@
+package approvals
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	namePrefix = "approval-"
+
+	StatusApproved = "Approved"
+	StatusRejected = "Rejected"
+)
+
+var (
+	// NotificationApprovalResource is the NotificationApproval custom resource
+	NotificationApprovalResource = schema.GroupVersionResource{Group: "argocd-notifications.argoproj.io", Version: "v1alpha1", Resource: "notificationapprovals"}
+)
+
+// Approval is the decision of the user who approved or rejected the application revision
+type Approval struct {
+	// Name is the name of the NotificationApproval resource
+	Name string `json:"-"`
+	// App is the application name
+	App string `json:"app"`
+	// Revision is the approved revision; empty if the decision is not bound to a revision
+	Revision string `json:"revision,omitempty"`
+	// Status is one of: Approved|Rejected
+	Status string `json:"status"`
+	// DecidedBy is the user who made the decision, e.g. slack:<user id>
+	DecidedBy string    `json:"decidedBy,omitempty"`
+	DecidedAt time.Time `json:"decidedAt"`
+	Comment   string    `json:"comment,omitempty"`
+}
+
+// Store persists approvals
+type Store interface {
+	// Record saves the decision and returns an error if the decision of the application revision is already recorded
+	Record(approval Approval) (Approval, error)
+	// Get returns the decision of the application revision or nil if the decision is not recorded
+	Get(app string, revision string) (*Approval, error)
+	// List returns all recorded decisions
+	List() ([]Approval, error)
+}
+
+// NewCRDStore returns store that keeps approvals as NotificationApproval custom resources in the specified namespace
+func NewCRDStore(client dynamic.Interface, namespace string) Store {
+	return &crdStore{client: client.Resource(NotificationApprovalResource).Namespace(namespace)}
+}
+
+type crdStore struct {
+	client dynamic.ResourceInterface
+}
+
+// nameOf returns the resource name of the application revision decision, so only one decision is recorded
+func nameOf(app string, revision string) string {
+	hash := sha256.Sum256([]byte(app + "/" + revision))
+	return namePrefix + hex.EncodeToString(hash[:8])
+}
+
+func (s *crdStore) Record(approval Approval) (Approval, error) {
+	data, err := json.Marshal(approval)
+	if err != nil {
+		return approval, err
+	}
+	spec := map[string]interface{}{}
+	if err = json.Unmarshal(data, &spec); err != nil {
+		return approval, err
+	}
+	approval.Name = nameOf(approval.App, approval.Revision)
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion(NotificationApprovalResource.GroupVersion().String())
+	obj.SetKind("NotificationApproval")
+	obj.SetName(approval.Name)
+	_, err = s.client.Create(obj, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		existing, getErr := s.Get(approval.App, approval.Revision)
+		if getErr != nil || existing == nil {
+			return approval, err
+		}
+		return approval, fmt.Errorf("application %s is already %s by %s", approval.App, existing.Status, existing.DecidedBy)
+	}
+	return approval, err
+}
+
+func (s *crdStore) Get(app string, revision string) (*Approval, error) {
+	obj, err := s.client.Get(nameOf(app, revision), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fromUnstructured(obj)
+}
+
+func (s *crdStore) List() ([]Approval, error) {
+	list, err := s.client.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Approval, 0)
+	for i := range list.Items {
+		approval, err := fromUnstructured(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *approval)
+	}
+	return res, nil
+}
+
+func fromUnstructured(obj *unstructured.Unstructured) (*Approval, error) {
+	spec, ok := obj.Object["spec"]
+	if !ok {
+		return nil, fmt.Errorf("notification approval %s has no spec", obj.GetName())
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	var approval Approval
+	if err = json.Unmarshal(data, &approval); err != nil {
+		return nil, fmt.Errorf("failed to parse notification approval %s: %v", obj.GetName(), err)
+	}
+	approval.Name = obj.GetName()
+	return &approval, nil
+}
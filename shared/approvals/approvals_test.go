@@ -0,0 +1,44 @@
+package approvals
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestCRDStore(t *testing.T) {
+	store := NewCRDStore(fake.NewSimpleDynamicClient(runtime.NewScheme()), "default")
+	approval, err := store.Record(Approval{
+		App:       "guestbook",
+		Revision:  "abc",
+		Status:    StatusApproved,
+		DecidedBy: "slack:U123",
+		DecidedAt: time.Now().UTC().Truncate(time.Second),
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, approval.Name)
+
+	found, err := store.Get("guestbook", "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, &approval, found)
+
+	found, err = store.Get("guestbook", "def")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	items, err := store.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []Approval{approval}, items)
+}
+
+func TestCRDStore_AlreadyDecided(t *testing.T) {
+	store := NewCRDStore(fake.NewSimpleDynamicClient(runtime.NewScheme()), "default")
+	_, err := store.Record(Approval{App: "guestbook", Revision: "abc", Status: StatusRejected, DecidedBy: "slack:U123"})
+	assert.NoError(t, err)
+
+	_, err = store.Record(Approval{App: "guestbook", Revision: "abc", Status: StatusApproved, DecidedBy: "slack:U456"})
+	assert.EqualError(t, err, "application guestbook is already Rejected by slack:U123")
+}
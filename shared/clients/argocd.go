@@ -0,0 +1,309 @@
+package clients
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	applicationpkg "github.com/argoproj/argo-cd/pkg/apiclient/application"
+	projectpkg "github.com/argoproj/argo-cd/pkg/apiclient/project"
+	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// projectsRelistInterval is the interval of the projects reload since the Argo CD API does not stream project changes
+	projectsRelistInterval = 5 * time.Minute
+)
+
+var (
+	errNotSupported = errors.New("operation is not supported by the Argo CD API client")
+)
+
+// ArgoCDServerOptions configures connection to the Argo CD API server
+type ArgoCDServerOptions struct {
+	// Address of the Argo CD API server in <host>:<port> format
+	Address string
+	// AuthToken is the Argo CD account or project token
+	AuthToken string
+	// PlainText disables TLS
+	PlainText bool
+	// Insecure skips the server certificate verification
+	Insecure bool
+}
+
+type tokenCredentials struct {
+	token     string
+	plainText bool
+}
+
+func (c tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return !c.plainText
+}
+
+// DialArgoCDServer returns gRPC connection to the Argo CD API server
+func DialArgoCDServer(opts ArgoCDServerOptions) (*grpc.ClientConn, error) {
+	if opts.Address == "" {
+		return nil, errors.New("Argo CD server address is not specified")
+	}
+	dialOpts := []grpc.DialOption{grpc.WithPerRPCCredentials(tokenCredentials{token: opts.AuthToken, plainText: opts.PlainText})}
+	if opts.PlainText {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	} else {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: opts.Insecure})))
+	}
+	return grpc.Dial(opts.Address, dialOpts...)
+}
+
+// NewArgoCDAPIClient returns dynamic client that reads applications and projects using the Argo CD API server
+// instead of the Kubernetes API, so the controller does not need Kubernetes RBAC permissions to access applications.
+// Applications are watched using the Argo CD application event stream and updated using the merge patches, projects are
+// periodically reloaded. Other operations and resources are not supported.
+func NewArgoCDAPIClient(conn *grpc.ClientConn) dynamic.Interface {
+	return &argocdAPIClient{
+		apps:     applicationpkg.NewApplicationServiceClient(conn),
+		projects: projectpkg.NewProjectServiceClient(conn),
+	}
+}
+
+type argocdAPIClient struct {
+	apps     applicationpkg.ApplicationServiceClient
+	projects projectpkg.ProjectServiceClient
+}
+
+func (c *argocdAPIClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	switch resource.Resource {
+	case "applications":
+		return &argocdAppsClient{client: c.apps}
+	case "appprojects":
+		return &argocdProjectsClient{client: c.projects}
+	}
+	return &unsupportedResourceClient{}
+}
+
+func toUnstructured(obj runtime.Object, kind string) (*unstructured.Unstructured, error) {
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	res := &unstructured.Unstructured{Object: data}
+	res.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind(kind))
+	return res, nil
+}
+
+// unsupportedResourceClient implements methods which are not supported by the Argo CD API client
+type unsupportedResourceClient struct{}
+
+func (c *unsupportedResourceClient) Namespace(string) dynamic.ResourceInterface {
+	return c
+}
+
+func (c *unsupportedResourceClient) Create(*unstructured.Unstructured, metav1.CreateOptions, ...string) (*unstructured.Unstructured, error) {
+	return nil, errNotSupported
+}
+
+func (c *unsupportedResourceClient) Update(*unstructured.Unstructured, metav1.UpdateOptions, ...string) (*unstructured.Unstructured, error) {
+	return nil, errNotSupported
+}
+
+func (c *unsupportedResourceClient) UpdateStatus(*unstructured.Unstructured, metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	return nil, errNotSupported
+}
+
+func (c *unsupportedResourceClient) Delete(string, *metav1.DeleteOptions, ...string) error {
+	return errNotSupported
+}
+
+func (c *unsupportedResourceClient) DeleteCollection(*metav1.DeleteOptions, metav1.ListOptions) error {
+	return errNotSupported
+}
+
+func (c *unsupportedResourceClient) Get(string, metav1.GetOptions, ...string) (*unstructured.Unstructured, error) {
+	return nil, errNotSupported
+}
+
+func (c *unsupportedResourceClient) List(metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return nil, errNotSupported
+}
+
+func (c *unsupportedResourceClient) Watch(metav1.ListOptions) (watch.Interface, error) {
+	return nil, errNotSupported
+}
+
+func (c *unsupportedResourceClient) Patch(string, types.PatchType, []byte, metav1.PatchOptions, ...string) (*unstructured.Unstructured, error) {
+	return nil, errNotSupported
+}
+
+// argocdAppsClient ignores the namespace since the Argo CD API server manages applications of its own namespace
+type argocdAppsClient struct {
+	unsupportedResourceClient
+	client applicationpkg.ApplicationServiceClient
+}
+
+func (c *argocdAppsClient) Namespace(string) dynamic.ResourceInterface {
+	return c
+}
+
+func (c *argocdAppsClient) Get(name string, _ metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(subresources) > 0 {
+		return nil, errNotSupported
+	}
+	app, err := c.client.Get(context.Background(), &applicationpkg.ApplicationQuery{Name: &name})
+	if err != nil {
+		return nil, err
+	}
+	return toUnstructured(app, "Application")
+}
+
+func (c *argocdAppsClient) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	apps, err := c.client.List(context.Background(), &applicationpkg.ApplicationQuery{Selector: opts.LabelSelector})
+	if err != nil {
+		return nil, err
+	}
+	res := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	res.SetResourceVersion(apps.ResourceVersion)
+	for i := range apps.Items {
+		item, err := toUnstructured(&apps.Items[i], "Application")
+		if err != nil {
+			return nil, err
+		}
+		res.Items = append(res.Items, *item)
+	}
+	return res, nil
+}
+
+func (c *argocdAppsClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if opts.TimeoutSeconds != nil {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(*opts.TimeoutSeconds)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	stream, err := c.client.Watch(ctx, &applicationpkg.ApplicationQuery{Selector: opts.LabelSelector, ResourceVersion: opts.ResourceVersion})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	w := &streamWatcher{result: make(chan watch.Event), cancel: cancel}
+	go func() {
+		defer close(w.result)
+		defer cancel()
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				// the reflector re-lists applications after the watch is closed
+				return
+			}
+			obj, err := toUnstructured(&event.Application, "Application")
+			if err != nil {
+				w.send(ctx, watch.Event{Type: watch.Error, Object: &metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}})
+				return
+			}
+			if !w.send(ctx, watch.Event{Type: event.Type, Object: obj}) {
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+func (c *argocdAppsClient) Patch(name string, pt types.PatchType, data []byte, _ metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if pt != types.MergePatchType || len(subresources) > 0 {
+		return nil, fmt.Errorf("patch type %s is not supported by the Argo CD API client", pt)
+	}
+	app, err := c.client.Patch(context.Background(), &applicationpkg.ApplicationPatchRequest{Name: &name, Patch: string(data), PatchType: "merge"})
+	if err != nil {
+		return nil, err
+	}
+	return toUnstructured(app, "Application")
+}
+
+// streamWatcher converts the Argo CD application event stream into the Kubernetes watch
+type streamWatcher struct {
+	result chan watch.Event
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func (w *streamWatcher) send(ctx context.Context, event watch.Event) bool {
+	select {
+	case w.result <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (w *streamWatcher) Stop() {
+	w.once.Do(w.cancel)
+}
+
+func (w *streamWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// argocdProjectsClient ignores the namespace since the Argo CD API server manages projects of its own namespace
+type argocdProjectsClient struct {
+	unsupportedResourceClient
+	client projectpkg.ProjectServiceClient
+}
+
+func (c *argocdProjectsClient) Namespace(string) dynamic.ResourceInterface {
+	return c
+}
+
+func (c *argocdProjectsClient) Get(name string, _ metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(subresources) > 0 {
+		return nil, errNotSupported
+	}
+	proj, err := c.client.Get(context.Background(), &projectpkg.ProjectQuery{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	return toUnstructured(proj, "AppProject")
+}
+
+func (c *argocdProjectsClient) List(metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	projects, err := c.client.List(context.Background(), &projectpkg.ProjectQuery{})
+	if err != nil {
+		return nil, err
+	}
+	res := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	res.SetResourceVersion(projects.ResourceVersion)
+	for i := range projects.Items {
+		item, err := toUnstructured(&projects.Items[i], "AppProject")
+		if err != nil {
+			return nil, err
+		}
+		res.Items = append(res.Items, *item)
+	}
+	return res, nil
+}
+
+// Watch returns watch that does not produce events and is closed after the relist interval, so the reflector
+// periodically reloads projects
+func (c *argocdProjectsClient) Watch(metav1.ListOptions) (watch.Interface, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), projectsRelistInterval)
+	w := &streamWatcher{result: make(chan watch.Event), cancel: cancel}
+	go func() {
+		<-ctx.Done()
+		close(w.result)
+	}()
+	return w, nil
+}
@@ -0,0 +1,107 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	applicationpkg "github.com/argoproj/argo-cd/pkg/apiclient/application"
+	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+type fakeAppsClient struct {
+	applicationpkg.ApplicationServiceClient
+	apps   []v1alpha1.Application
+	events []v1alpha1.ApplicationWatchEvent
+	patch  *applicationpkg.ApplicationPatchRequest
+}
+
+func (c *fakeAppsClient) List(_ context.Context, q *applicationpkg.ApplicationQuery, _ ...grpc.CallOption) (*v1alpha1.ApplicationList, error) {
+	return &v1alpha1.ApplicationList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}, Items: c.apps}, nil
+}
+
+func (c *fakeAppsClient) Patch(_ context.Context, req *applicationpkg.ApplicationPatchRequest, _ ...grpc.CallOption) (*v1alpha1.Application, error) {
+	c.patch = req
+	return &c.apps[0], nil
+}
+
+func (c *fakeAppsClient) Watch(_ context.Context, _ *applicationpkg.ApplicationQuery, _ ...grpc.CallOption) (applicationpkg.ApplicationService_WatchClient, error) {
+	return &fakeWatchClient{events: c.events}, nil
+}
+
+type fakeWatchClient struct {
+	grpc.ClientStream
+	events []v1alpha1.ApplicationWatchEvent
+}
+
+func (c *fakeWatchClient) Recv() (*v1alpha1.ApplicationWatchEvent, error) {
+	if len(c.events) == 0 {
+		return nil, io.EOF
+	}
+	event := c.events[0]
+	c.events = c.events[1:]
+	return &event, nil
+}
+
+var appsResource = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "applications"}
+
+func TestArgoCDAPIClient_List(t *testing.T) {
+	apps := &fakeAppsClient{apps: []v1alpha1.Application{{ObjectMeta: metav1.ObjectMeta{Name: "guestbook", Namespace: "argocd"}}}}
+	client := (&argocdAPIClient{apps: apps}).Resource(appsResource).Namespace("argocd")
+
+	list, err := client.List(metav1.ListOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", list.GetResourceVersion())
+	if assert.Len(t, list.Items, 1) {
+		assert.Equal(t, "guestbook", list.Items[0].GetName())
+		assert.Equal(t, "Application", list.Items[0].GetKind())
+		assert.Equal(t, "argoproj.io/v1alpha1", list.Items[0].GetAPIVersion())
+	}
+}
+
+func TestArgoCDAPIClient_Watch(t *testing.T) {
+	apps := &fakeAppsClient{events: []v1alpha1.ApplicationWatchEvent{{
+		Type: watch.Modified, Application: v1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: "guestbook"}},
+	}}}
+	client := (&argocdAPIClient{apps: apps}).Resource(appsResource).Namespace("argocd")
+
+	w, err := client.Watch(metav1.ListOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer w.Stop()
+
+	event := <-w.ResultChan()
+	assert.Equal(t, watch.Modified, event.Type)
+	assert.Equal(t, "guestbook", event.Object.(*unstructured.Unstructured).GetName())
+	_, ok := <-w.ResultChan()
+	assert.False(t, ok)
+}
+
+func TestArgoCDAPIClient_Patch(t *testing.T) {
+	apps := &fakeAppsClient{apps: []v1alpha1.Application{{ObjectMeta: metav1.ObjectMeta{Name: "guestbook"}}}}
+	client := (&argocdAPIClient{apps: apps}).Resource(appsResource).Namespace("argocd")
+
+	_, err := client.Patch("guestbook", types.MergePatchType, []byte(`{"metadata":{}}`), metav1.PatchOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "merge", apps.patch.PatchType)
+	assert.Equal(t, `{"metadata":{}}`, apps.patch.Patch)
+
+	_, err = client.Patch("guestbook", types.JSONPatchType, []byte(`[]`), metav1.PatchOptions{})
+	assert.Error(t, err)
+}
+
+func TestArgoCDAPIClient_UnsupportedResource(t *testing.T) {
+	client := (&argocdAPIClient{}).Resource(schema.GroupVersionResource{Resource: "configmaps"}).Namespace("argocd")
+	_, err := client.List(metav1.ListOptions{})
+	assert.True(t, errors.Is(err, errNotSupported))
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LogOpts holds logging flags values
+type LogOpts struct {
+	Level  string
+	Format string
+}
+
+// AddLogFlagsToCmd registers --log-level and --log-format flags
+func AddLogFlagsToCmd(cmd *cobra.Command) *LogOpts {
+	opts := LogOpts{}
+	cmd.Flags().StringVar(&opts.Level, "log-level", "info", "Set the logging level. One of: debug|info|warn|error")
+	cmd.Flags().StringVar(&opts.Format, "log-format", LogFormatText, "Set the logging format. One of: text|json")
+	return &opts
+}
+
+// Apply configures the global logger
+func (o *LogOpts) Apply() error {
+	level, err := log.ParseLevel(o.Level)
+	if err != nil {
+		return err
+	}
+	switch o.Format {
+	case LogFormatText:
+		log.SetFormatter(&log.TextFormatter{})
+	case LogFormatJSON:
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format '%s', expected one of: text|json", o.Format)
+	}
+	log.SetLevel(level)
+	return nil
+}
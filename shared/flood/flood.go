@@ -0,0 +1,135 @@
+package flood
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+const (
+	redisSentKey       = "argocd-notifications:flood:sent"
+	redisPausedKey     = "argocd-notifications:flood:paused"
+	redisSuppressedKey = "argocd-notifications:flood:suppressed"
+)
+
+// Store holds the flood protection state shared by the controller replicas, so the threshold applies to the
+// notifications of all replicas and the delivery is paused and resumed on every replica
+type Store interface {
+	// Allow records the sent notification and returns false if the delivery is paused; the second result is true if
+	// the notification has just paused the delivery
+	Allow(threshold int, window time.Duration) (bool, bool, error)
+	// Suppress returns true if the delivery is paused and counts the suppressed notification
+	Suppress() (bool, error)
+	// Resume resumes the paused delivery and returns false if the delivery is not paused
+	Resume() (bool, error)
+	// Status returns the time the delivery has been paused, nil if the delivery is not paused, and the number of
+	// suppressed notifications
+	Status() (*time.Time, int, error)
+}
+
+// redisAllowScript keeps the time of the notifications sent within the window in the sorted set and pauses the
+// delivery once the set holds more than threshold notifications. Returns 0 if the delivery is paused, 1 if the
+// notification is allowed and 2 if the notification has just paused the delivery.
+var redisAllowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local threshold = tonumber(ARGV[3])
+if redis.call('EXISTS', KEYS[2]) == 1 then
+  redis.call('INCR', KEYS[3])
+  return 0
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+redis.call('ZADD', KEYS[1], now, ARGV[4])
+redis.call('PEXPIRE', KEYS[1], window)
+if redis.call('ZCARD', KEYS[1]) <= threshold then
+  return 1
+end
+redis.call('SET', KEYS[2], now)
+redis.call('SET', KEYS[3], 1)
+redis.call('DEL', KEYS[1])
+return 2
+`)
+
+var redisSuppressScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return 0
+end
+redis.call('INCR', KEYS[2])
+return 1
+`)
+
+var redisResumeScript = redis.NewScript(`
+if redis.call('DEL', KEYS[1]) == 0 then
+  return 0
+end
+redis.call('DEL', KEYS[2])
+return 1
+`)
+
+// NewRedisStore returns the flood protection state stored in Redis. The window is computed using the replica clock, so
+// the replica clocks are expected to be synchronized.
+func NewRedisStore(client redis.UniversalClient) Store {
+	return &redisStore{client: client, now: time.Now}
+}
+
+type redisStore struct {
+	client redis.UniversalClient
+	now    func() time.Time
+}
+
+func (s *redisStore) nowMillis() int64 {
+	return s.now().UnixNano() / int64(time.Millisecond)
+}
+
+func (s *redisStore) Allow(threshold int, window time.Duration) (bool, bool, error) {
+	now := s.nowMillis()
+	// the member is unique, so the notifications sent at the same millisecond are counted separately
+	member := strconv.FormatInt(now, 10) + "-" + strconv.FormatInt(rand.Int63(), 36)
+	res, err := redisAllowScript.Run(s.client, []string{redisSentKey, redisPausedKey, redisSuppressedKey},
+		now, window.Milliseconds(), threshold, member).Int()
+	if err != nil {
+		return false, false, err
+	}
+	return res == 1, res == 2, nil
+}
+
+func (s *redisStore) Suppress() (bool, error) {
+	res, err := redisSuppressScript.Run(s.client, []string{redisPausedKey, redisSuppressedKey}).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (s *redisStore) Resume() (bool, error) {
+	res, err := redisResumeScript.Run(s.client, []string{redisPausedKey, redisSuppressedKey}).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (s *redisStore) Status() (*time.Time, int, error) {
+	values, err := s.client.MGet(redisPausedKey, redisSuppressedKey).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	if values[0] == nil {
+		return nil, 0, nil
+	}
+	pausedAt, err := strconv.ParseInt(fmt.Sprint(values[0]), 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse the flood protection pause time: %v", err)
+	}
+	suppressed := 0
+	if values[1] != nil {
+		if suppressed, err = strconv.Atoi(fmt.Sprint(values[1])); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse the number of suppressed notifications: %v", err)
+		}
+	}
+	res := time.Unix(0, pausedAt*int64(time.Millisecond))
+	return &res, suppressed, nil
+}
@@ -0,0 +1,67 @@
+package flood
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisStore(t *testing.T) {
+	server, err := miniredis.Run()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	replica1 := &redisStore{client: client, now: func() time.Time { return now }}
+	replica2 := &redisStore{client: client, now: func() time.Time { return now }}
+
+	allowed, _, err := replica1.Allow(2, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	now = now.Add(time.Minute)
+	// the first notification is outside of the window
+	for _, store := range []Store{replica2, replica1} {
+		allowed, tripped, err := store.Allow(2, time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+		assert.False(t, tripped)
+	}
+
+	allowed, tripped, err := replica2.Allow(2, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.True(t, tripped)
+	allowed, tripped, err = replica1.Allow(2, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.False(t, tripped)
+	suppressed, err := replica1.Suppress()
+	assert.NoError(t, err)
+	assert.True(t, suppressed)
+
+	pausedAt, count, err := replica1.Status()
+	assert.NoError(t, err)
+	if assert.NotNil(t, pausedAt) {
+		assert.True(t, now.Equal(*pausedAt))
+	}
+	assert.Equal(t, 3, count)
+
+	resumed, err := replica1.Resume()
+	assert.NoError(t, err)
+	assert.True(t, resumed)
+	resumed, err = replica2.Resume()
+	assert.NoError(t, err)
+	assert.False(t, resumed)
+	pausedAt, _, err = replica2.Status()
+	assert.NoError(t, err)
+	assert.Nil(t, pausedAt)
+	allowed, _, err = replica2.Allow(2, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+}
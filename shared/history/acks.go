@@ -0,0 +1,66 @@
+package history
+
+import (
+	"time"
+)
+
+// Acknowledge records that the user acknowledged the notifications of the application trigger. The empty namespace
+// acknowledges the application in any namespace and the empty trigger acknowledges notifications of all triggers.
+func Acknowledge(store Store, appNamespace string, app string, trigger string, user string) (Event, error) {
+	return AcknowledgeRecipient(store, appNamespace, app, trigger, "", user)
+}
+
+// AcknowledgeRecipient records that the user acknowledged the notifications of the application trigger sent to the
+// recipient, e.g. using the acknowledgement link of the notification.
+func AcknowledgeRecipient(store Store, appNamespace string, app string, trigger string, recipient string, user string) (Event, error) {
+	event := Event{
+		App:          app,
+		AppNamespace: appNamespace,
+		Trigger:      trigger,
+		Recipient:    recipient,
+		Timestamp:    time.Now(),
+		Status:       StatusAcknowledged,
+		User:         user,
+	}
+	event.ID = NewEventID(event)
+	return event, store.Record(event)
+}
+
+// GetAcknowledgement returns the first acknowledgement of the application trigger notifications recorded since the
+// specified time or nil if the notifications are not acknowledged
+func GetAcknowledgement(store Store, appNamespace string, app string, trigger string, since time.Time) (*Event, error) {
+	events, err := store.List(Filter{App: app, Status: StatusAcknowledged, Since: since})
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		e := events[i]
+		if (e.AppNamespace == "" || e.AppNamespace == appNamespace) && (e.Trigger == "" || e.Trigger == trigger) {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetLastAcknowledgement returns the acknowledgement of the most recent notification of the application trigger
+// or nil if the notification is not acknowledged yet
+func GetLastAcknowledgement(store Store, appNamespace string, app string, trigger string) (*Event, error) {
+	events, err := store.List(Filter{App: app})
+	if err != nil {
+		return nil, err
+	}
+	var ack *Event
+	for i := range events {
+		e := events[i]
+		switch {
+		case e.Status == StatusAcknowledged:
+			if (e.AppNamespace == "" || e.AppNamespace == appNamespace) && (e.Trigger == "" || e.Trigger == trigger) {
+				ack = &e
+			}
+		case e.AppNamespace == appNamespace && e.Trigger == trigger && e.Status != StatusEscalated:
+			// the new notification resets the acknowledgement
+			ack = nil
+		}
+	}
+	return ack, nil
+}
@@ -0,0 +1,62 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAcknowledgement(t *testing.T) {
+	store := NewMemoryStore(10)
+	since := time.Now()
+
+	ack, err := GetAcknowledgement(store, "argocd", "guestbook", "on-sync-failed", since)
+	assert.NoError(t, err)
+	assert.Nil(t, ack)
+
+	_, err = Acknowledge(store, "other", "guestbook", "on-sync-failed", "slack:U1")
+	assert.NoError(t, err)
+	_, err = Acknowledge(store, "argocd", "guestbook", "on-deployed", "slack:U2")
+	assert.NoError(t, err)
+	ack, err = GetAcknowledgement(store, "argocd", "guestbook", "on-sync-failed", since)
+	assert.NoError(t, err)
+	assert.Nil(t, ack)
+
+	_, err = Acknowledge(store, "", "guestbook", "", "slack:U3")
+	assert.NoError(t, err)
+	ack, err = GetAcknowledgement(store, "argocd", "guestbook", "on-sync-failed", since)
+	assert.NoError(t, err)
+	if assert.NotNil(t, ack) {
+		assert.Equal(t, "slack:U3", ack.User)
+		assert.Equal(t, StatusAcknowledged, ack.Status)
+	}
+
+	ack, err = GetAcknowledgement(store, "argocd", "guestbook", "on-sync-failed", time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Nil(t, ack)
+}
+
+func TestGetLastAcknowledgement(t *testing.T) {
+	store := NewMemoryStore(10)
+	now := time.Now()
+	record := func(status Status, offset time.Duration) {
+		assert.NoError(t, store.Record(Event{App: "guestbook", AppNamespace: "argocd", Trigger: "on-sync-failed", Status: status, Timestamp: now.Add(offset), User: "slack:U1"}))
+	}
+
+	record(StatusSent, 0)
+	ack, err := GetLastAcknowledgement(store, "argocd", "guestbook", "on-sync-failed")
+	assert.NoError(t, err)
+	assert.Nil(t, ack)
+
+	record(StatusAcknowledged, time.Second)
+	record(StatusEscalated, 2*time.Second)
+	ack, err = GetLastAcknowledgement(store, "argocd", "guestbook", "on-sync-failed")
+	assert.NoError(t, err)
+	assert.NotNil(t, ack)
+
+	record(StatusFailed, 3*time.Second)
+	ack, err = GetLastAcknowledgement(store, "argocd", "guestbook", "on-sync-failed")
+	assert.NoError(t, err)
+	assert.Nil(t, ack)
+}
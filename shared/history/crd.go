@@ -0,0 +1,131 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	labelPrefix  = "argocd-notifications.argoproj.io/"
+	appLabel     = labelPrefix + "app"
+	triggerLabel = labelPrefix + "trigger"
+	statusLabel  = labelPrefix + "status"
+	eventIDLabel = labelPrefix + "event-id"
+	maxNameLen   = 200
+)
+
+var (
+	// NotificationEventResource is the NotificationEvent custom resource
+	NotificationEventResource = schema.GroupVersionResource{Group: "argocd-notifications.argoproj.io", Version: "v1alpha1", Resource: "notificationevents"}
+)
+
+// NewCRDStore returns store that persists events as NotificationEvent custom resources in the specified namespace.
+// The app, trigger, status and event id are stored in labels, so events can be queried using kubectl label selectors.
+func NewCRDStore(client dynamic.Interface, namespace string) Store {
+	return &crdStore{client: client.Resource(NotificationEventResource).Namespace(namespace)}
+}
+
+type crdStore struct {
+	client dynamic.ResourceInterface
+}
+
+func eventName(event Event) string {
+	name := event.App
+	if len(name) > maxNameLen {
+		name = name[:maxNameLen]
+	}
+	id := event.ID
+	if id == "" {
+		id = NewEventID(event)
+	}
+	return strings.Trim(name, "-.") + "-" + id
+}
+
+// eventLabels returns labels of the non empty values that are valid label values
+func eventLabels(id string, app string, trigger string, status Status) map[string]string {
+	res := map[string]string{}
+	for k, v := range map[string]string{eventIDLabel: id, appLabel: app, triggerLabel: trigger, statusLabel: string(status)} {
+		if v != "" && len(validation.IsValidLabelValue(v)) == 0 {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func (s *crdStore) Record(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	spec := map[string]interface{}{}
+	if err = json.Unmarshal(data, &spec); err != nil {
+		return err
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion(NotificationEventResource.GroupVersion().String())
+	obj.SetKind("NotificationEvent")
+	obj.SetName(eventName(event))
+	obj.SetLabels(eventLabels(event.ID, event.App, event.Trigger, event.Status))
+	_, err = s.client.Create(obj, metav1.CreateOptions{})
+	return err
+}
+
+func (s *crdStore) List(filter Filter) ([]Event, error) {
+	// values which cannot be stored in labels are filtered on the client side
+	selector := labels.Set(eventLabels(filter.ID, filter.App, filter.Trigger, filter.Status))
+	list, err := s.client.List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Event, 0)
+	for _, item := range list.Items {
+		event, err := fromUnstructured(&item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse event %s: %v", item.GetName(), err)
+		}
+		if filter.Matches(event) {
+			res = append(res, event)
+		}
+	}
+	sortEvents(res)
+	return res, nil
+}
+
+func (s *crdStore) Prune(before time.Time) error {
+	list, err := s.client.List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, item := range list.Items {
+		event, err := fromUnstructured(&item)
+		if err != nil || event.Timestamp.Before(before) {
+			if err = s.client.Delete(item.GetName(), &metav1.DeleteOptions{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func fromUnstructured(obj *unstructured.Unstructured) (Event, error) {
+	var event Event
+	spec, ok := obj.Object["spec"]
+	if !ok {
+		return event, fmt.Errorf("spec is missing")
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return event, err
+	}
+	err = json.Unmarshal(data, &event)
+	return event, err
+}
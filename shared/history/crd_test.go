@@ -0,0 +1,60 @@
+package history
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestCRDStore(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	store := NewCRDStore(client, "default")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	assert.NoError(t, store.Record(Event{
+		App: "guestbook", Trigger: "on-sync-failed", Recipient: "slack:alerts", Notifier: "slack", Timestamp: now, Status: StatusFailed, Error: "timeout"}))
+	assert.NoError(t, store.Record(Event{
+		App: "guestbook", Trigger: "on-sync-succeeded", Recipient: "slack:alerts", Notifier: "slack", Timestamp: now.Add(-time.Hour), Status: StatusSent}))
+	assert.NoError(t, store.Record(Event{
+		App: strings.Repeat("a", 100), Trigger: "on-sync-succeeded", Recipient: "email:sre", Notifier: "email", Timestamp: now, Status: StatusSent}))
+
+	events, err := store.List(Filter{App: "guestbook"})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "on-sync-succeeded", events[0].Trigger)
+		assert.Equal(t, Event{
+			App: "guestbook", Trigger: "on-sync-failed", Recipient: "slack:alerts", Notifier: "slack", Timestamp: now, Status: StatusFailed, Error: "timeout"}, events[1])
+	}
+
+	events, err = store.List(Filter{App: strings.Repeat("a", 100)})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	assert.NoError(t, store.Prune(now.Add(-time.Minute)))
+	events, err = store.List(Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestCRDStore_ListByID(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	store := NewCRDStore(client, "default")
+	event := Event{App: "guestbook", Trigger: "on-sync-failed", Recipient: "slack:alerts", Timestamp: time.Now().UTC().Truncate(time.Second), Status: StatusFailed}
+	event.ID = NewEventID(event)
+
+	assert.NoError(t, store.Record(event))
+	assert.NoError(t, store.Record(Event{App: "guestbook", Trigger: "on-sync-failed", Recipient: "slack:alerts", Status: StatusFailed}))
+
+	events, err := store.List(Filter{ID: event.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{event}, events)
+
+	obj, err := client.Resource(NotificationEventResource).Namespace("default").Get("guestbook-"+event.ID, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, event.ID, obj.GetLabels()[eventIDLabel])
+}
@@ -0,0 +1,187 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+// Status is the notification delivery status
+type Status string
+
+const (
+	// StatusSent means that notification has been delivered to the notification service
+	StatusSent Status = "Sent"
+	// StatusFailed means that notification service returned an error
+	StatusFailed Status = "Failed"
+	// StatusDryRun means that notification has not been sent because the controller runs in dry-run mode
+	StatusDryRun Status = "DryRun"
+	// StatusEscalated means that notification has been escalated to the recipient of the next escalation tier
+	StatusEscalated Status = "Escalated"
+	// StatusAcknowledged means that the user acknowledged the notification, so it is not escalated further
+	StatusAcknowledged Status = "Acknowledged"
+)
+
+// Event is a record about the sent or failed notification
+type Event struct {
+	// ID uniquely identifies the event, e.g. in the metric exemplars
+	ID string `json:"id,omitempty"`
+	// App is the application name
+	App string `json:"app"`
+	// AppNamespace is the application namespace
+	AppNamespace string `json:"appNamespace,omitempty"`
+	// Trigger is the trigger that produced notification
+	Trigger string `json:"trigger"`
+	// Template is the template used to render notification
+	Template string `json:"template,omitempty"`
+	// Recipient in <service>:<target> format
+	Recipient string `json:"recipient"`
+	// Notifier is the notification service name
+	Notifier string `json:"notifier"`
+	// BodyHash is the SHA256 of the rendered notification
+	BodyHash string `json:"bodyHash,omitempty"`
+	// Timestamp is the delivery attempt time
+	Timestamp time.Time `json:"timestamp"`
+	// Status is the delivery status
+	Status Status `json:"status"`
+	// Error is the delivery error message if delivery failed
+	Error string `json:"error,omitempty"`
+	// Result is the notification service response, e.g. the id of the posted message
+	Result *notifiers.DeliveryResult `json:"result,omitempty"`
+	// User identifies the user who acknowledged the notification, e.g. slack:U0123
+	User string `json:"user,omitempty"`
+}
+
+// Filter limits events returned by the store. Empty fields match any value.
+type Filter struct {
+	ID        string
+	App       string
+	Trigger   string
+	Recipient string
+	Status    Status
+	// Since excludes events recorded before specified time
+	Since time.Time
+	// Until excludes events recorded after specified time
+	Until time.Time
+}
+
+// Matches returns true if event matches the filter
+func (f Filter) Matches(e Event) bool {
+	return (f.ID == "" || f.ID == e.ID) &&
+		(f.App == "" || f.App == e.App) &&
+		(f.Trigger == "" || f.Trigger == e.Trigger) &&
+		(f.Recipient == "" || f.Recipient == e.Recipient) &&
+		(f.Status == "" || f.Status == e.Status) &&
+		(f.Since.IsZero() || !e.Timestamp.Before(f.Since)) &&
+		(f.Until.IsZero() || !e.Timestamp.After(f.Until))
+}
+
+// Store persists notification events
+type Store interface {
+	// Record persists the event
+	Record(event Event) error
+	// List returns events that match the filter ordered by timestamp
+	List(filter Filter) ([]Event, error)
+	// Prune removes events recorded before the specified time
+	Prune(before time.Time) error
+}
+
+// NewEventID returns the event identifier which is short enough to be used as a label value
+func NewEventID(event Event) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s/%s/%s/%d",
+		event.AppNamespace, event.App, event.Trigger, event.Recipient, event.Status, event.Timestamp.UnixNano())))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// HashNotification returns SHA256 of the rendered notification, so events can be correlated without storing
+// potentially sensitive notification content
+func HashNotification(notification notifiers.Notification) string {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewMemoryStore returns store that keeps the specified number of the most recent events in memory
+func NewMemoryStore(limit int) Store {
+	return &memoryStore{limit: limit}
+}
+
+type memoryStore struct {
+	lock   sync.Mutex
+	limit  int
+	events []Event
+}
+
+func (s *memoryStore) Record(event Event) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.events = append(s.events, event)
+	if s.limit > 0 && len(s.events) > s.limit {
+		s.events = s.events[len(s.events)-s.limit:]
+	}
+	return nil
+}
+
+func (s *memoryStore) List(filter Filter) ([]Event, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := make([]Event, 0)
+	for _, e := range s.events {
+		if filter.Matches(e) {
+			res = append(res, e)
+		}
+	}
+	sortEvents(res)
+	return res, nil
+}
+
+func (s *memoryStore) Prune(before time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var res []Event
+	for _, e := range s.events {
+		if !e.Timestamp.Before(before) {
+			res = append(res, e)
+		}
+	}
+	s.events = res
+	return nil
+}
+
+func sortEvents(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+}
+
+// ParseFilter returns filter of the id, app, trigger, recipient, status, since and until query parameters. The since
+// and until parameters are in RFC3339 format.
+func ParseFilter(query url.Values) (Filter, error) {
+	filter := Filter{
+		ID:        query.Get("id"),
+		App:       query.Get("app"),
+		Trigger:   query.Get("trigger"),
+		Recipient: query.Get("recipient"),
+		Status:    Status(query.Get("status")),
+	}
+	for param, val := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if raw := query.Get(param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return filter, fmt.Errorf("%s should be in RFC3339 format: %v", param, err)
+			}
+			*val = t
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,85 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(2)
+	now := time.Now()
+	assert.NoError(t, store.Record(Event{App: "app1", Trigger: "on-sync", Timestamp: now.Add(-2 * time.Minute), Status: StatusSent}))
+	assert.NoError(t, store.Record(Event{App: "app2", Trigger: "on-sync", Timestamp: now, Status: StatusFailed}))
+	assert.NoError(t, store.Record(Event{App: "app1", Trigger: "on-sync", Timestamp: now.Add(-time.Minute), Status: StatusSent}))
+
+	events, err := store.List(Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "app1", events[0].App)
+	assert.Equal(t, "app2", events[1].App)
+
+	events, err = store.List(Filter{Status: StatusFailed})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "app2", events[0].App)
+
+	assert.NoError(t, store.Prune(now.Add(-30*time.Second)))
+	events, err = store.List(Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestHashNotification(t *testing.T) {
+	hash := HashNotification(notifiers.Notification{Title: "hello"})
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashNotification(notifiers.Notification{Title: "hello"}))
+	assert.NotEqual(t, hash, HashNotification(notifiers.Notification{Title: "world"}))
+}
+
+func TestListReplayable(t *testing.T) {
+	store := NewMemoryStore(0)
+	now := time.Now()
+	for _, e := range []Event{
+		{App: "app1", Trigger: "on-sync", Recipient: "slack:test", Timestamp: now.Add(-3 * time.Minute), Status: StatusFailed},
+		{App: "app1", Trigger: "on-sync", Recipient: "slack:test", Timestamp: now.Add(-2 * time.Minute), Status: StatusSent},
+		{App: "app2", Trigger: "on-sync", Recipient: "slack:test", Timestamp: now.Add(-2 * time.Minute), Status: StatusFailed},
+		{App: "app2", Trigger: "on-sync", Recipient: "slack:test", Timestamp: now.Add(-time.Minute), Status: StatusFailed},
+		{App: "app3", Trigger: "on-sync", Recipient: "slack:test", Timestamp: now, Status: StatusDryRun},
+		{Trigger: "digest", Recipient: "slack:test", Timestamp: now, Status: StatusFailed},
+	} {
+		assert.NoError(t, store.Record(e))
+	}
+
+	events, err := ListReplayable(store, Filter{})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "app2", events[0].App)
+		assert.Equal(t, now.Add(-time.Minute), events[0].Timestamp)
+	}
+
+	events, err = ListReplayable(store, Filter{Status: StatusDryRun})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "app3", events[0].App)
+	}
+
+	events, err = ListReplayable(store, Filter{Until: now.Add(-150 * time.Second)})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "app1", events[0].App)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(map[string][]string{"app": {"guestbook"}, "since": {"2020-01-01T00:00:00Z"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "guestbook", filter.App)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), filter.Since)
+
+	_, err = ParseFilter(map[string][]string{"until": {"yesterday"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package history
+
+// ListReplayable returns the most recent event of every application notification which last delivery attempt has the
+// filter status, so notifications that were delivered by the later retries are not replayed twice. The failed
+// notifications are returned if the filter status is empty. The digest notifications are not associated with the
+// application and cannot be replayed.
+func ListReplayable(store Store, filter Filter) ([]Event, error) {
+	status := filter.Status
+	if status == "" {
+		status = StatusFailed
+	}
+	filter.Status = ""
+	events, err := store.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	latest := map[string]int{}
+	var keys []string
+	for i, e := range events {
+		if e.App == "" {
+			continue
+		}
+		key := e.AppNamespace + "/" + e.App + "/" + e.Trigger + "/" + e.Recipient
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		}
+		latest[key] = i
+	}
+	res := make([]Event, 0)
+	for _, key := range keys {
+		if e := events[latest[key]]; e.Status == status {
+			res = append(res, e)
+		}
+	}
+	sortEvents(res)
+	return res, nil
+}
@@ -0,0 +1,43 @@
+package history
+
+import (
+	"time"
+)
+
+func eventKey(e Event) string {
+	if e.ID != "" {
+		return e.ID
+	}
+	return NewEventID(e)
+}
+
+// Tail passes to the handler events that match the filter and then polls the store with the specified interval and
+// passes newly recorded events until the stop channel is closed. Events are expected to be recorded in the timestamp
+// order: the event recorded with the timestamp before the last seen event is skipped.
+func Tail(store Store, filter Filter, interval time.Duration, stopCh <-chan struct{}, handler func(Event)) error {
+	// keys of the events having the last seen timestamp; the filter includes events recorded at the since time
+	seen := map[string]bool{}
+	for {
+		events, err := store.List(filter)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			key := eventKey(e)
+			if seen[key] {
+				continue
+			}
+			if e.Timestamp.After(filter.Since) {
+				filter.Since = e.Timestamp
+				seen = map[string]bool{}
+			}
+			seen[key] = true
+			handler(e)
+		}
+		select {
+		case <-stopCh:
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTail(t *testing.T) {
+	store := NewMemoryStore(0)
+	now := time.Now()
+	assert.NoError(t, store.Record(Event{App: "app1", Trigger: "on-sync", Timestamp: now.Add(-time.Minute)}))
+	assert.NoError(t, store.Record(Event{App: "app2", Trigger: "on-sync", Timestamp: now}))
+	assert.NoError(t, store.Record(Event{App: "app1", Trigger: "on-deployed", Timestamp: now}))
+
+	events := make(chan Event, 10)
+	stopCh := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- Tail(store, Filter{App: "app1"}, 10*time.Millisecond, stopCh, func(e Event) {
+			events <- e
+		})
+	}()
+
+	assert.Equal(t, "on-sync", (<-events).Trigger)
+	assert.Equal(t, "on-deployed", (<-events).Trigger)
+
+	assert.NoError(t, store.Record(Event{App: "app2", Trigger: "on-sync", Timestamp: now.Add(time.Second)}))
+	assert.NoError(t, store.Record(Event{App: "app1", Trigger: "on-health-degraded", Timestamp: now.Add(time.Second)}))
+	select {
+	case e := <-events:
+		assert.Equal(t, "on-health-degraded", e.Trigger)
+	case <-time.After(time.Second):
+		assert.Fail(t, "new event is not received")
+	}
+
+	close(stopCh)
+	assert.NoError(t, <-done)
+	assert.Empty(t, events)
+}
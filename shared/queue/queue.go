@@ -0,0 +1,131 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+var (
+	// PendingNotificationResource is the PendingNotification custom resource
+	PendingNotificationResource = schema.GroupVersionResource{Group: "argocd-notifications.argoproj.io", Version: "v1alpha1", Resource: "pendingnotifications"}
+)
+
+// Item is the notification waiting for the next delivery attempt
+type Item struct {
+	// ID uniquely identifies the item
+	ID           string `json:"id"`
+	App          string `json:"app,omitempty"`
+	AppNamespace string `json:"appNamespace,omitempty"`
+	// StateKey is the application state entry removed if the notification is not delivered after all attempts
+	StateKey     string                 `json:"stateKey,omitempty"`
+	Trigger      string                 `json:"trigger"`
+	Template     string                 `json:"template,omitempty"`
+	Service      string                 `json:"service"`
+	Target       string                 `json:"target"`
+	Notification notifiers.Notification `json:"notification"`
+	// Attempts is the number of failed delivery attempts
+	Attempts int `json:"attempts"`
+	// NextAttempt is the time of the next delivery attempt
+	NextAttempt time.Time `json:"nextAttempt"`
+	LastError   string    `json:"lastError,omitempty"`
+	// CorrelationID ties together log entries of the notification
+	CorrelationID string `json:"correlationId,omitempty"`
+	// Sequence orders notifications of the same application and recipient if delivery ordering is enabled
+	Sequence int64 `json:"sequence,omitempty"`
+	// Fallbacks are the recipients which receive the notification if the delivery fails after all attempts
+	Fallbacks []string `json:"fallbacks,omitempty"`
+	// Options are the recipient parameters passed to the notifier
+	Options map[string]string `json:"options,omitempty"`
+	// Escalation is set if the item is the pending escalation of the notification rather than the delivery; the
+	// escalation has no service and target, NextAttempt is the time of the next tier
+	Escalation *Escalation `json:"escalation,omitempty"`
+}
+
+// Escalation is the state of the pending notification escalation
+type Escalation struct {
+	// Tiers are the tiers of the escalation policy at the time the escalation started
+	Tiers []settings.EscalationTier `json:"tiers"`
+	// Tier is the index of the next tier
+	Tier int `json:"tier"`
+	// StartedAt is the time of the escalated notification
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Queue persists notifications waiting for the next delivery attempt, so they survive controller restarts
+type Queue interface {
+	// Save creates or updates the item
+	Save(item Item) error
+	// Delete removes the item with the specified ID
+	Delete(id string) error
+	// List returns all stored items
+	List() ([]Item, error)
+}
+
+// NewCRDQueue returns queue that stores items as PendingNotification custom resources in the specified namespace
+func NewCRDQueue(client dynamic.Interface, namespace string) Queue {
+	return &crdQueue{client: client.Resource(PendingNotificationResource).Namespace(namespace)}
+}
+
+type crdQueue struct {
+	client dynamic.ResourceInterface
+}
+
+func (q *crdQueue) Save(item Item) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	spec := map[string]interface{}{}
+	if err = json.Unmarshal(data, &spec); err != nil {
+		return err
+	}
+	existing, err := q.client.Get(item.ID, metav1.GetOptions{})
+	if err == nil {
+		existing.Object["spec"] = spec
+		_, err = q.client.Update(existing, metav1.UpdateOptions{})
+		return err
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion(PendingNotificationResource.GroupVersion().String())
+	obj.SetKind("PendingNotification")
+	obj.SetName(item.ID)
+	_, err = q.client.Create(obj, metav1.CreateOptions{})
+	return err
+}
+
+func (q *crdQueue) Delete(id string) error {
+	return q.client.Delete(id, &metav1.DeleteOptions{})
+}
+
+func (q *crdQueue) List() ([]Item, error) {
+	list, err := q.client.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Item, 0)
+	for _, obj := range list.Items {
+		spec, ok := obj.Object["spec"]
+		if !ok {
+			return nil, fmt.Errorf("pending notification %s has no spec", obj.GetName())
+		}
+		data, err := json.Marshal(spec)
+		if err != nil {
+			return nil, err
+		}
+		var item Item
+		if err = json.Unmarshal(data, &item); err != nil {
+			return nil, fmt.Errorf("failed to parse pending notification %s: %v", obj.GetName(), err)
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
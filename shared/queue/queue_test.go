@@ -0,0 +1,40 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+func TestCRDQueue(t *testing.T) {
+	q := NewCRDQueue(fake.NewSimpleDynamicClient(runtime.NewScheme()), "default")
+	item := Item{
+		ID:           "notification-1",
+		App:          "guestbook",
+		Trigger:      "on-sync-failed",
+		Service:      "slack",
+		Target:       "alerts",
+		Notification: notifiers.Notification{Title: "hello"},
+		Attempts:     1,
+		NextAttempt:  time.Now().UTC().Truncate(time.Second),
+	}
+
+	assert.NoError(t, q.Save(item))
+	item.Attempts = 2
+	item.LastError = "timeout"
+	assert.NoError(t, q.Save(item))
+
+	items, err := q.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item}, items)
+
+	assert.NoError(t, q.Delete(item.ID))
+	items, err = q.List()
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
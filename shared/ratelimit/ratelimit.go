@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+const redisKeyPrefix = "argocd-notifications:ratelimit:"
+
+// Buckets hold the token buckets of the rate limits shared by the controller replicas, so the limits apply to the
+// notifications of all replicas
+type Buckets interface {
+	// Reserve takes the token from the bucket with the specified limit per period and returns the delay after which
+	// the token is available
+	Reserve(key string, limit int, period time.Duration) (time.Duration, error)
+	// Cancel returns the reserved token to the bucket
+	Cancel(key string, limit int, period time.Duration) error
+}
+
+// redisReserveScript implements the token bucket using the generic cell rate algorithm: the key holds the theoretical
+// arrival time of the next token in milliseconds and the reservation is delayed once the arrival time is more than a
+// burst of tokens ahead of the current time
+var redisReserveScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+  tat = now
+end
+tat = tat + interval
+redis.call('SET', KEYS[1], tat, 'PX', math.ceil(tat - now))
+local delay = tat - now - burst * interval
+if delay < 0 then
+  return 0
+end
+return math.ceil(delay)
+`)
+
+var redisCancelScript = redis.NewScript(`
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat then
+  return 0
+end
+local now = tonumber(ARGV[2])
+tat = tat - tonumber(ARGV[1])
+if tat <= now then
+  redis.call('DEL', KEYS[1])
+else
+  redis.call('SET', KEYS[1], tat, 'PX', math.ceil(tat - now))
+end
+return 0
+`)
+
+// NewRedisBuckets returns the token buckets stored in Redis. The bucket state is computed using the replica clock, so
+// the replica clocks are expected to be synchronized.
+func NewRedisBuckets(client redis.UniversalClient) Buckets {
+	return &redisBuckets{client: client, now: time.Now}
+}
+
+type redisBuckets struct {
+	client redis.UniversalClient
+	now    func() time.Time
+}
+
+// interval returns the milliseconds between the tokens of the bucket
+func interval(limit int, period time.Duration) float64 {
+	return float64(period.Milliseconds()) / math.Max(float64(limit), 1)
+}
+
+func (b *redisBuckets) nowMillis() int64 {
+	return b.now().UnixNano() / int64(time.Millisecond)
+}
+
+func (b *redisBuckets) Reserve(key string, limit int, period time.Duration) (time.Duration, error) {
+	delay, err := redisReserveScript.Run(b.client, []string{redisKeyPrefix + key}, interval(limit, period), limit, b.nowMillis()).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(delay) * time.Millisecond, nil
+}
+
+func (b *redisBuckets) Cancel(key string, limit int, period time.Duration) error {
+	return redisCancelScript.Run(b.client, []string{redisKeyPrefix + key}, interval(limit, period), b.nowMillis()).Err()
+}
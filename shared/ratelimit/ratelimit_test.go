@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisBuckets(t *testing.T) {
+	server, err := miniredis.Run()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	replica1 := &redisBuckets{client: client, now: func() time.Time { return now }}
+	replica2 := &redisBuckets{client: client, now: func() time.Time { return now }}
+
+	for _, buckets := range []Buckets{replica1, replica2, replica1} {
+		delay, err := buckets.Reserve("slack", 3, time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Duration(0), delay)
+	}
+	delay, err := replica2.Reserve("slack", 3, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 20*time.Second, delay)
+
+	assert.NoError(t, replica2.Cancel("slack", 3, time.Minute))
+	now = now.Add(20 * time.Second)
+	delay, err = replica1.Reserve("slack", 3, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), delay)
+
+	delay, err = replica1.Reserve("email", 3, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), delay)
+}
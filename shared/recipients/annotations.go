@@ -2,6 +2,7 @@ package recipients
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/argoproj-labs/argocd-notifications/shared/text"
@@ -9,10 +10,18 @@ import (
 
 const (
 	AnnotationPostfix = "argocd-notifications.argoproj.io"
+	// FailoverSeparator separates recipients of the failover chain
+	FailoverSeparator = "|"
 )
 
 var (
 	RecipientsAnnotation = "recipients." + AnnotationPostfix
+	// SubscribeAnnotationPrefix is the prefix of annotations in format
+	// argocd-notifications.argoproj.io/subscribe.<optional-trigger>.<service>: <target1>,<target2>
+	SubscribeAnnotationPrefix = AnnotationPostfix + "/subscribe."
+	// UnsubscribeAnnotationPrefix is the prefix of annotations in format
+	// argocd-notifications.argoproj.io/unsubscribe.<optional-trigger>.<service>: <target1>,<target2>
+	UnsubscribeAnnotationPrefix = AnnotationPostfix + "/unsubscribe."
 )
 
 func GetRecipientsFromAnnotations(annotations map[string]string, trigger string) []string {
@@ -21,10 +30,125 @@ func GetRecipientsFromAnnotations(annotations map[string]string, trigger string)
 		annotation := annotations[k]
 		recipients = append(recipients, ParseRecipients(annotation)...)
 	}
+	recipients = append(recipients, GetSubscribedRecipients(annotations, trigger)...)
 
 	return recipients
 }
 
+// parseSubscribeAnnotation returns trigger and service of the annotation in the subscribe annotation format
+func parseSubscribeAnnotation(key string) (string, string, bool) {
+	return parseServiceAnnotation(key, SubscribeAnnotationPrefix)
+}
+
+// parseServiceAnnotation returns trigger and service of the annotation in <prefix><optional-trigger>.<service> format
+func parseServiceAnnotation(key string, prefix string) (string, string, bool) {
+	if !strings.HasPrefix(key, prefix) {
+		return "", "", false
+	}
+	name := key[len(prefix):]
+	trigger := ""
+	service := name
+	if i := strings.LastIndex(name, "."); i > -1 {
+		trigger = name[:i]
+		service = name[i+1:]
+	}
+	return trigger, service, service != ""
+}
+
+// GetSubscribedRecipients returns recipients subscribed to the specified trigger notifications using annotations in
+// format argocd-notifications.argoproj.io/subscribe.<optional-trigger>.<service>: <target1>,<target2>
+func GetSubscribedRecipients(annotations map[string]string, trigger string) []string {
+	recipients := make([]string, 0)
+	for k, v := range annotations {
+		annotationTrigger, service, ok := parseSubscribeAnnotation(k)
+		if !ok || annotationTrigger != "" && annotationTrigger != trigger {
+			continue
+		}
+		for _, target := range ParseRecipients(v) {
+			recipients = append(recipients, fmt.Sprintf("%s:%s", service, target))
+		}
+	}
+	return recipients
+}
+
+// GetAnnotationSubscriptions returns recipients of the legacy recipients and subscribe annotations grouped by trigger.
+// The recipients subscribed to notifications of all triggers are grouped by the empty trigger name.
+func GetAnnotationSubscriptions(annotations map[string]string) map[string][]string {
+	var keys []string
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res := map[string][]string{}
+	for _, k := range keys {
+		v := annotations[k]
+		if strings.HasSuffix(k, RecipientsAnnotation) {
+			trigger := strings.TrimRight(k[0:len(k)-len(RecipientsAnnotation)], ".")
+			res[trigger] = append(res[trigger], ParseRecipients(v)...)
+			continue
+		}
+		trigger, service, ok := parseSubscribeAnnotation(k)
+		if !ok {
+			continue
+		}
+		for _, target := range ParseRecipients(v) {
+			res[trigger] = append(res[trigger], fmt.Sprintf("%s:%s", service, target))
+		}
+	}
+	return res
+}
+
+// MigrateAnnotations converts recipients annotations in the legacy format <optional-trigger>.recipients.argocd-notifications.argoproj.io
+// into the subscribe annotations format. Recipients that cannot be converted, e.g. recipients without service name,
+// are left in the legacy annotation. Returns the converted annotations and true if any annotation has been changed.
+func MigrateAnnotations(annotations map[string]string) (map[string]string, bool) {
+	res := CopyStringMap(annotations)
+	changed := false
+	var keys []string
+	for k := range annotations {
+		if strings.HasSuffix(k, RecipientsAnnotation) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		trigger := strings.TrimRight(k[0:len(k)-len(RecipientsAnnotation)], ".")
+		var remaining []string
+		for _, recipient := range ParseRecipients(annotations[k]) {
+			service, target, ok := Split(recipient)
+			if !ok || target == "" {
+				remaining = append(remaining, recipient)
+				continue
+			}
+			key := SubscribeAnnotationPrefix + service
+			if trigger != "" {
+				key = SubscribeAnnotationPrefix + trigger + "." + service
+			}
+			targets := ParseRecipients(res[key])
+			if !containsString(targets, target) {
+				targets = append(targets, target)
+			}
+			res[key] = strings.Join(targets, ",")
+			changed = true
+		}
+		if len(remaining) == 0 {
+			delete(res, k)
+		} else {
+			res[k] = strings.Join(remaining, ",")
+		}
+	}
+	return res, changed
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
 func GetAnnotationKeys(annotations map[string]string, trigger string) []string {
 	keys := make([]string, 0)
 	for k := range annotations {
@@ -39,6 +163,22 @@ func GetAnnotationKeys(annotations map[string]string, trigger string) []string {
 	return keys
 }
 
+// GetUnsubscribedRecipients returns recipients that opted out of the specified trigger notifications using
+// annotations in format argocd-notifications.argoproj.io/unsubscribe.<optional-trigger>.<service>: <target1>,<target2>
+func GetUnsubscribedRecipients(annotations map[string]string, trigger string) []string {
+	recipients := make([]string, 0)
+	for k, v := range annotations {
+		annotationTrigger, service, ok := parseServiceAnnotation(k, UnsubscribeAnnotationPrefix)
+		if !ok || annotationTrigger != "" && annotationTrigger != trigger {
+			continue
+		}
+		for _, target := range ParseRecipients(v) {
+			recipients = append(recipients, fmt.Sprintf("%s:%s", service, target))
+		}
+	}
+	return recipients
+}
+
 func ParseRecipients(annotation string) []string {
 	recipients := make([]string, 0)
 	for _, recipient := range text.SplitRemoveEmpty(annotation, ",") {
@@ -49,6 +189,20 @@ func ParseRecipients(annotation string) []string {
 	return recipients
 }
 
+// SplitFailoverChain returns recipients of the failover chain in <type>:<name>|<type>:<name> format. The segment
+// without type belongs to the previous recipient, so the recipients like grafana:tag1|tag2 are not split.
+func SplitFailoverChain(recipient string) []string {
+	var chain []string
+	for _, part := range strings.Split(recipient, FailoverSeparator) {
+		if len(chain) > 0 && !strings.Contains(part, ":") {
+			chain[len(chain)-1] += FailoverSeparator + part
+		} else {
+			chain = append(chain, part)
+		}
+	}
+	return chain
+}
+
 func CopyStringMap(in map[string]string) map[string]string {
 	out := make(map[string]string)
 	for k, v := range in {
@@ -78,5 +232,6 @@ func AnnotationsPatch(old map[string]string, new map[string]string) map[string]*
 func FormatTriggerRecipientAnnotation(trigger string, recipient string) string {
 	recipient = strings.ReplaceAll(recipient, ":", ".")
 	recipient = strings.ReplaceAll(recipient, "@", ".")
+	recipient = strings.ReplaceAll(recipient, FailoverSeparator, ".")
 	return fmt.Sprintf("%s.%s.%s", trigger, recipient, AnnotationPostfix)
 }
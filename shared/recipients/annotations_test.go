@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"k8s.io/utils/pointer"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
 )
 
 func TestGetRecipientsFromAnnotations_NoTriggerNameInAnnotation(t *testing.T) {
@@ -23,6 +25,44 @@ func TestGetRecipientsFromAnnotations_HasTriggerNameInAnnotation(t *testing.T) {
 	assert.ElementsMatch(t, recipients, []string{"slack:test", "slack:test1"})
 }
 
+func TestGetRecipientsFromAnnotations_SubscribeAnnotations(t *testing.T) {
+	recipients := GetRecipientsFromAnnotations(map[string]string{
+		SubscribeAnnotationPrefix + "slack":                        "test, test1",
+		SubscribeAnnotationPrefix + "on-app-sync-unknown.email":    "my@email.com",
+		SubscribeAnnotationPrefix + "on-app-health-degraded.slack": "test2",
+	}, "on-app-sync-unknown")
+	assert.ElementsMatch(t, recipients, []string{"slack:test", "slack:test1", "email:my@email.com"})
+}
+
+func TestMigrateAnnotations(t *testing.T) {
+	annotations, changed := MigrateAnnotations(map[string]string{
+		RecipientsAnnotation: "slack:test,invalid",
+		fmt.Sprintf("on-app-sync-unknown.%s", RecipientsAnnotation): "slack:test1, email:my@email.com",
+		SubscribeAnnotationPrefix + "on-app-sync-unknown.slack":     "test1",
+		"other": "value",
+	})
+	assert.True(t, changed)
+	assert.Equal(t, map[string]string{
+		RecipientsAnnotation:                                    "invalid",
+		SubscribeAnnotationPrefix + "slack":                     "test",
+		SubscribeAnnotationPrefix + "on-app-sync-unknown.slack": "test1",
+		SubscribeAnnotationPrefix + "on-app-sync-unknown.email": "my@email.com",
+		"other": "value",
+	}, annotations)
+}
+
+func TestMigrateAnnotations_NothingToMigrate(t *testing.T) {
+	annotations, changed := MigrateAnnotations(map[string]string{"other": "value"})
+	assert.False(t, changed)
+	assert.Equal(t, map[string]string{"other": "value"}, annotations)
+}
+
+func TestSplitFailoverChain(t *testing.T) {
+	assert.Equal(t, []string{"slack:sre"}, SplitFailoverChain("slack:sre"))
+	assert.Equal(t, []string{"pagerduty:svc", "slack:sre", "email:sre@corp"}, SplitFailoverChain("pagerduty:svc|slack:sre|email:sre@corp"))
+	assert.Equal(t, []string{"grafana:tag1|tag2", "slack:sre"}, SplitFailoverChain("grafana:tag1|tag2|slack:sre"))
+}
+
 func TestCopyStringMap(t *testing.T) {
 	in := map[string]string{"key": "val"}
 	out := CopyStringMap(in)
@@ -63,3 +103,51 @@ func TestFormatTriggerRecipientAnnotation(t *testing.T) {
 		assert.Equal(t, tt.want, got)
 	}
 }
+
+func TestRenderRecipient(t *testing.T) {
+	app := NewApp("guestbook", WithLabels(map[string]string{"team": "payments"}))
+
+	recipient, err := RenderRecipient("slack:{{.app.metadata.labels.team}}-deploys", app)
+	assert.NoError(t, err)
+	assert.Equal(t, "slack:payments-deploys", recipient)
+
+	recipient, err = RenderRecipient("slack:{{.app.metadata.name | upper}}", app)
+	assert.NoError(t, err)
+	assert.Equal(t, "slack:GUESTBOOK", recipient)
+
+	recipient, err = RenderRecipient("slack:general", app)
+	assert.NoError(t, err)
+	assert.Equal(t, "slack:general", recipient)
+}
+
+func TestRenderRecipient_MissingField(t *testing.T) {
+	_, err := RenderRecipient("slack:{{.app.metadata.labels.owner}}-deploys", NewApp("guestbook", WithLabels(map[string]string{})))
+	assert.Error(t, err)
+}
+
+func TestGetUnsubscribedRecipients(t *testing.T) {
+	annotations := map[string]string{
+		UnsubscribeAnnotationPrefix + "slack":                        "channel1",
+		UnsubscribeAnnotationPrefix + "on-sync-status-unknown.slack": "noisy-channel, channel2",
+		UnsubscribeAnnotationPrefix + "on-sync-failed.email":         "my@email.com",
+		RecipientsAnnotation:                                         "slack:channel3",
+	}
+	assert.ElementsMatch(t, []string{"slack:channel1", "slack:noisy-channel", "slack:channel2"},
+		GetUnsubscribedRecipients(annotations, "on-sync-status-unknown"))
+	assert.ElementsMatch(t, []string{"slack:channel1", "email:my@email.com"},
+		GetUnsubscribedRecipients(annotations, "on-sync-failed"))
+}
+
+func TestGetAnnotationSubscriptions(t *testing.T) {
+	annotations := map[string]string{
+		RecipientsAnnotation:                                 "slack:general",
+		"on-sync-failed." + RecipientsAnnotation:             "email:sre@example.com",
+		SubscribeAnnotationPrefix + "slack":                  "alerts",
+		SubscribeAnnotationPrefix + "on-sync-failed.email":   "ops@example.com",
+		UnsubscribeAnnotationPrefix + "on-sync-failed.slack": "general",
+	}
+	assert.Equal(t, map[string][]string{
+		"":               {"slack:alerts", "slack:general"},
+		"on-sync-failed": {"email:ops@example.com", "email:sre@example.com"},
+	}, GetAnnotationSubscriptions(annotations))
+}
@@ -0,0 +1,53 @@
+package recipients
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// AuthorService is the service of the author:<service> recipients, e.g. author:slack, which are resolved to the
+// author of the application sync revision commit
+const AuthorService = "author"
+
+// authorServices maps the target of the author recipient to the notification service which receives the notification
+// addressed using the commit author email
+var authorServices = map[string]string{
+	"email": "email",
+	"slack": "slack-user",
+}
+
+func normalizeAuthorTarget(target string) (string, error) {
+	if _, ok := authorServices[target]; !ok {
+		return "", fmt.Errorf("author notification service %s is not supported, expected one of: email, slack", target)
+	}
+	return target, nil
+}
+
+// AuthorNotificationService returns the notification service which receives the notifications of the author recipient
+// with the specified target, e.g. slack-user for author:slack
+func AuthorNotificationService(target string) string {
+	return authorServices[target]
+}
+
+// IsAuthor returns true if the recipient chain item is the commit author recipient
+func IsAuthor(recipient string) bool {
+	service, _, ok := Split(strings.TrimSpace(recipient))
+	return ok && service == AuthorService
+}
+
+// ResolveAuthor returns the recipient of the commit author, e.g. slack-user:alice@corp.com for the author:slack
+// recipient. The author is the email address or the git author in "Name <email>" format.
+func ResolveAuthor(recipient string, author string) (string, error) {
+	parsed, err := Parse(recipient)
+	if err != nil {
+		return "", err
+	}
+	addr, err := mail.ParseAddress(author)
+	if err != nil {
+		return "", fmt.Errorf("commit author %s has no valid email address: %v", author, err)
+	}
+	parsed.Service = AuthorNotificationService(parsed.Target)
+	parsed.Target = strings.ToLower(addr.Address)
+	return parsed.String(), nil
+}
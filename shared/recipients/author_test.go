@@ -0,0 +1,28 @@
+package recipients
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveAuthor(t *testing.T) {
+	recipient, err := ResolveAuthor("author:slack", "Alice <Alice@corp.com>")
+	assert.NoError(t, err)
+	assert.Equal(t, "slack-user:alice@corp.com", recipient)
+
+	recipient, err = ResolveAuthor("author:email?thread=true", "alice@corp.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "email:alice@corp.com?thread=true", recipient)
+
+	_, err = ResolveAuthor("author:slack", "alice")
+	assert.Error(t, err)
+
+	_, err = ResolveAuthor("author:teams", "alice@corp.com")
+	assert.Error(t, err)
+}
+
+func TestIsAuthor(t *testing.T) {
+	assert.True(t, IsAuthor("author:slack"))
+	assert.False(t, IsAuthor("slack:author"))
+}
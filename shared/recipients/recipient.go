@@ -0,0 +1,140 @@
+package recipients
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+const (
+	// ServiceSeparator separates the notification service and the target of the recipient
+	ServiceSeparator = ":"
+	// ParamsSeparator separates the recipient target and the recipient parameters
+	ParamsSeparator = "?"
+)
+
+// Recipient is the recipient in <service>:<target>[?<param>=<value>&<param>=<value>] format
+type Recipient struct {
+	// Service is the notification service name, e.g. slack
+	Service string
+	// Target is the service specific destination, e.g. Slack channel or email address
+	Target string
+	// Params holds the recipient parameters; nil if the recipient has no parameters
+	Params url.Values
+}
+
+// String returns the recipient in <service>:<target>[?<params>] format
+func (r Recipient) String() string {
+	res := r.Service + ServiceSeparator + r.Target
+	if len(r.Params) > 0 {
+		res += ParamsSeparator + r.Params.Encode()
+	}
+	return res
+}
+
+// Options returns the first value of every recipient parameter or nil if the recipient has no parameters
+func (r Recipient) Options() map[string]string {
+	if len(r.Params) == 0 {
+		return nil
+	}
+	res := map[string]string{}
+	for k := range r.Params {
+		res[k] = r.Params.Get(k)
+	}
+	return res
+}
+
+// targetNormalizers validate the targets of the notification services and return the canonical target, so the same
+// destination written differently is delivered and deduplicated as the single recipient
+var targetNormalizers = map[string]func(target string) (string, error){
+	"slack":       withSlackWorkspace(normalizeSlackTarget),
+	"email":       normalizeEmailTarget,
+	"slack-user":  withSlackWorkspace(normalizeEmailTarget),
+	"slack-group": withSlackWorkspace(normalizeSlackGroupTarget),
+	AuthorService: normalizeAuthorTarget,
+}
+
+// withSlackWorkspace normalizes the target of the <workspace>/<target> recipient of the named Slack workspace
+func withSlackWorkspace(normalize func(target string) (string, error)) func(target string) (string, error) {
+	return func(target string) (string, error) {
+		parts := strings.SplitN(target, "/", 2)
+		if len(parts) < 2 {
+			return normalize(target)
+		}
+		if parts[0] == "" {
+			return "", errors.New("slack workspace is empty")
+		}
+		normalized, err := normalize(parts[1])
+		if err != nil {
+			return "", err
+		}
+		return parts[0] + "/" + normalized, nil
+	}
+}
+
+// normalizeSlackTarget removes the # prefix of the channel name; the user targets keep the @ prefix which is used
+// to open the direct message conversation
+func normalizeSlackTarget(target string) (string, error) {
+	target = strings.TrimPrefix(target, "#")
+	if target == "" || target == "@" {
+		return "", errors.New("slack channel or user is empty")
+	}
+	return target, nil
+}
+
+// normalizeSlackGroupTarget returns the user group handle with the @ prefix, e.g. @oncall for oncall
+func normalizeSlackGroupTarget(target string) (string, error) {
+	handle := strings.TrimPrefix(target, "@")
+	if handle == "" {
+		return "", errors.New("slack user group handle is empty")
+	}
+	return "@" + handle, nil
+}
+
+// normalizeEmailTarget returns the lower-cased address of the email recipient, e.g. sre@corp.com for "SRE <SRE@corp.com>"
+func normalizeEmailTarget(target string) (string, error) {
+	addr, err := mail.ParseAddress(target)
+	if err != nil {
+		return "", fmt.Errorf("%s is not valid email address: %v", target, err)
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+// Split returns the service and the raw target of the recipient in <service>:<target> format without validation
+func Split(recipient string) (string, string, bool) {
+	parts := strings.SplitN(recipient, ServiceSeparator, 2)
+	if len(parts) < 2 || parts[0] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// Parse parses the recipient in <service>:<target>[?<param>=<value>] format, validates the target of the
+// notification services with known target format and normalizes it
+func Parse(recipient string) (Recipient, error) {
+	service, target, ok := Split(strings.TrimSpace(recipient))
+	if !ok {
+		return Recipient{}, fmt.Errorf("%s is not valid recipient. Expected recipient format is <type>:<name>", recipient)
+	}
+	res := Recipient{Service: service, Target: target}
+	if i := strings.Index(target, ParamsSeparator); i > -1 {
+		params, err := url.ParseQuery(target[i+1:])
+		if err != nil {
+			return Recipient{}, fmt.Errorf("%s has not valid parameters: %v", recipient, err)
+		}
+		res.Target = target[:i]
+		if len(params) > 0 {
+			res.Params = params
+		}
+	}
+	if normalize, ok := targetNormalizers[service]; ok {
+		normalized, err := normalize(res.Target)
+		if err != nil {
+			return Recipient{}, fmt.Errorf("%s is not valid recipient: %v", recipient, err)
+		}
+		res.Target = normalized
+	}
+	return res, nil
+}
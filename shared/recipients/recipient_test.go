@@ -0,0 +1,98 @@
+package recipients
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	r, err := Parse("webhook:github")
+	assert.NoError(t, err)
+	assert.Equal(t, Recipient{Service: "webhook", Target: "github"}, r)
+	assert.Equal(t, "webhook:github", r.String())
+}
+
+func TestParse_TargetWithSeparator(t *testing.T) {
+	r, err := Parse("opsgenie:team:sre")
+	assert.NoError(t, err)
+	assert.Equal(t, "team:sre", r.Target)
+}
+
+func TestParse_Params(t *testing.T) {
+	r, err := Parse("slack:deploys?thread=true&broadcast=false")
+	assert.NoError(t, err)
+	assert.Equal(t, "deploys", r.Target)
+	assert.Equal(t, url.Values{"thread": {"true"}, "broadcast": {"false"}}, r.Params)
+	assert.Equal(t, "slack:deploys?broadcast=false&thread=true", r.String())
+}
+
+func TestParse_SlackChannel(t *testing.T) {
+	withHash, err := Parse("slack:#deploys")
+	assert.NoError(t, err)
+	withoutHash, err := Parse("slack:deploys")
+	assert.NoError(t, err)
+	assert.Equal(t, withoutHash, withHash)
+
+	user, err := Parse("slack:@john")
+	assert.NoError(t, err)
+	assert.Equal(t, "@john", user.Target)
+}
+
+func TestParse_SlackWorkspace(t *testing.T) {
+	r, err := Parse("slack:dev/#deploys")
+	assert.NoError(t, err)
+	assert.Equal(t, "dev/deploys", r.Target)
+
+	r, err = Parse("slack-user:dev/Alice@Corp.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "dev/alice@corp.com", r.Target)
+
+	r, err = Parse("slack-group:dev/oncall")
+	assert.NoError(t, err)
+	assert.Equal(t, "dev/@oncall", r.Target)
+
+	_, err = Parse("slack:/deploys")
+	assert.Error(t, err)
+}
+
+func TestParse_Email(t *testing.T) {
+	r, err := Parse("email:SRE@Example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "sre@example.com", r.Target)
+
+	r, err = Parse("email:SRE Team <SRE@example.com>")
+	assert.NoError(t, err)
+	assert.Equal(t, "sre@example.com", r.Target)
+}
+
+func TestParse_SlackLookup(t *testing.T) {
+	r, err := Parse("slack-user:Alice@Corp.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@corp.com", r.Target)
+
+	withAt, err := Parse("slack-group:@oncall-payments")
+	assert.NoError(t, err)
+	withoutAt, err := Parse("slack-group:oncall-payments")
+	assert.NoError(t, err)
+	assert.Equal(t, "@oncall-payments", withAt.Target)
+	assert.Equal(t, withAt, withoutAt)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, recipient := range []string{"slack", ":deploys", "slack:#", "email:not-an-address", "slack:deploys?%zz", "slack-user:alice", "slack-group:@"} {
+		_, err := Parse(recipient)
+		assert.Error(t, err, recipient)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	service, target, ok := Split("email:SRE@example.com?priority=high")
+	assert.True(t, ok)
+	assert.Equal(t, "email", service)
+	assert.Equal(t, "SRE@example.com?priority=high", target)
+
+	_, _, ok = Split("email")
+	assert.False(t, ok)
+}
@@ -0,0 +1,67 @@
+package recipients
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	"github.com/Masterminds/sprig"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var recipientFuncs = func() texttemplate.FuncMap {
+	f := sprig.TxtFuncMap()
+	delete(f, "env")
+	delete(f, "expandenv")
+	return f
+}()
+
+const (
+	maxCachedRecipientTemplates = 1000
+)
+
+var (
+	// recipientTemplates caches parsed recipient templates since recipients are rendered on every reconciliation
+	recipientTemplates     = map[string]*texttemplate.Template{}
+	recipientTemplatesLock sync.Mutex
+)
+
+func parseRecipientTemplate(recipient string) (*texttemplate.Template, error) {
+	recipientTemplatesLock.Lock()
+	defer recipientTemplatesLock.Unlock()
+	if tmpl, ok := recipientTemplates[recipient]; ok {
+		return tmpl, nil
+	}
+	tmpl, err := texttemplate.New(recipient).Funcs(recipientFuncs).Option("missingkey=error").Parse(recipient)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipientTemplates) >= maxCachedRecipientTemplates {
+		recipientTemplates = map[string]*texttemplate.Template{}
+	}
+	recipientTemplates[recipient] = tmpl
+	return tmpl, nil
+}
+
+// IsTemplate returns true if recipient contains template expressions
+func IsTemplate(recipient string) bool {
+	return strings.Contains(recipient, "{{")
+}
+
+// RenderRecipient renders recipient template expressions, e.g. slack:{{.app.metadata.labels.team}}-deploys,
+// using the specified application fields.
+func RenderRecipient(recipient string, app *unstructured.Unstructured) (string, error) {
+	if !IsTemplate(recipient) {
+		return recipient, nil
+	}
+	tmpl, err := parseRecipientTemplate(recipient)
+	if err != nil {
+		return "", err
+	}
+	var res bytes.Buffer
+	if err := tmpl.Execute(&res, map[string]interface{}{"app": app.Object}); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res.String()), nil
+}
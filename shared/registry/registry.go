@@ -0,0 +1,386 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
+)
+
+const (
+	dockerHubHost     = "docker.io"
+	dockerHubAPIHost  = "registry-1.docker.io"
+	dockerHubAuthKey  = "https://index.docker.io/v1/"
+	defaultCacheTTL   = 10 * time.Minute
+	annotationCommit  = "org.opencontainers.image.revision"
+	annotationSource  = "org.opencontainers.image.source"
+	annotationCreated = "org.opencontainers.image.created"
+	annotationVersion = "org.opencontainers.image.version"
+	// DefaultBuildURLKey is the OCI annotation which CI pipelines usually set to the build URL
+	DefaultBuildURLKey = "org.opencontainers.image.url"
+)
+
+var (
+	manifestMediaTypes = []string{
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.oci.image.index.v1+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+	}
+	challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	// legacyKeys are the label-schema.org labels used if the OCI annotations are missing
+	legacyKeys = map[string]string{
+		annotationCommit:  "org.label-schema.vcs-ref",
+		annotationSource:  "org.label-schema.vcs-url",
+		annotationCreated: "org.label-schema.build-date",
+		annotationVersion: "org.label-schema.version",
+	}
+)
+
+// Client returns the build metadata of the container images
+type Client interface {
+	GetProvenance(ctx context.Context, image string) (*shared.ImageProvenance, error)
+}
+
+// Credential is the registry username and password or the identity token
+type Credential struct {
+	Username string
+	Password string
+}
+
+type Options struct {
+	// Credentials are the registry credentials by registry host
+	Credentials map[string]Credential
+	// BuildURLKeys are the label or annotation keys of the build URL; the first present key is used
+	BuildURLKeys []string
+	// CacheTTL is the duration the image metadata is cached; ten minutes if zero
+	CacheTTL   time.Duration
+	HTTPClient *http.Client
+}
+
+// LoadDockerConfig parses the registry credentials of the Docker config.json file, e.g. mounted from the
+// kubernetes.io/dockerconfigjson Secret
+func LoadDockerConfig(path string) (map[string]Credential, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse docker config %s: %v", path, err)
+	}
+	res := map[string]Credential{}
+	for host, auth := range cfg.Auths {
+		cred := Credential{Username: auth.Username, Password: auth.Password}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode credentials of registry %s: %v", host, err)
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("credentials of registry %s are not in username:password format", host)
+			}
+			cred = Credential{Username: parts[0], Password: parts[1]}
+		}
+		res[registryHost(host)] = cred
+	}
+	return res, nil
+}
+
+// registryHost returns the host of the docker config auths key which might be a URL, e.g. https://index.docker.io/v1/
+func registryHost(key string) string {
+	if key == dockerHubAuthKey {
+		return dockerHubHost
+	}
+	if u, err := url.Parse(key); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return strings.TrimSuffix(key, "/")
+}
+
+type reference struct {
+	host       string
+	repository string
+	// ref is the tag or digest
+	ref string
+}
+
+// parseReference parses the image reference using the Docker rules: the first path component is the registry host
+// if it includes a dot or a port, the Docker Hub images without the namespace belong to the library namespace
+func parseReference(image string) (reference, error) {
+	res := reference{host: dockerHubHost, ref: "latest"}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, res.ref = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, res.ref = name[:i], name[i+1:]
+	}
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		res.host, name = parts[0], parts[1]
+	}
+	if name == "" || res.ref == "" {
+		return reference{}, fmt.Errorf("image reference %s is not valid", image)
+	}
+	if res.host == dockerHubHost && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	res.repository = name
+	return res, nil
+}
+
+func (r reference) apiHost() string {
+	if r.host == dockerHubHost {
+		return dockerHubAPIHost
+	}
+	return r.host
+}
+
+type cacheEntry struct {
+	provenance shared.ImageProvenance
+	expires    time.Time
+}
+
+// NewClient returns the client which reads the image config labels and manifest annotations using the OCI
+// distribution API
+func NewClient(opts Options) *client {
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = defaultCacheTTL
+	}
+	if len(opts.BuildURLKeys) == 0 {
+		opts.BuildURLKeys = []string{DefaultBuildURLKey}
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	return &client{opts: opts, cache: map[string]cacheEntry{}, now: time.Now}
+}
+
+type client struct {
+	opts  Options
+	lock  sync.Mutex
+	cache map[string]cacheEntry
+	now   func() time.Time
+}
+
+func (c *client) GetProvenance(ctx context.Context, image string) (*shared.ImageProvenance, error) {
+	c.lock.Lock()
+	entry, ok := c.cache[image]
+	c.lock.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		res := entry.provenance
+		return &res, nil
+	}
+	ref, err := parseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	session := &session{client: c, ref: ref}
+	labels, digest, err := session.getLabels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata of image %s: %v", image, err)
+	}
+	res := shared.ImageProvenance{
+		Image:   image,
+		Digest:  digest,
+		Commit:  lookup(labels, annotationCommit, legacyKeys[annotationCommit]),
+		Source:  lookup(labels, annotationSource, legacyKeys[annotationSource]),
+		Created: lookup(labels, annotationCreated, legacyKeys[annotationCreated]),
+		Version: lookup(labels, annotationVersion, legacyKeys[annotationVersion]),
+		Labels:  labels,
+	}
+	res.BuildURL = lookup(labels, c.opts.BuildURLKeys...)
+	c.lock.Lock()
+	c.cache[image] = cacheEntry{provenance: res, expires: c.now().Add(c.opts.CacheTTL)}
+	c.lock.Unlock()
+	return &res, nil
+}
+
+// lookup returns the value of the first present key
+func lookup(labels map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := labels[k]; ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+		} `json:"platform"`
+	} `json:"manifests"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// session holds the bearer token of the image repository requests
+type session struct {
+	client *client
+	ref    reference
+	token  string
+}
+
+// getLabels returns the image config labels merged with the manifest annotations and the manifest digest
+func (s *session) getLabels(ctx context.Context) (map[string]string, string, error) {
+	var m manifest
+	digest, err := s.get(ctx, "manifests/"+s.ref.ref, strings.Join(manifestMediaTypes, ", "), &m)
+	if err != nil {
+		return nil, "", err
+	}
+	annotations := m.Annotations
+	if len(m.Manifests) > 0 {
+		// the image index references the platform manifests, the linux/amd64 manifest is used if available
+		platform := m.Manifests[0].Digest
+		for _, item := range m.Manifests {
+			if item.Platform.OS == "linux" && item.Platform.Architecture == "amd64" {
+				platform = item.Digest
+				break
+			}
+		}
+		m = manifest{}
+		if _, err := s.get(ctx, "manifests/"+platform, strings.Join(manifestMediaTypes, ", "), &m); err != nil {
+			return nil, "", err
+		}
+	}
+	var config struct {
+		Config struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"config"`
+	}
+	if m.Config.Digest != "" {
+		if _, err := s.get(ctx, "blobs/"+m.Config.Digest, "", &config); err != nil {
+			return nil, "", err
+		}
+	}
+	labels := map[string]string{}
+	for k, v := range config.Config.Labels {
+		labels[k] = v
+	}
+	for _, values := range []map[string]string{m.Annotations, annotations} {
+		for k, v := range values {
+			labels[k] = v
+		}
+	}
+	return labels, digest, nil
+}
+
+// get sends the registry API request and authorizes it using the WWW-Authenticate challenge of the 401 response
+func (s *session) get(ctx context.Context, path string, accept string, out interface{}) (string, error) {
+	resp, err := s.do(ctx, path, accept)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && s.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+		if err := s.authorize(ctx, challenge); err != nil {
+			return "", err
+		}
+		if resp, err = s.do(ctx, path, accept); err != nil {
+			return "", err
+		}
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request to %s has failed with error code %d : %s", resp.Request.URL, resp.StatusCode, string(data))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+func (s *session) do(ctx context.Context, path string, accept string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://%s/v2/%s/%s", s.ref.apiHost(), s.ref.repository, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", s.token)
+	}
+	return s.client.opts.HTTPClient.Do(req.WithContext(ctx))
+}
+
+// authorize requests the pull token of the repository from the challenge realm or uses the basic credentials
+func (s *session) authorize(ctx context.Context, challenge string) error {
+	cred, hasCred := s.client.opts.Credentials[s.ref.host]
+	if strings.HasPrefix(strings.ToLower(challenge), "basic") {
+		if !hasCred {
+			return fmt.Errorf("registry %s requires credentials", s.ref.host)
+		}
+		s.token = "Basic " + base64.StdEncoding.EncodeToString([]byte(cred.Username+":"+cred.Password))
+		return nil
+	}
+	params := map[string]string{}
+	for _, match := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	if params["realm"] == "" {
+		return fmt.Errorf("registry %s returned unsupported authentication challenge '%s'", s.ref.host, challenge)
+	}
+	query := url.Values{"scope": {fmt.Sprintf("repository:%s:pull", s.ref.repository)}}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	req, err := http.NewRequest(http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if hasCred {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	}
+	resp, err := s.client.opts.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get token of registry %s: error code %d", s.ref.host, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	s.token = "Bearer " + token.Token
+	return nil
+}
@@ -0,0 +1,143 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	for image, expected := range map[string]reference{
+		"nginx":                             {host: "docker.io", repository: "library/nginx", ref: "latest"},
+		"argoproj/argocd:v1.8.0":            {host: "docker.io", repository: "argoproj/argocd", ref: "v1.8.0"},
+		"ghcr.io/org/app/api:1.0":           {host: "ghcr.io", repository: "org/app/api", ref: "1.0"},
+		"localhost:5000/app":                {host: "localhost:5000", repository: "app", ref: "latest"},
+		"localhost/app@sha256:abc":          {host: "localhost", repository: "app", ref: "sha256:abc"},
+		"registry.example.com:443/app:v1.2": {host: "registry.example.com:443", repository: "app", ref: "v1.2"},
+	} {
+		ref, err := parseReference(image)
+		assert.NoError(t, err, image)
+		assert.Equal(t, expected, ref, image)
+	}
+	_, err := parseReference("app:")
+	assert.Error(t, err)
+}
+
+func TestLoadDockerConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "config.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("alice:secret"))
+	err = ioutil.WriteFile(path, []byte(`{"auths": {
+		"https://index.docker.io/v1/": {"auth": "`+auth+`"},
+		"ghcr.io": {"username": "bob", "password": "token"}
+	}}`), 0600)
+	assert.NoError(t, err)
+
+	creds, err := LoadDockerConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Credential{
+		"docker.io": {Username: "alice", Password: "secret"},
+		"ghcr.io":   {Username: "bob", Password: "token"},
+	}, creds)
+}
+
+func newFakeRegistry(t *testing.T, requests *int) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.URL.Path == "/token" {
+			user, password, _ := r.BasicAuth()
+			assert.Equal(t, "alice", user)
+			assert.Equal(t, "secret", password)
+			assert.Equal(t, "repository:org/app:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token": "pull-token"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/org/app/manifests/v1":
+			assert.True(t, strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json"))
+			w.Header().Set("Docker-Content-Digest", "sha256:index")
+			_, _ = w.Write([]byte(`{"mediaType": "application/vnd.oci.image.index.v1+json", "manifests": [
+				{"digest": "sha256:arm", "platform": {"os": "linux", "architecture": "arm64"}},
+				{"digest": "sha256:amd", "platform": {"os": "linux", "architecture": "amd64"}}
+			], "annotations": {"org.opencontainers.image.revision": "abc123"}}`))
+		case "/v2/org/app/manifests/sha256:amd":
+			_, _ = w.Write([]byte(`{"config": {"digest": "sha256:config"}, "annotations": {"org.opencontainers.image.url": "https://ci.example.com/builds/42"}}`))
+		case "/v2/org/app/blobs/sha256:config":
+			_, _ = w.Write([]byte(`{"config": {"Labels": {"org.label-schema.vcs-url": "https://github.com/org/app", "org.opencontainers.image.version": "1.0"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func TestGetProvenance(t *testing.T) {
+	requests := 0
+	server := newFakeRegistry(t, &requests)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+	client := NewClient(Options{
+		Credentials: map[string]Credential{host: {Username: "alice", Password: "secret"}},
+		HTTPClient:  server.Client(),
+	})
+
+	provenance, err := client.GetProvenance(context.Background(), host+"/org/app:v1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, host+"/org/app:v1", provenance.Image)
+	assert.Equal(t, "sha256:index", provenance.Digest)
+	assert.Equal(t, "abc123", provenance.Commit)
+	assert.Equal(t, "https://github.com/org/app", provenance.Source)
+	assert.Equal(t, "https://ci.example.com/builds/42", provenance.BuildURL)
+	assert.Equal(t, "1.0", provenance.Version)
+
+	// the metadata is cached
+	sent := requests
+	_, err = client.GetProvenance(context.Background(), host+"/org/app:v1")
+	assert.NoError(t, err)
+	assert.Equal(t, sent, requests)
+
+	client.now = func() time.Time {
+		return time.Now().Add(time.Hour)
+	}
+	_, err = client.GetProvenance(context.Background(), host+"/org/app:v1")
+	assert.NoError(t, err)
+	assert.True(t, requests > sent)
+}
+
+func TestGetProvenance_NotFound(t *testing.T) {
+	requests := 0
+	server := newFakeRegistry(t, &requests)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+	client := NewClient(Options{
+		Credentials: map[string]Credential{host: {Username: "alice", Password: "secret"}},
+		HTTPClient:  server.Client(),
+	})
+
+	_, err := client.GetProvenance(context.Background(), host+"/org/app:v2")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get metadata of image")
+}
@@ -0,0 +1,83 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+const defaultAggregateTriggerInterval = time.Minute
+
+// AggregateTrigger is evaluated over every watched application rather than a single one, e.g. to notify the platform
+// team if a large share of the project applications is degraded
+type AggregateTrigger struct {
+	// Name identifies the trigger in the logs and notification history
+	Name string `json:"name"`
+	// Optional expression which selects the evaluated applications, e.g. app.spec.project == 'payments'; all
+	// applications are evaluated if empty
+	Selector string `json:"selector,omitempty"`
+	// Match is the expression which is true for the matched applications, e.g. app.status.health.status == 'Degraded'
+	Match string `json:"match"`
+	// Optional expression of the matched and total application numbers and the matched ratio which fires the trigger,
+	// e.g. ratio > 0.2; matched > 0 if empty
+	Condition string `json:"condition,omitempty"`
+	// Template is the name of the template which renders the notification of the matched applications
+	Template string `json:"template"`
+	// Recipients in <type>:<name> format, e.g. slack:platform
+	Recipients []string `json:"recipients"`
+	// Optional interval between the evaluations, e.g. 5m; 1m if empty
+	Interval string `json:"interval,omitempty"`
+}
+
+type aggregateTriggerAlias AggregateTrigger
+
+func (t *AggregateTrigger) UnmarshalJSON(data []byte) error {
+	alias := aggregateTriggerAlias{}
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*t = AggregateTrigger(alias)
+	return t.Validate()
+}
+
+// Validate returns an error if the aggregate trigger fields are malformed
+func (t *AggregateTrigger) Validate() error {
+	if t.Name == "" {
+		return errors.New("aggregate trigger name must be specified")
+	}
+	if t.Match == "" {
+		return fmt.Errorf("aggregate trigger %s match expression must be specified", t.Name)
+	}
+	if t.Template == "" {
+		return fmt.Errorf("aggregate trigger %s template must be specified", t.Name)
+	}
+	if len(t.Recipients) == 0 {
+		return fmt.Errorf("aggregate trigger %s recipients must be specified", t.Name)
+	}
+	for _, recipient := range t.Recipients {
+		if _, err := recipients.Parse(recipient); err != nil {
+			return err
+		}
+	}
+	if t.Interval != "" {
+		if interval, err := time.ParseDuration(t.Interval); err != nil || interval <= 0 {
+			return fmt.Errorf("invalid aggregate trigger %s interval '%s'", t.Name, t.Interval)
+		}
+	}
+	if _, err := triggers.NewAggregate(t.Selector, t.Match, t.Condition, nil); err != nil {
+		return fmt.Errorf("aggregate trigger %s: %v", t.Name, err)
+	}
+	return nil
+}
+
+// GetInterval returns the interval between the trigger evaluations
+func (t *AggregateTrigger) GetInterval() time.Duration {
+	if interval, err := time.ParseDuration(t.Interval); err == nil && interval > 0 {
+		return interval
+	}
+	return defaultAggregateTriggerInterval
+}
@@ -0,0 +1,37 @@
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateTrigger_Unmarshal(t *testing.T) {
+	var trigger AggregateTrigger
+	err := yaml.Unmarshal([]byte(`
+name: on-payments-degraded
+selector: app.spec.project == 'payments'
+match: app.status.health.status == 'Degraded'
+condition: ratio > 0.2
+template: apps-degraded
+recipients: [slack:platform]
+interval: 5m`), &trigger)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ratio > 0.2", trigger.Condition)
+	assert.Equal(t, 5*time.Minute, trigger.GetInterval())
+	assert.Equal(t, time.Minute, (&AggregateTrigger{}).GetInterval())
+}
+
+func TestAggregateTrigger_Invalid(t *testing.T) {
+	var trigger AggregateTrigger
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{match: 'true', template: t, recipients: [slack:platform]}`), &trigger), "error unmarshaling JSON: aggregate trigger name must be specified")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{name: a, template: t, recipients: [slack:platform]}`), &trigger), "error unmarshaling JSON: aggregate trigger a match expression must be specified")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{name: a, match: 'true', recipients: [slack:platform]}`), &trigger), "error unmarshaling JSON: aggregate trigger a template must be specified")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{name: a, match: 'true', template: t}`), &trigger), "error unmarshaling JSON: aggregate trigger a recipients must be specified")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{name: a, match: 'true', template: t, recipients: [slack:platform], interval: 0s}`), &trigger), "error unmarshaling JSON: invalid aggregate trigger a interval '0s'")
+	assert.Error(t, yaml.Unmarshal([]byte(`{name: a, match: 'true', template: t, recipients: [platform]}`), &trigger))
+	assert.Error(t, yaml.Unmarshal([]byte(`{name: a, match: 'true >', template: t, recipients: [slack:platform]}`), &trigger))
+}
@@ -0,0 +1,48 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Batch combines notifications of every matching recipient sent during the interval into a single notification, so
+// the recipient gets at most one message per interval
+type Batch struct {
+	// Optional notification service name, e.g. slack, which notifications are batched
+	Notifier string `json:"notifier,omitempty"`
+	// Optional recipient in <type>:<name> format which notifications are batched
+	Recipient string `json:"recipient,omitempty"`
+	// Interval between messages sent to the recipient, e.g. 30s
+	Interval string `json:"interval"`
+}
+
+type batchAlias Batch
+
+func (b *Batch) UnmarshalJSON(data []byte) error {
+	alias := batchAlias{}
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*b = Batch(alias)
+	return b.Validate()
+}
+
+// Validate returns an error if batch fields are malformed
+func (b *Batch) Validate() error {
+	if interval, err := time.ParseDuration(b.Interval); err != nil || interval <= 0 {
+		return fmt.Errorf("invalid batch interval '%s'", b.Interval)
+	}
+	return nil
+}
+
+// GetInterval returns the batch interval duration
+func (b *Batch) GetInterval() time.Duration {
+	interval, _ := time.ParseDuration(b.Interval)
+	return interval
+}
+
+// Matches returns true if notifications of the specified recipient are batched
+func (b *Batch) Matches(service string, recipient string) bool {
+	return (b.Notifier == "" || b.Notifier == service) && (b.Recipient == "" || b.Recipient == recipient)
+}
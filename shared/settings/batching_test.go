@@ -0,0 +1,27 @@
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch_Unmarshal(t *testing.T) {
+	var batch Batch
+	err := yaml.Unmarshal([]byte(`
+notifier: slack
+interval: 30s`), &batch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, batch.GetInterval())
+	assert.True(t, batch.Matches("slack", "slack:alerts"))
+	assert.False(t, batch.Matches("email", "email:user@example.com"))
+}
+
+func TestBatch_Invalid(t *testing.T) {
+	var batch Batch
+	assert.EqualError(t, yaml.Unmarshal([]byte(`notifier: slack`), &batch), "error unmarshaling JSON: invalid batch interval ''")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`interval: -1s`), &batch), "error unmarshaling JSON: invalid batch interval '-1s'")
+}
@@ -0,0 +1,82 @@
+package settings
+
+import (
+	"fmt"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+// ConfigBuilder builds notifications configuration programmatically, without config maps.
+type ConfigBuilder struct {
+	cfg  Config
+	errs []error
+}
+
+// NewConfigBuilder returns empty configuration builder
+func NewConfigBuilder() *ConfigBuilder {
+	return &ConfigBuilder{cfg: Config{Context: map[string]string{}}}
+}
+
+// Trigger adds trigger that sends notification using the specified template when the condition is true
+func (b *ConfigBuilder) Trigger(name string, condition string, template string) *ConfigBuilder {
+	for _, t := range b.cfg.Triggers {
+		if t.Name == name {
+			b.errs = append(b.errs, fmt.Errorf("trigger %s is defined more than once", name))
+			return b
+		}
+	}
+	b.cfg.Triggers = append(b.cfg.Triggers, triggers.NotificationTrigger{Name: name, Condition: condition, Template: template})
+	return b
+}
+
+// Template adds notification template
+func (b *ConfigBuilder) Template(name string, notification notifiers.Notification) *ConfigBuilder {
+	for _, t := range b.cfg.Templates {
+		if t.Name == name {
+			b.errs = append(b.errs, fmt.Errorf("template %s is defined more than once", name))
+			return b
+		}
+	}
+	b.cfg.Templates = append(b.cfg.Templates, triggers.NotificationTemplate{Name: name, Notification: notification})
+	return b
+}
+
+// Context sets the template context variable
+func (b *ConfigBuilder) Context(key string, value string) *ConfigBuilder {
+	b.cfg.Context[key] = value
+	return b
+}
+
+// Subscription adds default subscription
+func (b *ConfigBuilder) Subscription(subscription Subscription) *ConfigBuilder {
+	if subscription.Schedule != nil {
+		if err := subscription.Schedule.Validate(); err != nil {
+			b.errs = append(b.errs, err)
+			return b
+		}
+	}
+	b.cfg.Subscriptions = append(b.cfg.Subscriptions, subscription)
+	return b
+}
+
+// Build returns configuration or the first error found in triggers and templates definitions
+func (b *ConfigBuilder) Build() (*Config, error) {
+	if len(b.errs) > 0 {
+		return nil, b.errs[0]
+	}
+	if _, err := triggers.GetTriggers(b.cfg.Templates, b.cfg.Triggers, nil); err != nil {
+		return nil, err
+	}
+	// copy collections so that the built config is not affected by the further builder changes
+	cfg := Config{
+		Triggers:      append([]triggers.NotificationTrigger{}, b.cfg.Triggers...),
+		Templates:     append([]triggers.NotificationTemplate{}, b.cfg.Templates...),
+		Subscriptions: append(DefaultSubscriptions{}, b.cfg.Subscriptions...),
+		Context:       map[string]string{},
+	}
+	for k, v := range b.cfg.Context {
+		cfg.Context[k] = v
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,47 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestConfigBuilder(t *testing.T) {
+	builder := NewConfigBuilder().
+		Trigger("on-synced", "app.status.sync.status == 'Synced'", "app-synced").
+		Template("app-synced", notifiers.Notification{Title: "{{.app.metadata.name}} synced"}).
+		Context("argocdUrl", "https://argocd.example.com").
+		Subscription(Subscription{Recipients: []string{"slack:deployments"}})
+
+	cfg, err := builder.Build()
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, cfg.Triggers, 1)
+	assert.Len(t, cfg.Templates, 1)
+	assert.Equal(t, "https://argocd.example.com", cfg.Context["argocdUrl"])
+	assert.Equal(t, []string{"slack:deployments"}, cfg.Subscriptions.GetRecipients("on-synced", NewApp("test")))
+
+	builder.Context("argocdUrl", "https://other.example.com")
+	assert.Equal(t, "https://argocd.example.com", cfg.Context["argocdUrl"])
+}
+
+func TestConfigBuilder_DuplicateTrigger(t *testing.T) {
+	_, err := NewConfigBuilder().
+		Trigger("on-synced", "true", "app-synced").
+		Trigger("on-synced", "false", "app-synced").
+		Template("app-synced", notifiers.Notification{Title: "synced"}).
+		Build()
+
+	assert.EqualError(t, err, "trigger on-synced is defined more than once")
+}
+
+func TestConfigBuilder_MissingTemplate(t *testing.T) {
+	_, err := NewConfigBuilder().Trigger("on-synced", "true", "app-synced").Build()
+
+	assert.EqualError(t, err, "trigger on-synced references unknown template app-synced")
+}
@@ -0,0 +1,205 @@
+package settings
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// ChangeType is the type of the configuration change
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// ConfigChange describes the difference of a single configuration field, e.g. the trigger condition
+type ConfigChange struct {
+	Type ChangeType `json:"type"`
+	// Path is the dot separated field path, e.g. triggers.on-sync-failed.condition or services.slack.token
+	Path string `json:"path"`
+	// Old and New are the field values; the nested values are JSON encoded and the secret values are redacted
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+var (
+	// namedSections are the configuration sections which items are compared by name rather than by position
+	namedSections = []string{"triggers", "templates"}
+	// keyedSections are compared key by key even if the section is missing in one of the configurations
+	keyedSections = append([]string{"context"}, namedSections...)
+)
+
+// DiffConfigMaps returns changes of the triggers, templates, subscriptions and other settings. The ${NAME} references
+// are compared as is rather than expanded, so the result does not depend on the environment.
+func DiffConfigMaps(oldConfigMap *v1.ConfigMap, newConfigMap *v1.ConfigMap) ([]ConfigChange, error) {
+	var trees []interface{}
+	for _, configMap := range []*v1.ConfigMap{oldConfigMap, newConfigMap} {
+		cfg, err := parseConfigMap(configMap, false)
+		if err != nil {
+			return nil, err
+		}
+		tree, err := toTree(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := tree.(map[string]interface{}); ok {
+			for _, section := range namedSections {
+				m[section] = byName(m[section])
+			}
+			for _, section := range keyedSections {
+				if m[section] == nil {
+					m[section] = map[string]interface{}{}
+				}
+			}
+		}
+		trees = append(trees, tree)
+	}
+	return diffTrees(trees[0], trees[1], trees[0], trees[1]), nil
+}
+
+// DiffSecrets returns changes of the notification services settings. The secret values are compared as is but
+// redacted in the result.
+func DiffSecrets(oldSecret *v1.Secret, newSecret *v1.Secret) ([]ConfigChange, error) {
+	var trees, redacted []interface{}
+	for _, secret := range []*v1.Secret{oldSecret, newSecret} {
+		cfg, err := parseSecret(secret, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		tree, err := toTree(cfg)
+		if err != nil {
+			return nil, err
+		}
+		redactedTree, err := Redact(cfg)
+		if err != nil {
+			return nil, err
+		}
+		// the notifiers settings have no omitempty tags, so zero values are removed to compare only specified fields
+		trees = append(trees, map[string]interface{}{"services": pruneZero(tree)})
+		redacted = append(redacted, map[string]interface{}{"services": pruneZero(redactedTree)})
+	}
+	return diffTrees(trees[0], trees[1], redacted[0], redacted[1]), nil
+}
+
+func toTree(obj interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	err = json.Unmarshal(data, &res)
+	return res, err
+}
+
+// byName converts list of items with the name field into the map
+func byName(items interface{}) interface{} {
+	list, ok := items.([]interface{})
+	if !ok {
+		return items
+	}
+	res := map[string]interface{}{}
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		delete(m, "name")
+		res[name] = m
+	}
+	return res
+}
+
+func diffTrees(oldTree, newTree, oldDisplay, newDisplay interface{}) []ConfigChange {
+	changes := make([]ConfigChange, 0)
+	diffValues(nil, oldTree, newTree, oldDisplay, newDisplay, &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func diffValues(path []string, oldVal, newVal, oldDisplay, newDisplay interface{}, changes *[]ConfigChange) {
+	oldMap, oldIsMap := oldVal.(map[string]interface{})
+	newMap, newIsMap := newVal.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		oldDisplayMap, _ := oldDisplay.(map[string]interface{})
+		newDisplayMap, _ := newDisplay.(map[string]interface{})
+		keys := map[string]bool{}
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+		for k := range keys {
+			diffValues(append(path[:len(path):len(path)], k), oldMap[k], newMap[k], oldDisplayMap[k], newDisplayMap[k], changes)
+		}
+		return
+	}
+	change := ConfigChange{Path: strings.Join(path, "."), Old: formatValue(oldDisplay), New: formatValue(newDisplay)}
+	switch {
+	case isEmpty(oldVal) && isEmpty(newVal):
+		return
+	case isEmpty(oldVal):
+		change.Type = ChangeAdded
+	case isEmpty(newVal):
+		change.Type = ChangeRemoved
+	case !reflect.DeepEqual(oldVal, newVal):
+		change.Type = ChangeChanged
+	default:
+		return
+	}
+	*changes = append(*changes, change)
+}
+
+// pruneZero removes nulls, empty strings, false and zero values from the maps
+func pruneZero(val interface{}) interface{} {
+	switch val := val.(type) {
+	case map[string]interface{}:
+		for k, v := range val {
+			if v = pruneZero(v); v == nil || v == "" || v == false || v == float64(0) || isEmpty(v) {
+				delete(val, k)
+			} else {
+				val[k] = v
+			}
+		}
+	case []interface{}:
+		for i := range val {
+			val[i] = pruneZero(val[i])
+		}
+	}
+	return val
+}
+
+func isEmpty(val interface{}) bool {
+	switch val := val.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(val) == 0
+	case []interface{}:
+		return len(val) == 0
+	}
+	return false
+}
+
+func formatValue(val interface{}) string {
+	switch val := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
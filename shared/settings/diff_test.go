@@ -0,0 +1,58 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestDiffConfigMaps(t *testing.T) {
+	oldConfigMap := &v1.ConfigMap{Data: map[string]string{
+		"trigger.on-sync-failed":   `{condition: "app.status.operationState.phase in ['Error']", template: app-sync-failed}`,
+		"trigger.on-deployed":      `{condition: "true", template: app-deployed}`,
+		"template.app-sync-failed": `{body: "{{.app.metadata.name}} sync failed"}`,
+		"template.app-deployed":    `{body: "deployed"}`,
+		"config.yaml":              `context: {argocdUrl: "https://argocd.example.com"}`,
+	}}
+	newConfigMap := &v1.ConfigMap{Data: map[string]string{
+		"trigger.on-sync-failed":   `{condition: "app.status.operationState.phase in ['Error', 'Failed']", template: app-sync-failed}`,
+		"trigger.on-deployed":      `{condition: "true", template: app-deployed}`,
+		"trigger.on-health":        `{condition: "true", template: app-deployed}`,
+		"template.app-sync-failed": `{body: "{{.app.metadata.name}} sync failed"}`,
+		"template.app-deployed":    `{body: "deployed"}`,
+		"config.yaml":              `subscriptions: [{recipients: [slack:alerts], triggers: [on-sync-failed]}]`,
+	}}
+
+	changes, err := DiffConfigMaps(oldConfigMap, newConfigMap)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []ConfigChange{
+		{Type: ChangeRemoved, Path: "context.argocdUrl", Old: "https://argocd.example.com"},
+		{Type: ChangeAdded, Path: "subscriptions", New: `[{"recipients":["slack:alerts"],"triggers":["on-sync-failed"]}]`},
+		{Type: ChangeAdded, Path: "triggers.on-health", New: `{"condition":"true","template":"app-deployed"}`},
+		{Type: ChangeChanged, Path: "triggers.on-sync-failed.condition",
+			Old: "app.status.operationState.phase in ['Error']", New: "app.status.operationState.phase in ['Error', 'Failed']"},
+	}, changes)
+
+	changes, err = DiffConfigMaps(oldConfigMap, oldConfigMap)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiffSecrets(t *testing.T) {
+	changes, err := DiffSecrets(&v1.Secret{Data: map[string][]byte{
+		"notifiers.yaml": []byte(`{slack: {token: "${SLACK_TOKEN}"}, email: {host: smtp.example.com}}`),
+	}}, &v1.Secret{Data: map[string][]byte{
+		"notifiers.yaml": []byte(`{slack: {token: "xoxb-new"}, webhook: [{name: github, url: "https://api.github.com"}]}`),
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []ConfigChange{
+		{Type: ChangeRemoved, Path: "services.email", Old: `{"host":"smtp.example.com"}`},
+		{Type: ChangeChanged, Path: "services.slack.token", Old: "******", New: "******"},
+		{Type: ChangeAdded, Path: "services.webhook", New: `[{"name":"github","url":"https://api.github.com"}]`},
+	}, changes)
+}
@@ -0,0 +1,64 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+const emailService = "email"
+
+// EmailDigest collects notifications of every matching email recipient and sends them as a single summary email on the
+// cron schedule
+type EmailDigest struct {
+	// Optional recipient in email:<address> format which notifications are collected; all email recipients if empty
+	Recipient string `json:"recipient,omitempty"`
+	// Schedule is the cron expression of the digest emails, e.g. "0 9 * * *" or @daily
+	Schedule string `json:"schedule"`
+	// Timezone name from the IANA database which the schedule is evaluated in, e.g. Europe/Berlin. UTC is used if empty.
+	Timezone string `json:"timezone,omitempty"`
+	// Optional name of the template which renders the digest email; the titles and bodies of the collected
+	// notifications grouped by application are sent if empty
+	Template string `json:"template,omitempty"`
+}
+
+type emailDigestAlias EmailDigest
+
+func (d *EmailDigest) UnmarshalJSON(data []byte) error {
+	alias := emailDigestAlias{}
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*d = EmailDigest(alias)
+	return d.Validate()
+}
+
+// Validate returns an error if the digest fields are malformed
+func (d *EmailDigest) Validate() error {
+	if _, err := cron.ParseStandard(d.Schedule); err != nil {
+		return fmt.Errorf("invalid email digest schedule '%s': %v", d.Schedule, err)
+	}
+	if _, err := time.LoadLocation(d.Timezone); err != nil {
+		return fmt.Errorf("invalid email digest timezone '%s': %v", d.Timezone, err)
+	}
+	return nil
+}
+
+// Matches returns true if notifications of the specified recipient are collected into the digest
+func (d *EmailDigest) Matches(service string, recipient string) bool {
+	return service == emailService && (d.Recipient == "" || d.Recipient == recipient)
+}
+
+// Next returns the time of the next digest email after the specified time
+func (d *EmailDigest) Next(after time.Time) time.Time {
+	schedule, err := cron.ParseStandard(d.Schedule)
+	if err != nil {
+		return after
+	}
+	if loc, err := time.LoadLocation(d.Timezone); err == nil {
+		after = after.In(loc)
+	}
+	return schedule.Next(after)
+}
@@ -0,0 +1,39 @@
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailDigest_Unmarshal(t *testing.T) {
+	var digest EmailDigest
+	err := yaml.Unmarshal([]byte(`
+recipient: email:team@example.com
+schedule: "0 9 * * *"
+timezone: Europe/Berlin
+template: daily-digest`), &digest)
+
+	assert.NoError(t, err)
+	assert.True(t, digest.Matches("email", "email:team@example.com"))
+	assert.False(t, digest.Matches("email", "email:other@example.com"))
+	assert.False(t, digest.Matches("slack", "slack:team@example.com"))
+	assert.Equal(t, "daily-digest", digest.Template)
+}
+
+func TestEmailDigest_Invalid(t *testing.T) {
+	var digest EmailDigest
+	assert.Error(t, yaml.Unmarshal([]byte(`schedule: "every day"`), &digest))
+	assert.Error(t, yaml.Unmarshal([]byte(`{schedule: "@daily", timezone: Mars/Olympus}`), &digest))
+}
+
+func TestEmailDigest_Next(t *testing.T) {
+	digest := EmailDigest{Schedule: "0 9 * * *", Timezone: "Europe/Berlin"}
+	next := digest.Next(time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2020, 6, 2, 7, 0, 0, 0, time.UTC), next.UTC())
+
+	weekly := EmailDigest{Schedule: "@weekly"}
+	assert.Equal(t, time.Date(2020, 6, 7, 0, 0, 0, 0, time.UTC), weekly.Next(time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)).UTC())
+}
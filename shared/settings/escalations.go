@@ -0,0 +1,72 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+)
+
+// Escalation notifies the next recipient tier if the notification of the matching trigger is not acknowledged in time
+type Escalation struct {
+	// Triggers are the names of the triggers which notifications are escalated
+	Triggers []string `json:"triggers"`
+	// Tiers are the recipients notified one after another until the notification is acknowledged
+	Tiers []EscalationTier `json:"tiers"`
+}
+
+// EscalationTier is the group of recipients notified if the notification is not acknowledged after the delay
+type EscalationTier struct {
+	// After is the delay since the notification or the previous tier, e.g. 15m
+	After string `json:"after"`
+	// Recipients in <type>:<name> format, e.g. opsgenie:payments
+	Recipients []string `json:"recipients"`
+}
+
+type escalationAlias Escalation
+
+func (e *Escalation) UnmarshalJSON(data []byte) error {
+	alias := escalationAlias{}
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*e = Escalation(alias)
+	return e.Validate()
+}
+
+// Validate returns an error if escalation fields are malformed
+func (e *Escalation) Validate() error {
+	if len(e.Triggers) == 0 {
+		return errors.New("escalation triggers must be specified")
+	}
+	if len(e.Tiers) == 0 {
+		return errors.New("escalation tiers must be specified")
+	}
+	for _, tier := range e.Tiers {
+		if after, err := time.ParseDuration(tier.After); err != nil || after <= 0 {
+			return fmt.Errorf("invalid escalation tier delay '%s'", tier.After)
+		}
+		if len(tier.Recipients) == 0 {
+			return errors.New("escalation tier recipients must be specified")
+		}
+		for _, recipient := range tier.Recipients {
+			if _, err := recipients.Parse(recipient); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetAfter returns the delay of the tier
+func (t *EscalationTier) GetAfter() time.Duration {
+	after, _ := time.ParseDuration(t.After)
+	return after
+}
+
+// Matches returns true if notifications of the specified trigger are escalated
+func (e *Escalation) Matches(trigger string) bool {
+	return containsString(e.Triggers, trigger)
+}
@@ -0,0 +1,37 @@
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscalation_Unmarshal(t *testing.T) {
+	var escalation Escalation
+	err := yaml.Unmarshal([]byte(`
+triggers: [on-health-degraded]
+tiers:
+- after: 15m
+  recipients: [opsgenie:payments]
+- after: 30m
+  recipients: ["webhook:sms?to=oncall"]`), &escalation)
+
+	assert.NoError(t, err)
+	assert.True(t, escalation.Matches("on-health-degraded"))
+	assert.False(t, escalation.Matches("on-deployed"))
+	if assert.Len(t, escalation.Tiers, 2) {
+		assert.Equal(t, 15*time.Minute, escalation.Tiers[0].GetAfter())
+		assert.Equal(t, []string{"webhook:sms?to=oncall"}, escalation.Tiers[1].Recipients)
+	}
+}
+
+func TestEscalation_Invalid(t *testing.T) {
+	var escalation Escalation
+	assert.EqualError(t, yaml.Unmarshal([]byte(`tiers: [{after: 15m, recipients: [slack:oncall]}]`), &escalation), "error unmarshaling JSON: escalation triggers must be specified")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`triggers: [on-sync-failed]`), &escalation), "error unmarshaling JSON: escalation tiers must be specified")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{triggers: [on-sync-failed], tiers: [{after: 0s, recipients: [slack:oncall]}]}`), &escalation), "error unmarshaling JSON: invalid escalation tier delay '0s'")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{triggers: [on-sync-failed], tiers: [{after: 15m}]}`), &escalation), "error unmarshaling JSON: escalation tier recipients must be specified")
+	assert.Error(t, yaml.Unmarshal([]byte(`{triggers: [on-sync-failed], tiers: [{after: 15m, recipients: [oncall]}]}`), &escalation))
+}
@@ -23,6 +23,13 @@ func NewSecretInformer(clientset kubernetes.Interface, namespace string) cache.S
 	})
 }
 
+// NewConfigMapsInformer returns informer of config maps matching the label selector
+func NewConfigMapsInformer(clientset kubernetes.Interface, namespace string, selector string) cache.SharedIndexInformer {
+	return corev1.NewFilteredConfigMapInformer(clientset, namespace, settingsResyncDuration, cache.Indexers{}, func(options *metav1.ListOptions) {
+		options.LabelSelector = selector
+	})
+}
+
 func NewConfigMapInformer(clientset kubernetes.Interface, namespace string) cache.SharedIndexInformer {
 	return corev1.NewFilteredConfigMapInformer(clientset, namespace, settingsResyncDuration, cache.Indexers{}, func(options *metav1.ListOptions) {
 		options.FieldSelector = fmt.Sprintf("metadata.name=%s", ConfigMapName)
@@ -0,0 +1,303 @@
+package settings
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/parser"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+// Severity is the lint issue severity
+type Severity string
+
+const (
+	// SeverityError means that configuration is broken
+	SeverityError Severity = "error"
+	// SeverityWarning means that configuration works but probably not as expected
+	SeverityWarning Severity = "warning"
+)
+
+// LintIssue describes a problem found in the configuration
+type LintIssue struct {
+	Severity Severity `json:"severity"`
+	// Rule identifies the kind of the problem, e.g. in the SARIF report
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+const (
+	// RuleInvalidConfig means that configuration cannot be parsed
+	RuleInvalidConfig = "invalid-config"
+	// RuleInvalidTemplate means that template cannot be parsed
+	RuleInvalidTemplate = "invalid-template"
+	// RuleInvalidExpression means that trigger condition or oncePer expression cannot be parsed
+	RuleInvalidExpression = "invalid-expression"
+	// RuleInvalidRecipient means that subscription recipient has wrong format
+	RuleInvalidRecipient = "invalid-recipient"
+	// RuleDuplicate means that trigger or template is defined more than once
+	RuleDuplicate = "duplicate-definition"
+	// RuleUnknownReference means that configuration references undefined trigger, template or notification service
+	RuleUnknownReference = "unknown-reference"
+	// RuleNeverFires means that trigger condition is always false
+	RuleNeverFires = "never-fires"
+	// RuleUnused means that template is not used by any trigger
+	RuleUnused = "unused-template"
+	// RuleIgnored means that definition of the additional config map is ignored during merge
+	RuleIgnored = "ignored-definition"
+	// RuleEvaluationError means that trigger fails for the sample application
+	RuleEvaluationError = "evaluation-error"
+	// RuleRenderingError means that template cannot be rendered for the sample application
+	RuleRenderingError = "rendering-error"
+)
+
+func lintIssue(severity Severity, rule string, format string, args ...interface{}) LintIssue {
+	return LintIssue{Severity: severity, Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
+
+// HasErrors returns true if at least one issue has error severity
+func HasErrors(issues []LintIssue) bool {
+	for i := range issues {
+		if issues[i].Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint returns problems found in the configuration. If notifiers configuration is not nil then subscriptions recipients
+// are verified against configured notification services.
+func Lint(cfg *Config, notifiersCfg *notifiers.Config) []LintIssue {
+	var issues []LintIssue
+
+	templateNames := map[string]bool{}
+	for _, t := range cfg.Templates {
+		if templateNames[t.Name] {
+			issues = append(issues, lintIssue(SeverityError, RuleDuplicate, "template %s is defined more than once", t.Name))
+		}
+		templateNames[t.Name] = true
+		if _, err := triggers.GetTriggers([]triggers.NotificationTemplate{t}, nil, nil); err != nil {
+			issues = append(issues, lintIssue(SeverityError, RuleInvalidTemplate, "%v", err))
+		}
+	}
+
+	usedTemplates := map[string]bool{}
+	triggerNames := map[string]bool{}
+	for _, t := range cfg.Triggers {
+		if triggerNames[t.Name] {
+			issues = append(issues, lintIssue(SeverityError, RuleDuplicate, "trigger %s is defined more than once", t.Name))
+		}
+		triggerNames[t.Name] = true
+		usedTemplates[t.Template] = true
+		if !templateNames[t.Template] {
+			issues = append(issues, lintIssue(SeverityError, RuleUnknownReference, "trigger %s references unknown template %s", t.Name, t.Template))
+		}
+		if t.OncePer != "" {
+			if _, err := expr.Compile(t.OncePer); err != nil {
+				issues = append(issues, lintIssue(SeverityError, RuleInvalidExpression, "failed to parse trigger %s oncePer expression: %v", t.Name, err))
+			}
+		}
+		if t.Condition == "" {
+			issues = append(issues, lintIssue(SeverityError, RuleInvalidExpression, "trigger %s condition is empty", t.Name))
+			continue
+		}
+		if _, err := expr.Compile(t.Condition); err != nil {
+			issues = append(issues, lintIssue(SeverityError, RuleInvalidExpression, "failed to parse trigger %s condition: %v", t.Name, err))
+			continue
+		}
+		if isConstant(t.Condition) {
+			if res, err := expr.Eval(t.Condition, nil); err == nil && res != true {
+				issues = append(issues, lintIssue(SeverityWarning, RuleNeverFires, "trigger %s condition is always false so trigger never fires", t.Name))
+			}
+		}
+	}
+
+	for _, d := range cfg.EmailDigests {
+		if d.Template == "" {
+			continue
+		}
+		usedTemplates[d.Template] = true
+		if !templateNames[d.Template] {
+			issues = append(issues, lintIssue(SeverityError, RuleUnknownReference, "email digest references unknown template %s", d.Template))
+		}
+	}
+
+	aggregateNames := map[string]bool{}
+	for _, t := range cfg.AggregateTriggers {
+		if aggregateNames[t.Name] || triggerNames[t.Name] {
+			issues = append(issues, lintIssue(SeverityError, RuleDuplicate, "trigger %s is defined more than once", t.Name))
+		}
+		aggregateNames[t.Name] = true
+		usedTemplates[t.Template] = true
+		if !templateNames[t.Template] {
+			issues = append(issues, lintIssue(SeverityError, RuleUnknownReference, "aggregate trigger %s references unknown template %s", t.Name, t.Template))
+		}
+	}
+
+	for _, t := range cfg.Templates {
+		if !usedTemplates[t.Name] {
+			issues = append(issues, lintIssue(SeverityWarning, RuleUnused, "template %s is not used by any trigger", t.Name))
+		}
+	}
+
+	var configuredServices map[string]notifiers.Notifier
+	if notifiersCfg != nil {
+		configuredServices = notifiers.GetAll(*notifiersCfg)
+	}
+	for _, s := range cfg.Subscriptions {
+		for _, trigger := range s.Triggers {
+			if !triggerNames[trigger] {
+				issues = append(issues, lintIssue(SeverityWarning, RuleUnknownReference, "subscription references unknown trigger %s", trigger))
+			}
+		}
+		for _, chain := range s.Recipients {
+			// every recipient of the failover chain should be valid
+			for _, recipient := range recipients.SplitFailoverChain(chain) {
+				if _, _, ok := recipients.Split(recipient); !ok {
+					issues = append(issues, lintIssue(SeverityError, RuleInvalidRecipient, "subscription recipient %s is not valid, expected format is <type>:<name>", recipient))
+					continue
+				}
+				parsed, err := recipients.Parse(recipient)
+				if err != nil {
+					issues = append(issues, lintIssue(SeverityError, RuleInvalidRecipient, "subscription %v", err))
+					continue
+				}
+				if parsed.Service == recipients.AuthorService {
+					parsed.Service = recipients.AuthorNotificationService(parsed.Target)
+				}
+				if configuredServices != nil {
+					if _, ok := configuredServices[parsed.Service]; !ok {
+						issues = append(issues, lintIssue(SeverityError, RuleUnknownReference, "subscription recipient %s references notification service %s which is not configured", recipient, parsed.Service))
+					}
+				}
+			}
+		}
+	}
+	for _, e := range cfg.Escalations {
+		for _, trigger := range e.Triggers {
+			if !triggerNames[trigger] {
+				issues = append(issues, lintIssue(SeverityWarning, RuleUnknownReference, "escalation references unknown trigger %s", trigger))
+			}
+		}
+		for _, tier := range e.Tiers {
+			for _, recipient := range tier.Recipients {
+				parsed, err := recipients.Parse(recipient)
+				if err != nil || configuredServices == nil {
+					continue
+				}
+				if _, ok := configuredServices[parsed.Service]; !ok {
+					issues = append(issues, lintIssue(SeverityError, RuleUnknownReference, "escalation recipient %s references notification service %s which is not configured", recipient, parsed.Service))
+				}
+			}
+		}
+	}
+	for _, t := range cfg.AggregateTriggers {
+		for _, recipient := range t.Recipients {
+			parsed, err := recipients.Parse(recipient)
+			if err != nil || configuredServices == nil {
+				continue
+			}
+			if _, ok := configuredServices[parsed.Service]; !ok {
+				issues = append(issues, lintIssue(SeverityError, RuleUnknownReference, "aggregate trigger %s recipient %s references notification service %s which is not configured", t.Name, recipient, parsed.Service))
+			}
+		}
+	}
+	return issues
+}
+
+// LintConfigMaps returns problems found in the config map and the additional config maps: definitions ignored during
+// merge and the problems of the merged configuration.
+func LintConfigMaps(configMap *v1.ConfigMap, extraConfigMaps []*v1.ConfigMap, notifiersCfg *notifiers.Config) []LintIssue {
+	cfg, err := ParseConfigMap(configMap)
+	if err != nil {
+		return []LintIssue{lintIssue(SeverityError, RuleInvalidConfig, "%v", err)}
+	}
+	var issues []LintIssue
+	triggerSources := map[string]string{}
+	templateSources := map[string]string{}
+	for _, t := range cfg.Triggers {
+		triggerSources[t.Name] = configMap.Name
+	}
+	for _, t := range cfg.Templates {
+		templateSources[t.Name] = configMap.Name
+	}
+	sorted := make([]*v1.ConfigMap, len(extraConfigMaps))
+	copy(sorted, extraConfigMaps)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	for _, cm := range sorted {
+		extraCfg, err := ParseConfigMap(cm)
+		if err != nil {
+			issues = append(issues, lintIssue(SeverityError, RuleInvalidConfig, "failed to parse config map %s: %v", cm.Name, err))
+			continue
+		}
+		for _, t := range extraCfg.Triggers {
+			if source, ok := triggerSources[t.Name]; ok {
+				issues = append(issues, lintIssue(SeverityWarning, RuleIgnored, "trigger %s defined in config map %s is ignored because it is already defined in %s", t.Name, cm.Name, source))
+			} else {
+				triggerSources[t.Name] = cm.Name
+			}
+		}
+		for _, t := range extraCfg.Templates {
+			if source, ok := templateSources[t.Name]; ok {
+				issues = append(issues, lintIssue(SeverityWarning, RuleIgnored, "template %s defined in config map %s is ignored because it is already defined in %s", t.Name, cm.Name, source))
+			} else {
+				templateSources[t.Name] = cm.Name
+			}
+		}
+		if len(extraCfg.Policies) > 0 {
+			issues = append(issues, lintIssue(SeverityWarning, RuleIgnored, "policies defined in config map %s are ignored, policies are only read from %s", cm.Name, ConfigMapName))
+		}
+		if len(extraCfg.RateLimits) > 0 {
+			issues = append(issues, lintIssue(SeverityWarning, RuleIgnored, "rate limits defined in config map %s are ignored, rate limits are only read from %s", cm.Name, ConfigMapName))
+		}
+		if len(extraCfg.Batching) > 0 {
+			issues = append(issues, lintIssue(SeverityWarning, RuleIgnored, "batching defined in config map %s is ignored, batching is only read from %s", cm.Name, ConfigMapName))
+		}
+		if len(extraCfg.EmailDigests) > 0 {
+			issues = append(issues, lintIssue(SeverityWarning, RuleIgnored, "email digests defined in config map %s are ignored, email digests are only read from %s", cm.Name, ConfigMapName))
+		}
+		if len(extraCfg.Escalations) > 0 {
+			issues = append(issues, lintIssue(SeverityWarning, RuleIgnored, "escalations defined in config map %s are ignored, escalations are only read from %s", cm.Name, ConfigMapName))
+		}
+		if len(extraCfg.AggregateTriggers) > 0 {
+			issues = append(issues, lintIssue(SeverityWarning, RuleIgnored, "aggregate triggers defined in config map %s are ignored, aggregate triggers are only read from %s", cm.Name, ConfigMapName))
+		}
+	}
+	merged, err := MergeConfigMaps(cfg, extraConfigMaps...)
+	if err != nil {
+		return append(issues, lintIssue(SeverityError, RuleInvalidConfig, "%v", err))
+	}
+	return append(issues, Lint(merged, notifiersCfg)...)
+}
+
+type identifiersVisitor struct {
+	found bool
+}
+
+func (v *identifiersVisitor) Enter(node *ast.Node) {
+	switch (*node).(type) {
+	case *ast.IdentifierNode, *ast.FunctionNode:
+		v.found = true
+	}
+}
+
+func (v *identifiersVisitor) Exit(_ *ast.Node) {}
+
+// isConstant returns true if the expression does not reference any variables or functions
+func isConstant(condition string) bool {
+	tree, err := parser.Parse(condition)
+	if err != nil {
+		return false
+	}
+	visitor := &identifiersVisitor{}
+	ast.Walk(&tree.Node, visitor)
+	return !visitor.found
+}
@@ -0,0 +1,120 @@
+package settings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func TestLint_Valid(t *testing.T) {
+	issues := Lint(&Config{
+		Triggers:  []triggers.NotificationTrigger{{Name: "my-trigger", Condition: "app.status.sync.status == 'Synced'", Template: "my-template"}},
+		Templates: []triggers.NotificationTemplate{{Name: "my-template"}},
+		Subscriptions: DefaultSubscriptions{{
+			Triggers:   []string{"my-trigger"},
+			Recipients: []string{"slack:my-channel", "author:slack"},
+		}},
+	}, &notifiers.Config{Slack: &notifiers.SlackOptions{Token: "abc"}})
+
+	assert.Empty(t, issues)
+	assert.False(t, HasErrors(issues))
+}
+
+func TestLint_Issues(t *testing.T) {
+	issues := Lint(&Config{
+		Triggers: []triggers.NotificationTrigger{
+			{Name: "missing-template", Condition: "true", Template: "missing"},
+			{Name: "never", Condition: "1 > 2", Template: "used"},
+			{Name: "invalid", Condition: "app.status ==", Template: "used"},
+		},
+		Templates: []triggers.NotificationTemplate{{Name: "used"}, {Name: "unused"}},
+		Subscriptions: DefaultSubscriptions{{
+			Recipients: []string{"slack:my-channel", "invalid", "author:email"},
+		}},
+	}, &notifiers.Config{})
+
+	assert.True(t, HasErrors(issues))
+	assert.Contains(t, issues, LintIssue{Severity: SeverityError, Rule: RuleUnknownReference, Message: "trigger missing-template references unknown template missing"})
+	assert.Contains(t, issues, LintIssue{Severity: SeverityWarning, Rule: RuleNeverFires, Message: "trigger never condition is always false so trigger never fires"})
+	assert.Contains(t, issues, LintIssue{Severity: SeverityWarning, Rule: RuleUnused, Message: "template unused is not used by any trigger"})
+	assert.Contains(t, issues, LintIssue{Severity: SeverityError, Rule: RuleUnknownReference, Message: "subscription recipient slack:my-channel references notification service slack which is not configured"})
+	assert.Contains(t, issues, LintIssue{Severity: SeverityError, Rule: RuleInvalidRecipient, Message: "subscription recipient invalid is not valid, expected format is <type>:<name>"})
+	assert.Contains(t, issues, LintIssue{Severity: SeverityError, Rule: RuleUnknownReference, Message: "subscription recipient author:email references notification service email which is not configured"})
+	found := false
+	for _, issue := range issues {
+		if issue.Severity == SeverityError && strings.HasPrefix(issue.Message, "failed to parse trigger invalid condition") {
+			found = true
+		}
+	}
+	assert.True(t, found, "expected invalid condition issue")
+}
+
+func TestLint_EmailDigestTemplate(t *testing.T) {
+	issues := Lint(&Config{
+		Templates:    []triggers.NotificationTemplate{{Name: "digest"}},
+		EmailDigests: []EmailDigest{{Schedule: "@daily", Template: "digest"}, {Schedule: "@weekly", Template: "missing"}},
+	}, nil)
+
+	assert.Equal(t, []LintIssue{{Severity: SeverityError, Rule: RuleUnknownReference, Message: "email digest references unknown template missing"}}, issues)
+}
+
+func TestLint_Escalations(t *testing.T) {
+	issues := Lint(&Config{
+		Escalations: []Escalation{{Triggers: []string{"missing"}, Tiers: []EscalationTier{{After: "15m", Recipients: []string{"opsgenie:payments"}}}}},
+	}, &notifiers.Config{})
+
+	assert.Contains(t, issues, LintIssue{Severity: SeverityWarning, Rule: RuleUnknownReference, Message: "escalation references unknown trigger missing"})
+	assert.Contains(t, issues, LintIssue{Severity: SeverityError, Rule: RuleUnknownReference, Message: "escalation recipient opsgenie:payments references notification service opsgenie which is not configured"})
+}
+
+func TestLint_AggregateTriggers(t *testing.T) {
+	issues := Lint(&Config{
+		Templates: []triggers.NotificationTemplate{{Name: "apps-degraded"}},
+		AggregateTriggers: []AggregateTrigger{
+			{Name: "on-apps-degraded", Match: "true", Template: "apps-degraded", Recipients: []string{"slack:platform"}},
+			{Name: "on-apps-missing", Match: "true", Template: "missing", Recipients: []string{"slack:platform"}},
+		},
+	}, &notifiers.Config{})
+
+	assert.NotContains(t, issues, LintIssue{Severity: SeverityWarning, Rule: RuleUnused, Message: "template apps-degraded is not used by any trigger"})
+	assert.Contains(t, issues, LintIssue{Severity: SeverityError, Rule: RuleUnknownReference, Message: "aggregate trigger on-apps-missing references unknown template missing"})
+	assert.Contains(t, issues, LintIssue{Severity: SeverityError, Rule: RuleUnknownReference, Message: "aggregate trigger on-apps-degraded recipient slack:platform references notification service slack which is not configured"})
+}
+
+func TestLint_SkipsServicesCheckWithoutNotifiersConfig(t *testing.T) {
+	issues := Lint(&Config{
+		Subscriptions: DefaultSubscriptions{{Recipients: []string{"slack:my-channel"}}},
+	}, nil)
+
+	assert.Empty(t, issues)
+}
+
+func TestLintConfigMaps_OverriddenDefinitions(t *testing.T) {
+	main := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName},
+		Data: map[string]string{
+			"trigger.my-trigger":   "{condition: 'true', template: my-template}",
+			"template.my-template": "{title: main}",
+		},
+	}
+	extra := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		Data: map[string]string{
+			"template.my-template": "{title: team-a}",
+		},
+	}
+
+	issues := LintConfigMaps(main, []*v1.ConfigMap{extra}, nil)
+
+	assert.Equal(t, []LintIssue{{
+		Severity: SeverityWarning,
+		Rule:     RuleIgnored,
+		Message:  "template my-template defined in config map team-a is ignored because it is already defined in argocd-notifications-cm",
+	}}, issues)
+}
@@ -0,0 +1,82 @@
+package settings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Layout is the way triggers and templates are stored in the config map
+type Layout string
+
+const (
+	// LayoutFlat stores all triggers and templates in the config.yaml key
+	LayoutFlat Layout = "flat"
+	// LayoutKeys stores every trigger and template in a separate trigger.<name> or template.<name> key
+	LayoutKeys Layout = "keys"
+
+	triggerKeyPrefix  = "trigger."
+	templateKeyPrefix = "template."
+)
+
+// MigrateConfigMap converts the config map into the specified layout. The keys which are not related to the
+// notifications configuration and variable references are preserved.
+func MigrateConfigMap(configMap *v1.ConfigMap, layout Layout) (*v1.ConfigMap, error) {
+	if layout != LayoutFlat && layout != LayoutKeys {
+		return nil, fmt.Errorf("layout '%s' is not supported, should be one of: %s, %s", layout, LayoutFlat, LayoutKeys)
+	}
+	cfg, err := parseConfigMap(configMap, false)
+	if err != nil {
+		return nil, err
+	}
+	// config map keys are not ordered so sort definitions to produce stable config.yaml
+	sort.Slice(cfg.Triggers, func(i, j int) bool {
+		return cfg.Triggers[i].Name < cfg.Triggers[j].Name
+	})
+	sort.Slice(cfg.Templates, func(i, j int) bool {
+		return cfg.Templates[i].Name < cfg.Templates[j].Name
+	})
+	res := configMap.DeepCopy()
+	res.Data = map[string]string{}
+	for k, v := range configMap.Data {
+		if k == configKey || strings.HasPrefix(k, "trigger") || strings.HasPrefix(k, "template") {
+			continue
+		}
+		res.Data[k] = v
+	}
+
+	if layout == LayoutKeys {
+		for _, t := range cfg.Triggers {
+			name := t.Name
+			t.Name = ""
+			data, err := yaml.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			res.Data[triggerKeyPrefix+name] = string(data)
+		}
+		for _, t := range cfg.Templates {
+			name := t.Name
+			t.Name = ""
+			data, err := yaml.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			res.Data[templateKeyPrefix+name] = string(data)
+		}
+		cfg.Triggers = nil
+		cfg.Templates = nil
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) != "{}\n" {
+		res.Data[configKey] = string(data)
+	}
+	return res, nil
+}
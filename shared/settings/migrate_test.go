@@ -0,0 +1,75 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMigrateConfigMap_Keys(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName},
+		Data: map[string]string{
+			"config.yaml": `
+context:
+  argocdUrl: https://example.com
+triggers:
+- name: my-trigger
+  condition: "true"
+  template: my-template
+templates:
+- name: my-template
+  title: hello
+`,
+			"trigger.other-trigger": "{condition: 'false', template: my-template}",
+			"unrelated":             "value",
+		},
+	}
+
+	res, err := MigrateConfigMap(cm, LayoutKeys)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"config.yaml":           "context:\n  argocdUrl: https://example.com\n",
+		"trigger.my-trigger":    "condition: \"true\"\ntemplate: my-template\n",
+		"trigger.other-trigger": "condition: \"false\"\ntemplate: my-template\n",
+		"template.my-template":  "title: hello\n",
+		"unrelated":             "value",
+	}, res.Data)
+
+	parsed, err := ParseConfigMap(res)
+	assert.NoError(t, err)
+	assert.Len(t, parsed.Triggers, 2)
+	assert.Len(t, parsed.Templates, 1)
+}
+
+func TestMigrateConfigMap_Flat(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName},
+		Data: map[string]string{
+			"trigger.my-trigger":   "{condition: 'true', template: my-template}",
+			"template.my-template": "{title: hello}",
+		},
+	}
+
+	res, err := MigrateConfigMap(cm, LayoutFlat)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"config.yaml": `templates:
+- name: my-template
+  title: hello
+triggers:
+- condition: "true"
+  name: my-trigger
+  template: my-template
+`,
+	}, res.Data)
+}
+
+func TestMigrateConfigMap_UnsupportedLayout(t *testing.T) {
+	_, err := MigrateConfigMap(&v1.ConfigMap{}, "unknown")
+	assert.Error(t, err)
+}
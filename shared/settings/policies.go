@@ -0,0 +1,107 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gobwas/glob"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type rawRecipientPolicy struct {
+	Selector   string
+	Projects   []string
+	Recipients []string
+}
+
+// RecipientPolicy restricts recipients which might be notified about matching applications.
+type RecipientPolicy struct {
+	// Optional label selector of applications restricted by the policy
+	Selector labels.Selector
+	// Optional list of projects of applications restricted by the policy
+	Projects []string
+	// Recipient patterns allowed for the matching applications, e.g. slack:team-a-*
+	Recipients []string
+
+	patterns []glob.Glob
+}
+
+// MatchesApp returns true if application matches policy selector and projects
+func (p *RecipientPolicy) MatchesApp(app *unstructured.Unstructured) bool {
+	if p.Selector != nil && !p.Selector.Matches(fields.Set(app.GetLabels())) {
+		return false
+	}
+	if len(p.Projects) > 0 {
+		project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+		if !containsString(p.Projects, project) {
+			return false
+		}
+	}
+	return true
+}
+
+// Allows returns true if recipient matches one of the policy recipient patterns
+func (p *RecipientPolicy) Allows(recipient string) bool {
+	for i := range p.patterns {
+		if p.patterns[i].Match(recipient) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RecipientPolicy) UnmarshalJSON(data []byte) error {
+	raw := rawRecipientPolicy{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	p.Projects = raw.Projects
+	p.Recipients = raw.Recipients
+	p.patterns = nil
+	for _, pattern := range raw.Recipients {
+		compiled, err := glob.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid recipient pattern '%s': %v", pattern, err)
+		}
+		p.patterns = append(p.patterns, compiled)
+	}
+	if raw.Selector != "" {
+		selector, err := labels.Parse(raw.Selector)
+		if err != nil {
+			return err
+		}
+		p.Selector = selector
+	}
+	return nil
+}
+
+func (p *RecipientPolicy) MarshalJSON() ([]byte, error) {
+	raw := rawRecipientPolicy{
+		Projects:   p.Projects,
+		Recipients: p.Recipients,
+	}
+	if p.Selector != nil {
+		raw.Selector = p.Selector.String()
+	}
+	return json.Marshal(raw)
+}
+
+type RecipientPolicies []RecipientPolicy
+
+// IsAllowed returns true if recipient might be notified about the application. Applications which don't match any
+// policy might notify any recipient; otherwise recipient must match the patterns of at least one matching policy.
+func (policies RecipientPolicies) IsAllowed(app *unstructured.Unstructured, recipient string) bool {
+	restricted := false
+	for i := range policies {
+		if !policies[i].MatchesApp(app) {
+			continue
+		}
+		if policies[i].Allows(recipient) {
+			return true
+		}
+		restricted = true
+	}
+	return !restricted
+}
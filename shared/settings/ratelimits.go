@@ -0,0 +1,83 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// RateLimitOverflowQueue delays notifications which exceed the rate limit
+	RateLimitOverflowQueue = "queue"
+	// RateLimitOverflowSummary collapses notifications which exceed the rate limit into a single summary notification
+	RateLimitOverflowSummary = "summary"
+
+	defaultRateLimitPeriod = time.Minute
+)
+
+// RateLimit restricts the number of notifications sent during the period. The limit without notifier and recipient
+// applies to all notifications.
+type RateLimit struct {
+	// Optional notification service name, e.g. slack, which notifications are limited
+	Notifier string `json:"notifier,omitempty"`
+	// Optional recipient in <type>:<name> format which notifications are limited
+	Recipient string `json:"recipient,omitempty"`
+	// PerRecipient enables the separate limit for every matching recipient
+	PerRecipient bool `json:"perRecipient,omitempty"`
+	// Max number of notifications during the period
+	Limit int `json:"limit"`
+	// Period duration, e.g. 1m. One minute if empty.
+	Period string `json:"period,omitempty"`
+	// Overflow defines how the notifications exceeding the limit are handled: queue (default) or summary
+	Overflow string `json:"overflow,omitempty"`
+}
+
+type rateLimitAlias RateLimit
+
+func (l *RateLimit) UnmarshalJSON(data []byte) error {
+	alias := rateLimitAlias{}
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*l = RateLimit(alias)
+	return l.Validate()
+}
+
+// Validate returns an error if rate limit fields are malformed
+func (l *RateLimit) Validate() error {
+	if l.Limit <= 0 {
+		return fmt.Errorf("rate limit should be a positive number, got %d", l.Limit)
+	}
+	if l.Period != "" {
+		if period, err := time.ParseDuration(l.Period); err != nil || period <= 0 {
+			return fmt.Errorf("invalid rate limit period '%s'", l.Period)
+		}
+	}
+	switch l.Overflow {
+	case "", RateLimitOverflowQueue, RateLimitOverflowSummary:
+	default:
+		return fmt.Errorf("rate limit overflow should be one of: %s, %s", RateLimitOverflowQueue, RateLimitOverflowSummary)
+	}
+	return nil
+}
+
+// GetPeriod returns the rate limit period duration
+func (l *RateLimit) GetPeriod() time.Duration {
+	if period, err := time.ParseDuration(l.Period); err == nil && period > 0 {
+		return period
+	}
+	return defaultRateLimitPeriod
+}
+
+// GetOverflow returns the overflow handling mode
+func (l *RateLimit) GetOverflow() string {
+	if l.Overflow == "" {
+		return RateLimitOverflowQueue
+	}
+	return l.Overflow
+}
+
+// Matches returns true if the limit applies to notifications of the specified recipient
+func (l *RateLimit) Matches(service string, recipient string) bool {
+	return (l.Notifier == "" || l.Notifier == service) && (l.Recipient == "" || l.Recipient == recipient)
+}
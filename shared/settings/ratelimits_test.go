@@ -0,0 +1,41 @@
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_Unmarshal(t *testing.T) {
+	var limit RateLimit
+	err := yaml.Unmarshal([]byte(`
+notifier: slack
+perRecipient: true
+limit: 30
+period: 1h
+overflow: summary`), &limit)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, limit.GetPeriod())
+	assert.Equal(t, RateLimitOverflowSummary, limit.GetOverflow())
+	assert.True(t, limit.Matches("slack", "slack:alerts"))
+	assert.False(t, limit.Matches("email", "email:user@example.com"))
+}
+
+func TestRateLimit_Defaults(t *testing.T) {
+	limit := RateLimit{Limit: 1}
+
+	assert.NoError(t, limit.Validate())
+	assert.Equal(t, time.Minute, limit.GetPeriod())
+	assert.Equal(t, RateLimitOverflowQueue, limit.GetOverflow())
+	assert.True(t, limit.Matches("slack", "slack:alerts"))
+}
+
+func TestRateLimit_Invalid(t *testing.T) {
+	var limit RateLimit
+	assert.EqualError(t, yaml.Unmarshal([]byte(`limit: 0`), &limit), "error unmarshaling JSON: rate limit should be a positive number, got 0")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{limit: 1, period: abc}`), &limit), "error unmarshaling JSON: invalid rate limit period 'abc'")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{limit: 1, overflow: drop}`), &limit), "error unmarshaling JSON: rate limit overflow should be one of: queue, summary")
+}
@@ -0,0 +1,115 @@
+package settings
+
+import (
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+)
+
+const (
+	// SourceSubscriptions means that recipient is subscribed using the default subscriptions of the config map
+	SourceSubscriptions = "subscriptions"
+	// SourceApplication means that recipient is subscribed using the application annotations
+	SourceApplication = "application"
+	// SourceProject means that recipient is subscribed using the project annotations
+	SourceProject = "project"
+
+	// ExcludedUnsubscribed means that recipient is unsubscribed using the application or project annotations
+	ExcludedUnsubscribed = "unsubscribed"
+	// ExcludedPolicy means that recipient is forbidden by the recipient policies
+	ExcludedPolicy = "policy"
+)
+
+// ResolvedRecipient explains why the recipient is or is not notified about the application
+type ResolvedRecipient struct {
+	Recipient string `json:"recipient"`
+	// Sources are where the recipient is subscribed: subscriptions, application or project
+	Sources []string `json:"sources"`
+	// Excluded is the reason why recipient is not notified: unsubscribed or policy; empty if recipient is notified
+	Excluded string `json:"excluded,omitempty"`
+	// Error is the recipient template rendering error
+	Error string `json:"error,omitempty"`
+}
+
+// ResolveRecipients returns recipients of the trigger notifications about the specified application. The recipients
+// are collected from the default subscriptions, application and project annotations; unsubscribed recipients and
+// recipients forbidden by policies are excluded. The project is optional.
+func ResolveRecipients(app *unstructured.Unstructured, appProj *unstructured.Unstructured, trigger string, subscriptions DefaultSubscriptions, policies RecipientPolicies) map[string]bool {
+	res := make(map[string]bool)
+	for _, r := range ExplainRecipients(app, appProj, trigger, subscriptions, policies) {
+		switch {
+		case r.Error != "":
+			log.Warnf("Failed to render recipient '%s' of app %s/%s: %v", r.Recipient, app.GetNamespace(), app.GetName(), r.Error)
+		case r.Excluded == ExcludedPolicy:
+			log.Warnf("Recipient '%s' is not allowed to receive notifications about app %s/%s by recipient policies", r.Recipient, app.GetNamespace(), app.GetName())
+		case r.Excluded == "":
+			res[r.Recipient] = true
+		}
+	}
+	return res
+}
+
+// ExplainRecipients returns every recipient subscribed to the trigger notifications about the application together
+// with the subscription sources and the reason of the exclusion. The recipients are sorted by name.
+func ExplainRecipients(app *unstructured.Unstructured, appProj *unstructured.Unstructured, trigger string, subscriptions DefaultSubscriptions, policies RecipientPolicies) []ResolvedRecipient {
+	resolved := map[string]*ResolvedRecipient{}
+	add := func(recipient string, source string) {
+		r, ok := resolved[recipient]
+		if !ok {
+			r = &ResolvedRecipient{Recipient: recipient}
+			resolved[recipient] = r
+		}
+		if !containsString(r.Sources, source) {
+			r.Sources = append(r.Sources, source)
+		}
+	}
+	// addRendered renders recipient template expressions using the application fields
+	addRendered := func(recipient string, source string) {
+		rendered, err := recipients.RenderRecipient(recipient, app)
+		if err != nil {
+			add(recipient, source)
+			resolved[recipient].Error = fmt.Sprintf("%v", err)
+			return
+		}
+		if rendered != "" {
+			add(rendered, source)
+		}
+	}
+	for _, r := range subscriptions.GetRecipients(trigger, app) {
+		add(r, SourceSubscriptions)
+	}
+	unsubscribed := recipients.GetUnsubscribedRecipients(app.GetAnnotations(), trigger)
+	if annotations := app.GetAnnotations(); annotations != nil {
+		for _, recipient := range recipients.GetRecipientsFromAnnotations(annotations, trigger) {
+			addRendered(recipient, SourceApplication)
+		}
+	}
+	if appProj != nil {
+		if annotations := appProj.GetAnnotations(); annotations != nil {
+			for _, recipient := range recipients.GetRecipientsFromAnnotations(annotations, trigger) {
+				addRendered(recipient, SourceProject)
+			}
+			unsubscribed = append(unsubscribed, recipients.GetUnsubscribedRecipients(annotations, trigger)...)
+		}
+	}
+	for _, recipient := range unsubscribed {
+		if r, ok := resolved[recipient]; ok && r.Error == "" {
+			r.Excluded = ExcludedUnsubscribed
+		}
+	}
+	var res []ResolvedRecipient
+	for _, r := range resolved {
+		if r.Error == "" && r.Excluded == "" && !policies.IsAllowed(app, r.Recipient) {
+			r.Excluded = ExcludedPolicy
+		}
+		res = append(res, *r)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Recipient < res[j].Recipient
+	})
+	return res
+}
@@ -0,0 +1,40 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestExplainRecipients(t *testing.T) {
+	cfg, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+subscriptions:
+- recipients: [slack:general, slack:team-a-alerts]
+  triggers: [on-sync-failed]
+policies:
+- projects: [team-a]
+  recipients: ["slack:team-a-*"]`}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	app := NewApp("guestbook", WithProject("team-a"), WithAnnotations(map[string]string{
+		"argocd-notifications.argoproj.io/subscribe.on-sync-failed.slack": "team-a-deployments",
+	}))
+	proj := NewProject("team-a", WithAnnotations(map[string]string{
+		"argocd-notifications.argoproj.io/subscribe.slack":                  "team-a-alerts",
+		"argocd-notifications.argoproj.io/unsubscribe.on-sync-failed.slack": "team-a-deployments",
+		"argocd-notifications.argoproj.io/subscribe.on-deployed.slack":      "ignored",
+	}))
+
+	res := ExplainRecipients(app, proj, "on-sync-failed", cfg.Subscriptions, cfg.Policies)
+
+	assert.Equal(t, []ResolvedRecipient{
+		{Recipient: "slack:general", Sources: []string{SourceSubscriptions}, Excluded: ExcludedPolicy},
+		{Recipient: "slack:team-a-alerts", Sources: []string{SourceSubscriptions, SourceProject}},
+		{Recipient: "slack:team-a-deployments", Sources: []string{SourceApplication}, Excluded: ExcludedUnsubscribed},
+	}, res)
+	assert.Equal(t, map[string]bool{"slack:team-a-alerts": true}, ResolveRecipients(app, proj, "on-sync-failed", cfg.Subscriptions, cfg.Policies))
+}
@@ -0,0 +1,97 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+const (
+	redactedValue = "******"
+	// redactedURLPart replaces the URL credentials, path and query; unlike redactedValue it is not escaped in the URL
+	redactedURLPart = "redacted"
+)
+
+var (
+	// secretFieldNames are parts of the secret field names; the incoming webhook URLs of the recipients include the
+	// secret path, so they are redacted completely
+	secretFieldNames = []string{"token", "password", "secret", "apikey", "privatekey", "credentials", "recipienturls"}
+)
+
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, secretName := range secretFieldNames {
+		if strings.Contains(name, secretName) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL keeps only the scheme and host of the URL; credentials, path, query and fragment might hold tokens, e.g.
+// the incoming webhook URLs include the secret path. The value which is not an absolute URL is redacted completely.
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		if value == "" {
+			return value
+		}
+		return redactedValue
+	}
+	res := url.URL{Scheme: u.Scheme, Host: u.Host}
+	if u.User != nil {
+		res.User = url.User(redactedURLPart)
+	}
+	if u.Path != "" && u.Path != "/" || u.Opaque != "" {
+		res.Path = "/" + redactedURLPart
+	}
+	if u.RawQuery != "" {
+		res.RawQuery = redactedURLPart
+	}
+	if u.Fragment != "" {
+		res.Fragment = redactedURLPart
+	}
+	return res.String()
+}
+
+func redact(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		// header value might be the authorization header
+		_, isHeader := value["name"]
+		for k, v := range value {
+			switch {
+			case isSecretField(k), isHeader && k == "value":
+				if v != nil && v != "" {
+					value[k] = redactedValue
+				}
+			case strings.HasSuffix(strings.ToLower(k), "url"):
+				if s, ok := v.(string); ok {
+					value[k] = redactURL(s)
+				}
+			default:
+				value[k] = redact(v)
+			}
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = redact(value[i])
+		}
+	}
+	return value
+}
+
+// Redact returns the JSON representation of the configuration with secret values replaced by the placeholder. The
+// values of fields like token, password or apiKey, header values and everything but the scheme and host of URLs are
+// redacted.
+func Redact(obj interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return redact(res), nil
+}
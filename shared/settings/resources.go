@@ -0,0 +1,91 @@
+package settings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// ResourcesConfigMapName is the name of the config map that holds the triggers and templates of the custom resources
+	ResourcesConfigMapName = "custom-resources"
+)
+
+var (
+	// NotificationTriggerResource is the NotificationTrigger custom resource
+	NotificationTriggerResource = schema.GroupVersionResource{Group: "argocd-notifications.argoproj.io", Version: "v1alpha1", Resource: "notificationtriggers"}
+	// NotificationTemplateResource is the NotificationTemplate custom resource
+	NotificationTemplateResource = schema.GroupVersionResource{Group: "argocd-notifications.argoproj.io", Version: "v1alpha1", Resource: "notificationtemplates"}
+)
+
+// NewResourceInformer returns informer of the NotificationTrigger or NotificationTemplate resources in the specified
+// namespace
+func NewResourceInformer(client dynamic.Interface, resource schema.GroupVersionResource, namespace string) cache.SharedIndexInformer {
+	resourceClient := client.Resource(resource).Namespace(namespace)
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return resourceClient.List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return resourceClient.Watch(options)
+			},
+		},
+		&unstructured.Unstructured{},
+		settingsResyncDuration,
+		cache.Indexers{},
+	)
+}
+
+// ResourcesConfigMap returns the config map with the trigger.<name> and template.<name> keys holding the spec of the
+// NotificationTrigger and NotificationTemplate resources, so the resources are validated and merged into the
+// configuration the same way as the additional config maps. The resource version of the config map changes if any of
+// the resources changes.
+func ResourcesConfigMap(triggerResources []*unstructured.Unstructured, templateResources []*unstructured.Unstructured) (*v1.ConfigMap, error) {
+	cm := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ResourcesConfigMapName}, Data: map[string]string{}}
+	var versions []string
+	add := func(prefix string, obj *unstructured.Unstructured) error {
+		spec, _, err := unstructured.NestedMap(obj.Object, "spec")
+		if err != nil {
+			return fmt.Errorf("failed to read spec of %s%s: %v", prefix, obj.GetName(), err)
+		}
+		if spec == nil {
+			spec = map[string]interface{}{}
+		}
+		data, err := json.Marshal(spec)
+		if err != nil {
+			return err
+		}
+		cm.Data[prefix+obj.GetName()] = string(data)
+		versions = append(versions, prefix+obj.GetName()+"="+obj.GetResourceVersion())
+		return nil
+	}
+	for _, obj := range triggerResources {
+		if err := add("trigger.", obj); err != nil {
+			return nil, err
+		}
+	}
+	for _, obj := range templateResources {
+		if err := add("template.", obj); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(versions)
+	hash := sha256.New()
+	for _, version := range versions {
+		_, _ = hash.Write([]byte(version + "\n"))
+	}
+	cm.ResourceVersion = hex.EncodeToString(hash.Sum(nil))[:16]
+	return cm, nil
+}
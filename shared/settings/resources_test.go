@@ -0,0 +1,51 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newResource(name string, resourceVersion string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "resourceVersion": resourceVersion},
+		"spec":     spec,
+	}}
+}
+
+func TestResourcesConfigMap(t *testing.T) {
+	trigger := newResource("on-deployed", "1", map[string]interface{}{"condition": "true", "template": "app-deployed"})
+	template := newResource("app-deployed", "2", map[string]interface{}{"title": "deployed"})
+
+	cm, err := ResourcesConfigMap([]*unstructured.Unstructured{trigger}, []*unstructured.Unstructured{template})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]string{
+		"trigger.on-deployed":   `{"condition":"true","template":"app-deployed"}`,
+		"template.app-deployed": `{"title":"deployed"}`,
+	}, cm.Data)
+
+	cfg, err := ParseConfigMap(cm)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, cfg.Triggers, 1)
+	assert.Len(t, cfg.Templates, 1)
+
+	template.SetResourceVersion("3")
+	changed, err := ResourcesConfigMap([]*unstructured.Unstructured{trigger}, []*unstructured.Unstructured{template})
+	assert.NoError(t, err)
+	assert.NotEqual(t, cm.ResourceVersion, changed.ResourceVersion)
+}
+
+func TestResourcesConfigMap_InvalidFields(t *testing.T) {
+	trigger := newResource("on-deployed", "1", map[string]interface{}{"condition": "true", "tempalte": "app-deployed"})
+	cm, err := ResourcesConfigMap([]*unstructured.Unstructured{trigger}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ParseConfigMap(cm)
+	assert.Error(t, err)
+}
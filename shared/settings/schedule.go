@@ -0,0 +1,98 @@
+package settings
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const scheduleTimeLayout = "15:04"
+
+// Schedule defines the time window when subscription recipients are notified.
+type Schedule struct {
+	// Timezone name from the IANA database, e.g. Europe/Berlin. UTC is used if empty.
+	Timezone string `json:"timezone,omitempty"`
+	// Days of week when notifications are delivered, e.g. Mon, Tue. All days if empty.
+	Days []string `json:"days,omitempty"`
+	// Start of the delivery window in HH:MM format
+	Start string `json:"start,omitempty"`
+	// End of the delivery window in HH:MM format
+	End string `json:"end,omitempty"`
+	// Digest enables delivering notifications suppressed outside of the window as a single message
+	Digest bool `json:"digest,omitempty"`
+}
+
+func parseScheduleTime(val string) (int, error) {
+	if val == "" {
+		return -1, nil
+	}
+	t, err := time.Parse(scheduleTimeLayout, val)
+	if err != nil {
+		return -1, fmt.Errorf("time '%s' should be in HH:MM format", val)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate returns an error if schedule fields are malformed
+func (s *Schedule) Validate() error {
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("invalid schedule timezone '%s': %v", s.Timezone, err)
+	}
+	for _, day := range s.Days {
+		if _, ok := parseWeekday(day); !ok {
+			return fmt.Errorf("invalid schedule day '%s'", day)
+		}
+	}
+	if _, err := parseScheduleTime(s.Start); err != nil {
+		return err
+	}
+	if _, err := parseScheduleTime(s.End); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseWeekday(day string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := d.String()
+		if strings.EqualFold(day, name) || strings.EqualFold(day, name[:3]) {
+			return d, true
+		}
+	}
+	return time.Sunday, false
+}
+
+// IsActive returns true if the specified time is within the delivery window
+func (s *Schedule) IsActive(t time.Time) bool {
+	if loc, err := time.LoadLocation(s.Timezone); err == nil {
+		t = t.In(loc)
+	}
+	if len(s.Days) > 0 {
+		matched := false
+		for _, day := range s.Days {
+			if d, ok := parseWeekday(day); ok && d == t.Weekday() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	start, _ := parseScheduleTime(s.Start)
+	end, _ := parseScheduleTime(s.End)
+	minutes := t.Hour()*60 + t.Minute()
+	switch {
+	case start < 0 && end < 0:
+		return true
+	case end < 0:
+		return minutes >= start
+	case start < 0:
+		return minutes < end
+	case start <= end:
+		return minutes >= start && minutes < end
+	default:
+		// window spans midnight, e.g. 22:00-06:00
+		return minutes >= start || minutes < end
+	}
+}
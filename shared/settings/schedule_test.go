@@ -0,0 +1,48 @@
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParseTime(t *testing.T, val string) time.Time {
+	res, err := time.Parse(time.RFC3339, val)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return res
+}
+
+func TestSchedule_IsActive(t *testing.T) {
+	businessHours := Schedule{Days: []string{"Mon", "Tuesday"}, Start: "09:00", End: "17:00"}
+	// 2020-06-01 is Monday
+	assert.True(t, businessHours.IsActive(mustParseTime(t, "2020-06-01T09:00:00Z")))
+	assert.True(t, businessHours.IsActive(mustParseTime(t, "2020-06-02T16:59:00Z")))
+	assert.False(t, businessHours.IsActive(mustParseTime(t, "2020-06-01T17:00:00Z")))
+	assert.False(t, businessHours.IsActive(mustParseTime(t, "2020-06-01T08:59:00Z")))
+	assert.False(t, businessHours.IsActive(mustParseTime(t, "2020-06-03T10:00:00Z")))
+
+	overnight := Schedule{Start: "22:00", End: "06:00"}
+	assert.True(t, overnight.IsActive(mustParseTime(t, "2020-06-01T23:00:00Z")))
+	assert.True(t, overnight.IsActive(mustParseTime(t, "2020-06-01T05:00:00Z")))
+	assert.False(t, overnight.IsActive(mustParseTime(t, "2020-06-01T12:00:00Z")))
+
+	assert.True(t, (&Schedule{}).IsActive(time.Now()))
+}
+
+func TestSchedule_IsActiveUsesTimezone(t *testing.T) {
+	schedule := Schedule{Timezone: "America/New_York", Start: "09:00", End: "17:00"}
+	assert.NoError(t, schedule.Validate())
+	// 13:00 UTC is 09:00 in New York during daylight saving time
+	assert.True(t, schedule.IsActive(mustParseTime(t, "2020-06-01T13:00:00Z")))
+	assert.False(t, schedule.IsActive(mustParseTime(t, "2020-06-01T10:00:00Z")))
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	assert.Error(t, (&Schedule{Timezone: "Mars/Olympus"}).Validate())
+	assert.Error(t, (&Schedule{Days: []string{"Someday"}}).Validate())
+	assert.Error(t, (&Schedule{Start: "9am"}).Validate())
+	assert.NoError(t, (&Schedule{Days: []string{"sat", "Sunday"}, Start: "10:00"}).Validate())
+}
@@ -0,0 +1,227 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/text"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// schemaTypeOverrides holds the types which have custom JSON serialization
+var schemaTypeOverrides = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(Subscription{}):    reflect.TypeOf(rawSubscription{}),
+	reflect.TypeOf(RecipientPolicy{}): reflect.TypeOf(rawRecipientPolicy{}),
+}
+
+// GenerateConfigSchema returns JSON schema of the config.yaml key of argocd-notifications-cm config map
+func GenerateConfigSchema() ([]byte, error) {
+	return generateSchema("argocd-notifications-cm config.yaml", reflect.TypeOf(Config{}))
+}
+
+// GenerateNotifiersSchema returns JSON schema of the notifiers.yaml key of argocd-notifications-secret secret
+func GenerateNotifiersSchema() ([]byte, error) {
+	return generateSchema("argocd-notifications-secret notifiers.yaml", reflect.TypeOf(notifiers.Config{}))
+}
+
+func generateSchema(title string, t reflect.Type) ([]byte, error) {
+	schema := typeSchema(t)
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = title
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func lowerFirst(s string) string {
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if override, ok := schemaTypeOverrides[t]; ok {
+		t = override
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		addStructProperties(t, properties)
+		return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func addStructProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		// embedded structs fields are serialized as the parent struct fields
+		if field.Anonymous && tag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			addStructProperties(embedded, properties)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		name := tag
+		if name == "" {
+			name = lowerFirst(field.Name)
+		}
+		properties[name] = typeSchema(field.Type)
+	}
+}
+
+// FieldError describes unknown field found in the configuration
+type FieldError struct {
+	Line  int
+	Field string
+	Path  string
+	// Suggestion is the known field with the most similar name; empty if there is no similar field
+	Suggestion string
+}
+
+func (e *FieldError) Error() string {
+	msg := fmt.Sprintf("line %d: unknown field \"%s\"", e.Line, e.Field)
+	if e.Path != "" {
+		msg += fmt.Sprintf(" in %s", e.Path)
+	}
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(", did you mean \"%s\"?", e.Suggestion)
+	}
+	return msg
+}
+
+var (
+	configSchema    map[string]interface{}
+	notifiersSchema map[string]interface{}
+	triggerSchema   map[string]interface{}
+	templateSchema  map[string]interface{}
+)
+
+func init() {
+	if err := json.Unmarshal([]byte(configSchemaJSON), &configSchema); err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal([]byte(notifiersSchemaJSON), &notifiersSchema); err != nil {
+		panic(err)
+	}
+	properties := configSchema["properties"].(map[string]interface{})
+	triggerSchema = properties["triggers"].(map[string]interface{})["items"].(map[string]interface{})
+	templateSchema = properties["templates"].(map[string]interface{})["items"].(map[string]interface{})
+}
+
+// validateFields returns an error if YAML document contains fields that are not defined in the schema
+func validateFields(data []byte, schema map[string]interface{}) error {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		// syntax errors are reported by the decoder
+		return nil
+	}
+	if len(node.Content) == 0 {
+		return nil
+	}
+	return validateNode(node.Content[0], schema, "")
+}
+
+func validateNode(node *yaml.Node, schema map[string]interface{}, path string) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		properties, hasProperties := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := key.Value
+			if path != "" {
+				fieldPath = path + "." + key.Value
+			}
+			if !hasProperties {
+				if additional != nil {
+					if err := validateNode(value, additional, fieldPath); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			fieldSchema, ok := findProperty(properties, key.Value)
+			if !ok {
+				return &FieldError{Line: key.Line, Field: key.Value, Path: path, Suggestion: suggestField(properties, key.Value)}
+			}
+			if err := validateNode(value, fieldSchema, fieldPath); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		items, ok := schema["items"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range node.Content {
+			if err := validateNode(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findProperty finds property schema by name; names are matched case-insensitively the same way as JSON decoder does
+func findProperty(properties map[string]interface{}, name string) (map[string]interface{}, bool) {
+	if schema, ok := properties[name]; ok {
+		res, _ := schema.(map[string]interface{})
+		return res, true
+	}
+	for k, schema := range properties {
+		if strings.EqualFold(k, name) {
+			res, _ := schema.(map[string]interface{})
+			return res, true
+		}
+	}
+	return nil, false
+}
+
+func suggestField(properties map[string]interface{}, name string) string {
+	var names []string
+	for k := range properties {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	suggestion := ""
+	best := len(name)/2 + 1
+	for _, k := range names {
+		if d := text.Distance(strings.ToLower(k), strings.ToLower(name)); d < best {
+			best = d
+			suggestion = k
+		}
+	}
+	return suggestion
+}
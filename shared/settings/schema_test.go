@@ -0,0 +1,62 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestEmbeddedSchemaIsUpToDate(t *testing.T) {
+	configSchema, err := GenerateConfigSchema()
+	assert.NoError(t, err)
+	assert.Equal(t, configSchemaJSON, string(configSchema), "run ./hack/schema.sh to regenerate schema")
+
+	notifiersSchema, err := GenerateNotifiersSchema()
+	assert.NoError(t, err)
+	assert.Equal(t, notifiersSchemaJSON, string(notifiersSchema), "run ./hack/schema.sh to regenerate schema")
+}
+
+func TestParseConfigMap_UnknownField(t *testing.T) {
+	_, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+triggers:
+- name: on-sync
+  condition: "true"
+  tempalte: app-sync`}})
+
+	assert.EqualError(t, err, `Failed to read config.yaml key from configmap: line 5: unknown field "tempalte" in triggers[0], did you mean "template"?`)
+}
+
+func TestParseConfigMap_UnknownSubscriptionField(t *testing.T) {
+	_, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+subscriptions:
+- recipients: [slack:test]
+  schedule: {timezone: UTC, strat: "09:00"}`}})
+
+	assert.EqualError(t, err, `Failed to read config.yaml key from configmap: line 4: unknown field "strat" in subscriptions[0].schedule, did you mean "start"?`)
+}
+
+func TestParseConfigMap_UnknownTriggerKeyField(t *testing.T) {
+	_, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{
+		"trigger.on-sync": "condition: 'true'\nfoo: bar",
+	}})
+
+	assert.EqualError(t, err, `Failed to unmarshal trigger on-sync: line 2: unknown field "foo"`)
+}
+
+func TestParseConfigMap_FieldsAreCaseInsensitive(t *testing.T) {
+	cfg, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+subscriptions:
+- Recipients: [slack:test]`}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"slack:test"}, cfg.Subscriptions[0].Recipients)
+}
+
+func TestParseSecret_UnknownField(t *testing.T) {
+	_, err := ParseSecret(&v1.Secret{Data: map[string][]byte{"notifiers.yaml": []byte(`
+slack:
+  tokn: abc`)}})
+
+	assert.EqualError(t, err, `line 3: unknown field "tokn" in slack, did you mean "token"?`)
+}
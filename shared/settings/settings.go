@@ -3,6 +3,7 @@ package settings
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/argoproj-labs/argocd-notifications/notifiers"
@@ -12,15 +13,26 @@ import (
 
 	"github.com/ghodss/yaml"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 )
 
+var (
+	// triggersCache keeps compiled triggers between configuration reloads
+	triggersCache = triggers.NewCache()
+)
+
 type rawSubscription struct {
-	Recipients []string
-	Triggers   []string
-	Selector   string
+	Recipients []string  `json:"recipients,omitempty"`
+	Triggers   []string  `json:"triggers,omitempty"`
+	Selector   string    `json:"selector,omitempty"`
+	Projects   []string  `json:"projects,omitempty"`
+	Namespaces []string  `json:"namespaces,omitempty"`
+	Clusters   []string  `json:"clusters,omitempty"`
+	Exclude    string    `json:"exclude,omitempty"`
+	Schedule   *Schedule `json:"schedule,omitempty"`
 }
 
 // DefaultSubscription holds recipients that receives notification by default.
@@ -31,20 +43,64 @@ type Subscription struct {
 	Triggers []string
 	// Options label selector that limits applied applications
 	Selector labels.Selector
+	// Optional list of projects that limits applied applications
+	Projects []string
+	// Optional list of destination namespaces that limits applied applications
+	Namespaces []string
+	// Optional list of destination cluster URLs or names that limits applied applications
+	Clusters []string
+	// Optional label selector of applications that opt out of subscription
+	Exclude labels.Selector
+	// Optional schedule that limits when recipients are notified
+	Schedule *Schedule
 }
 
 func (s *Subscription) MatchesTrigger(trigger string) bool {
 	if len(s.Triggers) == 0 {
 		return true
 	}
-	for i := range s.Triggers {
-		if s.Triggers[i] == trigger {
+	return containsString(s.Triggers, trigger)
+}
+
+func containsString(items []string, item string) bool {
+	for i := range items {
+		if items[i] == item {
 			return true
 		}
 	}
 	return false
 }
 
+// MatchesApp returns true if application matches subscription selector, projects, namespaces and clusters
+func (s *Subscription) MatchesApp(app *unstructured.Unstructured) bool {
+	if s.Selector != nil && !s.Selector.Matches(fields.Set(app.GetLabels())) {
+		return false
+	}
+	if s.Exclude != nil && s.Exclude.Matches(fields.Set(app.GetLabels())) {
+		return false
+	}
+	if len(s.Projects) > 0 {
+		project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+		if !containsString(s.Projects, project) {
+			return false
+		}
+	}
+	if len(s.Namespaces) > 0 {
+		namespace, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
+		if !containsString(s.Namespaces, namespace) {
+			return false
+		}
+	}
+	if len(s.Clusters) > 0 {
+		server, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "server")
+		name, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "name")
+		if !(server != "" && containsString(s.Clusters, server)) && !(name != "" && containsString(s.Clusters, name)) {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Subscription) UnmarshalJSON(data []byte) error {
 	raw := rawSubscription{}
 	if err := json.Unmarshal(data, &raw); err != nil {
@@ -52,11 +108,27 @@ func (s *Subscription) UnmarshalJSON(data []byte) error {
 	}
 	s.Triggers = raw.Triggers
 	s.Recipients = raw.Recipients
+	s.Projects = raw.Projects
+	s.Namespaces = raw.Namespaces
+	s.Clusters = raw.Clusters
+	if raw.Schedule != nil {
+		if err := raw.Schedule.Validate(); err != nil {
+			return err
+		}
+	}
+	s.Schedule = raw.Schedule
 	selector, err := labels.Parse(raw.Selector)
 	if err != nil {
 		return err
 	}
 	s.Selector = selector
+	if raw.Exclude != "" {
+		exclude, err := labels.Parse(raw.Exclude)
+		if err != nil {
+			return err
+		}
+		s.Exclude = exclude
+	}
 	return nil
 }
 
@@ -64,51 +136,207 @@ func (s *Subscription) MarshalJSON() ([]byte, error) {
 	raw := rawSubscription{
 		Triggers:   s.Triggers,
 		Recipients: s.Recipients,
+		Projects:   s.Projects,
+		Namespaces: s.Namespaces,
+		Clusters:   s.Clusters,
+		Schedule:   s.Schedule,
 	}
 	if s.Selector != nil {
 		raw.Selector = s.Selector.String()
 	}
+	if s.Exclude != nil {
+		raw.Exclude = s.Exclude.String()
+	}
 	return json.Marshal(raw)
 }
 
 type DefaultSubscriptions []Subscription
 
-// Returns list of recipients for the specified trigger
-func (subscriptions DefaultSubscriptions) GetRecipients(trigger string, labels map[string]string) []string {
+// Returns list of recipients for the specified trigger and application
+func (subscriptions DefaultSubscriptions) GetRecipients(trigger string, app *unstructured.Unstructured) []string {
 	var result []string
 	for _, s := range subscriptions {
-		if s.MatchesTrigger(trigger) && s.Selector.Matches(fields.Set(labels)) {
+		if s.MatchesTrigger(trigger) && s.MatchesApp(app) {
 			result = append(result, s.Recipients...)
 		}
 	}
 	return result
 }
 
+// GetSchedule returns the schedule of the first matching subscription that includes the recipient.
+// Returns nil if recipient should be notified at any time.
+func (subscriptions DefaultSubscriptions) GetSchedule(trigger string, app *unstructured.Unstructured, recipient string) *Schedule {
+	for _, s := range subscriptions {
+		if s.Schedule == nil || !s.MatchesTrigger(trigger) || !s.MatchesApp(app) {
+			continue
+		}
+		for i := range s.Recipients {
+			if s.Recipients[i] == recipient {
+				return s.Schedule
+			}
+		}
+	}
+	return nil
+}
+
 type Config struct {
-	Triggers      []triggers.NotificationTrigger  `json:"triggers,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
-	Templates     []triggers.NotificationTemplate `json:"templates,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
-	Context       map[string]string               `json:"context,omitempty"`
-	Subscriptions DefaultSubscriptions            `json:"subscriptions,omitempty"`
+	Triggers          []triggers.NotificationTrigger  `json:"triggers,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	Templates         []triggers.NotificationTemplate `json:"templates,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	Context           map[string]string               `json:"context,omitempty"`
+	Subscriptions     DefaultSubscriptions            `json:"subscriptions,omitempty"`
+	Policies          RecipientPolicies               `json:"policies,omitempty"`
+	RateLimits        []RateLimit                     `json:"rateLimits,omitempty"`
+	Batching          []Batch                         `json:"batching,omitempty"`
+	EmailDigests      []EmailDigest                   `json:"emailDigests,omitempty"`
+	Escalations       []Escalation                    `json:"escalations,omitempty"`
+	AggregateTriggers []AggregateTrigger              `json:"aggregateTriggers,omitempty"`
 }
 
-// ParseSecret retrieves configured notification services from the provided secret
-func ParseSecret(secret *v1.Secret) (notifiersConfig notifiers.Config, err error) {
-	notifiersData := secret.Data["notifiers.yaml"]
+// ParseSecret retrieves configured notification services from the provided secret. Besides notifiers.yaml key the
+// secret might have notifiers.<service>.yaml keys with the settings of a single service, e.g. notifiers.slack.yaml.
+// The service specific keys take precedence over the notifiers.yaml sections. The ${NAME} references are replaced
+// with environment variables if the variables are enabled.
+func ParseSecret(secret *v1.Secret) (notifiers.Config, error) {
+	return parseSecret(secret, true, map[string]string{})
+}
+
+// parseSecret parses the secret and replaces ${NAME} references with the environment variables if env is true and
+// ${context.<key>} references with the provided context values if context is not nil
+func parseSecret(secret *v1.Secret, env bool, context map[string]string) (notifiersConfig notifiers.Config, err error) {
+	expandedData := map[string][]byte{}
+	for k, v := range secret.Data {
+		if k != notifiersKey {
+			if _, ok := parseServiceKey(k); !ok {
+				continue
+			}
+		}
+		expanded, err := expandYAML(string(v), env, context)
+		if err != nil {
+			return notifiers.Config{}, fmt.Errorf("key %s: %v", k, err)
+		}
+		expandedData[k] = []byte(expanded)
+	}
+	secret = &v1.Secret{ObjectMeta: secret.ObjectMeta, Data: expandedData}
+	notifiersData := secret.Data[notifiersKey]
+	if err = validateFields(notifiersData, notifiersSchema); err != nil {
+		return notifiers.Config{}, err
+	}
 	err = yaml.Unmarshal(notifiersData, &notifiersConfig)
 	if err != nil {
 		return notifiers.Config{}, err
 	}
-	return notifiersConfig, nil
+	var serviceKeys []string
+	for k := range secret.Data {
+		if _, ok := parseServiceKey(k); ok {
+			serviceKeys = append(serviceKeys, k)
+		}
+	}
+	if len(serviceKeys) == 0 {
+		return notifiersConfig, nil
+	}
+	sort.Strings(serviceKeys)
+	merged := map[string]interface{}{}
+	if len(notifiersData) > 0 {
+		if err = yaml.Unmarshal(notifiersData, &merged); err != nil {
+			return notifiers.Config{}, err
+		}
+	}
+	properties := notifiersSchema["properties"].(map[string]interface{})
+	for _, k := range serviceKeys {
+		service, _ := parseServiceKey(k)
+		schema, ok := findProperty(properties, service)
+		if !ok {
+			return notifiers.Config{}, fmt.Errorf("key %s has settings of unknown notification service '%s'", k, service)
+		}
+		if err = validateFields(secret.Data[k], schema); err != nil {
+			return notifiers.Config{}, fmt.Errorf("key %s: %v", k, err)
+		}
+		var serviceSettings interface{}
+		if err = yaml.Unmarshal(secret.Data[k], &serviceSettings); err != nil {
+			return notifiers.Config{}, fmt.Errorf("key %s: %v", k, err)
+		}
+		merged[service] = serviceSettings
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return notifiers.Config{}, err
+	}
+	notifiersConfig = notifiers.Config{}
+	err = json.Unmarshal(data, &notifiersConfig)
+	return notifiersConfig, err
+}
+
+// parseServiceKey returns service name of the notifiers.<service>.yaml key
+func parseServiceKey(key string) (string, bool) {
+	if key == notifiersKey || !strings.HasPrefix(key, notifiersKeyPrefix) || !strings.HasSuffix(key, notifiersKeySuffix) {
+		return "", false
+	}
+	service := strings.TrimSuffix(strings.TrimPrefix(key, notifiersKeyPrefix), notifiersKeySuffix)
+	return service, service != "" && !strings.Contains(service, ".")
 }
 
-// ParseSecret retrieves configured templates and triggers from the provided config map
+// MergeSecrets merges notifiers configuration of the additional secrets into provided config. The notifiers which are
+// already configured take precedence over the notifiers with the same type from the additional secrets.
+func MergeSecrets(cfg notifiers.Config, secrets ...*v1.Secret) (notifiers.Config, error) {
+	merged := map[string]json.RawMessage{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return cfg, err
+	}
+	if err = json.Unmarshal(data, &merged); err != nil {
+		return cfg, err
+	}
+	for _, secret := range secrets {
+		other, err := ParseSecret(secret)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to parse secret %s: %v", secret.Name, err)
+		}
+		data, err := json.Marshal(other)
+		if err != nil {
+			return cfg, err
+		}
+		fields := map[string]json.RawMessage{}
+		if err = json.Unmarshal(data, &fields); err != nil {
+			return cfg, err
+		}
+		for k, v := range fields {
+			if existing, ok := merged[k]; (!ok || string(existing) == "null") && string(v) != "null" {
+				merged[k] = v
+			}
+		}
+	}
+	data, err = json.Marshal(merged)
+	if err != nil {
+		return cfg, err
+	}
+	res := notifiers.Config{}
+	err = json.Unmarshal(data, &res)
+	return res, err
+}
+
+// ParseSecret retrieves configured templates and triggers from the provided config map. If the variables are enabled
+// the ${NAME} references are replaced with environment variables and ${context.<key>} references with the config.yaml
+// context values.
 func ParseConfigMap(configMap *v1.ConfigMap) (*Config, error) {
+	return parseConfigMap(configMap, true)
+}
+
+func parseConfigMap(configMap *v1.ConfigMap, expand bool) (*Config, error) {
 	root := &Config{}
 	cfg := &Config{}
+	// config.yaml context should be loaded first to expand references in all keys
+	context := map[string]string{}
+	configData, hasConfig := configMap.Data[configKey]
+	if hasConfig && expand {
+		var err error
+		if configData, err = expandConfigVariables(configData, context); err != nil {
+			return cfg, fmt.Errorf("Failed to read config.yaml key from configmap: %v", err)
+		}
+	}
 	// read all the keys in format of templates.%s and triggers.%s
 	// to create config
 	for k, v := range configMap.Data {
-		if k == "config.yaml" {
+		if k == configKey {
 			// config.yaml should be read at the end to create base templates and triggers
 			continue
 		}
@@ -116,6 +344,15 @@ func ParseConfigMap(configMap *v1.ConfigMap) (*Config, error) {
 		if strings.HasPrefix(k, "template") {
 			name := strings.Join(parts[1:], ".")
 			tmpl := triggers.NotificationTemplate{}
+			if expand {
+				var err error
+				if v, err = expandYAML(v, true, context); err != nil {
+					return root, fmt.Errorf("Failed to unmarshal template %s: %v", name, err)
+				}
+			}
+			if err := validateFields([]byte(v), templateSchema); err != nil {
+				return root, fmt.Errorf("Failed to unmarshal template %s: %v", name, err)
+			}
 			if err := yaml.Unmarshal([]byte(v), &tmpl); err != nil {
 				return root, fmt.Errorf("Failed to unmarshal template %s: %v", name, err)
 			}
@@ -127,6 +364,15 @@ func ParseConfigMap(configMap *v1.ConfigMap) (*Config, error) {
 		if strings.HasPrefix(k, "trigger") {
 			name := strings.Join(parts[1:], ".")
 			trigger := triggers.NotificationTrigger{}
+			if expand {
+				var err error
+				if v, err = expandYAML(v, true, context); err != nil {
+					return root, fmt.Errorf("Failed to unmarshal trigger %s: %v", name, err)
+				}
+			}
+			if err := validateFields([]byte(v), triggerSchema); err != nil {
+				return root, fmt.Errorf("Failed to unmarshal trigger %s: %v", name, err)
+			}
 			if err := yaml.Unmarshal([]byte(v), &trigger); err != nil {
 				return root, fmt.Errorf("Failed to unmarshal trigger %s: %v", name, err)
 			}
@@ -140,8 +386,11 @@ func ParseConfigMap(configMap *v1.ConfigMap) (*Config, error) {
 		continue
 
 	}
-	if data, ok := configMap.Data["config.yaml"]; ok {
-		err := yaml.Unmarshal([]byte(data), &cfg)
+	if hasConfig {
+		if err := validateFields([]byte(configData), configSchema); err != nil {
+			return cfg, fmt.Errorf("Failed to read config.yaml key from configmap: %v", err)
+		}
+		err := yaml.Unmarshal([]byte(configData), &cfg)
 		if err != nil {
 			return cfg, fmt.Errorf("Failed to read config.yaml key from configmap: %v", err)
 		}
@@ -149,6 +398,28 @@ func ParseConfigMap(configMap *v1.ConfigMap) (*Config, error) {
 	return cfg.Merge(root)
 }
 
+// expandConfigVariables expands environment variables of config.yaml, loads the context into the provided map and
+// then expands the context references
+func expandConfigVariables(data string, context map[string]string) (string, error) {
+	data, err := expandYAML(data, true, nil)
+	if err != nil {
+		return "", err
+	}
+	if !getVariablesEnabled() {
+		return data, nil
+	}
+	var contextOnly struct {
+		Context map[string]string `json:"context"`
+	}
+	// syntax errors are reported when the whole config is parsed
+	if err := yaml.Unmarshal([]byte(data), &contextOnly); err == nil {
+		for k, v := range contextOnly.Context {
+			context[k] = v
+		}
+	}
+	return expandYAML(data, false, context)
+}
+
 func (cfg *Config) Merge(other *Config) (*Config, error) {
 	origData, err := json.Marshal(cfg)
 	if err != nil {
@@ -172,23 +443,78 @@ func (cfg *Config) Merge(other *Config) (*Config, error) {
 	return res, nil
 }
 
+// MergeConfigMaps merges configuration of the additional config maps into provided config. The additional config maps
+// are merged in the order of names; in case of conflicts the provided config definitions take precedence. Subscriptions
+// of all config maps are combined. Recipient policies, rate limits, batching, email digests, escalations and aggregate
+// triggers are only read from the provided config.
+func MergeConfigMaps(cfg *Config, configMaps ...*v1.ConfigMap) (*Config, error) {
+	sorted := make([]*v1.ConfigMap, len(configMaps))
+	copy(sorted, configMaps)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	for _, cm := range sorted {
+		extraCfg, err := ParseConfigMap(cm)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config map %s: %v", cm.Name, err)
+		}
+		subscriptions := append(append(DefaultSubscriptions{}, cfg.Subscriptions...), extraCfg.Subscriptions...)
+		policies := cfg.Policies
+		rateLimits := cfg.RateLimits
+		batching := cfg.Batching
+		emailDigests := cfg.EmailDigests
+		escalations := cfg.Escalations
+		aggregateTriggers := cfg.AggregateTriggers
+		cfg, err = extraCfg.Merge(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge config map %s: %v", cm.Name, err)
+		}
+		cfg.Subscriptions = subscriptions
+		cfg.Policies = policies
+		cfg.RateLimits = rateLimits
+		cfg.Batching = batching
+		cfg.EmailDigests = emailDigests
+		cfg.Escalations = escalations
+		cfg.AggregateTriggers = aggregateTriggers
+	}
+	return cfg, nil
+}
+
 // ParseConfig parses notifications configuration from the provided config map and secret.
-func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret, defaultCfg Config, argocdService argocd.Service) (map[string]triggers.Trigger, map[string]notifiers.Notifier, *Config, error) {
+// The optional additional config maps and secrets are merged using MergeConfigMaps and MergeSecrets.
+func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret, defaultCfg Config, argocdService argocd.Service, extraConfigMaps []*v1.ConfigMap, extraSecrets []*v1.Secret) (map[string]triggers.Trigger, map[string]notifiers.Notifier, *Config, error) {
 	cfg, err := ParseConfigMap(configMap)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	cfg, err = MergeConfigMaps(cfg, extraConfigMaps...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	cfg, err = defaultCfg.Merge(cfg)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	t, err := triggers.GetTriggers(cfg.Templates, cfg.Triggers, argocdService)
+	t, err := triggersCache.GetTriggers(cfg.Templates, cfg.Triggers, argocdService)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	notifiersConfig, err := ParseSecret(secret)
+	notifiersConfig, err := ParseNotifiersConfig(secret, cfg.Context, extraSecrets...)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 	return t, notifiers.GetAll(notifiersConfig), cfg, nil
 }
+
+// ParseNotifiersConfig retrieves configured notification services from the secret and additional secrets. The
+// ${context.<key>} references are replaced with the provided context values.
+func ParseNotifiersConfig(secret *v1.Secret, context map[string]string, extraSecrets ...*v1.Secret) (notifiers.Config, error) {
+	if context == nil {
+		context = map[string]string{}
+	}
+	notifiersConfig, err := parseSecret(secret, true, context)
+	if err != nil {
+		return notifiers.Config{}, err
+	}
+	return MergeSecrets(notifiersConfig, extraSecrets...)
+}
@@ -5,10 +5,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/utils/pointer"
 
 	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
 	"github.com/argoproj-labs/argocd-notifications/triggers"
 )
 
@@ -416,7 +418,293 @@ func TestDefaultSubscriptions_GetRecipients(t *testing.T) {
 		Selector:   selector,
 	}})
 
-	assert.ElementsMatch(t, []string{"slack:test1", "slack:test2"}, subscriptions.GetRecipients("trigger1", map[string]string{}))
-	assert.ElementsMatch(t, []string{"slack:test1", "slack:test2", "slack:test3"}, subscriptions.GetRecipients("trigger2", map[string]string{}))
-	assert.ElementsMatch(t, []string{"slack:test1", "slack:test2", "slack:test4"}, subscriptions.GetRecipients("trigger3", map[string]string{"test": "true"}))
+	assert.ElementsMatch(t, []string{"slack:test1", "slack:test2"}, subscriptions.GetRecipients("trigger1", NewApp("test")))
+	assert.ElementsMatch(t, []string{"slack:test1", "slack:test2", "slack:test3"}, subscriptions.GetRecipients("trigger2", NewApp("test")))
+	assert.ElementsMatch(t, []string{"slack:test1", "slack:test2", "slack:test4"}, subscriptions.GetRecipients("trigger3", NewApp("test", WithLabels(map[string]string{"test": "true"}))))
+}
+
+func TestDefaultSubscriptions_GetSchedule(t *testing.T) {
+	schedule := &Schedule{Start: "09:00", End: "17:00"}
+	subscriptions := DefaultSubscriptions([]Subscription{{
+		Recipients: []string{"slack:test1"},
+		Selector:   labels.NewSelector(),
+	}, {
+		Recipients: []string{"slack:test2"},
+		Selector:   labels.NewSelector(),
+		Schedule:   schedule,
+	}})
+
+	assert.Nil(t, subscriptions.GetSchedule("trigger", NewApp("test"), "slack:test1"))
+	assert.Equal(t, schedule, subscriptions.GetSchedule("trigger", NewApp("test"), "slack:test2"))
+}
+
+func TestParseConfigMap_SubscriptionSchedule(t *testing.T) {
+	cfg, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+subscriptions:
+- recipients: [slack:test]
+  schedule:
+    timezone: Europe/Berlin
+    days: [Mon, Tue, Wed, Thu, Fri]
+    start: "09:00"
+    end: "17:00"
+    digest: true`}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &Schedule{
+		Timezone: "Europe/Berlin",
+		Days:     []string{"Mon", "Tue", "Wed", "Thu", "Fri"},
+		Start:    "09:00",
+		End:      "17:00",
+		Digest:   true,
+	}, cfg.Subscriptions[0].Schedule)
+
+	_, err = ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+subscriptions:
+- recipients: [slack:test]
+  schedule:
+    start: "9am"`}})
+	assert.Error(t, err)
+}
+
+func TestDefaultSubscriptions_GetRecipientsByProjectNamespaceAndCluster(t *testing.T) {
+	subscriptions := DefaultSubscriptions([]Subscription{{
+		Recipients: []string{"slack:team-a"},
+		Selector:   labels.NewSelector(),
+		Projects:   []string{"team-a"},
+	}, {
+		Recipients: []string{"slack:prod"},
+		Selector:   labels.NewSelector(),
+		Namespaces: []string{"prod"},
+		Clusters:   []string{"https://kubernetes.default.svc"},
+	}})
+
+	assert.ElementsMatch(t, []string{"slack:team-a"}, subscriptions.GetRecipients("trigger",
+		NewApp("test", WithProject("team-a"), WithDestination("https://remote", "prod"))))
+	assert.ElementsMatch(t, []string{"slack:prod"}, subscriptions.GetRecipients("trigger",
+		NewApp("test", WithProject("team-b"), WithDestination("https://kubernetes.default.svc", "prod"))))
+	assert.Empty(t, subscriptions.GetRecipients("trigger",
+		NewApp("test", WithProject("team-b"), WithDestination("https://kubernetes.default.svc", "dev"))))
+}
+
+func TestParseConfigMap_SubscriptionProjects(t *testing.T) {
+	cfg, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+subscriptions:
+- recipients: [slack:test]
+  projects: [team-a]
+  namespaces: [prod]
+  clusters: [in-cluster]`}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"team-a"}, cfg.Subscriptions[0].Projects)
+	assert.Equal(t, []string{"prod"}, cfg.Subscriptions[0].Namespaces)
+	assert.Equal(t, []string{"in-cluster"}, cfg.Subscriptions[0].Clusters)
+}
+
+func TestDefaultSubscriptions_GetRecipientsExclude(t *testing.T) {
+	cfg, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+subscriptions:
+- recipients: [slack:test]
+  exclude: notifications=disabled`}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.ElementsMatch(t, []string{"slack:test"}, cfg.Subscriptions.GetRecipients("trigger", NewApp("test")))
+	assert.Empty(t, cfg.Subscriptions.GetRecipients("trigger",
+		NewApp("test", WithLabels(map[string]string{"notifications": "disabled"}))))
+}
+
+func TestMergeConfigMaps(t *testing.T) {
+	cfg, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+subscriptions:
+- recipients: [slack:main]
+triggers:
+- name: shared
+  condition: "true"
+  template: main`}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	merged, err := MergeConfigMaps(cfg, &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-notifications-cm-team-b"},
+		Data: map[string]string{
+			"trigger.shared":          `{condition: "false", template: team-b}`,
+			"trigger.on-team-b-event": `{condition: "true", template: team-b}`,
+			"config.yaml":             `subscriptions: [{recipients: [slack:team-b]}]`,
+		},
+	}, &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-notifications-cm-team-a"},
+		Data: map[string]string{
+			"config.yaml": `subscriptions: [{recipients: [slack:team-a]}]`,
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.ElementsMatch(t, []triggers.NotificationTrigger{{
+		Name:      "shared",
+		Condition: "true",
+		Template:  "main",
+	}, {
+		Name:      "on-team-b-event",
+		Condition: "true",
+		Template:  "team-b",
+	}}, merged.Triggers)
+	var subscriptions []string
+	for _, s := range merged.Subscriptions {
+		subscriptions = append(subscriptions, s.Recipients...)
+	}
+	assert.Equal(t, []string{"slack:main", "slack:team-a", "slack:team-b"}, subscriptions)
+}
+
+func TestMergeSecrets(t *testing.T) {
+	cfg := notifiers.Config{Slack: &notifiers.SlackOptions{Token: "main"}}
+
+	merged, err := MergeSecrets(cfg, &v1.Secret{Data: map[string][]byte{
+		"notifiers.yaml": []byte(`{slack: {token: other}, webhook: [{name: github, url: https://api.github.com}]}`),
+	}})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "main", merged.Slack.Token)
+	if assert.NotNil(t, merged.Webhook) {
+		assert.Len(t, *merged.Webhook, 1)
+	}
+}
+
+func TestRecipientPolicies(t *testing.T) {
+	cfg, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{"config.yaml": `
+policies:
+- projects: [team-a]
+  recipients: ["slack:team-a-*", "email:*@team-a.com"]
+- selector: team=b
+  recipients: ["slack:team-b-*"]`}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	teamAApp := NewApp("test", WithProject("team-a"))
+	assert.True(t, cfg.Policies.IsAllowed(teamAApp, "slack:team-a-alerts"))
+	assert.True(t, cfg.Policies.IsAllowed(teamAApp, "email:dev@team-a.com"))
+	assert.False(t, cfg.Policies.IsAllowed(teamAApp, "slack:team-b-alerts"))
+
+	teamBApp := NewApp("test", WithLabels(map[string]string{"team": "b"}))
+	assert.True(t, cfg.Policies.IsAllowed(teamBApp, "slack:team-b-alerts"))
+	assert.False(t, cfg.Policies.IsAllowed(teamBApp, "slack:team-a-alerts"))
+
+	otherApp := NewApp("test", WithProject("other"))
+	assert.True(t, cfg.Policies.IsAllowed(otherApp, "slack:team-a-alerts"))
+}
+
+func TestMergeConfigMaps_IgnoresAdditionalPolicies(t *testing.T) {
+	cfg, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	merged, err := MergeConfigMaps(cfg, &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-notifications-cm-team-a"},
+		Data:       map[string]string{"config.yaml": `policies: [{recipients: ["slack:*"]}]`},
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, merged.Policies)
+}
+
+func TestParseSecret_ServiceKeys(t *testing.T) {
+	cfg, err := ParseSecret(&v1.Secret{Data: map[string][]byte{
+		"notifiers.yaml":         []byte(`{slack: {token: old}, email: {host: smtp.example.com}}`),
+		"notifiers.slack.yaml":   []byte(`token: new`),
+		"notifiers.webhook.yaml": []byte(`[{name: github, url: https://api.github.com}]`),
+	}})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "new", cfg.Slack.Token)
+	assert.Equal(t, "smtp.example.com", cfg.Email.Host)
+	if assert.NotNil(t, cfg.Webhook) {
+		assert.Equal(t, "github", (*cfg.Webhook)[0].Name)
+	}
+}
+
+func TestParseSecret_UnknownServiceKey(t *testing.T) {
+	_, err := ParseSecret(&v1.Secret{Data: map[string][]byte{
+		"notifiers.slak.yaml": []byte(`token: abc`),
+	}})
+
+	assert.EqualError(t, err, "key notifiers.slak.yaml has settings of unknown notification service 'slak'")
+}
+
+func TestParseConfigMap_Variables(t *testing.T) {
+	defer withVariables(t, map[string]string{VariableEnvPrefix + "TEST_ARGOCD_URL": "https://argocd.example.com"})()
+
+	cfg, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{
+		"config.yaml": `
+context:
+  argocdUrl: ${TEST_ARGOCD_URL}
+  environment: ${TEST_ENVIRONMENT:-staging}
+subscriptions:
+- recipients:
+  - slack:${context.environment}-deploys
+`,
+		"template.my-template": `title: "${context.environment}: {{.app.metadata.name}} synced"`,
+	}})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]string{"argocdUrl": "https://argocd.example.com", "environment": "staging"}, cfg.Context)
+	assert.Equal(t, []string{"slack:staging-deploys"}, cfg.Subscriptions[0].Recipients)
+	assert.Equal(t, "staging: {{.app.metadata.name}} synced", cfg.Templates[0].Title)
+}
+
+func TestParseConfigMap_UndefinedVariable(t *testing.T) {
+	defer withVariables(t, nil)()
+
+	_, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{
+		"config.yaml": `context: {argocdUrl: "${TEST_UNDEFINED_VARIABLE}"}`,
+	}})
+
+	assert.EqualError(t, err, "Failed to read config.yaml key from configmap: variable 'TEST_UNDEFINED_VARIABLE' is not defined")
+}
+
+func TestParseConfig_SecretVariables(t *testing.T) {
+	defer withVariables(t, map[string]string{VariableEnvPrefix + "TEST_SLACK_TOKEN": "abc"})()
+
+	_, _, _, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"config.yaml": `context: {smtpHost: smtp.example.com}`,
+	}}, &v1.Secret{Data: map[string][]byte{
+		"notifiers.yaml": []byte(`{slack: {token: "${TEST_SLACK_TOKEN}"}, email: {host: "${context.smtpHost}"}}`),
+	}}, Config{}, nil, nil, nil)
+	assert.NoError(t, err)
+
+	cfg, err := parseSecret(&v1.Secret{Data: map[string][]byte{
+		"notifiers.yaml": []byte(`{slack: {token: "${TEST_SLACK_TOKEN}"}, email: {host: "${context.smtpHost}"}}`),
+	}}, true, map[string]string{"smtpHost": "smtp.example.com"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "abc", cfg.Slack.Token)
+	assert.Equal(t, "smtp.example.com", cfg.Email.Host)
+}
+
+func TestParseConfigMap_VariablesDisabled(t *testing.T) {
+	cfg, err := ParseConfigMap(&v1.ConfigMap{Data: map[string]string{
+		"template.my-template": `body: "cost: ${{.app.metadata.annotations.cost}}"`,
+		"config.yaml":          `context: {argocdUrl: "${TEST_UNDEFINED_VARIABLE}"}`,
+	}})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]string{"argocdUrl": "${TEST_UNDEFINED_VARIABLE}"}, cfg.Context)
+	assert.Equal(t, "cost: ${{.app.metadata.annotations.cost}}", cfg.Templates[0].Body)
 }
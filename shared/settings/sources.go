@@ -0,0 +1,182 @@
+package settings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	checksumFragmentPrefix = "sha256="
+	notifiersKey           = "notifiers.yaml"
+	notifiersKeyPrefix     = "notifiers."
+	notifiersKeySuffix     = ".yaml"
+	configKey              = "config.yaml"
+)
+
+// Source loads configuration from outside of the Kubernetes API. The returned config map and secret use the same
+// format as argocd-notifications-cm and argocd-notifications-secret; the secret might be nil.
+type Source interface {
+	Name() string
+	Load() (*v1.ConfigMap, *v1.Secret, error)
+}
+
+// WatchableSource is a source that notifies about configuration changes, so it can be reloaded without waiting for
+// the refresh interval
+type WatchableSource interface {
+	Source
+	Watch(stop <-chan struct{}, onChange func()) error
+}
+
+// NewDirSource returns source that reads configuration from directory files. The config map keys such as config.yaml,
+// trigger.<name> and template.<name> should be stored in separate files, the notifiers.yaml and notifiers.<service>.yaml
+// files are loaded into secret. The layout matches the config map, secret and Secrets Store CSI driver volume mounts.
+func NewDirSource(dir string) Source {
+	return &dirSource{dir: dir}
+}
+
+type dirSource struct {
+	dir string
+}
+
+func (s *dirSource) Name() string {
+	return "dir:" + s.dir
+}
+
+func (s *dirSource) Load() (*v1.ConfigMap, *v1.Secret, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	cm := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: s.Name()}, Data: map[string]string{}}
+	var secret *v1.Secret
+	for _, f := range files {
+		// skip hidden files including ..data directory created by the Kubernetes volume mounts
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		filePath := filepath.Join(s.dir, f.Name())
+		// volume mounts use symlinks so stat the target to find out if it is a regular file
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := parseServiceKey(f.Name()); ok || f.Name() == notifiersKey {
+			if secret == nil {
+				secret = &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: s.Name()}, Data: map[string][]byte{}}
+			}
+			secret.Data[f.Name()] = data
+			continue
+		}
+		cm.Data[f.Name()] = string(data)
+	}
+	return cm, secret, nil
+}
+
+// Watch notifies about directory changes until stop channel is closed
+func (s *dirSource) Watch(stop <-chan struct{}, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err = watcher.Add(s.dir); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	go func() {
+		defer func() {
+			_ = watcher.Close()
+		}()
+		for {
+			select {
+			case <-stop:
+				return
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				onChange()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warnf("Failed to watch directory %s: %v", s.dir, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// NewURLSource returns source that downloads config.yaml formatted configuration from the specified URL. If URL has
+// fragment in format #sha256=<hex-checksum> then the downloaded content checksum is verified.
+func NewURLSource(rawURL string) (Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("config URL %s should use http or https scheme", rawURL)
+	}
+	var checksum string
+	if u.Fragment != "" {
+		if !strings.HasPrefix(u.Fragment, checksumFragmentPrefix) {
+			return nil, fmt.Errorf("config URL %s fragment should be in format #sha256=<checksum>", rawURL)
+		}
+		checksum = strings.ToLower(strings.TrimPrefix(u.Fragment, checksumFragmentPrefix))
+		u.Fragment = ""
+	}
+	return &urlSource{url: u.String(), checksum: checksum, client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+type urlSource struct {
+	url      string
+	checksum string
+	client   *http.Client
+}
+
+func (s *urlSource) Name() string {
+	return "url:" + s.url
+}
+
+func (s *urlSource) Load() (*v1.ConfigMap, *v1.Secret, error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to download %s: unexpected status code %d", s.url, resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.checksum != "" {
+		sum := sha256.Sum256(data)
+		if actual := hex.EncodeToString(sum[:]); actual != s.checksum {
+			return nil, nil, fmt.Errorf("checksum of %s does not match: expected %s, got %s", s.url, s.checksum, actual)
+		}
+	}
+	return &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: s.Name()}, Data: map[string]string{configKey: string(data)}}, nil, nil
+}
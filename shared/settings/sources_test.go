@@ -0,0 +1,103 @@
+package settings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("context: {}"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notifiers.yaml"), []byte("slack: {token: abc}"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notifiers.email.yaml"), []byte("host: smtp.example.com"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("hidden"), 0644))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "..data"), 0755))
+
+	cm, secret, err := NewDirSource(dir).Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"config.yaml": "context: {}"}, cm.Data)
+	assert.Equal(t, "slack: {token: abc}", string(secret.Data["notifiers.yaml"]))
+	assert.Equal(t, "host: smtp.example.com", string(secret.Data["notifiers.email.yaml"]))
+}
+
+func TestDirSource_Watch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	stop := make(chan struct{})
+	defer close(stop)
+	changed := make(chan struct{}, 10)
+	err = NewDirSource(dir).(WatchableSource).Watch(stop, func() {
+		changed <- struct{}{}
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("context: {}"), 0644))
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("directory change has not been detected")
+	}
+}
+
+func TestURLSource(t *testing.T) {
+	content := "triggers: []"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+
+	t.Run("NoChecksum", func(t *testing.T) {
+		source, err := NewURLSource(server.URL)
+		assert.NoError(t, err)
+		cm, secret, err := source.Load()
+		assert.NoError(t, err)
+		assert.Nil(t, secret)
+		assert.Equal(t, content, cm.Data["config.yaml"])
+	})
+
+	t.Run("ValidChecksum", func(t *testing.T) {
+		source, err := NewURLSource(server.URL + "#sha256=" + checksum)
+		assert.NoError(t, err)
+		cm, _, err := source.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, content, cm.Data["config.yaml"])
+	})
+
+	t.Run("InvalidChecksum", func(t *testing.T) {
+		source, err := NewURLSource(server.URL + "#sha256=abc")
+		assert.NoError(t, err)
+		_, _, err = source.Load()
+		assert.Error(t, err)
+	})
+
+	t.Run("InvalidScheme", func(t *testing.T) {
+		_, err := NewURLSource("file:///etc/config.yaml")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,137 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	// VariableEnvPrefix is the prefix of the environment variables which values are available as ${NAME} references
+	VariableEnvPrefix = "ARGOCD_NOTIFICATIONS_VAR_"
+
+	contextVariablePrefix = "context."
+	escapedVariable       = "$${"
+)
+
+// variableRegex matches escaped $${ sequence and ${NAME} or ${NAME:-default} variable references
+var variableRegex = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_.\-]*)(:-([^}]*))?\}`)
+
+var (
+	variablesEnabled     bool
+	variablesEnabledLock sync.RWMutex
+)
+
+// SetVariablesEnabled enables expansion of the ${NAME} and ${context.<key>} references in the configuration; the
+// references are kept as is if the expansion is disabled
+func SetVariablesEnabled(enabled bool) {
+	variablesEnabledLock.Lock()
+	defer variablesEnabledLock.Unlock()
+	variablesEnabled = enabled
+}
+
+func getVariablesEnabled() bool {
+	variablesEnabledLock.RLock()
+	defer variablesEnabledLock.RUnlock()
+	return variablesEnabled
+}
+
+// expandYAML expands the variable references in the string values of the YAML document, so the expanded values
+// never change the document structure. The document is returned unchanged if the expansion is disabled. Syntax errors
+// are left to be reported when the document is parsed.
+func expandYAML(data string, env bool, context map[string]string) (string, error) {
+	if !getVariablesEnabled() || !strings.Contains(data, "${") {
+		return data, nil
+	}
+	jsonData, err := yaml.YAMLToJSON([]byte(data))
+	if err != nil {
+		return data, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+	var doc interface{}
+	if err = decoder.Decode(&doc); err != nil {
+		return data, nil
+	}
+	if doc, err = expandValue(doc, env, context); err != nil {
+		return "", err
+	}
+	if jsonData, err = json.Marshal(doc); err != nil {
+		return "", err
+	}
+	res, err := yaml.JSONToYAML(jsonData)
+	return string(res), err
+}
+
+func expandValue(val interface{}, env bool, context map[string]string) (interface{}, error) {
+	switch val := val.(type) {
+	case string:
+		return expandVariables(val, env, context)
+	case map[string]interface{}:
+		for k, v := range val {
+			expanded, err := expandValue(v, env, context)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = expanded
+		}
+	case []interface{}:
+		for i, v := range val {
+			expanded, err := expandValue(v, env, context)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = expanded
+		}
+	}
+	return val, nil
+}
+
+// expandVariables replaces ${NAME} references with the ARGOCD_NOTIFICATIONS_VAR_<NAME> environment variables if env
+// is true and ${context.<key>} references with the context values if context is not nil. The ${NAME:-default} form
+// provides value of undefined variable. References which are not resolved and $${ escape sequences are left unchanged
+// unless context is provided, so the content can be expanded in several passes.
+func expandVariables(data string, env bool, context map[string]string) (string, error) {
+	var err error
+	res := variableRegex.ReplaceAllStringFunc(data, func(match string) string {
+		if err != nil {
+			return match
+		}
+		if match == escapedVariable {
+			if context == nil {
+				return match
+			}
+			return "${"
+		}
+		groups := variableRegex.FindStringSubmatch(match)
+		name, hasDefault, defaultVal := groups[1], groups[2] != "", groups[3]
+		var val string
+		var ok bool
+		if strings.HasPrefix(name, contextVariablePrefix) {
+			if context == nil {
+				return match
+			}
+			val, ok = context[strings.TrimPrefix(name, contextVariablePrefix)]
+		} else {
+			if !env {
+				return match
+			}
+			val, ok = os.LookupEnv(VariableEnvPrefix + name)
+		}
+		if !ok {
+			if !hasDefault {
+				err = fmt.Errorf("variable '%s' is not defined", name)
+				return match
+			}
+			val = defaultVal
+		}
+		return val
+	})
+	return res, err
+}
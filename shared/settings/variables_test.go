@@ -0,0 +1,76 @@
+package settings
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withVariables(t *testing.T, env map[string]string) func() {
+	SetVariablesEnabled(true)
+	for k, v := range env {
+		assert.NoError(t, os.Setenv(k, v))
+	}
+	return func() {
+		SetVariablesEnabled(false)
+		for k := range env {
+			_ = os.Unsetenv(k)
+		}
+	}
+}
+
+func TestExpandVariables(t *testing.T) {
+	defer withVariables(t, map[string]string{VariableEnvPrefix + "TEST_ENV": "staging"})()
+
+	res, err := expandVariables("${TEST_ENV} ${TEST_MISSING:-default} ${context.url} $${ESCAPED} {{.app}}",
+		true, map[string]string{"url": "https://example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "staging default https://example.com ${ESCAPED} {{.app}}", res)
+}
+
+func TestExpandVariables_Passes(t *testing.T) {
+	defer withVariables(t, map[string]string{VariableEnvPrefix + "TEST_ENV": "staging"})()
+
+	res, err := expandVariables("${TEST_ENV} ${context.url} $${ESCAPED}", true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "staging ${context.url} $${ESCAPED}", res)
+
+	res, err = expandVariables(res, false, map[string]string{"url": "https://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "staging https://example.com ${ESCAPED}", res)
+}
+
+func TestExpandVariables_Undefined(t *testing.T) {
+	_, err := expandVariables("${TEST_MISSING}", true, nil)
+	assert.EqualError(t, err, "variable 'TEST_MISSING' is not defined")
+
+	_, err = expandVariables("${context.missing}", false, map[string]string{})
+	assert.EqualError(t, err, "variable 'context.missing' is not defined")
+}
+
+func TestExpandVariables_UnprefixedEnv(t *testing.T) {
+	defer withVariables(t, map[string]string{"TEST_SECRET": "abc"})()
+
+	_, err := expandVariables("${TEST_SECRET}", true, nil)
+	assert.EqualError(t, err, "variable 'TEST_SECRET' is not defined")
+}
+
+func TestExpandYAML_StringValues(t *testing.T) {
+	defer withVariables(t, map[string]string{VariableEnvPrefix + "TEST_CHANNEL": "ops\nsend: [all]"})()
+
+	res, err := expandYAML("recipient: ${TEST_CHANNEL}\nport: 587\n", true, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "port: 587\nrecipient: |-\n  ops\n  send: [all]\n", res)
+}
+
+func TestExpandYAML_Disabled(t *testing.T) {
+	data := "condition: app.metadata.annotations['${NAME}'] == 'true'\n"
+
+	res, err := expandYAML(data, true, map[string]string{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, data, res)
+}
@@ -0,0 +1,193 @@
+package silences
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	namePrefix = "silence-"
+)
+
+var (
+	// NotificationSilenceResource is the NotificationSilence custom resource
+	NotificationSilenceResource = schema.GroupVersionResource{Group: "argocd-notifications.argoproj.io", Version: "v1alpha1", Resource: "notificationsilences"}
+)
+
+// Silence suppresses notifications of the matching application, trigger and recipient until it expires. Empty
+// fields match any value.
+type Silence struct {
+	// Name is the name of the NotificationSilence resource
+	Name string `json:"-"`
+	// App is the application in [<namespace>/]<name> format
+	App       string `json:"app,omitempty"`
+	Trigger   string `json:"trigger,omitempty"`
+	Recipient string `json:"recipient,omitempty"`
+	// ExpiresAt is the time when notifications are no longer suppressed
+	ExpiresAt time.Time `json:"expiresAt"`
+	// CreatedBy is the user who created the silence, e.g. slack:<user id>
+	CreatedBy string `json:"createdBy,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+	// NotifyOnExpiry requests the notification of the recipient when the silence expires
+	NotifyOnExpiry bool `json:"notifyOnExpiry,omitempty"`
+}
+
+// IsActive returns true if the silence is not expired at the specified time
+func (s Silence) IsActive(now time.Time) bool {
+	return now.Before(s.ExpiresAt)
+}
+
+// Matches returns true if the silence suppresses the notification of the application trigger sent to the recipient
+func (s Silence) Matches(appNamespace string, appName string, trigger string, recipient string) bool {
+	if s.App != "" {
+		parts := strings.SplitN(s.App, "/", 2)
+		if len(parts) == 2 && (parts[0] != appNamespace || parts[1] != appName) || len(parts) == 1 && parts[0] != appName {
+			return false
+		}
+	}
+	return (s.Trigger == "" || s.Trigger == trigger) && (s.Recipient == "" || s.Recipient == recipient)
+}
+
+// Store persists silences
+type Store interface {
+	// Create saves the silence and returns it with the assigned name
+	Create(silence Silence) (Silence, error)
+	// Delete removes the silence with the specified name
+	Delete(name string) error
+	// List returns all stored silences including expired ones
+	List() ([]Silence, error)
+}
+
+// Matcher finds the silence of the notification
+type Matcher interface {
+	// Find returns the active silence which suppresses the notification or nil
+	Find(appNamespace string, appName string, trigger string, recipient string) *Silence
+}
+
+// NewCRDStore returns store that keeps silences as NotificationSilence custom resources in the specified namespace
+func NewCRDStore(client dynamic.Interface, namespace string) Store {
+	return &crdStore{client: client.Resource(NotificationSilenceResource).Namespace(namespace)}
+}
+
+type crdStore struct {
+	client dynamic.ResourceInterface
+}
+
+func newName() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return namePrefix + hex.EncodeToString(id)
+}
+
+func (s *crdStore) Create(silence Silence) (Silence, error) {
+	data, err := json.Marshal(silence)
+	if err != nil {
+		return silence, err
+	}
+	spec := map[string]interface{}{}
+	if err = json.Unmarshal(data, &spec); err != nil {
+		return silence, err
+	}
+	silence.Name = newName()
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion(NotificationSilenceResource.GroupVersion().String())
+	obj.SetKind("NotificationSilence")
+	obj.SetName(silence.Name)
+	_, err = s.client.Create(obj, metav1.CreateOptions{})
+	return silence, err
+}
+
+func (s *crdStore) Delete(name string) error {
+	return s.client.Delete(name, &metav1.DeleteOptions{})
+}
+
+func (s *crdStore) List() ([]Silence, error) {
+	list, err := s.client.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Silence, 0)
+	for i := range list.Items {
+		silence, err := fromUnstructured(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *silence)
+	}
+	return res, nil
+}
+
+func fromUnstructured(obj *unstructured.Unstructured) (*Silence, error) {
+	spec, ok := obj.Object["spec"]
+	if !ok {
+		return nil, fmt.Errorf("notification silence %s has no spec", obj.GetName())
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	var silence Silence
+	if err = json.Unmarshal(data, &silence); err != nil {
+		return nil, fmt.Errorf("failed to parse notification silence %s: %v", obj.GetName(), err)
+	}
+	silence.Name = obj.GetName()
+	return &silence, nil
+}
+
+// NewInformer returns informer of the NotificationSilence resources in the specified namespace
+func NewInformer(client dynamic.Interface, namespace string, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	resourceClient := client.Resource(NotificationSilenceResource).Namespace(namespace)
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return resourceClient.List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return resourceClient.Watch(options)
+			},
+		},
+		&unstructured.Unstructured{},
+		resyncPeriod,
+		cache.Indexers{},
+	)
+}
+
+// NewInformerMatcher returns matcher of the silences cached by the informer
+func NewInformerMatcher(informer cache.SharedIndexInformer) Matcher {
+	return &informerMatcher{informer: informer, now: time.Now}
+}
+
+type informerMatcher struct {
+	informer cache.SharedIndexInformer
+	now      func() time.Time
+}
+
+func (m *informerMatcher) Find(appNamespace string, appName string, trigger string, recipient string) *Silence {
+	now := m.now()
+	for _, obj := range m.informer.GetStore().List() {
+		un, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		silence, err := fromUnstructured(un)
+		if err != nil || !silence.IsActive(now) {
+			continue
+		}
+		if silence.Matches(appNamespace, appName, trigger, recipient) {
+			return silence
+		}
+	}
+	return nil
+}
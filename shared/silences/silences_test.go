@@ -0,0 +1,64 @@
+package silences
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestSilence_Matches(t *testing.T) {
+	assert.True(t, Silence{}.Matches("argocd", "guestbook", "on-sync-failed", "slack:alerts"))
+	assert.True(t, Silence{App: "guestbook"}.Matches("argocd", "guestbook", "on-sync-failed", "slack:alerts"))
+	assert.True(t, Silence{App: "argocd/guestbook", Trigger: "on-sync-failed"}.Matches("argocd", "guestbook", "on-sync-failed", "slack:alerts"))
+	assert.False(t, Silence{App: "default/guestbook"}.Matches("argocd", "guestbook", "on-sync-failed", "slack:alerts"))
+	assert.False(t, Silence{App: "other"}.Matches("argocd", "guestbook", "on-sync-failed", "slack:alerts"))
+	assert.False(t, Silence{Trigger: "on-deployed"}.Matches("argocd", "guestbook", "on-sync-failed", "slack:alerts"))
+	assert.False(t, Silence{Recipient: "slack:general"}.Matches("argocd", "guestbook", "on-sync-failed", "slack:alerts"))
+}
+
+func TestCRDStore(t *testing.T) {
+	store := NewCRDStore(fake.NewSimpleDynamicClient(runtime.NewScheme()), "default")
+	silence, err := store.Create(Silence{
+		App:       "guestbook",
+		Trigger:   "on-sync-failed",
+		ExpiresAt: time.Now().UTC().Add(time.Hour).Truncate(time.Second),
+		CreatedBy: "slack:U123",
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, silence.Name)
+
+	items, err := store.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []Silence{silence}, items)
+
+	assert.NoError(t, store.Delete(silence.Name))
+	items, err = store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestInformerMatcher(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	store := NewCRDStore(client, "default")
+	now := time.Now()
+	active, err := store.Create(Silence{App: "guestbook", ExpiresAt: now.Add(time.Hour)})
+	assert.NoError(t, err)
+	_, err = store.Create(Silence{Trigger: "on-deployed", ExpiresAt: now.Add(-time.Hour)})
+	assert.NoError(t, err)
+
+	informer := NewInformer(client, "default", 0)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go informer.Run(stopCh)
+	assert.True(t, cache.WaitForCacheSync(stopCh, informer.HasSynced))
+	matcher := NewInformerMatcher(informer)
+
+	if silence := matcher.Find("argocd", "guestbook", "on-sync-failed", "slack:alerts"); assert.NotNil(t, silence) {
+		assert.Equal(t, active.Name, silence.Name)
+	}
+	assert.Nil(t, matcher.Find("argocd", "other", "on-deployed", "slack:alerts"))
+}
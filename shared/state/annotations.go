@@ -0,0 +1,52 @@
+package state
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// NewAnnotationStore returns store that keeps the state in the application annotations. The Save method only updates
+// the annotations of the provided application object, so the caller is responsible for the application update.
+func NewAnnotationStore(appClient dynamic.ResourceInterface) Store {
+	return &annotationStore{appClient: appClient}
+}
+
+type annotationStore struct {
+	appClient dynamic.ResourceInterface
+}
+
+func (s *annotationStore) Load(app *unstructured.Unstructured, refresh bool) (State, error) {
+	if refresh {
+		// informer might have stale data, so load the latest application state
+		refreshedApp, err := s.appClient.Get(app.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		app = refreshedApp
+	}
+	res := State{}
+	for k, v := range app.GetAnnotations() {
+		if isStateEntry(k, v) {
+			res[k] = v
+		}
+	}
+	return res, nil
+}
+
+func (s *annotationStore) Save(app *unstructured.Unstructured, state State) error {
+	annotations := map[string]string{}
+	for k, v := range app.GetAnnotations() {
+		if !isStateEntry(k, v) {
+			annotations[k] = v
+		}
+	}
+	for k, v := range state {
+		annotations[k] = v
+	}
+	if len(annotations) == 0 && app.GetAnnotations() == nil {
+		return nil
+	}
+	app.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,64 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/clients"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestAnnotationStore(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	key := Key("on-sync", "slack:alerts")
+	app := NewApp("guestbook", WithAnnotations(map[string]string{
+		recipients.RecipientsAnnotation: "slack:alerts",
+		key:                             now,
+	}))
+	store := NewAnnotationStore(clients.NewAppClient(fake.NewSimpleDynamicClient(runtime.NewScheme(), app), TestNamespace))
+
+	appState, err := store.Load(app, false)
+	assert.NoError(t, err)
+	assert.Equal(t, State{key: now}, appState)
+
+	delete(appState, key)
+	appState[Key("on-deployed", "email:sre")] = now
+	assert.NoError(t, store.Save(app, appState))
+	assert.Equal(t, map[string]string{
+		recipients.RecipientsAnnotation: "slack:alerts",
+		Key("on-deployed", "email:sre"): now,
+	}, app.GetAnnotations())
+}
+
+func TestAnnotationStore_Refresh(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	key := Key("on-sync", "slack:alerts")
+	app := NewApp("guestbook", WithAnnotations(map[string]string{key: now}))
+	store := NewAnnotationStore(clients.NewAppClient(fake.NewSimpleDynamicClient(runtime.NewScheme(), app), TestNamespace))
+
+	appState, err := store.Load(NewApp("guestbook"), true)
+	assert.NoError(t, err)
+	assert.Equal(t, State{key: now}, appState)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	app := NewApp("guestbook")
+
+	appState, err := store.Load(app, false)
+	assert.NoError(t, err)
+	assert.Empty(t, appState)
+
+	appState["a"] = "2020-01-01T00:00:00Z"
+	assert.NoError(t, store.Save(app, appState))
+	appState["b"] = "2020-01-01T00:00:00Z"
+
+	loaded, err := store.Load(app, true)
+	assert.NoError(t, err)
+	assert.Equal(t, State{"a": "2020-01-01T00:00:00Z"}, loaded)
+}
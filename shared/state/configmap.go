@@ -0,0 +1,124 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	configMapNamePrefix   = "argocd-notifications-state-"
+	configMapStateKey     = "state.json"
+	labelPrefix           = "argocd-notifications.argoproj.io/"
+	configMapAppLabel     = labelPrefix + "app"
+	configMapAppNamespace = labelPrefix + "app-namespace"
+	typeLabel             = labelPrefix + "type"
+	typeLabelValue        = "state"
+)
+
+// NewConfigMapStore returns store that keeps the state of every application in a separate ConfigMap in the
+// specified namespace, so application objects are not modified by the controller. Loaded states are cached in memory.
+func NewConfigMapStore(clientset kubernetes.Interface, namespace string) Store {
+	return &configMapStore{clientset: clientset, namespace: namespace, cache: map[string]State{}}
+}
+
+type configMapStore struct {
+	clientset kubernetes.Interface
+	namespace string
+	lock      sync.Mutex
+	cache     map[string]State
+}
+
+func appKey(app *unstructured.Unstructured) string {
+	return app.GetNamespace() + "/" + app.GetName()
+}
+
+// configMapName returns the state ConfigMap name; the name is hashed since applications of different namespaces
+// might have the same name
+func configMapName(app *unstructured.Unstructured) string {
+	sum := sha256.Sum256([]byte(appKey(app)))
+	return configMapNamePrefix + hex.EncodeToString(sum[:])[:16]
+}
+
+func (s *configMapStore) Load(app *unstructured.Unstructured, refresh bool) (State, error) {
+	key := appKey(app)
+	s.lock.Lock()
+	cached, ok := s.cache[key]
+	s.lock.Unlock()
+	if ok && !refresh {
+		return cached.Copy(), nil
+	}
+	res := State{}
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(configMapName(app), metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil && cm.Data[configMapStateKey] != "" {
+		if err := json.Unmarshal([]byte(cm.Data[configMapStateKey]), &res); err != nil {
+			return nil, err
+		}
+	}
+	s.lock.Lock()
+	s.cache[key] = res.Copy()
+	s.lock.Unlock()
+	return res, nil
+}
+
+func (s *configMapStore) Save(app *unstructured.Unstructured, state State) error {
+	configMaps := s.clientset.CoreV1().ConfigMaps(s.namespace)
+	name := configMapName(app)
+	if len(state) == 0 {
+		if err := configMaps.Delete(name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		cm, err := configMaps.Get(name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			cm = &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: configMapLabels(app)}}
+			// the state is garbage collected together with the application if both are in the same namespace
+			if app.GetNamespace() == s.namespace && app.GetUID() != "" {
+				cm.OwnerReferences = []metav1.OwnerReference{{
+					APIVersion: app.GetAPIVersion(), Kind: app.GetKind(), Name: app.GetName(), UID: app.GetUID(),
+				}}
+			}
+			cm.Data = map[string]string{configMapStateKey: string(data)}
+			if _, err = configMaps.Create(cm); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			cm.Data = map[string]string{configMapStateKey: string(data)}
+			if _, err = configMaps.Update(cm); err != nil {
+				return err
+			}
+		}
+	}
+	s.lock.Lock()
+	s.cache[appKey(app)] = state.Copy()
+	s.lock.Unlock()
+	return nil
+}
+
+func configMapLabels(app *unstructured.Unstructured) map[string]string {
+	res := map[string]string{typeLabel: typeLabelValue}
+	for k, v := range map[string]string{configMapAppLabel: app.GetName(), configMapAppNamespace: app.GetNamespace()} {
+		if v != "" && len(validation.IsValidLabelValue(v)) == 0 {
+			res[k] = v
+		}
+	}
+	return res
+}
@@ -0,0 +1,52 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestConfigMapStore(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	store := NewConfigMapStore(clientset, TestNamespace)
+	app := NewApp("guestbook")
+	app.SetUID("123")
+
+	appState, err := store.Load(app, false)
+	assert.NoError(t, err)
+	assert.Empty(t, appState)
+
+	assert.NoError(t, store.Save(app, State{"on-sync.slack.alerts": "2020-01-01T00:00:00Z"}))
+	cm, err := clientset.CoreV1().ConfigMaps(TestNamespace).Get(configMapName(app), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"on-sync.slack.alerts":"2020-01-01T00:00:00Z"}`, cm.Data[configMapStateKey])
+	assert.Equal(t, "guestbook", cm.Labels[configMapAppLabel])
+	if assert.Len(t, cm.OwnerReferences, 1) {
+		assert.Equal(t, "123", string(cm.OwnerReferences[0].UID))
+	}
+
+	// state is loaded from the ConfigMap by the new store instance
+	appState, err = NewConfigMapStore(clientset, TestNamespace).Load(app, false)
+	assert.NoError(t, err)
+	assert.Equal(t, State{"on-sync.slack.alerts": "2020-01-01T00:00:00Z"}, appState)
+
+	assert.NoError(t, store.Save(app, State{"on-sync.slack.alerts": "2020-01-02T00:00:00Z"}))
+	appState, err = store.Load(app, true)
+	assert.NoError(t, err)
+	assert.Equal(t, State{"on-sync.slack.alerts": "2020-01-02T00:00:00Z"}, appState)
+
+	assert.NoError(t, store.Save(app, State{}))
+	_, err = clientset.CoreV1().ConfigMaps(TestNamespace).Get(configMapName(app), metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestConfigMapName_IncludesNamespace(t *testing.T) {
+	app1 := NewApp("guestbook")
+	app2 := NewApp("guestbook")
+	app2.SetNamespace("other")
+	assert.NotEqual(t, configMapName(app1), configMapName(app2))
+}
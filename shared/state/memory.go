@@ -0,0 +1,34 @@
+package state
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NewMemoryStore returns store that keeps the state in memory, so the state is lost after the controller restart
+func NewMemoryStore() Store {
+	return &memoryStore{states: map[string]State{}}
+}
+
+type memoryStore struct {
+	lock   sync.Mutex
+	states map[string]State
+}
+
+func (s *memoryStore) Load(app *unstructured.Unstructured, _ bool) (State, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.states[appKey(app)].Copy(), nil
+}
+
+func (s *memoryStore) Save(app *unstructured.Unstructured, state State) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(state) == 0 {
+		delete(s.states, appKey(app))
+	} else {
+		s.states[appKey(app)] = state.Copy()
+	}
+	return nil
+}
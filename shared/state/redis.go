@@ -0,0 +1,77 @@
+package state
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	redisKeyPrefix      = "argocd-notifications:state:"
+	redisClaimKeyPrefix = "argocd-notifications:claim:"
+	// redisClaimTTL expires the claims of the notifications which state was never saved, e.g. the replica crashed
+	redisClaimTTL = 24 * time.Hour
+)
+
+// redisClaimScript sets the claim unless it holds the same notification hash
+var redisClaimScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+// NewRedisStore returns store that keeps the state of every application in a Redis hash, so the state is shared by
+// all controller replicas. The store implements Claimer, so the replicas do not send the same notification twice.
+func NewRedisStore(client redis.UniversalClient) Store {
+	return &redisStore{client: client}
+}
+
+type redisStore struct {
+	client redis.UniversalClient
+}
+
+func redisKey(app *unstructured.Unstructured) string {
+	return redisKeyPrefix + appKey(app)
+}
+
+func (s *redisStore) Load(app *unstructured.Unstructured, _ bool) (State, error) {
+	values, err := s.client.HGetAll(redisKey(app)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return State(values), nil
+}
+
+func (s *redisStore) Save(app *unstructured.Unstructured, state State) error {
+	key := redisKey(app)
+	pipe := s.client.TxPipeline()
+	pipe.Del(key)
+	if len(state) > 0 {
+		values := map[string]interface{}{}
+		for k, v := range state {
+			values[k] = v
+		}
+		pipe.HSet(key, values)
+	}
+	_, err := pipe.Exec()
+	return err
+}
+
+func redisClaimKey(app *unstructured.Unstructured, key string) string {
+	return redisClaimKeyPrefix + appKey(app) + ":" + key
+}
+
+func (s *redisStore) Claim(app *unstructured.Unstructured, key string, hash string) (bool, error) {
+	claimed, err := redisClaimScript.Run(s.client, []string{redisClaimKey(app, key)}, hash, redisClaimTTL.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return claimed == 1, nil
+}
+
+func (s *redisStore) Release(app *unstructured.Unstructured, key string) error {
+	return s.client.Del(redisClaimKey(app, key)).Err()
+}
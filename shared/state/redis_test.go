@@ -0,0 +1,65 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestRedisStore(t *testing.T) {
+	server, err := miniredis.Run()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+	app := NewApp("guestbook")
+
+	appState, err := store.Load(app, false)
+	assert.NoError(t, err)
+	assert.Empty(t, appState)
+
+	assert.NoError(t, store.Save(app, State{"a": "2020-01-01T00:00:00Z", "b": "2020-01-01T00:00:00Z"}))
+	assert.NoError(t, store.Save(app, State{"a": "2020-01-02T00:00:00Z"}))
+
+	appState, err = store.Load(app, true)
+	assert.NoError(t, err)
+	assert.Equal(t, State{"a": "2020-01-02T00:00:00Z"}, appState)
+
+	assert.NoError(t, store.Save(app, State{}))
+	assert.False(t, server.Exists(redisKeyPrefix+"default/guestbook"))
+}
+
+func TestRedisStore_Claim(t *testing.T) {
+	server, err := miniredis.Run()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	replica1 := NewRedisStore(client).(Claimer)
+	replica2 := NewRedisStore(client).(Claimer)
+	app := NewApp("guestbook")
+
+	claimed, err := replica1.Claim(app, "on-deployed.slack.deploys", "abc")
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = replica2.Claim(app, "on-deployed.slack.deploys", "abc")
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	// the notification of the next oncePer value is claimed again
+	claimed, err = replica2.Claim(app, "on-deployed.slack.deploys", "def")
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	assert.NoError(t, replica2.Release(app, "on-deployed.slack.deploys"))
+	claimed, err = replica1.Claim(app, "on-deployed.slack.deploys", "def")
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+}
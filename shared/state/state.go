@@ -0,0 +1,86 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+)
+
+const (
+	entryHashSeparator = ";"
+)
+
+// OutOfSyncSinceKey is the state key of the time the application has become OutOfSync
+var OutOfSyncSinceKey = "out-of-sync-since." + recipients.AnnotationPostfix
+
+// State holds the time when notifications of the application were sent and the notifications hashes. The keys are
+// formatted using the Key function and values using the Entry function.
+type State map[string]string
+
+// Key returns state key of the trigger notification sent to the recipient
+func Key(trigger string, recipient string) string {
+	return recipients.FormatTriggerRecipientAnnotation(trigger, recipient)
+}
+
+// Hash returns the identity of the notification: trigger, oncePer expression value and recipient
+func Hash(trigger string, oncePer string, recipient string) string {
+	sum := sha256.Sum256([]byte(trigger + "\x00" + oncePer + "\x00" + recipient))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Entry returns the state value that holds the time when notification was sent and the notification hash
+func Entry(sentAt time.Time, hash string) string {
+	res := sentAt.Format(time.RFC3339)
+	if hash != "" {
+		res += entryHashSeparator + hash
+	}
+	return res
+}
+
+// EntryMatches returns true if the state value belongs to the notification with the specified hash; values persisted
+// without hash match any notification
+func EntryMatches(value string, hash string) bool {
+	parts := strings.SplitN(value, entryHashSeparator, 2)
+	return len(parts) < 2 || parts[1] == hash
+}
+
+// Copy returns copy of the state
+func (s State) Copy() State {
+	res := State{}
+	for k, v := range s {
+		res[k] = v
+	}
+	return res
+}
+
+// Store persists notifications that were already sent, so they are not sent again while the trigger condition holds
+type Store interface {
+	// Load returns the application state. The latest persisted state is returned if refresh is true, otherwise the
+	// store might return cached state.
+	Load(app *unstructured.Unstructured, refresh bool) (State, error)
+	// Save persists the application state
+	Save(app *unstructured.Unstructured, state State) error
+}
+
+// Claimer is implemented by the stores shared by the controller replicas. The replica claims the notification before
+// sending it, so the notification is sent once even if several replicas process the application at the same time.
+type Claimer interface {
+	// Claim returns false if the notification with the same state key and hash is already claimed
+	Claim(app *unstructured.Unstructured, key string, hash string) (bool, error)
+	// Release removes the claim, so the notification is sent again once the trigger condition holds
+	Release(app *unstructured.Unstructured, key string) error
+}
+
+// isStateEntry returns true if the annotation holds the notification state
+func isStateEntry(key string, value string) bool {
+	if !strings.HasSuffix(key, "."+recipients.AnnotationPostfix) {
+		return false
+	}
+	_, err := time.Parse(time.RFC3339, strings.SplitN(value, entryHashSeparator, 2)[0])
+	return err == nil
+}
@@ -0,0 +1,23 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntry(t *testing.T) {
+	sentAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	hash := Hash("on-deployed", "abc", "slack:alerts")
+	assert.NotEqual(t, hash, Hash("on-deployed", "def", "slack:alerts"))
+
+	entry := Entry(sentAt, hash)
+	assert.Equal(t, "2020-01-01T00:00:00Z;"+hash, entry)
+	assert.True(t, EntryMatches(entry, hash))
+	assert.False(t, EntryMatches(entry, Hash("on-deployed", "def", "slack:alerts")))
+	assert.True(t, isStateEntry(Key("on-deployed", "slack:alerts"), entry))
+
+	// entries persisted without hash match any notification
+	assert.True(t, EntryMatches(sentAt.Format(time.RFC3339), hash))
+}
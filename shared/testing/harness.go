@@ -0,0 +1,118 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/controller"
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/clients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const (
+	// Namespace is the namespace of the fixture applications
+	Namespace = "argocd"
+	// DefaultTimeout is the recommended timeout of the Wait function
+	DefaultTimeout = 10 * time.Second
+)
+
+// Harness runs the notifications controller against the fake Kubernetes API populated with the fixture
+// applications, so custom triggers, templates and notification services can be tested end-to-end without a cluster
+type Harness struct {
+	// Client is the fake Kubernetes API client that holds the applications and projects
+	Client    *fake.FakeDynamicClient
+	configMap *v1.ConfigMap
+	secret    *v1.Secret
+	cancel    context.CancelFunc
+}
+
+// NewHarness returns the harness that parses the config map and the notification services configuration the same way
+// as the controller does. The applications and projects are moved to the Namespace. Use the fake servers options to
+// send notifications to the fake servers.
+func NewHarness(configMap *v1.ConfigMap, services notifiers.Config, objs ...runtime.Object) (*Harness, error) {
+	data, err := yaml.Marshal(services)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			u.SetNamespace(Namespace)
+		}
+	}
+	return &Harness{
+		Client:    fake.NewSimpleDynamicClient(runtime.NewScheme(), objs...),
+		configMap: configMap,
+		secret: &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: settings.SecretName, Namespace: Namespace},
+			Data:       map[string][]byte{"notifiers.yaml": data},
+		},
+	}, nil
+}
+
+// Start starts the controller; the controller processes every application change until Stop is called. The
+// applications annotations are patched by the controller to keep the notification state.
+func (h *Harness) Start() error {
+	triggers, services, cfg, err := settings.ParseConfig(h.configMap, h.secret, settings.Config{}, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	ctrl, err := controller.NewController(h.Client, Namespace, triggers, services, cfg.Context, cfg.Subscriptions, cfg.Policies,
+		"", controller.Sharding{}, controller.InformerOptions{ResyncPeriod: controller.DefaultResyncPeriod},
+		controller.NewMetricsRegistry(), nil, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err = ctrl.Init(ctx); err != nil {
+		cancel()
+		return err
+	}
+	h.cancel = cancel
+	go ctrl.Run(ctx, 1)
+	return nil
+}
+
+// Stop stops the controller
+func (h *Harness) Stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+}
+
+// GetApp returns the current state of the application
+func (h *Harness) GetApp(name string) (*unstructured.Unstructured, error) {
+	return clients.NewAppClient(h.Client, Namespace).Get(name, metav1.GetOptions{})
+}
+
+// UpdateApp applies the changes to the current state of the application, so the controller re-evaluates triggers
+func (h *Harness) UpdateApp(name string, change func(app *unstructured.Unstructured)) error {
+	appClient := clients.NewAppClient(h.Client, Namespace)
+	app, err := appClient.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	change(app)
+	_, err = appClient.Update(app, metav1.UpdateOptions{})
+	return err
+}
+
+// Wait polls the condition until it returns true or the timeout expires
+func Wait(timeout time.Duration, condition func() bool) error {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return errors.New("timed out waiting for the condition")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package testing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestHarness(t *testing.T) {
+	slack := NewSlackServer()
+	defer slack.Close()
+	webhook := NewWebhookServer()
+	defer webhook.Close()
+	smtp, err := NewSMTPServer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer smtp.Close()
+
+	slackOpts := slack.Options()
+	emailOpts := smtp.Options()
+	harness, err := NewHarness(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName},
+		Data: map[string]string{
+			"trigger.on-sync-failed":   `{condition: "app.status.operationState.phase in ['Failed']", template: app-sync-failed}`,
+			"template.app-sync-failed": `{title: "{{.app.metadata.name}} sync failed", body: "Application {{.app.metadata.name}} sync failed", webhook: {test: {method: POST, body: "{{.app.metadata.name}}"}}}`,
+		},
+	}, notifiers.Config{
+		Slack:   &slackOpts,
+		Email:   &emailOpts,
+		Webhook: &notifiers.WebhookOptions{webhook.Options("test")},
+	}, NewApp("guestbook", WithAnnotations(map[string]string{
+		"argocd-notifications.argoproj.io/subscribe.on-sync-failed.slack":   "alerts",
+		"argocd-notifications.argoproj.io/subscribe.on-sync-failed.email":   "ops@example.com",
+		"argocd-notifications.argoproj.io/subscribe.on-sync-failed.webhook": "test",
+	})))
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, harness.Start()) {
+		return
+	}
+	defer harness.Stop()
+
+	assert.NoError(t, harness.UpdateApp("guestbook", func(app *unstructured.Unstructured) {
+		now := time.Now()
+		WithSyncOperationPhase("Failed")(app)
+		WithSyncOperationFinishedAt(now.Add(-time.Minute))(app)
+		WithReconciledAt(now)(app)
+	}))
+	assert.NoError(t, Wait(DefaultTimeout, func() bool {
+		return len(slack.Messages()) > 0 && len(smtp.Emails()) > 0 && len(webhook.Requests()) > 0
+	}))
+
+	if messages := slack.Messages(); assert.Len(t, messages, 1) {
+		assert.Equal(t, "alerts", messages[0].Channel)
+		assert.Equal(t, "Application guestbook sync failed", messages[0].Text)
+	}
+	if emails := smtp.Emails(); assert.Len(t, emails, 1) {
+		assert.Equal(t, []string{"ops@example.com"}, emails[0].To)
+		assert.Equal(t, "guestbook sync failed", emails[0].Subject)
+		assert.Equal(t, "Application guestbook sync failed", emails[0].Body)
+	}
+	if requests := webhook.Requests(); assert.Len(t, requests, 1) {
+		assert.Equal(t, "guestbook", requests[0].Body)
+	}
+}
@@ -0,0 +1,252 @@
+package testing
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+)
+
+// SlackMessage is the message received by the fake Slack server
+type SlackMessage struct {
+	Channel     string
+	Text        string
+	Username    string
+	Attachments string
+	Blocks      string
+}
+
+// SlackServer is the fake Slack Web API server that accepts messages posted by the Slack notification service
+type SlackServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	messages []SlackMessage
+}
+
+// NewSlackServer starts the fake Slack Web API server; the server should be closed after the test
+func NewSlackServer() *SlackServer {
+	s := &SlackServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		msg := SlackMessage{
+			Channel:     r.PostForm.Get("channel"),
+			Text:        r.PostForm.Get("text"),
+			Username:    r.PostForm.Get("username"),
+			Attachments: r.PostForm.Get("attachments"),
+			Blocks:      r.PostForm.Get("blocks"),
+		}
+		s.lock.Lock()
+		s.messages = append(s.messages, msg)
+		s.lock.Unlock()
+		writeSlackResponse(w, map[string]interface{}{"ok": true, "channel": msg.Channel, "ts": strconv.Itoa(len(s.Messages()))})
+	})
+	mux.HandleFunc("/conversations.open", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		writeSlackResponse(w, map[string]interface{}{"ok": true, "channel": map[string]interface{}{"id": "D" + r.PostForm.Get("users")}})
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func writeSlackResponse(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// Options returns the Slack notification service settings that send messages to the fake server
+func (s *SlackServer) Options() notifiers.SlackOptions {
+	return notifiers.SlackOptions{Token: "xoxb-test", ApiUrl: s.URL + "/"}
+}
+
+// Messages returns the received messages
+func (s *SlackServer) Messages() []SlackMessage {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]SlackMessage{}, s.messages...)
+}
+
+// WebhookRequest is the request received by the fake webhook server
+type WebhookRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   string
+}
+
+// WebhookServer is the fake webhook receiver that responds with the 200 status to every request
+type WebhookServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	requests []WebhookRequest
+}
+
+// NewWebhookServer starts the fake webhook receiver; the server should be closed after the test
+func NewWebhookServer() *WebhookServer {
+	s := &WebhookServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.requests = append(s.requests, WebhookRequest{Method: r.Method, Path: r.URL.Path, Header: r.Header, Body: string(body)})
+	}))
+	return s
+}
+
+// Options returns the webhook settings that send requests to the fake server
+func (s *WebhookServer) Options(name string) notifiers.WebhookSettings {
+	return notifiers.WebhookSettings{Name: name, URL: s.URL}
+}
+
+// Requests returns the received requests
+func (s *WebhookServer) Requests() []WebhookRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]WebhookRequest{}, s.requests...)
+}
+
+// Email is the message received by the fake SMTP server
+type Email struct {
+	From    string
+	To      []string
+	Subject string
+	Body    string
+}
+
+// SMTPServer is the fake SMTP server that accepts every message without authentication and TLS
+type SMTPServer struct {
+	listener net.Listener
+	lock     sync.Mutex
+	emails   []Email
+	wg       sync.WaitGroup
+}
+
+// NewSMTPServer starts the fake SMTP server on the random local port; the server should be closed after the test
+func NewSMTPServer() (*SMTPServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &SMTPServer{listener: listener}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(conn)
+			}()
+		}
+	}()
+	return s, nil
+}
+
+// Options returns the email notification service settings that send messages to the fake server
+func (s *SMTPServer) Options() notifiers.EmailOptions {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return notifiers.EmailOptions{Host: addr.IP.String(), Port: addr.Port, From: "argocd@example.com"}
+}
+
+// Emails returns the received messages
+func (s *SMTPServer) Emails() []Email {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Email{}, s.emails...)
+}
+
+// Close stops the server and waits until open connections are closed
+func (s *SMTPServer) Close() {
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *SMTPServer) serve(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	reader := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) {
+		_, _ = fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+	reply("220 localhost fake SMTP server")
+	var email Email
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			email = Email{From: smtpAddress(line)}
+			reply("250 OK")
+		case "RCPT":
+			email.To = append(email.To, smtpAddress(line))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" || dataLine == ".\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			if msg, err := mail.ReadMessage(strings.NewReader(data.String())); err == nil {
+				email.Subject = msg.Header.Get("Subject")
+				if body, err := ioutil.ReadAll(msg.Body); err == nil {
+					email.Body = strings.TrimSpace(string(body))
+				}
+			}
+			s.lock.Lock()
+			s.emails = append(s.emails, email)
+			s.lock.Unlock()
+			reply("250 OK")
+		case "RSET", "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// smtpAddress returns the address of the MAIL FROM:<address> or RCPT TO:<address> command
+func smtpAddress(line string) string {
+	start := strings.Index(line, "<")
+	end := strings.LastIndex(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
@@ -22,3 +22,35 @@ func SplitRemoveEmpty(in string, separator string) []string {
 	}
 	return res
 }
+
+// Distance returns the Levenshtein distance between two strings
+func Distance(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		curr := make([]int, len(t)+1)
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(t)]
+}
+
+func min(first int, other ...int) int {
+	res := first
+	for _, v := range other {
+		if v < res {
+			res = v
+		}
+	}
+	return res
+}
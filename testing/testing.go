@@ -17,6 +17,19 @@ func WithAnnotations(annotations map[string]string) func(obj *unstructured.Unstr
 	}
 }
 
+func WithLabels(labels map[string]string) func(obj *unstructured.Unstructured) {
+	return func(app *unstructured.Unstructured) {
+		app.SetLabels(labels)
+	}
+}
+
+func WithDestination(server string, namespace string) func(app *unstructured.Unstructured) {
+	return func(app *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(app.Object, server, "spec", "destination", "server")
+		_ = unstructured.SetNestedField(app.Object, namespace, "spec", "destination", "namespace")
+	}
+}
+
 func WithProject(project string) func(app *unstructured.Unstructured) {
 	return func(app *unstructured.Unstructured) {
 		_ = unstructured.SetNestedField(app.Object, project, "spec", "project")
@@ -111,3 +124,19 @@ func NewProject(name string, modifiers ...func(app *unstructured.Unstructured))
 	}
 	return &proj
 }
+
+// WithHistory sets the application deploy history; the revisions are deployed an hour apart, the last one an hour ago
+func WithHistory(revisions ...string) func(app *unstructured.Unstructured) {
+	return func(app *unstructured.Unstructured) {
+		var history []interface{}
+		for i, revision := range revisions {
+			deployedAt := time.Now().Add(-time.Duration(len(revisions)-i) * time.Hour)
+			history = append(history, map[string]interface{}{
+				"id":         int64(i),
+				"revision":   revision,
+				"deployedAt": deployedAt.Format(time.RFC3339),
+			})
+		}
+		_ = unstructured.SetNestedSlice(app.Object, history, "status", "history")
+	}
+}
@@ -0,0 +1,111 @@
+package triggers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/vm"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+)
+
+const defaultAggregateCondition = "matched > 0"
+
+// Aggregate evaluates the aggregate trigger expressions over the set of applications
+type Aggregate struct {
+	selector      *vm.Program
+	match         *vm.Program
+	condition     *vm.Program
+	argocdService argocd.Service
+}
+
+// AggregateResult is the result of the aggregate trigger evaluation
+type AggregateResult struct {
+	Triggered bool
+	// Matched are the selected applications which match the trigger, ordered by namespace and name
+	Matched []*unstructured.Unstructured
+	// Total is the number of the selected applications
+	Total int
+	// Ratio is the matched share of the selected applications, zero if no application is selected
+	Ratio float64
+}
+
+// NewAggregate compiles the aggregate trigger expressions. The selector and match expressions are evaluated for every
+// application and have access to the same variables as the trigger conditions. The condition has access to the
+// matched and total numbers of the applications and their ratio; the condition is "matched > 0" if empty.
+func NewAggregate(selector string, match string, condition string, argocdService argocd.Service) (*Aggregate, error) {
+	res := &Aggregate{argocdService: argocdService}
+	var err error
+	if selector != "" {
+		if res.selector, err = expr.Compile(selector); err != nil {
+			return nil, fmt.Errorf("failed to parse selector: %v", err)
+		}
+	}
+	if res.match, err = expr.Compile(match); err != nil {
+		return nil, fmt.Errorf("failed to parse match expression: %v", err)
+	}
+	if condition == "" {
+		condition = defaultAggregateCondition
+	}
+	if res.condition, err = expr.Compile(condition); err != nil {
+		return nil, fmt.Errorf("failed to parse condition: %v", err)
+	}
+	return res, nil
+}
+
+// Evaluate returns the applications which match the trigger and whether the trigger condition is true. The
+// applications which expressions fail are neither selected nor matched.
+func (a *Aggregate) Evaluate(apps []*unstructured.Unstructured) (AggregateResult, error) {
+	res := AggregateResult{}
+	for _, app := range apps {
+		envs := spawnExprEnvs(app, map[string]interface{}{"app": appVars(app)}, a.argocdService)
+		if a.selector != nil && !isTrue(expr.Run(a.selector, envs)) {
+			continue
+		}
+		res.Total++
+		if isTrue(expr.Run(a.match, envs)) {
+			res.Matched = append(res.Matched, app)
+		}
+	}
+	sort.Slice(res.Matched, func(i, j int) bool {
+		if res.Matched[i].GetNamespace() != res.Matched[j].GetNamespace() {
+			return res.Matched[i].GetNamespace() < res.Matched[j].GetNamespace()
+		}
+		return res.Matched[i].GetName() < res.Matched[j].GetName()
+	})
+	if res.Total > 0 {
+		res.Ratio = float64(len(res.Matched)) / float64(res.Total)
+	}
+	value, err := expr.Run(a.condition, map[string]interface{}{
+		"matched": len(res.Matched),
+		"total":   res.Total,
+		"ratio":   res.Ratio,
+	})
+	if err != nil {
+		return res, err
+	}
+	res.Triggered = isTrue(value, nil)
+	return res, nil
+}
+
+func isTrue(value interface{}, err error) bool {
+	res, ok := value.(bool)
+	return err == nil && ok && res
+}
+
+// Vars returns the variables of the aggregate notification template: the matched applications in the apps variable
+// and the matched, total and ratio values of the condition
+func (r AggregateResult) Vars() map[string]interface{} {
+	apps := make([]interface{}, len(r.Matched))
+	for i := range r.Matched {
+		apps[i] = appVars(r.Matched[i])
+	}
+	return map[string]interface{}{
+		"apps":    apps,
+		"matched": len(r.Matched),
+		"total":   r.Total,
+		"ratio":   r.Ratio,
+	}
+}
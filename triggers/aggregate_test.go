@@ -0,0 +1,62 @@
+package triggers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	testingutil "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newAggregateApps() []*unstructured.Unstructured {
+	return []*unstructured.Unstructured{
+		testingutil.NewApp("payments-b", testingutil.WithProject("payments"), testingutil.WithHealthStatus("Degraded")),
+		testingutil.NewApp("payments-a", testingutil.WithProject("payments"), testingutil.WithHealthStatus("Degraded")),
+		testingutil.NewApp("payments-c", testingutil.WithProject("payments"), testingutil.WithHealthStatus("Healthy")),
+		testingutil.NewApp("billing", testingutil.WithProject("billing"), testingutil.WithHealthStatus("Healthy")),
+	}
+}
+
+func TestAggregate_Evaluate(t *testing.T) {
+	aggregate, err := NewAggregate("app.spec.project == 'payments'", "app.status.health.status == 'Degraded'", "ratio > 0.5", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := aggregate.Evaluate(newAggregateApps())
+
+	assert.NoError(t, err)
+	assert.True(t, res.Triggered)
+	assert.Equal(t, 3, res.Total)
+	if assert.Len(t, res.Matched, 2) {
+		assert.Equal(t, "payments-a", res.Matched[0].GetName())
+		assert.Equal(t, "payments-b", res.Matched[1].GetName())
+	}
+
+	vars := res.Vars()
+	assert.Equal(t, 2, vars["matched"])
+	assert.Equal(t, 3, vars["total"])
+	assert.Len(t, vars["apps"], 2)
+}
+
+func TestAggregate_EvaluateDefaultCondition(t *testing.T) {
+	aggregate, err := NewAggregate("", "app.status.health.status == 'Missing'", "", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := aggregate.Evaluate(newAggregateApps())
+
+	assert.NoError(t, err)
+	assert.False(t, res.Triggered)
+	assert.Equal(t, 4, res.Total)
+	assert.Equal(t, float64(0), res.Ratio)
+}
+
+func TestAggregate_Invalid(t *testing.T) {
+	_, err := NewAggregate("app.spec.project ==", "true", "", nil)
+	assert.Error(t, err)
+	_, err = NewAggregate("", "true", "matched >", nil)
+	assert.Error(t, err)
+}
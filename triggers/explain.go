@@ -0,0 +1,97 @@
+package triggers
+
+import (
+	"strings"
+
+	"github.com/antonmedv/expr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+)
+
+// ClauseResult is the value of the condition clause evaluated against the application
+type ClauseResult struct {
+	// Clause is the source of the clause, e.g. app.status.sync.status == 'Unknown'
+	Clause string
+	// Value is the clause evaluation result
+	Value interface{}
+	// Error is the clause compilation or evaluation error
+	Error error
+}
+
+// SplitCondition splits the condition into the clauses joined by the top-level logical operators. The clauses
+// in parentheses, brackets or string literals are not split.
+func SplitCondition(condition string) []string {
+	var clauses []string
+	depth := 0
+	var quote rune
+	start := 0
+	runes := []rune(condition)
+	add := func(end int) {
+		if clause := strings.TrimSpace(string(runes[start:end])); clause != "" {
+			clauses = append(clauses, clause)
+		}
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if quote != 0 {
+			if r == '\\' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch r {
+		case '\'', '"', '`':
+			quote = r
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+		if depth != 0 {
+			continue
+		}
+		rest := string(runes[i:])
+		if strings.HasPrefix(rest, "&&") || strings.HasPrefix(rest, "||") {
+			add(i)
+			start = i + 2
+			i++
+			continue
+		}
+		if i > 0 && isSpace(runes[i-1]) {
+			for _, keyword := range []string{"and", "or"} {
+				if strings.HasPrefix(rest, keyword) && len(runes) > i+len(keyword) && isSpace(runes[i+len(keyword)]) {
+					add(i)
+					start = i + len(keyword)
+					i += len(keyword) - 1
+					break
+				}
+			}
+		}
+	}
+	add(len(runes))
+	return clauses
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+// ExplainCondition evaluates every clause of the condition against the application and returns the clause values,
+// so it is visible why the condition matches or not
+func ExplainCondition(condition string, app *unstructured.Unstructured, argocdService argocd.Service) []ClauseResult {
+	var results []ClauseResult
+	envs := spawnExprEnvs(app, map[string]interface{}{"app": appVars(app)}, argocdService)
+	for _, clause := range SplitCondition(condition) {
+		result := ClauseResult{Clause: clause}
+		if program, err := expr.Compile(clause); err != nil {
+			result.Error = err
+		} else {
+			result.Value, result.Error = expr.Run(program, envs)
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,28 @@
+package triggers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestSplitCondition(t *testing.T) {
+	assert.Equal(t, []string{"a == 1", "b in ['x && y', 'z']", "(c or d)"},
+		SplitCondition("a == 1 && b in ['x && y', 'z'] || (c or d)"))
+	assert.Equal(t, []string{"app.metadata.name == 'guestbook'", "app.spec.project != 'default'"},
+		SplitCondition("app.metadata.name == 'guestbook' and app.spec.project != 'default'"))
+	assert.Equal(t, []string{"true"}, SplitCondition("true"))
+}
+
+func TestExplainCondition(t *testing.T) {
+	results := ExplainCondition("app.metadata.name == 'foo' and app.metadata.namespace == 'other' and unknown()",
+		NewApp("foo"), nil)
+
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, ClauseResult{Clause: "app.metadata.name == 'foo'", Value: true}, results[0])
+		assert.Equal(t, ClauseResult{Clause: "app.metadata.namespace == 'other'", Value: false}, results[1])
+		assert.Error(t, results[2].Error)
+	}
+}
@@ -0,0 +1,35 @@
+package acks
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+)
+
+func getAcknowledgement(store history.Store, app *unstructured.Unstructured, trigger string) *history.Event {
+	if store == nil || app == nil {
+		return nil
+	}
+	ack, err := history.GetLastAcknowledgement(store, app.GetNamespace(), app.GetName(), trigger)
+	if err != nil {
+		panic(err)
+	}
+	return ack
+}
+
+func NewExprs(store history.Store, app *unstructured.Unstructured) map[string]interface{} {
+	return map[string]interface{}{
+		"IsAcknowledged": func(trigger string) bool {
+			return getAcknowledgement(store, app, trigger) != nil
+		},
+		"GetAcknowledgement": func(trigger string) interface{} {
+			if ack := getAcknowledgement(store, app, trigger); ack != nil {
+				return map[string]interface{}{
+					"user":      ack.User,
+					"timestamp": ack.Timestamp,
+				}
+			}
+			return nil
+		},
+	}
+}
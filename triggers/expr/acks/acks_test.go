@@ -0,0 +1,38 @@
+package acks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+)
+
+func TestIsAcknowledged(t *testing.T) {
+	store := history.NewMemoryStore(10)
+	app := &unstructured.Unstructured{}
+	app.SetName("guestbook")
+	app.SetNamespace("argocd")
+	exprs := NewExprs(store, app)
+	isAcknowledged := exprs["IsAcknowledged"].(func(string) bool)
+	getAcknowledgement := exprs["GetAcknowledgement"].(func(string) interface{})
+
+	assert.False(t, isAcknowledged("on-sync-failed"))
+	assert.Nil(t, getAcknowledgement("on-sync-failed"))
+
+	_, err := history.Acknowledge(store, "argocd", "guestbook", "on-sync-failed", "alice")
+	assert.NoError(t, err)
+
+	assert.True(t, isAcknowledged("on-sync-failed"))
+	assert.False(t, isAcknowledged("on-health-degraded"))
+	ack := getAcknowledgement("on-sync-failed").(map[string]interface{})
+	assert.Equal(t, "alice", ack["user"])
+	assert.WithinDuration(t, time.Now(), ack["timestamp"].(time.Time), time.Minute)
+}
+
+func TestIsAcknowledged_NoStore(t *testing.T) {
+	exprs := NewExprs(nil, nil)
+	assert.False(t, exprs["IsAcknowledged"].(func(string) bool)("on-sync-failed"))
+}
@@ -0,0 +1,66 @@
+package deploys
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
+)
+
+func NewExprs(app *unstructured.Unstructured) map[string]interface{} {
+	deploys := shared.GetDeploys(app)
+	last := func(offset int) interface{} {
+		if i := len(deploys) - 1 - offset; i >= 0 {
+			return deploys[i]
+		}
+		return nil
+	}
+	return map[string]interface{}{
+		"GetLast": func() interface{} {
+			return last(0)
+		},
+		"GetPrevious": func() interface{} {
+			return last(1)
+		},
+		"SincePrevious": func() time.Duration {
+			if len(deploys) < 2 {
+				return 0
+			}
+			return deploys[len(deploys)-1].DeployedAt.Sub(deploys[len(deploys)-2].DeployedAt)
+		},
+		"CountSince": func(duration string) int {
+			d, err := time.ParseDuration(duration)
+			if err != nil {
+				panic(err)
+			}
+			since := time.Now().Add(-d)
+			count := 0
+			for i := range deploys {
+				if !deploys[i].DeployedAt.Before(since) {
+					count++
+				}
+			}
+			return count
+		},
+		"IsFirstOfDay": func() bool {
+			if len(deploys) == 0 {
+				return false
+			}
+			if len(deploys) == 1 {
+				return true
+			}
+			y1, m1, d1 := deploys[len(deploys)-1].DeployedAt.Local().Date()
+			y2, m2, d2 := deploys[len(deploys)-2].DeployedAt.Local().Date()
+			return y1 != y2 || m1 != m2 || d1 != d2
+		},
+		"FindRevision": func(revision string) interface{} {
+			for i := len(deploys) - 2; i >= 0; i-- {
+				if deploys[i].Revision == revision {
+					return deploys[i]
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package deploys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
+)
+
+func newApp(history ...map[string]interface{}) *unstructured.Unstructured {
+	entries := make([]interface{}, len(history))
+	for i := range history {
+		entries[i] = history[i]
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"history": entries},
+	}}
+}
+
+func entry(id int64, revision string, deployedAt time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"revision":   revision,
+		"deployedAt": deployedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func TestGetLastAndPrevious(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	exprs := NewExprs(newApp(entry(1, "aaa", now.Add(-2*time.Hour)), entry(2, "bbb", now)))
+
+	last := exprs["GetLast"].(func() interface{})().(shared.Deploy)
+	assert.Equal(t, int64(2), last.ID)
+	assert.Equal(t, "bbb", last.Revision)
+	assert.True(t, now.Equal(last.DeployedAt))
+
+	previous := exprs["GetPrevious"].(func() interface{})().(shared.Deploy)
+	assert.Equal(t, "aaa", previous.Revision)
+	assert.Equal(t, 2*time.Hour, exprs["SincePrevious"].(func() time.Duration)())
+}
+
+func TestNoHistory(t *testing.T) {
+	exprs := NewExprs(&unstructured.Unstructured{Object: map[string]interface{}{}})
+
+	assert.Nil(t, exprs["GetLast"].(func() interface{})())
+	assert.Nil(t, exprs["GetPrevious"].(func() interface{})())
+	assert.Equal(t, time.Duration(0), exprs["SincePrevious"].(func() time.Duration)())
+	assert.Equal(t, 0, exprs["CountSince"].(func(string) int)("24h"))
+	assert.False(t, exprs["IsFirstOfDay"].(func() bool)())
+}
+
+func TestCountSince(t *testing.T) {
+	now := time.Now()
+	exprs := NewExprs(newApp(
+		entry(1, "aaa", now.Add(-48*time.Hour)),
+		entry(2, "bbb", now.Add(-3*time.Hour)),
+		entry(3, "ccc", now.Add(-time.Hour)),
+	))
+	countSince := exprs["CountSince"].(func(string) int)
+
+	assert.Equal(t, 2, countSince("24h"))
+	assert.Equal(t, 3, countSince("72h"))
+	assert.Panics(t, func() { countSince("day") })
+}
+
+func TestIsFirstOfDay(t *testing.T) {
+	day := time.Date(2021, 3, 10, 12, 0, 0, 0, time.Local)
+
+	assert.True(t, NewExprs(newApp(entry(1, "aaa", day)))["IsFirstOfDay"].(func() bool)())
+	assert.False(t, NewExprs(newApp(
+		entry(1, "aaa", day.Add(-time.Hour)),
+		entry(2, "bbb", day),
+	))["IsFirstOfDay"].(func() bool)())
+	assert.True(t, NewExprs(newApp(
+		entry(1, "aaa", day.Add(-24*time.Hour)),
+		entry(2, "bbb", day),
+	))["IsFirstOfDay"].(func() bool)())
+}
+
+func TestFindRevision(t *testing.T) {
+	now := time.Now()
+	exprs := NewExprs(newApp(
+		entry(1, "aaa", now.Add(-2*time.Hour)),
+		entry(2, "bbb", now.Add(-time.Hour)),
+		entry(3, "aaa", now),
+	))
+	findRevision := exprs["FindRevision"].(func(string) interface{})
+
+	assert.Equal(t, int64(1), findRevision("aaa").(shared.Deploy).ID)
+	assert.Equal(t, int64(2), findRevision("bbb").(shared.Deploy).ID)
+	assert.Nil(t, findRevision("ccc"))
+}
+
+func TestMultiSourceRevision(t *testing.T) {
+	exprs := NewExprs(newApp(map[string]interface{}{
+		"id":         int64(1),
+		"revisions":  []interface{}{"aaa", "bbb"},
+		"deployedAt": "2021-03-10T12:00:00Z",
+	}))
+
+	assert.Equal(t, "aaa", exprs["GetLast"].(func() interface{})().(shared.Deploy).Revision)
+}
@@ -1,7 +1,15 @@
 package expr
 
 import (
+	"sync"
+
 	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/registry"
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/acks"
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/deploys"
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/images"
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/operation"
 	"github.com/argoproj-labs/argocd-notifications/triggers/expr/repo"
 	"github.com/argoproj-labs/argocd-notifications/triggers/expr/time"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -9,6 +17,14 @@ import (
 
 var helpers = map[string]interface{}{}
 
+var (
+	ackStoreLock sync.RWMutex
+	ackStore     history.Store
+
+	imageRegistryLock sync.RWMutex
+	imageRegistry     registry.Client
+)
+
 func init() {
 	helpers = make(map[string]interface{})
 	register("time", time.NewExprs())
@@ -18,12 +34,44 @@ func register(namespace string, entry map[string]interface{}) {
 	helpers[namespace] = entry
 }
 
+// SetAckStore sets the history store which acks helpers read acknowledgements from; notifications are never
+// acknowledged if the store is nil
+func SetAckStore(store history.Store) {
+	ackStoreLock.Lock()
+	defer ackStoreLock.Unlock()
+	ackStore = store
+}
+
+func getAckStore() history.Store {
+	ackStoreLock.RLock()
+	defer ackStoreLock.RUnlock()
+	return ackStore
+}
+
+// SetImageRegistry sets the registry client which images helpers read the image build metadata from; the image
+// provenance is empty if the client is nil
+func SetImageRegistry(client registry.Client) {
+	imageRegistryLock.Lock()
+	defer imageRegistryLock.Unlock()
+	imageRegistry = client
+}
+
+func getImageRegistry() registry.Client {
+	imageRegistryLock.RLock()
+	defer imageRegistryLock.RUnlock()
+	return imageRegistry
+}
+
 func Spawn(app *unstructured.Unstructured, argocdService argocd.Service) map[string]interface{} {
 	clone := make(map[string]interface{})
 	for namespace, helper := range helpers {
 		clone[namespace] = helper
 	}
 	clone["repo"] = repo.NewExprs(argocdService, app)
+	clone["acks"] = acks.NewExprs(getAckStore(), app)
+	clone["images"] = images.NewExprs(getImageRegistry(), app)
+	clone["deploys"] = deploys.NewExprs(app)
+	clone["operation"] = operation.NewExprs(app)
 
 	return clone
 }
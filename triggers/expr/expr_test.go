@@ -10,6 +10,8 @@ func TestExpr(t *testing.T) {
 	namespaces := []string{
 		"time",
 		"repo",
+		"acks",
+		"images",
 	}
 
 	for _, ns := range namespaces {
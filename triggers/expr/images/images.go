@@ -0,0 +1,44 @@
+package images
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/registry"
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
+)
+
+// getProvenance returns the build metadata of the image; the image without metadata is returned if the registry
+// client is not configured or the registry request fails, so the enrichment never breaks notifications
+func getProvenance(client registry.Client, image string) shared.ImageProvenance {
+	if client == nil {
+		return shared.ImageProvenance{Image: image}
+	}
+	provenance, err := client.GetProvenance(context.Background(), image)
+	if err != nil {
+		log.Warnf("Failed to get provenance of image %s: %v", image, err)
+		return shared.ImageProvenance{Image: image}
+	}
+	return *provenance
+}
+
+func NewExprs(client registry.Client, app *unstructured.Unstructured) map[string]interface{} {
+	return map[string]interface{}{
+		"GetProvenance": func(image string) interface{} {
+			return getProvenance(client, image)
+		},
+		"GetAppProvenance": func() []interface{} {
+			var res []interface{}
+			if app == nil {
+				return res
+			}
+			images, _, _ := unstructured.NestedStringSlice(app.Object, "status", "summary", "images")
+			for _, image := range images {
+				res = append(res, getProvenance(client, image))
+			}
+			return res
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
+)
+
+type fakeRegistry map[string]shared.ImageProvenance
+
+func (r fakeRegistry) GetProvenance(_ context.Context, image string) (*shared.ImageProvenance, error) {
+	if provenance, ok := r[image]; ok {
+		return &provenance, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestGetAppProvenance(t *testing.T) {
+	app := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"summary": map[string]interface{}{
+				"images": []interface{}{"ghcr.io/org/app:v1", "redis:6"},
+			},
+		},
+	}}
+	client := fakeRegistry{"ghcr.io/org/app:v1": {Image: "ghcr.io/org/app:v1", Commit: "abc", BuildURL: "https://ci.example.com/42"}}
+	exprs := NewExprs(client, app)
+
+	provenance := exprs["GetAppProvenance"].(func() []interface{})()
+	assert.Equal(t, []interface{}{
+		shared.ImageProvenance{Image: "ghcr.io/org/app:v1", Commit: "abc", BuildURL: "https://ci.example.com/42"},
+		shared.ImageProvenance{Image: "redis:6"},
+	}, provenance)
+	assert.Equal(t, shared.ImageProvenance{Image: "ghcr.io/org/app:v1", Commit: "abc", BuildURL: "https://ci.example.com/42"},
+		exprs["GetProvenance"].(func(string) interface{})("ghcr.io/org/app:v1"))
+}
+
+func TestGetProvenance_NoRegistry(t *testing.T) {
+	exprs := NewExprs(nil, nil)
+	assert.Equal(t, shared.ImageProvenance{Image: "redis:6"}, exprs["GetProvenance"].(func(string) interface{})("redis:6"))
+	assert.Empty(t, exprs["GetAppProvenance"].(func() []interface{})())
+}
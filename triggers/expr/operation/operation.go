@@ -0,0 +1,24 @@
+package operation
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
+)
+
+func NewExprs(app *unstructured.Unstructured) map[string]interface{} {
+	return map[string]interface{}{
+		"Get": func() shared.Operation {
+			return shared.GetOperation(app)
+		},
+		"GetFailedHooks": func() []shared.HookResult {
+			var res []shared.HookResult
+			for _, hook := range shared.GetOperation(app).Hooks {
+				if hook.HookPhase == "Failed" || hook.HookPhase == "Error" {
+					res = append(res, hook)
+				}
+			}
+			return res
+		},
+	}
+}
@@ -0,0 +1,99 @@
+package operation
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
+)
+
+func newApp(t *testing.T, status string) *unstructured.Unstructured {
+	app := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if !assert.NoError(t, yaml.Unmarshal([]byte(status), &app.Object)) {
+		t.FailNow()
+	}
+	return app
+}
+
+func TestGet(t *testing.T) {
+	app := newApp(t, `
+status:
+  operationState:
+    phase: Failed
+    operation:
+      initiatedBy:
+        username: alice
+      sync:
+        revision: abc
+        prune: true
+        syncOptions: [CreateNamespace=true]
+        syncStrategy:
+          apply:
+            force: true
+    syncResult:
+      revision: abc
+      resources:
+      - kind: Deployment
+        name: guestbook
+        status: Synced
+      - kind: Job
+        name: migrate
+        namespace: default
+        hookType: PreSync
+        hookPhase: Failed
+        message: Job has reached the specified backoff limit
+      - kind: Job
+        name: smoke-test
+        hookType: PostSync
+        hookPhase: Succeeded`)
+	exprs := NewExprs(app)
+
+	operation := exprs["Get"].(func() shared.Operation)()
+	assert.Equal(t, "Failed", operation.Phase)
+	assert.Equal(t, "alice", operation.InitiatedBy)
+	assert.False(t, operation.Automated)
+	assert.Equal(t, "abc", operation.Revision)
+	assert.True(t, operation.Prune)
+	assert.False(t, operation.DryRun)
+	assert.True(t, operation.ApplyOnly)
+	assert.True(t, operation.Force)
+	assert.Equal(t, []string{"CreateNamespace=true"}, operation.SyncOptions)
+	assert.Len(t, operation.Hooks, 2)
+
+	failed := exprs["GetFailedHooks"].(func() []shared.HookResult)()
+	assert.Equal(t, []shared.HookResult{{
+		Kind: "Job", Namespace: "default", Name: "migrate", HookType: "PreSync", HookPhase: "Failed",
+		Message: "Job has reached the specified backoff limit",
+	}}, failed)
+}
+
+func TestGet_Automated(t *testing.T) {
+	app := newApp(t, `
+status:
+  operationState:
+    phase: Succeeded
+    operation:
+      initiatedBy:
+        automated: true
+      sync:
+        revision: abc
+        syncStrategy:
+          hook: {}`)
+
+	operation := NewExprs(app)["Get"].(func() shared.Operation)()
+	assert.True(t, operation.Automated)
+	assert.Empty(t, operation.InitiatedBy)
+	assert.Equal(t, "abc", operation.Revision)
+	assert.False(t, operation.ApplyOnly)
+	assert.False(t, operation.Force)
+}
+
+func TestGet_NoOperation(t *testing.T) {
+	exprs := NewExprs(newApp(t, `status: {}`))
+
+	assert.Equal(t, shared.Operation{}, exprs["Get"].(func() shared.Operation)())
+	assert.Empty(t, exprs["GetFailedHooks"].(func() []shared.HookResult)())
+}
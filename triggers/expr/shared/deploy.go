@@ -0,0 +1,74 @@
+package shared
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type Deploy struct {
+	// ID of the application history entry
+	ID int64
+	// Revision is the deployed revision; the revision of the first source for multi-source applications
+	Revision string
+	// DeployedAt is the time the deploy has completed
+	DeployedAt time.Time
+	// DeployStartedAt is the time the deploy has started
+	DeployStartedAt time.Time
+}
+
+// GetDeploys returns the deploys recorded in the application status.history field, the oldest first; the entries
+// without the deploy time are skipped
+func GetDeploys(app *unstructured.Unstructured) []Deploy {
+	if app == nil {
+		return nil
+	}
+	entries, _, _ := unstructured.NestedSlice(app.Object, "status", "history")
+	var deploys []Deploy
+	for i := range entries {
+		entry, ok := entries[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		deployedAt, ok := parseTime(entry["deployedAt"])
+		if !ok {
+			continue
+		}
+		deploy := Deploy{DeployedAt: deployedAt}
+		deploy.DeployStartedAt, _ = parseTime(entry["deployStartedAt"])
+		deploy.ID, _, _ = unstructured.NestedInt64(entry, "id")
+		deploy.Revision, _, _ = unstructured.NestedString(entry, "revision")
+		if revisions, _, _ := unstructured.NestedStringSlice(entry, "revisions"); deploy.Revision == "" && len(revisions) > 0 {
+			deploy.Revision = revisions[0]
+		}
+		deploys = append(deploys, deploy)
+	}
+	return deploys
+}
+
+// IsRollback returns true if the most recent deploy has moved the application to the revision deployed before the
+// previous deploy; redeploying the previous revision is not a rollback
+func IsRollback(deploys []Deploy) bool {
+	if len(deploys) < 3 {
+		return false
+	}
+	last := deploys[len(deploys)-1]
+	if last.Revision == "" || last.Revision == deploys[len(deploys)-2].Revision {
+		return false
+	}
+	for i := len(deploys) - 3; i >= 0; i-- {
+		if deploys[i].Revision == last.Revision {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTime(value interface{}) (time.Time, bool) {
+	str, ok := value.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, str)
+	return t, err == nil
+}
@@ -0,0 +1,87 @@
+package shared
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type Operation struct {
+	// Phase of the operation, e.g. Running or Succeeded
+	Phase string
+	// InitiatedBy is the name of the user who started the operation; empty for the automated sync
+	InitiatedBy string
+	// Automated is true if the operation is started by the automated sync policy
+	Automated bool
+	// Revision is the synced revision
+	Revision string
+	// Prune is true if the sync deletes the resources that are no longer in Git
+	Prune bool
+	// DryRun is true if the sync does not apply the changes
+	DryRun bool
+	// ApplyOnly is true if the sync applies the resources without running the hooks
+	ApplyOnly bool
+	// Force is true if the resources are replaced when the apply fails
+	Force bool
+	// SyncOptions are the sync options of the operation, e.g. CreateNamespace=true
+	SyncOptions []string
+	// Hooks are the results of the sync hooks
+	Hooks []HookResult
+}
+
+type HookResult struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// HookType is the hook type, e.g. PreSync or PostSync
+	HookType string
+	// HookPhase is the hook phase, e.g. Succeeded or Failed
+	HookPhase string
+	// Message of the hook result
+	Message string
+}
+
+// GetOperation returns the latest operation of the application recorded in the status.operationState field or the
+// empty operation if the application has never been synced
+func GetOperation(app *unstructured.Unstructured) Operation {
+	res := Operation{}
+	if app == nil {
+		return res
+	}
+	state, ok, _ := unstructured.NestedMap(app.Object, "status", "operationState")
+	if !ok {
+		return res
+	}
+	res.Phase, _, _ = unstructured.NestedString(state, "phase")
+	res.InitiatedBy, _, _ = unstructured.NestedString(state, "operation", "initiatedBy", "username")
+	res.Automated, _, _ = unstructured.NestedBool(state, "operation", "initiatedBy", "automated")
+	res.Revision, _, _ = unstructured.NestedString(state, "syncResult", "revision")
+	if res.Revision == "" {
+		res.Revision, _, _ = unstructured.NestedString(state, "operation", "sync", "revision")
+	}
+	res.Prune, _, _ = unstructured.NestedBool(state, "operation", "sync", "prune")
+	res.DryRun, _, _ = unstructured.NestedBool(state, "operation", "sync", "dryRun")
+	res.SyncOptions, _, _ = unstructured.NestedStringSlice(state, "operation", "sync", "syncOptions")
+	if apply, ok, _ := unstructured.NestedMap(state, "operation", "sync", "syncStrategy", "apply"); ok {
+		res.ApplyOnly = true
+		res.Force, _, _ = unstructured.NestedBool(apply, "force")
+	} else {
+		res.Force, _, _ = unstructured.NestedBool(state, "operation", "sync", "syncStrategy", "hook", "force")
+	}
+	resources, _, _ := unstructured.NestedSlice(state, "syncResult", "resources")
+	for i := range resources {
+		resource, ok := resources[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hook := HookResult{}
+		if hook.HookType, _, _ = unstructured.NestedString(resource, "hookType"); hook.HookType == "" {
+			continue
+		}
+		hook.Kind, _, _ = unstructured.NestedString(resource, "kind")
+		hook.Namespace, _, _ = unstructured.NestedString(resource, "namespace")
+		hook.Name, _, _ = unstructured.NestedString(resource, "name")
+		hook.HookPhase, _, _ = unstructured.NestedString(resource, "hookPhase")
+		hook.Message, _, _ = unstructured.NestedString(resource, "message")
+		res.Hooks = append(res.Hooks, hook)
+	}
+	return res
+}
@@ -0,0 +1,20 @@
+package shared
+
+type ImageProvenance struct {
+	// Image reference, e.g. ghcr.io/argoproj/guestbook:v1
+	Image string
+	// Digest of the image manifest
+	Digest string
+	// Commit is the revision the image is built from
+	Commit string
+	// Source is the URL of the image source repository
+	Source string
+	// BuildURL is the URL of the CI run that built the image
+	BuildURL string
+	// Created is the image build date
+	Created string
+	// Version of the packaged software
+	Version string
+	// Labels are the image config labels and the manifest annotations
+	Labels map[string]string
+}
@@ -4,7 +4,9 @@ package triggers
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"sync"
 	texttemplate "text/template"
 
 	"github.com/Masterminds/sprig"
@@ -15,6 +17,7 @@ import (
 	"github.com/argoproj-labs/argocd-notifications/notifiers"
 	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
 	exprHelpers "github.com/argoproj-labs/argocd-notifications/triggers/expr"
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
 )
 
 type NotificationTrigger struct {
@@ -23,6 +26,12 @@ type NotificationTrigger struct {
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
 	Template    string `json:"template,omitempty" yaml:"template,omitempty"`
 	Enabled     *bool  `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// Event is the type of the external event that fires the trigger; the trigger is not evaluated on application
+	// changes if the event is specified
+	Event string `json:"event,omitempty" yaml:"event,omitempty"`
+	// OncePer is the expression which value identifies the trigger firing, e.g. the synced revision. The notification
+	// is sent once per value even if the condition flips or the controller restarts.
+	OncePer string `json:"oncePer,omitempty" yaml:"oncePer,omitempty"`
 }
 
 type NotificationTemplate struct {
@@ -36,12 +45,66 @@ type Trigger interface {
 	GetTemplateName() string
 }
 
+// EventTrigger is the trigger which can be fired by the external events. The event is available in the condition and
+// templates as the event variable.
+type EventTrigger interface {
+	Trigger
+	// GetEvent returns the type of external events that fire the trigger or empty string if the trigger is fired by
+	// the application changes
+	GetEvent() string
+	TriggeredByEvent(app *unstructured.Unstructured, event map[string]interface{}) (bool, error)
+	FormatEventNotification(app *unstructured.Unstructured, event map[string]interface{}, context map[string]string) (*notifiers.Notification, error)
+}
+
+// OncePerTrigger is the trigger which notification is sent once per value of the oncePer expression
+type OncePerTrigger interface {
+	Trigger
+	// HasOncePer returns true if the oncePer expression is specified
+	HasOncePer() bool
+	// GetOncePer returns the string representation of the oncePer expression value
+	GetOncePer(app *unstructured.Unstructured) (string, error)
+}
+
 type webhookTemplate struct {
 	body   *texttemplate.Template
 	path   *texttemplate.Template
 	method string
 }
 
+type cloudEventsTemplate struct {
+	eventType *texttemplate.Template
+	subject   *texttemplate.Template
+	data      *texttemplate.Template
+}
+
+type gitHubDispatchTemplate struct {
+	eventType     *texttemplate.Template
+	clientPayload *texttemplate.Template
+}
+
+type deploymentTemplate struct {
+	repoURL        *texttemplate.Template
+	ref            *texttemplate.Template
+	targetRevision *texttemplate.Template
+	state          *texttemplate.Template
+	description    *texttemplate.Template
+	environmentURL *texttemplate.Template
+	logURL         *texttemplate.Template
+}
+
+// fields returns the deployment field templates and the rendered field destinations
+func (t *deploymentTemplate) fields(d *notifiers.DeploymentNotification) map[*texttemplate.Template]*string {
+	return map[*texttemplate.Template]*string{
+		t.repoURL:        &d.RepoURL,
+		t.ref:            &d.Ref,
+		t.targetRevision: &d.TargetRevision,
+		t.state:          &d.State,
+		t.description:    &d.Description,
+		t.environmentURL: &d.EnvironmentURL,
+		t.logURL:         &d.LogURL,
+	}
+}
+
 type template struct {
 	name             string
 	title            *texttemplate.Template
@@ -49,16 +112,37 @@ type template struct {
 	slackAttachments *texttemplate.Template
 	slackBlocks      *texttemplate.Template
 	webhooks         map[string]webhookTemplate
+	cloudEvents      *cloudEventsTemplate
+	deployment       *deploymentTemplate
+	jenkinsParams    map[string]*texttemplate.Template
+	githubDispatch   *gitHubDispatchTemplate
 }
 
-func (tmpl template) formatNotification(app *unstructured.Unstructured, context map[string]string, argocdService argocd.Service) (*notifiers.Notification, error) {
+func (tmpl template) formatNotification(app *unstructured.Unstructured, event map[string]interface{}, context map[string]string, argocdService argocd.Service) (*notifiers.Notification, error) {
 	vars := map[string]interface{}{
-		"app":     app.Object,
+		"app":     appVars(app),
 		"context": context,
 	}
+	if event != nil {
+		vars["event"] = event
+	}
 	for k, v := range exprHelpers.Spawn(app, argocdService) {
 		vars[k] = v
 	}
+	return tmpl.execute(vars)
+}
+
+// FormatTemplate renders the template using the specified variables instead of the application, e.g. the
+// notifications collected into the digest
+func FormatTemplate(nt NotificationTemplate, vars map[string]interface{}) (*notifiers.Notification, error) {
+	templates, err := parseTemplates([]NotificationTemplate{nt}, parseTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return templates[nt.Name].execute(vars)
+}
+
+func (tmpl template) execute(vars map[string]interface{}) (*notifiers.Notification, error) {
 	var title bytes.Buffer
 
 	err := tmpl.title.Execute(&title, vars)
@@ -108,23 +192,132 @@ func (tmpl template) formatNotification(app *unstructured.Unstructured, context
 			Path:   path.String(),
 		}
 	}
+	if tmpl.cloudEvents != nil {
+		var eventType, subject, data bytes.Buffer
+		if err = tmpl.cloudEvents.eventType.Execute(&eventType, vars); err != nil {
+			return nil, err
+		}
+		if err = tmpl.cloudEvents.subject.Execute(&subject, vars); err != nil {
+			return nil, err
+		}
+		if err = tmpl.cloudEvents.data.Execute(&data, vars); err != nil {
+			return nil, err
+		}
+		notification.CloudEvents = &notifiers.CloudEventsNotification{
+			Type:    eventType.String(),
+			Subject: subject.String(),
+			Data:    data.String(),
+		}
+	}
+	if tmpl.deployment != nil {
+		notification.Deployment = &notifiers.DeploymentNotification{}
+		for fieldTemplate, field := range tmpl.deployment.fields(notification.Deployment) {
+			var value bytes.Buffer
+			if err = fieldTemplate.Execute(&value, vars); err != nil {
+				return nil, err
+			}
+			*field = value.String()
+		}
+	}
+	if tmpl.jenkinsParams != nil {
+		notification.Jenkins = &notifiers.JenkinsNotification{Parameters: map[string]string{}}
+		for k, paramTemplate := range tmpl.jenkinsParams {
+			var value bytes.Buffer
+			if err = paramTemplate.Execute(&value, vars); err != nil {
+				return nil, err
+			}
+			notification.Jenkins.Parameters[k] = value.String()
+		}
+	}
+	if tmpl.githubDispatch != nil {
+		var eventType, clientPayload bytes.Buffer
+		if err = tmpl.githubDispatch.eventType.Execute(&eventType, vars); err != nil {
+			return nil, err
+		}
+		if err = tmpl.githubDispatch.clientPayload.Execute(&clientPayload, vars); err != nil {
+			return nil, err
+		}
+		notification.GitHubDispatch = &notifiers.GitHubDispatchNotification{
+			EventType:     eventType.String(),
+			ClientPayload: clientPayload.String(),
+		}
+	}
 	return notification, nil
 }
 
 type trigger struct {
+	event         string
 	condition     *vm.Program
+	oncePer       *vm.Program
 	template      template
 	argocdService argocd.Service
 }
 
 func GetTriggers(templatesCfg []NotificationTemplate, triggersCfg []NotificationTrigger, argocdService argocd.Service) (map[string]Trigger, error) {
-	templates, err := parseTemplates(templatesCfg)
+	return NewCache().GetTriggers(templatesCfg, triggersCfg, argocdService)
+}
+
+// Cache holds compiled trigger conditions and parsed templates, so the configuration reload recompiles only changed
+// triggers and templates. The cache keeps entries used by the latest GetTriggers call.
+type Cache struct {
+	lock       sync.Mutex
+	conditions map[string]*vm.Program
+	templates  map[string]template
+}
+
+func NewCache() *Cache {
+	return &Cache{conditions: map[string]*vm.Program{}, templates: map[string]template{}}
+}
+
+// GetTriggers returns triggers using the previously compiled conditions and templates if they are not changed
+func (c *Cache) GetTriggers(templatesCfg []NotificationTemplate, triggersCfg []NotificationTrigger, argocdService argocd.Service) (map[string]Trigger, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	conditions := map[string]*vm.Program{}
+	templates := map[string]template{}
+	parsedTemplates, err := parseTemplates(templatesCfg, func(nt NotificationTemplate, f texttemplate.FuncMap) (*template, error) {
+		data, err := json.Marshal(nt)
+		if err != nil {
+			return nil, err
+		}
+		key := string(data)
+		t, ok := c.templates[key]
+		if !ok {
+			parsed, err := parseTemplate(nt, f)
+			if err != nil {
+				return nil, err
+			}
+			t = *parsed
+		}
+		templates[key] = t
+		return &t, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return parseTriggers(triggersCfg, templates, argocdService)
+	res, err := parseTriggers(triggersCfg, parsedTemplates, argocdService, func(conditionExpr string) (*vm.Program, error) {
+		condition, ok := c.conditions[conditionExpr]
+		if !ok {
+			var err error
+			if condition, err = expr.Compile(conditionExpr); err != nil {
+				return nil, err
+			}
+		}
+		conditions[conditionExpr] = condition
+		return condition, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.conditions = conditions
+	c.templates = templates
+	return res, nil
 }
 
+type compileFunc func(conditionExpr string) (*vm.Program, error)
+
+type parseTemplateFunc func(nt NotificationTemplate, f texttemplate.FuncMap) (*template, error)
+
 func spawnExprEnvs(app *unstructured.Unstructured, opts map[string]interface{}, argocdService argocd.Service) interface{} {
 	envs := exprHelpers.Spawn(app, argocdService)
 	for name, env := range opts {
@@ -134,8 +327,26 @@ func spawnExprEnvs(app *unstructured.Unstructured, opts map[string]interface{},
 	return envs
 }
 
+// appVars returns the application object extended with the computed fields available in the conditions and
+// templates; the object is copied so the computed fields are not added to the cached application
+func appVars(app *unstructured.Unstructured) map[string]interface{} {
+	vars := make(map[string]interface{}, len(app.Object)+1)
+	for k, v := range app.Object {
+		vars[k] = v
+	}
+	vars["isRollback"] = shared.IsRollback(shared.GetDeploys(app))
+	return vars
+}
+
 func (t *trigger) Triggered(app *unstructured.Unstructured) (bool, error) {
-	envs := map[string]interface{}{"app": app.Object}
+	return t.TriggeredByEvent(app, nil)
+}
+
+func (t *trigger) TriggeredByEvent(app *unstructured.Unstructured, event map[string]interface{}) (bool, error) {
+	envs := map[string]interface{}{"app": appVars(app)}
+	if event != nil {
+		envs["event"] = event
+	}
 	if res, err := expr.Run(t.condition, spawnExprEnvs(app, envs, t.argocdService)); err != nil {
 		return false, err
 	} else if boolRes, ok := res.(bool); ok {
@@ -144,15 +355,38 @@ func (t *trigger) Triggered(app *unstructured.Unstructured) (bool, error) {
 	return false, nil
 }
 
+func (t *trigger) HasOncePer() bool {
+	return t.oncePer != nil
+}
+
+func (t *trigger) GetOncePer(app *unstructured.Unstructured) (string, error) {
+	if t.oncePer == nil {
+		return "", nil
+	}
+	res, err := expr.Run(t.oncePer, spawnExprEnvs(app, map[string]interface{}{"app": appVars(app)}, t.argocdService))
+	if err != nil || res == nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v", res), nil
+}
+
 func (t *trigger) GetTemplateName() string {
 	return t.template.name
 }
 
+func (t *trigger) GetEvent() string {
+	return t.event
+}
+
 func (t *trigger) FormatNotification(app *unstructured.Unstructured, context map[string]string) (*notifiers.Notification, error) {
-	return t.template.formatNotification(app, context, t.argocdService)
+	return t.template.formatNotification(app, nil, context, t.argocdService)
 }
 
-func parseTemplates(templates []NotificationTemplate) (map[string]template, error) {
+func (t *trigger) FormatEventNotification(app *unstructured.Unstructured, event map[string]interface{}, context map[string]string) (*notifiers.Notification, error) {
+	return t.template.formatNotification(app, event, context, t.argocdService)
+}
+
+func parseTemplates(templates []NotificationTemplate, parseTemplate parseTemplateFunc) (map[string]template, error) {
 	res := make(map[string]template)
 	f := sprig.TxtFuncMap()
 	delete(f, "env")
@@ -178,7 +412,7 @@ func parseTemplate(nt NotificationTemplate, f texttemplate.FuncMap) (*template,
 	if err != nil {
 		return nil, err
 	}
-	t := template{title: title, body: body}
+	t := template{name: nt.Name, title: title, body: body}
 	if nt.Slack != nil {
 		slackAttachments, err := texttemplate.New(nt.Name).Funcs(f).Parse(nt.Slack.Attachments)
 		if err != nil {
@@ -204,27 +438,89 @@ func parseTemplate(nt NotificationTemplate, f texttemplate.FuncMap) (*template,
 		}
 		t.webhooks[k] = webhookTemplate{body: body, method: v.Method, path: path}
 	}
+	if nt.CloudEvents != nil {
+		eventType, err := texttemplate.New(nt.Name).Funcs(f).Parse(nt.CloudEvents.Type)
+		if err != nil {
+			return nil, err
+		}
+		subject, err := texttemplate.New(nt.Name).Funcs(f).Parse(nt.CloudEvents.Subject)
+		if err != nil {
+			return nil, err
+		}
+		data, err := texttemplate.New(nt.Name).Funcs(f).Parse(nt.CloudEvents.Data)
+		if err != nil {
+			return nil, err
+		}
+		t.cloudEvents = &cloudEventsTemplate{eventType: eventType, subject: subject, data: data}
+	}
+	if nt.Deployment != nil {
+		t.deployment = &deploymentTemplate{}
+		fields := map[**texttemplate.Template]string{
+			&t.deployment.repoURL:        nt.Deployment.RepoURL,
+			&t.deployment.ref:            nt.Deployment.Ref,
+			&t.deployment.targetRevision: nt.Deployment.TargetRevision,
+			&t.deployment.state:          nt.Deployment.State,
+			&t.deployment.description:    nt.Deployment.Description,
+			&t.deployment.environmentURL: nt.Deployment.EnvironmentURL,
+			&t.deployment.logURL:         nt.Deployment.LogURL,
+		}
+		for field, text := range fields {
+			if *field, err = texttemplate.New(nt.Name).Funcs(f).Parse(text); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if nt.Jenkins != nil {
+		t.jenkinsParams = map[string]*texttemplate.Template{}
+		for k, v := range nt.Jenkins.Parameters {
+			if t.jenkinsParams[k], err = texttemplate.New(k).Funcs(f).Parse(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if nt.GitHubDispatch != nil {
+		eventType, err := texttemplate.New(nt.Name).Funcs(f).Parse(nt.GitHubDispatch.EventType)
+		if err != nil {
+			return nil, err
+		}
+		clientPayload, err := texttemplate.New(nt.Name).Funcs(f).Parse(nt.GitHubDispatch.ClientPayload)
+		if err != nil {
+			return nil, err
+		}
+		t.githubDispatch = &gitHubDispatchTemplate{eventType: eventType, clientPayload: clientPayload}
+	}
 	return &t, nil
 }
 
-func parseTriggers(triggers []NotificationTrigger, templates map[string]template, argocdService argocd.Service) (map[string]Trigger, error) {
+func parseTriggers(triggers []NotificationTrigger, templates map[string]template, argocdService argocd.Service, compile compileFunc) (map[string]Trigger, error) {
 	res := make(map[string]Trigger)
 	for _, t := range triggers {
 		if t.Enabled != nil && !*t.Enabled {
 			continue
 		}
-		if t.Condition == "" {
-			return nil, fmt.Errorf("trigger '%s' condition is empty", t.Name)
+		conditionExpr := t.Condition
+		if conditionExpr == "" {
+			if t.Event == "" {
+				return nil, fmt.Errorf("trigger '%s' condition is empty", t.Name)
+			}
+			// the event trigger fires on every event of the specified type by default
+			conditionExpr = "true"
 		}
-		condition, err := expr.Compile(t.Condition)
+		condition, err := compile(conditionExpr)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse trigger '%s' condition: %v", t.Name, err)
 		}
+		var oncePer *vm.Program
+		if t.OncePer != "" {
+			if oncePer, err = compile(t.OncePer); err != nil {
+				return nil, fmt.Errorf("failed to parse trigger '%s' oncePer expression: %v", t.Name, err)
+			}
+		}
 		template, ok := templates[t.Template]
 		if !ok {
 			return nil, fmt.Errorf("trigger %s references unknown template %s", t.Name, t.Template)
 		}
-		res[t.Name] = &trigger{condition: condition, template: template, argocdService: argocdService}
+		res[t.Name] = &trigger{event: t.Event, condition: condition, oncePer: oncePer, template: template, argocdService: argocdService}
 	}
 	return res, nil
 }
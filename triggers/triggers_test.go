@@ -1,6 +1,7 @@
 package triggers
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -36,6 +37,7 @@ func TestGetTriggers(t *testing.T) {
 
 	trigger, ok := triggers["trigger"]
 	assert.True(t, ok)
+	assert.Equal(t, "template", trigger.GetTemplateName())
 
 	ok, err = trigger.Triggered(testingutil.NewApp("foo"))
 	assert.NoError(t, err)
@@ -51,6 +53,110 @@ func TestGetTriggers(t *testing.T) {
 	assert.Equal(t, "the body: test", notification.Body)
 }
 
+func TestGetTriggers_EventTrigger(t *testing.T) {
+	triggers, err := GetTriggers([]NotificationTemplate{{
+		Name: "template",
+		Notification: notifiers.Notification{
+			Title: "{{.app.metadata.name}} image updated to {{.event.payload.image}}",
+		},
+	}}, []NotificationTrigger{{
+		Name:      "on-image-updated",
+		Template:  "template",
+		Event:     "image-updated",
+		Condition: "event.payload.image != ''",
+	}, {
+		Name:     "on-ci-finished",
+		Template: "template",
+		Event:    "ci-finished",
+	}}, nil)
+	assert.NoError(t, err)
+
+	trigger, ok := triggers["on-image-updated"].(EventTrigger)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, "image-updated", trigger.GetEvent())
+	event := map[string]interface{}{"type": "image-updated", "payload": map[string]interface{}{"image": "guestbook:v2"}}
+	ok, err = trigger.TriggeredByEvent(testingutil.NewApp("guestbook"), event)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	notification, err := trigger.FormatEventNotification(testingutil.NewApp("guestbook"), event, map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "guestbook image updated to guestbook:v2", notification.Title)
+
+	ok, err = triggers["on-ci-finished"].(EventTrigger).TriggeredByEvent(testingutil.NewApp("guestbook"), event)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestGetTriggers_OncePer(t *testing.T) {
+	triggers, err := GetTriggers([]NotificationTemplate{{Name: "template"}}, []NotificationTrigger{{
+		Name:      "on-deployed",
+		Template:  "template",
+		Condition: "true",
+		OncePer:   "app.metadata.name",
+	}, {
+		Name:      "on-sync",
+		Template:  "template",
+		Condition: "true",
+	}}, nil)
+	assert.NoError(t, err)
+
+	trigger, ok := triggers["on-deployed"].(OncePerTrigger)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.True(t, trigger.HasOncePer())
+	oncePer, err := trigger.GetOncePer(testingutil.NewApp("guestbook"))
+	assert.NoError(t, err)
+	assert.Equal(t, "guestbook", oncePer)
+
+	assert.False(t, triggers["on-sync"].(OncePerTrigger).HasOncePer())
+}
+
+func TestGetTriggers_IsRollback(t *testing.T) {
+	triggers, err := GetTriggers([]NotificationTemplate{{
+		Name: "template",
+		Notification: notifiers.Notification{
+			Title: "{{.app.metadata.name}} rolled back: {{.app.isRollback}}",
+		},
+	}}, []NotificationTrigger{{
+		Name:      "on-rollback",
+		Template:  "template",
+		Condition: "app.isRollback",
+	}}, nil)
+	assert.NoError(t, err)
+	trigger := triggers["on-rollback"]
+
+	for history, expected := range map[string]bool{
+		"":        false,
+		"a,b":     false,
+		"a,b,c":   false,
+		"a,b,b":   false,
+		"a,b,a":   true,
+		"a,b,c,a": true,
+	} {
+		app := testingutil.NewApp("guestbook", testingutil.WithHistory(strings.Split(history, ",")...))
+		ok, err := trigger.Triggered(app)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, ok, history)
+		_, computed := app.Object["isRollback"]
+		assert.False(t, computed)
+	}
+
+	notification, err := trigger.FormatNotification(testingutil.NewApp("guestbook", testingutil.WithHistory("a", "b", "a")), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "guestbook rolled back: true", notification.Title)
+}
+
+func TestGetTriggers_FailsIfOncePerIsInvalid(t *testing.T) {
+	_, err := GetTriggers([]NotificationTemplate{{Name: "template"}}, []NotificationTrigger{{
+		Name: "on-deployed", Template: "template", Condition: "true", OncePer: "app.(",
+	}}, nil)
+	assert.Error(t, err)
+}
+
 func TestGetTriggers_UsingContext(t *testing.T) {
 	triggers, err := GetTriggers([]NotificationTemplate{{
 		Name: "template",
@@ -171,7 +277,7 @@ func TestTrigger_FormatWebhookNotification(t *testing.T) {
 				},
 			},
 		},
-	}})
+	}}, parseTemplate)
 	assert.NoError(t, err)
 
 	testTemplate, ok := templates["myTemplate"]
@@ -179,7 +285,7 @@ func TestTrigger_FormatWebhookNotification(t *testing.T) {
 		return
 	}
 
-	nt, err := testTemplate.formatNotification(testingutil.NewApp("world"), map[string]string{}, nil)
+	nt, err := testTemplate.formatNotification(testingutil.NewApp("world"), nil, map[string]string{}, nil)
 	if !assert.NoError(t, err) {
 		return
 	}
@@ -190,3 +296,126 @@ func TestTrigger_FormatWebhookNotification(t *testing.T) {
 	}
 	assert.Equal(t, hook.Body, "hello world")
 }
+
+func TestFormatTemplate(t *testing.T) {
+	n, err := FormatTemplate(NotificationTemplate{
+		Name: "digest",
+		Notification: notifiers.Notification{
+			Title: "{{.count}} notification(s)",
+			Body:  "{{range .apps}}{{.name}}:{{range .notifications}} {{.trigger}}{{end}}{{end}}",
+		},
+	}, map[string]interface{}{
+		"count": 2,
+		"apps": []map[string]interface{}{{
+			"name":          "guestbook",
+			"notifications": []map[string]interface{}{{"trigger": "on-deployed"}, {"trigger": "on-sync-failed"}},
+		}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2 notification(s)", n.Title)
+	assert.Equal(t, "guestbook: on-deployed on-sync-failed", n.Body)
+}
+
+func TestTrigger_FormatCloudEventsNotification(t *testing.T) {
+	templates, err := parseTemplates([]NotificationTemplate{{
+		Name: "myTemplate",
+		Notification: notifiers.Notification{
+			CloudEvents: &notifiers.CloudEventsNotification{
+				Type:    "io.example.app.{{.app.metadata.name}}",
+				Subject: "{{.app.metadata.namespace}}/{{.app.metadata.name}}",
+				Data:    `{"app": "{{.app.metadata.name}}"}`,
+			},
+		},
+	}}, parseTemplate)
+	assert.NoError(t, err)
+
+	nt, err := templates["myTemplate"].formatNotification(testingutil.NewApp("world"), nil, map[string]string{}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &notifiers.CloudEventsNotification{
+		Type:    "io.example.app.world",
+		Subject: "default/world",
+		Data:    `{"app": "world"}`,
+	}, nt.CloudEvents)
+}
+
+func TestTrigger_FormatDeploymentNotification(t *testing.T) {
+	templates, err := parseTemplates([]NotificationTemplate{{
+		Name: "myTemplate",
+		Notification: notifiers.Notification{
+			Deployment: &notifiers.DeploymentNotification{
+				RepoURL: "https://github.com/argoproj/{{.app.metadata.name}}.git",
+				Ref:     "abc",
+				State:   "success",
+				LogURL:  "https://argocd.example.com/applications/{{.app.metadata.name}}",
+			},
+		},
+	}}, parseTemplate)
+	assert.NoError(t, err)
+
+	nt, err := templates["myTemplate"].formatNotification(testingutil.NewApp("world"), nil, map[string]string{}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &notifiers.DeploymentNotification{
+		RepoURL: "https://github.com/argoproj/world.git",
+		Ref:     "abc",
+		State:   "success",
+		LogURL:  "https://argocd.example.com/applications/world",
+	}, nt.Deployment)
+}
+
+func TestTrigger_FormatCINotification(t *testing.T) {
+	templates, err := parseTemplates([]NotificationTemplate{{
+		Name: "myTemplate",
+		Notification: notifiers.Notification{
+			Jenkins: &notifiers.JenkinsNotification{
+				Parameters: map[string]string{"APP": "{{.app.metadata.name}}"},
+			},
+			GitHubDispatch: &notifiers.GitHubDispatchNotification{
+				EventType:     "deployed",
+				ClientPayload: `{"app": "{{.app.metadata.name}}"}`,
+			},
+		},
+	}}, parseTemplate)
+	assert.NoError(t, err)
+
+	nt, err := templates["myTemplate"].formatNotification(testingutil.NewApp("world"), nil, map[string]string{}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &notifiers.JenkinsNotification{Parameters: map[string]string{"APP": "world"}}, nt.Jenkins)
+	assert.Equal(t, &notifiers.GitHubDispatchNotification{EventType: "deployed", ClientPayload: `{"app": "world"}`}, nt.GitHubDispatch)
+}
+
+func TestCache_GetTriggers(t *testing.T) {
+	cache := NewCache()
+	templates := []NotificationTemplate{{Name: "test", Notification: notifiers.Notification{Title: "hello"}}}
+	triggersCfg := []NotificationTrigger{{Name: "test", Condition: "true", Template: "test"}}
+	first, err := cache.GetTriggers(templates, triggersCfg, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	second, err := cache.GetTriggers(templates, triggersCfg, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Same(t, first["test"].(*trigger).condition, second["test"].(*trigger).condition)
+	assert.Same(t, first["test"].(*trigger).template.title, second["test"].(*trigger).template.title)
+
+	templates[0].Title = "updated"
+	triggersCfg[0].Condition = "false"
+	third, err := cache.GetTriggers(templates, triggersCfg, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotSame(t, first["test"].(*trigger).condition, third["test"].(*trigger).condition)
+	assert.NotSame(t, first["test"].(*trigger).template.title, third["test"].(*trigger).template.title)
+	assert.Len(t, cache.conditions, 1)
+	assert.Len(t, cache.templates, 1)
+}
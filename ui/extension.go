@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const (
+	// appNameHeader is the header set by the Argo CD API server proxy extension in <namespace>:<name> format
+	appNameHeader = "Argocd-Application-Name"
+)
+
+// TriggerSubscriptions holds the recipients of the trigger notifications
+type TriggerSubscriptions struct {
+	Trigger    string                       `json:"trigger"`
+	Recipients []settings.ResolvedRecipient `json:"recipients"`
+}
+
+// AppStatus is the notifications status of the application
+type AppStatus struct {
+	Application string `json:"application"`
+	Namespace   string `json:"namespace"`
+	// Subscriptions are the effective recipients of every trigger
+	Subscriptions []TriggerSubscriptions `json:"subscriptions"`
+	// Notifications are the last delivery attempts per trigger and recipient; empty if the history is disabled
+	Notifications []history.Event `json:"notifications"`
+	// Failures are the recent failed delivery attempts starting from the newest one
+	Failures []history.Event `json:"failures"`
+	// HistoryEnabled is false if the notification history is disabled
+	HistoryEnabled bool `json:"historyEnabled"`
+}
+
+// appName returns the application namespace and name from the app query parameter or the Argo CD proxy extension
+// header in [<namespace>:]<name> format; the namespace is empty if not specified
+func appName(r *http.Request) (string, string) {
+	name := r.URL.Query().Get("app")
+	if name == "" {
+		name = r.Header.Get(appNameHeader)
+	}
+	namespace := ""
+	if parts := strings.SplitN(name, ":", 2); len(parts) == 2 {
+		namespace, name = parts[0], parts[1]
+	}
+	return strings.TrimSpace(namespace), strings.TrimSpace(name)
+}
+
+func writeJSON(w http.ResponseWriter, status int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(obj)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// serveAppStatus responds with the application notifications status. The endpoint is designed for the Argo CD UI
+// extensions and accepts the application name in the Argocd-Application-Name header set by the Argo CD proxy.
+func (s *server) serveAppStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method "+r.Method+" is not supported")
+		return
+	}
+	namespace, name := appName(r)
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "application name is not specified")
+		return
+	}
+	cfg, _ := s.opts.Config()
+	if cfg == nil {
+		writeError(w, http.StatusServiceUnavailable, "configuration is not loaded")
+		return
+	}
+	app, err := s.opts.GetApp(namespace, name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	var proj *unstructured.Unstructured
+	if projName, _, _ := unstructured.NestedString(app.Object, "spec", "project"); projName != "" && s.opts.GetProject != nil {
+		// the project annotations are ignored if the project cannot be loaded, the same way as the controller does
+		proj, _ = s.opts.GetProject(projName)
+	}
+	status := AppStatus{
+		Application:    name,
+		Namespace:      app.GetNamespace(),
+		Subscriptions:  []TriggerSubscriptions{},
+		Notifications:  []history.Event{},
+		Failures:       []history.Event{},
+		HistoryEnabled: s.opts.History != nil,
+	}
+	for _, t := range cfg.Triggers {
+		item := TriggerSubscriptions{Trigger: t.Name, Recipients: []settings.ResolvedRecipient{}}
+		item.Recipients = append(item.Recipients, settings.ExplainRecipients(app, proj, t.Name, cfg.Subscriptions, cfg.Policies)...)
+		status.Subscriptions = append(status.Subscriptions, item)
+	}
+	if s.opts.History != nil {
+		events, err := s.opts.History.List(history.Filter{App: name, Since: time.Now().Add(-s.opts.StatsWindow)})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if namespace != "" {
+			events = namespaceEvents(events, namespace)
+		}
+		status.Notifications = lastEvents(events)
+		for _, e := range recentEvents(events, len(events)) {
+			if e.Status == history.StatusFailed && len(status.Failures) < s.opts.RecentLimit {
+				status.Failures = append(status.Failures, e)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// namespaceEvents returns the events of the applications in the namespace; the events recorded without the application
+// namespace are kept
+func namespaceEvents(events []history.Event, namespace string) []history.Event {
+	res := make([]history.Event, 0, len(events))
+	for _, e := range events {
+		if e.AppNamespace == "" || e.AppNamespace == namespace {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// lastEvents returns the most recent event of every trigger and recipient pair
+func lastEvents(events []history.Event) []history.Event {
+	latest := map[string]int{}
+	var keys []string
+	for i, e := range events {
+		key := e.AppNamespace + "/" + e.Trigger + "/" + e.Recipient
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		}
+		latest[key] = i
+	}
+	res := make([]history.Event, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, events[latest[key]])
+	}
+	return res
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func newExtensionTestHandler(store history.Store) http.Handler {
+	cfg := &settings.Config{
+		Triggers: []triggers.NotificationTrigger{{Name: "on-sync-failed", Condition: "true", Template: "app-sync-failed"}},
+		Subscriptions: settings.DefaultSubscriptions{{
+			Recipients: []string{"slack:platform"}, Triggers: []string{"on-sync-failed"},
+		}},
+	}
+	return NewHandler(Options{
+		Config: func() (*settings.Config, map[string]notifiers.Notifier) {
+			return cfg, nil
+		},
+		GetApp: func(namespace string, name string) (*unstructured.Unstructured, error) {
+			if name != "guestbook" || namespace != "" && namespace != "argocd" {
+				return nil, errors.New("application " + namespace + "/" + name + " is not found")
+			}
+			app := NewApp(name, WithProject("default"), WithAnnotations(map[string]string{
+				"argocd-notifications.argoproj.io/subscribe.on-sync-failed.slack": "guestbook",
+			}))
+			app.SetNamespace("argocd")
+			return app, nil
+		},
+		GetProject: func(name string) (*unstructured.Unstructured, error) {
+			return NewProject(name, WithAnnotations(map[string]string{
+				"argocd-notifications.argoproj.io/subscribe.on-sync-failed.slack": "team",
+			})), nil
+		},
+		History: store,
+	})
+}
+
+func TestAppStatus(t *testing.T) {
+	store := history.NewMemoryStore(0)
+	now := time.Now()
+	assert.NoError(t, store.Record(history.Event{App: "guestbook", AppNamespace: "argocd", Trigger: "on-sync-failed", Recipient: "slack:guestbook", Timestamp: now.Add(-time.Minute), Status: history.StatusFailed, Error: "timeout"}))
+	assert.NoError(t, store.Record(history.Event{App: "guestbook", AppNamespace: "argocd", Trigger: "on-sync-failed", Recipient: "slack:guestbook", Timestamp: now, Status: history.StatusSent}))
+	assert.NoError(t, store.Record(history.Event{App: "guestbook", AppNamespace: "team-a", Trigger: "on-sync-failed", Recipient: "slack:guestbook", Timestamp: now, Status: history.StatusFailed}))
+	assert.NoError(t, store.Record(history.Event{App: "other", Trigger: "on-sync-failed", Recipient: "slack:guestbook", Timestamp: now, Status: history.StatusFailed}))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/application", nil)
+	r.Header.Set(appNameHeader, "argocd:guestbook")
+	w := httptest.NewRecorder()
+	newExtensionTestHandler(store).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var status AppStatus
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, "guestbook", status.Application)
+	assert.Equal(t, "argocd", status.Namespace)
+	assert.True(t, status.HistoryEnabled)
+	if assert.Len(t, status.Subscriptions, 1) {
+		var recipients []string
+		for _, r := range status.Subscriptions[0].Recipients {
+			recipients = append(recipients, r.Recipient)
+		}
+		assert.ElementsMatch(t, []string{"slack:platform", "slack:guestbook", "slack:team"}, recipients)
+	}
+	if assert.Len(t, status.Notifications, 1) {
+		assert.Equal(t, history.StatusSent, status.Notifications[0].Status)
+	}
+	if assert.Len(t, status.Failures, 1) {
+		assert.Equal(t, "timeout", status.Failures[0].Error)
+	}
+}
+
+func TestAppStatus_Errors(t *testing.T) {
+	handler := newExtensionTestHandler(nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/application", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/application?app=unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":"application /unknown is not found"}`, w.Body.String())
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/application", nil)
+	r.Header.Set(appNameHeader, "team-a:guestbook")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":"application team-a/guestbook is not found"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/application?app=guestbook", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"historyEnabled":false`)
+}
@@ -0,0 +1,88 @@
+package ui
+
+// pageHTML is the single page of the web UI; the page has no external dependencies, so it works in air-gapped clusters
+const pageHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Argo CD Notifications</title>
+<style>
+body { font-family: sans-serif; margin: 0 2em 2em; color: #333; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ddd; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; vertical-align: top; padding: 4px 8px; border-bottom: 1px solid #eee; }
+pre { margin: 0; white-space: pre-wrap; background: #f6f8fa; padding: 4px; }
+.failed, .error { color: #c00; }
+.muted { color: #888; }
+textarea { width: 100%; height: 6em; }
+label { display: block; margin-top: 0.5em; }
+</style>
+</head>
+<body>
+<h1>Argo CD Notifications</h1>
+{{if not .Config}}<p class="error">Configuration is not loaded yet.</p>{{end}}
+
+<h2 id="triggers">Triggers</h2>
+<table>
+<tr><th>Name</th><th>Description</th><th>Condition</th><th>Template</th><th>Enabled</th></tr>
+{{range .Triggers}}<tr>
+<td>{{.Name}}</td><td>{{.Description}}</td><td><pre>{{.Condition}}</pre></td><td><a href="#template-{{.Template}}">{{.Template}}</a></td>
+<td>{{if isEnabled .}}yes{{else}}<span class="muted">no</span>{{end}}</td>
+</tr>{{end}}
+</table>
+
+<h2 id="templates">Templates</h2>
+<table>
+<tr><th>Name</th><th>Title</th><th>Body</th></tr>
+{{range .Templates}}<tr id="template-{{.Name}}">
+<td>{{.Name}}</td><td><pre>{{.Title}}</pre></td><td><pre>{{.Body}}</pre></td>
+</tr>{{end}}
+</table>
+
+{{with .Config}}{{if .Subscriptions}}<h2 id="subscriptions">Default Subscriptions</h2>
+<table>
+<tr><th>Recipients</th><th>Triggers</th></tr>
+{{range .Subscriptions}}<tr><td>{{range .Recipients}}{{.}} {{end}}</td><td>{{if .Triggers}}{{range .Triggers}}{{.}} {{end}}{{else}}<span class="muted">all triggers</span>{{end}}</td></tr>{{end}}
+</table>{{end}}{{end}}
+
+<h2 id="deliveries">Deliveries</h2>
+{{if not .HistoryEnabled}}<p class="muted">Notification history is disabled. Use the --history-backend flag to enable it.</p>
+{{else if .HistoryError}}<p class="error">Failed to load notification history: {{.HistoryError}}</p>
+{{else}}<h3>Error rates during the last {{.StatsWindow}}</h3>
+<table>
+<tr><th>Notification service</th><th>Sent</th><th>Failed</th><th>Error rate</th></tr>
+{{range .Stats}}<tr><td>{{.Notifier}}</td><td>{{.Sent}}</td><td>{{.Failed}}</td><td{{if .Failed}} class="failed"{{end}}>{{printf "%.1f" .ErrorRate}}%</td></tr>{{end}}
+</table>
+<h3>Recent deliveries</h3>
+<table>
+<tr><th>Time</th><th>Application</th><th>Trigger</th><th>Recipient</th><th>Status</th><th>Error</th></tr>
+{{range .Recent}}<tr><td>{{formatTime .Timestamp}}</td><td>{{if .AppNamespace}}{{.AppNamespace}}/{{end}}{{.App}}</td><td>{{.Trigger}}</td><td>{{.Recipient}}</td>
+<td{{if eq .Status "Failed"}} class="failed"{{end}}>{{.Status}}</td><td>{{.Error}}</td></tr>{{end}}
+</table>{{end}}
+
+<h2 id="preview">Template Preview</h2>
+<form method="post" action="preview#preview">
+<label>Template
+<select name="template">
+<option value="{{.PreviewTemplateID}}">custom title and body</option>
+{{$selected := .Request.Template}}{{range .Templates}}<option value="{{.Name}}"{{if eq .Name $selected}} selected{{end}}>{{.Name}}</option>{{end}}
+</select></label>
+<label>Title <input name="title" size="80" value="{{.Request.Title}}"></label>
+<label>Body <textarea name="body">{{.Request.Body}}</textarea></label>
+<label>Application <input name="app" value="{{.Request.App}}"></label>
+<label>Notification service
+<select name="service">
+<option value="">-</option>
+{{$service := .Request.Service}}{{range .Services}}<option value="{{.}}"{{if eq . $service}} selected{{end}}>{{.}}</option>{{end}}
+</select></label>
+<label>Recipient <input name="recipient" value="{{.Request.Recipient}}"></label>
+<p><button type="submit">Preview</button> <span class="muted">notifications are rendered but not sent</span></p>
+</form>
+{{with .Preview}}{{if .Error}}<p class="error">{{.Error}}</p>{{else}}
+<h3>Title</h3><pre>{{.Title}}</pre>
+<h3>Body</h3><pre>{{.Body}}</pre>
+{{if .Service}}<h3>Service message</h3><pre>{{.Service}}</pre>{{end}}{{end}}{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,324 @@
+package ui
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/recipients"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+const (
+	previewTrigger     = "__preview__"
+	previewTemplate    = "__custom__"
+	defaultRecentLimit = 50
+	defaultStatsWindow = 24 * time.Hour
+	defaultRecipient   = "preview"
+)
+
+// ConfigSource returns the active configuration and notification services; the configuration is nil until loaded
+type ConfigSource func() (*settings.Config, map[string]notifiers.Notifier)
+
+// AppSource returns the live application or project by name
+type AppSource func(name string) (*unstructured.Unstructured, error)
+
+// NamespacedAppSource returns the live application by namespace and name; the empty namespace is the controller
+// namespace
+type NamespacedAppSource func(namespace string, name string) (*unstructured.Unstructured, error)
+
+// Options holds the web UI data sources
+type Options struct {
+	Config ConfigSource
+	// GetApp loads applications rendered by the template preview
+	GetApp NamespacedAppSource
+	// GetProject loads the application projects which annotations subscribe recipients; optional
+	GetProject AppSource
+	// History is the notification history store; the deliveries are not shown if nil
+	History history.Store
+	// ArgoCDService is used by the template functions that access the repository metadata; optional
+	ArgoCDService argocd.Service
+	// StatsWindow is the period of the notification services error rates
+	StatsWindow time.Duration
+	// RecentLimit is the max number of shown recent deliveries
+	RecentLimit int
+	// Token is the bearer token required by every request; the requests are not authenticated if empty
+	Token string
+}
+
+// NotifierStats holds the number of sent and failed notifications of the notification service
+type NotifierStats struct {
+	Notifier string
+	Sent     int
+	Failed   int
+}
+
+// ErrorRate returns the percentage of failed delivery attempts
+func (s NotifierStats) ErrorRate() float64 {
+	if s.Sent+s.Failed == 0 {
+		return 0
+	}
+	return float64(s.Failed) * 100 / float64(s.Sent+s.Failed)
+}
+
+// previewRequest holds the template preview playground form values
+type previewRequest struct {
+	Template  string
+	Title     string
+	Body      string
+	App       string
+	Service   string
+	Recipient string
+}
+
+type previewResult struct {
+	Title   string
+	Body    string
+	Service string
+	Error   string
+}
+
+type pageData struct {
+	Config            *settings.Config
+	Triggers          []triggers.NotificationTrigger
+	Templates         []triggers.NotificationTemplate
+	Services          []string
+	HistoryEnabled    bool
+	HistoryError      string
+	Recent            []history.Event
+	Stats             []NotifierStats
+	StatsWindow       time.Duration
+	Request           previewRequest
+	Preview           *previewResult
+	PreviewTemplateID string
+}
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	},
+	"isEnabled": func(t triggers.NotificationTrigger) bool {
+		return t.Enabled == nil || *t.Enabled
+	},
+}).Parse(pageHTML))
+
+// NewHandler returns HTTP handler of the web UI that shows the configured triggers and templates, recent deliveries,
+// notification services error rates and the template preview playground. The playground renders templates but never
+// sends notifications. If the token is configured the requests without the matching bearer token are rejected.
+func NewHandler(opts Options) http.Handler {
+	if opts.StatsWindow == 0 {
+		opts.StatsWindow = defaultStatsWindow
+	}
+	if opts.RecentLimit == 0 {
+		opts.RecentLimit = defaultRecentLimit
+	}
+	s := &server{opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.serveIndex)
+	mux.HandleFunc("/preview", s.servePreview)
+	mux.HandleFunc("/api/v1/application", s.serveAppStatus)
+	if opts.Token == "" {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actual := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(opts.Token), []byte(actual)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+type server struct {
+	opts Options
+}
+
+func (s *server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	s.render(w, previewRequest{Recipient: defaultRecipient}, nil)
+}
+
+func (s *server) servePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := previewRequest{
+		Template:  r.PostForm.Get("template"),
+		Title:     r.PostForm.Get("title"),
+		Body:      r.PostForm.Get("body"),
+		App:       strings.TrimSpace(r.PostForm.Get("app")),
+		Service:   r.PostForm.Get("service"),
+		Recipient: strings.TrimSpace(r.PostForm.Get("recipient")),
+	}
+	if req.Recipient == "" {
+		req.Recipient = defaultRecipient
+	}
+	res := s.preview(req)
+	s.render(w, req, &res)
+}
+
+func (s *server) render(w http.ResponseWriter, req previewRequest, preview *previewResult) {
+	data := pageData{
+		Request:           req,
+		Preview:           preview,
+		StatsWindow:       s.opts.StatsWindow,
+		HistoryEnabled:    s.opts.History != nil,
+		PreviewTemplateID: previewTemplate,
+	}
+	cfg, services := s.opts.Config()
+	if cfg != nil {
+		data.Config = cfg
+		data.Triggers = append(data.Triggers, cfg.Triggers...)
+		sort.Slice(data.Triggers, func(i, j int) bool {
+			return data.Triggers[i].Name < data.Triggers[j].Name
+		})
+		data.Templates = append(data.Templates, cfg.Templates...)
+		sort.Slice(data.Templates, func(i, j int) bool {
+			return data.Templates[i].Name < data.Templates[j].Name
+		})
+	}
+	for name := range services {
+		data.Services = append(data.Services, name)
+	}
+	sort.Strings(data.Services)
+	if s.opts.History != nil {
+		events, err := s.opts.History.List(history.Filter{Since: time.Now().Add(-s.opts.StatsWindow)})
+		if err != nil {
+			data.HistoryError = err.Error()
+		} else {
+			data.Stats = notifierStats(events)
+			data.Recent = recentEvents(events, s.opts.RecentLimit)
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, data); err != nil {
+		log.Errorf("Failed to render web UI page: %v", err)
+	}
+}
+
+// notifierStats returns the number of sent and failed notifications per notification service
+func notifierStats(events []history.Event) []NotifierStats {
+	statsByNotifier := map[string]*NotifierStats{}
+	for _, e := range events {
+		if e.Status == history.StatusAcknowledged {
+			// acknowledgements are not associated with the notification service
+			continue
+		}
+		notifier := e.Notifier
+		if notifier == "" {
+			notifier, _, _ = recipients.Split(e.Recipient)
+		}
+		stats, ok := statsByNotifier[notifier]
+		if !ok {
+			stats = &NotifierStats{Notifier: notifier}
+			statsByNotifier[notifier] = stats
+		}
+		switch e.Status {
+		case history.StatusSent:
+			stats.Sent++
+		case history.StatusFailed:
+			stats.Failed++
+		}
+	}
+	res := make([]NotifierStats, 0, len(statsByNotifier))
+	for _, stats := range statsByNotifier {
+		res = append(res, *stats)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Notifier < res[j].Notifier
+	})
+	return res
+}
+
+// recentEvents returns up to limit most recent events starting from the newest one
+func recentEvents(events []history.Event, limit int) []history.Event {
+	res := make([]history.Event, 0, limit)
+	for i := len(events) - 1; i >= 0 && len(res) < limit; i-- {
+		res = append(res, events[i])
+	}
+	return res
+}
+
+func (s *server) preview(req previewRequest) previewResult {
+	res := previewResult{}
+	notification, err := s.renderNotification(req)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Title = notification.Title
+	res.Body = notification.Body
+	if req.Service == "" {
+		return res
+	}
+	_, services := s.opts.Config()
+	notifier, ok := services[req.Service]
+	if !ok {
+		res.Error = fmt.Sprintf("notification service %s is not configured", req.Service)
+		return res
+	}
+	previewer, ok := notifier.(notifiers.Previewer)
+	if !ok {
+		res.Service = fmt.Sprintf("%s notification service does not support previews", req.Service)
+		return res
+	}
+	if res.Service, err = previewer.Preview(*notification, req.Recipient); err != nil {
+		res.Error = fmt.Sprintf("failed to render %s notification: %v", req.Service, err)
+	}
+	return res
+}
+
+func (s *server) renderNotification(req previewRequest) (*notifiers.Notification, error) {
+	cfg, _ := s.opts.Config()
+	if cfg == nil {
+		return nil, fmt.Errorf("configuration is not loaded")
+	}
+	var tmpl *triggers.NotificationTemplate
+	if req.Template == "" || req.Template == previewTemplate {
+		tmpl = &triggers.NotificationTemplate{Name: previewTemplate, Notification: notifiers.Notification{Title: req.Title, Body: req.Body}}
+	} else {
+		for i := range cfg.Templates {
+			if cfg.Templates[i].Name == req.Template {
+				tmpl = &cfg.Templates[i]
+			}
+		}
+		if tmpl == nil {
+			return nil, fmt.Errorf("template %s is not configured", req.Template)
+		}
+	}
+	t, err := triggers.GetTriggers([]triggers.NotificationTemplate{*tmpl}, []triggers.NotificationTrigger{{
+		Name: previewTrigger, Template: tmpl.Name, Condition: "true",
+	}}, s.opts.ArgoCDService)
+	if err != nil {
+		return nil, err
+	}
+	app := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if req.App != "" {
+		if app, err = s.opts.GetApp("", req.App); err != nil {
+			return nil, fmt.Errorf("failed to load application %s: %v", req.App, err)
+		}
+	}
+	ctx := recipients.CopyStringMap(cfg.Context)
+	ctx["notificationType"] = req.Service
+	return t[previewTrigger].FormatNotification(app, ctx)
+}
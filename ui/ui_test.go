@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/notifiers"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+	"github.com/argoproj-labs/argocd-notifications/triggers"
+)
+
+func newTestHandler(store history.Store) http.Handler {
+	cfg := &settings.Config{
+		Triggers: []triggers.NotificationTrigger{{
+			Name: "on-sync-failed", Condition: "app.status.operationState.phase in ['Error']", Template: "app-sync-failed",
+		}},
+		Templates: []triggers.NotificationTemplate{{
+			Name: "app-sync-failed", Notification: notifiers.Notification{Title: "{{.app.metadata.name}} sync failed"},
+		}},
+		Context: map[string]string{"argocdUrl": "https://argocd.example.com"},
+	}
+	services := map[string]notifiers.Notifier{"slack": notifiers.NewSlackNotifier(notifiers.SlackOptions{Token: "secret"})}
+	return NewHandler(Options{
+		Config: func() (*settings.Config, map[string]notifiers.Notifier) {
+			return cfg, services
+		},
+		GetApp: func(_ string, name string) (*unstructured.Unstructured, error) {
+			if name != "guestbook" {
+				return nil, errors.New("not found")
+			}
+			return NewApp(name), nil
+		},
+		History: store,
+	})
+}
+
+func TestIndex(t *testing.T) {
+	store := history.NewMemoryStore(0)
+	now := time.Now()
+	assert.NoError(t, store.Record(history.Event{App: "guestbook", Trigger: "on-sync-failed", Recipient: "slack:alerts", Notifier: "slack", Timestamp: now, Status: history.StatusFailed, Error: "timeout"}))
+	assert.NoError(t, store.Record(history.Event{App: "guestbook", Trigger: "on-sync-failed", Recipient: "slack:alerts", Notifier: "slack", Timestamp: now.Add(-time.Minute), Status: history.StatusSent}))
+
+	w := httptest.NewRecorder()
+	newTestHandler(store).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	page := w.Body.String()
+	assert.Contains(t, page, "<pre>app.status.operationState.phase in [&#39;Error&#39;]</pre>")
+	assert.Contains(t, page, `<a href="#template-app-sync-failed">app-sync-failed</a>`)
+	assert.Contains(t, page, `<td>slack</td><td>1</td><td>1</td><td class="failed">50.0%</td>`)
+	assert.Contains(t, page, "timeout")
+	assert.True(t, strings.Index(page, "Failed</td>") < strings.Index(page, "Sent</td>"), "recent deliveries should start from the newest one")
+}
+
+func TestIndex_HistoryDisabled(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestHandler(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, w.Body.String(), "Notification history is disabled")
+
+	w = httptest.NewRecorder()
+	newTestHandler(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPreview(t *testing.T) {
+	form := url.Values{"template": {"app-sync-failed"}, "app": {"guestbook"}, "service": {"slack"}, "recipient": {"alerts"}}
+	r := httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	newTestHandler(nil).ServeHTTP(w, r)
+
+	page := w.Body.String()
+	assert.Contains(t, page, "<h3>Title</h3><pre>guestbook sync failed</pre>")
+	assert.Contains(t, page, "&#34;channel&#34;: &#34;alerts&#34;")
+	assert.NotContains(t, page, "secret")
+}
+
+func TestPreview_CustomTemplate(t *testing.T) {
+	form := url.Values{"template": {previewTemplate}, "body": {"{{.context.argocdUrl}}/applications/{{.app.metadata.name}}"}, "app": {"missing"}}
+	r := httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	newTestHandler(nil).ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), "failed to load application missing: not found")
+
+	form.Set("app", "guestbook")
+	r = httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	newTestHandler(nil).ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), "<h3>Body</h3><pre>https://argocd.example.com/applications/guestbook</pre>")
+}
+
+func TestNotifierStats(t *testing.T) {
+	stats := notifierStats([]history.Event{
+		{Recipient: "email:sre", Status: history.StatusSent},
+		{Notifier: "slack", Status: history.StatusFailed},
+		{Notifier: "slack", Status: history.StatusDryRun},
+		{User: "slack:U123", Status: history.StatusAcknowledged},
+	})
+	assert.Equal(t, []NotifierStats{{Notifier: "email", Sent: 1}, {Notifier: "slack", Failed: 1}}, stats)
+	assert.Equal(t, float64(100), stats[1].ErrorRate())
+	assert.Equal(t, float64(0), NotifierStats{}.ErrorRate())
+}
+
+func TestHandler_Token(t *testing.T) {
+	handler := NewHandler(Options{
+		Config: func() (*settings.Config, map[string]notifiers.Notifier) {
+			return &settings.Config{}, nil
+		},
+		Token: "my-token",
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer my-token")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/admission/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// NewHandler returns HTTP handler of the validating admission webhook that rejects invalid
+// argocd-notifications-cm config map and argocd-notifications-secret secret updates.
+func NewHandler() http.Handler {
+	return http.HandlerFunc(handle)
+}
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, fmt.Sprintf("method %s is not supported", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review := v1beta1.AdmissionReview{}
+	if err := json.Unmarshal(data, &review); err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse admission review: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "admission review request is empty", http.StatusBadRequest)
+		return
+	}
+	response := &v1beta1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	if err := validate(review.Request); err != nil {
+		log.Infof("Rejected %s %s/%s: %v", review.Request.Kind.Kind, review.Request.Namespace, review.Request.Name, err)
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: err.Error(),
+		}
+	}
+	review.Response = response
+	review.Request = nil
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(review)
+}
+
+func validate(req *v1beta1.AdmissionRequest) error {
+	if req.Operation == v1beta1.Delete || len(req.Object.Raw) == 0 {
+		return nil
+	}
+	switch req.Kind.Kind {
+	case "ConfigMap":
+		configMap := &v1.ConfigMap{}
+		if err := json.Unmarshal(req.Object.Raw, configMap); err != nil {
+			return err
+		}
+		if configMap.Name != settings.ConfigMapName {
+			return nil
+		}
+		return ValidateConfigMap(configMap)
+	case "Secret":
+		secret := &v1.Secret{}
+		if err := json.Unmarshal(req.Object.Raw, secret); err != nil {
+			return err
+		}
+		if secret.Name != settings.SecretName {
+			return nil
+		}
+		return ValidateSecret(secret)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/admission/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+func review(t *testing.T, kind string, obj interface{}) *v1beta1.AdmissionResponse {
+	raw, err := json.Marshal(obj)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	data, err := json.Marshal(v1beta1.AdmissionReview{Request: &v1beta1.AdmissionRequest{
+		UID:       "123",
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: kind},
+		Operation: v1beta1.Update,
+		Object:    runtime.RawExtension{Raw: raw},
+	}})
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	w := httptest.NewRecorder()
+	NewHandler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(data)))
+	if !assert.Equal(t, http.StatusOK, w.Code) {
+		return nil
+	}
+	res := v1beta1.AdmissionReview{}
+	if !assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res)) {
+		return nil
+	}
+	assert.Equal(t, "123", string(res.Response.UID))
+	return res.Response
+}
+
+func newConfigMap(data map[string]string) *v1.ConfigMap {
+	return &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName}, Data: data}
+}
+
+func TestValidateConfigMap_Valid(t *testing.T) {
+	res := review(t, "ConfigMap", newConfigMap(map[string]string{
+		"trigger.on-synced":   `{condition: "app.status.sync.status == 'Synced'", template: app-synced}`,
+		"template.app-synced": `{title: "{{.app.metadata.name}} synced", body: "{{(call .repo.GetCommitMetadata .app.status.sync.revision).Author}}"}`,
+	}))
+	assert.True(t, res.Allowed)
+}
+
+func TestValidateConfigMap_InvalidCondition(t *testing.T) {
+	res := review(t, "ConfigMap", newConfigMap(map[string]string{
+		"trigger.on-synced":   `{condition: "app.status.sync.status ==", template: app-synced}`,
+		"template.app-synced": `{title: hello}`,
+	}))
+	assert.False(t, res.Allowed)
+	assert.Contains(t, res.Result.Message, "on-synced")
+}
+
+func TestValidateConfigMap_TemplateRenderingFailed(t *testing.T) {
+	res := review(t, "ConfigMap", newConfigMap(map[string]string{
+		"trigger.on-synced":   `{condition: "true", template: app-synced}`,
+		"template.app-synced": `{title: "{{.app.metadata.name.foo}}"}`,
+	}))
+	assert.False(t, res.Allowed)
+	assert.Contains(t, res.Result.Message, "failed to render template app-synced")
+}
+
+func TestValidateConfigMap_IgnoresOtherConfigMaps(t *testing.T) {
+	res := review(t, "ConfigMap", &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Data:       map[string]string{"trigger.on-synced": "{condition: ==}"},
+	})
+	assert.True(t, res.Allowed)
+}
+
+func TestValidateSecret(t *testing.T) {
+	res := review(t, "Secret", &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.SecretName},
+		Data:       map[string][]byte{"notifiers.yaml": []byte("slack: [")},
+	})
+	assert.False(t, res.Allowed)
+
+	res = review(t, "Secret", &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.SecretName},
+		Data:       map[string][]byte{"notifiers.yaml": []byte("slack: {token: abc}")},
+	})
+	assert.True(t, res.Allowed)
+}
@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/triggers/expr/shared"
+)
+
+// sampleApp is used to verify that triggers conditions can be evaluated and templates can be rendered
+const sampleApp = `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+  namespace: argocd
+  labels:
+    app.kubernetes.io/name: guestbook
+spec:
+  project: default
+  source:
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+    path: guestbook
+    targetRevision: HEAD
+  destination:
+    server: https://kubernetes.default.svc
+    namespace: default
+status:
+  health:
+    status: Healthy
+  sync:
+    status: Synced
+    revision: 0ab3f5f9f2b6d2d05b0668437c21ea1b0d0a7f48
+  operationState:
+    phase: Succeeded
+    message: successfully synced
+    startedAt: "2020-01-01T00:00:00Z"
+    finishedAt: "2020-01-01T00:01:00Z"
+    syncResult:
+      revision: 0ab3f5f9f2b6d2d05b0668437c21ea1b0d0a7f48
+  conditions:
+  - type: SyncError
+    message: sample error
+  observedAt: "2020-01-01T00:01:00Z"
+`
+
+// sampleArgocdService returns the same commit metadata for any revision so templates can be rendered without Argo CD
+type sampleArgocdService struct{}
+
+func (svc *sampleArgocdService) GetCommitMetadata(_ context.Context, _ string, _ string) (*shared.CommitMetadata, error) {
+	return &shared.CommitMetadata{Message: "sample commit", Author: "sample author", Date: time.Unix(0, 0), Tags: []string{"v1.0.0"}}, nil
+}
+
+func newSampleApp() (*unstructured.Unstructured, error) {
+	app := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(sampleApp), &app.Object); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// ValidateConfigMap parses triggers and templates from the provided config map, compiles triggers conditions and
+// renders templates against the sample application.
+func ValidateConfigMap(configMap *v1.ConfigMap) error {
+	triggers, _, cfg, err := settings.ParseConfig(configMap, &v1.Secret{}, settings.Config{}, &sampleArgocdService{}, nil, nil)
+	if err != nil {
+		return err
+	}
+	app, err := newSampleApp()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := triggers[name].FormatNotification(app, cfg.Context); err != nil {
+			return fmt.Errorf("failed to render template %s of trigger %s: %v", triggers[name].GetTemplateName(), name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateSecret parses notifiers configuration from the provided secret
+func ValidateSecret(secret *v1.Secret) error {
+	if _, err := settings.ParseSecret(secret); err != nil {
+		return fmt.Errorf("failed to parse notifiers configuration: %v", err)
+	}
+	return nil
+}